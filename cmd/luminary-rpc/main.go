@@ -23,18 +23,25 @@ import (
 	"Luminary/pkg/provider/registry"
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"io"
-	"net/rpc/jsonrpc"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 )
 
 var (
 	Version = "dev"
 )
 
+// shutdownGracePeriod is how long running calls may take to finish after a shutdown signal
+const shutdownGracePeriod = 10 * time.Second
+
 // stdInOutReadWriteCloser wraps stdin/stdout for JSON-RPC
 type stdInOutReadWriteCloser struct {
 	reader io.Reader
@@ -68,8 +75,79 @@ func (s *stdInOutReadWriteCloser) Close() error {
 }
 
 func main() {
+	listen := flag.String("listen", "", "serve clients on a socket (tcp://host:port or unix:///path) instead of stdin/stdout")
+	maxConns := flag.Int("max-connections", rpc.DefaultLimits.MaxConnections, "maximum concurrent socket connections (0 = unlimited)")
+	rate := flag.Float64("rate", rpc.DefaultLimits.RequestsPerSecond, "requests per second allowed per socket client (0 = unlimited)")
+	burst := flag.Int("burst", rpc.DefaultLimits.Burst, "requests a socket client may send at once before being throttled")
+	requestTimeout := flag.Duration("request-timeout", 0, "time budget of one HTTP request (default from ~/.luminary/config.json, else 30s; 0 = unlimited)")
+	pageTimeout := flag.Duration("page-timeout", 0, "time budget of one page including retries and fallbacks (default 2m; 0 = unlimited)")
+	chapterTimeout := flag.Duration("chapter-timeout", 0, "time budget of one chapter download (default 10m; 0 = unlimited)")
+	searchTimeout := flag.String("search-timeout", "", "time budget of a search as base[,per-page[,per-provider[,max]]] (default 30s,30s,10s,5m; 0 = unlimited)")
+	listTimeout := flag.String("list-timeout", "", "time budget of browsing a tag as base[,per-page[,per-provider[,max]]] (default 30s,30s,10s,3m; 0 = unlimited)")
+	cacheEntries := flag.Int("cache-entries", 0, "entries the in-memory cache may hold (default from ~/.luminary/config.json, else 2000; negative = unlimited)")
+	cacheBytes := flag.Int64("cache-bytes", 0, "approximate bytes the in-memory cache may hold (default 64 MiB; negative = unlimited)")
+	webhookListen := flag.String("webhook-listen", "", "serve an HTTP endpoint queueing downloads on this address (host:port), e.g. for bookmarklets")
+	webhookToken := flag.String("webhook-token", os.Getenv("LUMINARY_WEBHOOK_TOKEN"), "token webhook requests must carry (default $LUMINARY_WEBHOOK_TOKEN)")
+	webhookOutput := flag.String("webhook-output", ".", "directory webhook downloads are saved to")
+	feedListen := flag.String("feed-listen", "", "serve RSS/Atom feeds of new chapters of followed series on this address (host:port)")
+	feedToken := flag.String("feed-token", os.Getenv("LUMINARY_FEED_TOKEN"), "token feed requests must carry as ?token= (default $LUMINARY_FEED_TOKEN)")
+	feedInterval := flag.Duration("feed-interval", time.Hour, "how often followed series are checked for new chapters for the feeds")
+	wsListen := flag.String("ws-listen", "", "serve the JSON-RPC protocol over WebSocket on this address (host:port), for browser clients")
+	wsToken := flag.String("ws-token", os.Getenv("LUMINARY_WS_TOKEN"), "token WebSocket clients must carry as ?token= (default $LUMINARY_WS_TOKEN)")
+	wsOrigins := flag.String("ws-origins", os.Getenv("LUMINARY_WS_ORIGINS"), "comma-separated origins of web pages allowed to connect over WebSocket, or * for any (default $LUMINARY_WS_ORIGINS)")
+	syncInterval := flag.Duration("sync-interval", 0, "sync the followed series every interval, downloading their new chapters as 'luminary sync' does (0 = never)")
+	warmAt := flag.String("warm-at", "", "warm the cache for followed series every day at this local time (HH:MM), e.g. during off-peak hours")
+	flag.Parse()
+
+	var warmTime time.Time
+	if *warmAt != "" {
+		var err error
+		if warmTime, err = time.Parse("15:04", *warmAt); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Invalid --warm-at %q, expected a time like 04:30\n", *warmAt)
+			os.Exit(2)
+		}
+	}
+
+	policies := make(map[string]engine.TimeoutPolicy)
+	for name, text := range map[string]string{"search-timeout": *searchTimeout, "list-timeout": *listTimeout} {
+		if text == "" {
+			continue
+		}
+		policy, err := engine.ParseTimeoutPolicy(text)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Invalid --%s: %v\n", name, err)
+			os.Exit(2)
+		}
+		policies[name] = policy
+	}
+
 	// Initialize the Luminary engine
 	appEngine := engine.New()
+	appEngine.SetVersion(Version)
+
+	// Time budgets and cache budgets given as flags override the config file, also when
+	// it is reloaded
+	appEngine.SetConfigOverrides(func(config *engine.Config) {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "request-timeout":
+				config.Timeouts.Request = engine.Duration(*requestTimeout)
+			case "page-timeout":
+				config.Timeouts.Page = engine.Duration(*pageTimeout)
+			case "chapter-timeout":
+				config.Timeouts.Chapter = engine.Duration(*chapterTimeout)
+			case "search-timeout":
+				config.Timeouts.Search = policies[f.Name]
+			case "list-timeout":
+				config.Timeouts.List = policies[f.Name]
+			case "cache-entries":
+				config.Cache.MaxEntries = *cacheEntries
+			case "cache-bytes":
+				config.Cache.MaxBytes = *cacheBytes
+			}
+		})
+	})
+
 	defer func(appEngine *engine.Engine) {
 		err := appEngine.Shutdown()
 		if err != nil {
@@ -90,22 +168,150 @@ func main() {
 		// Continue anyway
 	}
 
+	if *warmAt != "" {
+		go warmDaily(ctx, appEngine, warmTime)
+	}
+	if *syncInterval > 0 {
+		go syncEvery(ctx, appEngine, *syncInterval)
+	}
+
+	// Create the RPC server with services
+	rpcServer, err := rpc.NewServer(appEngine, Version)
+	if err != nil {
+		appEngine.Logger.Error("Failed to create RPC server: %v", err)
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to create RPC server: %v\n", err)
+		os.Exit(1)
+	}
+	rpcServer.SetLimits(rpc.Limits{MaxConnections: *maxConns, RequestsPerSecond: *rate, Burst: *burst})
+
+	// Open the socket before reporting readiness so clients can connect right away
+	var listener net.Listener
+	if *listen != "" {
+		listener, err = openListener(*listen)
+		if err != nil {
+			appEngine.Logger.Error("Failed to listen on %s: %v", *listen, err)
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to listen on %s: %v\n", *listen, err)
+			os.Exit(1)
+		}
+	}
+
+	var webhookServer *http.Server
+	if *webhookListen != "" {
+		webhook, err := rpc.NewWebhook(appEngine, *webhookToken, *webhookOutput)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to start the webhook: %s\n", appEngine.FormatError(err))
+			os.Exit(2)
+		}
+		go webhook.Run(ctx)
+
+		webhookServer = &http.Server{Addr: *webhookListen, Handler: webhook, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := webhookServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				appEngine.Logger.Error("Webhook on %s failed: %v", *webhookListen, err)
+				_, _ = fmt.Fprintf(os.Stderr, "Webhook on %s failed: %v\n", *webhookListen, err)
+			}
+		}()
+		appEngine.Logger.Info("Webhook listening on %s", *webhookListen)
+	}
+
+	var feedServer *http.Server
+	if *feedListen != "" {
+		if *feedInterval <= 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "The feed interval must be positive\n")
+			os.Exit(2)
+		}
+		feeds, err := rpc.NewFeeds(appEngine, *feedToken)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to start the feeds: %s\n", appEngine.FormatError(err))
+			os.Exit(2)
+		}
+		go feeds.Run(ctx, *feedInterval)
+
+		feedServer = &http.Server{Addr: *feedListen, Handler: feeds, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := feedServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				appEngine.Logger.Error("Feeds on %s failed: %v", *feedListen, err)
+				_, _ = fmt.Fprintf(os.Stderr, "Feeds on %s failed: %v\n", *feedListen, err)
+			}
+		}()
+		appEngine.Logger.Info("Feeds listening on %s", *feedListen)
+	}
+
+	var wsServer *http.Server
+	if *wsListen != "" {
+		wsServer = &http.Server{Addr: *wsListen, Handler: rpc.NewWebSocket(rpcServer, *wsToken, strings.Split(*wsOrigins, ",")), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := wsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				appEngine.Logger.Error("WebSocket on %s failed: %v", *wsListen, err)
+				_, _ = fmt.Fprintf(os.Stderr, "WebSocket on %s failed: %v\n", *wsListen, err)
+			}
+		}()
+		appEngine.Logger.Info("WebSocket listening on %s", *wsListen)
+	}
+
+	// SIGHUP reloads the config file and the provider definitions
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			result, err := registry.Reload(ctx, appEngine)
+			if err != nil {
+				appEngine.Logger.Error("Reload failed, keeping the current providers and settings: %v", err)
+				_, _ = fmt.Fprintf(os.Stderr, "Reload failed: %v\n", err)
+				continue
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "Reloaded %d providers (added %v, removed %v)\n",
+				len(result.Providers), result.Added, result.Removed)
+		}
+	}()
+
 	// Set up signal handling
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-sigChan
+		sig := <-sigChan
 		appEngine.Logger.Info("RPC server shutting down...")
-		err := appEngine.Shutdown()
-		if err != nil {
-			return
+
+		// Tell clients we are going away and give running calls time to finish
+		drainCtx, cancel := context.WithTimeout(ctx, shutdownGracePeriod)
+		if err := rpcServer.Shutdown(drainCtx, "received "+sig.String()); err != nil {
+			appEngine.Logger.Warn("Cancelled RPC calls still running after %v", shutdownGracePeriod)
+		}
+		cancel()
+
+		if listener != nil {
+			_ = listener.Close()
+		}
+		if webhookServer != nil {
+			_ = webhookServer.Close()
+		}
+		if wsServer != nil {
+			_ = wsServer.Close()
 		}
+		if feedServer != nil {
+			_ = feedServer.Close()
+		}
+
+		// The deferred Shutdown in main does nothing after this one
+		_ = appEngine.Shutdown()
 		os.Exit(0)
 	}()
 
-	// Create the RPC server with services
-	rpcServer := rpc.NewServer(appEngine, Version)
+	// Log startup
+	appEngine.Logger.Info("Luminary RPC server v%s started", Version)
+	appEngine.Logger.Info("Loaded %d providers", appEngine.ProviderCount())
+
+	if listener != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Luminary RPC v%s listening on %s with %d providers\n",
+			Version, *listen, appEngine.ProviderCount())
+
+		// Serve socket clients until shutdown closes the listener
+		if err := rpcServer.Serve(ctx, listener); err != nil {
+			appEngine.Logger.Error("RPC listener failed: %v", err)
+		}
+		return
+	}
 
 	// Set up JSON-RPC over stdin/stdout
 	rwc := &stdInOutReadWriteCloser{
@@ -113,20 +319,83 @@ func main() {
 		writer: os.Stdout,
 	}
 
-	// Log startup
-	appEngine.Logger.Info("Luminary RPC server v%s started", Version)
-	appEngine.Logger.Info("Loaded %d providers", appEngine.ProviderCount())
-
 	// Log initial status to stderr (won't interfere with JSON-RPC)
-	_, err := fmt.Fprintf(os.Stderr, "Luminary RPC v%s ready with %d providers\n", Version, appEngine.ProviderCount())
+	_, err = fmt.Fprintf(os.Stderr, "Luminary RPC v%s ready with %d providers\n", Version, appEngine.ProviderCount())
 	if err != nil {
 		return
 	}
 
 	// Start serving JSON-RPC (this blocks)
-	codec := jsonrpc.NewServerCodec(rwc)
-	rpcServer.ServeCodec(codec)
+	if err := rpcServer.ServeConn(ctx, rwc); err != nil {
+		appEngine.Logger.Error("RPC connection failed: %v", err)
+	}
 
 	// If we get here, the connection was closed
 	appEngine.Logger.Info("RPC connection closed")
 }
+
+// warmDaily warms the cache for followed series every day at the time of day of at
+func warmDaily(ctx context.Context, eng *engine.Engine, at time.Time) {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		eng.Logger.Info("Next cache warming at %s", next.Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		warmed, err := eng.WarmCache(ctx, nil)
+		if err != nil {
+			eng.Logger.Warn("Cache warming failed: %v", err)
+			continue
+		}
+		eng.Logger.Info("Warmed the cache for %d series", warmed)
+	}
+}
+
+// syncEvery syncs the followed series every interval, so subscribers hear of new chapters
+// and downloads as they happen
+func syncEvery(ctx context.Context, eng *engine.Engine, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		summary, err := eng.Sync(ctx, engine.SyncOptions{})
+		if err != nil {
+			eng.Logger.Warn("Sync failed: %v", err)
+			continue
+		}
+		eng.Logger.Info("Sync found %d missing chapters and downloaded %d", summary.Missing, summary.Downloaded)
+	}
+}
+
+// openListener listens on an address like tcp://127.0.0.1:7777 or unix:///tmp/luminary.sock;
+// addresses without a scheme are TCP
+func openListener(address string) (net.Listener, error) {
+	network, addr, found := strings.Cut(address, "://")
+	if !found {
+		network, addr = "tcp", address
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "unix":
+		// Remove a socket left behind by a previous run
+		if info, err := os.Stat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(addr)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network %q, expected tcp or unix", network)
+	}
+
+	return net.Listen(network, addr)
+}
@@ -36,6 +36,7 @@ var (
 func main() {
 	// Create engine
 	eng := engine.New()
+	eng.SetVersion(Version)
 	defer func(eng *engine.Engine) {
 		err := eng.Shutdown()
 		if err != nil {
@@ -73,8 +74,16 @@ func main() {
 	// Create CLI app
 	app := cli.NewApp(eng, Version)
 
-	// Run CLI
+	// Run CLI; panics are reported before they crash the program
+	defer eng.RecoverPanic()
 	if err := app.Run(context.Background(), os.Args); err != nil {
+		eng.ReportError(err)
+		_ = eng.Shutdown()
+
+		// With --errors json, standard error only holds the JSON written by the app
+		if eng.JSONErrors() {
+			os.Exit(1)
+		}
 		_, err := fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		if err != nil {
 			return
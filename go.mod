@@ -6,6 +6,7 @@ require (
 	github.com/PuerkitoBio/goquery v1.10.3
 	github.com/fatih/color v1.18.0
 	github.com/urfave/cli/v3 v3.3.8
+	golang.org/x/text v0.24.0
 )
 
 require (
@@ -0,0 +1,88 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewCacheWarmCommand creates the cache warm command
+func NewCacheWarmCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if eng.Snapshots == nil {
+			return errors.New("no home directory to keep snapshots in").AsFileSystem().Error()
+		}
+
+		_, _ = headerStyle.Printf("Warming the cache for followed series\n")
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+		start := time.Now()
+		failed := 0
+		warmed, err := eng.WarmCache(ctx, func(r engine.WarmResult) {
+			if r.Error != "" {
+				failed++
+				_, _ = errorStyle.Printf("✗ %s:%s ", r.Provider, r.MangaID)
+				_, _ = secondaryStyle.Printf("%s\n", r.Error)
+				return
+			}
+			_, _ = successStyle.Printf("✓ ")
+			_, _ = titleStyle.Printf("%s ", r.Title)
+			_, _ = secondaryStyle.Printf("(%s:%s, %d chapters, %s)\n", r.Provider, r.MangaID, r.Chapters, r.Elapsed.Round(time.Millisecond))
+		})
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		if warmed == 0 && failed == 0 {
+			_, _ = warningStyle.Println("No series to warm; the library has no downloads with a known manga yet")
+			return nil
+		}
+
+		_, _ = infoStyle.Printf("Warmed %d series in %s", warmed, formatDuration(time.Since(start)))
+		if failed > 0 {
+			_, _ = errorStyle.Printf(", %d failed", failed)
+		}
+		fmt.Println()
+		_, _ = secondaryStyle.Printf("'info' answers from these for up to %s; pass --fresh to skip them\n", eng.SnapshotAge())
+		return nil
+	}
+}
+
+// NewCacheClearCommand creates the cache clear command
+func NewCacheClearCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if eng.Snapshots == nil {
+			return nil
+		}
+
+		eng.Log(ctx).Debug("Removing cache snapshots in %s", eng.Snapshots.Dir())
+		if err := eng.Snapshots.Clear(); err != nil {
+			return errors.Track(err).WithContext("path", eng.Snapshots.Dir()).AsFileSystem().Error()
+		}
+
+		_, _ = successStyle.Printf("Removed the cache snapshots in %s\n", eng.Snapshots.Dir())
+		return nil
+	}
+}
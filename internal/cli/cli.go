@@ -17,12 +17,17 @@
 package cli
 
 import (
+	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
 	"context"
 	"fmt"
 	"github.com/urfave/cli/v3"
 	"net/mail"
 	"os"
+	"time"
 )
 
 // NewApp creates a new CLI application
@@ -41,12 +46,69 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 				Aliases: []string{"d"},
 				Usage:   "Enable debug output",
 			},
+			&cli.BoolFlag{
+				Name:  "offline",
+				Usage: "Forbid network access; info, chapters and list answer from saved manga details",
+			},
+			&cli.StringSliceFlag{
+				Name:    "trace",
+				Usage:   "Log every request and selector of these providers to the log file, whatever the log level (comma-separated IDs)",
+				Sources: cli.EnvVars("LUMINARY_TRACE"),
+			},
+			&cli.StringFlag{
+				Name:  "errors",
+				Usage: "How errors are printed: text, or json for one JSON object per error on standard error",
+				Value: "text",
+			},
+			&cli.DurationFlag{
+				Name:  "request-timeout",
+				Usage: "Time budget of one HTTP request (default from ~/.luminary/config.json, else 30s; 0 = unlimited)",
+			},
+			&cli.DurationFlag{
+				Name:  "page-timeout",
+				Usage: "Time budget of one page including retries and fallbacks (default 2m; 0 = unlimited)",
+			},
+			&cli.DurationFlag{
+				Name:  "chapter-timeout",
+				Usage: "Time budget of one chapter download (default 10m; 0 = unlimited)",
+			},
+			&cli.StringFlag{
+				Name:  "search-timeout",
+				Usage: "Time budget of a search as base[,per-page[,per-provider[,max]]] (default 30s,30s,10s,5m; 0 = unlimited)",
+			},
+			&cli.StringFlag{
+				Name:  "list-timeout",
+				Usage: "Time budget of browsing a tag as base[,per-page[,per-provider[,max]]] (default 30s,30s,10s,3m; 0 = unlimited)",
+			},
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 			// Set error formatting mode
 			if cmd.Bool("debug") {
 				engine.SetDebugMode(true)
 			}
+			switch cmd.String("errors") {
+			case "text":
+			case "json":
+				engine.SetJSONErrors(true)
+			default:
+				return ctx, errors.Newf("unknown error format %q", cmd.String("errors")).
+					WithMessagef("Unknown error format %q, expected text or json", cmd.String("errors")).Error()
+			}
+			if err := applyTimeoutFlags(engine, cmd); err != nil {
+				return ctx, err
+			}
+			if cmd.Bool("offline") {
+				engine.SetOffline(true)
+			}
+			for _, id := range cmd.StringSlice("trace") {
+				if err := engine.SetTracing(id, true); err != nil {
+					return ctx, err
+				}
+			}
+
+			// Tag log lines of this invocation so they can be told apart from concurrent runs
+			ctx = core.WithCorrelationID(ctx, core.NewCorrelationID())
+			engine.Log(ctx).Debug("Running: %v", os.Args[1:])
 
 			return ctx, nil
 		},
@@ -62,6 +124,10 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 						Aliases: []string{"p"},
 						Usage:   "Provider ID (leave empty to search all)",
 					},
+					&cli.StringSliceFlag{
+						Name:  "providers",
+						Usage: "Only search these providers (comma-separated IDs, e.g. mgd,kmg)",
+					},
 					&cli.IntFlag{
 						Name:    "limit",
 						Aliases: []string{"l"},
@@ -72,16 +138,22 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 						Name:  "fields",
 						Usage: "Search in specific fields (comma-separated)",
 					},
-					&cli.StringFlag{
+					&cli.BoolFlag{
+						Name:  "alt-titles",
+						Usage: "Match alternative titles too, showing which title matched",
+					},
+					&cli.StringSliceFlag{
 						Name:  "filter",
-						Usage: "Filter results (format: field=value)",
+						Usage: "Filter results by demographic, content_rating or status (format: field=value[,value])",
 					},
 					&cli.StringFlag{
 						Name:  "sort",
 						Usage: "Sort results by field",
 					},
+					timeoutFlag(),
+					recordFlag(),
 				},
-				Action: NewSearchCommand(engine),
+				Action: withTimeout(withRecording(NewSearchCommand(engine))),
 			},
 			{
 				Name:      "info",
@@ -93,14 +165,136 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 						Name:  "lang",
 						Usage: "Filter chapters by language (comma-separated)",
 					},
+					&cli.BoolFlag{
+						Name:  "related",
+						Usage: "Show related manga (sequels, spin-offs, recommendations)",
+					},
+					&cli.BoolFlag{
+						Name:  "pages",
+						Usage: "Show page counts for listed chapters (may require extra requests)",
+					},
+					&cli.BoolFlag{
+						Name:  "fresh",
+						Usage: "Fetch from the site even if 'cache warm' saved the details",
+					},
+					timeoutFlag(),
+					recordFlag(),
 				},
-				Action: NewInfoCommand(engine),
+				Action: withTimeout(withRecording(NewInfoCommand(engine))),
 			},
 			{
-				Name:      "download",
-				Aliases:   []string{"d"},
-				Usage:     "Download manga chapters",
-				ArgsUsage: "<provider:chapter-id> [provider:chapter-id...]",
+				Name:      "chapters",
+				Aliases:   []string{"c"},
+				Usage:     "List a manga's chapters (one per line, for piping into download --stdin)",
+				ArgsUsage: "<provider:manga-id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "language",
+						Aliases: []string{"lang"},
+						Usage:   "Only list chapters in these languages (comma-separated)",
+					},
+					&cli.FloatFlag{
+						Name:  "from",
+						Usage: "Lowest chapter number to list",
+					},
+					&cli.FloatFlag{
+						Name:  "to",
+						Usage: "Highest chapter number to list",
+					},
+					&cli.StringFlag{
+						Name:  "group",
+						Usage: "Only list chapters released by this scanlation group",
+					},
+					&cli.IntFlag{
+						Name:  "latest",
+						Usage: "Only list the newest chapters, this many (copies in other languages or by other groups count once)",
+					},
+					&cli.BoolFlag{
+						Name:  "unique",
+						Usage: "List one copy of chapters released by several groups or in several languages",
+					},
+					&cli.StringFlag{
+						Name:  "prefer-group",
+						Usage: "Scanlation groups to pick duplicates from, best first (comma-separated; implies --unique)",
+					},
+					&cli.StringFlag{
+						Name:  "prefer-language",
+						Usage: "Languages to pick duplicates in, best first (comma-separated; implies --unique)",
+					},
+					numberingFlag(),
+					numberingMapFlag(),
+					timeoutFlag(),
+				},
+				Action: withTimeout(NewChaptersCommand(engine)),
+			},
+			{
+				Name:      "compare",
+				Usage:     "Line up the chapters of a series on two providers and show those missing from either",
+				ArgsUsage: "<provider:manga-id> <provider:manga-id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "language",
+						Aliases: []string{"lang"},
+						Usage:   "Only compare chapters in these languages (comma-separated; default: preferred languages)",
+					},
+					&cli.BoolFlag{
+						Name:  "all-languages",
+						Usage: "Compare chapters in every language",
+					},
+					&cli.FloatFlag{
+						Name:  "from",
+						Usage: "Lowest chapter number to compare",
+					},
+					&cli.FloatFlag{
+						Name:  "to",
+						Usage: "Highest chapter number to compare",
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Also list the chapters both providers have",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the comparison as JSON",
+					},
+					timeoutFlag(),
+				},
+				Action: withTimeout(NewCompareCommand(engine)),
+			},
+			{
+				Name:      "tags",
+				Usage:     "List a provider's genres, or browse the manga of one",
+				ArgsUsage: "<provider> [tag-id]",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"l"},
+						Usage:   "Maximum number of manga to list when browsing a tag",
+						Value:   20,
+					},
+					timeoutFlag(),
+				},
+				Action: withTimeout(NewTagsCommand(engine)),
+			},
+			{
+				Name:      "open",
+				Usage:     "Open a manga or chapter on its provider's site in the browser",
+				ArgsUsage: "<provider:manga-id|provider:chapter-id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "chapter",
+						Usage: "The ID is a chapter ID",
+					},
+					&cli.BoolFlag{
+						Name:  "print",
+						Usage: "Print the URL instead of opening it",
+					},
+				},
+				Action: NewOpenCommand(engine),
+			},
+			{
+				Name:  "watch-clipboard",
+				Usage: "Download manga and chapters whose URLs are copied to the clipboard",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:    "output",
@@ -108,27 +302,494 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 						Usage:   "Output directory",
 						Value:   ".",
 					},
+					&cli.BoolFlag{
+						Name:  "auto",
+						Usage: "Download without asking first",
+					},
+					&cli.BoolFlag{
+						Name:  "all-languages",
+						Usage: "Download copied manga in every language, not only the preferred ones",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often the clipboard is read",
+						Value: time.Second,
+					},
+				},
+				Action: NewWatchClipboardCommand(engine),
+			},
+			{
+				Name:      "download",
+				Aliases:   []string{"d"},
+				Usage:     "Download manga chapters",
+				ArgsUsage: "<provider:chapter-id|chapter-url> [...]",
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
-						Name:  "format",
-						Usage: "Image format (jpeg, png, webp)",
+						Name:  "from-file",
+						Usage: "Read chapter IDs or URLs from a file, one per line ('-' for standard input)",
+					},
+					&cli.BoolFlag{
+						Name:  "stdin",
+						Usage: "Read chapter IDs from standard input (same as --from-file -)",
+					},
+				}, downloadFlags()...),
+				Action: withTimeout(withFootprint(withRecording(NewDownloadCommand(engine)))),
+			},
+			{
+				Name:  "sync",
+				Usage: "Refresh followed series and download their new chapters, for cron jobs and timers",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print only a summary as JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List the missing chapters without downloading them",
+					},
+					&cli.BoolFlag{
+						Name:  "fill-gaps",
+						Usage: "Also download missing chapters older than the newest one downloaded",
 					},
 					&cli.IntFlag{
-						Name:  "concurrent",
-						Usage: "Number of concurrent downloads",
+						Name:  "latest",
+						Usage: "Only download missing chapters among the newest of each series, this many",
+					},
+					&cli.StringSliceFlag{
+						Name:  "providers",
+						Usage: "Only sync the series of these providers (comma-separated IDs, e.g. mgd,kmg)",
+					},
+					&cli.StringFlag{
+						Name:  "prefer-group",
+						Usage: "Scanlation groups to pick duplicates from, best first (comma-separated)",
+					},
+					&cli.BoolFlag{
+						Name:  "all-languages",
+						Usage: "Download new chapters in every language, not only the preferred ones",
+					},
+					&cli.BoolFlag{
+						Name:  "failover",
+						Usage: "Download chapters that fail from a series linked with 'luminary link add' instead",
+					},
+					timeoutFlag(),
+					maxRequestsFlag(),
+				},
+				Action: withTimeout(withFootprint(NewSyncCommand(engine))),
+			},
+			{
+				Name:  "queue",
+				Usage: "Collect chapters to download later, or on another machine with a job file",
+				Commands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Queue chapters",
+						ArgsUsage: "<provider:chapter-id|chapter-url> [...]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "from-file",
+								Usage: "Read chapter IDs or URLs from a file, one per line ('-' for standard input)",
+							},
+							&cli.BoolFlag{
+								Name:  "stdin",
+								Usage: "Read chapter IDs from standard input (same as --from-file -)",
+							},
+						},
+						Action: NewQueueAddCommand(engine),
+					},
+					{
+						Name:   "list",
+						Usage:  "List the queued chapters",
+						Action: NewQueueListCommand(engine),
+					},
+					{
+						Name:      "remove",
+						Usage:     "Take chapters off the queue",
+						ArgsUsage: "<provider:chapter-id|chapter-url> [...]",
+						Action:    NewQueueRemoveCommand(engine),
+					},
+					{
+						Name:   "clear",
+						Usage:  "Empty the queue",
+						Action: NewQueueClearCommand(engine),
+					},
+					{
+						Name:      "export",
+						Usage:     "Write the queue as a signed job file to download on another machine",
+						ArgsUsage: "<job.lum|->",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Usage: "Image format the job downloads (jpeg, png, webp)",
+							},
+							&cli.StringFlag{
+								Name:  "process",
+								Usage: "Post-processing the job applies, e.g. convert=jpeg,cbz",
+							},
+							&cli.StringFlag{
+								Name:  "layout",
+								Usage: "Layout the job saves chapters in: flat or language",
+							},
+							&cli.StringFlag{
+								Name:  "collision",
+								Usage: "Naming the job gives chapters sharing a number: group, language or none",
+							},
+							&cli.StringFlag{
+								Name:  "numbering",
+								Usage: "Chapter numbers the job saves chapters with: source, absolute or volume",
+							},
+							&cli.BoolFlag{
+								Name:  "clear",
+								Usage: "Empty the queue once the job file is written",
+							},
+						},
+						Action: NewQueueExportCommand(engine),
+					},
+					{
+						Name:      "import",
+						Usage:     "Check a job file's signature and download its chapters",
+						ArgsUsage: "<job.lum|->",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:  "queue",
+								Usage: "Add the chapters to the queue instead of downloading them",
+							},
+						}, downloadFlags()...),
+						Action: withTimeout(withFootprint(withRecording(NewQueueImportCommand(engine)))),
+					},
+					{
+						Name:   "run",
+						Usage:  "Download the queued chapters; those that fail stay queued",
+						Flags:  downloadFlags(),
+						Action: withTimeout(withFootprint(withRecording(NewQueueRunCommand(engine)))),
+					},
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List the series in the download history, with the titles of those saved by 'cache warm'",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "provider",
+						Aliases: []string{"p"},
+						Usage:   "Only list series from this provider",
+					},
+				},
+				Action: NewListCommand(engine),
+			},
+			{
+				Name:  "history",
+				Usage: "Show past downloads",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "provider",
+						Aliases: []string{"p"},
+						Usage:   "Only show downloads from this provider",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only show downloads newer than this (e.g. 7d, 12h, 2025-01-31)",
+					},
+					&cli.BoolFlag{
+						Name:  "failed",
+						Usage: "Only show failed downloads that haven't succeeded since",
+					},
+					&cli.BoolFlag{
+						Name:  "retry",
+						Usage: "Retry the listed failed downloads (implies --failed)",
+					},
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"l"},
+						Usage:   "Maximum number of entries to show (0 for all)",
+						Value:   50,
+					},
+				},
+				Action: NewHistoryCommand(engine),
+			},
+			{
+				Name:  "stats",
+				Usage: "Show library statistics",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print statistics as JSON",
+					},
+					&cli.IntFlag{
+						Name:  "top",
+						Usage: "Number of most downloaded series to list",
 						Value: 5,
 					},
 				},
-				Action: NewDownloadCommand(engine),
+				Action: NewStatsCommand(engine),
+			},
+			{
+				Name:  "logs",
+				Usage: "Show the latest records of the log file",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "count",
+						Aliases: []string{"n"},
+						Usage:   "Number of records to show (0 for all)",
+						Value:   50,
+					},
+					&cli.StringFlag{
+						Name:  "level",
+						Usage: "Only show records at this level or above: debug, info, warn or error",
+					},
+					&cli.StringFlag{
+						Name:  "module",
+						Usage: "Only show records of a package (e.g. network) or source file (e.g. mangadex)",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print records as JSON",
+					},
+				},
+				Action: NewLogsCommand(engine),
+			},
+			{
+				Name:      "verify",
+				Usage:     "Check downloaded chapters for missing or corrupt pages",
+				ArgsUsage: "<path|library>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "repair",
+						Usage: "Download missing or corrupt pages again into the existing chapter directories and archives",
+					},
+				},
+				Action: NewVerifyCommand(engine),
+			},
+			{
+				Name:  "cache",
+				Usage: "Manage the saved details of followed series",
+				Commands: []*cli.Command{
+					{
+						Name:   "warm",
+						Usage:  "Fetch the details of every series in the library ahead of time, so 'info' answers at once",
+						Flags:  []cli.Flag{maxRequestsFlag()},
+						Action: withFootprint(NewCacheWarmCommand(engine)),
+					},
+					{
+						Name:   "clear",
+						Usage:  "Remove the details saved by 'cache warm'",
+						Action: NewCacheClearCommand(engine),
+					},
+				},
+			},
+			{
+				Name:  "dedupe",
+				Usage: "Manage the store of pages shared by downloads with --dedupe",
+				Commands: []*cli.Command{
+					{
+						Name:      "prune",
+						Usage:     "Remove stored pages no chapter in the library directories uses anymore",
+						ArgsUsage: "<store> [library-dir ...]",
+						Action:    NewDedupePruneCommand(engine),
+					},
+				},
+			},
+			{
+				Name:  "debug",
+				Usage: "Tools for developing and maintaining provider configurations",
+				Commands: []*cli.Command{
+					{
+						Name:  "selector",
+						Usage: "Fetch a page through the scraper and show the elements a CSS selector matches",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "url",
+								Usage:    "Page to fetch",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "selector",
+								Aliases:  []string{"s"},
+								Usage:    "CSS selector to evaluate",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:    "provider",
+								Aliases: []string{"p"},
+								Usage:   "Provider whose headers and rate limit to use (detected from the URL if omitted)",
+							},
+							&cli.IntFlag{
+								Name:    "limit",
+								Aliases: []string{"l"},
+								Usage:   "Maximum number of matches to print (0 for all)",
+								Value:   20,
+							},
+							&cli.BoolFlag{
+								Name:  "html",
+								Usage: "Also print the HTML of each match",
+							},
+						},
+						Action: NewDebugSelectorCommand(engine),
+					},
+					{
+						Name:  "extract",
+						Usage: "Run an API response mapping against a response and report what each path matched",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "url",
+								Usage: "API URL to fetch",
+							},
+							&cli.StringFlag{
+								Name:  "file",
+								Usage: "Read a saved response instead of fetching one",
+							},
+							&cli.StringFlag{
+								Name:    "provider",
+								Aliases: []string{"p"},
+								Usage:   "Provider whose mapping, headers and rate limit to use (detected from the URL if omitted)",
+							},
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "Which of the provider's response mappings to use",
+								Value: "search",
+							},
+							&cli.StringSliceFlag{
+								Name:  "field",
+								Usage: "Set or override a mapped path as name=path (e.g. --field \"tags=attributes.tags[*].name\")",
+							},
+							&cli.StringSliceFlag{
+								Name:  "transform",
+								Usage: "Set or override a field's transforms as name=transform[|transform] (e.g. --transform title=bestLocalizedString)",
+							},
+							&cli.IntFlag{
+								Name:    "limit",
+								Aliases: []string{"l"},
+								Usage:   "Maximum number of mapped manga to print (0 for all)",
+								Value:   20,
+							},
+						},
+						Action: NewDebugExtractCommand(engine),
+					},
+				},
+			},
+			{
+				Name:  "link",
+				Usage: "Mark series on different providers as the same, for downloads with --failover",
+				Commands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Link the same series on several providers",
+						ArgsUsage: "<provider:manga-id> <provider:manga-id>...",
+						Action:    NewLinkAddCommand(engine),
+					},
+					{
+						Name:   "list",
+						Usage:  "List the linked series",
+						Action: NewLinkListCommand(engine),
+					},
+					{
+						Name:      "remove",
+						Usage:     "Unlink a series from the others",
+						ArgsUsage: "<provider:manga-id>",
+						Action:    NewLinkRemoveCommand(engine),
+					},
+				},
+			},
+			{
+				Name:  "provider",
+				Usage: "Manage provider definitions",
+				Commands: []*cli.Command{
+					{
+						Name:  "add-madara",
+						Usage: "Add a site running the Madara WordPress theme as a provider, without recompiling",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "id",
+								Usage:    "Provider ID used in references like id:manga-id",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "name",
+								Usage:    "Display name",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "url",
+								Usage:    "Site address, e.g. https://example.com",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "description",
+								Usage: "Short description shown by 'luminary providers'",
+							},
+							&cli.BoolFlag{
+								Name:  "ajax-search",
+								Usage: "Search through the theme's AJAX action first",
+							},
+							&cli.StringSliceFlag{
+								Name:  "selector",
+								Usage: "Override a CSS selector as name=css (e.g. --selector \"search=div.post-title h3 a\")",
+							},
+						},
+						Action: NewAddMadaraCommand(engine),
+					},
+					{
+						Name:  "add-opds",
+						Usage: "Add an OPDS catalog, such as Komga's or Kavita's, as a provider",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "id",
+								Usage:    "Provider ID used in references like id:manga-id",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "name",
+								Usage:    "Display name",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "url",
+								Usage:    "Address of the catalog's root feed, e.g. https://komga.example/opds/v1.2/catalog",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "description",
+								Usage: "Short description shown by 'luminary providers'",
+							},
+							&cli.StringFlag{
+								Name:  "username",
+								Usage: "User name, for catalogs behind HTTP basic auth; the password is read from LUMINARY_OPDS_PASSWORD_<ID>",
+							},
+						},
+						Action: NewAddOPDSCommand(engine),
+					},
+				},
 			},
 			{
 				Name:    "providers",
 				Aliases: []string{"p"},
 				Usage:   "List available providers",
 				Action:  NewProvidersCommand(engine),
+				Commands: []*cli.Command{
+					{
+						Name:      "selftest",
+						Usage:     "Check the providers against their live sites with a known-good search, manga and chapter",
+						ArgsUsage: "[provider-id ...]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "Print the report as JSON",
+							},
+							&cli.StringFlag{
+								Name:  "output",
+								Usage: "Also write the JSON report to this file, e.g. for a CI dashboard",
+							},
+							timeoutFlag(),
+						},
+						Action: withTimeout(NewProvidersSelfTestCommand(engine)),
+					},
+				},
 			},
 		},
 		ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
 			if err != nil {
+				if id := core.CorrelationID(ctx); id != "" {
+					err = errors.AddContext(err, "correlation_id", id)
+				}
 				_, err = fmt.Fprintln(os.Stderr, engine.FormatError(err))
 			}
 		},
@@ -136,3 +797,193 @@ func NewApp(engine *engine.Engine, version string) *cli.Command {
 
 	return app
 }
+
+// downloadFlags are the flags of commands downloading chapters, see downloadChapters
+func downloadFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output directory, or - to stream the chapters to standard output as an archive",
+			Value:   ".",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Image format (jpeg, png, webp)",
+		},
+		&cli.IntFlag{
+			Name:    "page-concurrency",
+			Aliases: []string{"concurrent"},
+			Usage:   "Pages of a chapter downloaded at once (default 3)",
+		},
+		&cli.IntFlag{
+			Name:  "chapter-concurrency",
+			Usage: "Chapters downloaded at once",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "process",
+			Usage: "Post-process chapters, e.g. convert=jpeg,filter=400x300,split=2000,cbz",
+		},
+		&cli.StringFlag{
+			Name:  "layout",
+			Usage: "Where chapters are saved: flat (<output>/Chapter_10) or language (<output>/en/Chapter_10)",
+			Value: string(download.LayoutFlat),
+		},
+		&cli.StringFlag{
+			Name:  "collision",
+			Usage: "Naming of chapters sharing a number with one already saved: group (Chapter_10 [Group]), language (Chapter_10 (es)) or none to overwrite it (default from config, else group)",
+		},
+		numberingFlag(),
+		numberingMapFlag(),
+		&cli.StringFlag{
+			Name:  "stream-format",
+			Usage: "Archive written by --output -: tar or zip",
+			Value: "tar",
+		},
+		&cli.StringFlag{
+			Name:    "dedupe",
+			Usage:   "Keep identical pages once, as hard links to files in this directory on the same disk as --output",
+			Sources: cli.EnvVars("LUMINARY_DEDUPE"),
+		},
+		&cli.BoolFlag{
+			Name:  "audit",
+			Usage: "Save every HTTP request of each chapter (URL, status, bytes, duration, retries) to ~/.luminary/audit",
+		},
+		&cli.BoolFlag{
+			Name:  "failover",
+			Usage: "Download chapters that fail from a series linked with 'luminary link add' instead",
+		},
+		&cli.StringFlag{
+			Name:    "storage",
+			Usage:   "Write chapters to file:///dir, s3://bucket/prefix, webdav(s)://host/path or sftp://user@host/path; --output is a path below it",
+			Sources: cli.EnvVars("LUMINARY_STORAGE"),
+		},
+		timeoutFlag(),
+		recordFlag(),
+		maxRequestsFlag(),
+	}
+}
+
+// numberingFlag is the --numbering flag of commands listing or downloading chapters
+func numberingFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "numbering",
+		Usage: "Chapter numbers: source (as the site has them), absolute (counting on across volumes) or volume (starting at 1 in every volume, saved as Vol_2_Chapter_1)",
+	}
+}
+
+// numberingMapFlag is the --numbering-map flag of commands listing or downloading chapters
+func numberingMapFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "numbering-map",
+		Usage: "JSON file correcting the site's chapter numbers and volumes, see the README",
+	}
+}
+
+// timeoutFlag is the --timeout flag of commands doing network work
+func timeoutFlag() cli.Flag {
+	return &cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Time limit of the whole command, replacing the time budgets of the config and flags (0 for none)",
+	}
+}
+
+// withTimeout runs action within the limit of its --timeout flag, if given. The computed
+// time budgets don't apply then, so slow connections get as long as the user allows.
+func withTimeout(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if !c.IsSet("timeout") {
+			return action(ctx, c)
+		}
+
+		opCtx, cancel := network.WithOverride(ctx, c.Duration("timeout"))
+		defer cancel()
+		err := action(opCtx, c)
+		if budgetErr := network.BudgetError(opCtx, ctx); err != nil && budgetErr != nil {
+			return budgetErr
+		}
+		return err
+	}
+}
+
+// recordFlag is the --record-responses flag of commands whose provider responses can be
+// saved for debugging
+func recordFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "record-responses",
+		Usage: "Save the raw HTML/JSON responses of the providers to this directory, for bug reports",
+	}
+}
+
+// withRecording runs action saving the responses it receives to the directory of its
+// --record-responses flag, if given
+func withRecording(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		dir := c.String("record-responses")
+		if dir == "" {
+			return action(ctx, c)
+		}
+
+		recorder, err := network.NewRecorder(dir)
+		if err != nil {
+			return err
+		}
+		err = action(network.WithRecorder(ctx, recorder), c)
+		_, _ = fmt.Fprintf(os.Stderr, "Recorded %d responses to %s\n", recorder.Count(), recorder.Dir())
+		if failed, recordErr := recorder.Err(); recordErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to record %d responses: %v\n", failed, recordErr)
+		}
+		return err
+	}
+}
+
+// maxRequestsFlag is the --max-requests flag of bulk commands
+func maxRequestsFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:  "max-requests",
+		Usage: "Stop once this many HTTP requests, retries included, were sent (0 = unlimited)",
+	}
+}
+
+// withFootprint runs action counting its requests by provider, stopping its work once the
+// budget of its --max-requests flag is spent, and prints what it asked of the sites to
+// stderr, so scripted output stays clean
+func withFootprint(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		ctx, footprint := network.WithFootprint(ctx, int(c.Int("max-requests")))
+		err := action(ctx, c)
+		printFootprint(footprint)
+		return err
+	}
+}
+
+// applyTimeoutFlags overrides the time budgets of the config file with those given as flags
+func applyTimeoutFlags(eng *engine.Engine, cmd *cli.Command) error {
+	timeouts := eng.Timeouts()
+	if cmd.IsSet("request-timeout") {
+		timeouts.Request = engine.Duration(cmd.Duration("request-timeout"))
+	}
+	if cmd.IsSet("page-timeout") {
+		timeouts.Page = engine.Duration(cmd.Duration("page-timeout"))
+	}
+	if cmd.IsSet("chapter-timeout") {
+		timeouts.Chapter = engine.Duration(cmd.Duration("chapter-timeout"))
+	}
+	if cmd.IsSet("search-timeout") {
+		policy, err := engine.ParseTimeoutPolicy(cmd.String("search-timeout"))
+		if err != nil {
+			return err
+		}
+		timeouts.Search = policy
+	}
+	if cmd.IsSet("list-timeout") {
+		policy, err := engine.ParseTimeoutPolicy(cmd.String("list-timeout"))
+		if err != nil {
+			return err
+		}
+		timeouts.List = policy
+	}
+	eng.SetTimeouts(timeouts)
+	return nil
+}
@@ -0,0 +1,213 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewWatchClipboardCommand creates the watch-clipboard command, which downloads the manga
+// and chapters whose URLs are copied while it runs
+func NewWatchClipboardCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		command, err := clipboardCommand()
+		if err != nil {
+			return err
+		}
+
+		interval := c.Duration("interval")
+		if interval <= 0 {
+			return errors.Newf("invalid interval %s", interval).
+				WithMessage("The interval must be positive, e.g. 1s").Error()
+		}
+		outputDir := c.String("output")
+		auto := c.Bool("auto")
+		allLanguages := c.Bool("all-languages")
+
+		targets := make(chan *engine.ResolvedURL, 16)
+		go watchClipboard(ctx, eng, command, interval, targets)
+
+		_, _ = headerStyle.Printf("Watching the clipboard for manga and chapter URLs ")
+		_, _ = secondaryStyle.Printf("(Ctrl+C to stop)\n")
+
+		input := bufio.NewReader(os.Stdin)
+		for target := range targets {
+			name := target.Kind + " " + target.Provider.ID() + ":" + target.ID
+			if target.Kind == engine.URLKindManga {
+				if info, err := eng.GetManga(ctx, target.Provider, target.ID); err == nil {
+					name = "manga " + info.Title
+				}
+			}
+			_, _ = infoStyle.Printf("Found %s ", name)
+			_, _ = secondaryStyle.Printf("on %s\n", target.Provider.Name())
+
+			if !auto {
+				_, _ = labelStyle.Printf("Download? [Y/n] ")
+				answer, err := input.ReadString('\n')
+				if err == io.EOF {
+					return errors.New("standard input closed").
+						WithMessage("Can't ask before downloading; use --auto to download without asking").Error()
+				}
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+					continue
+				}
+			}
+
+			downloadClipboardTarget(ctx, eng, target, outputDir, allLanguages)
+		}
+		return nil
+	}
+}
+
+// downloadClipboardTarget downloads a copied manga or chapter, printing the outcome. Manga
+// are downloaded in the preferred languages unless allLanguages is set.
+func downloadClipboardTarget(ctx context.Context, eng *engine.Engine, target *engine.ResolvedURL, outputDir string, allLanguages bool) {
+	printRecord := func(record library.Record) {
+		chapterID := record.Provider + ":" + record.ChapterID
+		switch record.Status {
+		case library.StatusCompleted:
+			_, _ = successStyle.Printf("✓ Chapter %s downloaded successfully\n", chapterID)
+		case library.StatusPartial:
+			_, _ = warningStyle.Printf("⚠ Chapter %s downloaded without pages %s\n", chapterID, joinInts(record.FailedPages))
+		case library.StatusSkipped:
+			_, _ = warningStyle.Printf("↷ Skipped %s: %s\n", chapterID, record.Error)
+		default:
+			_, _ = errorStyle.Printf("✗ Chapter %s failed: %s\n", chapterID, record.Error)
+		}
+	}
+
+	if target.Kind == engine.URLKindChapter {
+		record, _ := eng.DownloadChapterRecord(ctx, target.Provider, target.ID, outputDir)
+		printRecord(record)
+		return
+	}
+
+	_, err := eng.DownloadManga(ctx, target.Provider, target.ID, engine.MangaDownloadOptions{
+		OutputDir:    outputDir,
+		AllLanguages: allLanguages,
+		OnChapter:    printRecord,
+	})
+	if err != nil {
+		printError(eng, err)
+	}
+}
+
+// clipboardMemory is how long a copied URL is remembered; copied again later, it is sent
+// again, and the watcher doesn't hold on to every URL of a long session
+const clipboardMemory = time.Hour
+
+// watchClipboard reads the clipboard every interval and sends the URLs of known providers
+// copied since it started, each once within clipboardMemory. What the clipboard holds at
+// the start is ignored.
+func watchClipboard(ctx context.Context, eng *engine.Engine, command []string, interval time.Duration, targets chan<- *engine.ResolvedURL) {
+	defer close(targets)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := readClipboard(ctx, eng, command)
+	seen := make(map[string]time.Time)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		text := readClipboard(ctx, eng, command)
+		if text == last {
+			continue
+		}
+		last = text
+
+		now := time.Now()
+		for rawURL, copied := range seen {
+			if now.Sub(copied) > clipboardMemory {
+				delete(seen, rawURL)
+			}
+		}
+
+		for _, field := range strings.Fields(text) {
+			rawURL := strings.Trim(field, `<>"'()`)
+			if _, ok := seen[rawURL]; ok || !(strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")) {
+				continue
+			}
+			seen[rawURL] = now
+
+			resolved, err := eng.ResolveURL(rawURL)
+			if err != nil {
+				eng.Log(ctx).Debug("Ignoring copied URL %s: %v", rawURL, err)
+				continue
+			}
+			select {
+			case targets <- resolved:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// clipboardCommand returns the command printing the clipboard's text on this system
+func clipboardCommand() ([]string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-paste", "--no-newline"})
+		}
+		candidates = append(candidates,
+			[]string{"xclip", "-selection", "clipboard", "-o"},
+			[]string{"xsel", "--clipboard", "--output"})
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return candidate, nil
+		}
+	}
+	return nil, errors.New("no clipboard tool found").
+		WithMessage("Reading the clipboard needs pbpaste on macOS, PowerShell on Windows, or wl-paste, xclip or xsel on Linux").
+		Error()
+}
+
+// readClipboard returns the clipboard's text; an empty or unreadable clipboard reads as
+// empty
+func readClipboard(ctx context.Context, eng *engine.Engine, command []string) string {
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+	if err != nil {
+		eng.Log(ctx).Debug("Failed to read the clipboard with %s: %v", command[0], err)
+		return ""
+	}
+	return string(out)
+}
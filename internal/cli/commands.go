@@ -19,10 +19,21 @@ package cli
 import (
 	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/engine/numbering"
 	"Luminary/pkg/errors"
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 
 	"github.com/fatih/color"
@@ -66,25 +77,40 @@ func NewSearchCommand(eng *engine.Engine) cli.ActionFunc {
 		limit := c.Int("limit")
 		sort := c.String("sort")
 
-		eng.Logger.Debug("Search parameters: query=%s, provider=%s, limit=%d, sort=%s",
+		eng.Log(ctx).Debug("Search parameters: query=%s, provider=%s, limit=%d, sort=%s",
 			query, provider, limit, sort)
 
 		options := core.SearchOptions{
-			Query: query,
-			Limit: limit,
-			Pages: 1,
+			Query:            query,
+			Limit:            limit,
+			Pages:            1,
+			IncludeAltTitles: c.Bool("alt-titles"),
 		}
 
 		// Add sort if specified
 		if sort != "" {
 			options.Sort = sort
-			eng.Logger.Debug("Using sort: %s", sort)
+			eng.Log(ctx).Debug("Using sort: %s", sort)
+		}
+
+		// Add filters if specified
+		if filterArgs := c.StringSlice("filter"); len(filterArgs) > 0 {
+			filters, err := parseSearchFilters(filterArgs)
+			if err != nil {
+				return err
+			}
+			options.Filters = filters
+			eng.Log(ctx).Debug("Using filters: %v", filters)
 		}
 
 		_, _ = headerStyle.Printf("Searching for: ")
 		_, _ = titleStyle.Printf("%s\n", query)
 		_, _ = dividerColor.Println(strings.Repeat("─", 50))
 
+		if provider != "" && c.IsSet("providers") {
+			return errors.New("--provider and --providers can't be used together").Error()
+		}
+
 		if provider != "" {
 			// Search single provider
 			p, err := eng.GetProvider(provider)
@@ -92,29 +118,98 @@ func NewSearchCommand(eng *engine.Engine) cli.ActionFunc {
 				return err // Let the ExitErrHandler format this
 			}
 
-			eng.Logger.Debug("Searching provider: %s", p.ID())
-			results, err := p.Search(ctx, query, options)
+			eng.Log(ctx).Debug("Searching provider: %s", p.ID())
+			results, err := eng.Search(ctx, p, query, options)
 			if err != nil {
 				return err // Let the ExitErrHandler format this
 			}
 
 			printSearchResults(p.Name(), results)
 		} else {
-			// Search all providers
-			eng.Logger.Debug("Searching all providers")
-			for _, p := range eng.AllProviders() {
-				results, err := p.Search(ctx, query, options)
-				if err != nil {
-					// Return immediately on first error instead of continuing
-					return err
-				}
+			// Search all (or the chosen) providers, printing each one's results as they arrive
+			providers, err := eng.SelectProviders(c.StringSlice("providers"))
+			if err != nil {
+				return err // Let the ExitErrHandler format this
+			}
+
+			eng.Log(ctx).Debug("Searching %d providers", len(providers))
+			return streamSearchResults(ctx, eng, providers, query, options)
+		}
+
+		return nil
+	}
+}
 
-				printSearchResults(p.Name(), results)
+// stillSearchingInterval is how often providers that haven't answered yet are listed
+const stillSearchingInterval = 2 * time.Second
+
+// streamSearchResults searches the providers at once and prints each one's results as soon
+// as they arrive. Providers that are slow to answer are listed as still searching, and
+// those that failed are summed up at the end. Fails only when no provider could be searched.
+func streamSearchResults(ctx context.Context, eng *engine.Engine, providers []engine.Provider, query string, options core.SearchOptions) error {
+	pending := make(map[string]bool, len(providers))
+	for _, p := range providers {
+		pending[p.ID()] = true
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(stillSearchingInterval)
+	defer ticker.Stop()
+
+	var multi core.MultiResult[[]core.Manga]
+	results := eng.SearchAll(ctx, providers, query, options)
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				multi.Elapsed = time.Since(start)
+				return summarizeSearch(multi, providers)
+			}
+			delete(pending, r.Provider.ID())
+			multi.Results = append(multi.Results, r.Result())
+
+			if r.Err != nil {
+				_, _ = secondaryStyle.Printf("\n[%s] ", r.Provider.Name())
+				_, _ = errorStyle.Print("Search failed\n")
+				printError(eng, r.Err)
+				continue
 			}
+			printSearchResults(r.Provider.Name(), r.Results)
+
+		case <-ticker.C:
+			var names []string
+			for _, p := range providers {
+				if pending[p.ID()] {
+					names = append(names, p.Name())
+				}
+			}
+			_, _ = secondaryStyle.Printf("… still searching (%s): ", formatDuration(time.Since(start)))
+			_, _ = warningStyle.Printf("%s\n", strings.Join(names, ", "))
 		}
+	}
+}
 
+// summarizeSearch lists the providers a search failed on, failing when it failed on all
+func summarizeSearch(multi core.MultiResult[[]core.Manga], providers []engine.Provider) error {
+	if multi.AllFailed() {
+		return errors.Track(multi.FirstError()).WithMessagef("Search failed on all %d providers", len(providers)).Error()
+	}
+	failures := multi.Failures()
+	if len(failures) == 0 {
 		return nil
 	}
+
+	names := make(map[string]string, len(providers))
+	for _, p := range providers {
+		names[p.ID()] = p.Name()
+	}
+	_, _ = warningStyle.Printf("Search failed on %d of %d providers after %s:\n", len(failures), len(multi.Results), formatDuration(multi.Elapsed))
+	for _, failure := range failures {
+		_, _ = bulletStyle.Print("  • ")
+		_, _ = titleStyle.Printf("%s ", names[failure.Provider])
+		_, _ = secondaryStyle.Printf("(%s, %s)\n", failure.Provider, formatDuration(failure.Elapsed))
+	}
+	return nil
 }
 
 // NewInfoCommand creates the info command
@@ -126,8 +221,10 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 
 		mangaID := c.Args().First()
 		langFilter := c.String("lang")
+		showRelated := c.Bool("related")
+		showPages := c.Bool("pages")
 
-		eng.Logger.Debug("Info request: manga=%s, lang=%s", mangaID, langFilter)
+		eng.Log(ctx).Debug("Info request: manga=%s, lang=%s", mangaID, langFilter)
 
 		// Parse combined ID
 		parts := strings.SplitN(mangaID, ":", 2)
@@ -143,9 +240,16 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 			return err // Let the ExitErrHandler format this
 		}
 
+		// Details saved by 'cache warm' are good enough to show; fresh ones replace them
+		if c.Bool("fresh") {
+			ctx = cache.Refresh(ctx)
+		} else {
+			ctx = cache.AllowStale(ctx)
+		}
+
 		// Get manga info
-		eng.Logger.Debug("Fetching manga info from provider: %s, id: %s", providerID, id)
-		info, err := provider.GetManga(ctx, id)
+		eng.Log(ctx).Debug("Fetching manga info from provider: %s, id: %s", providerID, id)
+		info, err := eng.GetManga(ctx, provider, id)
 		if err != nil {
 			return err // Let the ExitErrHandler format this
 		}
@@ -166,6 +270,11 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 			_, _ = valueStyle.Printf("%s\n", strings.Join(info.Authors, ", "))
 		}
 
+		if len(info.Artists) > 0 {
+			_, _ = labelStyle.Printf("Artists: ")
+			_, _ = valueStyle.Printf("%s\n", strings.Join(info.Artists, ", "))
+		}
+
 		if info.Status != "" {
 			_, _ = labelStyle.Printf("Status: ")
 
@@ -184,6 +293,20 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 			}
 		}
 
+		if info.Demographic != "" {
+			_, _ = labelStyle.Printf("Demographic: ")
+			_, _ = valueStyle.Printf("%s\n", info.Demographic)
+		}
+
+		if info.ContentRating != "" {
+			_, _ = labelStyle.Printf("Content Rating: ")
+			if info.ContentRating == "safe" {
+				_, _ = valueStyle.Printf("%s\n", info.ContentRating)
+			} else {
+				_, _ = warningStyle.Printf("%s\n", info.ContentRating)
+			}
+		}
+
 		if len(info.Tags) > 0 {
 			_, _ = labelStyle.Printf("Tags: ")
 
@@ -197,13 +320,52 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 			fmt.Println()
 		}
 
+		if len(info.ExternalIDs) > 0 {
+			_, _ = labelStyle.Printf("Trackers: ")
+
+			first := true
+			for _, source := range []string{core.ExternalAniList, core.ExternalMyAnimeList, core.ExternalMangaUpdates} {
+				id, ok := info.ExternalIDs[source]
+				if !ok {
+					continue
+				}
+				if !first {
+					fmt.Print(", ")
+				}
+				_, _ = valueStyle.Printf("%s ", source)
+				_, _ = secondaryStyle.Printf("%s", id)
+				first = false
+			}
+			fmt.Println()
+		}
+
+		if showRelated && len(info.Related) > 0 {
+			_, _ = dividerColor.Println(strings.Repeat("─", 50))
+			_, _ = sectionStyle.Printf("Related (%d):\n", len(info.Related))
+
+			for _, rel := range info.Related {
+				_, _ = bulletStyle.Printf("  • ")
+				_, _ = infoStyle.Printf("[%s:%s]", providerID, rel.ID)
+
+				if rel.Title != "" {
+					_, _ = titleStyle.Printf(" %s", rel.Title)
+				}
+
+				if rel.Relation != "" {
+					_, _ = secondaryStyle.Printf(" (%s)", strings.ReplaceAll(rel.Relation, "_", " "))
+				}
+
+				fmt.Println()
+			}
+		}
+
 		_, _ = dividerColor.Println(strings.Repeat("─", 50))
 
 		// Filter chapters if requested
 		chapters := info.Chapters
 		if langFilter != "" {
 			languages := strings.Split(langFilter, ",")
-			eng.Logger.Debug("Filtering chapters by languages: %v", languages)
+			eng.Log(ctx).Debug("Filtering chapters by languages: %v", languages)
 			chapters = filterChaptersByLanguage(chapters, languages)
 		}
 
@@ -218,7 +380,7 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 
 			_, _ = bulletStyle.Printf("  • ")
 			_, _ = infoStyle.Printf("[%s:%s]", providerID, ch.ID)
-			_, _ = valueStyle.Printf(" Ch.%g", ch.Number)
+			_, _ = valueStyle.Printf(" Ch.%s", ch.DisplayNumber())
 
 			if ch.Title != "" {
 				_, _ = titleStyle.Printf(" - %s", ch.Title)
@@ -228,6 +390,27 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 				_, _ = secondaryStyle.Printf(" (%s)", ch.Date.Format("2006-01-02"))
 			}
 
+			if ch.ExternalURL != "" {
+				_, _ = warningStyle.Printf(" [external: %s]", ch.ExternalURL)
+				fmt.Println()
+				continue
+			}
+
+			if showPages {
+				pageCount := ch.PageCount
+				if pageCount == 0 {
+					if count, err := eng.ChapterPageCount(ctx, provider, ch.ID); err == nil {
+						pageCount = count
+					} else {
+						eng.Log(ctx).Debug("Failed to get page count for %s: %v", ch.ID, err)
+					}
+				}
+
+				if pageCount > 0 {
+					_, _ = secondaryStyle.Printf(" [%d pages]", pageCount)
+				}
+			}
+
 			fmt.Println()
 		}
 
@@ -235,101 +418,383 @@ func NewInfoCommand(eng *engine.Engine) cli.ActionFunc {
 	}
 }
 
-// NewDownloadCommand creates the download command
-func NewDownloadCommand(eng *engine.Engine) cli.ActionFunc {
+// NewChaptersCommand creates the chapters command
+func NewChaptersCommand(eng *engine.Engine) cli.ActionFunc {
 	return func(ctx context.Context, c *cli.Command) error {
 		if c.NArg() == 0 {
-			return errors.New("chapter ID is required").Error()
+			return errors.New("manga ID is required").Error()
 		}
 
-		// Support multiple chapters as shown in README
-		chapterIDs := c.Args().Slice()
-		outputDir := c.String("output")
-		format := c.String("format")
-		concurrent := c.Int("concurrent")
+		mangaID := c.Args().First()
+		parts := strings.SplitN(mangaID, ":", 2)
+		if len(parts) != 2 {
+			return errors.Newf("invalid manga ID format: %s", mangaID).Error()
+		}
 
-		eng.Logger.Debug("Download request: chapters=%v, output=%s, format=%s, concurrent=%d",
-			chapterIDs, outputDir, format, concurrent)
+		providerID, id := parts[0], parts[1]
 
-		start := time.Now()
+		provider, err := eng.GetProvider(providerID)
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
 
-		hasErrors := false
-		successCount := 0
+		filter := engine.ChapterFilter{
+			From:   c.Float("from"),
+			To:     c.Float("to"),
+			Group:  c.String("group"),
+			Latest: c.Int("latest"),
+		}
+		if lang := c.String("language"); lang != "" {
+			filter.Languages = strings.Split(lang, ",")
+		}
 
-		_, _ = headerStyle.Printf("Download started to: ")
-		_, _ = valueStyle.Printf("%s\n", outputDir)
+		// Duplicates go by group first, then language, then page count
+		groups, languages := c.String("prefer-group"), c.String("prefer-language")
+		if c.Bool("unique") || groups != "" || languages != "" {
+			filter.Prefer = &engine.ChapterPreference{}
+			if groups != "" {
+				filter.Prefer.Groups = strings.Split(groups, ",")
+			}
+			if languages != "" {
+				filter.Prefer.Languages = strings.Split(languages, ",")
+			}
+		}
+
+		eng.Log(ctx).Debug("Chapters request: manga=%s, filter=%+v", mangaID, filter)
 
-		if len(chapterIDs) > 1 {
-			_, _ = infoStyle.Printf("Processing %d chapters...\n", len(chapterIDs))
+		ctx, err = withNumbering(ctx, c)
+		if err != nil {
+			return err
 		}
+		n, _ := numbering.FromContext(ctx)
 
-		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		chapters, err := eng.Chapters(ctx, provider, id, filter)
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
 
-		for _, chapterID := range chapterIDs {
-			// Parse combined ID
-			parts := strings.SplitN(chapterID, ":", 2)
-			if len(parts) != 2 {
-				err := errors.Newf("invalid chapter ID format: %s", chapterID).Error()
-				fmt.Println(eng.FormatError(err))
-				hasErrors = true
-				continue
+		// One tab-separated line per chapter, combined ID first so the output can be piped into download --stdin
+		for _, ch := range chapters {
+			_, _ = infoStyle.Printf("%s:%s", providerID, ch.ID)
+			// Numbers starting over in every volume need it
+			if n.Scheme == numbering.SchemeVolume && ch.Volume != "" {
+				_, _ = valueStyle.Printf("\tVol.%s Ch.%s", ch.Volume, ch.DisplayNumber())
+			} else {
+				_, _ = valueStyle.Printf("\tCh.%s", ch.DisplayNumber())
 			}
+			_, _ = secondaryStyle.Printf("\t%s\t%s", ch.Language, ch.Group)
+			_, _ = titleStyle.Printf("\t%s\n", ch.Title)
+		}
 
-			providerID, id := parts[0], parts[1]
+		return nil
+	}
+}
+
+// NewDownloadCommand creates the download command
+func NewDownloadCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		// Support multiple chapters as shown in README
+		chapterIDs := c.Args().Slice()
 
-			// Get provider
-			provider, err := eng.GetProvider(providerID)
+		// Chapter lists can come from a file, or from stdin with "-" or --stdin
+		fromFile := c.String("from-file")
+		if c.Bool("stdin") {
+			fromFile = "-"
+		}
+		if fromFile != "" {
+			ids, err := readChapterList(fromFile)
 			if err != nil {
-				fmt.Println(eng.FormatError(err))
-				hasErrors = true
-				continue
+				return err
 			}
+			chapterIDs = append(chapterIDs, ids...)
+		}
+
+		if len(chapterIDs) == 0 {
+			return errors.New("chapter ID is required").Error()
+		}
+
+		_, err := downloadChapters(ctx, eng, c, chapterIDs)
+		return err
+	}
+}
+
+// withNumbering renumbers the chapters listed or downloaded as --numbering and
+// --numbering-map say
+func withNumbering(ctx context.Context, c *cli.Command) (context.Context, error) {
+	var n numbering.Normalizer
+	if name := c.String("numbering"); name != "" {
+		scheme, err := numbering.ParseScheme(name)
+		if err != nil {
+			return ctx, err
+		}
+		n.Scheme = scheme
+	}
+	if path := c.String("numbering-map"); path != "" {
+		mapping, err := numbering.LoadMapping(path)
+		if err != nil {
+			return ctx, err
+		}
+		n.Mapping = mapping
+	}
+	return numbering.WithNormalizer(ctx, n), nil
+}
+
+// downloadChapters downloads chapters with the settings of the download flags on c,
+// printing their progress and a summary. The results are in the order of chapterIDs;
+// the error is set when any chapter failed.
+func downloadChapters(ctx context.Context, eng *engine.Engine, c *cli.Command, chapterIDs []string) ([]downloadResult, error) {
+	outputDir := c.String("output")
+	format := c.String("format")
+	chapterConcurrency := max(c.Int("chapter-concurrency"), 1)
+	if n := c.Int("page-concurrency"); n > 0 {
+		ctx = core.WithPageConcurrency(ctx, n)
+	}
+
+	if spec := c.String("process"); spec != "" {
+		pipeline, err := download.ParsePipeline(spec)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithPipeline(ctx, pipeline)
+	}
+
+	layout, err := download.ParseLayout(c.String("layout"))
+	if err != nil {
+		return nil, err
+	}
+	ctx = download.WithLayout(ctx, layout)
+	if name := c.String("collision"); name != "" {
+		collision, err := download.ParseCollision(name)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithCollision(ctx, collision)
+	}
+	ctx, err = withNumbering(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// With other storage the output directory is a path below its root
+	destination := outputDir
+	storage, err := download.ParseStorage(c.String("storage"))
+	if err != nil {
+		return nil, err
+	}
+	if storage != nil {
+		ctx = download.WithStorage(ctx, storage)
+		destination = storage.Location(outputDir)
+	}
+
+	if dir := c.String("dedupe"); dir != "" {
+		store, err := download.NewBlobStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithBlobStore(ctx, store)
+	}
+	if c.Bool("audit") {
+		ctx = engine.WithAudit(ctx)
+	}
+	if c.Bool("failover") {
+		ctx = engine.WithFailover(ctx)
+	}
+
+	// "-" streams the chapters out as one archive; everything else printed goes to stderr
+	var stream *download.ArchiveStream
+	if outputDir == "-" {
+		if storage != nil {
+			return nil, errors.New("--output - and --storage can't be combined").Error()
+		}
+		var restore func()
+		stream, restore, err = streamToStdout(c.String("stream-format"))
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+
+		ctx = download.WithStorage(ctx, stream)
+		outputDir, destination = ".", "standard output"
+	}
+
+	eng.Log(ctx).Debug("Download request: chapters=%v, output=%s, format=%s, chapter_concurrency=%d, page_concurrency=%d",
+		chapterIDs, outputDir, format, chapterConcurrency, core.PageConcurrency(ctx))
 
-			// Download chapter
-			_, _ = infoStyle.Printf("Downloading: ")
-			_, _ = titleStyle.Printf("%s ", chapterID)
+	start := time.Now()
+
+	_, _ = headerStyle.Printf("Download started to: ")
+	_, _ = valueStyle.Printf("%s\n", destination)
+
+	if len(chapterIDs) > 1 {
+		_, _ = infoStyle.Printf("Processing %d chapters...\n", len(chapterIDs))
+	}
+
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+	// Chapters downloaded side by side keep their lines together
+	var printMutex sync.Mutex
+	var quotaReached atomic.Bool
+	// On a terminal the pages of running chapters show on a progress line
+	progress := newProgressLine(&printMutex)
+	downloadOne := func(chapterID string) downloadResult {
+		// Resolve combined ID or chapter URL
+		provider, id, err := eng.ResolveChapter(chapterID)
+		if err != nil {
+			printMutex.Lock()
+			progress.clear()
+			printError(eng, err)
+			printMutex.Unlock()
+			return downloadResult{chapterID, downloadFailed, err.Error()}
+		}
+
+		// Download chapter
+		printMutex.Lock()
+		progress.clear()
+		_, _ = infoStyle.Printf("Downloading: ")
+		_, _ = titleStyle.Printf("%s ", chapterID)
+		if ref := provider.ID() + ":" + id; ref != chapterID {
+			// Show what a chapter URL resolved to, for scripts and later runs
+			_, _ = secondaryStyle.Printf("from %s (%s)\n", provider.Name(), ref)
+		} else {
 			_, _ = secondaryStyle.Printf("from %s\n", provider.Name())
+		}
+		printMutex.Unlock()
 
-			eng.Logger.Debug("Downloading chapter: provider=%s, id=%s, output=%s",
-				providerID, id, outputDir)
+		eng.Log(ctx).Debug("Downloading chapter: provider=%s, id=%s, output=%s",
+			provider.ID(), id, outputDir)
 
-			if err := provider.DownloadChapter(ctx, id, outputDir); err != nil {
-				fmt.Println(eng.FormatError(err))
-				hasErrors = true
-				continue
+		chapterCtx := ctx
+		if progress != nil {
+			chapterCtx = download.WithProgress(ctx, progress.report(chapterID))
+		}
+		record, err := eng.DownloadChapterRecord(chapterCtx, provider, id, outputDir)
+
+		printMutex.Lock()
+		defer printMutex.Unlock()
+		progress.finish(chapterID)
+		if err != nil {
+			// External, licensed and locked chapters can't be downloaded; skip them
+			// instead of failing
+			if download.IsUnavailable(err) {
+				_, _ = warningStyle.Printf("↷ Skipped %s: %s\n", chapterID, err.Error())
+				return downloadResult{chapterID, downloadSkipped, err.Error()}
+			}
+
+			if errors.Is(err, download.ErrQuotaExceeded) {
+				quotaReached.Store(true)
 			}
+			printError(eng, err)
+			return downloadResult{chapterID, downloadFailed, err.Error()}
+		}
+
+		if record.Status == library.StatusPartial {
+			_, _ = warningStyle.Printf("⚠ Chapter %s downloaded without pages %s\n", chapterID, joinInts(record.FailedPages))
+			return downloadResult{chapterID, downloadPartial, record.Error}
+		}
+
+		if record.Existing {
+			_, _ = successStyle.Printf("✓ Chapter %s already downloaded to %s\n", chapterID, record.Path)
+			return downloadResult{chapterID, downloadSucceeded, ""}
+		}
+		if record.FailoverFrom != "" {
+			_, _ = successStyle.Printf("✓ Chapter %s downloaded successfully from %s:%s\n", chapterID, record.Provider, record.ChapterID)
+			return downloadResult{chapterID, downloadSucceeded, ""}
+		}
+		_, _ = successStyle.Printf("✓ Chapter %s downloaded successfully\n", chapterID)
+		return downloadResult{chapterID, downloadSucceeded, ""}
+	}
 
-			_, _ = successStyle.Printf("✓ Chapter %s downloaded successfully\n", chapterID)
+	// Up to --chapter-concurrency chapters at once; the summary keeps the given order.
+	// Once the download quota is reached or the request budget is spent no more chapters
+	// are started.
+	results := make([]downloadResult, len(chapterIDs))
+	sem := make(chan struct{}, chapterConcurrency)
+	var wg sync.WaitGroup
+	for i, chapterID := range chapterIDs {
+		sem <- struct{}{}
+		var stopped error
+		if quotaReached.Load() {
+			stopped = download.ErrQuotaExceeded
+		} else if network.RequestBudgetError(ctx) != nil {
+			stopped = network.ErrRequestBudget
+		}
+		if stopped != nil {
+			<-sem
+			results[i] = downloadResult{chapterID, downloadFailed, "not started: " + stopped.Error()}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = downloadOne(chapterID)
+		}()
+	}
+	wg.Wait()
+
+	hasErrors := false
+	successCount := 0
+	partialCount := 0
+	skippedCount := 0
+	for _, result := range results {
+		switch result.Status {
+		case downloadSucceeded:
 			successCount++
+		case downloadPartial:
+			partialCount++
+		case downloadSkipped:
+			skippedCount++
+		case downloadFailed:
+			hasErrors = true
 		}
+	}
+
+	if stream != nil {
+		if err := stream.Close(); err != nil {
+			return results, errors.Track(err).WithMessage("Failed to finish the archive").Error()
+		}
+	}
 
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+	// Batches get a per-chapter summary so failures don't scroll out of sight
+	if len(results) > 1 {
+		printDownloadSummary(results)
 		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+	}
 
-		elapsed := time.Since(start)
+	elapsed := time.Since(start)
 
-		if successCount > 0 {
-			if successCount == len(chapterIDs) {
-				_, _ = successStyle.Printf("All %d chapter(s) downloaded successfully ", successCount)
-			} else {
-				_, _ = warningStyle.Printf("%d of %d chapter(s) downloaded ", successCount, len(chapterIDs))
-			}
-			_, _ = secondaryStyle.Printf("in %s\n", formatDuration(elapsed))
+	if successCount > 0 {
+		if successCount == len(chapterIDs) {
+			_, _ = successStyle.Printf("All %d chapter(s) downloaded successfully ", successCount)
+		} else {
+			_, _ = warningStyle.Printf("%d of %d chapter(s) downloaded ", successCount, len(chapterIDs))
 		}
+		_, _ = secondaryStyle.Printf("in %s\n", formatDuration(elapsed))
+	}
 
-		if hasErrors {
-			return errors.New("some downloads failed").
-				WithMessage("Some chapters could not be downloaded. See above for details.").Error()
-		}
+	if partialCount > 0 {
+		_, _ = warningStyle.Printf("%d chapter(s) are missing pages; 'luminary history --failed --retry' fetches them later\n", partialCount)
+	}
 
-		return nil
+	if skippedCount > 0 {
+		_, _ = warningStyle.Printf("%d external chapter(s) skipped\n", skippedCount)
+	}
+
+	if hasErrors {
+		return results, errors.New("some downloads failed").
+			WithMessage("Some chapters could not be downloaded. See above for details.").Error()
 	}
+
+	return results, nil
 }
 
 // NewProvidersCommand creates the providers command
 func NewProvidersCommand(eng *engine.Engine) cli.ActionFunc {
 	return func(ctx context.Context, c *cli.Command) error {
 		providers := eng.AllProviders()
-		eng.Logger.Debug("Listing %d providers", len(providers))
+		eng.Log(ctx).Debug("Listing %d providers", len(providers))
 
 		_, _ = headerStyle.Printf("Available providers ")
 		_, _ = titleStyle.Printf("(%d)\n", len(providers))
@@ -341,6 +806,19 @@ func NewProvidersCommand(eng *engine.Engine) cli.ActionFunc {
 			_, _ = secondaryStyle.Printf("    %s\n", p.Description())
 			_, _ = infoStyle.Printf("    URL: ")
 			_, _ = valueStyle.Printf("%s\n", p.SiteURL())
+			if priority := eng.ProviderSettings(p.ID()).Priority; priority != 0 {
+				_, _ = infoStyle.Printf("    Priority: ")
+				_, _ = valueStyle.Printf("%d\n", priority)
+			}
+			switch readiness := eng.ProviderReadiness(p.ID()); readiness.State {
+			case engine.ReadinessReady:
+			case engine.ReadinessFailed:
+				_, _ = infoStyle.Printf("    Status: ")
+				_, _ = errorStyle.Printf("failed to initialize: %s\n", readiness.Error)
+			default:
+				_, _ = infoStyle.Printf("    Status: ")
+				_, _ = warningStyle.Printf("%s\n", readiness.State)
+			}
 			fmt.Println()
 		}
 
@@ -353,7 +831,7 @@ func NewProvidersCommand(eng *engine.Engine) cli.ActionFunc {
 func printSearchResults(providerName string, results []core.Manga) {
 	if len(results) == 0 {
 		_, _ = secondaryStyle.Printf("\n[%s] ", providerName)
-		_, _ = warningStyle.Println("No results found\n")
+		_, _ = warningStyle.Print("No results found\n\n")
 		return
 	}
 
@@ -364,6 +842,10 @@ func printSearchResults(providerName string, results []core.Manga) {
 		_, _ = bulletStyle.Print("  • ")
 		_, _ = titleStyle.Printf("%s ", manga.Title)
 		_, _ = secondaryStyle.Printf("(ID: %s)\n", manga.ID)
+		if manga.MatchedTitle != "" && manga.MatchedTitle != manga.Title {
+			_, _ = infoStyle.Printf("    Matched: ")
+			_, _ = valueStyle.Printf("%s\n", manga.MatchedTitle)
+		}
 
 		if manga.Description != "" {
 			desc := manga.Description
@@ -376,6 +858,154 @@ func printSearchResults(providerName string, results []core.Manga) {
 	fmt.Println()
 }
 
+// printError prints an error that doesn't end the command: to standard output as text, or
+// to standard error as JSON so it doesn't mix with the output
+func printError(eng *engine.Engine, err error) {
+	eng.ReportError(err)
+	if eng.JSONErrors() {
+		_, _ = fmt.Fprintln(os.Stderr, eng.FormatError(err))
+		return
+	}
+	fmt.Println(eng.FormatError(err))
+}
+
+// streamToStdout returns an archive stream on standard output and sends everything else
+// printed to standard error until restore is called
+func streamToStdout(format string) (stream *download.ArchiveStream, restore func(), err error) {
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return nil, nil, errors.New("refusing to write an archive to a terminal").
+			WithMessage("Pipe or redirect the archive, e.g. luminary download <id> -o - > chapter.tar").Error()
+	}
+
+	stream, err = download.NewArchiveStream(os.Stdout, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, color.Error
+	return stream, func() { os.Stdout, color.Output = stdout, output }, nil
+}
+
+// Outcomes of a single chapter in a batch download
+const (
+	downloadSucceeded = "ok"
+	downloadPartial   = "partial"
+	downloadSkipped   = "skipped"
+	downloadFailed    = "failed"
+)
+
+// downloadResult records the outcome of one chapter for the batch summary
+type downloadResult struct {
+	ChapterID string
+	Status    string
+	Detail    string
+}
+
+// printDownloadSummary prints a table of per-chapter outcomes
+func printDownloadSummary(results []downloadResult) {
+	_, _ = sectionStyle.Println("Summary:")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		status := r.Status
+		switch r.Status {
+		case downloadSucceeded:
+			status = successStyle.Sprint("✓ " + status)
+		case downloadPartial:
+			status = warningStyle.Sprint("⚠ " + status)
+		case downloadSkipped:
+			status = warningStyle.Sprint("↷ " + status)
+		case downloadFailed:
+			status = errorStyle.Sprint("✗ " + status)
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", status, r.ChapterID, secondaryStyle.Sprint(r.Detail))
+	}
+	_ = w.Flush()
+}
+
+// readChapterList reads chapter IDs or URLs from a file, or from stdin when path is "-"
+func readChapterList(path string) ([]string, error) {
+	if path == "-" {
+		return readChapterIDs(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("Failed to open chapter list").
+			AsFileSystem().Error()
+	}
+	defer f.Close()
+
+	return readChapterIDs(f)
+}
+
+// readChapterIDs reads chapter IDs or URLs from r, taking the first field of each line
+// so the output of the chapters command can be piped in directly. Lines starting
+// with '#' are comments.
+func readChapterIDs(r io.Reader) ([]string, error) {
+	var ids []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ids = append(ids, fields[0])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Track(err).
+			WithMessage("Failed to read chapter list").
+			AsFileSystem().Error()
+	}
+
+	return ids, nil
+}
+
+// parseSearchFilters turns "field=value" arguments into search filters. The flag parser
+// splits values on commas, so bare entries extend the previous filter's alternatives.
+func parseSearchFilters(args []string) (map[string]string, error) {
+	filters := make(map[string]string)
+	lastKey := ""
+
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			if lastKey == "" {
+				return nil, errors.Newf("invalid filter: %s", arg).
+					WithMessage("Filters must use the format field=value, e.g. demographic=seinen").Error()
+			}
+			filters[lastKey] += "," + arg
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "rating" {
+			key = "content_rating"
+		}
+		switch key {
+		case "demographic", "content_rating", "status":
+		default:
+			return nil, errors.Newf("unsupported filter: %s", key).
+				WithMessage("Supported filters are demographic, content_rating and status").Error()
+		}
+
+		filters[key] = strings.TrimSpace(value)
+		lastKey = key
+	}
+
+	return filters, nil
+}
+
 func filterChaptersByLanguage(chapters []core.ChapterInfo, languages []string) []core.ChapterInfo {
 	var filtered []core.ChapterInfo
 
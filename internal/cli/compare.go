@@ -0,0 +1,165 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewCompareCommand creates the compare command
+func NewCompareCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.NArg() != 2 {
+			return errors.New("two manga IDs are required").
+				WithMessage("Give the same series on two providers, e.g. luminary compare mgd:abc mpk:123").Error()
+		}
+
+		var providers [2]engine.Provider
+		var ids [2]string
+		for i, arg := range c.Args().Slice() {
+			providerID, id, ok := strings.Cut(arg, ":")
+			if !ok || id == "" {
+				return errors.Newf("invalid manga ID format: %s", arg).Error()
+			}
+			provider, err := eng.GetProvider(providerID)
+			if err != nil {
+				return err // Let the ExitErrHandler format this
+			}
+			providers[i], ids[i] = provider, id
+		}
+
+		// Chapters in other languages would make either side look more complete than it is
+		filter := engine.ChapterFilter{From: c.Float("from"), To: c.Float("to")}
+		if lang := c.String("language"); lang != "" {
+			filter.Languages = strings.Split(lang, ",")
+		} else if !c.Bool("all-languages") {
+			filter.Languages = eng.PreferredLanguages()
+		}
+
+		comparison, err := eng.CompareChapters(ctx, providers[0], ids[0], providers[1], ids[1], filter)
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(comparison); err != nil {
+				return errors.Track(err).Error()
+			}
+			return nil
+		}
+
+		printComparison(comparison, c.Bool("all"))
+		return nil
+	}
+}
+
+// printComparison prints the chapters missing from either side, or all with showAll,
+// and which side is more complete
+func printComparison(cmp *engine.ChapterComparison, showAll bool) {
+	left := cmp.Left.Provider + ":" + cmp.Left.MangaID
+	right := cmp.Right.Provider + ":" + cmp.Right.MangaID
+
+	_, _ = headerStyle.Printf("Comparing %s with %s\n", left, right)
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+	// Columns fit the longest chapter label and left cell; fmt pads by runes
+	width, leftWidth := len("Chapter"), max(len(left), len("external"))
+	for _, row := range cmp.Rows {
+		width = max(width, len(row.Chapter))
+		if row.Left != nil {
+			leftWidth = max(leftWidth, utf8.RuneCountInString(comparedCopyText(row.Left)))
+		}
+	}
+
+	_, _ = secondaryStyle.Printf("%-*s  %-*s  %s\n", width, "Chapter", leftWidth, left, right)
+	shown := 0
+	for _, row := range cmp.Rows {
+		if row.Common() && !showAll {
+			continue
+		}
+		shown++
+		_, _ = valueStyle.Printf("%-*s  ", width, row.Chapter)
+		printComparedCopy(row.Left, leftWidth)
+		_, _ = fmt.Print("  ")
+		printComparedCopy(row.Right, 0)
+		if row.Volume != "" && row.Chapter != "Vol."+row.Volume {
+			_, _ = secondaryStyle.Printf("  Vol.%s", row.Volume)
+		}
+		fmt.Println()
+	}
+	if shown == 0 {
+		_, _ = successStyle.Println("Both list the same chapters")
+	}
+
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+	for _, side := range []engine.ComparedSeries{cmp.Left, cmp.Right} {
+		_, _ = titleStyle.Printf("%s ", side.Title)
+		_, _ = secondaryStyle.Printf("(%s:%s) ", side.Provider, side.MangaID)
+		_, _ = infoStyle.Printf("%d chapters", side.Chapters)
+		if side.External > 0 {
+			_, _ = warningStyle.Printf(", %d external only", side.External)
+		}
+		if side.Missing > 0 {
+			_, _ = errorStyle.Printf(", %d missing", side.Missing)
+		}
+		fmt.Println()
+	}
+
+	// Chapters that can only be read on an external site can't be downloaded either
+	leftCount := cmp.Left.Chapters - cmp.Left.External
+	rightCount := cmp.Right.Chapters - cmp.Right.External
+	switch {
+	case leftCount > rightCount:
+		_, _ = successStyle.Printf("%s has %d more chapters to download\n", left, leftCount-rightCount)
+	case rightCount > leftCount:
+		_, _ = successStyle.Printf("%s has %d more chapters to download\n", right, rightCount-leftCount)
+	case shown > 0 && !showAll:
+		_, _ = infoStyle.Println("Both have as many chapters to download, but not the same ones")
+	}
+}
+
+// printComparedCopy prints whether a side has a chapter, padded to width
+func printComparedCopy(chapter *core.ChapterInfo, width int) {
+	switch {
+	case chapter == nil:
+		_, _ = errorStyle.Printf("%-*s", width, "missing")
+	case chapter.ExternalURL != "":
+		_, _ = warningStyle.Printf("%-*s", width, "external")
+	default:
+		_, _ = successStyle.Printf("%-*s", width, comparedCopyText(chapter))
+	}
+}
+
+// comparedCopyText is the cell of a chapter a side has
+func comparedCopyText(chapter *core.ChapterInfo) string {
+	if chapter.ExternalURL != "" {
+		return "external"
+	}
+	return "✓ " + chapter.ID
+}
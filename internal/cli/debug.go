@@ -0,0 +1,285 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/engine/parser/html"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewDebugSelectorCommand creates the debug selector command
+func NewDebugSelectorCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		pageURL := c.String("url")
+		selector := c.String("selector")
+		if pageURL == "" || selector == "" {
+			return errors.New("--url and --selector are required").Error()
+		}
+
+		doc, provider, err := fetchDebugPage(ctx, eng, pageURL, c.String("provider"))
+		if err != nil {
+			return err
+		}
+
+		_, _ = headerStyle.Printf("Selector: ")
+		_, _ = titleStyle.Printf("%s\n", selector)
+		if provider != nil {
+			_, _ = labelStyle.Printf("Fetched via: ")
+			_, _ = valueStyle.Printf("%s\n", provider.Name())
+		}
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+		elements := doc.Select(selector).AllOrEmpty()
+		if len(elements) == 0 {
+			_, _ = warningStyle.Println("No elements matched")
+			return nil
+		}
+
+		limit := c.Int("limit")
+		showHTML := c.Bool("html")
+
+		for i, elem := range elements {
+			if limit > 0 && i >= limit {
+				_, _ = secondaryStyle.Printf("... %d more (use --limit 0 to show all)\n", len(elements)-limit)
+				break
+			}
+
+			_, _ = bulletStyle.Printf("#%d ", i+1)
+			_, _ = highlightStyle.Printf("<%s>", elem.Tag())
+			if text := elem.Extract().CleanText(); text != "" {
+				_, _ = valueStyle.Printf(" %s", truncate(text, 120))
+			}
+			fmt.Println()
+
+			for _, attr := range elem.Attrs() {
+				_, _ = labelStyle.Printf("    %s", attr.Name)
+				_, _ = secondaryStyle.Printf(" = ")
+				_, _ = valueStyle.Printf("%s\n", attr.Value)
+			}
+
+			if showHTML {
+				_, _ = secondaryStyle.Printf("    %s\n", elem.OuterHTML())
+			}
+		}
+
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		_, _ = successStyle.Printf("%d element(s) matched\n", len(elements))
+		return nil
+	}
+}
+
+// NewDebugExtractCommand creates the debug extract command
+func NewDebugExtractCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		pageURL, file := c.String("url"), c.String("file")
+		if (pageURL == "") == (file == "") {
+			return errors.New("exactly one of --url and --file is required").Error()
+		}
+
+		var (
+			body     []byte
+			provider engine.Provider
+			err      error
+		)
+		if file != "" {
+			body, err = os.ReadFile(file)
+			if err != nil {
+				return errors.Track(err).WithContext("file", file).AsFileSystem().Error()
+			}
+			if id := c.String("provider"); id != "" {
+				if provider, err = eng.GetProvider(id); err != nil {
+					return err
+				}
+			}
+		} else if body, provider, err = fetchDebugBody(ctx, eng, pageURL, c.String("provider")); err != nil {
+			return err
+		}
+
+		// Start from the provider's mapping, then apply the overrides
+		endpoint := c.String("endpoint")
+		mapping := base.ResponseMap{Fields: make(map[string]string), Transforms: make(map[string]string)}
+		if bp, ok := provider.(*base.Provider); ok && bp.Config.API != nil {
+			if configured, ok := bp.Config.API.ResponseMapping[endpoint]; ok {
+				mapping.IDField, mapping.TitleField = configured.IDField, configured.TitleField
+				for field, path := range configured.Fields {
+					mapping.Fields[field] = path
+				}
+				for field, chain := range configured.Transforms {
+					mapping.Transforms[field] = chain
+				}
+			}
+		}
+		for _, override := range c.StringSlice("field") {
+			field, path, found := strings.Cut(override, "=")
+			if !found {
+				return errors.Newf("invalid --field %q, expected name=path", override).Error()
+			}
+			switch field = strings.TrimSpace(field); field {
+			case "id":
+				mapping.IDField = path
+			case "title":
+				mapping.TitleField = path
+			default:
+				mapping.Fields[field] = path
+			}
+		}
+		for _, override := range c.StringSlice("transform") {
+			field, chain, found := strings.Cut(override, "=")
+			if !found {
+				return errors.Newf("invalid --transform %q, expected name=transform[|transform]", override).Error()
+			}
+			mapping.Transforms[strings.TrimSpace(field)] = chain
+		}
+
+		report, err := mapping.Validate(body, eng.PreferredLanguages())
+		if err != nil {
+			return err
+		}
+
+		_, _ = headerStyle.Printf("Extraction report")
+		if provider != nil {
+			_, _ = secondaryStyle.Printf(" (%s, %s mapping)", provider.Name(), endpoint)
+		}
+		fmt.Println()
+		_, _ = labelStyle.Printf("Result items: ")
+		_, _ = valueStyle.Printf("%d\n", report.Items)
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+		missing := 0
+		for _, field := range report.Fields {
+			switch {
+			case field.MissingRequired():
+				missing++
+				_, _ = errorStyle.Printf("✗ %s", field.Field)
+			case field.Matched == 0:
+				_, _ = warningStyle.Printf("- %s", field.Field)
+			default:
+				_, _ = successStyle.Printf("✓ %s", field.Field)
+			}
+			_, _ = secondaryStyle.Printf("  %s", field.Path)
+			if field.Transform != "" {
+				_, _ = secondaryStyle.Printf(" | %s", field.Transform)
+			}
+			if field.Required {
+				_, _ = secondaryStyle.Printf("  (required)")
+			}
+			fmt.Println()
+
+			_, _ = labelStyle.Printf("    matched: ")
+			_, _ = valueStyle.Printf("%d", field.Matched)
+			_, _ = labelStyle.Printf("  missing: ")
+			_, _ = valueStyle.Printf("%d\n", field.Missing)
+			for _, sample := range field.Samples {
+				raw, _ := json.Marshal(sample)
+				_, _ = secondaryStyle.Printf("    = %s\n", truncate(string(raw), 120))
+			}
+			if field.Transform != "" {
+				for _, value := range field.Values {
+					raw, _ := json.Marshal(value)
+					_, _ = secondaryStyle.Printf("    → %s\n", truncate(string(raw), 120))
+				}
+			}
+		}
+
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		if missing > 0 {
+			_, _ = errorStyle.Printf("%d required field(s) matched nothing\n", missing)
+		}
+		_, _ = successStyle.Printf("%d manga mapped\n", len(report.Manga))
+		limit := c.Int("limit")
+		for i, manga := range report.Manga {
+			if limit > 0 && i >= limit {
+				_, _ = secondaryStyle.Printf("... %d more\n", len(report.Manga)-i)
+				break
+			}
+			_, _ = bulletStyle.Printf("  • ")
+			_, _ = titleStyle.Printf("%s ", manga.Title)
+			_, _ = secondaryStyle.Printf("(ID: %s)\n", manga.ID)
+		}
+
+		return nil
+	}
+}
+
+// fetchDebugPage fetches and parses a page, using the provider serving its site (or the one
+// given explicitly) so that headers and rate limits match what the scraper sends
+func fetchDebugPage(ctx context.Context, eng *engine.Engine, pageURL, providerID string) (*html.Parser, engine.Provider, error) {
+	body, provider, err := fetchDebugBody(ctx, eng, pageURL, providerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, nil, errors.Track(err).
+			WithContext("url", pageURL).
+			AsParser().Error()
+	}
+
+	return doc, provider, nil
+}
+
+// fetchDebugBody fetches a URL like fetchDebugPage, returning the raw response body
+func fetchDebugBody(ctx context.Context, eng *engine.Engine, pageURL, providerID string) ([]byte, engine.Provider, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return nil, nil, errors.Newf("invalid URL: %s", pageURL).Error()
+	}
+
+	var provider engine.Provider
+	if providerID != "" {
+		if provider, err = eng.GetProvider(providerID); err != nil {
+			return nil, nil, err
+		}
+	} else if p, err := eng.ProviderForURL(u); err == nil {
+		provider = p
+	}
+
+	req := &network.Request{URL: pageURL, Method: "GET"}
+	if requester, ok := provider.(engine.Requester); ok {
+		req = requester.NewRequest(pageURL)
+	}
+
+	eng.Log(ctx).Debug("Debug fetch: url=%s, provider=%v", pageURL, providerID)
+
+	resp, err := eng.Network.Request(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, provider, nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,56 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/errors"
+	"context"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewDedupePruneCommand creates the dedupe prune command
+func NewDedupePruneCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.NArg() == 0 {
+			return errors.New("store directory is required").Error()
+		}
+
+		store, err := download.NewBlobStore(c.Args().First())
+		if err != nil {
+			return err
+		}
+
+		// The store usually sits in the library it serves
+		roots := c.Args().Tail()
+		if len(roots) == 0 {
+			roots = []string{filepath.Dir(store.Dir())}
+		}
+
+		eng.Log(ctx).Debug("Pruning %s against %v", store.Dir(), roots)
+		removed, freed, err := store.Prune(ctx, roots)
+		if err != nil {
+			return err
+		}
+
+		_, _ = successStyle.Printf("Removed %d unused pages from %s, freeing %s\n", removed, store.Dir(), formatBytes(freed))
+		return nil
+	}
+}
@@ -0,0 +1,203 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewHistoryCommand creates the history command
+func NewHistoryCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if eng.Library == nil {
+			return errors.New("download history is not available").
+				WithMessage("Download history requires a home directory to store its records").Error()
+		}
+
+		retry := c.Bool("retry")
+		filter := library.Filter{
+			Provider: c.String("provider"),
+		}
+		if c.Bool("failed") || retry {
+			filter.Status = library.StatusFailed
+		}
+		if since := c.String("since"); since != "" {
+			t, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			filter.Since = t
+		}
+
+		eng.Log(ctx).Debug("History request: filter=%+v, retry=%t", filter, retry)
+
+		records, err := eng.Library.Records(filter)
+		if err != nil {
+			return err
+		}
+
+		// Show the most recent entries
+		if limit := c.Int("limit"); limit > 0 && len(records) > limit {
+			records = records[len(records)-limit:]
+		}
+
+		if len(records) == 0 {
+			_, _ = warningStyle.Println("No downloads recorded")
+			return nil
+		}
+
+		if retry {
+			return retryDownloads(ctx, eng, records)
+		}
+
+		printHistory(records)
+		return nil
+	}
+}
+
+// printHistory prints records as a table, newest last
+func printHistory(records []library.Record) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, labelStyle.Sprint("TIME\tSTATUS\tCHAPTER\tNO.\tLOCATION"))
+
+	for _, r := range records {
+		status := string(r.Status)
+		location := r.Path
+		switch r.Status {
+		case library.StatusCompleted:
+			status = successStyle.Sprint(status)
+		case library.StatusPartial:
+			status = warningStyle.Sprint(status)
+			location = fmt.Sprintf("%s (missing pages %s)", r.Path, joinInts(r.FailedPages))
+			if r.Audit != "" {
+				location += fmt.Sprintf(" (audit %s)", r.Audit)
+			}
+		case library.StatusSkipped:
+			status = warningStyle.Sprint(status)
+			location = r.Error
+		case library.StatusFailed:
+			status = errorStyle.Sprint(status)
+			location = r.Error
+			if r.Audit != "" {
+				location += fmt.Sprintf(" (audit %s)", r.Audit)
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s:%s\t%s\t%s\n",
+			secondaryStyle.Sprint(r.Time.Local().Format("2006-01-02 15:04")),
+			status,
+			r.Provider, r.ChapterID,
+			r.Chapter,
+			location)
+	}
+	_ = w.Flush()
+}
+
+// retryDownloads downloads failed chapters again into their original directories
+func retryDownloads(ctx context.Context, eng *engine.Engine, records []library.Record) error {
+	// A chapter may have failed several times; retry it once
+	seen := make(map[string]bool)
+	failed := 0
+
+	for _, r := range records {
+		key := r.Provider + ":" + r.ChapterID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		provider, err := eng.GetProvider(r.Provider)
+		if err != nil {
+			printError(eng, err)
+			failed++
+			continue
+		}
+
+		// Chapters written to other storage go there again
+		storage, err := download.ParseStorage(r.Storage)
+		if err != nil {
+			printError(eng, err)
+			failed++
+			continue
+		}
+
+		_, _ = infoStyle.Printf("Retrying: ")
+		_, _ = titleStyle.Printf("%s ", key)
+		_, _ = secondaryStyle.Printf("to %s\n", r.OutputDir)
+
+		// Audited downloads stay audited
+		retryCtx := download.WithStorage(ctx, storage)
+		if r.Audit != "" {
+			retryCtx = engine.WithAudit(retryCtx)
+		}
+		if err := eng.DownloadChapter(retryCtx, provider, r.ChapterID, r.OutputDir); err != nil {
+			printError(eng, err)
+			failed++
+			continue
+		}
+
+		_, _ = successStyle.Printf("✓ Chapter %s downloaded successfully\n", key)
+	}
+
+	if failed > 0 {
+		return errors.Newf("%d of %d retries failed", failed, len(seen)).
+			WithMessage("Some chapters could still not be downloaded. See above for details.").Error()
+	}
+	return nil
+}
+
+// parseSince parses a relative age like "7d" or "12h", or a date like "2025-01-31"
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	// time.ParseDuration has no day unit
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, errors.Newf("invalid --since value: %s", value).
+		WithMessage("Use a relative age like 7d or 12h, or a date like 2025-01-31").Error()
+}
+
+// joinInts formats numbers as a comma-separated list
+func joinInts(numbers []int) string {
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
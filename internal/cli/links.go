@@ -0,0 +1,110 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// requireLinks returns an error when linked series can't be stored
+func requireLinks(eng *engine.Engine) error {
+	if eng.Links == nil {
+		return errors.New("linked series are not available").
+			WithMessage("Linked series require a home directory to store them").Error()
+	}
+	return nil
+}
+
+// NewLinkAddCommand creates the link add command
+func NewLinkAddCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireLinks(eng); err != nil {
+			return err
+		}
+		ids := c.Args().Slice()
+		if len(ids) < 2 {
+			return errors.New("at least two series are required").
+				WithMessage("Give the same series on two or more providers, e.g. luminary link add mgd:abc mpk:123").Error()
+		}
+
+		// Series of providers that aren't registered would never be found
+		for _, id := range ids {
+			providerID, _, _ := strings.Cut(id, ":")
+			if _, err := eng.GetProvider(providerID); err != nil {
+				return err
+			}
+		}
+
+		if err := eng.Links.Link(ids...); err != nil {
+			return err
+		}
+		_, _ = successStyle.Printf("Linked %s\n", strings.Join(ids, ", "))
+		_, _ = secondaryStyle.Printf("    Saved to %s\n", eng.Links.Path())
+		return nil
+	}
+}
+
+// NewLinkListCommand creates the link list command
+func NewLinkListCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireLinks(eng); err != nil {
+			return err
+		}
+		groups, err := eng.Links.Groups()
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			_, _ = secondaryStyle.Println("No linked series")
+			return nil
+		}
+
+		for _, group := range groups {
+			_, _ = bulletStyle.Print("• ")
+			_, _ = valueStyle.Printf("%s\n", strings.Join(group, " = "))
+		}
+		return nil
+	}
+}
+
+// NewLinkRemoveCommand creates the link remove command
+func NewLinkRemoveCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireLinks(eng); err != nil {
+			return err
+		}
+		id := c.Args().First()
+		if id == "" {
+			return errors.New("series ID is required").Error()
+		}
+
+		removed, err := eng.Links.Unlink(id)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return errors.Newf("%s is not linked", id).AsNotFound().Error()
+		}
+		_, _ = successStyle.Printf("Unlinked %s\n", id)
+		return nil
+	}
+}
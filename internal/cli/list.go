@@ -0,0 +1,80 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewListCommand creates the list command. It only reads the download history and the
+// saved manga details, so it works offline.
+func NewListCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if eng.Library == nil {
+			return errors.New("download history is not available").
+				WithMessage("The list of series comes from the download history, which requires a home directory").Error()
+		}
+
+		series, err := eng.Library.Series()
+		if err != nil {
+			return err
+		}
+		provider := c.String("provider")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, labelStyle.Sprint("SERIES\tTITLE\tCHAPTERS\tLATEST\tLAST DOWNLOAD"))
+		listed := 0
+		for _, s := range series {
+			if provider != "" && s.Provider != provider {
+				continue
+			}
+
+			// Titles are only known for series whose details were saved
+			title := "-"
+			if info := eng.SavedManga(s.Provider, s.MangaID); info != nil {
+				title = info.Title
+			}
+			latest := "-"
+			if s.Latest > 0 {
+				latest = strconv.FormatFloat(s.Latest, 'g', -1, 64)
+			}
+
+			_, _ = fmt.Fprintf(w, "%s:%s\t%s\t%d\t%s\t%s\n",
+				s.Provider, s.MangaID,
+				title,
+				len(s.Downloaded),
+				latest,
+				secondaryStyle.Sprint(s.LastDownload.Local().Format("2006-01-02 15:04")))
+			listed++
+		}
+
+		if listed == 0 {
+			_, _ = warningStyle.Println("No series downloaded yet")
+			return nil
+		}
+		_ = w.Flush()
+		return nil
+	}
+}
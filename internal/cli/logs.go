@@ -0,0 +1,96 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v3"
+)
+
+// NewLogsCommand creates the logs command, which shows the latest records of the log file
+func NewLogsCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		level, err := logger.ParseLevel(c.String("level"))
+		if err != nil {
+			return errors.Track(err).WithContext("level", c.String("level")).Error()
+		}
+
+		logFile := ""
+		if service, ok := eng.Logger.(*logger.Service); ok {
+			logFile = service.LogFile()
+		}
+		if logFile == "" {
+			return errors.New("logging to a file is disabled").
+				WithMessage("Luminary could not create ~/.luminary/logs, so there is no log to show").
+				AsFileSystem().Error()
+		}
+
+		records, err := logger.ReadTail(logFile, c.Int("count"), level, c.String("module"))
+		if os.IsNotExist(err) {
+			records, err = nil, nil
+		}
+		if err != nil {
+			return errors.Track(err).WithContext("path", logFile).AsFileSystem().Error()
+		}
+
+		if c.Bool("json") {
+			if records == nil {
+				records = []logger.Record{}
+			}
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return errors.Track(err).Error()
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if len(records) == 0 {
+			_, _ = warningStyle.Println("No matching log records")
+			return nil
+		}
+		for _, record := range records {
+			_, _ = secondaryStyle.Printf("%s ", record.Time.Format("2006-01-02 15:04:05.000"))
+			_, _ = levelStyle(record.Level).Printf("%-5s ", record.Level)
+			_, _ = labelStyle.Printf("%s/%s ", record.Module, record.File)
+			fmt.Println(record.Message)
+		}
+		return nil
+	}
+}
+
+// levelStyle returns the color of a log level
+func levelStyle(level string) *color.Color {
+	switch level {
+	case "ERROR":
+		return errorStyle
+	case "WARN":
+		return warningStyle
+	case "INFO":
+		return infoStyle
+	default:
+		return secondaryStyle
+	}
+}
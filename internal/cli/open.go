@@ -0,0 +1,92 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewOpenCommand creates the open command, which shows a manga or chapter on its
+// provider's site in the default browser
+func NewOpenCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.NArg() == 0 {
+			return errors.New("manga or chapter ID is required").Error()
+		}
+
+		ref := c.Args().First()
+		providerID, id, ok := strings.Cut(ref, ":")
+		if !ok || id == "" {
+			return errors.Newf("invalid ID format: %s", ref).
+				WithMessage("IDs must look like provider:manga-id or provider:chapter-id").
+				Error()
+		}
+
+		provider, err := eng.GetProvider(providerID)
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		kind := engine.URLKindManga
+		if c.Bool("chapter") {
+			kind = engine.URLKindChapter
+		}
+		siteURL, err := eng.URLFor(provider, kind, id)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("print") {
+			fmt.Println(siteURL)
+			return nil
+		}
+
+		eng.Log(ctx).Debug("Opening %s in the browser", siteURL)
+		if err := openBrowser(siteURL); err != nil {
+			return errors.Track(err).
+				WithContext("url", siteURL).
+				WithMessagef("Failed to open a browser, the page is at %s", siteURL).
+				Error()
+		}
+
+		_, _ = infoStyle.Printf("Opened ")
+		_, _ = valueStyle.Printf("%s\n", siteURL)
+		return nil
+	}
+}
+
+// openBrowser shows a URL in the default browser of the system
+func openBrowser(rawURL string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+	return cmd.Start()
+}
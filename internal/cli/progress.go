@@ -0,0 +1,108 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine/download"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBarWidth is the number of cells of the progress bar
+const progressBarWidth = 20
+
+// progressLine shows the page progress of the running chapters on one terminal line,
+// which the other output of a download clears first. Chapters downloaded side by side
+// share the line, adding up their pages and speeds.
+type progressLine struct {
+	mu      *sync.Mutex // Guards all output of the download
+	shown   bool
+	running map[string]download.Progress // Latest progress by chapter
+}
+
+// newProgressLine returns a progress line printing under mu, or nil when standard output
+// isn't a terminal
+func newProgressLine(mu *sync.Mutex) *progressLine {
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressLine{mu: mu, running: make(map[string]download.Progress)}
+}
+
+// report returns a progress function drawing the progress of chapterID
+func (l *progressLine) report(chapterID string) download.ProgressFunc {
+	return func(p download.Progress) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.running[chapterID] = p
+
+		fmt.Print("\r\033[K")
+		_, _ = secondaryStyle.Print(l.line())
+		l.shown = true
+	}
+}
+
+// line describes the running chapters: the one chapter, or the totals of all of them
+// with the time the slowest still needs
+func (l *progressLine) line() string {
+	var total download.Progress
+	label := fmt.Sprintf("%d chapters", len(l.running))
+	for chapterID, p := range l.running {
+		if len(l.running) == 1 {
+			label = chapterID
+		}
+		total.Pages += p.Pages
+		total.Done += p.Done
+		total.Failed += p.Failed
+		total.Speed += p.Speed
+		total.ETA = max(total.ETA, p.ETA)
+	}
+
+	handled := total.Done + total.Failed
+	filled := progressBarWidth * handled / max(total.Pages, 1)
+	line := fmt.Sprintf("%s %s%s %d/%d pages", label,
+		strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), handled, total.Pages)
+	if total.Speed > 0 {
+		line += fmt.Sprintf("  %s/s", formatBytes(int64(total.Speed)))
+	}
+	if total.ETA > 0 {
+		line += "  ETA " + total.ETA.Round(time.Second).String()
+	}
+	return line
+}
+
+// finish removes a chapter that stopped downloading from the line, and the line until
+// the next report; the caller holds the output mutex
+func (l *progressLine) finish(chapterID string) {
+	if l == nil {
+		return
+	}
+	delete(l.running, chapterID)
+	l.clear()
+}
+
+// clear removes the progress line; the caller holds the output mutex
+func (l *progressLine) clear() {
+	if l == nil || !l.shown {
+		return
+	}
+	fmt.Print("\r\033[K")
+	l.shown = false
+}
@@ -0,0 +1,211 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/registry"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewProvidersSelfTestCommand creates the providers selftest command
+func NewProvidersSelfTestCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		providers := eng.AllProviders()
+		if c.NArg() > 0 {
+			providers = providers[:0:0]
+			for _, id := range c.Args().Slice() {
+				provider, err := eng.GetProvider(id)
+				if err != nil {
+					return err // Let the ExitErrHandler format this
+				}
+				providers = append(providers, provider)
+			}
+		}
+
+		asJSON := c.Bool("json")
+		if !asJSON {
+			_, _ = headerStyle.Printf("Self-testing %d providers against their sites\n", len(providers))
+			_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		}
+
+		report := eng.SelfTest(ctx, providers)
+
+		if path := c.String("output"); path != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return errors.Track(err).Error()
+			}
+			if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+				return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+			}
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return errors.Track(err).Error()
+			}
+		} else {
+			printSelfTestReport(report)
+		}
+
+		// Sites that are down or block us say nothing about the providers
+		if report.Broken > 0 {
+			return errors.Newf("%d providers look broken", report.Broken).
+				WithMessagef("%d of %d providers look broken; their selectors or endpoints may have to be updated",
+					report.Broken, len(report.Providers)).
+				AsProvider("selftest").Error()
+		}
+		return nil
+	}
+}
+
+// printSelfTestReport prints the outcome of every provider and the totals of a self-test
+func printSelfTestReport(report *engine.SelfTestReport) {
+	for _, result := range report.Providers {
+		var failed *engine.SelfTestCheck
+		var passed []string
+		for i, check := range result.Checks {
+			if check.Status == engine.SelfTestPassed {
+				passed = append(passed, check.Check+": "+check.Detail)
+			} else {
+				failed = &result.Checks[i]
+			}
+		}
+
+		switch result.Status {
+		case engine.SelfTestPassed:
+			_, _ = successStyle.Printf("✓ ")
+		case engine.SelfTestBroken:
+			_, _ = errorStyle.Printf("✗ ")
+		case engine.SelfTestUnreachable:
+			_, _ = warningStyle.Printf("⚠ ")
+		default:
+			_, _ = secondaryStyle.Printf("- ")
+		}
+		_, _ = titleStyle.Printf("%s ", result.Name)
+		_, _ = highlightStyle.Printf("[%s] ", result.Provider)
+		_, _ = valueStyle.Printf("%s\n", result.Status)
+
+		if len(passed) > 0 {
+			_, _ = secondaryStyle.Printf("    %s\n", strings.Join(passed, "; "))
+		}
+		if failed != nil {
+			message := failed.Detail
+			if failed.Error != "" {
+				message = failed.Error
+			}
+			_, _ = secondaryStyle.Printf("    %s: %s\n", failed.Check, message)
+		}
+	}
+
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+	_, _ = successStyle.Printf("%d passed", report.Passed)
+	if report.Broken > 0 {
+		_, _ = errorStyle.Printf(", %d broken", report.Broken)
+	}
+	if report.Unreachable > 0 {
+		_, _ = warningStyle.Printf(", %d unreachable", report.Unreachable)
+	}
+	if report.Skipped > 0 {
+		_, _ = secondaryStyle.Printf(", %d skipped", report.Skipped)
+	}
+	_, _ = secondaryStyle.Printf(" in %s\n", formatDuration(report.Finished.Sub(report.Started)))
+}
+
+// NewAddMadaraCommand creates the provider add-madara command
+func NewAddMadaraCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		site := registry.Site{
+			ID:          c.String("id"),
+			Name:        c.String("name"),
+			Description: c.String("description"),
+			URL:         c.String("url"),
+			AjaxSearch:  c.Bool("ajax-search"),
+		}
+
+		for _, arg := range c.StringSlice("selector") {
+			name, selector, ok := strings.Cut(arg, "=")
+			if !ok || name == "" || selector == "" {
+				return errors.Newf("invalid selector %q", arg).
+					WithMessage("Selectors are given as name=css, e.g. --selector \"search=div.post-title h3 a\"").
+					Error()
+			}
+			if site.Selectors == nil {
+				site.Selectors = make(map[string]string)
+			}
+			site.Selectors[name] = selector
+		}
+
+		path, err := registry.SitesPath()
+		if err != nil {
+			return err
+		}
+
+		eng.Log(ctx).Debug("Adding Madara site %s (%s) to %s", site.ID, site.URL, path)
+		if err := registry.AddSite(eng, path, site); err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		_, _ = successStyle.Printf("Added provider ")
+		_, _ = highlightStyle.Printf("[%s] ", site.ID)
+		_, _ = titleStyle.Printf("%s\n", site.Name)
+		_, _ = secondaryStyle.Printf("    Saved to %s\n", path)
+		return nil
+	}
+}
+
+// NewAddOPDSCommand creates the provider add-opds command
+func NewAddOPDSCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		site := registry.Site{
+			Type:        registry.SiteOPDS,
+			ID:          c.String("id"),
+			Name:        c.String("name"),
+			Description: c.String("description"),
+			URL:         c.String("url"),
+			Username:    c.String("username"),
+		}
+
+		path, err := registry.SitesPath()
+		if err != nil {
+			return err
+		}
+
+		eng.Log(ctx).Debug("Adding OPDS catalog %s (%s) to %s", site.ID, site.URL, path)
+		if err := registry.AddSite(eng, path, site); err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		_, _ = successStyle.Printf("Added provider ")
+		_, _ = highlightStyle.Printf("[%s] ", site.ID)
+		_, _ = titleStyle.Printf("%s\n", site.Name)
+		_, _ = secondaryStyle.Printf("    Saved to %s\n", path)
+		if site.Username != "" && os.Getenv(site.PasswordVar()) == "" {
+			_, _ = secondaryStyle.Printf("    Set %s to the password before using it\n", site.PasswordVar())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,367 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// requireQueue returns an error when the download queue can't be stored
+func requireQueue(eng *engine.Engine) error {
+	if eng.Queue == nil {
+		return errors.New("download queue is not available").
+			WithMessage("The download queue requires a home directory to store it").Error()
+	}
+	return nil
+}
+
+// NewQueueAddCommand creates the queue add command
+func NewQueueAddCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		refs := c.Args().Slice()
+		fromFile := c.String("from-file")
+		if c.Bool("stdin") {
+			fromFile = "-"
+		}
+		if fromFile != "" {
+			ids, err := readChapterList(fromFile)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ids...)
+		}
+		if len(refs) == 0 {
+			return errors.New("chapter ID is required").Error()
+		}
+
+		// Chapters of providers that aren't registered here would fail on every machine
+		for _, ref := range refs {
+			if _, _, err := eng.ResolveChapter(ref); err != nil {
+				return err
+			}
+		}
+
+		added, err := eng.Queue.Add(refs...)
+		if err != nil {
+			return err
+		}
+		chapters, err := eng.Queue.Chapters()
+		if err != nil {
+			return err
+		}
+		_, _ = successStyle.Printf("Queued %d chapter(s)", added)
+		if skipped := len(refs) - added; skipped > 0 {
+			_, _ = secondaryStyle.Printf(" (%d already queued)", skipped)
+		}
+		fmt.Println()
+		_, _ = secondaryStyle.Printf("    %d chapter(s) in %s\n", len(chapters), eng.Queue.Path())
+		return nil
+	}
+}
+
+// NewQueueListCommand creates the queue list command
+func NewQueueListCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		chapters, err := eng.Queue.Chapters()
+		if err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			_, _ = secondaryStyle.Println("The download queue is empty")
+			return nil
+		}
+
+		for _, chapter := range chapters {
+			_, _ = bulletStyle.Print("• ")
+			_, _ = valueStyle.Printf("%s", chapter.Ref)
+			_, _ = secondaryStyle.Printf("  added %s\n", chapter.Added.Format("2006-01-02 15:04"))
+		}
+		_, _ = infoStyle.Printf("%d chapter(s) queued\n", len(chapters))
+		return nil
+	}
+}
+
+// NewQueueRemoveCommand creates the queue remove command
+func NewQueueRemoveCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		refs := c.Args().Slice()
+		if len(refs) == 0 {
+			return errors.New("chapter ID is required").Error()
+		}
+
+		removed, err := eng.Queue.Remove(refs...)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return errors.New("none of the chapters are queued").AsNotFound().Error()
+		}
+		_, _ = successStyle.Printf("Removed %d chapter(s) from the queue\n", removed)
+		return nil
+	}
+}
+
+// NewQueueClearCommand creates the queue clear command
+func NewQueueClearCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		if err := eng.Queue.Clear(); err != nil {
+			return err
+		}
+		_, _ = successStyle.Println("Cleared the download queue")
+		return nil
+	}
+}
+
+// NewQueueExportCommand creates the queue export command, which writes the queue as a
+// signed job file for 'luminary queue import' on another machine
+func NewQueueExportCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		path := c.Args().First()
+		if path == "" {
+			return errors.New("job file is required").
+				WithMessage("Give the file to write, e.g. luminary queue export job.lum ('-' for standard output)").Error()
+		}
+		chapters, err := eng.Queue.Chapters()
+		if err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			return errors.New("the download queue is empty").
+				WithMessage("Queue chapters with 'luminary queue add' first").Error()
+		}
+
+		key, keyPath, created, err := jobKey(true)
+		if err != nil {
+			return err
+		}
+
+		job := library.Job{
+			Created: time.Now(),
+			Options: library.JobOptions{
+				Format:    c.String("format"),
+				Process:   c.String("process"),
+				Layout:    c.String("layout"),
+				Collision: c.String("collision"),
+				Numbering: c.String("numbering"),
+			},
+		}
+		job.Host, _ = os.Hostname()
+		for _, chapter := range chapters {
+			job.Chapters = append(job.Chapters, chapter.Ref)
+		}
+
+		data, err := library.EncodeJob(job, key)
+		if err != nil {
+			return err
+		}
+		if path == "-" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+		}
+
+		if c.Bool("clear") {
+			if err := eng.Queue.Clear(); err != nil {
+				return err
+			}
+		}
+
+		// With the job on standard output the notes go to stderr
+		out, destination := os.Stdout, path
+		if path == "-" {
+			out, destination = os.Stderr, "standard output"
+		}
+		_, _ = successStyle.Fprintf(out, "Exported %d chapter(s) to %s\n", len(job.Chapters), destination)
+		if created {
+			_, _ = warningStyle.Fprintf(out, "Created the signing key %s; copy it to the machines running the job\n", keyPath)
+		} else if keyPath != "" {
+			_, _ = secondaryStyle.Fprintf(out, "    Signed with %s\n", keyPath)
+		}
+		return nil
+	}
+}
+
+// NewQueueImportCommand creates the queue import command, which checks the signature of
+// a job file and downloads its chapters, or queues them with --queue
+func NewQueueImportCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		path := c.Args().First()
+		if path == "" {
+			return errors.New("job file is required").
+				WithMessage("Give the job file to import, e.g. luminary queue import job.lum ('-' for standard input)").Error()
+		}
+		job, err := readJob(path)
+		if err != nil {
+			return err
+		}
+		if len(job.Chapters) == 0 {
+			_, _ = secondaryStyle.Println("The job has no chapters")
+			return nil
+		}
+
+		_, _ = infoStyle.Printf("Job of %d chapter(s)", len(job.Chapters))
+		if job.Host != "" {
+			_, _ = secondaryStyle.Printf(" from %s", job.Host)
+		}
+		_, _ = secondaryStyle.Printf(", created %s\n", job.Created.Format("2006-01-02 15:04"))
+
+		if c.Bool("queue") {
+			if err := requireQueue(eng); err != nil {
+				return err
+			}
+			added, err := eng.Queue.Add(job.Chapters...)
+			if err != nil {
+				return err
+			}
+			_, _ = successStyle.Printf("Queued %d chapter(s)\n", added)
+			return nil
+		}
+
+		if err := applyJobOptions(c, job.Options); err != nil {
+			return err
+		}
+		_, err = downloadChapters(ctx, eng, c, job.Chapters)
+		return err
+	}
+}
+
+// NewQueueRunCommand creates the queue run command, which downloads the queued chapters.
+// Chapters that didn't fail leave the queue, so running it again retries the others.
+func NewQueueRunCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if err := requireQueue(eng); err != nil {
+			return err
+		}
+		chapters, err := eng.Queue.Chapters()
+		if err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			_, _ = secondaryStyle.Println("The download queue is empty")
+			return nil
+		}
+
+		refs := make([]string, len(chapters))
+		for i, chapter := range chapters {
+			refs[i] = chapter.Ref
+		}
+		results, err := downloadChapters(ctx, eng, c, refs)
+
+		var done []string
+		for _, result := range results {
+			if result.Status != downloadFailed {
+				done = append(done, result.ChapterID)
+			}
+		}
+		if len(done) > 0 {
+			if _, removeErr := eng.Queue.Remove(done...); removeErr != nil {
+				return removeErr
+			}
+		}
+		return err
+	}
+}
+
+// jobKey returns the key job files are signed with: LUMINARY_JOB_KEY, else the key file,
+// generated if create is set and there is none. Keys aren't taken as flags, which would
+// leave them in the shell history and process list. The path is empty for a key from the
+// environment; created reports a generated key.
+func jobKey(create bool) (key []byte, path string, created bool, err error) {
+	if k := os.Getenv("LUMINARY_JOB_KEY"); k != "" {
+		return []byte(k), "", false, nil
+	}
+	path, err = library.JobKeyPath()
+	if err != nil {
+		return nil, "", false, err
+	}
+	_, statErr := os.Stat(path)
+	key, err = library.LoadJobKey(path, create)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return key, path, os.IsNotExist(statErr), nil
+}
+
+// readJob reads and verifies a job file, from stdin when path is "-"
+func readJob(path string) (*library.Job, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("Failed to read the job file").
+			AsFileSystem().Error()
+	}
+
+	key, _, _, err := jobKey(false)
+	if err != nil {
+		return nil, err
+	}
+	return library.DecodeJob(data, key)
+}
+
+// applyJobOptions sets the download flags from the settings of a job, leaving flags
+// given on the command line as they are
+func applyJobOptions(c *cli.Command, options library.JobOptions) error {
+	for name, value := range map[string]string{
+		"format":    options.Format,
+		"process":   options.Process,
+		"layout":    options.Layout,
+		"collision": options.Collision,
+		"numbering": options.Numbering,
+	} {
+		if value == "" || c.IsSet(name) {
+			continue
+		}
+		if err := c.Set(name, value); err != nil {
+			return errors.Track(err).WithMessagef("The job's %s setting %q is not valid", name, value).Error()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewStatsCommand creates the stats command
+func NewStatsCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if eng.Library == nil {
+			return errors.New("library is not available").
+				WithMessage("Library statistics require a home directory to store download records").Error()
+		}
+
+		stats, err := eng.Library.Stats(c.Int("top"))
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				return errors.Track(err).Error()
+			}
+			return nil
+		}
+
+		_, _ = headerStyle.Println("Library")
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+		_, _ = labelStyle.Printf("Series: ")
+		_, _ = valueStyle.Printf("%d\n", stats.Series)
+		_, _ = labelStyle.Printf("Chapters: ")
+		_, _ = valueStyle.Printf("%d\n", stats.Chapters)
+		_, _ = labelStyle.Printf("Size on disk: ")
+		_, _ = valueStyle.Printf("%s\n", formatBytes(stats.Bytes))
+
+		if len(stats.Providers) > 0 {
+			fmt.Println()
+			_, _ = sectionStyle.Println("By provider:")
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, labelStyle.Sprint("  PROVIDER\tSERIES\tCHAPTERS\tSIZE"))
+			for _, ps := range stats.Providers {
+				name := ps.Provider
+				if p := eng.GetProviderOrNil(ps.Provider); p != nil {
+					name = p.Name()
+				}
+				_, _ = fmt.Fprintf(w, "  %s\t%d\t%d\t%s\n", name, ps.Series, ps.Chapters, formatBytes(ps.Bytes))
+			}
+			_ = w.Flush()
+		}
+
+		if len(stats.TopSeries) > 0 {
+			fmt.Println()
+			_, _ = sectionStyle.Println("Most downloaded series:")
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, labelStyle.Sprint("  SERIES\tCHAPTERS\tSIZE"))
+			for _, ss := range stats.TopSeries {
+				_, _ = fmt.Fprintf(w, "  %s:%s\t%d\t%s\n", ss.Provider, ss.MangaID, ss.Chapters, formatBytes(ss.Bytes))
+			}
+			_ = w.Flush()
+		}
+
+		return nil
+	}
+}
+
+// printFootprint prints to stderr what a command asked of each provider: its requests,
+// the lookups the cache answered instead and how long the rate limits held it back
+func printFootprint(f *network.Footprint) {
+	total := f.Total()
+	if total.Requests == 0 && total.CacheHits == 0 {
+		return
+	}
+
+	_, _ = secondaryStyle.Fprintf(os.Stderr, "Sent %d requests (%s), %d answered from the cache", total.Requests,
+		formatBytes(total.Bytes), total.CacheHits)
+	if total.Waits > 0 {
+		_, _ = secondaryStyle.Fprintf(os.Stderr, ", waited %s for rate limits", formatDuration(total.Waited))
+	}
+	if total.Throttled > 0 {
+		_, _ = warningStyle.Fprintf(os.Stderr, ", slowed down %d times", total.Throttled)
+	}
+	if limit := f.Limit(); limit > 0 {
+		_, _ = secondaryStyle.Fprintf(os.Stderr, " of a budget of %d", limit)
+	}
+	_, _ = fmt.Fprintln(os.Stderr)
+
+	sources := f.Sources()
+	if len(sources) < 2 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, labelStyle.Sprint("  SOURCE\tREQUESTS\tSIZE\tCACHED\tWAITED\tSLOWED DOWN"))
+	for _, s := range sources {
+		_, _ = fmt.Fprintf(w, "  %s\t%d\t%s\t%d\t%s\t%d\n", s.Source, s.Requests, formatBytes(s.Bytes), s.CacheHits,
+			formatDuration(s.Waited), s.Throttled)
+	}
+	_ = w.Flush()
+}
+
+// formatBytes formats a byte count in a human-readable form
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,149 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewSyncCommand creates the sync command
+func NewSyncCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		opts := engine.SyncOptions{
+			FillGaps:     c.Bool("fill-gaps"),
+			Latest:       c.Int("latest"),
+			Providers:    c.StringSlice("providers"),
+			AllLanguages: c.Bool("all-languages"),
+			DryRun:       c.Bool("dry-run"),
+		}
+		if groups := c.String("prefer-group"); groups != "" {
+			opts.Prefer = &engine.ChapterPreference{Groups: strings.Split(groups, ",")}
+		}
+		if c.Bool("failover") {
+			ctx = engine.WithFailover(ctx)
+		}
+
+		// JSON output is the summary alone, for scripts and timers
+		asJSON := c.Bool("json")
+		if !asJSON {
+			_, _ = headerStyle.Printf("Syncing followed series\n")
+			_, _ = dividerColor.Println(strings.Repeat("─", 50))
+			opts.OnSeries = printSyncResult
+		}
+
+		summary, err := eng.Sync(ctx, opts)
+		if summary == nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if encErr := enc.Encode(summary); encErr != nil {
+				return errors.Track(encErr).Error()
+			}
+		} else {
+			printSyncSummary(summary)
+		}
+
+		if err != nil {
+			return err
+		}
+		if failures := summary.Failures(); failures > 0 {
+			return errors.Newf("sync finished with %d failures", failures).
+				WithMessagef("Sync finished with %d failed series or chapters", failures).
+				AsDownload().Error()
+		}
+		return nil
+	}
+}
+
+// printSyncResult prints the outcome of a series and its chapters
+func printSyncResult(r engine.SyncResult) {
+	name := r.Title
+	if name == "" {
+		name = r.Provider + ":" + r.MangaID
+	}
+
+	switch {
+	case r.Error != "" && len(r.Records) == 0:
+		_, _ = errorStyle.Printf("✗ %s ", name)
+		_, _ = secondaryStyle.Printf("%s\n", r.Error)
+		return
+	case len(r.Missing) == 0:
+		_, _ = successStyle.Printf("✓ ")
+		_, _ = titleStyle.Printf("%s ", name)
+		_, _ = secondaryStyle.Printf("(%s:%s) up to date\n", r.Provider, r.MangaID)
+		return
+	}
+
+	_, _ = infoStyle.Printf("● ")
+	_, _ = titleStyle.Printf("%s ", name)
+	_, _ = secondaryStyle.Printf("(%s:%s) %d new: %s\n", r.Provider, r.MangaID, len(r.Missing), strings.Join(r.Missing, ", "))
+	for _, record := range r.Records {
+		switch record.Status {
+		case library.StatusCompleted:
+			_, _ = successStyle.Printf("    ✓ Chapter %s\n", record.Chapter)
+		case library.StatusPartial:
+			_, _ = warningStyle.Printf("    ⚠ Chapter %s without pages %s\n", record.Chapter, joinInts(record.FailedPages))
+		case library.StatusSkipped:
+			_, _ = warningStyle.Printf("    ↷ Chapter %s skipped: %s\n", record.Chapter, record.Error)
+		default:
+			_, _ = errorStyle.Printf("    ✗ Chapter %s failed: %s\n", record.Chapter, record.Error)
+		}
+	}
+}
+
+// printSyncSummary prints the totals of a sync
+func printSyncSummary(s *engine.SyncSummary) {
+	_, _ = dividerColor.Println(strings.Repeat("─", 50))
+	if len(s.Series) == 0 {
+		_, _ = warningStyle.Println("No series to sync; the library has no downloads with a known manga yet")
+		return
+	}
+
+	_, _ = infoStyle.Printf("Checked %d series in %s, %d new chapters", s.Checked,
+		formatDuration(s.Finished.Sub(s.Started)), s.Missing)
+	if s.DryRun {
+		fmt.Println(" (dry run, nothing downloaded)")
+		return
+	}
+	fmt.Println()
+	_, _ = successStyle.Printf("%d downloaded", s.Downloaded)
+	if s.Partial > 0 {
+		_, _ = warningStyle.Printf(", %d partial", s.Partial)
+	}
+	if s.Skipped > 0 {
+		_, _ = warningStyle.Printf(", %d skipped", s.Skipped)
+	}
+	if s.Errors > 0 {
+		_, _ = errorStyle.Printf(", %d failed", s.Errors)
+	}
+	if s.Failed > 0 {
+		_, _ = errorStyle.Printf(", %d series failed", s.Failed)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,91 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewTagsCommand creates the tags command, listing a provider's tags or the manga of one
+func NewTagsCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.NArg() == 0 {
+			return errors.New("provider ID is required").Error()
+		}
+
+		p, err := eng.GetProvider(c.Args().First())
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		browser, ok := p.(engine.TagBrowser)
+		if !ok {
+			return errors.Newf("provider %s does not support tag browsing", p.ID()).AsProvider(p.ID()).Error()
+		}
+
+		if c.NArg() < 2 {
+			tags, err := eng.GetTags(ctx, browser, p.ID())
+			if err != nil {
+				return err // Let the ExitErrHandler format this
+			}
+			printTags(p.Name(), tags)
+			return nil
+		}
+
+		tagID := c.Args().Get(1)
+		eng.Log(ctx).Debug("Browsing tag %s of %s, limit=%d", tagID, p.ID(), c.Int("limit"))
+
+		results, err := eng.BrowseTag(ctx, browser, p.ID(), tagID, core.SearchOptions{Limit: c.Int("limit")})
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		_, _ = headerStyle.Printf("Browsing tag: ")
+		_, _ = titleStyle.Printf("%s\n", tagID)
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		printSearchResults(p.Name(), results)
+		return nil
+	}
+}
+
+func printTags(providerName string, tags []core.Tag) {
+	if len(tags) == 0 {
+		_, _ = secondaryStyle.Printf("[%s] ", providerName)
+		_, _ = warningStyle.Print("No tags found\n")
+		return
+	}
+
+	_, _ = sectionStyle.Printf("[%s] ", providerName)
+	_, _ = titleStyle.Printf("%d tags:\n", len(tags))
+
+	for _, tag := range tags {
+		_, _ = bulletStyle.Print("  • ")
+		_, _ = titleStyle.Printf("%s ", tag.Name)
+		_, _ = secondaryStyle.Printf("(ID: %s)", tag.ID)
+		if tag.Count > 0 {
+			_, _ = valueStyle.Printf(" %d manga", tag.Count)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,124 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// NewVerifyCommand creates the verify command
+func NewVerifyCommand(eng *engine.Engine) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.NArg() == 0 {
+			return errors.New("a path or 'library' is required").Error()
+		}
+
+		target := c.Args().First()
+		repair := c.Bool("repair")
+
+		_, _ = headerStyle.Printf("Verifying: ")
+		_, _ = titleStyle.Printf("%s\n", target)
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+
+		var checked, broken, repaired, unverified int
+		report := func(check *engine.ChapterCheck) {
+			checked++
+			if check.Unverified {
+				unverified++
+			} else if len(check.Repaired) > 0 && check.OK() {
+				repaired++
+			} else if !check.OK() {
+				broken++
+			}
+			printChapterCheck(check)
+		}
+
+		var err error
+		if target == "library" {
+			err = eng.VerifyLibrary(ctx, repair, report)
+		} else {
+			err = eng.VerifyPath(ctx, target, repair, report)
+		}
+		if err != nil {
+			return err // Let the ExitErrHandler format this
+		}
+
+		_, _ = dividerColor.Println(strings.Repeat("─", 50))
+		_, _ = infoStyle.Printf("%d chapter(s) checked", checked)
+		if repaired > 0 {
+			_, _ = successStyle.Printf(", %d repaired", repaired)
+		}
+		if broken > 0 {
+			_, _ = errorStyle.Printf(", %d damaged", broken)
+		}
+		if unverified > 0 {
+			_, _ = warningStyle.Printf(", %d unverified", unverified)
+		}
+		fmt.Println()
+
+		if broken > 0 {
+			if !repair {
+				return errors.Newf("%d damaged chapter(s)", broken).
+					WithMessage("Some chapters are missing pages or have corrupt ones; run again with --repair to fix them").Error()
+			}
+			return errors.Newf("%d chapter(s) could not be repaired", broken).
+				WithMessage("Some chapters are still damaged. See above for details.").Error()
+		}
+		return nil
+	}
+}
+
+// printChapterCheck prints one line per verified chapter
+func printChapterCheck(check *engine.ChapterCheck) {
+	name := check.Dir
+	if check.Provider != "" && check.ChapterID != "" {
+		name = fmt.Sprintf("%s:%s", check.Provider, check.ChapterID)
+	}
+
+	switch {
+	case check.Error != "":
+		_, _ = errorStyle.Printf("✗ %s: %s\n", name, check.Error)
+	case !check.OK():
+		_, _ = errorStyle.Printf("✗ %s", name)
+		if len(check.Missing) > 0 {
+			_, _ = warningStyle.Printf("  missing pages %s", joinInts(check.Missing))
+		}
+		if len(check.Corrupt) > 0 {
+			_, _ = warningStyle.Printf("  corrupt pages %s", joinInts(check.Corrupt))
+		}
+		fmt.Println()
+	case check.Unverified:
+		_, _ = warningStyle.Printf("? %s", name)
+		_, _ = secondaryStyle.Printf("  archive without a manifest; its pages weren't checked\n")
+	case len(check.Repaired) > 0:
+		_, _ = successStyle.Printf("✓ %s", name)
+		_, _ = valueStyle.Printf("  repaired pages %s\n", joinInts(check.Repaired))
+	default:
+		_, _ = successStyle.Printf("✓ %s", name)
+		_, _ = secondaryStyle.Printf("  %d pages\n", check.Pages)
+	}
+
+	if check.NoManifest {
+		_, _ = secondaryStyle.Printf("    No manifest; only the page count of %s was checked\n", check.Dir)
+	}
+}
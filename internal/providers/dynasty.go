@@ -0,0 +1,320 @@
+package providers
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"Luminary/pkg/provider/common"
+	"Luminary/pkg/provider/registry"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type DysTag struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Permalink string `json:"permalink"`
+}
+
+type DysSeriesResp struct {
+	Name        string   `json:"name"`
+	Permalink   string   `json:"permalink"`
+	Aliases     []string `json:"aliases"`
+	Description string   `json:"description"`
+	Cover       string   `json:"cover"`
+	Status      string   `json:"status"`
+	Tags        []DysTag `json:"tags"`
+	// Taggings lists the chapters in reading order, with volume headers between them
+	Taggings []struct {
+		Header     string   `json:"header,omitempty"`
+		Title      string   `json:"title,omitempty"`
+		Permalink  string   `json:"permalink,omitempty"`
+		ReleasedOn string   `json:"released_on,omitempty"`
+		Tags       []DysTag `json:"tags,omitempty"`
+	} `json:"taggings"`
+}
+
+type DysChapterResp struct {
+	Title      string   `json:"title"`
+	LongTitle  string   `json:"long_title"`
+	Permalink  string   `json:"permalink"`
+	ReleasedOn string   `json:"released_on"`
+	Tags       []DysTag `json:"tags"`
+	Series     string   `json:"series,omitempty"`
+	Pages      []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"pages"`
+}
+
+// Validate checks that a series response carries the series
+func (r DysSeriesResp) Validate() error {
+	if r.Permalink == "" {
+		return errors.New("missing permalink").Error()
+	}
+	return nil
+}
+
+// Validate checks that a chapter response carries the chapter
+func (r DysChapterResp) Validate() error {
+	if r.Permalink == "" {
+		return errors.New("missing permalink").Error()
+	}
+	return nil
+}
+
+// Register the provider automatically on startup
+func init() {
+	registry.Register(NewDynastyProvider)
+}
+
+// NewDynastyProvider creates the Dynasty Scans provider. Series and chapters are read from
+// the JSON the site serves for every page with a .json suffix; IDs are their permalinks.
+func NewDynastyProvider(e *engine.Engine) engine.Provider {
+	b := base.New(e, base.Config{
+		ID:          "dys",
+		Name:        "Dynasty Scans",
+		Description: "Yuri manga and doujinshi scanlations",
+		SiteURL:     "https://dynasty-scans.com",
+		Type:        base.TypeWeb,
+
+		Web: &base.WebConfig{
+			MangaPath: "/series/{id}",
+		},
+
+		Headers: map[string]string{
+			"User-Agent": "Luminary/1.0 (https://github.com/lumisxh/luminary)",
+			"Referer":    "https://dynasty-scans.com/",
+		},
+
+		RateLimit: 1 * time.Second,
+
+		SelfTest: &engine.SelfTestFixture{Query: "citrus", MangaID: "citrus"},
+	})
+
+	p := b.Build().(*base.Provider)
+	return b.WithSearch(customDynastySearch(p)).
+		WithGetManga(customDynastyGetManga(p)).
+		WithGetChapter(customDynastyGetChapter(p)).
+		WithResolveChapterURL(resolveDynastyChapterURL).
+		WithResolveMangaURL(resolveDynastyMangaURL).
+		WithURLFor(dynastyURLFor).
+		Build()
+}
+
+// customDynastySearch searches series. The search has no JSON variant, so its result
+// list is scraped.
+func customDynastySearch(p *base.Provider) func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+	return func(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+		pages := max(options.Pages, 1)
+
+		var results []core.Manga
+		for page := 1; page <= pages; page++ {
+			searchURL := fmt.Sprintf("%s/search?q=%s&classes[]=Series&page=%d", p.Config.SiteURL, url.QueryEscape(query), page)
+			resp, err := p.Engine.Network.Request(ctx, p.NewRequest(searchURL))
+			if err != nil {
+				return nil, errors.TP(err, p.ID())
+			}
+			doc, err := resp.HTML()
+			if err != nil {
+				return nil, errors.Track(err).WithContext("provider_id", p.ID()).AsParser().Error()
+			}
+
+			links := doc.Select(".chapter-list dd a.name").AllOrEmpty()
+			for _, link := range links {
+				id := dynastySeriesID(link.Extract().Href())
+				if id == "" {
+					continue
+				}
+				results = append(results, core.Manga{ID: id, Title: link.Extract().CleanText()})
+
+				if options.Limit > 0 && len(results) >= options.Limit {
+					return results, nil
+				}
+			}
+
+			// A page without a next page link is the last one
+			if len(links) == 0 || !doc.Select(".pagination a[rel=next]").Exists() {
+				break
+			}
+		}
+
+		return results, nil
+	}
+}
+
+// customDynastyGetManga reads a series and its chapters
+func customDynastyGetManga(p *base.Provider) func(context.Context, string) (*core.MangaInfo, error) {
+	return func(ctx context.Context, id string) (*core.MangaInfo, error) {
+		req := p.NewRequest(fmt.Sprintf("%s/series/%s.json", p.Config.SiteURL, id))
+		req.Endpoint = "series"
+		resp, err := p.Engine.Network.Request(ctx, req)
+		if err != nil {
+			return nil, errors.Track(err).WithContext("manga_id", id).AsProvider(p.ID()).Error()
+		}
+		var series DysSeriesResp
+		if err := resp.JSON(&series); err != nil {
+			return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+		}
+
+		info := &core.MangaInfo{Manga: core.Manga{ID: id, Title: series.Name}}
+		info.AlternativeTitles = series.Aliases
+		info.Status = strings.ToLower(series.Status)
+		if series.Cover != "" {
+			info.CoverURL = p.Config.SiteURL + series.Cover
+		}
+		// Descriptions are HTML, which the engine converts
+		info.Description = series.Description
+		for _, tag := range series.Tags {
+			switch tag.Type {
+			case "Author":
+				info.Authors = append(info.Authors, tag.Name)
+			case "Status":
+				if info.Status == "" {
+					info.Status = strings.ToLower(tag.Name)
+				}
+			case "General":
+				info.Tags = append(info.Tags, tag.Name)
+			}
+		}
+		info.Demographic = common.InferDemographic(info.Tags)
+		info.ContentRating = common.InferContentRating(info.Tags)
+		// Everything on the site is an English scanlation
+		info.AvailableLanguages = []string{"en"}
+
+		var volume string
+		for _, tagging := range series.Taggings {
+			if tagging.Header != "" {
+				volume = strings.TrimSpace(strings.TrimPrefix(tagging.Header, "Volume"))
+				continue
+			}
+			if tagging.Permalink == "" {
+				continue
+			}
+			chapter := mapDynastyChapter(p, tagging.Permalink, tagging.Title, tagging.ReleasedOn, tagging.Tags)
+			chapter.Volume = volume
+			chapter.Sequence = len(info.Chapters) + 1
+			info.Chapters = append(info.Chapters, chapter)
+		}
+
+		return info, nil
+	}
+}
+
+// customDynastyGetChapter reads the pages of a chapter
+func customDynastyGetChapter(p *base.Provider) func(context.Context, string) (*core.Chapter, error) {
+	return func(ctx context.Context, chapterID string) (*core.Chapter, error) {
+		req := p.NewRequest(fmt.Sprintf("%s/chapters/%s.json", p.Config.SiteURL, chapterID))
+		req.Endpoint = "chapter"
+		resp, err := p.Engine.Network.Request(ctx, req)
+		if err != nil {
+			return nil, errors.Track(err).WithContext("chapter_id", chapterID).AsProvider(p.ID()).Error()
+		}
+		var data DysChapterResp
+		if err := resp.JSON(&data); err != nil {
+			return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+		}
+
+		chapter := &core.Chapter{
+			Info: mapDynastyChapter(p, data.Permalink, data.Title, data.ReleasedOn, data.Tags),
+		}
+		// Chapters of a series carry it as a tag; oneshots and anthology entries have none
+		for _, tag := range data.Tags {
+			if tag.Type == "Series" {
+				chapter.MangaID = tag.Permalink
+			}
+		}
+		for i, page := range data.Pages {
+			chapter.Pages = append(chapter.Pages, core.Page{
+				Index:    i,
+				URL:      p.Config.SiteURL + page.URL,
+				Filename: page.Name,
+			})
+		}
+		if len(chapter.Pages) == 0 {
+			return nil, errors.Newf("no pages found for chapter %s", chapterID).
+				WithContext("chapter_id", chapterID).
+				AsProvider(p.ID()).Error()
+		}
+		chapter.Info.PageCount = len(chapter.Pages)
+
+		return chapter, nil
+	}
+}
+
+// mapDynastyChapter maps a chapter of a series or of the chapter endpoint; the number
+// comes from titles like "Chapter 12.5: Name" and the group from the scanlator tags
+func mapDynastyChapter(p *base.Provider, permalink, title, releasedOn string, tags []DysTag) core.ChapterInfo {
+	chapter := core.ChapterInfo{
+		ID:       permalink,
+		Title:    title,
+		Language: "en",
+		Date:     common.ParseDate(releasedOn),
+	}
+	// Named chapters look like "Chapter 3: The Title"
+	name, rest, named := strings.Cut(title, ":")
+	if named && strings.TrimSpace(rest) != "" {
+		chapter.Title = strings.TrimSpace(rest)
+	}
+	chapter.Label = p.Engine.Parser.ExtractChapterLabel(name)
+	chapter.Number, _ = p.Engine.Parser.ExtractChapterNumber(name)
+
+	var groups []string
+	for _, tag := range tags {
+		if tag.Type == "Scanlator" {
+			groups = append(groups, tag.Name)
+		}
+	}
+	chapter.Group = strings.Join(groups, ", ")
+	return chapter
+}
+
+// dynastySeriesID returns the permalink of a series link like /series/citrus
+func dynastySeriesID(href string) string {
+	_, id, ok := strings.Cut(href, "/series/")
+	if !ok {
+		return ""
+	}
+	id, _, _ = strings.Cut(id, "?")
+	return strings.TrimSuffix(strings.Trim(id, "/"), ".json")
+}
+
+// resolveDynastyChapterURL maps reader URLs like https://dynasty-scans.com/chapters/citrus_ch01
+// to their chapter ID
+func resolveDynastyChapterURL(u *url.URL) (string, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) != 2 || segments[0] != "chapters" || segments[1] == "" {
+		return "", errors.Newf("not a Dynasty Scans chapter URL: %s", u.String()).
+			WithMessage("Expected a chapter URL like https://dynasty-scans.com/chapters/<chapter>").
+			AsProvider("dys").Error()
+	}
+	return strings.TrimSuffix(segments[1], ".json"), nil
+}
+
+// resolveDynastyMangaURL maps series URLs like https://dynasty-scans.com/series/citrus to
+// their manga ID
+func resolveDynastyMangaURL(u *url.URL) (string, error) {
+	id := dynastySeriesID(u.Path)
+	if id == "" || strings.Contains(id, "/") {
+		return "", errors.Newf("not a Dynasty Scans series URL: %s", u.String()).
+			WithMessage("Expected a series URL like https://dynasty-scans.com/series/<series>").
+			AsProvider("dys").Error()
+	}
+	return id, nil
+}
+
+// dynastyURLFor returns the page of a series or the reader page of a chapter
+func dynastyURLFor(kind, id string) (string, error) {
+	switch kind {
+	case engine.URLKindManga:
+		return "https://dynasty-scans.com/series/" + id, nil
+	case engine.URLKindChapter:
+		return "https://dynasty-scans.com/chapters/" + id, nil
+	default:
+		return "", errors.Newf("unknown URL kind %q", kind).AsProvider("dys").Error()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"Luminary/pkg/engine"
 	"Luminary/pkg/provider/base"
 	"Luminary/pkg/provider/registry"
+	"regexp"
 	"time"
 )
 
@@ -26,10 +27,13 @@ func NewKissMangaProvider(e *engine.Engine) engine.Provider {
 				"title":       "h1.post-title, .post-title-font",
 				"description": ".description-summary, .summary__content",
 				"chapters":    "li.wp-manga-chapter > a, .chapter-link",
+				"page_count":  "span.chapter-pages, .chapter-page-count",
 				"pages":       "div.page-break img, .reading-content img",
 				"author":      ".author-content a, .manga-authors a",
+				"artist":      ".artist-content a",
 				"status":      ".post-status .summary-content",
 				"genres":      ".genres-content a",
+				"related":     ".related-reading-content .widget-title a",
 			},
 			AjaxSearch:       true,
 			CustomLoadAction: "madara_load_more",
@@ -49,5 +53,11 @@ func NewKissMangaProvider(e *engine.Engine) engine.Provider {
 		},
 
 		RateLimit: 2 * time.Second,
+
+		SelfTest: &engine.SelfTestFixture{Query: "One Piece"},
+		// Summaries often end with an invitation to read the manga on the site
+		Boilerplate: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)(?:you are )?read(?:ing)? .{1,120}? (?:online )?(?:for free )?(?:at|on) kissmanga(?:\.in)?\.?`),
+		},
 	}).Build()
 }
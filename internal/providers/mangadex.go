@@ -3,6 +3,7 @@ package providers
 import (
 	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
 	"Luminary/pkg/engine/network"
 	"Luminary/pkg/errors"
 	"Luminary/pkg/provider/base"
@@ -12,6 +13,7 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,11 +36,14 @@ type MgdMangaData struct {
 }
 
 type MgdMangaAttributes struct {
-	Title       map[string]string   `json:"title"`
-	AltTitles   []map[string]string `json:"altTitles"`
-	Description map[string]string   `json:"description"`
-	Status      string              `json:"status"`
-	Tags        []struct {
+	Title         map[string]string   `json:"title"`
+	AltTitles     []map[string]string `json:"altTitles"`
+	Description   map[string]string   `json:"description"`
+	Status        string              `json:"status"`
+	Demographic   *string             `json:"publicationDemographic"`
+	ContentRating string              `json:"contentRating"`
+	Links         map[string]string   `json:"links"`
+	Tags          []struct {
 		Attributes struct {
 			Name map[string]string `json:"name"`
 		} `json:"attributes"`
@@ -49,8 +54,10 @@ type MgdMangaAttributes struct {
 type MgdRelationship struct {
 	ID         string `json:"id"`
 	Type       string `json:"type"`
+	Related    string `json:"related,omitempty"`
 	Attributes *struct {
-		Name string `json:"name"`
+		Name  string            `json:"name"`
+		Title map[string]string `json:"title"`
 	} `json:"attributes"`
 }
 
@@ -73,6 +80,8 @@ type MgdChapterData struct {
 		Volume             string    `json:"volume"`
 		PublishAt          time.Time `json:"publishAt"`
 		TranslatedLanguage string    `json:"translatedLanguage"`
+		Pages              int       `json:"pages"`
+		ExternalURL        *string   `json:"externalUrl"`
 	} `json:"attributes"`
 	Relationships []MgdRelationship `json:"relationships"`
 }
@@ -86,6 +95,40 @@ type MgdPagesResp struct {
 	} `json:"chapter"`
 }
 
+// Validate checks that a manga response carries the manga
+func (r MgdMangaResp) Validate() error {
+	if r.Data.ID == "" {
+		return errors.New("missing data.id").Error()
+	}
+	return nil
+}
+
+// Validate checks that a chapter response carries the chapter
+func (r MgdChapterResp) Validate() error {
+	if r.Data.ID == "" {
+		return errors.New("missing data.id").Error()
+	}
+	return nil
+}
+
+// Validate checks that an at-home response carries what page URLs are built from
+func (r MgdPagesResp) Validate() error {
+	switch {
+	case r.BaseURL == "":
+		return errors.New("missing baseUrl").Error()
+	case r.Chapter.Hash == "":
+		return errors.New("missing chapter.hash").Error()
+	}
+	return nil
+}
+
+// Page sizes accepted by the MangaDex API
+const (
+	mgdDefaultSearchLimit = 10
+	mgdMaxSearchPageSize  = 100
+	mgdMaxFeedPageSize    = 500
+)
+
 // Register the provider automatically on startup
 func init() {
 	registry.Register(NewMangaDexProvider)
@@ -114,6 +157,10 @@ func NewMangaDexProvider(e *engine.Engine) engine.Provider {
 			"Referer":    "https://mangadex.org",
 		},
 		RateLimit: 1 * time.Second, // MangaDex API has a rate limit of 5 requests/second
+		// The title parameter of /manga matches alternative titles as well
+		SearchesAltTitles: true,
+
+		SelfTest: &engine.SelfTestFixture{Query: "One Piece", MangaID: "a1c7c817-4e59-43b7-9365-09675a149a6f"},
 	})
 
 	// Inject custom implementations for MangaDex's complex API
@@ -122,40 +169,47 @@ func NewMangaDexProvider(e *engine.Engine) engine.Provider {
 	return b.WithSearch(customMangaDexSearch(p)).
 		WithGetManga(customMangaDexGetManga(p)).
 		WithGetChapter(customMangaDexGetChapter(p)).
+		WithGetChapterPageCount(customMangaDexGetChapterPageCount(p)).
+		WithResolveChapterURL(resolveMangaDexChapterURL).
+		WithResolveMangaURL(resolveMangaDexMangaURL).
+		WithURLFor(mangaDexURLFor).
 		Build()
 }
 
 // customMangaDexSearch provides the implementation for the Search operation.
 func customMangaDexSearch(p *base.Provider) func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
 	return func(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
-		// Build search URL
-		searchURL := p.Config.API.BaseURL + "/manga"
-		queryParams := formatSearchQuery(query, options)
-		reqURL := searchURL + "?" + queryParams.Encode()
-		// Perform network request
-		resp, err := p.Engine.Network.Request(ctx, &network.Request{
-			URL:     reqURL,
-			Method:  "GET",
-			Headers: p.Config.Headers,
-		})
-
-		if err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
-		}
-
-		// Parse response
-		var searchResp MgdSearchResp
-		if err := resp.JSON(&searchResp); err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+		limit := options.Limit
+		if limit <= 0 {
+			limit = mgdDefaultSearchLimit
 		}
+		pagination := mgdPagination(min(limit, mgdMaxSearchPageSize))
+		languages := p.Engine.PreferredLanguages()
 
 		// Map API response to core.Manga model
 		var results []core.Manga
-		for _, mangaData := range searchResp.Data {
-			results = append(results, core.Manga{
-				ID:    mangaData.ID,
-				Title: common.ExtractBestTitle(mangaData.Attributes.Title), // Use common helper
-			})
+		for resp, err := range p.Paginate(ctx, pagination, "/manga", formatSearchQuery(query, options)) {
+			if err != nil {
+				return nil, err
+			}
+
+			var searchResp MgdSearchResp
+			if err := resp.JSON(&searchResp); err != nil {
+				return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+			}
+
+			for _, mangaData := range searchResp.Data {
+				results = append(results, core.Manga{
+					ID:            mangaData.ID,
+					Title:         common.ExtractBestTitle(mangaData.Attributes.Title, languages), // Use common helper
+					Demographic:   mapDemographic(mangaData.Attributes),
+					ContentRating: mangaData.Attributes.ContentRating,
+					Status:        mangaData.Attributes.Status,
+				})
+			}
+			if len(results) >= limit {
+				return results[:limit], nil
+			}
 		}
 
 		return results, nil
@@ -166,19 +220,19 @@ func customMangaDexSearch(p *base.Provider) func(context.Context, string, core.S
 func customMangaDexGetManga(p *base.Provider) func(context.Context, string) (*core.MangaInfo, error) {
 	return func(ctx context.Context, id string) (*core.MangaInfo, error) {
 		// 1. Fetch main manga details
-		mangaURL := fmt.Sprintf("%s/manga/%s?includes[]=author&includes[]=artist&includes[]=cover_art", p.Config.API.BaseURL, id)
-		resp, err := p.Engine.Network.Request(ctx, &network.Request{URL: mangaURL, Headers: p.Config.Headers})
+		mangaURL := fmt.Sprintf("%s/manga/%s?includes[]=author&includes[]=artist&includes[]=cover_art&includes[]=manga", p.Config.API.BaseURL, id)
+		resp, err := p.Engine.Network.Request(ctx, &network.Request{URL: mangaURL, Headers: p.Config.Headers, Endpoint: "manga"})
 		if err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+			return nil, errors.TP(err, p.ID())
 		}
 
 		var mangaResp MgdMangaResp
 		if err := resp.JSON(&mangaResp); err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+			return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
 		}
 
 		// Map to core.MangaInfo
-		mangaInfo := mapMangaDataToInfo(mangaResp.Data)
+		mangaInfo := mapMangaDataToInfo(mangaResp.Data, p.Engine.PreferredLanguages())
 
 		// 2. Fetch all chapters using pagination
 		chapters, err := fetchAllChapters(ctx, p, id)
@@ -196,44 +250,77 @@ func customMangaDexGetManga(p *base.Provider) func(context.Context, string) (*co
 func customMangaDexGetChapter(p *base.Provider) func(context.Context, string) (*core.Chapter, error) {
 	return func(ctx context.Context, chapterID string) (*core.Chapter, error) {
 		// 1. Fetch chapter details to get manga ID and other info
-		chapterInfoURL := fmt.Sprintf("%s/chapter/%s", p.Config.API.BaseURL, chapterID)
-		infoResp, err := p.Engine.Network.Request(ctx, &network.Request{URL: chapterInfoURL, Headers: p.Config.Headers})
+		chapterInfoURL := fmt.Sprintf("%s/chapter/%s?includes[]=scanlation_group", p.Config.API.BaseURL, chapterID)
+		infoResp, err := p.Engine.Network.Request(ctx, &network.Request{URL: chapterInfoURL, Headers: p.Config.Headers, Endpoint: "chapter"})
 		if err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+			return nil, errors.TP(err, p.ID())
 		}
 		var chapterResp MgdChapterResp
 		if err := infoResp.JSON(&chapterResp); err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+			return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+		}
+
+		// Chapters hosted by official publishers have no pages on the at-home network
+		if chapterResp.Data.Attributes.ExternalURL != nil && *chapterResp.Data.Attributes.ExternalURL != "" {
+			return mapChapterDataToChapter(chapterResp.Data, MgdPagesResp{})
 		}
 
-		// 2. Fetch page URLs from the at-home server
-		pagesURL := fmt.Sprintf("%s/at-home/server/%s", p.Config.API.BaseURL, chapterID)
-		pagesResp, err := p.Engine.Network.Request(ctx, &network.Request{URL: pagesURL, Headers: p.Config.Headers})
+		// 2. Fetch page URLs from the at-home server. It refuses chapters blocked in the
+		// user's region, and chapters removed after licensing are listed without pages.
+		pagesData, err := fetchAtHomeServer(ctx, p, chapterID)
+		if errors.IsForbidden(err) {
+			return nil, download.Unavailable(p.ID(), chapterID, download.ReasonRegion)
+		}
 		if err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+			return nil, err
 		}
-		var pagesData MgdPagesResp
-		if err := pagesResp.JSON(&pagesData); err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+		if len(pagesData.Chapter.Data) == 0 && len(pagesData.Chapter.DataSaver) == 0 && chapterResp.Data.Attributes.Pages == 0 {
+			return nil, download.Unavailable(p.ID(), chapterID, download.ReasonLicensed)
 		}
 
 		// 3. Construct the full Chapter object
-		return mapChapterDataToChapter(chapterResp.Data, pagesData)
+		return mapChapterDataToChapter(chapterResp.Data, *pagesData)
 	}
 }
 
-// fetchAllChapters handles pagination to retrieve all chapters for a manga.
+// customMangaDexGetChapterPageCount reads the page count from the at-home server
+// metadata, which avoids fetching chapter details and building every page URL.
+func customMangaDexGetChapterPageCount(p *base.Provider) func(context.Context, string) (int, error) {
+	return func(ctx context.Context, chapterID string) (int, error) {
+		pagesData, err := fetchAtHomeServer(ctx, p, chapterID)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(pagesData.Chapter.Data) > 0 {
+			return len(pagesData.Chapter.Data), nil
+		}
+		return len(pagesData.Chapter.DataSaver), nil
+	}
+}
+
+// fetchAtHomeServer retrieves the at-home server metadata (base URL, hash and page files) for a chapter.
+func fetchAtHomeServer(ctx context.Context, p *base.Provider, chapterID string) (*MgdPagesResp, error) {
+	pagesURL := fmt.Sprintf("%s/at-home/server/%s", p.Config.API.BaseURL, chapterID)
+	pagesResp, err := p.Engine.Network.Request(ctx, &network.Request{URL: pagesURL, Headers: p.Config.Headers, Endpoint: "at-home"})
+	if err != nil {
+		return nil, errors.TP(err, p.ID())
+	}
+
+	var pagesData MgdPagesResp
+	if err := pagesResp.JSON(&pagesData); err != nil {
+		return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+
+	return &pagesData, nil
+}
+
+// fetchAllChapters retrieves all chapters for a manga, walking every page of its feed.
 func fetchAllChapters(ctx context.Context, p *base.Provider, mangaID string) ([]core.ChapterInfo, error) {
 	var allChapters []core.ChapterInfo
-	var offset = 0
-	const limit = 500 // Max limit for this endpoint
 
-	for {
-		// Build paginated request URL
-		queryParams := formatChaptersQuery(offset, limit)
-		reqURL := fmt.Sprintf("%s/manga/%s/feed?%s", p.Config.API.BaseURL, mangaID, queryParams.Encode())
-
-		resp, err := p.Engine.Network.Request(ctx, &network.Request{URL: reqURL, Headers: p.Config.Headers})
+	feed := fmt.Sprintf("/manga/%s/feed", mangaID)
+	for resp, err := range p.Paginate(ctx, mgdPagination(mgdMaxFeedPageSize), feed, formatChaptersQuery()) {
 		if err != nil {
 			return nil, err
 		}
@@ -244,61 +331,131 @@ func fetchAllChapters(ctx context.Context, p *base.Provider, mangaID string) ([]
 		}
 
 		// Map and append chapters from the current page
+		// The feed is ordered by volume and chapter, so its position is the reading order
 		for _, chapterData := range listResp.Data {
-			allChapters = append(allChapters, mapChapterDataToInfo(chapterData))
+			chapterInfo := mapChapterDataToInfo(chapterData)
+			chapterInfo.Sequence = len(allChapters) + 1
+			allChapters = append(allChapters, chapterInfo)
 		}
+	}
 
-		// Check if we've fetched all chapters
-		if listResp.Total <= offset+listResp.Limit {
-			break
-		}
+	return allChapters, nil
+}
 
-		// Prepare for the next page
-		offset += listResp.Limit
+// mgdPagination describes the offset pagination of MangaDex list endpoints.
+func mgdPagination(pageSize int) base.PaginationConfig {
+	return base.PaginationConfig{
+		Style:     base.PaginateOffset,
+		PageSize:  pageSize,
+		ItemsPath: "data",
+		TotalPath: "total",
 	}
+}
 
-	return allChapters, nil
+// mgdTrackerLinks maps MangaDex link keys to core external ID sources.
+var mgdTrackerLinks = map[string]string{
+	"al":  core.ExternalAniList,
+	"mal": core.ExternalMyAnimeList,
+	"mu":  core.ExternalMangaUpdates,
 }
 
-// mapMangaDataToInfo maps the API response to the core.MangaInfo struct.
-func mapMangaDataToInfo(data MgdMangaData) *core.MangaInfo {
+// mapMangaDataToInfo maps the API response to the core.MangaInfo struct, choosing localized
+// text by the language priority given.
+func mapMangaDataToInfo(data MgdMangaData, languages []string) *core.MangaInfo {
 	info := &core.MangaInfo{
 		Manga: core.Manga{
-			ID:          data.ID,
-			Title:       common.ExtractBestTitle(data.Attributes.Title),       // Use common helper
-			Description: common.ExtractBestTitle(data.Attributes.Description), // Use common helper
-			Status:      data.Attributes.Status,
+			ID:            data.ID,
+			Title:         common.ExtractBestTitle(data.Attributes.Title, languages), // Use common helper
+			Description:   core.BestLocalized(data.Attributes.Description, languages),
+			Status:        data.Attributes.Status,
+			Demographic:   mapDemographic(data.Attributes),
+			ContentRating: data.Attributes.ContentRating,
 		},
 		LastUpdated: &data.Attributes.UpdatedAt,
 	}
 
 	for _, alt := range data.Attributes.AltTitles {
-		info.Manga.AlternativeTitles = append(info.Manga.AlternativeTitles, common.ExtractBestTitle(alt))
+		info.Manga.AlternativeTitles = append(info.Manga.AlternativeTitles, common.ExtractBestTitle(alt, languages))
 	}
 
 	for _, tag := range data.Attributes.Tags {
-		info.Manga.Tags = append(info.Manga.Tags, common.ExtractBestTitle(tag.Attributes.Name))
+		info.Manga.Tags = append(info.Manga.Tags, common.ExtractBestTitle(tag.Attributes.Name, languages))
+	}
+
+	// MangaDex links use short site keys; keep the ones identifying the manga on trackers
+	for key, source := range mgdTrackerLinks {
+		if id := data.Attributes.Links[key]; id != "" {
+			if info.ExternalIDs == nil {
+				info.ExternalIDs = make(map[string]string)
+			}
+			info.ExternalIDs[source] = id
+		}
 	}
 
 	for _, rel := range data.Relationships {
-		if (rel.Type == "author" || rel.Type == "artist") && rel.Attributes != nil {
+		// Related manga are listed even when their attributes were not expanded
+		if rel.Type == "manga" {
+			related := core.RelatedManga{
+				ID:       rel.ID,
+				Relation: rel.Related,
+			}
+			if rel.Attributes != nil {
+				related.Title = common.ExtractBestTitle(rel.Attributes.Title, languages)
+			}
+			info.Related = append(info.Related, related)
+			continue
+		}
+
+		if rel.Attributes == nil {
+			continue
+		}
+		switch rel.Type {
+		case "author":
 			info.Manga.Authors = append(info.Manga.Authors, rel.Attributes.Name)
+		case "artist":
+			info.Manga.Artists = append(info.Manga.Artists, rel.Attributes.Name)
 		}
 	}
 	return info
 }
 
+// mapDemographic returns the publication demographic, which MangaDex leaves null when unknown.
+func mapDemographic(attrs MgdMangaAttributes) string {
+	if attrs.Demographic == nil {
+		return ""
+	}
+	return *attrs.Demographic
+}
+
 // mapChapterDataToInfo maps chapter list data to core.ChapterInfo.
 func mapChapterDataToInfo(data MgdChapterData) core.ChapterInfo {
 	chapterNum, _ := strconv.ParseFloat(data.Attributes.Chapter, 64)
-	return core.ChapterInfo{
-		ID:       data.ID,
-		Title:    data.Attributes.Title,
-		Number:   chapterNum,
-		Volume:   data.Attributes.Volume,
-		Language: data.Attributes.TranslatedLanguage,
-		Date:     &data.Attributes.PublishAt,
+	info := core.ChapterInfo{
+		ID:        data.ID,
+		Title:     data.Attributes.Title,
+		Number:    chapterNum,
+		Volume:    data.Attributes.Volume,
+		Language:  data.Attributes.TranslatedLanguage,
+		Date:      &data.Attributes.PublishAt,
+		PageCount: data.Attributes.Pages,
+		Label:     data.Attributes.Chapter,
+	}
+	// Chapters without a number are oneshots on MangaDex
+	if info.Label == "" {
+		info.Label = "Oneshot"
 	}
+	if data.Attributes.ExternalURL != nil {
+		info.ExternalURL = *data.Attributes.ExternalURL
+	}
+	// Joint releases list several groups; join them so filters match any of them
+	var groups []string
+	for _, rel := range data.Relationships {
+		if rel.Type == "scanlation_group" && rel.Attributes != nil {
+			groups = append(groups, rel.Attributes.Name)
+		}
+	}
+	info.Group = strings.Join(groups, ", ")
+	return info
 }
 
 // mapChapterDataToChapter constructs a full core.Chapter from API responses.
@@ -317,44 +474,111 @@ func mapChapterDataToChapter(chapterData MgdChapterData, pagesData MgdPagesResp)
 		}
 	}
 
-	// Construct full page URLs
-	pageURLs := pagesData.Chapter.Data
+	// Construct full page URLs; the data-saver copy of each page is its fallback
+	pageURLs, quality := pagesData.Chapter.Data, "data"
 	if len(pageURLs) == 0 {
-		pageURLs = pagesData.Chapter.DataSaver // Fallback to data-saver images
+		pageURLs, quality = pagesData.Chapter.DataSaver, "data-saver" // Fallback to data-saver images
 	}
 
 	for i, filename := range pageURLs {
-		pageURL := fmt.Sprintf("%s/data/%s/%s", pagesData.BaseURL, pagesData.Chapter.Hash, filename)
-		chapter.Pages = append(chapter.Pages, core.Page{
+		page := core.Page{
 			Index:    i,
-			URL:      pageURL,
+			URL:      fmt.Sprintf("%s/%s/%s/%s", pagesData.BaseURL, quality, pagesData.Chapter.Hash, filename),
 			Filename: filename,
-		})
+		}
+		if quality == "data" && i < len(pagesData.Chapter.DataSaver) {
+			page.Fallbacks = []string{fmt.Sprintf("%s/data-saver/%s/%s", pagesData.BaseURL, pagesData.Chapter.Hash, pagesData.Chapter.DataSaver[i])}
+		}
+		chapter.Pages = append(chapter.Pages, page)
 	}
 
 	return chapter, nil
 }
 
-// formatSearchQuery creates the query parameters for a manga search request.
+// resolveMangaDexChapterURL extracts the chapter UUID from reader URLs like
+// https://mangadex.org/chapter/<uuid>/1
+func resolveMangaDexChapterURL(u *url.URL) (string, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "chapter" || segments[1] == "" {
+		return "", errors.Newf("not a MangaDex chapter URL: %s", u.String()).
+			WithMessage("Expected a chapter URL like https://mangadex.org/chapter/<id>").
+			AsProvider("mgd").Error()
+	}
+	return segments[1], nil
+}
+
+// resolveMangaDexMangaURL extracts the manga UUID from title URLs like
+// https://mangadex.org/title/<uuid>/<slug>
+func resolveMangaDexMangaURL(u *url.URL) (string, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "title" || segments[1] == "" {
+		return "", errors.Newf("not a MangaDex title URL: %s", u.String()).
+			WithMessage("Expected a title URL like https://mangadex.org/title/<id>").
+			AsProvider("mgd").Error()
+	}
+	return segments[1], nil
+}
+
+// mangaDexURLFor returns the title page of a manga or the reader page of a chapter
+func mangaDexURLFor(kind, id string) (string, error) {
+	switch kind {
+	case engine.URLKindManga:
+		return "https://mangadex.org/title/" + id, nil
+	case engine.URLKindChapter:
+		return "https://mangadex.org/chapter/" + id, nil
+	default:
+		return "", errors.Newf("unknown URL kind %q", kind).AsProvider("mgd").Error()
+	}
+}
+
+// formatSearchQuery creates the query parameters for a manga search request; the limit
+// and offset are added by the paginator.
 func formatSearchQuery(query string, options core.SearchOptions) url.Values {
 	p := url.Values{}
 	p.Set("title", query)
-	p.Set("limit", strconv.Itoa(options.Limit))
 	p.Add("order[relevance]", "desc")
-	// Include a wide range of content ratings
-	for _, rating := range []string{"safe", "suggestive", "erotica", "pornographic"} {
+
+	// Let the API narrow the results when filters are set; the base provider re-checks them
+	ratings := []string{"safe", "suggestive", "erotica", "pornographic"}
+	for key, value := range options.Filters {
+		switch strings.ToLower(key) {
+		case "content_rating", "rating":
+			ratings = splitFilterValues(value)
+		case "demographic":
+			for _, d := range splitFilterValues(value) {
+				p.Add("publicationDemographic[]", d)
+			}
+		case "status":
+			for _, st := range splitFilterValues(value) {
+				p.Add("status[]", st)
+			}
+		}
+	}
+
+	// Include a wide range of content ratings unless filtered
+	for _, rating := range ratings {
 		p.Add("contentRating[]", rating)
 	}
 	return p
 }
 
+// splitFilterValues splits a comma-separated filter value into lower-cased values.
+func splitFilterValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // formatChaptersQuery creates query parameters for fetching a manga's chapter feed.
-func formatChaptersQuery(offset, limit int) url.Values {
+func formatChaptersQuery() url.Values {
 	p := url.Values{}
-	p.Set("limit", strconv.Itoa(limit))
-	p.Set("offset", strconv.Itoa(offset))
 	p.Add("order[volume]", "asc")
 	p.Add("order[chapter]", "asc")
+	p.Add("includes[]", "scanlation_group")
 	// Include all languages by default in the feed
 	p.Add("translatedLanguage[]", "en") // Start with english, but MD returns all if not specified
 	for _, rating := range []string{"safe", "suggestive", "erotica", "pornographic"} {
@@ -0,0 +1,594 @@
+package providers
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/parser/html"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"Luminary/pkg/provider/common"
+	"Luminary/pkg/provider/registry"
+	"context"
+	"crypto/rc4"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MgfAjaxResp is the envelope of MangaFire's AJAX endpoints
+type MgfAjaxResp[T any] struct {
+	Status  int    `json:"status"`
+	Message string `json:"message,omitempty"`
+	Result  T      `json:"result"`
+}
+
+// MgfReadList is the result of the reader's chapter list
+type MgfReadList struct {
+	HTML string `json:"html"`
+}
+
+// MgfImages is the result of the image list of a chapter. Each image is [url, page,
+// offset]; a positive offset means the site scrambled the image in tiles.
+type MgfImages struct {
+	Images [][]interface{} `json:"images"`
+}
+
+// mgfLanguages are the translations MangaFire hosts
+var mgfLanguages = []string{"en", "es", "es-la", "fr", "ja", "pt", "pt-br"}
+
+// mgfVRFKey is the RC4 key the reader script derives the vrf token of AJAX requests
+// with; the site changes it now and then, which shows as its API answering 403
+const mgfVRFKey = "FWsfu0KQd9vxYGNB"
+
+// Register the provider automatically on startup
+func init() {
+	registry.Register(NewMangaFireProvider)
+}
+
+// NewMangaFireProvider creates the MangaFire provider. Pages are scraped, chapter lists
+// and images come from the site's AJAX API.
+func NewMangaFireProvider(e *engine.Engine) engine.Provider {
+	b := base.New(e, base.Config{
+		ID:          "mgf",
+		Name:        "MangaFire",
+		Description: "Read manga online with translations in several languages",
+		SiteURL:     "https://mangafire.to",
+		Type:        base.TypeWeb,
+
+		Web: &base.WebConfig{
+			SearchPath: "/filter?keyword={query}",
+			MangaPath:  "/manga/{id}",
+		},
+
+		Headers: map[string]string{
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+			"Accept-Language": "en-US,en;q=0.9",
+			"Referer":         "https://mangafire.to/",
+		},
+
+		RateLimit: 1 * time.Second,
+
+		SelfTest: &engine.SelfTestFixture{Query: "One Piece", MangaID: "one-piece.dkw"},
+	})
+
+	p := b.Build().(*base.Provider)
+	return b.WithSearch(customMangaFireSearch(p)).
+		WithGetManga(customMangaFireGetManga(p)).
+		WithGetChapter(customMangaFireGetChapter(p)).
+		WithDownloadChapter(customMangaFireDownloadChapter(p)).
+		WithResolveChapterURL(resolveMangaFireChapterURL).
+		WithResolveMangaURL(resolveMangaFireMangaURL).
+		WithURLFor(mangaFireURLFor).
+		Build()
+}
+
+// customMangaFireSearch searches the site's filter page. Manga IDs are the "slug.code"
+// of the manga's URL.
+func customMangaFireSearch(p *base.Provider) func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+	return func(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+		pages := max(options.Pages, 1)
+
+		var results []core.Manga
+		for page := 1; page <= pages; page++ {
+			searchURL := fmt.Sprintf("%s/filter?keyword=%s&page=%d", p.Config.SiteURL, url.QueryEscape(query), page)
+			resp, err := p.Engine.Network.Request(ctx, p.NewRequest(searchURL))
+			if err != nil {
+				return nil, errors.TP(err, p.ID())
+			}
+			doc, err := resp.HTML()
+			if err != nil {
+				return nil, errors.Track(err).WithContext("provider_id", p.ID()).AsParser().Error()
+			}
+
+			units := doc.Select(".original.card-lg .unit").AllOrEmpty()
+			for _, unit := range units {
+				link := unit.Find(".info > a").FirstOrNil()
+				if link == nil {
+					continue
+				}
+				id := mangaFireMangaID(link.Extract().Href())
+				if id == "" {
+					continue
+				}
+
+				manga := core.Manga{ID: id, Title: link.Extract().CleanText()}
+				if img := unit.Find(".poster img").FirstOrNil(); img != nil {
+					manga.CoverURL = img.Extract().AbsSrc(p.Config.SiteURL)
+				}
+				results = append(results, manga)
+
+				if options.Limit > 0 && len(results) >= options.Limit {
+					return results, nil
+				}
+			}
+
+			// A page without a next page link is the last one
+			if len(units) == 0 || !doc.Select(".pagination .page-item a[rel=next]").Exists() {
+				break
+			}
+		}
+
+		return results, nil
+	}
+}
+
+// customMangaFireGetManga reads the manga page and the chapters of the preferred
+// languages the site has
+func customMangaFireGetManga(p *base.Provider) func(context.Context, string) (*core.MangaInfo, error) {
+	return func(ctx context.Context, id string) (*core.MangaInfo, error) {
+		resp, err := p.Engine.Network.Request(ctx, p.NewRequest(p.Config.SiteURL+"/manga/"+id))
+		if err != nil {
+			return nil, errors.Track(err).WithContext("manga_id", id).AsProvider(p.ID()).Error()
+		}
+		doc, err := resp.HTML()
+		if err != nil {
+			return nil, errors.Track(err).WithContext("provider_id", p.ID()).AsParser().Error()
+		}
+
+		info := &core.MangaInfo{Manga: core.Manga{ID: id}}
+		if title := doc.Select(".info h1").FirstOrNil(); title != nil {
+			info.Title = title.Extract().CleanText()
+		}
+		if info.Title == "" {
+			return nil, errors.Newf("no manga found at %s", resp.URL).
+				WithContext("manga_id", id).
+				AsNotFound().Error()
+		}
+		if alt := doc.Select(".info h6").FirstOrNil(); alt != nil {
+			for _, title := range strings.Split(alt.Extract().CleanText(), ";") {
+				if title = strings.TrimSpace(title); title != "" {
+					info.AlternativeTitles = append(info.AlternativeTitles, title)
+				}
+			}
+		}
+		if status := doc.Select(".info > p").FirstOrNil(); status != nil {
+			info.Status = strings.ToLower(status.Extract().CleanText())
+		}
+		if synopsis := doc.MultiSelect("#synopsis .modal-content", ".description").All(); len(synopsis) > 0 {
+			info.Description = synopsis[0].Extract().CleanText()
+		}
+		if img := doc.Select(".poster img").FirstOrNil(); img != nil {
+			info.CoverURL = img.Extract().AbsSrc(p.Config.SiteURL)
+		}
+
+		// The meta block lists "Author:", "Genres:" and so on, each followed by links
+		doc.Select(".meta > div").Each(func(_ int, row *html.Element) {
+			span := row.Find("span").FirstOrNil()
+			if span == nil {
+				return
+			}
+			label := strings.ToLower(span.Extract().CleanText())
+			names := row.Find("a").MapString(func(a *html.Element) string { return a.Extract().CleanText() })
+			switch {
+			case strings.HasPrefix(label, "author"):
+				info.Authors = append(info.Authors, names...)
+			case strings.HasPrefix(label, "genre"):
+				info.Tags = append(info.Tags, names...)
+			}
+		})
+		info.Demographic = common.InferDemographic(info.Tags)
+		info.ContentRating = common.InferContentRating(info.Tags)
+
+		for _, lang := range mangaFireLanguages(p.Engine.PreferredLanguages()) {
+			chapters, err := fetchMangaFireChapters(ctx, p, id, lang)
+			if err != nil {
+				// Show the manga even if a chapter list fails
+				p.Engine.Logger.Warn("Failed to fetch %s chapters of %s: %v", lang, id, err)
+				continue
+			}
+			if len(chapters) > 0 {
+				info.AvailableLanguages = append(info.AvailableLanguages, lang)
+			}
+			info.Chapters = append(info.Chapters, chapters...)
+		}
+
+		return info, nil
+	}
+}
+
+// fetchMangaFireChapters lists the chapters of a manga in one language, newest first.
+// Chapter IDs are "<manga-id>/<language>/chapter-<number>", the path of the reader.
+func fetchMangaFireChapters(ctx context.Context, p *base.Provider, mangaID, lang string) ([]core.ChapterInfo, error) {
+	listURL := fmt.Sprintf("%s/ajax/manga/%s/chapter/%s", p.Config.SiteURL, mangaFireCode(mangaID), lang)
+	req := p.NewRequest(listURL)
+	req.Endpoint = "chapters"
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return nil, errors.TP(err, p.ID())
+	}
+
+	var list MgfAjaxResp[string]
+	if err := resp.JSON(&list); err != nil {
+		return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+	if list.Status != 200 {
+		return nil, errors.Newf("chapter list failed with status %d: %s", list.Status, list.Message).
+			WithContext("manga_id", mangaID).
+			AsProvider(p.ID()).Error()
+	}
+
+	doc, err := resp.HTMLFragment(list.Result)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("provider_id", p.ID()).AsParser().Error()
+	}
+
+	items := doc.Select("li.item").AllOrEmpty()
+	chapters := make([]core.ChapterInfo, 0, len(items))
+	for i, item := range items {
+		number := item.Extract().Data("number")
+		spans := item.Find("a span").AllOrEmpty()
+
+		chapter := core.ChapterInfo{
+			ID:       mangaID + "/" + lang + "/chapter-" + number,
+			Label:    number,
+			Language: lang,
+			Sequence: len(items) - i,
+		}
+		chapter.Number, _ = strconv.ParseFloat(number, 64)
+		if len(spans) > 0 {
+			chapter.Title = spans[0].Extract().CleanText()
+		}
+		if len(spans) > 1 {
+			chapter.Date = common.ParseDate(spans[1].Extract().CleanText())
+		}
+		chapters = append(chapters, chapter)
+	}
+	return chapters, nil
+}
+
+// customMangaFireGetChapter resolves the pages of a chapter
+func customMangaFireGetChapter(p *base.Provider) func(context.Context, string) (*core.Chapter, error) {
+	return func(ctx context.Context, chapterID string) (*core.Chapter, error) {
+		chapter, _, err := fetchMangaFireChapter(ctx, p, chapterID)
+		return chapter, err
+	}
+}
+
+// customMangaFireDownloadChapter downloads a chapter, putting scrambled pages back
+// together before any other processing
+func customMangaFireDownloadChapter(p *base.Provider) func(context.Context, string, string) error {
+	return func(ctx context.Context, chapterID, destDir string) error {
+		chapter, offsets, err := fetchMangaFireChapter(ctx, p, chapterID)
+		if err != nil {
+			return err
+		}
+
+		if len(offsets) > 0 {
+			ctx = download.WithPreprocessors(ctx, mgfDescrambler{offsets: offsets})
+		}
+		ctx = download.WithRefresh(ctx, func(ctx context.Context) (*core.Chapter, error) {
+			chapter, _, err := fetchMangaFireChapter(ctx, p, chapterID)
+			return chapter, err
+		})
+		return p.Engine.Download.DownloadChapter(ctx, chapter, destDir)
+	}
+}
+
+// fetchMangaFireChapter resolves a chapter through the reader's AJAX API: the chapter
+// list of the language maps the chapter number to the site's chapter ID, whose image
+// list holds the pages. Also returns the scramble offset of each scrambled page by index.
+func fetchMangaFireChapter(ctx context.Context, p *base.Provider, chapterID string) (*core.Chapter, map[int]int, error) {
+	mangaID, lang, number, ok := parseMangaFireChapterID(chapterID)
+	if !ok {
+		return nil, nil, errors.Newf("invalid MangaFire chapter ID: %s", chapterID).
+			WithMessage("MangaFire chapter IDs look like <manga-id>/<language>/chapter-<number>").
+			AsProvider(p.ID()).Error()
+	}
+	code := mangaFireCode(mangaID)
+
+	listURL := fmt.Sprintf("%s/ajax/read/%s/chapter/%s?vrf=%s", p.Config.SiteURL, code, lang,
+		url.QueryEscape(mangaFireVRF(code+"@chapter@"+lang)))
+	req := p.NewRequest(listURL)
+	req.Endpoint = "read"
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return nil, nil, errors.Track(err).WithContext("chapter_id", chapterID).AsProvider(p.ID()).Error()
+	}
+	var list MgfAjaxResp[MgfReadList]
+	if err := resp.JSON(&list); err != nil {
+		return nil, nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+	doc, err := resp.HTMLFragment(list.Result.HTML)
+	if err != nil {
+		return nil, nil, errors.Track(err).WithContext("provider_id", p.ID()).AsParser().Error()
+	}
+
+	var readerID, title string
+	doc.Select("a[data-id]").Each(func(_ int, a *html.Element) {
+		if readerID == "" && a.Extract().Data("number") == number {
+			readerID, title = a.Extract().Data("id"), a.Extract().CleanText()
+		}
+	})
+	if readerID == "" {
+		return nil, nil, errors.Newf("chapter %s not found", chapterID).
+			WithContext("chapter_id", chapterID).
+			AsNotFound().Error()
+	}
+
+	imagesURL := fmt.Sprintf("%s/ajax/read/chapter/%s?vrf=%s", p.Config.SiteURL, readerID,
+		url.QueryEscape(mangaFireVRF("chapter@"+readerID)))
+	req = p.NewRequest(imagesURL)
+	req.Endpoint = "images"
+	resp, err = p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return nil, nil, errors.Track(err).WithContext("chapter_id", chapterID).AsProvider(p.ID()).Error()
+	}
+	var images MgfAjaxResp[MgfImages]
+	if err := resp.JSON(&images); err != nil {
+		return nil, nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+
+	chapter := &core.Chapter{
+		MangaID: mangaID,
+		Info: core.ChapterInfo{
+			ID:       chapterID,
+			Title:    title,
+			Label:    number,
+			Language: lang,
+		},
+	}
+	chapter.Info.Number, _ = strconv.ParseFloat(number, 64)
+
+	offsets := make(map[int]int)
+	for _, image := range images.Result.Images {
+		if len(image) == 0 {
+			continue
+		}
+		imageURL, _ := image[0].(string)
+		if imageURL == "" {
+			continue
+		}
+		index := len(chapter.Pages)
+		chapter.Pages = append(chapter.Pages, core.Page{Index: index, URL: imageURL})
+		if len(image) > 2 {
+			if offset, _ := image[2].(float64); offset > 0 {
+				offsets[index] = int(offset)
+			}
+		}
+	}
+	if len(chapter.Pages) == 0 {
+		return nil, nil, errors.Newf("no pages found for chapter %s", chapterID).
+			WithContext("chapter_id", chapterID).
+			AsProvider(p.ID()).Error()
+	}
+	chapter.Info.PageCount = len(chapter.Pages)
+
+	return chapter, offsets, nil
+}
+
+// mangaFireVRF computes the vrf token the AJAX API expects for input, as the reader
+// script does: RC4 over the URL-escaped input, then base64
+func mangaFireVRF(input string) string {
+	cipher, _ := rc4.NewCipher([]byte(mgfVRFKey))
+	data := []byte(url.QueryEscape(input))
+	cipher.XORKeyStream(data, data)
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// mangaFireLanguages returns the preferred languages MangaFire hosts, English without any
+func mangaFireLanguages(preferred []string) []string {
+	var languages []string
+	for _, lang := range preferred {
+		if slices.Contains(mgfLanguages, lang) && !slices.Contains(languages, lang) {
+			languages = append(languages, lang)
+		}
+	}
+	if len(languages) == 0 {
+		languages = []string{"en"}
+	}
+	return languages
+}
+
+// mangaFireMangaID returns the "slug.code" of a manga link like /manga/one-piece.dkw
+func mangaFireMangaID(href string) string {
+	_, id, ok := strings.Cut(href, "/manga/")
+	if !ok {
+		return ""
+	}
+	id, _, _ = strings.Cut(id, "?")
+	return strings.Trim(id, "/")
+}
+
+// mangaFireCode returns the code the API knows a manga by, the part of its ID after the
+// last dot
+func mangaFireCode(mangaID string) string {
+	return mangaID[strings.LastIndex(mangaID, ".")+1:]
+}
+
+// parseMangaFireChapterID splits "<manga-id>/<language>/chapter-<number>"
+func parseMangaFireChapterID(chapterID string) (mangaID, lang, number string, ok bool) {
+	parts := strings.Split(chapterID, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || !strings.HasPrefix(parts[2], "chapter-") {
+		return "", "", "", false
+	}
+	number = strings.TrimPrefix(parts[2], "chapter-")
+	return parts[0], parts[1], number, number != ""
+}
+
+// resolveMangaFireChapterURL maps reader URLs like
+// https://mangafire.to/read/one-piece.dkw/en/chapter-1100 to their chapter ID
+func resolveMangaFireChapterURL(u *url.URL) (string, error) {
+	id := strings.TrimPrefix(strings.Trim(u.Path, "/"), "read/")
+	if _, _, _, ok := parseMangaFireChapterID(id); !ok || !strings.HasPrefix(u.Path, "/read/") {
+		return "", errors.Newf("not a MangaFire chapter URL: %s", u.String()).
+			WithMessage("Expected a chapter URL like https://mangafire.to/read/<manga>/<language>/chapter-<number>").
+			AsProvider("mgf").Error()
+	}
+	return id, nil
+}
+
+// resolveMangaFireMangaURL maps manga URLs like https://mangafire.to/manga/one-piece.dkw
+// to their manga ID
+func resolveMangaFireMangaURL(u *url.URL) (string, error) {
+	id := mangaFireMangaID(u.Path)
+	if id == "" || strings.Contains(id, "/") {
+		return "", errors.Newf("not a MangaFire manga URL: %s", u.String()).
+			WithMessage("Expected a manga URL like https://mangafire.to/manga/<manga>").
+			AsProvider("mgf").Error()
+	}
+	return id, nil
+}
+
+// mangaFireURLFor returns the page of a manga or the reader page of a chapter
+func mangaFireURLFor(kind, id string) (string, error) {
+	switch kind {
+	case engine.URLKindManga:
+		return "https://mangafire.to/manga/" + id, nil
+	case engine.URLKindChapter:
+		return "https://mangafire.to/read/" + id, nil
+	default:
+		return "", errors.Newf("unknown URL kind %q", kind).AsProvider("mgf").Error()
+	}
+}
+
+// Tiles of scrambled pages are at most this big, and pages are cut into at least this
+// many of them per side
+const (
+	mgfPieceSize     = 200
+	mgfMinSplitCount = 5
+)
+
+// mgfDescrambler puts the tiles of scrambled pages back in place, like the reader does
+// before showing them
+type mgfDescrambler struct {
+	offsets map[int]int // Scramble offset by page index
+}
+
+func (d mgfDescrambler) Name() string          { return "mangafire-descramble" }
+func (d mgfDescrambler) Stage() download.Stage { return download.StageConvert }
+
+// ProcessPage implements download.PageProcessor
+func (d mgfDescrambler) ProcessPage(_ context.Context, page download.PageFile) ([]download.PageFile, error) {
+	offset, ok := d.offsets[page.Index]
+	if !ok {
+		return []download.PageFile{page}, nil
+	}
+
+	f, err := os.Open(page.Path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(f, header)
+	_, _ = f.Seek(0, io.SeekStart)
+	img, format, err := image.Decode(f)
+	_ = f.Close()
+	if err != nil {
+		// A page left scrambled is as broken as a missing one, so it's removed and fails
+		// the chapter. The standard library can't decode WebP, which MangaFire serves
+		// some chapters as.
+		_ = os.Remove(page.Path)
+		contentType := http.DetectContentType(header[:n])
+		return nil, errors.Track(err).
+			WithContext("content_type", contentType).
+			WithMessagef("Page %d is scrambled and its format (%s) can't be unscrambled", page.Index+1, contentType).
+			AsDownload().Error()
+	}
+
+	// JPEG pages stay JPEG, anything else becomes PNG
+	target := page.Path
+	if format != "jpeg" && format != "png" {
+		target = strings.TrimSuffix(page.Path, filepath.Ext(page.Path)) + ".png"
+	}
+	if err := writeMangaFirePage(target, descrambleMangaFire(img, offset), format); err != nil {
+		return nil, err
+	}
+	if target != page.Path {
+		if err := os.Remove(page.Path); err != nil {
+			return nil, err
+		}
+	}
+	return []download.PageFile{{Index: page.Index, Path: target}}, nil
+}
+
+// writeMangaFirePage encodes a restored page next to path and moves it there, so a
+// failed encode never leaves a broken page behind
+func writeMangaFirePage(path string, img image.Image, format string) error {
+	temp := path + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	if format == "jpeg" {
+		err = jpeg.Encode(out, img, &jpeg.Options{Quality: 95})
+	} else {
+		err = png.Encode(out, img)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp, path)
+	}
+	if err != nil {
+		_ = os.Remove(temp)
+	}
+	return err
+}
+
+// descrambleMangaFire moves every tile but those of the last row and column back from
+// where the offset shuffled it to
+func descrambleMangaFire(img image.Image, offset int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pieceWidth := min(mgfPieceSize, (width+mgfMinSplitCount-1)/mgfMinSplitCount)
+	pieceHeight := min(mgfPieceSize, (height+mgfMinSplitCount-1)/mgfMinSplitCount)
+	if pieceWidth == 0 || pieceHeight == 0 {
+		return img
+	}
+	xMax := (width+pieceWidth-1)/pieceWidth - 1
+	yMax := (height+pieceHeight-1)/pieceHeight - 1
+
+	restored := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y <= yMax; y++ {
+		for x := 0; x <= xMax; x++ {
+			xDst, yDst := pieceWidth*x, pieceHeight*y
+			w, h := min(pieceWidth, width-xDst), min(pieceHeight, height-yDst)
+
+			xSrc, ySrc := xDst, yDst
+			if x < xMax {
+				xSrc = pieceWidth * ((xMax - x + offset) % xMax)
+			}
+			if y < yMax {
+				ySrc = pieceHeight * ((yMax - y + offset) % yMax)
+			}
+
+			draw.Draw(restored, image.Rect(xDst, yDst, xDst+w, yDst+h), img,
+				bounds.Min.Add(image.Pt(xSrc, ySrc)), draw.Src)
+		}
+	}
+	return restored
+}
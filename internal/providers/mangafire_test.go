@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"Luminary/pkg/engine/download"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMangaFireDescrambler(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 40, 40), color.Palette{color.Black, color.White})
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i % 2)
+	}
+	var pngData, gifData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	webpData := []byte("RIFF\x24\x00\x00\x00WEBPVP8 \x18\x00\x00\x00")
+
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		want    string // Page left behind, empty if it's removed
+		wantErr bool
+	}{
+		{"png", "001.png", pngData.Bytes(), "001.png", false},
+		{"gif becomes png", "001.gif", gifData.Bytes(), "001.png", false},
+		{"webp fails", "001.webp", webpData, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			d := mgfDescrambler{offsets: map[int]int{0: 3}}
+			pages, err := d.ProcessPage(context.Background(), download.PageFile{Index: 0, Path: path})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessPage() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			var left []string
+			for _, entry := range entries {
+				left = append(left, entry.Name())
+			}
+			if tt.want == "" {
+				if len(left) != 0 {
+					t.Errorf("files left = %v, want none", left)
+				}
+				return
+			}
+			if len(left) != 1 || left[0] != tt.want {
+				t.Fatalf("files left = %v, want [%s]", left, tt.want)
+			}
+			if len(pages) != 1 || pages[0].Path != filepath.Join(dir, tt.want) {
+				t.Errorf("pages = %v, want %s", pages, tt.want)
+			}
+			f, _ := os.Open(pages[0].Path)
+			defer f.Close()
+			if _, format, err := image.Decode(f); err != nil || format != "png" {
+				t.Errorf("page decodes as %q, %v, want png", format, err)
+			}
+		})
+	}
+}
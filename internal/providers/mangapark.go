@@ -0,0 +1,437 @@
+package providers
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"Luminary/pkg/provider/common"
+	"Luminary/pkg/provider/registry"
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MpkResp is the envelope of every answer of MangaPark's GraphQL endpoint
+type MpkResp[T any] struct {
+	Data   T `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors,omitempty"`
+}
+
+type MpkSearchData struct {
+	SearchComic struct {
+		Paging struct {
+			Pages int `json:"pages"`
+			Page  int `json:"page"`
+			Next  int `json:"next"`
+		} `json:"paging"`
+		Items []struct {
+			Data MpkComic `json:"data"`
+		} `json:"items"`
+	} `json:"get_searchComic"`
+}
+
+type MpkComicData struct {
+	ComicNode struct {
+		Data *MpkComic `json:"data"`
+	} `json:"get_comicNode"`
+}
+
+type MpkComic struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	AltNames       []string `json:"altNames"`
+	Authors        []string `json:"authors"`
+	Artists        []string `json:"artists"`
+	Genres         []string `json:"genres"`
+	OriginalStatus string   `json:"originalStatus"`
+	UploadStatus   string   `json:"uploadStatus"`
+	Summary        string   `json:"summary"`
+	URLPath        string   `json:"urlPath"`
+	URLCoverOri    string   `json:"urlCoverOri"`
+}
+
+type MpkChapterListData struct {
+	ChapterList []struct {
+		Data MpkChapter `json:"data"`
+	} `json:"get_comicChapterList"`
+}
+
+// MpkChapter is one release of a chapter. Chapters uploaded from several sources list the
+// other releases as duplicates.
+type MpkChapter struct {
+	ID         string `json:"id"`
+	DName      string `json:"dname"`
+	Title      string `json:"title"`
+	DateCreate int64  `json:"dateCreate"`
+	URLPath    string `json:"urlPath"`
+	SrcTitle   string `json:"srcTitle"`
+	UserNode   *struct {
+		Data *struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	} `json:"userNode"`
+	DupChapters []struct {
+		Data MpkChapter `json:"data"`
+	} `json:"dupChapters,omitempty"`
+}
+
+type MpkChapterPagesData struct {
+	ChapterNode struct {
+		Data *struct {
+			ID        string `json:"id"`
+			ComicID   string `json:"comicId"`
+			DName     string `json:"dname"`
+			Title     string `json:"title"`
+			ImageFile *struct {
+				URLList []string `json:"urlList"`
+			} `json:"imageFile"`
+		} `json:"data"`
+	} `json:"get_chapterNode"`
+}
+
+// GraphQL queries of the apo endpoint, trimmed to the fields the provider maps
+const (
+	mpkSearchQuery = `query($select: SearchComic_Select) {
+  get_searchComic(select: $select) {
+    paging { pages page next }
+    items { data { id name altNames authors artists genres originalStatus uploadStatus summary urlPath urlCoverOri } }
+  }
+}`
+	mpkComicQuery = `query($id: ID!) {
+  get_comicNode(id: $id) {
+    data { id name altNames authors artists genres originalStatus uploadStatus summary urlPath urlCoverOri }
+  }
+}`
+	mpkChapterFields = `id dname title dateCreate urlPath srcTitle userNode { data { name } }`
+	mpkChaptersQuery = `query($id: ID!) {
+  get_comicChapterList(comicId: $id) {
+    data { ` + mpkChapterFields + ` dupChapters { data { ` + mpkChapterFields + ` } } }
+  }
+}`
+	mpkPagesQuery = `query($id: ID!) {
+  get_chapterNode(id: $id) {
+    data { id comicId dname title imageFile { urlList } }
+  }
+}`
+)
+
+// mpkDefaultSearchLimit is the page size of searches without a limit
+const mpkDefaultSearchLimit = 20
+
+var (
+	// mpkDisplayName matches chapter display names like "Vol.2 Ch.10.5"
+	mpkDisplayName = regexp.MustCompile(`(?i)(?:vol(?:ume)?\.?\s*([\w.]+)\s+)?ch(?:apter)?\.?\s*(\d+(?:\.\d+)?)`)
+	// mpkTitlePath matches the start of title paths like /title/343921-en-one-piece
+	mpkTitlePath = regexp.MustCompile(`^/title/(\d+)(?:-([a-z]{2}(?:_[a-z]{2})?)-)?`)
+)
+
+// Register the provider automatically on startup
+func init() {
+	registry.Register(NewMangaParkProvider)
+}
+
+// NewMangaParkProvider creates the MangaPark provider. Everything goes through the
+// site's GraphQL endpoint; chapter IDs are "<manga-id>/<chapter-id>".
+func NewMangaParkProvider(e *engine.Engine) engine.Provider {
+	b := base.New(e, base.Config{
+		ID:          "mpk",
+		Name:        "MangaPark",
+		Description: "Read manga online, with chapters from several uploaders",
+		SiteURL:     "https://mangapark.net",
+		Type:        base.TypeAPI,
+
+		API: &base.APIConfig{
+			BaseURL: "https://mangapark.net/apo/",
+		},
+
+		Headers: map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+			"Referer":    "https://mangapark.net/",
+			// Show adult titles instead of hiding them from searches
+			"Cookie": "nsfw=2",
+		},
+
+		RateLimit: 1 * time.Second,
+		// The search matches alternative titles as well
+		SearchesAltTitles: true,
+
+		SelfTest: &engine.SelfTestFixture{Query: "One Piece", MangaID: "343921"},
+	})
+
+	p := b.Build().(*base.Provider)
+	return b.WithSearch(customMangaParkSearch(p)).
+		WithGetManga(customMangaParkGetManga(p)).
+		WithGetChapter(customMangaParkGetChapter(p)).
+		WithResolveChapterURL(resolveMangaParkChapterURL).
+		WithResolveMangaURL(resolveMangaParkMangaURL).
+		WithURLFor(mangaParkURLFor).
+		Build()
+}
+
+// mangaParkQuery posts a GraphQL query and decodes its data into out
+func mangaParkQuery[T any](ctx context.Context, p *base.Provider, endpoint, query string, variables map[string]interface{}, out *T) error {
+	req := p.NewRequest(p.Config.API.BaseURL)
+	req.Method = "POST"
+	req.Endpoint = endpoint
+	req.JSONData = map[string]interface{}{"query": query, "variables": variables}
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return errors.TP(err, p.ID())
+	}
+
+	var envelope MpkResp[T]
+	if err := resp.JSON(&envelope); err != nil {
+		return errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+	if len(envelope.Errors) > 0 {
+		return errors.Newf("%s query failed: %s", endpoint, envelope.Errors[0].Message).
+			WithContext("provider_id", p.ID()).
+			AsProvider(p.ID()).Error()
+	}
+	*out = envelope.Data
+	return nil
+}
+
+// customMangaParkSearch searches comics by relevance, page by page
+func customMangaParkSearch(p *base.Provider) func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+	return func(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+		limit := options.Limit
+		if limit <= 0 {
+			limit = mpkDefaultSearchLimit
+		}
+		pages := max(options.Pages, 1)
+
+		var results []core.Manga
+		for page := 1; page <= pages; page++ {
+			variables := map[string]interface{}{
+				"select": map[string]interface{}{
+					"word":   query,
+					"page":   page,
+					"size":   limit,
+					"sortby": "field_score",
+				},
+			}
+			var data MpkSearchData
+			if err := mangaParkQuery(ctx, p, "search", mpkSearchQuery, variables, &data); err != nil {
+				return nil, err
+			}
+
+			for _, item := range data.SearchComic.Items {
+				results = append(results, mapMangaParkComic(item.Data))
+				if len(results) >= limit {
+					return results, nil
+				}
+			}
+			if data.SearchComic.Paging.Next == 0 || len(data.SearchComic.Items) == 0 {
+				break
+			}
+		}
+
+		return results, nil
+	}
+}
+
+// customMangaParkGetManga fetches a comic and all releases of its chapters
+func customMangaParkGetManga(p *base.Provider) func(context.Context, string) (*core.MangaInfo, error) {
+	return func(ctx context.Context, id string) (*core.MangaInfo, error) {
+		var data MpkComicData
+		if err := mangaParkQuery(ctx, p, "manga", mpkComicQuery, map[string]interface{}{"id": id}, &data); err != nil {
+			return nil, errors.Track(err).WithContext("manga_id", id).Error()
+		}
+		if data.ComicNode.Data == nil {
+			return nil, errors.Newf("no manga found with ID %s", id).
+				WithContext("manga_id", id).
+				AsNotFound().Error()
+		}
+
+		comic := *data.ComicNode.Data
+		info := &core.MangaInfo{Manga: mapMangaParkComic(comic)}
+		info.Description = comic.Summary
+		info.AlternativeTitles = comic.AltNames
+		info.Authors = comic.Authors
+		info.Artists = comic.Artists
+		info.Status = strings.ToLower(comic.OriginalStatus)
+		info.Tags = comic.Genres
+
+		chapters, err := fetchMangaParkChapters(ctx, p, id)
+		if err != nil {
+			// Show the manga even if the chapter list fails
+			p.Engine.Logger.Warn("Failed to fetch chapters of %s: %v", id, err)
+		}
+		info.Chapters = chapters
+		if _, lang := mangaParkPathIDs(comic.URLPath); lang != "" {
+			info.AvailableLanguages = []string{lang}
+		}
+
+		return info, nil
+	}
+}
+
+// fetchMangaParkChapters lists every release of every chapter in reading order. Each
+// release names its uploader as the group, so the scanlator preference picks between
+// the copies of a chapter.
+func fetchMangaParkChapters(ctx context.Context, p *base.Provider, mangaID string) ([]core.ChapterInfo, error) {
+	var data MpkChapterListData
+	if err := mangaParkQuery(ctx, p, "chapters", mpkChaptersQuery, map[string]interface{}{"id": mangaID}, &data); err != nil {
+		return nil, err
+	}
+
+	var chapters []core.ChapterInfo
+	for i, item := range data.ChapterList {
+		releases := []MpkChapter{item.Data}
+		for _, dup := range item.Data.DupChapters {
+			releases = append(releases, dup.Data)
+		}
+		for _, release := range releases {
+			chapter := mapMangaParkChapter(mangaID, release)
+			// Copies of a chapter share its place in the reading order
+			chapter.Sequence = i + 1
+			chapters = append(chapters, chapter)
+		}
+	}
+	return chapters, nil
+}
+
+// customMangaParkGetChapter resolves the image list of a chapter release
+func customMangaParkGetChapter(p *base.Provider) func(context.Context, string) (*core.Chapter, error) {
+	return func(ctx context.Context, chapterID string) (*core.Chapter, error) {
+		mangaID, releaseID, ok := strings.Cut(chapterID, "/")
+		if !ok || mangaID == "" || releaseID == "" {
+			return nil, errors.Newf("invalid MangaPark chapter ID: %s", chapterID).
+				WithMessage("MangaPark chapter IDs look like <manga-id>/<chapter-id>").
+				AsProvider(p.ID()).Error()
+		}
+
+		var data MpkChapterPagesData
+		if err := mangaParkQuery(ctx, p, "chapter", mpkPagesQuery, map[string]interface{}{"id": releaseID}, &data); err != nil {
+			return nil, errors.Track(err).WithContext("chapter_id", chapterID).Error()
+		}
+		node := data.ChapterNode.Data
+		if node == nil {
+			return nil, errors.Newf("chapter %s not found", chapterID).
+				WithContext("chapter_id", chapterID).
+				AsNotFound().Error()
+		}
+
+		chapter := &core.Chapter{
+			MangaID: mangaID,
+			Info:    mapMangaParkChapter(mangaID, MpkChapter{ID: node.ID, DName: node.DName, Title: node.Title}),
+		}
+		if node.ImageFile != nil {
+			for i, imageURL := range node.ImageFile.URLList {
+				chapter.Pages = append(chapter.Pages, core.Page{Index: i, URL: imageURL})
+			}
+		}
+		if len(chapter.Pages) == 0 {
+			return nil, errors.Newf("no pages found for chapter %s", chapterID).
+				WithContext("chapter_id", chapterID).
+				AsProvider(p.ID()).Error()
+		}
+		chapter.Info.PageCount = len(chapter.Pages)
+
+		return chapter, nil
+	}
+}
+
+// mapMangaParkComic maps a comic to a search result
+func mapMangaParkComic(comic MpkComic) core.Manga {
+	manga := core.Manga{
+		ID:            comic.ID,
+		Title:         comic.Name,
+		CoverURL:      comic.URLCoverOri,
+		Demographic:   common.InferDemographic(comic.Genres),
+		ContentRating: common.InferContentRating(comic.Genres),
+	}
+	if strings.HasPrefix(manga.CoverURL, "/") {
+		manga.CoverURL = "https://mangapark.net" + manga.CoverURL
+	}
+	return manga
+}
+
+// mapMangaParkChapter maps a chapter release; its display name carries the volume and
+// the number
+func mapMangaParkChapter(mangaID string, release MpkChapter) core.ChapterInfo {
+	chapter := core.ChapterInfo{
+		ID:    mangaID + "/" + release.ID,
+		Title: strings.TrimSpace(strings.TrimLeft(release.Title, ":- ")),
+		Label: strings.TrimSpace(release.DName),
+	}
+	if match := mpkDisplayName.FindStringSubmatch(release.DName); match != nil {
+		chapter.Volume = match[1]
+		chapter.Label = match[2]
+		chapter.Number, _ = strconv.ParseFloat(match[2], 64)
+	}
+	if release.DateCreate > 0 {
+		date := time.UnixMilli(release.DateCreate)
+		chapter.Date = &date
+	}
+	if _, lang := mangaParkPathIDs(release.URLPath); lang != "" {
+		chapter.Language = lang
+	}
+
+	// The source a release was taken from names it best, its uploader otherwise
+	chapter.Group = release.SrcTitle
+	if chapter.Group == "" && release.UserNode != nil && release.UserNode.Data != nil {
+		chapter.Group = release.UserNode.Data.Name
+	}
+	return chapter
+}
+
+// mangaParkPathIDs returns the manga ID and the language of title paths like
+// /title/343921-en-one-piece/9152651-ch-1100
+func mangaParkPathIDs(path string) (mangaID, lang string) {
+	match := mpkTitlePath.FindStringSubmatch(path)
+	if match == nil {
+		return "", ""
+	}
+	return match[1], strings.ReplaceAll(match[2], "_", "-")
+}
+
+// resolveMangaParkChapterURL maps reader URLs like
+// https://mangapark.net/title/343921-en-one-piece/9152651-ch-1100 to their chapter ID
+func resolveMangaParkChapterURL(u *url.URL) (string, error) {
+	mangaID, _ := mangaParkPathIDs(u.Path)
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if mangaID != "" && len(segments) >= 3 {
+		if releaseID, _, _ := strings.Cut(segments[2], "-"); releaseID != "" {
+			if _, err := strconv.Atoi(releaseID); err == nil {
+				return mangaID + "/" + releaseID, nil
+			}
+		}
+	}
+	return "", errors.Newf("not a MangaPark chapter URL: %s", u.String()).
+		WithMessage("Expected a chapter URL like https://mangapark.net/title/<manga>/<chapter>").
+		AsProvider("mpk").Error()
+}
+
+// resolveMangaParkMangaURL maps title URLs like https://mangapark.net/title/343921-en-one-piece
+// to their manga ID
+func resolveMangaParkMangaURL(u *url.URL) (string, error) {
+	mangaID, _ := mangaParkPathIDs(u.Path)
+	// Reader URLs extend the title path with the chapter
+	if mangaID == "" || strings.Count(strings.Trim(u.Path, "/"), "/") > 1 {
+		return "", errors.Newf("not a MangaPark title URL: %s", u.String()).
+			WithMessage("Expected a title URL like https://mangapark.net/title/<manga>").
+			AsProvider("mpk").Error()
+	}
+	return mangaID, nil
+}
+
+// mangaParkURLFor returns the title page of a manga or the reader page of a chapter;
+// the site redirects IDs without their slugs
+func mangaParkURLFor(kind, id string) (string, error) {
+	switch kind {
+	case engine.URLKindManga:
+		return "https://mangapark.net/title/" + id, nil
+	case engine.URLKindChapter:
+		return "https://mangapark.net/title/" + id, nil
+	default:
+		return "", errors.Newf("unknown URL kind %q", kind).AsProvider("mpk").Error()
+	}
+}
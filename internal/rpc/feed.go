@@ -0,0 +1,315 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+	"crypto/subtle"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// feedItemLimit is how many items a feed lists at most
+const feedItemLimit = 100
+
+// Feeds serves RSS and Atom feeds of the chapters that appeared on followed series: the
+// series in the download history. /feed.rss and /feed.atom list every series, and a
+// "series=provider:manga-id" parameter narrows them to one. With a token set, requests
+// must carry it as a bearer token or a "token" parameter, which feed readers can keep in
+// the URL.
+type Feeds struct {
+	engine *engine.Engine
+	token  string
+}
+
+// NewFeeds creates the feed endpoint. Call Run to look for new chapters.
+func NewFeeds(eng *engine.Engine, token string) (*Feeds, error) {
+	if eng.Feed == nil || eng.Library == nil {
+		return nil, errors.New("download history is not available").
+			WithMessage("Feeds need the download history in ~/.luminary to know which series you follow").
+			AsFileSystem().Error()
+	}
+	return &Feeds{engine: eng, token: token}, nil
+}
+
+// Run checks the followed series for new chapters right away and then every interval,
+// until ctx ends
+func (f *Feeds) Run(ctx context.Context, interval time.Duration) {
+	for {
+		added, err := f.engine.CheckFeed(ctx)
+		if err != nil {
+			f.engine.Logger.Warn("Feed check failed: %v", err)
+		} else {
+			f.engine.Logger.Info("Feed check found %d new chapters", len(added))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ServeHTTP serves a feed, as RSS for paths ending in .rss and as Atom otherwise
+func (f *Feeds) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		http.Error(rw, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	if f.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			token = r.FormValue("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(f.token)) != 1 {
+			http.Error(rw, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var format string
+	switch r.URL.Path {
+	case "/feed.rss":
+		format = "rss"
+	case "/", "/feed.atom":
+		format = "atom"
+	default:
+		http.NotFound(rw, r)
+		return
+	}
+
+	var provider, mangaID string
+	if series := strings.TrimSpace(r.FormValue("series")); series != "" {
+		var ok bool
+		if provider, mangaID, ok = strings.Cut(series, ":"); !ok || provider == "" || mangaID == "" {
+			http.Error(rw, "series must be provider:manga-id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	items, err := f.engine.Feed.Items(provider, mangaID)
+	if err != nil {
+		f.engine.Logger.Error("Failed to read the feed: %v", err)
+		http.Error(rw, "failed to read the feed", http.StatusInternalServerError)
+		return
+	}
+	if len(items) > feedItemLimit {
+		items = items[:feedItemLimit]
+	}
+
+	channel := feedChannel{
+		Title: "Luminary: new chapters",
+		Self:  requestScheme(r) + "://" + r.Host + r.URL.RequestURI(),
+		ID:    "urn:luminary:feed",
+		Items: items,
+	}
+	if provider != "" {
+		channel.ID += ":" + provider + ":" + mangaID
+		channel.Title = "Luminary: new chapters of " + provider + ":" + mangaID
+		if len(items) > 0 {
+			channel.Title = "Luminary: new chapters of " + items[0].MangaTitle
+			channel.Link = items[0].MangaURL
+		}
+	}
+
+	var doc interface{}
+	contentType := "application/atom+xml; charset=utf-8"
+	if format == "rss" {
+		doc, contentType = channel.rss(), "application/rss+xml; charset=utf-8"
+	} else {
+		doc = channel.atom()
+	}
+
+	rw.Header().Set("Content-Type", contentType)
+	_, _ = rw.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(rw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		f.engine.Logger.Warn("Failed to write a feed: %v", err)
+	}
+}
+
+// requestScheme returns the scheme a request was made with: https when it came over TLS or
+// a proxy in front says so with X-Forwarded-Proto, http otherwise
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	if strings.EqualFold(strings.TrimSpace(proto), "https") {
+		return "https"
+	}
+	return "http"
+}
+
+// feedChannel is a feed before it is written in either format
+type feedChannel struct {
+	Title string
+	Self  string // URL the feed was requested at
+	Link  string // Page the feed is about, if any
+	ID    string
+	Items []library.FeedItem
+}
+
+// feedItemTitle names a chapter like "One Piece - Chapter 1100: The Title"
+func feedItemTitle(item library.FeedItem) string {
+	title := item.MangaTitle
+	if title == "" {
+		title = item.Provider + ":" + item.MangaID
+	}
+	title += " - Chapter " + item.Chapter
+	if item.Title != "" {
+		title += ": " + item.Title
+	}
+	return title
+}
+
+// feedItemSummary describes a chapter's release
+func feedItemSummary(item library.FeedItem) string {
+	var parts []string
+	if item.Group != "" {
+		parts = append(parts, "by "+item.Group)
+	}
+	if item.Language != "" {
+		parts = append(parts, "in "+item.Language)
+	}
+	parts = append(parts, fmt.Sprintf("on %s, download with: luminary download %s:%s", item.Provider, item.Provider, item.ChapterID))
+	return "Released " + strings.Join(parts, " ")
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rss returns the feed as RSS 2.0
+func (c feedChannel) rss() rssFeed {
+	channel := rssChannel{
+		Title:       c.Title,
+		Link:        c.Link,
+		Description: "Chapters that appeared on the series followed in Luminary",
+	}
+	if channel.Link == "" {
+		channel.Link = c.Self
+	}
+	if len(c.Items) > 0 {
+		channel.LastBuildDate = c.Items[0].Detected.Format(time.RFC1123Z)
+	}
+	for _, item := range c.Items {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       feedItemTitle(item),
+			Link:        item.URL,
+			Description: feedItemSummary(item),
+			GUID:        rssGUID{Value: item.Provider + ":" + item.ChapterID},
+			PubDate:     item.Detected.Format(time.RFC1123Z),
+			Category:    item.MangaTitle,
+		})
+	}
+	return rssFeed{Version: "2.0", Channel: channel}
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published,omitempty"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+}
+
+// atom returns the feed as Atom 1.0
+func (c feedChannel) atom() atomFeed {
+	feed := atomFeed{
+		Title:   c.Title,
+		ID:      c.ID,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: c.Self, Rel: "self"}},
+		Author:  atomAuthor{Name: "Luminary"},
+	}
+	if c.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: c.Link, Rel: "alternate"})
+	}
+	if len(c.Items) > 0 {
+		feed.Updated = c.Items[0].Detected.UTC().Format(time.RFC3339)
+	}
+	for _, item := range c.Items {
+		entry := atomEntry{
+			Title:   feedItemTitle(item),
+			ID:      "urn:luminary:chapter:" + item.Provider + ":" + item.ChapterID,
+			Updated: item.Detected.UTC().Format(time.RFC3339),
+			Summary: feedItemSummary(item),
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.URL != "" {
+			entry.Links = []atomLink{{Href: item.URL, Rel: "alternate"}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
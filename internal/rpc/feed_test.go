@@ -0,0 +1,62 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeedSelfLinkKeepsScheme(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	feeds, err := NewFeeds(engine.New(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		tls   bool
+		proto string
+		want  string
+	}{
+		{"plain", false, "", `href="http://feeds.example/feed.atom"`},
+		{"tls", true, "", `href="https://feeds.example/feed.atom"`},
+		{"behind a proxy", false, "https", `href="https://feeds.example/feed.atom"`},
+		{"behind proxies", false, "HTTPS, http", `href="https://feeds.example/feed.atom"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://feeds.example/feed.atom", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			rec := httptest.NewRecorder()
+			feeds.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("got %d %s, want a self link %s", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000 // Application errors; data.category holds the error category
+	CodeCancelled      = -32001 // The call was aborted through Operations.Cancel
+	CodeShuttingDown   = -32002 // The server is draining and accepts no new calls
+	CodeRateLimited    = -32003 // The client sent more requests than its rate limit allows
+	CodeTooManyConns   = -32004 // The connection was refused because the server is full
+)
+
+// maxLineSize bounds a single request line (batches included)
+const maxLineSize = 16 * 1024 * 1024
+
+// Request is a JSON-RPC request. Requests without "jsonrpc": "2.0" are treated as
+// JSON-RPC 1.0 (the format of Go's net/rpc/jsonrpc) and answered in kind.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
+}
+
+// Response is a JSON-RPC 2.0 response; exactly one of Result and Error is set
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Notification is a JSON-RPC 2.0 notification sent by the server
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// legacyResponse is a JSON-RPC 1.0 response as produced by net/rpc/jsonrpc
+type legacyResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorData is attached to application errors
+type ErrorData struct {
+	Category string `json:"category"`
+	// Code refines the category where the cause is known, e.g. "network_timeout"
+	Code          string                 `json:"code,omitempty"`
+	Context       map[string]interface{} `json:"context,omitempty"`
+	CallChain     []errors.CallJSON      `json:"call_chain,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+}
+
+var (
+	typeOfError   = reflect.TypeOf((*error)(nil)).Elem()
+	typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// method is an exported service method of the form
+// M(req *Req, resp *Resp) error or M(ctx context.Context, req *Req, resp *Resp) error
+type method struct {
+	service   string
+	fn        reflect.Value
+	withCtx   bool
+	argType   reflect.Type
+	replyType reflect.Type
+}
+
+// register exposes the suitable methods of rcvr as "name.Method"
+func (s *Server) register(name string, rcvr interface{}) error {
+	v := reflect.ValueOf(rcvr)
+	t := v.Type()
+	count := 0
+
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		mt := m.Type
+
+		// Receiver is In(0)
+		in := mt.NumIn() - 1
+		offset := 1
+		withCtx := in == 3 && mt.In(1) == typeOfContext
+		if withCtx {
+			offset = 2
+		} else if in != 2 {
+			continue
+		}
+
+		argType, replyType := mt.In(offset), mt.In(offset+1)
+		if argType.Kind() != reflect.Ptr || replyType.Kind() != reflect.Ptr ||
+			mt.NumOut() != 1 || mt.Out(0) != typeOfError {
+			continue
+		}
+
+		s.methods[name+"."+m.Name] = &method{
+			service:   name,
+			fn:        v.Method(i),
+			withCtx:   withCtx,
+			argType:   argType.Elem(),
+			replyType: replyType.Elem(),
+		}
+		count++
+	}
+
+	if count == 0 {
+		return errors.Newf("service %s has no suitable methods", name).Error()
+	}
+	return nil
+}
+
+// ServeConn reads newline-delimited requests from conn and writes responses to it until
+// the input ends. Requests are handled concurrently; responses may arrive out of order.
+func (s *Server) ServeConn(ctx context.Context, conn io.ReadWriter) error {
+	return s.serveConn(ctx, conn, nil)
+}
+
+// serveConn serves a connection whose requests are throttled by bucket (nil for no limit)
+func (s *Server) serveConn(ctx context.Context, conn io.ReadWriter, bucket *tokenBucket) error {
+	reader := bufio.NewReaderSize(conn, 64*1024)
+	return s.serveMessages(ctx, conn, func() ([]byte, error) { return readLine(reader) }, bucket)
+}
+
+// serveMessages handles the messages next returns, each a request or a batch, and writes
+// the responses to conn until next fails. io.EOF ends the connection without an error.
+func (s *Server) serveMessages(ctx context.Context, conn io.Writer, next func() ([]byte, error), bucket *tokenBucket) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		writeMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	write := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			s.engine.Logger.Error("Failed to encode RPC response: %v", err)
+			return
+		}
+
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			s.engine.Logger.Error("Failed to write RPC response: %v", err)
+		}
+	}
+
+	c := &connection{write: write, cancel: cancel, bucket: bucket}
+	defer s.lifecycle.addConn(c)()
+	defer c.closeSubscriptions()
+	ctx = withConnection(ctx, c)
+
+	for {
+		message, readErr := next()
+		if message = bytes.TrimSpace(message); len(message) > 0 {
+			wg.Add(1)
+			go func(message []byte) {
+				defer wg.Done()
+				if resp := s.handleMessage(ctx, message); resp != nil {
+					write(resp)
+				}
+			}(message)
+		}
+
+		if readErr != nil {
+			wg.Wait()
+			if readErr == io.EOF {
+				return nil
+			}
+			return errors.Track(readErr).WithMessage("Failed to read RPC request").Error()
+		}
+	}
+}
+
+// Serve accepts connections on l and serves each of them until l is closed
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return errors.Track(err).WithMessage("Failed to accept RPC connection").Error()
+		}
+
+		bucket, release, ok := s.clients.acquire(conn, s.limits)
+		if !ok {
+			s.engine.Logger.Warn("Refused RPC client %s: %d connections open", conn.RemoteAddr(), s.limits.MaxConnections)
+			refuseConn(conn)
+			continue
+		}
+
+		go func(conn net.Conn) {
+			defer release()
+			defer conn.Close()
+
+			s.engine.Logger.Info("RPC client connected: %s", conn.RemoteAddr())
+			if err := s.serveConn(ctx, conn, bucket); err != nil {
+				s.engine.Logger.Error("RPC connection failed: %v", err)
+			}
+			s.engine.Logger.Info("RPC client disconnected: %s", conn.RemoteAddr())
+		}(conn)
+	}
+}
+
+// refuseConn tells a client the server is full and closes its connection
+func refuseConn(conn net.Conn) {
+	defer conn.Close()
+
+	data, _ := json.Marshal(errorResponse(nil, &Error{Code: CodeTooManyConns, Message: "too many connections"}))
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_, _ = conn.Write(append(data, '\n'))
+}
+
+// readLine reads one line, rejecting lines longer than maxLineSize
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		line = append(line, chunk...)
+		if len(line) > maxLineSize {
+			return nil, errors.Newf("request exceeds %d bytes", maxLineSize).Error()
+		}
+		if err != nil || !isPrefix {
+			return line, err
+		}
+	}
+}
+
+// handleMessage handles a single request or a batch, returning what to write back (nil for none)
+func (s *Server) handleMessage(ctx context.Context, data []byte) interface{} {
+	if data[0] != '[' {
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error: " + err.Error()})
+		}
+		return s.handleRequest(ctx, &req)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error: " + err.Error()})
+	}
+	if len(batch) == 0 {
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"})
+	}
+
+	// Batch entries run concurrently; the reply keeps the request order
+	replies := make([]interface{}, len(batch))
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		wg.Add(1)
+		go func(i int, raw json.RawMessage) {
+			defer wg.Done()
+			var req Request
+			if err := json.Unmarshal(raw, &req); err != nil {
+				replies[i] = errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "invalid request: " + err.Error()})
+				return
+			}
+			replies[i] = s.handleRequest(ctx, &req)
+		}(i, raw)
+	}
+	wg.Wait()
+
+	var responses []interface{}
+	for _, r := range replies {
+		if r != nil {
+			responses = append(responses, r)
+		}
+	}
+
+	// A batch of notifications gets no reply at all
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// handleRequest calls the requested method and builds its response; nil for notifications
+func (s *Server) handleRequest(ctx context.Context, req *Request) interface{} {
+	legacy := req.JSONRPC != "2.0"
+	notification := !legacy && req.ID == nil
+
+	result, rpcErr := s.call(ctx, req)
+
+	if notification {
+		if rpcErr != nil {
+			s.engine.Logger.Warn("RPC notification %s failed: %s", req.Method, rpcErr.Message)
+		}
+		return nil
+	}
+
+	id := req.ID
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	if legacy {
+		resp := legacyResponse{ID: id, Result: json.RawMessage("null")}
+		if rpcErr != nil {
+			resp.Error = rpcErr.Message
+		} else {
+			resp.Result = result
+		}
+		return resp
+	}
+
+	if rpcErr != nil {
+		return errorResponse(id, rpcErr)
+	}
+	return &Response{JSONRPC: "2.0", Result: result, ID: id}
+}
+
+// call decodes the params, invokes the method and encodes its result
+func (s *Server) call(ctx context.Context, req *Request) (result json.RawMessage, rpcErr *Error) {
+	if req.Method == "" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "missing method"}
+	}
+
+	m, ok := s.lookup(req.Method)
+	if !ok {
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if c := connectionFrom(ctx); c != nil && !c.bucket.allow() {
+		return nil, &Error{
+			Code:    CodeRateLimited,
+			Message: "rate limit exceeded, slow down",
+			Data:    ErrorData{Category: string(errors.CategoryRateLimit)},
+		}
+	}
+
+	// Meta calls keep working while draining so clients can watch the shutdown
+	if !s.lifecycle.enter(m.service == "Meta") {
+		return nil, &Error{Code: CodeShuttingDown, Message: "server is shutting down"}
+	}
+	defer s.lifecycle.leave()
+
+	// Every call gets its own correlation ID so its log lines can be told apart
+	id := core.NewCorrelationID()
+	ctx = core.WithCorrelationID(ctx, id)
+	log := s.engine.Log(ctx)
+	log.Debug("RPC %s started (request id %s)", req.Method, string(req.ID))
+
+	start := time.Now()
+	defer func() {
+		if rpcErr != nil {
+			log.Debug("RPC %s failed after %v: %s", req.Method, time.Since(start), rpcErr.Message)
+		} else {
+			log.Debug("RPC %s finished after %v", req.Method, time.Since(start))
+		}
+	}()
+
+	arg := reflect.New(m.argType)
+	if err := decodeParams(req.Params, arg.Interface()); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	reply := reflect.New(m.replyType)
+
+	// Calls carrying an operation ID can be aborted through Operations.Cancel, and those
+	// carrying a timeout get that instead of the computed time budgets
+	callCtx := ctx
+	if op, ok := arg.Interface().(cancellable); ok {
+		if op.operation() != "" {
+			opCtx, done, err := s.operations.start(ctx, op.operation())
+			if err != nil {
+				return nil, &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+			}
+			defer done()
+			ctx = opCtx
+		}
+		if timeout := op.timeout(); timeout > 0 {
+			opCtx, cancel := network.WithOverride(ctx, timeout)
+			defer cancel()
+			ctx = opCtx
+		}
+	}
+
+	// A panicking handler must not take down the whole server
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("RPC method %s panicked: %v\n%s", req.Method, r, debug.Stack())
+			s.engine.ReportError(errors.AddContext(errors.Recovered(r), "rpc_method", req.Method))
+			result, rpcErr = nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+
+	args := []reflect.Value{arg, reply}
+	if m.withCtx {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+
+	if errVal := m.fn.Call(args)[0]; !errVal.IsNil() {
+		if context.Cause(ctx) == errOperationCancelled {
+			return nil, &Error{
+				Code:    CodeCancelled,
+				Message: "operation cancelled",
+				Data:    ErrorData{Category: "cancelled", CorrelationID: id},
+			}
+		}
+		err := errVal.Interface().(error)
+		if budgetErr := network.BudgetError(ctx, callCtx); budgetErr != nil {
+			err = budgetErr
+		}
+		err = errors.AddContext(err, "correlation_id", id)
+		s.engine.ReportError(err)
+		return nil, toRPCError(err, id)
+	}
+
+	data, err := json.Marshal(reply.Interface())
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: "failed to encode result: " + err.Error()}
+	}
+	return data, nil
+}
+
+// lookup finds a method, accepting both "Search.Search" and "SearchService.Search"
+func (s *Server) lookup(name string) (*method, bool) {
+	if m, ok := s.methods[name]; ok {
+		return m, true
+	}
+
+	service, methodName, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+	m, ok := s.methods[strings.TrimSuffix(service, "Service")+"."+methodName]
+	return m, ok
+}
+
+// decodeParams accepts params by position (an array holding the argument object, as sent
+// by net/rpc clients) or by name (the argument object itself)
+func decodeParams(params json.RawMessage, arg interface{}) error {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+
+	if params[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return err
+		}
+		switch len(positional) {
+		case 0:
+			return nil
+		case 1:
+			params = positional[0]
+		default:
+			return fmt.Errorf("expected a single argument object, got %d", len(positional))
+		}
+	}
+
+	return json.Unmarshal(params, arg)
+}
+
+// toRPCError converts a method error into a JSON-RPC error object
+func toRPCError(err error, correlationID string) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	details := errors.ToJSON(err)
+	return &Error{
+		Code:    CodeServerError,
+		Message: err.Error(),
+		Data: ErrorData{
+			Category:      details.Category,
+			Code:          details.Code,
+			Context:       details.Context,
+			CallChain:     details.CallChain,
+			CorrelationID: correlationID,
+		},
+	}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", Error: err, ID: id}
+}
@@ -0,0 +1,226 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ShutdownNotification is the method of the notification sent to every connection
+// when the server starts draining
+const ShutdownNotification = "Meta.Shutdown"
+
+// cancelGracePeriod is how long cancelled calls get to answer before Shutdown returns
+const cancelGracePeriod = time.Second
+
+// ShutdownParams are the params of the shutdown notification
+type ShutdownParams struct {
+	Reason   string `json:"reason"`
+	InFlight int    `json:"in_flight"`
+}
+
+// connection is a served connection the server can notify and abort
+type connection struct {
+	write  func(interface{})
+	cancel context.CancelFunc
+	bucket *tokenBucket // Request rate limit; nil for none
+
+	mu            sync.Mutex
+	subscriptions map[string]func()
+	closed        bool // Set once the connection is gone; it takes no more subscriptions
+}
+
+type connectionKey struct{}
+
+// withConnection makes the connection available to the methods called over it
+func withConnection(ctx context.Context, c *connection) context.Context {
+	return context.WithValue(ctx, connectionKey{}, c)
+}
+
+// connectionFrom returns the connection a call arrived on, or nil
+func connectionFrom(ctx context.Context) *connection {
+	c, _ := ctx.Value(connectionKey{}).(*connection)
+	return c
+}
+
+// subscribe remembers a function ending an event subscription of this connection,
+// reporting false if the connection is gone already; the caller ends the subscription then
+func (c *connection) subscribe(id string, stop func()) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]func())
+	}
+	c.subscriptions[id] = stop
+	return true
+}
+
+// unsubscribe ends a subscription, reporting whether it existed
+func (c *connection) unsubscribe(id string) bool {
+	c.mu.Lock()
+	stop, exists := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.mu.Unlock()
+
+	if exists {
+		stop()
+	}
+	return exists
+}
+
+// closeSubscriptions ends every subscription once the connection is gone
+func (c *connection) closeSubscriptions() {
+	c.mu.Lock()
+	subscriptions := c.subscriptions
+	c.subscriptions = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	for _, stop := range subscriptions {
+		stop()
+	}
+}
+
+// lifecycle tracks connections and in-flight calls so the server can drain on shutdown
+type lifecycle struct {
+	mu       sync.Mutex
+	started  time.Time
+	draining bool
+	active   int
+	idle     chan struct{} // Closed once draining and no call is active
+	idleOnce sync.Once
+	conns    map[int]*connection
+	nextConn int
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		started: time.Now(),
+		idle:    make(chan struct{}),
+		conns:   make(map[int]*connection),
+	}
+}
+
+// addConn registers a connection and returns a function removing it again
+func (l *lifecycle) addConn(c *connection) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.nextConn
+	l.nextConn++
+	l.conns[id] = c
+
+	return func() {
+		l.mu.Lock()
+		delete(l.conns, id)
+		l.mu.Unlock()
+	}
+}
+
+// enter marks a call as in flight. While draining only calls that are allowed
+// anyway (such as pings) are accepted.
+func (l *lifecycle) enter(allowWhileDraining bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.draining && !allowWhileDraining {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// leave marks a call as finished
+func (l *lifecycle) leave() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if l.draining && l.active == 0 {
+		l.closeIdle()
+	}
+}
+
+// closeIdle closes idle; pings admitted while draining reach zero calls again after it
+// was closed
+func (l *lifecycle) closeIdle() {
+	l.idleOnce.Do(func() { close(l.idle) })
+}
+
+// beginDrain stops accepting calls and returns the connections to notify
+func (l *lifecycle) beginDrain() ([]*connection, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.draining {
+		l.draining = true
+		if l.active == 0 {
+			l.closeIdle()
+		}
+	}
+
+	conns := make([]*connection, 0, len(l.conns))
+	for _, c := range l.conns {
+		conns = append(conns, c)
+	}
+	return conns, l.active
+}
+
+// status reports whether the server is draining and how many calls are in flight
+func (l *lifecycle) status() (draining bool, active int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.draining, l.active
+}
+
+// Shutdown stops accepting new calls, notifies every connection and waits for the
+// calls in flight. Calls still running when ctx ends are cancelled.
+func (s *Server) Shutdown(ctx context.Context, reason string) error {
+	conns, active := s.lifecycle.beginDrain()
+	s.engine.Logger.Info("RPC server draining (%s), %d calls in flight", reason, active)
+
+	notification := &Notification{
+		JSONRPC: "2.0",
+		Method:  ShutdownNotification,
+		Params:  ShutdownParams{Reason: reason, InFlight: active},
+	}
+	for _, c := range conns {
+		c.write(notification)
+	}
+
+	select {
+	case <-s.lifecycle.idle:
+		return nil
+	case <-ctx.Done():
+		for _, c := range conns {
+			c.cancel()
+		}
+
+		// Give the cancelled calls a moment to send their error responses
+		select {
+		case <-s.lifecycle.idle:
+		case <-time.After(cancelGracePeriod):
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLifecycleDrain(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(l *lifecycle)
+		idle bool
+	}{
+		{
+			name: "idle server drains at once",
+			run:  func(l *lifecycle) { l.beginDrain() },
+			idle: true,
+		},
+		{
+			name: "drain waits for the call in flight",
+			run: func(l *lifecycle) {
+				l.enter(false)
+				l.beginDrain()
+			},
+			idle: false,
+		},
+		{
+			name: "drain ends with the last call",
+			run: func(l *lifecycle) {
+				l.enter(false)
+				l.enter(false)
+				l.beginDrain()
+				l.leave()
+				l.leave()
+			},
+			idle: true,
+		},
+		{
+			name: "ping after the drain ended",
+			run: func(l *lifecycle) {
+				l.beginDrain()
+				l.enter(true)
+				l.leave()
+			},
+			idle: true,
+		},
+		{
+			name: "drain started twice",
+			run: func(l *lifecycle) {
+				l.beginDrain()
+				l.beginDrain()
+			},
+			idle: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLifecycle()
+			tt.run(l)
+
+			select {
+			case <-l.idle:
+				if !tt.idle {
+					t.Fatal("idle closed while a call is in flight")
+				}
+			default:
+				if tt.idle {
+					t.Fatal("idle not closed")
+				}
+			}
+		})
+	}
+}
+
+func TestLifecycleRefusesCallsWhileDraining(t *testing.T) {
+	l := newLifecycle()
+	l.beginDrain()
+
+	if l.enter(false) {
+		t.Error("call admitted while draining")
+	}
+	if !l.enter(true) {
+		t.Error("ping refused while draining")
+	}
+	l.leave()
+
+	if draining, active := l.status(); !draining || active != 0 {
+		t.Errorf("status() = %v, %d; want true, 0", draining, active)
+	}
+}
+
+func TestMetaCallWhileDraining(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server, err := NewServer(engine.New(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx, "test"); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	// Pings keep working and report the drain
+	for range 2 {
+		resp, ok := server.handleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"Meta.Ping","id":1}`)).(*Response)
+		if !ok || resp.Error != nil {
+			t.Fatalf("Meta.Ping failed: %+v", resp)
+		}
+		var ping PingResponse
+		if err := json.Unmarshal(resp.Result, &ping); err != nil {
+			t.Fatal(err)
+		}
+		if ping.Status != "draining" || ping.InFlight != 0 {
+			t.Errorf("ping = %q with %d in flight; want draining with 0", ping.Status, ping.InFlight)
+		}
+	}
+
+	// Everything else is refused
+	resp, ok := server.handleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"Providers.List","id":2}`)).(*Response)
+	if !ok || resp.Error == nil || resp.Error.Code != CodeShuttingDown {
+		t.Errorf("Providers.List while draining = %+v; want code %d", resp, CodeShuttingDown)
+	}
+}
+
+func TestConnectionRefusesSubscriptionsOnceClosed(t *testing.T) {
+	conn := &connection{}
+	stopped := 0
+	if !conn.subscribe("a", func() { stopped++ }) {
+		t.Fatal("subscription refused on an open connection")
+	}
+
+	conn.closeSubscriptions()
+	if stopped != 1 {
+		t.Errorf("closing ended %d subscriptions, want 1", stopped)
+	}
+	if conn.subscribe("b", func() { stopped++ }) {
+		t.Error("subscription kept after the connection closed")
+	}
+	conn.closeSubscriptions()
+	if stopped != 1 {
+		t.Errorf("a refused subscription was kept: %d stopped", stopped)
+	}
+}
@@ -0,0 +1,143 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Limits protect the engine from misbehaving socket clients. Zero values disable a limit.
+// They apply to connections accepted by Serve, not to the stdin/stdout connection.
+type Limits struct {
+	// MaxConnections is the maximum number of concurrently served connections
+	MaxConnections int
+	// RequestsPerSecond is the sustained request rate allowed per client address;
+	// each entry of a batch counts as a request
+	RequestsPerSecond float64
+	// Burst is how many requests a client may send at once before being throttled
+	Burst int
+}
+
+// DefaultLimits are the limits of a new server
+var DefaultLimits = Limits{
+	MaxConnections:    16,
+	RequestsPerSecond: 20,
+	Burst:             40,
+}
+
+// tokenBucket allows rate requests per second on average and burst at once
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// client is a remote address with connections to the server; its connections share a bucket
+type client struct {
+	conns  int
+	bucket *tokenBucket
+}
+
+// clients tracks connected client addresses
+type clients struct {
+	mu    sync.Mutex
+	total int
+	byKey map[string]*client
+}
+
+func newClients() *clients {
+	return &clients{byKey: make(map[string]*client)}
+}
+
+// acquire admits a connection, returning its client's bucket and a release function,
+// or false when the connection limit is reached
+func (c *clients) acquire(conn net.Conn, limits Limits) (*tokenBucket, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limits.MaxConnections > 0 && c.total >= limits.MaxConnections {
+		return nil, nil, false
+	}
+
+	key := clientKey(conn)
+	cl, exists := c.byKey[key]
+	if !exists {
+		cl = &client{}
+		if limits.RequestsPerSecond > 0 {
+			cl.bucket = newTokenBucket(limits.RequestsPerSecond, limits.Burst)
+		}
+		if key != "" {
+			c.byKey[key] = cl
+		}
+	}
+	cl.conns++
+	c.total++
+
+	release := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.total--
+		if cl.conns--; cl.conns == 0 && key != "" {
+			delete(c.byKey, key)
+		}
+	}
+	return cl.bucket, release, true
+}
+
+// clientKey identifies the client behind a connection by its IP. Other connections
+// (Unix sockets) can't be told apart by address; they get an empty key and count on their own.
+func clientKey(conn net.Conn) string {
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ""
+}
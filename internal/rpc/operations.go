@@ -0,0 +1,93 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"sync"
+	"time"
+)
+
+// errOperationCancelled is the cancellation cause of operations stopped by Operations.Cancel
+var errOperationCancelled = errors.New("operation cancelled").Error()
+
+// Operation is embedded in the requests of long-running methods. Clients that set
+// an operation ID can abort the call with Operations.Cancel.
+type Operation struct {
+	OperationID string `json:"operation_id,omitempty"`
+	// TimeoutSeconds limits the whole call, replacing the time budgets the daemon would
+	// compute for it, so slow connections aren't cut off early; zero keeps those
+	TimeoutSeconds float64 `json:"timeout_seconds,omitempty"`
+}
+
+func (o Operation) operation() string {
+	return o.OperationID
+}
+
+func (o Operation) timeout() time.Duration {
+	return time.Duration(o.TimeoutSeconds * float64(time.Second))
+}
+
+// cancellable is implemented by requests embedding Operation
+type cancellable interface {
+	operation() string
+	timeout() time.Duration
+}
+
+// operations tracks the in-flight calls that carry an operation ID
+type operations struct {
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc
+}
+
+func newOperations() *operations {
+	return &operations{running: make(map[string]context.CancelCauseFunc)}
+}
+
+// start registers an operation and returns its context and a function to call once it is done
+func (o *operations) start(ctx context.Context, id string) (context.Context, func(), error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, exists := o.running[id]; exists {
+		return nil, nil, errors.Newf("operation %s is already running", id).Error()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	o.running[id] = cancel
+
+	done := func() {
+		o.mu.Lock()
+		delete(o.running, id)
+		o.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, done, nil
+}
+
+// cancel stops a running operation, reporting whether it was found
+func (o *operations) cancel(id string) bool {
+	o.mu.Lock()
+	cancel, exists := o.running[id]
+	o.mu.Unlock()
+
+	if exists {
+		cancel(errOperationCancelled)
+	}
+	return exists
+}
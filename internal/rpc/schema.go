@@ -0,0 +1,145 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema needed to describe the RPC request and
+// response types. Fields without omitempty are listed as required.
+type Schema struct {
+	Type                 string             `json:"-"` // Encoded by MarshalJSON
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"-"` // Adds "null" to the encoded type
+}
+
+// MarshalJSON encodes the type of nullable values as a list with "null", such as
+// ["string", "null"]
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	out := struct {
+		Type interface{} `json:"type,omitempty"`
+		*schema
+	}{schema: (*schema)(s)}
+	if s.Type != "" {
+		out.Type = s.Type
+		if s.Nullable {
+			out.Type = []string{s.Type, "null"}
+		}
+	}
+	return json.Marshal(out)
+}
+
+var (
+	typeOfTime       = reflect.TypeOf(time.Time{})
+	typeOfRawMessage = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor generates the schema of the JSON encoding of t
+func schemaFor(t reflect.Type) *Schema {
+	return buildSchema(t, make(map[reflect.Type]bool))
+}
+
+func buildSchema(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := buildSchema(t.Elem(), visiting)
+		s.Nullable = true
+		return s
+	}
+
+	switch t {
+	case typeOfTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typeOfRawMessage:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: buildSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: buildSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		// Recursive types are described once; inner references stay open objects
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(s, t, visiting)
+		return s
+	default:
+		// interface{} and anything else accepts any value
+		return &Schema{}
+	}
+}
+
+// addFields adds the JSON fields of struct type t to s, flattening embedded structs
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, visiting)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = buildSchema(f.Type, visiting)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
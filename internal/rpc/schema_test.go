@@ -0,0 +1,68 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchemaFor(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	type request struct {
+		ID       string            `json:"id"`
+		Limit    *int              `json:"limit,omitempty"`
+		Since    *time.Time        `json:"since,omitempty"`
+		Item     *item             `json:"item,omitempty"`
+		Tags     []string          `json:"tags"`
+		Extra    map[string]string `json:"extra,omitempty"`
+		Anything interface{}       `json:"anything,omitempty"`
+	}
+
+	tests := []struct {
+		property string
+		want     string
+	}{
+		{"id", `{"type":"string"}`},
+		{"limit", `{"type":["integer","null"]}`},
+		{"since", `{"type":["string","null"],"format":"date-time"}`},
+		{"item", `{"type":["object","null"],"properties":{"name":{"type":"string"}},"required":["name"]}`},
+		{"tags", `{"type":"array","items":{"type":"string"}}`},
+		{"extra", `{"type":"object","additionalProperties":{"type":"string"}}`},
+		{"anything", `{}`},
+	}
+
+	schema := schemaFor(reflect.TypeOf(request{}))
+	if got, want := schema.Required, []string{"id", "tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %v, want %v", got, want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			got, err := json.Marshal(schema.Properties[tt.property])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
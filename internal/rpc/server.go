@@ -19,12 +19,18 @@ package rpc
 import (
 	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/engine/library"
 	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/engine/numbering"
 	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/registry"
 	"context"
 	"fmt"
-	"net/rpc"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
@@ -36,49 +42,62 @@ type RuntimeInfo struct {
 	Arch      string
 }
 
-// Server wraps all RPC services
+// Server exposes the engine over JSON-RPC
 type Server struct {
 	engine  *engine.Engine
 	version string
+	methods map[string]*method
+
+	operations *operations
+	lifecycle  *lifecycle
+	clients    *clients
+	limits     Limits
 }
 
 // NewServer creates a new RPC server with all services registered
-func NewServer(e *engine.Engine, version string) *rpc.Server {
-	server := rpc.NewServer()
-
-	// Create service container
-	services := &Server{
+func NewServer(e *engine.Engine, version string) (*Server, error) {
+	server := &Server{
 		engine:  e,
 		version: version,
+		methods: make(map[string]*method),
+
+		operations: newOperations(),
+		lifecycle:  newLifecycle(),
+		clients:    newClients(),
+		limits:     DefaultLimits,
 	}
 
 	// Register services
-	err := server.RegisterName("Version", &VersionService{server: services})
-	if err != nil {
-		return nil
+	services := []struct {
+		name string
+		rcvr interface{}
+	}{
+		{"Version", &VersionService{server: server}},
+		{"Meta", &MetaService{server: server}},
+		{"Providers", &ProvidersService{server: server}},
+		{"Search", &SearchService{server: server}},
+		{"Info", &InfoService{server: server}},
+		{"Download", &DownloadService{server: server}},
+		{"Chapters", &ChaptersService{server: server}},
+		{"Resolve", &ResolveService{server: server}},
+		{"Operations", &OperationsService{server: server}},
+		{"Events", &EventsService{server: server}},
+		{"List", &ListService{server: server}},
+		{"Tags", &TagsService{server: server}},
+		{"Logs", &LogsService{server: server}},
 	}
-	err = server.RegisterName("Providers", &ProvidersService{server: services})
-	if err != nil {
-		return nil
-	}
-	err = server.RegisterName("Search", &SearchService{server: services})
-	if err != nil {
-		return nil
-	}
-	err = server.RegisterName("Info", &InfoService{server: services})
-	if err != nil {
-		return nil
-	}
-	err = server.RegisterName("Download", &DownloadService{server: services})
-	if err != nil {
-		return nil
-	}
-	err = server.RegisterName("List", &ListService{server: services})
-	if err != nil {
-		return nil
+	for _, svc := range services {
+		if err := server.register(svc.name, svc.rcvr); err != nil {
+			return nil, err
+		}
 	}
 
-	return server
+	return server, nil
+}
+
+// SetLimits replaces the connection and rate limits; call it before Serve
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
 }
 
 // --- Version Service ---
@@ -105,8 +124,9 @@ func (s *VersionService) Get(req *VersionRequest, resp *VersionResponse) error {
 		Arch:      runtime.GOARCH,
 	}
 
-	if logFile := s.server.engine.Logger.(*logger.Service).LogFile(); logFile != "" {
-		resp.LogFile = logFile
+	// An engine built with WithLogger may log elsewhere
+	if service, ok := s.server.engine.Logger.(*logger.Service); ok && service.LogFile() != "" {
+		resp.LogFile = service.LogFile()
 	} else {
 		resp.LogFile = "disabled"
 	}
@@ -114,6 +134,109 @@ func (s *VersionService) Get(req *VersionRequest, resp *VersionResponse) error {
 	return nil
 }
 
+// --- Meta Service ---
+
+type MetaService struct {
+	server *Server
+}
+
+type DescribeRequest struct {
+	Service string `json:"service,omitempty"`
+}
+
+type MethodDescription struct {
+	Name     string  `json:"name"`
+	Request  *Schema `json:"request"`
+	Response *Schema `json:"response"`
+}
+
+type DescribeResponse struct {
+	Version  string              `json:"version"`
+	Protocol string              `json:"protocol"`
+	Methods  []MethodDescription `json:"methods"`
+}
+
+// Describe lists the available methods with JSON schemas of their parameters and results
+func (s *MetaService) Describe(req *DescribeRequest, resp *DescribeResponse) error {
+	*resp = DescribeResponse{
+		Version:  s.server.version,
+		Protocol: "2.0",
+		Methods:  []MethodDescription{},
+	}
+
+	service := strings.TrimSuffix(req.Service, "Service")
+	for name, m := range s.server.methods {
+		if service != "" && !strings.HasPrefix(name, service+".") {
+			continue
+		}
+		resp.Methods = append(resp.Methods, MethodDescription{
+			Name:     name,
+			Request:  schemaFor(m.argType),
+			Response: schemaFor(m.replyType),
+		})
+	}
+
+	if len(resp.Methods) == 0 && service != "" {
+		return errors.Newf("unknown service: %s", req.Service).AsNotFound().Error()
+	}
+
+	sort.Slice(resp.Methods, func(i, j int) bool {
+		return resp.Methods[i].Name < resp.Methods[j].Name
+	})
+
+	return nil
+}
+
+type PingRequest struct{}
+
+type PingResponse struct {
+	Status        string    `json:"status"`
+	Version       string    `json:"version"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+	InFlight      int       `json:"in_flight"`
+	Time          time.Time `json:"time"`
+}
+
+// Ping reports that the server is alive and whether it is draining
+func (s *MetaService) Ping(req *PingRequest, resp *PingResponse) error {
+	draining, active := s.server.lifecycle.status()
+
+	*resp = PingResponse{
+		Status:        "ok",
+		Version:       s.server.version,
+		UptimeSeconds: int64(time.Since(s.server.lifecycle.started).Seconds()),
+		// The ping itself is not counted
+		InFlight: active - 1,
+		Time:     time.Now().UTC(),
+	}
+	if draining {
+		resp.Status = "draining"
+	}
+
+	return nil
+}
+
+type CacheRequest struct {
+	Clear bool `json:"clear,omitempty"` // Empty the cache after reporting its statistics
+}
+
+type CacheResponse struct {
+	cache.Stats
+	TTLSeconds int64 `json:"ttl_seconds"`
+}
+
+// Cache reports the size, budgets and hit rate of the engine's in-memory cache
+func (s *MetaService) Cache(req *CacheRequest, resp *CacheResponse) error {
+	*resp = CacheResponse{
+		Stats:      s.server.engine.Cache.Stats(),
+		TTLSeconds: int64(s.server.engine.CacheTTL().Seconds()),
+	}
+	if req.Clear {
+		s.server.engine.Cache.Clear()
+	}
+	return nil
+}
+
 // --- Providers Service ---
 
 type ProvidersService struct {
@@ -126,6 +249,10 @@ type ProviderInfo struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Priority    int    `json:"priority"`
+	Tracing     bool   `json:"tracing,omitempty"`
+	// Readiness is how far the provider got initializing
+	Readiness engine.ProviderReadiness `json:"readiness"`
 }
 
 type ProvidersResponse []ProviderInfo
@@ -139,6 +266,9 @@ func (s *ProvidersService) List(req *ProvidersRequest, resp *ProvidersResponse)
 			ID:          p.ID(),
 			Name:        p.Name(),
 			Description: p.Description(),
+			Priority:    s.server.engine.ProviderSettings(p.ID()).Priority,
+			Tracing:     s.server.engine.Tracing(p.ID()),
+			Readiness:   s.server.engine.ProviderReadiness(p.ID()),
 		}
 	}
 
@@ -146,6 +276,43 @@ func (s *ProvidersService) List(req *ProvidersRequest, resp *ProvidersResponse)
 	return nil
 }
 
+type ProvidersReloadRequest struct{}
+
+type ProvidersReloadResponse = registry.ReloadResult
+
+// Reload reads the config file and the provider definitions again and replaces every
+// provider with a fresh, initialized one, as SIGHUP does
+func (s *ProvidersService) Reload(ctx context.Context, req *ProvidersReloadRequest, resp *ProvidersReloadResponse) error {
+	result, err := registry.Reload(ctx, s.server.engine)
+	if err != nil {
+		return err
+	}
+	*resp = *result
+	return nil
+}
+
+type ProvidersTraceRequest struct {
+	Provider string `json:"provider"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type ProvidersTraceResponse struct {
+	Traced []string `json:"traced"` // Every provider traced now
+}
+
+// Trace turns tracing of one provider on or off while the server runs, see
+// engine.SetTracing
+func (s *ProvidersService) Trace(req *ProvidersTraceRequest, resp *ProvidersTraceResponse) error {
+	if req.Provider == "" {
+		return errors.New("provider is required").Error()
+	}
+	if err := s.server.engine.SetTracing(req.Provider, req.Enabled); err != nil {
+		return err
+	}
+	resp.Traced = s.server.engine.TracedProviders()
+	return nil
+}
+
 // --- Search Service ---
 
 type SearchService struct {
@@ -153,13 +320,19 @@ type SearchService struct {
 }
 
 type SearchRequest struct {
-	Query            string `json:"query"`
-	Provider         string `json:"provider,omitempty"`
-	Limit            int    `json:"limit,omitempty"`
-	Pages            int    `json:"pages,omitempty"`
-	Sort             string `json:"sort,omitempty"`
-	IncludeAltTitles bool   `json:"include_alt_titles,omitempty"`
-	Concurrency      int    `json:"concurrency,omitempty"`
+	Operation
+
+	Query            string   `json:"query"`
+	Provider         string   `json:"provider,omitempty"`
+	Providers        []string `json:"providers,omitempty"`
+	Limit            int      `json:"limit,omitempty"`
+	Pages            int      `json:"pages,omitempty"`
+	Sort             string   `json:"sort,omitempty"`
+	IncludeAltTitles bool     `json:"include_alt_titles,omitempty"`
+	Concurrency      int      `json:"concurrency,omitempty"`
+	Stream           bool     `json:"stream,omitempty"`
+
+	Filters map[string]string `json:"filters,omitempty"`
 }
 
 type SearchResultItem struct {
@@ -168,17 +341,88 @@ type SearchResultItem struct {
 	Provider     string   `json:"provider"`
 	ProviderName string   `json:"provider_name"`
 	AltTitles    []string `json:"alt_titles,omitempty"`
+	MatchedTitle string   `json:"matched_title,omitempty"`
 	Authors      []string `json:"authors,omitempty"`
+	Artists      []string `json:"artists,omitempty"`
 	Tags         []string `json:"tags,omitempty"`
+
+	Demographic   string `json:"demographic,omitempty"`
+	ContentRating string `json:"content_rating,omitempty"`
 }
 
 type SearchResponse struct {
 	Query   string             `json:"query"`
 	Results []SearchResultItem `json:"results"`
 	Count   int                `json:"count"`
+	// Providers tells how every provider of a search across providers fared, failed ones
+	// included
+	Providers []ProviderOutcome `json:"providers,omitempty"`
+}
+
+// ProviderOutcome is how one provider of a call across providers fared
+type ProviderOutcome struct {
+	Provider  string `json:"provider"`
+	Count     int    `json:"count"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// providerOutcomes describes how each provider of a call across providers fared; count
+// tells how many items a provider's value holds
+func providerOutcomes[T any](result core.MultiResult[T], count func(T) int) []ProviderOutcome {
+	outcomes := make([]ProviderOutcome, 0, len(result.Results))
+	for _, r := range result.Results {
+		outcome := ProviderOutcome{Provider: r.Provider, ElapsedMs: r.Elapsed.Milliseconds()}
+		if r.Err != nil {
+			outcome.Error = r.Err.Error()
+		} else {
+			outcome.Count = count(r.Value)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// SearchResultsNotification is the method of the notifications carrying one provider's
+// results during a streamed search
+const SearchResultsNotification = "Search.Results"
+
+// SearchResultsParams are the params of a search results notification
+type SearchResultsParams struct {
+	Query        string             `json:"query"`
+	OperationID  string             `json:"operation_id,omitempty"`
+	Provider     string             `json:"provider"`
+	ProviderName string             `json:"provider_name"`
+	Results      []SearchResultItem `json:"results,omitempty"`
+	Count        int                `json:"count"`
+	Error        string             `json:"error,omitempty"`
+	ElapsedMs    int64              `json:"elapsed_ms"`
+	Pending      []string           `json:"pending"`
+}
+
+// searchResultItems converts a provider's search results
+func searchResultItems(provider engine.Provider, mangas []core.Manga) []SearchResultItem {
+	items := make([]SearchResultItem, 0, len(mangas))
+	for _, manga := range mangas {
+		items = append(items, SearchResultItem{
+			ID:           fmt.Sprintf("%s:%s", provider.ID(), manga.ID),
+			Title:        manga.Title,
+			Provider:     provider.ID(),
+			ProviderName: provider.Name(),
+			AltTitles:    manga.AlternativeTitles,
+			MatchedTitle: manga.MatchedTitle,
+			Authors:      manga.Authors,
+			Artists:      manga.Artists,
+			Tags:         manga.Tags,
+
+			Demographic:   manga.Demographic,
+			ContentRating: manga.ContentRating,
+		})
+	}
+	return items
 }
 
-func (s *SearchService) Search(req *SearchRequest, resp *SearchResponse) error {
+func (s *SearchService) Search(ctx context.Context, req *SearchRequest, resp *SearchResponse) error {
 	// Set defaults
 	if req.Limit <= 0 {
 		req.Limit = 10
@@ -195,61 +439,110 @@ func (s *SearchService) Search(req *SearchRequest, resp *SearchResponse) error {
 		Sort:             req.Sort,
 		IncludeAltTitles: req.IncludeAltTitles,
 		Concurrency:      req.Concurrency,
+		Filters:          req.Filters,
 	}
 
-	var results []SearchResultItem
-	ctx := context.Background()
+	if req.Provider != "" && len(req.Providers) > 0 {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: provider and providers can't be used together"}
+	}
+
+	var (
+		results  []SearchResultItem
+		outcomes []ProviderOutcome
+	)
 
 	if req.Provider != "" {
 		// Search single provider
 		provider, err := s.server.engine.GetProvider(req.Provider)
 		if err != nil {
-			return errors.Track(err).AsProvider(req.Provider).Error()
+			return errors.TP(err, req.Provider)
 		}
 
-		mangas, err := provider.Search(ctx, req.Query, options)
+		mangas, err := s.server.engine.Search(ctx, provider, req.Query, options)
 		if err != nil {
-			return errors.Track(err).AsProvider(req.Provider).Error()
+			return errors.TP(err, req.Provider)
 		}
 
-		for _, manga := range mangas {
-			results = append(results, SearchResultItem{
-				ID:           fmt.Sprintf("%s:%s", req.Provider, manga.ID),
-				Title:        manga.Title,
-				Provider:     req.Provider,
-				ProviderName: provider.Name(),
-				AltTitles:    manga.AlternativeTitles,
-				Authors:      manga.Authors,
-				Tags:         manga.Tags,
-			})
-		}
+		results = searchResultItems(provider, mangas)
 	} else {
-		// Search all providers
-		for _, provider := range s.server.engine.AllProviders() {
-			mangas, err := provider.Search(ctx, req.Query, options)
-			if err != nil {
-				s.server.engine.Logger.Error("Search failed for %s: %v", provider.ID(), err)
-				continue
+		// Search all (or the chosen) providers at once, notifying the caller of each one's results as they arrive
+		var conn *connection
+		if req.Stream {
+			if conn = connectionFrom(ctx); conn == nil {
+				return errors.New("streamed search results need a persistent connection").Error()
 			}
+		}
+
+		providers, err := s.server.engine.SelectProviders(req.Providers)
+		if err != nil {
+			return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+		}
+
+		pending := make(map[string]bool, len(providers))
+		byID := make(map[string]engine.Provider, len(providers))
+		for _, provider := range providers {
+			pending[provider.ID()] = true
+			byID[provider.ID()] = provider
+		}
 
-			for _, manga := range mangas {
-				results = append(results, SearchResultItem{
-					ID:           fmt.Sprintf("%s:%s", provider.ID(), manga.ID),
-					Title:        manga.Title,
-					Provider:     provider.ID(),
-					ProviderName: provider.Name(),
-					AltTitles:    manga.AlternativeTitles,
-					Authors:      manga.Authors,
-					Tags:         manga.Tags,
-				})
+		multi := s.server.engine.SearchAcrossProviders(ctx, providers, req.Query, options, func(r engine.SearchResult) {
+			if conn == nil {
+				return
 			}
+			delete(pending, r.Provider.ID())
+
+			params := SearchResultsParams{
+				Query:        req.Query,
+				OperationID:  req.OperationID,
+				Provider:     r.Provider.ID(),
+				ProviderName: r.Provider.Name(),
+				ElapsedMs:    r.Elapsed.Milliseconds(),
+				Pending:      []string{},
+			}
+			if r.Err != nil {
+				params.Error = r.Err.Error()
+			} else {
+				params.Results = searchResultItems(r.Provider, r.Results)
+				params.Count = len(params.Results)
+			}
+
+			for _, provider := range providers {
+				if pending[provider.ID()] {
+					params.Pending = append(params.Pending, provider.ID())
+				}
+			}
+			conn.write(&Notification{
+				JSONRPC: "2.0",
+				Method:  SearchResultsNotification,
+				Params:  params,
+			})
+		})
+
+		if ctx.Err() != nil {
+			return errors.Track(ctx.Err()).WithMessage("Search aborted").Error()
 		}
+		for _, failure := range multi.Failures() {
+			s.server.engine.Log(ctx).Error("Search failed for %s: %v", failure.Provider, failure.Err)
+		}
+		if multi.AllFailed() {
+			return errors.Track(multi.FirstError()).
+				WithContext("query", req.Query).
+				WithMessagef("Search failed on all %d providers", len(providers)).
+				Error()
+		}
+
+		// Results keep the usual provider order regardless of who answered first
+		for _, success := range multi.Successes() {
+			results = append(results, searchResultItems(byID[success.Provider], success.Value)...)
+		}
+		outcomes = providerOutcomes(multi, func(mangas []core.Manga) int { return len(mangas) })
 	}
 
 	*resp = SearchResponse{
-		Query:   req.Query,
-		Results: results,
-		Count:   len(results),
+		Query:     req.Query,
+		Results:   results,
+		Count:     len(results),
+		Providers: outcomes,
 	}
 
 	return nil
@@ -262,9 +555,13 @@ type InfoService struct {
 }
 
 type InfoRequest struct {
+	Operation
+
 	MangaID        string `json:"manga_id"`
 	LanguageFilter string `json:"language_filter,omitempty"`
 	ShowLanguages  bool   `json:"show_languages,omitempty"`
+	// Fresh fetches from the site, bypassing the cache and warmed details
+	Fresh bool `json:"fresh,omitempty"`
 }
 
 type InfoResponse struct {
@@ -274,17 +571,28 @@ type InfoResponse struct {
 	ProviderName         string             `json:"provider_name"`
 	Description          string             `json:"description"`
 	Authors              []string           `json:"authors"`
+	Artists              []string           `json:"artists,omitempty"`
 	Status               string             `json:"status"`
 	Tags                 []string           `json:"tags"`
+	Demographic          string             `json:"demographic,omitempty"`
+	ContentRating        string             `json:"content_rating,omitempty"`
 	Chapters             []core.ChapterInfo `json:"chapters"`
 	ChapterCount         int                `json:"chapter_count"`
 	LastUpdated          *time.Time         `json:"last_updated,omitempty"`
 	AvailableLanguages   []string           `json:"available_languages,omitempty"`
+	Related              []RelatedItem      `json:"related,omitempty"`
+	ExternalIDs          map[string]string  `json:"external_ids,omitempty"`
 	FilteredChapters     bool               `json:"filtered_chapters,omitempty"`
 	OriginalChapterCount int                `json:"original_chapter_count,omitempty"`
 }
 
-func (s *InfoService) Get(req *InfoRequest, resp *InfoResponse) error {
+type RelatedItem struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Relation string `json:"relation,omitempty"`
+}
+
+func (s *InfoService) Get(ctx context.Context, req *InfoRequest, resp *InfoResponse) error {
 	// Parse combined ID
 	parts := strings.SplitN(req.MangaID, ":", 2)
 	if len(parts) != 2 {
@@ -296,29 +604,47 @@ func (s *InfoService) Get(req *InfoRequest, resp *InfoResponse) error {
 	// Get provider
 	provider, err := s.server.engine.GetProvider(providerID)
 	if err != nil {
-		return errors.Track(err).AsProvider(providerID).Error()
+		return errors.TP(err, providerID)
+	}
+
+	// Warmed details answer right away unless fresh ones are asked for
+	if req.Fresh {
+		ctx = cache.Refresh(ctx)
+	} else {
+		ctx = cache.AllowStale(ctx)
 	}
 
 	// Get manga info
-	ctx := context.Background()
-	info, err := provider.GetManga(ctx, mangaID)
+	info, err := s.server.engine.GetManga(ctx, provider, mangaID)
 	if err != nil {
-		return errors.Track(err).AsProvider(providerID).Error()
+		return errors.TP(err, providerID)
 	}
 
 	// Build response
 	*resp = InfoResponse{
-		ID:           req.MangaID,
-		Title:        info.Title,
-		Provider:     providerID,
-		ProviderName: provider.Name(),
-		Description:  info.Description,
-		Authors:      info.Authors,
-		Status:       info.Status,
-		Tags:         info.Tags,
-		Chapters:     info.Chapters,
-		ChapterCount: len(info.Chapters),
-		LastUpdated:  info.LastUpdated,
+		ID:            req.MangaID,
+		Title:         info.Title,
+		Provider:      providerID,
+		ProviderName:  provider.Name(),
+		Description:   info.Description,
+		Authors:       info.Authors,
+		Artists:       info.Artists,
+		Status:        info.Status,
+		Tags:          info.Tags,
+		Demographic:   info.Demographic,
+		ContentRating: info.ContentRating,
+		Chapters:      info.Chapters,
+		ChapterCount:  len(info.Chapters),
+		LastUpdated:   info.LastUpdated,
+		ExternalIDs:   info.ExternalIDs,
+	}
+
+	for _, rel := range info.Related {
+		resp.Related = append(resp.Related, RelatedItem{
+			ID:       fmt.Sprintf("%s:%s", providerID, rel.ID),
+			Title:    rel.Title,
+			Relation: rel.Relation,
+		})
 	}
 
 	// Handle language filtering
@@ -347,8 +673,30 @@ type DownloadService struct {
 }
 
 type DownloadRequest struct {
+	Operation
+
 	ChapterID string `json:"chapter_id"`
-	OutputDir string `json:"output_dir"`
+	OutputDir string `json:"output_dir,omitempty"`
+	Process   string `json:"process,omitempty"` // Post-processing pipeline, e.g. "convert=jpeg,cbz"
+	Layout    string `json:"layout,omitempty"`  // "flat" (default) or "language"
+	// Collision names a chapter sharing its number with one already saved: "group",
+	// "language" or "none", the configured strategy if empty
+	Collision string `json:"collision,omitempty"`
+	// Numbering renumbers the chapter: "source" (the default), "absolute" or "volume";
+	// NumberingMap corrects the site's numbers and volumes first
+	Numbering    string             `json:"numbering,omitempty"`
+	NumberingMap *numbering.Mapping `json:"numbering_map,omitempty"`
+	// Storage writes the chapter to S3 or WebDAV instead, e.g. "s3://bucket/manga";
+	// output_dir is a path below it then
+	Storage string `json:"storage,omitempty"`
+	// Dedupe keeps identical pages once, as hard links to files in this directory
+	Dedupe string `json:"dedupe,omitempty"`
+	// Audit saves every HTTP request of the download to an audit file
+	Audit bool `json:"audit,omitempty"`
+	// PageConcurrency is how many pages are downloaded at once, the service's default if 0
+	PageConcurrency int `json:"page_concurrency,omitempty"`
+	// Failover downloads the chapter from a linked series on another provider if it fails
+	Failover bool `json:"failover,omitempty"`
 }
 
 type DownloadResponse struct {
@@ -356,9 +704,14 @@ type DownloadResponse struct {
 	Message   string `json:"message"`
 	Path      string `json:"path,omitempty"`
 	PageCount int    `json:"page_count,omitempty"`
+	// FailedPages are the 1-based numbers of pages that couldn't be downloaded
+	FailedPages []int  `json:"failed_pages,omitempty"`
+	Audit       string `json:"audit,omitempty"` // Audit file of the download, when audited
+	// Source is the provider:chapter-id that served the chapter when it failed over
+	Source string `json:"source,omitempty"`
 }
 
-func (s *DownloadService) Chapter(req *DownloadRequest, resp *DownloadResponse) error {
+func (s *DownloadService) Chapter(ctx context.Context, req *DownloadRequest, resp *DownloadResponse) error {
 	// Parse combined ID
 	parts := strings.SplitN(req.ChapterID, ":", 2)
 	if len(parts) != 2 {
@@ -370,24 +723,527 @@ func (s *DownloadService) Chapter(req *DownloadRequest, resp *DownloadResponse)
 	// Get provider
 	provider, err := s.server.engine.GetProvider(providerID)
 	if err != nil {
-		return errors.Track(err).AsProvider(providerID).Error()
+		return errors.TP(err, providerID)
+	}
+
+	ctx, err = withPipeline(ctx, req.Process)
+	if err != nil {
+		return err
+	}
+	ctx, err = withLayout(ctx, req.Layout)
+	if err != nil {
+		return err
+	}
+	ctx, err = withCollision(ctx, req.Collision)
+	if err != nil {
+		return err
+	}
+	ctx, err = withNumbering(ctx, req.Numbering, req.NumberingMap)
+	if err != nil {
+		return err
+	}
+	ctx, err = withStorage(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	ctx, err = withBlobStore(ctx, req.Dedupe)
+	if err != nil {
+		return err
+	}
+	if req.Audit {
+		ctx = engine.WithAudit(ctx)
+	}
+	if req.PageConcurrency > 0 {
+		ctx = core.WithPageConcurrency(ctx, req.PageConcurrency)
+	}
+	if req.Failover {
+		ctx = engine.WithFailover(ctx)
 	}
 
 	// Download chapter
-	ctx := context.Background()
-	if err := provider.DownloadChapter(ctx, chapterID, req.OutputDir); err != nil {
-		return errors.Track(err).AsProvider(providerID).Error()
+	record, err := s.server.engine.DownloadChapterRecord(ctx, provider, chapterID, req.OutputDir)
+	if err != nil {
+		return errors.TP(err, providerID)
 	}
 
 	*resp = DownloadResponse{
-		Success: true,
-		Message: "Chapter downloaded successfully",
-		Path:    req.OutputDir,
+		Success:   true,
+		Message:   "Chapter downloaded successfully",
+		Path:      req.OutputDir,
+		PageCount: record.Pages,
+		Audit:     record.Audit,
+	}
+	if record.FailoverFrom != "" {
+		resp.Source = record.Provider + ":" + record.ChapterID
+	}
+	if record.Status == library.StatusPartial {
+		resp.Message = fmt.Sprintf("Chapter downloaded with %d missing page(s)", len(record.FailedPages))
+		resp.FailedPages = record.FailedPages
+	}
+
+	return nil
+}
+
+type DownloadMangaRequest struct {
+	Operation
+
+	MangaID     string   `json:"manga_id"`
+	OutputDir   string   `json:"output_dir,omitempty"`
+	Languages   []string `json:"languages,omitempty"`
+	From        float64  `json:"from,omitempty"`
+	To          float64  `json:"to,omitempty"`
+	Group       string   `json:"group,omitempty"`
+	Latest      int      `json:"latest,omitempty"` // Only the newest chapters, this many
+	StopOnError bool     `json:"stop_on_error,omitempty"`
+	Process     string   `json:"process,omitempty"`
+	Layout      string   `json:"layout,omitempty"`
+	Collision   string   `json:"collision,omitempty"`
+	// Numbering and NumberingMap renumber the chapters as for DownloadRequest; the filter
+	// goes by the new numbers
+	Numbering    string             `json:"numbering,omitempty"`
+	NumberingMap *numbering.Mapping `json:"numbering_map,omitempty"`
+	Storage      string             `json:"storage,omitempty"`
+	Dedupe       string             `json:"dedupe,omitempty"`
+	Audit        bool               `json:"audit,omitempty"`
+	// PageConcurrency is how many pages of a chapter and ChapterConcurrency how many
+	// chapters are downloaded at once
+	PageConcurrency    int `json:"page_concurrency,omitempty"`
+	ChapterConcurrency int `json:"chapter_concurrency,omitempty"`
+	// Failover downloads chapters that fail from a linked series on another provider
+	Failover bool `json:"failover,omitempty"`
+	// AllLanguages downloads every language when Languages is empty, instead of the
+	// preferred languages
+	AllLanguages bool `json:"all_languages,omitempty"`
+	// Unique downloads one copy of chapters released more than once, picked by the
+	// preferred groups, then the preferred languages, then the page count
+	Unique          bool     `json:"unique,omitempty"`
+	PreferGroups    []string `json:"prefer_groups,omitempty"`
+	PreferLanguages []string `json:"prefer_languages,omitempty"`
+}
+
+type ChapterStatus struct {
+	ChapterID string `json:"chapter_id"`
+	Chapter   string `json:"chapter,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Status    string `json:"status"`
+	Path      string `json:"path,omitempty"`
+	PageCount int    `json:"page_count,omitempty"`
+	Bytes     int64  `json:"bytes,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// FailedPages are the 1-based numbers of pages missing from a partial download
+	FailedPages []int  `json:"failed_pages,omitempty"`
+	Audit       string `json:"audit,omitempty"`
+	// Source is the provider:chapter-id that served the chapter when it failed over
+	Source string `json:"source,omitempty"`
+}
+
+type DownloadMangaResponse struct {
+	MangaID   string          `json:"manga_id"`
+	Chapters  []ChapterStatus `json:"chapters"`
+	Completed int             `json:"completed"`
+	Partial   int             `json:"partial"`
+	Failed    int             `json:"failed"`
+	Skipped   int             `json:"skipped"`
+	Stopped   bool            `json:"stopped,omitempty"` // Set when stop_on_error ended the download early
+}
+
+// Manga downloads every chapter of a manga matching the filters and reports the outcome
+// per chapter. Progress is published as download events while it runs.
+func (s *DownloadService) Manga(ctx context.Context, req *DownloadMangaRequest, resp *DownloadMangaResponse) error {
+	parts := strings.SplitN(req.MangaID, ":", 2)
+	if len(parts) != 2 {
+		return errors.Newf("invalid manga ID format: %s", req.MangaID).Error()
+	}
+
+	providerID, mangaID := parts[0], parts[1]
+
+	provider, err := s.server.engine.GetProvider(providerID)
+	if err != nil {
+		return errors.TP(err, providerID)
+	}
+
+	ctx, err = withPipeline(ctx, req.Process)
+	if err != nil {
+		return err
+	}
+	ctx, err = withLayout(ctx, req.Layout)
+	if err != nil {
+		return err
+	}
+	ctx, err = withCollision(ctx, req.Collision)
+	if err != nil {
+		return err
+	}
+	ctx, err = withNumbering(ctx, req.Numbering, req.NumberingMap)
+	if err != nil {
+		return err
+	}
+	ctx, err = withStorage(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	ctx, err = withBlobStore(ctx, req.Dedupe)
+	if err != nil {
+		return err
+	}
+	if req.Audit {
+		ctx = engine.WithAudit(ctx)
+	}
+	if req.PageConcurrency > 0 {
+		ctx = core.WithPageConcurrency(ctx, req.PageConcurrency)
+	}
+	if req.ChapterConcurrency > 0 {
+		ctx = core.WithChapterConcurrency(ctx, req.ChapterConcurrency)
+	}
+	if req.Failover {
+		ctx = engine.WithFailover(ctx)
+	}
+
+	filter := engine.ChapterFilter{
+		Languages: req.Languages,
+		From:      req.From,
+		To:        req.To,
+		Group:     req.Group,
+		Latest:    req.Latest,
+	}
+	if req.Unique || len(req.PreferGroups) > 0 || len(req.PreferLanguages) > 0 {
+		filter.Prefer = &engine.ChapterPreference{Groups: req.PreferGroups, Languages: req.PreferLanguages}
+	}
+
+	records, err := s.server.engine.DownloadManga(ctx, provider, mangaID, engine.MangaDownloadOptions{
+		Filter:      filter,
+		OutputDir:   req.OutputDir,
+		StopOnError: req.StopOnError,
+
+		AllLanguages: req.AllLanguages,
+	})
+	// A failed chapter list or a cancellation fails the call; stopping at a failed
+	// chapter still reports the chapters handled so far
+	if err != nil && (records == nil || ctx.Err() != nil) {
+		return errors.TP(err, providerID)
+	}
+
+	*resp = DownloadMangaResponse{
+		MangaID:  req.MangaID,
+		Chapters: make([]ChapterStatus, 0, len(records)),
+		Stopped:  err != nil,
+	}
+	for _, record := range records {
+		chapterID, source := fmt.Sprintf("%s:%s", providerID, record.ChapterID), ""
+		if record.FailoverFrom != "" {
+			chapterID, source = record.FailoverFrom, record.Provider+":"+record.ChapterID
+		}
+		resp.Chapters = append(resp.Chapters, ChapterStatus{
+			ChapterID: chapterID,
+			Chapter:   record.Chapter,
+			Title:     record.Title,
+			Status:    string(record.Status),
+			Path:      record.Path,
+			PageCount: record.Pages,
+			Bytes:     record.Bytes,
+			Error:     record.Error,
+
+			FailedPages: record.FailedPages,
+			Audit:       record.Audit,
+			Source:      source,
+		})
+
+		switch record.Status {
+		case library.StatusCompleted:
+			resp.Completed++
+		case library.StatusPartial:
+			resp.Partial++
+		case library.StatusFailed:
+			resp.Failed++
+		case library.StatusSkipped:
+			resp.Skipped++
+		}
+	}
+
+	return nil
+}
+
+// withPipeline sets up post-processing of downloads from a request's pipeline spec
+func withPipeline(ctx context.Context, spec string) (context.Context, error) {
+	if spec == "" {
+		return ctx, nil
+	}
+	pipeline, err := download.ParsePipeline(spec)
+	if err != nil {
+		return ctx, err
+	}
+	return download.WithPipeline(ctx, pipeline), nil
+}
+
+// withLayout sets where downloads are saved from a request's layout name
+func withLayout(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	layout, err := download.ParseLayout(name)
+	if err != nil {
+		return ctx, err
+	}
+	return download.WithLayout(ctx, layout), nil
+}
+
+// withCollision sets how downloads sharing a chapter number are named from a request's
+// collision strategy
+func withCollision(ctx context.Context, name string) (context.Context, error) {
+	if name == "" {
+		return ctx, nil
+	}
+	collision, err := download.ParseCollision(name)
+	if err != nil {
+		return ctx, err
+	}
+	return download.WithCollision(ctx, collision), nil
+}
+
+// withNumbering renumbers downloads by a request's numbering scheme and mapping
+func withNumbering(ctx context.Context, name string, mapping *numbering.Mapping) (context.Context, error) {
+	n := numbering.Normalizer{Mapping: mapping}
+	if name != "" {
+		scheme, err := numbering.ParseScheme(name)
+		if err != nil {
+			return ctx, err
+		}
+		n.Scheme = scheme
+	}
+	return numbering.WithNormalizer(ctx, n), nil
+}
+
+// withStorage sets where downloads are written from a request's storage spec
+func withStorage(ctx context.Context, spec string) (context.Context, error) {
+	if spec == "" {
+		return ctx, nil
+	}
+	storage, err := download.ParseStorage(spec)
+	if err != nil {
+		return ctx, err
+	}
+	return download.WithStorage(ctx, storage), nil
+}
+
+// withBlobStore deduplicates the pages of downloads into a request's blob store directory
+func withBlobStore(ctx context.Context, dir string) (context.Context, error) {
+	if dir == "" {
+		return ctx, nil
+	}
+	store, err := download.NewBlobStore(dir)
+	if err != nil {
+		return ctx, err
+	}
+	return download.WithBlobStore(ctx, store), nil
+}
+
+// --- Chapters Service ---
+
+type ChaptersService struct {
+	server *Server
+}
+
+type ChapterPagesRequest struct {
+	ChapterID string `json:"chapter_id"`
+}
+
+type ChapterPagesResponse struct {
+	ChapterID   string            `json:"chapter_id"`
+	Provider    string            `json:"provider"`
+	MangaID     string            `json:"manga_id,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Number      string            `json:"number,omitempty"`
+	ExternalURL string            `json:"external_url,omitempty"`
+	Pages       []core.Page       `json:"pages"`
+	PageCount   int               `json:"page_count"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// GetPages resolves the page URLs of a chapter without downloading them, along with
+// the headers the provider's image hosts expect
+func (s *ChaptersService) GetPages(ctx context.Context, req *ChapterPagesRequest, resp *ChapterPagesResponse) error {
+	provider, chapterID, err := s.server.engine.ResolveChapter(req.ChapterID)
+	if err != nil {
+		return err
+	}
+
+	chapter, err := s.server.engine.GetChapter(ctx, provider, chapterID)
+	if err != nil {
+		return errors.TP(err, provider.ID())
+	}
+
+	*resp = ChapterPagesResponse{
+		ChapterID:   fmt.Sprintf("%s:%s", provider.ID(), chapterID),
+		Provider:    provider.ID(),
+		Title:       chapter.Info.Title,
+		Number:      chapter.Info.DisplayNumber(),
+		ExternalURL: chapter.Info.ExternalURL,
+		Pages:       chapter.Pages,
+		PageCount:   len(chapter.Pages),
+	}
+	if chapter.MangaID != "" {
+		resp.MangaID = fmt.Sprintf("%s:%s", provider.ID(), chapter.MangaID)
+	}
+	if resp.Pages == nil {
+		resp.Pages = []core.Page{}
+	}
+
+	if requester, ok := provider.(engine.Requester); ok && len(chapter.Pages) > 0 {
+		resp.Headers = requester.NewRequest(chapter.Pages[0].URL).Headers
 	}
 
 	return nil
 }
 
+// --- Resolve Service ---
+
+type ResolveService struct {
+	server *Server
+}
+
+type ResolveRequest struct {
+	URL string `json:"url"`
+}
+
+type ResolveResponse struct {
+	Provider     string `json:"provider"`
+	ProviderName string `json:"provider_name"`
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+}
+
+// URL maps a manga or chapter URL to the combined ID the other services accept
+func (s *ResolveService) URL(req *ResolveRequest, resp *ResolveResponse) error {
+	resolved, err := s.server.engine.ResolveURL(strings.TrimSpace(req.URL))
+	if err != nil {
+		return err
+	}
+
+	*resp = ResolveResponse{
+		Provider:     resolved.Provider.ID(),
+		ProviderName: resolved.Provider.Name(),
+		Type:         resolved.Kind,
+		ID:           fmt.Sprintf("%s:%s", resolved.Provider.ID(), resolved.ID),
+	}
+
+	return nil
+}
+
+// --- Operations Service ---
+
+type OperationsService struct {
+	server *Server
+}
+
+type CancelRequest struct {
+	OperationID string `json:"operation_id"`
+}
+
+type CancelResponse struct {
+	OperationID string `json:"operation_id"`
+	Cancelled   bool   `json:"cancelled"`
+}
+
+// Cancel aborts the in-flight call started with the given operation ID. Cancelling an
+// operation that already finished is not an error; cancelled is false then.
+func (s *OperationsService) Cancel(req *CancelRequest, resp *CancelResponse) error {
+	if req.OperationID == "" {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: operation_id is required"}
+	}
+
+	*resp = CancelResponse{
+		OperationID: req.OperationID,
+		Cancelled:   s.server.operations.cancel(req.OperationID),
+	}
+
+	return nil
+}
+
+// --- Events Service ---
+
+// EventNotification is the method of the notifications carrying subscribed events
+const EventNotification = "Events.Event"
+
+type EventsService struct {
+	server *Server
+}
+
+type SubscribeRequest struct {
+	Types []string `json:"types,omitempty"`
+}
+
+type SubscribeResponse struct {
+	SubscriptionID string   `json:"subscription_id"`
+	Types          []string `json:"types"`
+}
+
+type UnsubscribeRequest struct {
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type UnsubscribeResponse struct {
+	Unsubscribed bool `json:"unsubscribed"`
+}
+
+// EventParams are the params of an event notification
+type EventParams struct {
+	SubscriptionID string `json:"subscription_id"`
+	events.Event
+}
+
+// Subscribe starts pushing events of the requested types (all when none are given) to
+// the calling connection as notifications, until it unsubscribes or disconnects
+func (s *EventsService) Subscribe(ctx context.Context, req *SubscribeRequest, resp *SubscribeResponse) error {
+	conn := connectionFrom(ctx)
+	if conn == nil {
+		return errors.New("events need a persistent connection").Error()
+	}
+
+	for _, t := range req.Types {
+		if !events.IsType(t) {
+			return &Error{
+				Code:    CodeInvalidParams,
+				Message: fmt.Sprintf("invalid params: unknown event type %q, expected one of %s", t, strings.Join(events.Types, ", ")),
+			}
+		}
+	}
+
+	id := core.NewCorrelationID()
+	ch, stop := s.server.engine.Events.Subscribe(req.Types...)
+	if !conn.subscribe(id, stop) {
+		stop()
+		return errors.New("the connection is closed").Error()
+	}
+
+	go func() {
+		for event := range ch {
+			conn.write(&Notification{
+				JSONRPC: "2.0",
+				Method:  EventNotification,
+				Params:  EventParams{SubscriptionID: id, Event: event},
+			})
+		}
+	}()
+
+	*resp = SubscribeResponse{SubscriptionID: id, Types: req.Types}
+	if len(resp.Types) == 0 {
+		resp.Types = events.Types
+	}
+
+	return nil
+}
+
+// Unsubscribe stops a subscription of the calling connection
+func (s *EventsService) Unsubscribe(ctx context.Context, req *UnsubscribeRequest, resp *UnsubscribeResponse) error {
+	conn := connectionFrom(ctx)
+	if conn == nil {
+		return errors.New("events need a persistent connection").Error()
+	}
+
+	resp.Unsubscribed = conn.unsubscribe(req.SubscriptionID)
+	return nil
+}
+
 // --- List Service ---
 
 type ListService struct {
@@ -395,9 +1251,12 @@ type ListService struct {
 }
 
 type ListRequest struct {
-	Provider string `json:"provider,omitempty"`
-	Limit    int    `json:"limit,omitempty"`
-	Page     int    `json:"page,omitempty"`
+	Operation
+
+	Provider  string   `json:"provider,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+	Limit     int      `json:"limit,omitempty"`
+	Page      int      `json:"page,omitempty"`
 }
 
 type ListItem struct {
@@ -405,6 +1264,7 @@ type ListItem struct {
 	Title        string `json:"title"`
 	Provider     string `json:"provider"`
 	ProviderName string `json:"provider_name"`
+	CoverURL     string `json:"cover_url,omitempty"`
 }
 
 type ListResponse struct {
@@ -412,25 +1272,241 @@ type ListResponse struct {
 	Count        int        `json:"count"`
 	Provider     string     `json:"provider,omitempty"`
 	ProviderName string     `json:"provider_name,omitempty"`
+	// Providers tells how every provider of a listing across providers fared, failed ones
+	// included
+	Providers []ProviderOutcome `json:"providers,omitempty"`
 }
 
-func (s *ListService) Latest(req *ListRequest, resp *ListResponse) error {
-	// This would typically fetch latest manga from providers
-	// For now, return empty as this requires provider-specific implementation
-	*resp = ListResponse{
-		Results: []ListItem{},
-		Count:   0,
+// Latest lists the manga each provider's catalogue shows first when sorted by latest
+// update, as an empty search. Page n skips the first n-1 pages of limit results.
+func (s *ListService) Latest(ctx context.Context, req *ListRequest, resp *ListResponse) error {
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Provider != "" && len(req.Providers) > 0 {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: provider and providers can't be used together"}
 	}
 
+	options := core.SearchOptions{Limit: req.Limit * req.Page, Pages: req.Page, Sort: "latest"}
+	page := func(provider engine.Provider, mangas []core.Manga) []ListItem {
+		skip := min((req.Page-1)*req.Limit, len(mangas))
+		items := make([]ListItem, 0, len(mangas)-skip)
+		for _, manga := range mangas[skip:] {
+			items = append(items, ListItem{
+				ID:           fmt.Sprintf("%s:%s", provider.ID(), manga.ID),
+				Title:        manga.Title,
+				Provider:     provider.ID(),
+				ProviderName: provider.Name(),
+				CoverURL:     manga.CoverURL,
+			})
+		}
+		return items
+	}
+
+	*resp = ListResponse{Results: []ListItem{}}
 	if req.Provider != "" {
-		if provider := s.server.engine.GetProviderOrNil(req.Provider); provider != nil {
-			resp.Provider = req.Provider
-			resp.ProviderName = provider.Name()
+		provider, err := s.server.engine.GetProvider(req.Provider)
+		if err != nil {
+			return errors.TP(err, req.Provider)
 		}
+		mangas, err := s.server.engine.Search(ctx, provider, "", options)
+		if err != nil {
+			return errors.TP(err, req.Provider)
+		}
+		resp.Results = page(provider, mangas)
+		resp.Provider, resp.ProviderName = provider.ID(), provider.Name()
 	} else {
+		providers, err := s.server.engine.SelectProviders(req.Providers)
+		if err != nil {
+			return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+		}
+		byID := make(map[string]engine.Provider, len(providers))
+		for _, provider := range providers {
+			byID[provider.ID()] = provider
+		}
+
+		multi := s.server.engine.SearchAcrossProviders(ctx, providers, "", options, nil)
+		if ctx.Err() != nil {
+			return errors.Track(ctx.Err()).WithMessage("Listing aborted").Error()
+		}
+		for _, failure := range multi.Failures() {
+			s.server.engine.Log(ctx).Error("Listing latest manga failed for %s: %v", failure.Provider, failure.Err)
+		}
+		if multi.AllFailed() {
+			return errors.Track(multi.FirstError()).
+				WithMessagef("Listing latest manga failed on all %d providers", len(providers)).
+				Error()
+		}
+		// Providers take turns, so the limit doesn't leave out all but the first of them
+		var lists [][]ListItem
+		for _, success := range multi.Successes() {
+			lists = append(lists, page(byID[success.Provider], success.Value))
+		}
+		for i := 0; len(resp.Results) < req.Limit; i++ {
+			added := false
+			for _, list := range lists {
+				if i < len(list) {
+					resp.Results = append(resp.Results, list[i])
+					added = true
+				}
+			}
+			if !added {
+				break
+			}
+		}
 		resp.ProviderName = "Multiple Providers"
+		resp.Providers = providerOutcomes(multi, func(mangas []core.Manga) int { return len(mangas) })
+	}
+
+	if len(resp.Results) > req.Limit {
+		resp.Results = resp.Results[:req.Limit]
+	}
+	resp.Count = len(resp.Results)
+	return nil
+}
+
+// --- Tags Service ---
+
+type TagsService struct {
+	server *Server
+}
+
+type TagsRequest struct {
+	Operation
+
+	Provider string `json:"provider"`
+}
+
+type TagsResponse struct {
+	Provider     string     `json:"provider"`
+	ProviderName string     `json:"provider_name"`
+	Tags         []core.Tag `json:"tags"`
+	Count        int        `json:"count"`
+}
+
+func (s *TagsService) List(ctx context.Context, req *TagsRequest, resp *TagsResponse) error {
+	browser, provider, err := s.tagBrowser(req.Provider)
+	if err != nil {
+		return err
+	}
+
+	tags, err := s.server.engine.GetTags(ctx, browser, provider.ID())
+	if err != nil {
+		return errors.TP(err, req.Provider)
+	}
+	if tags == nil {
+		tags = []core.Tag{}
+	}
+
+	*resp = TagsResponse{
+		Provider:     req.Provider,
+		ProviderName: provider.Name(),
+		Tags:         tags,
+		Count:        len(tags),
+	}
+	return nil
+}
+
+type BrowseTagRequest struct {
+	Operation
+
+	Provider string            `json:"provider"`
+	Tag      string            `json:"tag"`
+	Limit    int               `json:"limit,omitempty"`
+	Filters  map[string]string `json:"filters,omitempty"`
+}
+
+func (s *TagsService) Browse(ctx context.Context, req *BrowseTagRequest, resp *ListResponse) error {
+	if req.Tag == "" {
+		return errors.New("tag is required").Error()
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	browser, provider, err := s.tagBrowser(req.Provider)
+	if err != nil {
+		return err
+	}
+
+	mangas, err := s.server.engine.BrowseTag(ctx, browser, provider.ID(), req.Tag, core.SearchOptions{Limit: req.Limit, Filters: req.Filters})
+	if err != nil {
+		return errors.TP(err, req.Provider)
+	}
+
+	results := make([]ListItem, 0, len(mangas))
+	for _, manga := range mangas {
+		results = append(results, ListItem{
+			ID:           fmt.Sprintf("%s:%s", req.Provider, manga.ID),
+			Title:        manga.Title,
+			Provider:     req.Provider,
+			ProviderName: provider.Name(),
+			CoverURL:     manga.CoverURL,
+		})
+	}
+
+	*resp = ListResponse{
+		Results:      results,
+		Count:        len(results),
+		Provider:     req.Provider,
+		ProviderName: provider.Name(),
 	}
+	return nil
+}
 
+// tagBrowser looks up a provider that supports tag browsing
+func (s *TagsService) tagBrowser(providerID string) (engine.TagBrowser, engine.Provider, error) {
+	provider, err := s.server.engine.GetProvider(providerID)
+	if err != nil {
+		return nil, nil, errors.TP(err, providerID)
+	}
+
+	browser, ok := provider.(engine.TagBrowser)
+	if !ok {
+		return nil, nil, errors.Newf("provider %s does not support tag browsing", providerID).AsProvider(providerID).Error()
+	}
+	return browser, provider, nil
+}
+
+// --- Logs Service ---
+
+type LogsService struct {
+	server *Server
+}
+
+type LogsTailRequest struct {
+	Count  int    `json:"count,omitempty"`  // Latest records to return; default 100
+	Level  string `json:"level,omitempty"`  // Minimum level: debug, info, warn or error
+	Module string `json:"module,omitempty"` // Package such as "network" or source file such as "mangadex"
+}
+
+type LogsTailResponse struct {
+	Records []logger.Record `json:"records"`
+	Count   int             `json:"count"`
+}
+
+// Tail returns the latest log records kept in memory, oldest first
+func (s *LogsService) Tail(req *LogsTailRequest, resp *LogsTailResponse) error {
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		return errors.Track(err).WithContext("level", req.Level).Error()
+	}
+	count := req.Count
+	if count <= 0 {
+		count = 100
+	}
+
+	var records []logger.Record
+	if service, ok := s.server.engine.Logger.(*logger.Service); ok {
+		records = service.Tail(count, level, req.Module)
+	}
+	if records == nil {
+		records = []logger.Record{}
+	}
+	*resp = LogsTailResponse{Records: records, Count: len(records)}
 	return nil
 }
 
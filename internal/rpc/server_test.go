@@ -0,0 +1,92 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/provider/base"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestListLatest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	eng := engine.New(engine.WithLogger(logger.NewService("")))
+	for _, id := range []string{"aaa", "bbb"} {
+		provider := base.New(eng, base.Config{ID: id, Name: id, SiteURL: "https://example.com", Type: base.TypeWeb}).
+			WithSearch(func(_ context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+				if query != "" || options.Sort != "latest" {
+					return nil, fmt.Errorf("searched %q sorted by %q", query, options.Sort)
+				}
+				var mangas []core.Manga
+				for n := 1; n <= options.Limit; n++ {
+					mangas = append(mangas, core.Manga{ID: fmt.Sprint(n), Title: fmt.Sprintf("%s %d", id, n)})
+				}
+				return mangas, nil
+			}).
+			Build()
+		if err := eng.RegisterProvider(provider); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server, err := NewServer(eng, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := &ListService{server: server}
+
+	tests := []struct {
+		name     string
+		req      ListRequest
+		want     []string
+		outcomes []string // Providers reported in Providers
+	}{
+		{"one provider", ListRequest{Provider: "aaa", Limit: 2}, []string{"aaa:1", "aaa:2"}, nil},
+		{"second page", ListRequest{Provider: "aaa", Limit: 2, Page: 2}, []string{"aaa:3", "aaa:4"}, nil},
+		{"providers take turns", ListRequest{Providers: []string{"aaa", "bbb"}, Limit: 3}, []string{"aaa:1", "bbb:1", "aaa:2"}, []string{"aaa", "bbb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp ListResponse
+			if err := list.Latest(context.Background(), &tt.req, &resp); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, item := range resp.Results {
+				ids = append(ids, item.ID)
+			}
+			if !slices.Equal(ids, tt.want) || resp.Count != len(tt.want) {
+				t.Errorf("Latest() = %v (count %d), want %v", ids, resp.Count, tt.want)
+			}
+			var outcomes []string
+			for _, outcome := range resp.Providers {
+				if outcome.Error != "" || outcome.Count != tt.req.Limit {
+					t.Errorf("outcome of %s = %+v", outcome.Provider, outcome)
+				}
+				outcomes = append(outcomes, outcome.Provider)
+			}
+			if !slices.Equal(outcomes, tt.outcomes) {
+				t.Errorf("provider outcomes for %v, want %v", outcomes, tt.outcomes)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/errors"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webhookQueueSize is how many downloads the webhook holds before refusing new ones
+const webhookQueueSize = 100
+
+// quotaPollInterval is how often a queue paused by the download quota checks for space
+const quotaPollInterval = time.Minute
+
+// Webhook is an HTTP endpoint that queues downloads, for integrations such as browser
+// bookmarklets or RSS-to-webhook services. Requests must carry the token as a bearer
+// token or in their body.
+type Webhook struct {
+	engine    *engine.Engine
+	token     string
+	outputDir string
+
+	queue chan WebhookJob
+	mu    sync.Mutex
+	depth int // Queued jobs not yet finished
+}
+
+// WebhookJob is a download queued through the webhook
+type WebhookJob struct {
+	Target    string `json:"target"`
+	Provider  string `json:"provider"`
+	Kind      string `json:"kind"` // engine.URLKindManga or engine.URLKindChapter
+	ID        string `json:"id"`
+	OutputDir string `json:"output_dir"`
+	// AllLanguages downloads a manga in every language instead of the preferred ones
+	AllLanguages bool `json:"all_languages,omitempty"`
+	Position     int  `json:"position"` // Jobs ahead of this one, the running one included
+}
+
+type webhookError struct {
+	Error string `json:"error"`
+}
+
+// NewWebhook creates a webhook saving downloads below outputDir. Call Run to process
+// the queue.
+func NewWebhook(eng *engine.Engine, token, outputDir string) (*Webhook, error) {
+	if token == "" {
+		return nil, errors.New("webhook token is required").
+			WithMessage("The webhook needs a token so only you can queue downloads").
+			Error()
+	}
+	return &Webhook{
+		engine:    eng,
+		token:     token,
+		outputDir: outputDir,
+		queue:     make(chan WebhookJob, webhookQueueSize),
+	}, nil
+}
+
+// Run downloads the queued jobs one at a time until ctx ends. Reaching the download quota
+// pauses the queue until space is freed, then the job runs again.
+func (w *Webhook) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-w.queue:
+			for errors.Is(w.download(ctx, job), download.ErrQuotaExceeded) {
+				if !w.waitForQuota(ctx, job.OutputDir) {
+					return
+				}
+			}
+			w.mu.Lock()
+			w.depth--
+			w.mu.Unlock()
+		}
+	}
+}
+
+// download runs a job; its progress and outcome are published as download events
+func (w *Webhook) download(ctx context.Context, job WebhookJob) error {
+	log := w.engine.Log(ctx)
+	provider, err := w.engine.GetProvider(job.Provider)
+	if err != nil {
+		log.Error("Webhook download of %s failed: %v", job.Target, err)
+		return err
+	}
+
+	log.Info("Webhook downloading %s %s:%s to %s", job.Kind, job.Provider, job.ID, job.OutputDir)
+	if job.Kind == engine.URLKindManga {
+		_, err = w.engine.DownloadManga(ctx, provider, job.ID, engine.MangaDownloadOptions{
+			OutputDir:    job.OutputDir,
+			AllLanguages: job.AllLanguages,
+		})
+	} else {
+		_, err = w.engine.DownloadChapterRecord(ctx, provider, job.ID, job.OutputDir)
+	}
+	if err != nil {
+		log.Error("Webhook download of %s failed: %v", job.Target, err)
+	}
+	return err
+}
+
+// waitForQuota waits until dir is below the download quota again, reporting false when
+// ctx ends first
+func (w *Webhook) waitForQuota(ctx context.Context, dir string) bool {
+	w.engine.Log(ctx).Warn("Webhook queue paused: %s reached the download quota", dir)
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(quotaPollInterval):
+		}
+		if w.engine.Download.CheckQuota(dir) == nil {
+			w.engine.Log(ctx).Info("Webhook queue resumed: %s is below the download quota", dir)
+			return true
+		}
+	}
+}
+
+// ServeHTTP queues the download named by the "target" field of a POST body: a manga or
+// chapter URL, or provider:chapter-id; "kind=manga" makes a provider:id a whole manga,
+// downloaded in the preferred languages unless "all_languages" is set.
+// Bodies are JSON or form-encoded. The token comes as a bearer token or as the body's
+// "token" field; URL parameters are ignored so it doesn't end up in logs and histories.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", "POST")
+		writeWebhookJSON(rw, http.StatusMethodNotAllowed, webhookError{"use POST"})
+		return
+	}
+
+	var body struct {
+		Target       string `json:"target"`
+		Kind         string `json:"kind"`
+		Token        string `json:"token"`
+		URL          string `json:"url"`
+		AllLanguages bool   `json:"all_languages"`
+	}
+	r.Body = http.MaxBytesReader(rw, r.Body, 64<<10)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeWebhookJSON(rw, http.StatusBadRequest, webhookError{"invalid JSON body"})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			writeWebhookJSON(rw, http.StatusBadRequest, webhookError{"invalid form body"})
+			return
+		}
+		body.Target = strings.TrimSpace(r.PostForm.Get("target"))
+		body.Kind = strings.TrimSpace(r.PostForm.Get("kind"))
+		body.Token = r.PostForm.Get("token")
+		body.URL = strings.TrimSpace(r.PostForm.Get("url"))
+		body.AllLanguages, _ = strconv.ParseBool(r.PostForm.Get("all_languages"))
+	}
+
+	token := body.Token
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token = strings.TrimPrefix(auth, "Bearer ")
+		if token == auth {
+			token = "" // Another scheme
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(w.token)) != 1 {
+		writeWebhookJSON(rw, http.StatusUnauthorized, webhookError{"missing or wrong token"})
+		return
+	}
+
+	target := body.Target
+	if target == "" {
+		target = body.URL // Bookmarklets tend to send the page as url
+	}
+	job, err := w.resolve(target, body.Kind)
+	if err != nil {
+		writeWebhookJSON(rw, http.StatusBadRequest, webhookError{err.Error()})
+		return
+	}
+	job.AllLanguages = body.AllLanguages && job.Kind == engine.URLKindManga
+
+	w.mu.Lock()
+	job.Position = w.depth
+	select {
+	case w.queue <- job:
+		w.depth++
+		w.mu.Unlock()
+	default:
+		w.mu.Unlock()
+		writeWebhookJSON(rw, http.StatusServiceUnavailable, webhookError{"download queue is full, try again later"})
+		return
+	}
+
+	w.engine.Logger.Info("Webhook queued %s %s:%s", job.Kind, job.Provider, job.ID)
+	writeWebhookJSON(rw, http.StatusAccepted, job)
+}
+
+// resolve turns a webhook target into a job
+func (w *Webhook) resolve(target, kind string) (WebhookJob, error) {
+	job := WebhookJob{Target: target, OutputDir: w.outputDir}
+	switch {
+	case target == "":
+		return job, errors.New("target is required").Error()
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		resolved, err := w.engine.ResolveURL(target)
+		if err != nil {
+			return job, err
+		}
+		job.Provider, job.Kind, job.ID = resolved.Provider.ID(), resolved.Kind, resolved.ID
+	default:
+		provider, id, err := w.engine.ResolveChapter(target)
+		if err != nil {
+			return job, err
+		}
+		job.Provider, job.Kind, job.ID = provider.ID(), engine.URLKindChapter, id
+		if kind == engine.URLKindManga {
+			job.Kind = engine.URLKindManga
+		}
+	}
+	return job, nil
+}
+
+func writeWebhookJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,98 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/provider/base"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookVerification(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	eng := engine.New()
+	provider := base.New(eng, base.Config{ID: "tst", Name: "Test", SiteURL: "https://example.com", Type: base.TypeWeb}).Build()
+	if err := eng.RegisterProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	webhook, err := NewWebhook(eng, "secret", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		header   string // Authorization header
+		body     string // JSON body, or a form when it doesn't start with {
+		status   int
+		wantKind string
+		wantAll  bool // All languages
+	}{
+		{"json body", http.MethodPost, "/", "", `{"target":"tst:series","kind":"manga","token":"secret"}`, http.StatusAccepted, engine.URLKindManga, false},
+		{"form body", http.MethodPost, "/", "", "token=secret&target=tst:ch1", http.StatusAccepted, engine.URLKindChapter, false},
+		{"all languages", http.MethodPost, "/", "", `{"target":"tst:series","kind":"manga","token":"secret","all_languages":true}`, http.StatusAccepted, engine.URLKindManga, true},
+		{"all languages form", http.MethodPost, "/", "", "token=secret&target=tst:series&kind=manga&all_languages=1", http.StatusAccepted, engine.URLKindManga, true},
+		{"all languages of a chapter", http.MethodPost, "/", "", "token=secret&target=tst:ch1&all_languages=1", http.StatusAccepted, engine.URLKindChapter, false},
+		{"bearer token", http.MethodPost, "/", "Bearer secret", `{"target":"tst:ch1"}`, http.StatusAccepted, engine.URLKindChapter, false},
+		{"url field", http.MethodPost, "/", "", "token=secret&url=tst:ch2", http.StatusAccepted, engine.URLKindChapter, false},
+		{"token parameter", http.MethodPost, "/?token=secret", "", `{"target":"tst:ch1"}`, http.StatusUnauthorized, "", false},
+		{"target parameter", http.MethodPost, "/?target=tst:ch1", "Bearer secret", "", http.StatusBadRequest, "", false},
+		{"get", http.MethodGet, "/?token=secret&target=tst:ch1", "", "", http.StatusMethodNotAllowed, "", false},
+		{"wrong token", http.MethodPost, "/", "", `{"target":"tst:ch1","token":"guess"}`, http.StatusUnauthorized, "", false},
+		{"missing token", http.MethodPost, "/", "", `{"target":"tst:ch1"}`, http.StatusUnauthorized, "", false},
+		{"wrong bearer token", http.MethodPost, "/", "Bearer guess", `{"target":"tst:ch1","token":"secret"}`, http.StatusUnauthorized, "", false},
+		{"other scheme", http.MethodPost, "/", "Basic secret", `{"target":"tst:ch1"}`, http.StatusUnauthorized, "", false},
+		{"invalid body", http.MethodPost, "/", "", `{"target":`, http.StatusBadRequest, "", false},
+		{"missing target", http.MethodPost, "/", "", `{"token":"secret"}`, http.StatusBadRequest, "", false},
+		{"unknown provider", http.MethodPost, "/", "", `{"target":"nope:ch1","token":"secret"}`, http.StatusBadRequest, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if strings.HasPrefix(tt.body, "{") {
+				req.Header.Set("Content-Type", "application/json")
+			} else if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			webhook.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusAccepted {
+				return
+			}
+			var job WebhookJob
+			if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+				t.Fatal(err)
+			}
+			if job.Provider != "tst" || job.Kind != tt.wantKind || job.AllLanguages != tt.wantAll {
+				t.Errorf("job = %+v, want a %s of tst, all languages %v", job, tt.wantKind, tt.wantAll)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/errors"
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the client's key to compute the handshake answer
+// (RFC 6455, section 1.3)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes (RFC 6455, section 5.2)
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// wsProtocolError is the close code sent for malformed frames
+const wsProtocolError = 1002
+
+// WebSocket serves the JSON-RPC protocol over WebSocket connections, for browser clients
+// that can't open sockets. Every text message is a request or a batch, and every
+// response and notification arrives as a message of its own, so Events.Subscribe works
+// as on a socket. With a token set, the upgrade request must carry it as a bearer token
+// or a "token" parameter, since browsers can't set headers on WebSocket requests.
+//
+// Browsers let any page open a WebSocket to localhost, so upgrade requests sent by a
+// page (those with an Origin header) are refused unless the origin is allowed.
+type WebSocket struct {
+	server  *Server
+	token   string
+	origins map[string]bool // Allowed origins, lowercased; "*" allows every origin
+}
+
+// NewWebSocket creates the WebSocket endpoint of a server, accepting browser clients
+// from the given origins (e.g. "http://localhost:3000", or "*" for any)
+func NewWebSocket(server *Server, token string, origins []string) *WebSocket {
+	w := &WebSocket{server: server, token: token, origins: make(map[string]bool, len(origins))}
+	for _, origin := range origins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			w.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+	return w
+}
+
+// originAllowed reports whether an upgrade request may be served: clients other than
+// browsers send no Origin header
+func (w *WebSocket) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || w.origins["*"] {
+		return true
+	}
+	return w.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))]
+}
+
+// ServeHTTP upgrades the request to a WebSocket and serves it until the client leaves
+func (w *WebSocket) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		rw.Header().Set("Allow", "GET")
+		http.Error(rw, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	if !w.originAllowed(r) {
+		w.server.engine.Logger.Warn("Refused WebSocket client from origin %s", r.Header.Get("Origin"))
+		http.Error(rw, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if w.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			token = r.FormValue("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(w.token)) != 1 {
+			http.Error(rw, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		rw.Header().Set("Upgrade", "websocket")
+		http.Error(rw, "expected a WebSocket upgrade", http.StatusUpgradeRequired)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		rw.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(rw, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return
+	}
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if key == "" {
+		http.Error(rw, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok {
+		http.Error(rw, "WebSocket upgrades are not supported here", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		w.server.engine.Logger.Error("Failed to take over WebSocket connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	// The HTTP server's deadlines don't apply to a connection that stays open
+	_ = conn.SetDeadline(time.Time{})
+
+	bucket, release, ok := w.server.clients.acquire(conn, w.server.limits)
+	if !ok {
+		w.server.engine.Logger.Warn("Refused WebSocket client %s: %d connections open", conn.RemoteAddr(), w.server.limits.MaxConnections)
+		_, _ = io.WriteString(conn, "HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		return
+	}
+	defer release()
+
+	if _, err := io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: "+websocketAccept(key)+"\r\n\r\n"); err != nil {
+		return
+	}
+
+	ws := &wsConn{conn: conn, reader: buffered.Reader}
+	w.server.engine.Logger.Info("WebSocket client connected: %s", conn.RemoteAddr())
+	if err := w.server.serveMessages(r.Context(), ws, ws.readMessage, bucket); err != nil {
+		w.server.engine.Logger.Error("WebSocket connection failed: %v", err)
+		_ = ws.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, wsProtocolError))
+	}
+	w.server.engine.Logger.Info("WebSocket client disconnected: %s", conn.RemoteAddr())
+}
+
+// websocketAccept answers a handshake key
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether a comma-separated header lists token, ignoring case
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn sends the responses of serveMessages as WebSocket messages and reads the
+// requests of the client, one whole message each, so requests spanning several lines
+// aren't split
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex // Pongs are written while serveMessages writes responses
+}
+
+// Write sends a response or notification, which serveMessages writes at once with its
+// newline, as one text message
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsText, bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// readMessage reads frames until a whole data message arrived, handling the control
+// frames in between. It returns io.EOF once the client closes the connection.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+		case wsClose:
+			// Echo the status code to complete the closing handshake
+			_ = c.writeFrame(wsClose, payload[:min(len(payload), 2)])
+			return nil, io.EOF
+		case wsText, wsBinary, wsContinuation:
+			if len(message)+len(payload) > maxLineSize {
+				return nil, errors.Newf("WebSocket message larger than %d bytes", maxLineSize).Error()
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, errors.Newf("unknown WebSocket opcode %#x", opcode).Error()
+		}
+	}
+}
+
+// readFrame reads one frame and unmasks its payload. Clients must mask every frame.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, errors.New("unmasked WebSocket frame from client").Error()
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxLineSize {
+		return false, 0, nil, errors.Newf("WebSocket frame larger than %d bytes", maxLineSize).Error()
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends an unfragmented, unmasked frame
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(n))
+	}
+	frame = append(frame, payload...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,289 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/engine/logger"
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebSocketAccept(t *testing.T) {
+	// The example handshake of RFC 6455, section 1.3
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("websocketAccept() = %q", got)
+	}
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	ts := newWebSocketTestServer(t, "secret", "http://localhost:3000")
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"missing token", "/", upgradeHeaders(), http.StatusUnauthorized},
+		{"wrong token", "/?token=nope", upgradeHeaders(), http.StatusUnauthorized},
+		{"plain request", "/?token=secret", nil, http.StatusUpgradeRequired},
+		{"old version", "/?token=secret", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "a2V5"}, http.StatusUpgradeRequired},
+		{"bearer token", "/", withHeader(upgradeHeaders(), "Authorization", "Bearer secret"), http.StatusSwitchingProtocols},
+		{"token parameter", "/?token=secret", upgradeHeaders(), http.StatusSwitchingProtocols},
+		{"foreign origin", "/?token=secret", withHeader(upgradeHeaders(), "Origin", "https://evil.example"), http.StatusForbidden},
+		{"allowed origin", "/?token=secret", withHeader(upgradeHeaders(), "Origin", "http://LOCALHOST:3000"), http.StatusSwitchingProtocols},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp := dialWebSocket(t, ts, tt.path, tt.headers)
+			defer conn.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketRefusesPagesWithoutToken(t *testing.T) {
+	// Without a token, the origin check alone keeps web pages out
+	ts := newWebSocketTestServer(t, "")
+	conn, resp := dialWebSocket(t, ts, "/", withHeader(upgradeHeaders(), "Origin", "https://evil.example"))
+	defer conn.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestWebSocketEvents(t *testing.T) {
+	ts := newWebSocketTestServer(t, "")
+	conn, resp := dialWebSocket(t, ts, "/", upgradeHeaders())
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	reader := bufio.NewReader(conn)
+
+	// A ping in the middle of a fragmented request is answered right away
+	request := `{"jsonrpc":"2.0","method":"Events.Subscribe","params":{"types":["chapter.new"]},"id":1}`
+	writeClientFrame(t, conn, false, wsText, []byte(request[:20]))
+	writeClientFrame(t, conn, true, wsPing, []byte("hi"))
+	if opcode, payload := readServerFrame(t, reader); opcode != wsPong || string(payload) != "hi" {
+		t.Fatalf("got opcode %#x %q, want pong", opcode, payload)
+	}
+	writeClientFrame(t, conn, true, wsContinuation, []byte(request[20:]))
+
+	var subscribed struct {
+		Result SubscribeResponse `json:"result"`
+		Error  *Error            `json:"error"`
+	}
+	readServerJSON(t, reader, &subscribed)
+	if subscribed.Error != nil || subscribed.Result.SubscriptionID == "" {
+		t.Fatalf("subscribe failed: %+v", subscribed)
+	}
+
+	ts.engine.Events.Publish(events.ChapterNew, map[string]interface{}{"provider": "mgd", "chapter_id": "chapter-1"})
+	var notification struct {
+		Method string      `json:"method"`
+		Params EventParams `json:"params"`
+	}
+	readServerJSON(t, reader, &notification)
+	if notification.Method != EventNotification || notification.Params.Type != events.ChapterNew ||
+		notification.Params.Data["chapter_id"] != "chapter-1" {
+		t.Errorf("notification = %+v", notification)
+	}
+
+	// Closing is echoed
+	writeClientFrame(t, conn, true, wsClose, binary.BigEndian.AppendUint16(nil, 1000))
+	if opcode, payload := readServerFrame(t, reader); opcode != wsClose || binary.BigEndian.Uint16(payload) != 1000 {
+		t.Errorf("got opcode %#x %v, want close 1000", opcode, payload)
+	}
+}
+
+func TestWebSocketMultiLineRequest(t *testing.T) {
+	ts := newWebSocketTestServer(t, "")
+	conn, _ := dialWebSocket(t, ts, "/", upgradeHeaders())
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	// A pretty-printed request is still one request
+	request := "{\n  \"jsonrpc\": \"2.0\",\n  \"method\": \"Meta.Ping\",\n  \"id\": 7\n}"
+	writeClientFrame(t, conn, true, wsText, []byte(request))
+
+	var resp struct {
+		ID     int          `json:"id"`
+		Result PingResponse `json:"result"`
+		Error  *Error       `json:"error"`
+	}
+	readServerJSON(t, reader, &resp)
+	if resp.Error != nil || resp.ID != 7 || resp.Result.Status == "" {
+		t.Fatalf("response = %+v, want the ping result", resp)
+	}
+
+	// Nothing else is answered
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := reader.ReadByte(); err == nil {
+		t.Error("got more than one response")
+	}
+}
+
+func TestWebSocketRejectsUnmaskedFrames(t *testing.T) {
+	ts := newWebSocketTestServer(t, "")
+	conn, _ := dialWebSocket(t, ts, "/", upgradeHeaders())
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte{0x80 | wsText, 2, '{', '}'})
+	opcode, payload := readServerFrame(t, bufio.NewReader(conn))
+	if opcode != wsClose || binary.BigEndian.Uint16(payload) != wsProtocolError {
+		t.Errorf("got opcode %#x %v, want close %d", opcode, payload, wsProtocolError)
+	}
+}
+
+type webSocketTestServer struct {
+	*httptest.Server
+	engine *engine.Engine
+}
+
+func newWebSocketTestServer(t *testing.T, token string, origins ...string) *webSocketTestServer {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	eng := engine.New(engine.WithLogger(logger.NewService("")))
+	server, err := NewServer(eng, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(NewWebSocket(server, token, origins))
+	t.Cleanup(ts.Close)
+	return &webSocketTestServer{Server: ts, engine: eng}
+}
+
+func upgradeHeaders() map[string]string {
+	return map[string]string{
+		"Connection":            "keep-alive, Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Version": "13",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+	}
+}
+
+func withHeader(headers map[string]string, name, value string) map[string]string {
+	headers[name] = value
+	return headers
+}
+
+// dialWebSocket sends an upgrade request and returns the connection and the response
+func dialWebSocket(t *testing.T, ts *webSocketTestServer, path string, headers map[string]string) (net.Conn, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the response headers byte by byte so no frame is consumed with them
+	var head strings.Builder
+	buf := make([]byte, 1)
+	for !strings.HasSuffix(head.String(), "\r\n\r\n") {
+		if _, err := conn.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		head.WriteByte(buf[0])
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(head.String())), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols && resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(headers["Sec-WebSocket-Key"]) {
+		t.Errorf("Sec-WebSocket-Accept = %q", resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return conn, resp
+}
+
+// writeClientFrame writes a masked frame, as clients must
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads an unmasked frame of at most 64 KiB
+func readServerFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	t.Helper()
+
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+// readServerJSON reads a text message and decodes it
+func readServerJSON(t *testing.T, reader *bufio.Reader, v interface{}) {
+	t.Helper()
+
+	opcode, payload := readServerFrame(t, reader)
+	if opcode != wsText {
+		t.Fatalf("got opcode %#x, want a text message", opcode)
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		t.Fatalf("%v: %s", err, payload)
+	}
+}
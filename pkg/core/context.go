@@ -0,0 +1,71 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type correlationKey struct{}
+
+type pageConcurrencyKey struct{}
+
+type chapterConcurrencyKey struct{}
+
+// NewCorrelationID returns a short random ID identifying one CLI or RPC operation
+func NewCorrelationID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithCorrelationID returns a context carrying the correlation ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID carried by ctx, or an empty string
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// WithPageConcurrency returns a context whose chapter downloads fetch up to n pages at once
+func WithPageConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, pageConcurrencyKey{}, n)
+}
+
+// PageConcurrency returns how many pages of a chapter ctx downloads at once, or 0 for the
+// download service's default
+func PageConcurrency(ctx context.Context) int {
+	n, _ := ctx.Value(pageConcurrencyKey{}).(int)
+	return max(n, 0)
+}
+
+// WithChapterConcurrency returns a context whose batch downloads handle up to n chapters
+// at once
+func WithChapterConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, chapterConcurrencyKey{}, n)
+}
+
+// ChapterConcurrency returns how many chapters ctx downloads at once, 1 unless set
+func ChapterConcurrency(ctx context.Context) int {
+	n, _ := ctx.Value(chapterConcurrencyKey{}).(int)
+	return max(n, 1)
+}
@@ -0,0 +1,74 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultPreferredLanguages is the language priority used when none is configured
+var DefaultPreferredLanguages = []string{"en"}
+
+// LanguagePriority cleans up a configured order in which languages are chosen when a
+// title or description comes in several, e.g. ja-ro, en, ja: lowercased and without
+// blanks. An empty list gives DefaultPreferredLanguages.
+func LanguagePriority(languages []string) []string {
+	var cleaned []string
+	for _, lang := range languages {
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+			cleaned = append(cleaned, lang)
+		}
+	}
+	if len(cleaned) == 0 {
+		return slices.Clone(DefaultPreferredLanguages)
+	}
+	return cleaned
+}
+
+// BestLocalized reduces a map of language to text to the text in the language coming
+// first in preferred (see LanguagePriority). A preferred "en" also takes regional variants such as "en-us". Without any
+// preferred language, the text of the alphabetically first language is used; "" when
+// all are empty.
+func BestLocalized(texts map[string]string, preferred []string) string {
+	langs := make([]string, 0, len(texts))
+	for lang, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			langs = append(langs, lang)
+		}
+	}
+	if len(langs) == 0 {
+		return ""
+	}
+	slices.Sort(langs)
+
+	for _, pref := range preferred {
+		for _, lang := range langs {
+			if strings.EqualFold(lang, pref) {
+				return texts[lang]
+			}
+		}
+	}
+	for _, pref := range preferred {
+		for _, lang := range langs {
+			if base, _, ok := strings.Cut(strings.ToLower(lang), "-"); ok && base == pref {
+				return texts[lang]
+			}
+		}
+	}
+	return texts[langs[0]]
+}
@@ -16,7 +16,12 @@
 
 package core
 
-import "time"
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
 
 // Manga represents basic manga information
 type Manga struct {
@@ -25,27 +30,108 @@ type Manga struct {
 	AlternativeTitles []string `json:"alt_titles,omitempty"`
 	Description       string   `json:"description,omitempty"`
 	Authors           []string `json:"authors,omitempty"`
+	Artists           []string `json:"artists,omitempty"`
 	Status            string   `json:"status,omitempty"`
 	Tags              []string `json:"tags,omitempty"`
 	CoverURL          string   `json:"cover_url,omitempty"`
+	Demographic       string   `json:"demographic,omitempty"`
+	ContentRating     string   `json:"content_rating,omitempty"`
+
+	// MatchedTitle is the title or alternative title a search matched, when alternative
+	// titles were searched
+	MatchedTitle string `json:"matched_title,omitempty"`
 }
 
 // MangaInfo represents detailed manga information including chapters
 type MangaInfo struct {
 	Manga
-	Chapters           []ChapterInfo `json:"chapters"`
-	LastUpdated        *time.Time    `json:"last_updated,omitempty"`
-	AvailableLanguages []string      `json:"available_languages,omitempty"`
+	Chapters           []ChapterInfo  `json:"chapters"`
+	LastUpdated        *time.Time     `json:"last_updated,omitempty"`
+	AvailableLanguages []string       `json:"available_languages,omitempty"`
+	Related            []RelatedManga `json:"related,omitempty"`
+
+	// ExternalIDs maps tracker sites (see the External* constants) to the manga's ID there
+	ExternalIDs map[string]string `json:"external_ids,omitempty"`
+}
+
+// Clone returns a copy of the manga information sharing no slices, maps or times with it
+func (m *MangaInfo) Clone() *MangaInfo {
+	if m == nil {
+		return nil
+	}
+	clone := *m
+	clone.AlternativeTitles = slices.Clone(m.AlternativeTitles)
+	clone.Authors = slices.Clone(m.Authors)
+	clone.Artists = slices.Clone(m.Artists)
+	clone.Tags = slices.Clone(m.Tags)
+	clone.Chapters = slices.Clone(m.Chapters)
+	for i, chapter := range clone.Chapters {
+		clone.Chapters[i].Date = cloneTime(chapter.Date)
+	}
+	clone.LastUpdated = cloneTime(m.LastUpdated)
+	clone.AvailableLanguages = slices.Clone(m.AvailableLanguages)
+	clone.Related = slices.Clone(m.Related)
+	clone.ExternalIDs = maps.Clone(m.ExternalIDs)
+	return &clone
+}
+
+// cloneTime returns a copy of t, or nil if it is
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// Tracker sites used as keys in MangaInfo.ExternalIDs
+const (
+	ExternalAniList      = "anilist"
+	ExternalMyAnimeList  = "mal"
+	ExternalMangaUpdates = "mangaupdates"
+)
+
+// RelatedManga represents a link from one manga to another (sequel, spin-off, recommendation...)
+type RelatedManga struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// Tag is a genre or other category a provider lets its catalogue be browsed by
+type Tag struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"` // Number of manga with the tag, when the site shows it
 }
 
 // ChapterInfo represents basic chapter information
 type ChapterInfo struct {
-	ID       string     `json:"id"`
-	Title    string     `json:"title"`
-	Number   float64    `json:"number"`
-	Volume   string     `json:"volume,omitempty"`
-	Language string     `json:"language,omitempty"`
-	Date     *time.Time `json:"date,omitempty"`
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Number    float64    `json:"number"`
+	Volume    string     `json:"volume,omitempty"`
+	Language  string     `json:"language,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	PageCount int        `json:"page_count,omitempty"`
+
+	// Label is the raw chapter identifier as shown by the source ("10.5 Omake", "Extra", "Oneshot")
+	Label string `json:"label,omitempty"`
+	// Sequence is the provider-supplied reading order, used when Number alone is ambiguous
+	Sequence int `json:"sequence,omitempty"`
+	// Group is the scanlation group that released the chapter
+	Group string `json:"group,omitempty"`
+
+	// ExternalURL is set when the chapter can only be read on an external (official) site
+	ExternalURL string `json:"external_url,omitempty"`
+}
+
+// DisplayNumber returns the label when the source supplied one, otherwise the formatted number
+func (c ChapterInfo) DisplayNumber() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return strconv.FormatFloat(c.Number, 'g', -1, 64)
 }
 
 // Chapter represents a full chapter with pages
@@ -60,6 +146,9 @@ type Page struct {
 	Index    int    `json:"index"`
 	URL      string `json:"url"`
 	Filename string `json:"filename,omitempty"`
+	// Fallbacks are other URLs of the same image (lazy-load attributes, mirrors), tried in
+	// order when URL fails
+	Fallbacks []string `json:"fallbacks,omitempty"`
 }
 
 // SearchOptions configures search behavior
@@ -70,6 +159,9 @@ type SearchOptions struct {
 	Sort             string `json:"sort,omitempty"`
 	IncludeAltTitles bool   `json:"include_alt_titles,omitempty"`
 	Concurrency      int    `json:"concurrency,omitempty"`
+
+	// Filters narrows results by field, e.g. {"demographic": "seinen", "content_rating": "safe"}
+	Filters map[string]string `json:"filters,omitempty"`
 }
 
 // DownloadOptions configures download behavior
@@ -0,0 +1,46 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMangaInfoCloneCopiesDates(t *testing.T) {
+	updated := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	released := updated.Add(-24 * time.Hour)
+	info := &MangaInfo{
+		Manga:       Manga{ID: "m", Tags: []string{"Sports"}},
+		Chapters:    []ChapterInfo{{ID: "c", Number: 1, Date: &released}},
+		LastUpdated: &updated,
+		ExternalIDs: map[string]string{ExternalAniList: "1"},
+	}
+
+	clone := info.Clone()
+	*clone.LastUpdated = time.Time{}
+	*clone.Chapters[0].Date = time.Time{}
+	clone.Tags[0] = "Drama"
+	clone.ExternalIDs[ExternalAniList] = "2"
+
+	if !info.LastUpdated.Equal(updated) || !info.Chapters[0].Date.Equal(released) {
+		t.Errorf("changing the clone's dates changed the original: %v, %v", info.LastUpdated, info.Chapters[0].Date)
+	}
+	if info.Tags[0] != "Sports" || info.ExternalIDs[ExternalAniList] != "1" {
+		t.Errorf("changing the clone changed the original: %v, %v", info.Tags, info.ExternalIDs)
+	}
+}
@@ -0,0 +1,102 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+)
+
+// ProviderResult is the outcome of an operation on one of several providers
+type ProviderResult[T any] struct {
+	Provider string // Provider ID
+	Value    T      // What the provider returned; the zero value when Err is set
+	Err      error
+	Elapsed  time.Duration
+}
+
+// MultiResult is the outcome of an operation run on several providers at once: what each
+// provider returned or why it failed, and how long it took, so callers can tell exactly
+// which providers failed instead of only seeing what the others found
+type MultiResult[T any] struct {
+	// Results holds one result per provider, in the order the providers were given
+	Results []ProviderResult[T]
+	// Elapsed is how long the whole operation took
+	Elapsed time.Duration
+}
+
+// Get returns the result of a provider
+func (r MultiResult[T]) Get(provider string) (ProviderResult[T], bool) {
+	for _, result := range r.Results {
+		if result.Provider == provider {
+			return result, true
+		}
+	}
+	return ProviderResult[T]{}, false
+}
+
+// Successes returns the results of the providers that answered, in provider order
+func (r MultiResult[T]) Successes() []ProviderResult[T] {
+	var successes []ProviderResult[T]
+	for _, result := range r.Results {
+		if result.Err == nil {
+			successes = append(successes, result)
+		}
+	}
+	return successes
+}
+
+// Failures returns the results of the providers that failed, in provider order
+func (r MultiResult[T]) Failures() []ProviderResult[T] {
+	var failures []ProviderResult[T]
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Errors returns the error of every provider that failed, by provider ID
+func (r MultiResult[T]) Errors() map[string]error {
+	errs := make(map[string]error)
+	for _, result := range r.Results {
+		if result.Err != nil {
+			errs[result.Provider] = result.Err
+		}
+	}
+	return errs
+}
+
+// AllFailed reports whether providers were asked and none of them answered
+func (r MultiResult[T]) AllFailed() bool {
+	for _, result := range r.Results {
+		if result.Err == nil {
+			return false
+		}
+	}
+	return len(r.Results) > 0
+}
+
+// FirstError returns the error of the first provider that failed, or nil
+func (r MultiResult[T]) FirstError() error {
+	for _, result := range r.Results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldedLetters maps the letters that don't decompose into a plain letter and a diacritic
+// to the plain letters they are matched as
+var foldedLetters = map[rune]string{
+	'đ': "d", 'ð': "d", 'ħ': "h", 'ı': "i", 'ł': "l", 'ø': "o", 'ŧ': "t",
+	'æ': "ae", 'œ': "oe", 'ß': "ss", 'þ': "th",
+}
+
+// NormalizeTitle reduces a title to the form titles are compared in: compatibility forms
+// such as full-width letters and ligatures folded (NFKD), lower case, diacritics of Latin
+// letters stripped and punctuation turned into spaces. Long vowels are only unified where
+// written with diacritics ("ō" as "o"), since folding "ou" or "oo" would also change
+// English words like "your" or "book". The result is only meant for matching, never for
+// display.
+func NormalizeTitle(title string) string {
+	// NFKD folds compatibility forms and splits letters from their diacritics
+	title = norm.NFKD.String(title)
+
+	var b strings.Builder
+	b.Grow(len(title))
+	var base rune // Letter the next combining mark belongs to
+	for _, r := range title {
+		r = unicode.ToLower(r)
+		if !unicode.Is(unicode.Mn, r) {
+			base = r
+		}
+		switch {
+		case r == '\'' || r == '’' || r == '`':
+			// "Kaguya's" and "Kaguyas" are the same title
+		case unicode.Is(unicode.Mn, r) && unicode.Is(unicode.Latin, base):
+			// Diacritics of Latin letters; the voicing marks of kana such as "が" stay
+		case unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		case foldedLetters[r] != "":
+			b.WriteString(foldedLetters[r])
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(norm.NFC.String(b.String())), " ")
+}
+
+// SameTitle reports whether two titles are the same once normalized, ignoring spaces so
+// that "Re:Zero" matches "ReZero"
+func SameTitle(a, b string) bool {
+	return titleKey(a) == titleKey(b)
+}
+
+// TitleContains reports whether the normalized query appears in the normalized title
+func TitleContains(title, query string) bool {
+	return strings.Contains(titleKey(title), titleKey(query))
+}
+
+// TitleMatches reports whether every word of the normalized query is a whole word of the
+// normalized title, so "love war" matches "Kaguya-sama: Love is War" but "war" doesn't
+// match "Warlock". A query word also matches consecutive title words written together,
+// so "rezero" matches "Re:Zero".
+func TitleMatches(title, query string) bool {
+	words := strings.Fields(NormalizeTitle(query))
+	if len(words) == 0 {
+		return false
+	}
+
+	tokens := strings.Fields(NormalizeTitle(title))
+	for _, word := range words {
+		if !hasToken(tokens, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasToken reports whether word is one of tokens or several consecutive ones joined
+func hasToken(tokens []string, word string) bool {
+	for i := range tokens {
+		joined := ""
+		for _, token := range tokens[i:] {
+			joined += token
+			if joined == word {
+				return true
+			}
+			if len(joined) >= len(word) || !strings.HasPrefix(word, joined) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// MatchTitle returns the title of the manga a query matches, the main title before the
+// alternative ones
+func (m Manga) MatchTitle(query string) (string, bool) {
+	if TitleMatches(m.Title, query) {
+		return m.Title, true
+	}
+	for _, alt := range m.AlternativeTitles {
+		if TitleMatches(alt, query) {
+			return alt, true
+		}
+	}
+	return "", false
+}
+
+func titleKey(title string) string {
+	return strings.ReplaceAll(NormalizeTitle(title), " ", "")
+}
+
+var (
+	// bracketed are the groups file names put in brackets, such as scanlators and sources
+	bracketed = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)|\{[^}]*\}`)
+	// chapterMarker finds the chapter number of a file name, "Ch. 12", "Chapter_12.5", "c012"
+	chapterMarker = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:chapter|chap|ch|c|episode|ep)[ ._#-]*(\d+(?:\.\d+)?)`)
+	// volumeMarker finds the volume of a file name, "Vol. 2", "v02"
+	volumeMarker = regexp.MustCompile(`(?i)(?:^|[^\pL])v(?:ol(?:ume)?)?[ ._-]*\d+`)
+	// trailingNumber is the number ending a file name without a chapter marker, "Title 012"
+	trailingNumber = regexp.MustCompile(`(\d+(?:\.\d+)?)[^\d]*$`)
+)
+
+// TitleFromFilename infers the normalized series title and the chapter number from the
+// name of a chapter file or directory, such as "[Group] Kaguya-sama - Love is War - Ch. 012.cbz".
+// The number is 0 and ok false if the name has none.
+func TitleFromFilename(name string) (title string, number float64, ok bool) {
+	name = strings.TrimSuffix(name, filepathExt(name))
+	name = bracketed.ReplaceAllString(name, " ")
+	// Volumes are blanked out so their numbers aren't taken for the chapter's
+	name = volumeMarker.ReplaceAllStringFunc(name, func(volume string) string {
+		return strings.Repeat(" ", len(volume))
+	})
+
+	match := chapterMarker.FindStringSubmatchIndex(name)
+	if match == nil {
+		match = trailingNumber.FindStringSubmatchIndex(name)
+	}
+	if match == nil {
+		return NormalizeTitle(name), 0, false
+	}
+	number, err := strconv.ParseFloat(name[match[2]:match[3]], 64)
+	if err != nil {
+		return NormalizeTitle(name), 0, false
+	}
+	return NormalizeTitle(name[:match[0]]), number, true
+}
+
+// filepathExt returns the extension of a file name, leaving out dots within numbers such
+// as "Chapter 10.5", which aren't extensions
+func filepathExt(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 || i == len(name)-1 {
+		return ""
+	}
+	ext := name[i:]
+	for _, r := range ext[1:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ""
+		}
+	}
+	if unicode.IsDigit(rune(ext[1])) {
+		return ""
+	}
+	return ext
+}
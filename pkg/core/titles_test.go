@@ -0,0 +1,98 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Kaguya-sama: Love is War", "kaguya sama love is war"},
+		{"Ｋａｇｕｙａ－ｓａｍａ", "kaguya sama"},
+		{"Pokémon Adventures", "pokemon adventures"},
+		{"Shōnen", "shonen"},
+		{"Kimi ha Boku", "kimi ha boku"},
+		{"Łódź ﬁnal Straße", "lodz final strasse"},
+		{"かぐや様は告らせたい", "かぐや様は告らせたい"},
+		{"ｶﾞｸｴﾝ", "ガクエン"},
+		{"The Book of Your Soul", "the book of your soul"},
+		{"JoJo's Bizarre Adventure", "jojos bizarre adventure"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.title); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTitleMatches(t *testing.T) {
+	tests := []struct {
+		title, query string
+		want         bool
+	}{
+		{"Kaguya-sama: Love is War", "Kaguya sama Love Is War", true},
+		{"Kaguya-sama: Love is War", "love war", true},
+		{"Re:Zero", "rezero", true},
+		{"Warlock of the Magus World", "war", false},
+		{"Kaguya-sama: Love is War", "kaguya hate", false},
+		{"Bokura no", "book", false},
+		{"Kaguya-sama: Love is War", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := TitleMatches(tt.title, tt.query); got != tt.want {
+			t.Errorf("TitleMatches(%q, %q) = %v, want %v", tt.title, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSameTitle(t *testing.T) {
+	if !SameTitle("Kaguya-sama: Love is War", "kaguya sama love is war") {
+		t.Error("SameTitle() = false for spellings of the same title")
+	}
+	if SameTitle("The Book", "The Bok") {
+		t.Error("SameTitle() = true for different titles")
+	}
+}
+
+func TestTitleFromFilename(t *testing.T) {
+	tests := []struct {
+		name   string
+		title  string
+		number float64
+		ok     bool
+	}{
+		{"[Group] Kaguya-sama - Love is War - Ch. 012.cbz", "kaguya sama love is war", 12, true},
+		{"Kaguya-sama Vol. 2 Chapter 10.5.cbz", "kaguya sama", 10.5, true},
+		{"Chapter_12", "", 12, true},
+		{"Vol_2_Chapter_1.cbz", "", 1, true},
+		{"Kaguya-sama c045 (Digital).cbz", "kaguya sama", 45, true},
+		{"Kaguya-sama 007.zip", "kaguya sama", 7, true},
+		{"Chainsaw Man v03.cbz", "chainsaw man", 0, false},
+		{"Oneshot.cbz", "oneshot", 0, false},
+	}
+
+	for _, tt := range tests {
+		title, number, ok := TitleFromFilename(tt.name)
+		if title != tt.title || number != tt.number || ok != tt.ok {
+			t.Errorf("TitleFromFilename(%q) = %q, %v, %v; want %q, %v, %v", tt.name, title, number, ok, tt.title, tt.number, tt.ok)
+		}
+	}
+}
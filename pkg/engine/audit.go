@@ -0,0 +1,108 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// ChapterAudit is the audit file of one chapter download: every HTTP request made for
+// it, for debugging incomplete chapters and accounting for bandwidth
+type ChapterAudit struct {
+	Provider  string               `json:"provider"`
+	ChapterID string               `json:"chapter_id"`
+	Path      string               `json:"path,omitempty"`
+	Status    library.Status       `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	Started   time.Time            `json:"started"`
+	Finished  time.Time            `json:"finished"`
+	Summary   network.AuditSummary `json:"summary"`
+	Requests  []network.AuditEntry `json:"requests"`
+}
+
+type auditKey struct{}
+
+// WithAudit asks for the HTTP requests of every chapter downloaded with ctx to be saved
+// to an audit file in AuditDir
+func WithAudit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditKey{}, true)
+}
+
+// auditing reports whether the requests of downloads made with ctx are audited, either
+// asked for by WithAudit or for all downloads by the config
+func (e *Engine) auditing(ctx context.Context) bool {
+	if e.auditDir == "" {
+		return false
+	}
+	if requested, _ := ctx.Value(auditKey{}).(bool); requested {
+		return true
+	}
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.auditAll
+}
+
+// AuditDir returns where audit files are written, ~/.luminary/audit; empty without a
+// home directory
+func (e *Engine) AuditDir() string {
+	return e.auditDir
+}
+
+// unsafeFileChars are replaced in chapter IDs used as file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// writeAudit saves the audit of a chapter download to
+// <audit dir>/<provider>/<chapter ID>-<time>.json and returns its path
+func (e *Engine) writeAudit(record library.Record, started time.Time, audit *network.Audit) (string, error) {
+	requests := audit.Entries()
+	if requests == nil {
+		requests = []network.AuditEntry{}
+	}
+	data, err := json.MarshalIndent(ChapterAudit{
+		Provider:  record.Provider,
+		ChapterID: record.ChapterID,
+		Path:      record.Path,
+		Status:    record.Status,
+		Error:     record.Error,
+		Started:   started,
+		Finished:  time.Now(),
+		Summary:   audit.Summary(),
+		Requests:  requests,
+	}, "", "  ")
+	if err != nil {
+		return "", errors.Track(err).Error()
+	}
+
+	dir := filepath.Join(e.auditDir, unsafeFileChars.ReplaceAllString(record.Provider, "_"))
+	name := unsafeFileChars.ReplaceAllString(record.ChapterID, "_") + "-" + started.Format("20060102T150405") + ".json"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Track(err).WithContext("directory", dir).AsFileSystem().Error()
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return path, nil
+}
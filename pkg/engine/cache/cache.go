@@ -0,0 +1,190 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package cache provides the engine's in-memory cache: an LRU bounded by a number of
+// entries and an approximate size in bytes, with expiring entries and hit statistics
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Default budgets of a cache
+const (
+	DefaultMaxEntries = 2000
+	DefaultMaxBytes   = 64 << 20
+)
+
+// Cache is an LRU cache safe for concurrent use. Entries beyond either budget are evicted,
+// least recently used first.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	order      *list.List // Front is the most recently used
+	items      map[string]*list.Element
+	bytes      int64
+
+	hits, misses, evictions uint64
+}
+
+type entry struct {
+	key     string
+	value   any
+	size    int64
+	expires time.Time // Zero for entries that don't expire
+}
+
+// Stats describes the contents and effectiveness of a cache
+type Stats struct {
+	Entries    int     `json:"entries"`
+	Bytes      int64   `json:"bytes"`
+	MaxEntries int     `json:"max_entries"`
+	MaxBytes   int64   `json:"max_bytes"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	Evictions  uint64  `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"` // Share of lookups answered from the cache, 0 to 1
+}
+
+// New creates a cache holding at most maxEntries entries and maxBytes bytes; zero or
+// less leaves that budget unlimited
+func New(maxEntries int, maxBytes int64) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored under key, if it is there and hasn't expired
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if ok {
+		if e := elem.Value.(*entry); e.expires.IsZero() || time.Now().Before(e.expires) {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return e.value, true
+		}
+		c.remove(elem)
+	}
+	c.misses++
+	return nil, false
+}
+
+// Set stores a value of the given approximate size under key for ttl, or without expiry
+// if ttl is zero. Values larger than the whole byte budget aren't stored.
+func (c *Cache) Set(key string, value any, size int64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	e := &entry{key: key, value: value, size: size}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	c.items[key] = c.order.PushFront(e)
+	c.bytes += size
+	c.evict()
+}
+
+// Delete removes the value stored under key
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Clear removes every entry; statistics are kept
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// SetLimits changes the budgets, evicting entries beyond the new ones
+func (c *Cache) SetLimits(maxEntries int, maxBytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxEntries, c.maxBytes = maxEntries, maxBytes
+	c.evict()
+}
+
+// Stats returns the current statistics of the cache
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Entries:    len(c.items),
+		Bytes:      c.bytes,
+		MaxEntries: c.maxEntries,
+		MaxBytes:   c.maxBytes,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// evict drops least recently used entries until both budgets are met
+func (c *Cache) evict() {
+	for c.order.Len() > 0 &&
+		((c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// remove drops an entry; the caller holds the lock
+func (c *Cache) remove(elem *list.Element) {
+	e := c.order.Remove(elem).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
+
+// SizeOf estimates the memory a value takes by the length of its JSON encoding, which
+// is close enough for the text-heavy values the engine caches
+func SizeOf(value any) int64 {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
@@ -0,0 +1,122 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshots keeps warmed lookups on disk, so separate runs of the CLI can answer
+// interactive calls from them. Each lookup is one JSON file named after its key.
+type Snapshots struct {
+	dir string
+}
+
+// NewSnapshots returns the snapshots kept in dir
+func NewSnapshots(dir string) *Snapshots {
+	return &Snapshots{dir: dir}
+}
+
+// Dir returns the directory the snapshots are kept in
+func (s *Snapshots) Dir() string {
+	return s.dir
+}
+
+// Save stores value as the snapshot of key
+func (s *Snapshots) Save(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	path := s.path(key)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return err
+	}
+	return nil
+}
+
+// Load decodes the snapshot of key into value if it is younger than maxAge, returning
+// when it was taken
+func (s *Snapshots) Load(key string, value any, maxAge time.Duration) (time.Time, bool) {
+	path := s.path(key)
+	info, err := os.Stat(path)
+	if err != nil || (maxAge > 0 && time.Since(info.ModTime()) > maxAge) {
+		return time.Time{}, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, value) != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// Clear removes every snapshot
+func (s *Snapshots) Clear() error {
+	return os.RemoveAll(s.dir)
+}
+
+// path returns the file of a key; keys hold IDs that aren't safe as file names
+func (s *Snapshots) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+type modeKey struct{}
+
+type mode int
+
+const (
+	modeStale mode = iota + 1
+	modeRefresh
+)
+
+// AllowStale returns a context whose lookups may be answered from warmed snapshots when
+// the memory cache misses, for interactive calls that favour speed over freshness
+func AllowStale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, modeKey{}, modeStale)
+}
+
+// Refresh returns a context whose lookups skip the cache and store what they fetch,
+// in memory and as snapshots, for warming the cache
+func Refresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, modeKey{}, modeRefresh)
+}
+
+// StaleAllowed reports whether lookups of ctx may use snapshots
+func StaleAllowed(ctx context.Context) bool {
+	return ctx.Value(modeKey{}) == modeStale
+}
+
+// Refreshing reports whether lookups of ctx are warming the cache
+func Refreshing(ctx context.Context) bool {
+	return ctx.Value(modeKey{}) == modeRefresh
+}
@@ -0,0 +1,239 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/numbering"
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChapterFilter narrows down a manga's chapter list
+type ChapterFilter struct {
+	Languages []string // Language codes to keep; empty keeps all
+	From      float64  // Lowest chapter number to keep (inclusive); 0 means no lower bound
+	To        float64  // Highest chapter number to keep (inclusive); 0 means no upper bound
+	Group     string   // Case-insensitive substring of the scanlation group
+	// Prefer, when set, keeps a single copy of chapters released more than once
+	Prefer *ChapterPreference
+	// Latest keeps only the newest chapters, this many; copies of a chapter in other
+	// languages or by other groups count once. 0 keeps all.
+	Latest int
+}
+
+// ChapterPreference picks one copy of a chapter released by several groups or in several
+// languages: the best ranked group wins, then the best ranked language, then the copy
+// with the most pages, then the one listed first by the provider
+type ChapterPreference struct {
+	Groups    []string // Case-insensitive substrings of scanlation groups, best first
+	Languages []string // Language codes, best first
+}
+
+// Chapters returns a manga's chapters from the given provider, filtered and in reading order
+func (e *Engine) Chapters(ctx context.Context, provider Provider, mangaID string, filter ChapterFilter) ([]core.ChapterInfo, error) {
+	info, err := e.GetManga(ctx, provider, mangaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chapters are numbered among all others of the manga, before any are left out
+	chapters := info.Chapters
+	if n, ok := numbering.FromContext(ctx); ok {
+		chapters = n.Normalize(chapters)
+	}
+	chapters = FilterChapters(chapters, filter)
+	if filter.Prefer != nil {
+		chapters = ResolveDuplicates(chapters, *filter.Prefer)
+	}
+	SortChapters(chapters)
+	if filter.Latest > 0 {
+		chapters = LatestChapters(chapters, filter.Latest)
+	}
+
+	e.Log(ctx).Debug("Listing %d of %d chapters for %s:%s", len(chapters), len(info.Chapters), provider.ID(), mangaID)
+	return chapters, nil
+}
+
+// FilterChapters returns the chapters matching every criterion of the filter
+func FilterChapters(chapters []core.ChapterInfo, filter ChapterFilter) []core.ChapterInfo {
+	languages := make(map[string]bool, len(filter.Languages))
+	for _, lang := range filter.Languages {
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+			languages[lang] = true
+		}
+	}
+	group := strings.ToLower(filter.Group)
+
+	filtered := make([]core.ChapterInfo, 0, len(chapters))
+	for _, ch := range chapters {
+		if len(languages) > 0 && ch.Language != "" && !languages[strings.ToLower(ch.Language)] {
+			continue
+		}
+		if filter.From > 0 && ch.Number < filter.From {
+			continue
+		}
+		if filter.To > 0 && ch.Number > filter.To {
+			continue
+		}
+		if group != "" && !strings.Contains(strings.ToLower(ch.Group), group) {
+			continue
+		}
+		filtered = append(filtered, ch)
+	}
+
+	return filtered
+}
+
+// ResolveDuplicates keeps one copy of every chapter, chosen by the preference, in the
+// order the chapters were given. Chapters without a number or label are never merged.
+func ResolveDuplicates(chapters []core.ChapterInfo, pref ChapterPreference) []core.ChapterInfo {
+	best := make(map[string]int, len(chapters)) // Chapter key to index in resolved
+	resolved := make([]core.ChapterInfo, 0, len(chapters))
+	for _, ch := range chapters {
+		key := duplicateKey(ch)
+		if i, ok := best[key]; ok {
+			if pref.better(ch, resolved[i]) {
+				resolved[i] = ch
+			}
+			continue
+		}
+		best[key] = len(resolved)
+		resolved = append(resolved, ch)
+	}
+	return resolved
+}
+
+// better reports whether chapter a is preferred over b, a copy of the same chapter
+func (p ChapterPreference) better(a, b core.ChapterInfo) bool {
+	if ra, rb := rank(p.Groups, a.Group, strings.Contains), rank(p.Groups, b.Group, strings.Contains); ra != rb {
+		return ra < rb
+	}
+	if ra, rb := rank(p.Languages, a.Language, strings.EqualFold), rank(p.Languages, b.Language, strings.EqualFold); ra != rb {
+		return ra < rb
+	}
+	return a.PageCount > b.PageCount
+}
+
+// rank returns the position of the first preference matching value, or len(prefs)
+func rank(prefs []string, value string, match func(value, pref string) bool) int {
+	value = strings.ToLower(value)
+	for i, pref := range prefs {
+		if pref = strings.ToLower(strings.TrimSpace(pref)); pref != "" && value != "" && match(value, pref) {
+			return i
+		}
+	}
+	return len(prefs)
+}
+
+// duplicateKey identifies copies of the same chapter. Sites that start over in every
+// volume reuse numbers, so the volume is part of it.
+func duplicateKey(ch core.ChapterInfo) string {
+	var key string
+	switch {
+	case ch.Number > 0:
+		key = "number:" + strconv.FormatFloat(ch.Number, 'g', -1, 64)
+	case ch.Label != "":
+		key = "label:" + core.NormalizeTitle(ch.Label)
+	default:
+		return "id:" + ch.ID
+	}
+	if volume := volumeKey(ch.Volume); volume != "" {
+		return "vol:" + volume + "/" + key
+	}
+	return key
+}
+
+// volumeKey normalizes a volume name, so "02" and "2" match
+func volumeKey(volume string) string {
+	volume = strings.ToLower(strings.TrimSpace(volume))
+	if number, err := strconv.ParseFloat(volume, 64); err == nil {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return volume
+}
+
+// LatestChapters returns the copies of the n newest chapters, in the order given. Chapters
+// are ranked by number, then release date, then position; copies of one chapter share
+// the best rank among them.
+func LatestChapters(chapters []core.ChapterInfo, n int) []core.ChapterInfo {
+	type rank struct {
+		number   float64
+		date     time.Time
+		position int
+	}
+	ranks := make(map[string]rank)
+	for i, ch := range chapters {
+		key := duplicateKey(ch)
+		r := ranks[key]
+		r.number = max(r.number, ch.Number)
+		if ch.Date != nil && ch.Date.After(r.date) {
+			r.date = *ch.Date
+		}
+		r.position = i
+		ranks[key] = r
+	}
+
+	keys := slices.Collect(maps.Keys(ranks))
+	slices.SortFunc(keys, func(a, b string) int {
+		ra, rb := ranks[a], ranks[b]
+		if c := cmp.Compare(rb.number, ra.number); c != 0 {
+			return c
+		}
+		if c := rb.date.Compare(ra.date); c != 0 {
+			return c
+		}
+		return cmp.Compare(rb.position, ra.position)
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	newest := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		newest[key] = true
+	}
+	latest := make([]core.ChapterInfo, 0, len(chapters))
+	for _, ch := range chapters {
+		if newest[duplicateKey(ch)] {
+			latest = append(latest, ch)
+		}
+	}
+	return latest
+}
+
+// SortChapters sorts chapters in reading order, preferring the provider's sequence
+// so that unnumbered chapters (extras, oneshots) keep their place
+func SortChapters(chapters []core.ChapterInfo) {
+	slices.SortStableFunc(chapters, func(a, b core.ChapterInfo) int {
+		if a.Sequence > 0 && b.Sequence > 0 && a.Sequence != b.Sequence {
+			return a.Sequence - b.Sequence
+		}
+		if a.Number < b.Number {
+			return -1
+		}
+		if a.Number > b.Number {
+			return 1
+		}
+		return strings.Compare(a.Language, b.Language)
+	})
+}
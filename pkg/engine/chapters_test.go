@@ -0,0 +1,80 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"testing"
+)
+
+func TestResolveDuplicatesKeepsVolumesApart(t *testing.T) {
+	chapters := []core.ChapterInfo{
+		{ID: "a", Number: 1, Volume: "1", Language: "en"},
+		{ID: "b", Number: 1, Volume: "01", Language: "es"},
+		{ID: "c", Number: 1, Volume: "2", Language: "en"},
+		{ID: "d", Number: 2, Language: "en"},
+		{ID: "e", Number: 2, Language: "es"},
+	}
+	resolved := engine.ResolveDuplicates(chapters, engine.ChapterPreference{Languages: []string{"es"}})
+
+	var ids []string
+	for _, ch := range resolved {
+		ids = append(ids, ch.ID)
+	}
+	if len(ids) != 3 || ids[0] != "b" || ids[1] != "c" || ids[2] != "e" {
+		t.Errorf("resolved %v, want [b c e]", ids)
+	}
+}
+
+func TestAlignChaptersByVolume(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right []core.ChapterInfo
+		want        int // Rows
+		common      int
+	}{
+		{
+			name:   "numbers restart per volume",
+			left:   []core.ChapterInfo{{ID: "l1", Number: 1, Volume: "1"}, {ID: "l2", Number: 1, Volume: "2"}},
+			right:  []core.ChapterInfo{{ID: "r1", Number: 1, Volume: "1"}},
+			want:   2,
+			common: 1,
+		},
+		{
+			name:   "only one side has volumes",
+			left:   []core.ChapterInfo{{ID: "l1", Number: 1, Volume: "1"}, {ID: "l2", Number: 2, Volume: "1"}},
+			right:  []core.ChapterInfo{{ID: "r1", Number: 1}, {ID: "r2", Number: 2}},
+			want:   2,
+			common: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned := engine.AlignChapters(tt.left, tt.right)
+			common := 0
+			for _, row := range aligned {
+				if row.Common() {
+					common++
+				}
+			}
+			if len(aligned) != tt.want || common != tt.common {
+				t.Errorf("got %d rows, %d common; want %d rows, %d common", len(aligned), common, tt.want, tt.common)
+			}
+		})
+	}
+}
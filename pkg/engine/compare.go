@@ -0,0 +1,197 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ChapterComparison lines up the chapter lists of one series on two providers
+type ChapterComparison struct {
+	Left  ComparedSeries    `json:"left"`
+	Right ComparedSeries    `json:"right"`
+	Rows  []ComparedChapter `json:"chapters"` // Every chapter of either side, in reading order
+}
+
+// ComparedSeries summarizes one side of a comparison
+type ComparedSeries struct {
+	Provider string `json:"provider"`
+	MangaID  string `json:"manga_id"`
+	Title    string `json:"title"`
+	Chapters int    `json:"chapters"` // Distinct chapters listed
+	External int    `json:"external"` // Chapters that can only be read on an external site
+	Missing  int    `json:"missing"`  // Chapters only the other side lists
+}
+
+// ComparedChapter is a chapter and its copy on each side; a side without the chapter is nil
+type ComparedChapter struct {
+	Chapter string            `json:"chapter"` // Display number or label
+	Volume  string            `json:"volume,omitempty"`
+	Left    *core.ChapterInfo `json:"left,omitempty"`
+	Right   *core.ChapterInfo `json:"right,omitempty"`
+}
+
+// Common reports whether both sides list the chapter
+func (c ComparedChapter) Common() bool {
+	return c.Left != nil && c.Right != nil
+}
+
+// CompareChapters fetches the chapters of a series on two providers, narrowed down by the
+// filter, and lines them up by chapter number, label or volume
+func (e *Engine) CompareChapters(ctx context.Context, left Provider, leftID string, right Provider, rightID string, filter ChapterFilter) (*ChapterComparison, error) {
+	sides := [2]struct {
+		provider Provider
+		mangaID  string
+		info     *core.MangaInfo
+	}{{provider: left, mangaID: leftID}, {provider: right, mangaID: rightID}}
+
+	// Both lists are fetched at once; they come from different sites
+	var wg sync.WaitGroup
+	errs := make([]error, len(sides))
+	for i := range sides {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sides[i].info, errs[i] = e.GetManga(ctx, sides[i].provider, sides[i].mangaID)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	comparison := &ChapterComparison{
+		Rows: AlignChapters(FilterChapters(sides[0].info.Chapters, filter), FilterChapters(sides[1].info.Chapters, filter)),
+	}
+	comparison.Left = ComparedSeries{Provider: left.ID(), MangaID: leftID, Title: sides[0].info.Title}
+	comparison.Right = ComparedSeries{Provider: right.ID(), MangaID: rightID, Title: sides[1].info.Title}
+	for _, row := range comparison.Rows {
+		comparison.Left.count(row.Left)
+		comparison.Right.count(row.Right)
+	}
+
+	e.Log(ctx).Debug("Compared %d chapters of %s:%s with %d of %s:%s", comparison.Left.Chapters, left.ID(), leftID,
+		comparison.Right.Chapters, right.ID(), rightID)
+	return comparison, nil
+}
+
+// count adds a side's copy of a chapter to its summary
+func (s *ComparedSeries) count(chapter *core.ChapterInfo) {
+	switch {
+	case chapter == nil:
+		s.Missing++
+	case chapter.ExternalURL != "":
+		s.Chapters++
+		s.External++
+	default:
+		s.Chapters++
+	}
+}
+
+// AlignChapters pairs the chapters of two lists of the same series. Chapters match by
+// number, then by label, and chapters with neither by volume. Numbers and labels match
+// within their volume when both lists have volumes. Of several copies of a
+// chapter on one side (other groups or languages) the first is kept, preferring one that
+// can be downloaded. Unnumbered chapters keep their place after the chapter before them.
+func AlignChapters(left, right []core.ChapterInfo) []ComparedChapter {
+	type row struct {
+		ComparedChapter
+		order float64 // Chapter number, or that of the chapter before an unnumbered one
+		index int
+	}
+	rows := make(map[string]*row)
+	var keys []string
+	hasVolume := func(ch core.ChapterInfo) bool { return ch.Volume != "" }
+	volumes := slices.ContainsFunc(left, hasVolume) && slices.ContainsFunc(right, hasVolume)
+
+	add := func(chapters []core.ChapterInfo, isLeft bool) {
+		chapters = slices.Clone(chapters)
+		SortChapters(chapters)
+
+		var order float64
+		for i := range chapters {
+			chapter := &chapters[i]
+			if chapter.Number > 0 {
+				order = chapter.Number
+			}
+
+			key := alignKey(*chapter, volumes)
+			r, ok := rows[key]
+			if !ok {
+				r = &row{
+					ComparedChapter: ComparedChapter{Chapter: chapter.DisplayNumber(), Volume: chapter.Volume},
+					order:           order,
+					index:           len(keys),
+				}
+				if strings.HasPrefix(key, "volume:") {
+					r.Chapter = "Vol." + chapter.Volume
+				}
+				rows[key] = r
+				keys = append(keys, key)
+			}
+			if r.Volume == "" {
+				r.Volume = chapter.Volume
+			}
+
+			side := &r.Right
+			if isLeft {
+				side = &r.Left
+			}
+			if *side == nil || ((*side).ExternalURL != "" && chapter.ExternalURL == "") {
+				*side = chapter
+			}
+		}
+	}
+	add(left, true)
+	add(right, false)
+
+	aligned := make([]*row, 0, len(keys))
+	for _, key := range keys {
+		aligned = append(aligned, rows[key])
+	}
+	slices.SortStableFunc(aligned, func(a, b *row) int {
+		if c := cmp.Compare(a.order, b.order); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.index, b.index)
+	})
+
+	result := make([]ComparedChapter, len(aligned))
+	for i, r := range aligned {
+		result[i] = r.ComparedChapter
+	}
+	return result
+}
+
+// alignKey identifies the same chapter on different providers. Volumes are left out
+// unless both providers list them, as chapters would never match otherwise.
+func alignKey(ch core.ChapterInfo, volumes bool) string {
+	if ch.Number <= 0 && ch.Label == "" && ch.Volume != "" {
+		return "volume:" + volumeKey(ch.Volume)
+	}
+	if !volumes {
+		ch.Volume = ""
+	}
+	return duplicateKey(ch)
+}
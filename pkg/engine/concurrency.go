@@ -0,0 +1,178 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"sync"
+)
+
+// DefaultProviderConcurrency is how many calls run at once against a provider without a
+// concurrency setting
+const DefaultProviderConcurrency = 4
+
+type heldSlotKey struct{}
+
+// heldSlot is a provider slot held by a call, linked to those of the calls around it
+type heldSlot struct {
+	providerID string
+	outer      *heldSlot
+}
+
+// holds reports whether ctx comes from a call holding a slot of the provider
+func holds(ctx context.Context, providerID string) bool {
+	for slot, _ := ctx.Value(heldSlotKey{}).(*heldSlot); slot != nil; slot = slot.outer {
+		if slot.providerID == providerID {
+			return true
+		}
+	}
+	return false
+}
+
+// acquireProvider waits for one of the provider's slots, so a slow or rate-limited
+// provider queues its own calls instead of holding up those of every provider. Calls made
+// within one holding a slot of the same provider don't wait again; the returned context
+// marks the slot as held. release gives the slot back.
+func (e *Engine) acquireProvider(ctx context.Context, providerID string) (_ context.Context, release func(), err error) {
+	ctx = e.providerContext(ctx, providerID)
+	if holds(ctx, providerID) {
+		return ctx, func() {}, nil
+	}
+	if provider := e.GetProviderOrNil(providerID); provider != nil {
+		if err := e.ensureInitialized(ctx, provider); err != nil {
+			return ctx, nil, err
+		}
+	}
+
+	slots := e.providerSlots(providerID)
+	if !slots.tryAcquire() {
+		e.Log(ctx).Debug("Waiting for a free slot of provider %s (%d busy)", providerID, slots.size())
+		if err := slots.acquire(ctx); err != nil {
+			return ctx, nil, errors.Track(err).WithContext("provider_id", providerID).Error()
+		}
+	}
+
+	outer, _ := ctx.Value(heldSlotKey{}).(*heldSlot)
+	ctx = context.WithValue(ctx, heldSlotKey{}, &heldSlot{providerID: providerID, outer: outer})
+	return ctx, slots.release, nil
+}
+
+// providerSlots returns the semaphore of a provider, sized by its concurrency setting
+func (e *Engine) providerSlots(providerID string) *semaphore {
+	e.slotsMutex.Lock()
+	defer e.slotsMutex.Unlock()
+
+	if slots, ok := e.slots[providerID]; ok {
+		return slots
+	}
+	if e.slots == nil {
+		e.slots = make(map[string]*semaphore)
+	}
+	e.slots[providerID] = newSemaphore(e.providerConcurrency(providerID))
+	return e.slots[providerID]
+}
+
+// resizeSlots applies the concurrency settings to the semaphores in use. Calls holding a
+// slot keep it; when there are fewer slots now, new calls wait until enough are given back.
+func (e *Engine) resizeSlots() {
+	e.slotsMutex.Lock()
+	defer e.slotsMutex.Unlock()
+	for providerID, slots := range e.slots {
+		slots.resize(e.providerConcurrency(providerID))
+	}
+}
+
+// providerConcurrency returns how many calls may run against a provider at once
+func (e *Engine) providerConcurrency(providerID string) int {
+	if n := e.ProviderSettings(providerID).Concurrency; n > 0 {
+		return n
+	}
+	return DefaultProviderConcurrency
+}
+
+// semaphore hands out a number of slots that can be changed while they are held
+type semaphore struct {
+	mu    sync.Mutex
+	limit int
+	busy  int
+	freed chan struct{} // Closed when a slot may have become free
+}
+
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{limit: limit, freed: make(chan struct{})}
+}
+
+// tryAcquire takes a slot if one is free
+func (s *semaphore) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy >= s.limit {
+		return false
+	}
+	s.busy++
+	return true
+}
+
+// acquire waits for a free slot until ctx ends
+func (s *semaphore) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.busy < s.limit {
+			s.busy++
+			s.mu.Unlock()
+			return nil
+		}
+		freed := s.freed
+		s.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release gives a slot back
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.busy--
+	s.wake()
+}
+
+// resize changes the number of slots
+func (s *semaphore) resize(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.wake()
+}
+
+// size returns the number of slots
+func (s *semaphore) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+// wake lets the waiting calls check for a free slot again; s.mu must be held
+func (s *semaphore) wake() {
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
@@ -0,0 +1,153 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/provider/base"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingProvider registers a provider whose lookups wait for release, counting the
+// lookups running at once in inFlight and the most seen in peak
+type blockingProvider struct {
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func newBlockingProvider(t *testing.T, e *engine.Engine, id string) (*blockingProvider, engine.Provider) {
+	t.Helper()
+	bp := &blockingProvider{release: make(chan struct{})}
+	provider := base.New(e, base.Config{ID: id, Name: id, SiteURL: "https://example.com", Type: base.TypeWeb}).
+		WithGetManga(func(ctx context.Context, mangaID string) (*core.MangaInfo, error) {
+			n := bp.inFlight.Add(1)
+			defer bp.inFlight.Add(-1)
+			for peak := bp.peak.Load(); n > peak && !bp.peak.CompareAndSwap(peak, n); peak = bp.peak.Load() {
+			}
+			select {
+			case <-bp.release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return &core.MangaInfo{Manga: core.Manga{ID: mangaID, Title: mangaID}}, nil
+		}).
+		WithSearch(func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+			return []core.Manga{{ID: "found", Title: "Found"}}, nil
+		}).
+		Build()
+	if err := e.RegisterProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	return bp, provider
+}
+
+// waitFor polls cond until it holds or a second passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProviderConcurrencyResizes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	bp, provider := newBlockingProvider(t, e, "slw")
+	e.SetProviderSettings(map[string]engine.ProviderSettings{"slw": {Concurrency: 2}})
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.GetManga(context.Background(), provider, fmt.Sprint(i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	waitFor(t, "two lookups", func() bool { return bp.inFlight.Load() == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if peak := bp.peak.Load(); peak != 2 {
+		t.Fatalf("%d lookups ran at once, want 2", peak)
+	}
+
+	// More slots let the waiting lookups in while the others still hold theirs
+	e.SetProviderSettings(map[string]engine.ProviderSettings{"slw": {Concurrency: 4}})
+	waitFor(t, "four lookups", func() bool { return bp.inFlight.Load() == 4 })
+
+	close(bp.release)
+	wg.Wait()
+	if peak := bp.peak.Load(); peak != 4 {
+		t.Errorf("%d lookups ran at once, want 4", peak)
+	}
+}
+
+func TestProviderConcurrencyIsolatesProviders(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	slow, slowProvider := newBlockingProvider(t, e, "slw")
+	_, fastProvider := newBlockingProvider(t, e, "fst")
+	e.SetProviderSettings(map[string]engine.ProviderSettings{"slw": {Concurrency: 1}})
+	defer close(slow.release)
+
+	// The slow provider's only slot is taken for the whole test
+	go func() { _, _ = e.GetManga(context.Background(), slowProvider, "busy") }()
+	waitFor(t, "the slow lookup", func() bool { return slow.inFlight.Load() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	results, err := e.Search(ctx, fastProvider, "query", core.SearchOptions{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("search of the fast provider = %v, %v", results, err)
+	}
+}
+
+func TestSearchBudgetStartsWithSlot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	slow, slowProvider := newBlockingProvider(t, e, "slw")
+	_, fastProvider := newBlockingProvider(t, e, "fst")
+	e.SetProviderSettings(map[string]engine.ProviderSettings{"slw": {Concurrency: 1}})
+
+	timeouts := e.Timeouts()
+	timeouts.Search = engine.TimeoutPolicy{Base: engine.Duration(100 * time.Millisecond)}
+	e.SetTimeouts(timeouts)
+
+	// The slow provider's slot is held for longer than the search budget
+	go func() { _, _ = e.GetManga(context.Background(), slowProvider, "busy") }()
+	waitFor(t, "the slow lookup", func() bool { return slow.inFlight.Load() == 1 })
+	time.AfterFunc(250*time.Millisecond, func() { close(slow.release) })
+
+	for r := range e.SearchAll(context.Background(), []engine.Provider{slowProvider, fastProvider}, "query", core.SearchOptions{}) {
+		if r.Err != nil || len(r.Results) != 1 {
+			t.Errorf("search of %s = %v, %v", r.Provider.ID(), r.Results, r.Err)
+		}
+	}
+}
@@ -0,0 +1,498 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/crash"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the settings read from ~/.luminary/config.json; settings the file leaves
+// out keep their defaults
+type Config struct {
+	Timeouts Timeouts    `json:"timeouts"`
+	Cache    CacheConfig `json:"cache"`
+
+	Providers map[string]ProviderSettings `json:"providers,omitempty"` // Keyed by provider ID
+
+	// PreferredLanguages orders the languages titles and descriptions are shown in when a
+	// site offers several, e.g. ["ja-ro", "en", "ja"]; English by default
+	PreferredLanguages []string `json:"preferred_languages,omitempty"`
+
+	CrashReports CrashReportConfig `json:"crash_reports"`
+
+	// AuditDownloads saves the HTTP requests of every chapter download to an audit file,
+	// as the --audit flag does for one run
+	AuditDownloads bool `json:"audit_downloads,omitempty"`
+	// FailoverDownloads downloads chapters that fail from a linked series on another
+	// provider, as the --failover flag does for one run
+	FailoverDownloads bool `json:"failover_downloads,omitempty"`
+
+	// DownloadQuota is the largest size the library may grow to, e.g. "50GB"; downloads
+	// stop once it is reached. Zero leaves it unlimited.
+	DownloadQuota ByteSize `json:"download_quota,omitempty"`
+	// LibraryRoot is the directory the library is downloaded into, which the quota
+	// covers as a whole. Without it, each download directory has its own quota.
+	LibraryRoot string `json:"library_root,omitempty"`
+	// ChapterCollision names chapters sharing a number with one already downloaded:
+	// "group" (the default), "language" or "none" to overwrite it
+	ChapterCollision string `json:"chapter_collision,omitempty"`
+
+	Descriptions DescriptionConfig `json:"descriptions"`
+
+	// LazyInit initializes providers on their first use instead of all at startup
+	LazyInit bool `json:"lazy_init,omitempty"`
+}
+
+// DescriptionConfig sets how manga descriptions of every provider are cleaned up before
+// they are shown or saved
+type DescriptionConfig struct {
+	// Format is "text" (the default) or "markdown", which keeps the emphasis, links and
+	// lists of descriptions scraped as HTML
+	Format string `json:"format,omitempty"`
+	// MaxLength shortens longer descriptions at a word boundary; zero leaves them whole
+	MaxLength int `json:"max_length,omitempty"`
+	// Boilerplate lists regular expressions, matched case-insensitively, whose matches are
+	// removed from every description
+	Boilerplate []string `json:"boilerplate,omitempty"`
+}
+
+// CrashReportConfig opts in to uploading panics to a Sentry-compatible server, so the
+// maintainers learn when a provider breaks; off by default
+type CrashReportConfig struct {
+	Enabled bool   `json:"enabled"`
+	DSN     string `json:"dsn"` // Such as https://<key>@o0.ingest.sentry.io/<project>
+}
+
+// ProviderSettings are the settings of one provider
+type ProviderSettings struct {
+	// Priority orders providers: higher ones come first in merged results and win when
+	// several providers could serve the same request. Providers default to 0.
+	Priority int `json:"priority"`
+	// Concurrency is how many searches and lookups run against the provider at once; more
+	// wait for a free slot. Defaults to DefaultProviderConcurrency.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Boilerplate lists regular expressions removed from the provider's descriptions, on
+	// top of those of DescriptionConfig
+	Boilerplate []string `json:"boilerplate,omitempty"`
+}
+
+// CacheConfig sets the budgets of the in-memory cache of provider lookups; the least
+// recently used entries are evicted beyond either
+type CacheConfig struct {
+	MaxEntries int      `json:"max_entries"` // Zero or less leaves the number of entries unlimited
+	MaxBytes   int64    `json:"max_bytes"`   // Approximate; zero or less leaves the size unlimited
+	TTL        Duration `json:"ttl"`         // How long manga details are reused; zero disables caching them
+	// SnapshotAge is how old the manga details saved by 'luminary cache warm' may be to
+	// answer interactive calls such as 'luminary info'
+	SnapshotAge Duration `json:"snapshot_age"`
+}
+
+// Timeouts are the time budgets of scraping and downloads; a zero budget is unlimited
+type Timeouts struct {
+	Request Duration `json:"request"` // One HTTP request attempt, reading the body included
+	Page    Duration `json:"page"`    // One page, including retries and fallback URLs
+	Chapter Duration `json:"chapter"` // One chapter, from resolving its pages to writing the last one
+	// Initialize is how long one provider may take to initialize; zero leaves it unlimited
+	Initialize Duration `json:"initialize"`
+
+	Search TimeoutPolicy `json:"search"` // A search, across all the providers it asks
+	List   TimeoutPolicy `json:"list"`   // Browsing the manga of a tag
+}
+
+// TimeoutPolicy sizes the time budget of an operation spanning pages and providers: Base,
+// plus PerPage for every page and PerProvider for the provider, capped at Max. Providers
+// are asked side by side, each under its own budget, so the slowest one sets the time an
+// operation takes rather than their sum. A zero Base leaves the operation unlimited and a
+// zero Max leaves it uncapped.
+type TimeoutPolicy struct {
+	Base        Duration `json:"base"`
+	PerPage     Duration `json:"per_page"`
+	PerProvider Duration `json:"per_provider"`
+	Max         Duration `json:"max"`
+}
+
+// Limit returns the budget of one provider for the given number of pages; zero is unlimited
+func (p TimeoutPolicy) Limit(pages int) time.Duration {
+	if p.Base <= 0 {
+		return 0
+	}
+
+	limit := time.Duration(p.Base) +
+		time.Duration(max(pages, 1))*time.Duration(p.PerPage) +
+		time.Duration(p.PerProvider)
+	if p.Max > 0 && limit > time.Duration(p.Max) {
+		limit = time.Duration(p.Max)
+	}
+	return limit
+}
+
+// String writes the policy the way ParseTimeoutPolicy reads it
+func (p TimeoutPolicy) String() string {
+	return fmt.Sprintf("%s,%s,%s,%s", time.Duration(p.Base), time.Duration(p.PerPage),
+		time.Duration(p.PerProvider), time.Duration(p.Max))
+}
+
+// ParseTimeoutPolicy reads a policy written as base[,per-page[,per-provider[,max]]], such
+// as "30s,30s,10s,5m"; fields left out are zero, so "1m" is a flat minute
+func ParseTimeoutPolicy(text string) (TimeoutPolicy, error) {
+	var policy TimeoutPolicy
+	fields := []*Duration{&policy.Base, &policy.PerPage, &policy.PerProvider, &policy.Max}
+
+	parts := strings.Split(text, ",")
+	if len(parts) > len(fields) {
+		return policy, errors.Newf("invalid timeout policy %q", text).
+			WithMessagef("A timeout policy has at most 4 fields, base,per-page,per-provider,max; got %q", text).
+			Error()
+	}
+	for i, part := range parts {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return policy, errors.Track(err).
+				WithMessagef("Invalid duration %q in timeout policy %q", part, text).
+				Error()
+		}
+		*fields[i] = Duration(d)
+	}
+	return policy, nil
+}
+
+// DefaultConfig returns the settings used when there is no config file
+func DefaultConfig() Config {
+	return Config{
+		Timeouts: Timeouts{
+			Request: Duration(30 * time.Second),
+			Page:    Duration(2 * time.Minute),
+			Chapter: Duration(10 * time.Minute),
+
+			Initialize: Duration(30 * time.Second),
+
+			Search: TimeoutPolicy{
+				Base:        Duration(30 * time.Second),
+				PerPage:     Duration(30 * time.Second),
+				PerProvider: Duration(10 * time.Second),
+				Max:         Duration(5 * time.Minute),
+			},
+			List: TimeoutPolicy{
+				Base:        Duration(30 * time.Second),
+				PerPage:     Duration(30 * time.Second),
+				PerProvider: Duration(10 * time.Second),
+				Max:         Duration(3 * time.Minute),
+			},
+		},
+		Cache: CacheConfig{
+			MaxEntries: cache.DefaultMaxEntries,
+			MaxBytes:   cache.DefaultMaxBytes,
+			TTL:        Duration(10 * time.Minute),
+
+			SnapshotAge: Duration(24 * time.Hour),
+		},
+	}
+}
+
+// ConfigPath returns the location of the config file, ~/.luminary/config.json
+func ConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Track(err).AsFileSystem().Error()
+	}
+	return filepath.Join(home, ".luminary", "config.json"), nil
+}
+
+// LoadConfig reads a config file over the defaults; a missing file gives the defaults
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return DefaultConfig(), errors.Track(err).
+			WithContext("path", path).
+			WithMessagef("The config file %s is not valid: %v", path, err).
+			AsParser().Error()
+	}
+	return config, nil
+}
+
+// Duration is a time.Duration written as a string such as "90s" or "10m" in JSON
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler; plain numbers are taken as seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ByteSize is a number of bytes written as a number or a string such as "500MB" or
+// "1.5GiB" in JSON
+type ByteSize int64
+
+// byteUnits are the units ParseByteSize accepts, powers of 1000 and of 1024
+var byteUnits = map[string]float64{
+	"": 1, "b": 1,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+	"k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+}
+
+// ParseByteSize reads sizes like "750", "500MB" or "1.5 GiB"; KB, MB, GB and TB are powers
+// of 1000, K, M, G and T and the KiB forms powers of 1024
+func ParseByteSize(text string) (ByteSize, error) {
+	text = strings.TrimSpace(text)
+	number := strings.TrimRight(text, "KMGTiBkmgtb ")
+	unit := strings.ToLower(strings.TrimSpace(text[len(number):]))
+
+	value, err := strconv.ParseFloat(number, 64)
+	multiplier, ok := byteUnits[unit]
+	if err != nil || !ok || value < 0 {
+		return 0, errors.Newf("invalid size %q", text).
+			WithMessagef("Invalid size %q, expected a number of bytes or a size like 500MB or 2GiB", text).
+			Error()
+	}
+	return ByteSize(value * multiplier), nil
+}
+
+// String writes the size in the largest unit it is a whole number of, so ParseByteSize
+// reads it back exactly
+func (s ByteSize) String() string {
+	units := []struct {
+		name string
+		size int64
+	}{
+		{"TiB", 1 << 40}, {"TB", 1e12}, {"GiB", 1 << 30}, {"GB", 1e9},
+		{"MiB", 1 << 20}, {"MB", 1e6}, {"KiB", 1 << 10}, {"KB", 1e3},
+	}
+	for _, unit := range units {
+		if s != 0 && int64(s)%unit.size == 0 {
+			return fmt.Sprintf("%d%s", int64(s)/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(s))
+}
+
+// MarshalJSON implements json.Marshaler
+func (s ByteSize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler; plain numbers are taken as bytes
+func (s *ByteSize) UnmarshalJSON(data []byte) error {
+	var bytes float64
+	if err := json.Unmarshal(data, &bytes); err == nil {
+		*s = ByteSize(bytes)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	parsed, err := ParseByteSize(text)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ApplyConfig applies settings to the engine, adjusted by the overrides of
+// SetConfigOverrides first
+func (e *Engine) ApplyConfig(config Config) {
+	e.settingsMutex.RLock()
+	overrides, version, reporter, previous := e.overrides, e.version, e.crash, e.config.CrashReports
+	e.settingsMutex.RUnlock()
+	if overrides != nil {
+		overrides(&config)
+	}
+
+	e.SetTimeouts(config.Timeouts)
+	e.SetCacheConfig(config.Cache)
+	e.SetProviderSettings(config.Providers)
+	e.Download.SetQuota(int64(config.DownloadQuota))
+	e.Download.SetLibraryRoot(config.LibraryRoot)
+	collision := download.CollisionGroup
+	if config.ChapterCollision != "" {
+		parsed, err := download.ParseCollision(config.ChapterCollision)
+		if err != nil {
+			e.Logger.Warn("Ignoring chapter_collision: %v", err)
+		} else {
+			collision = parsed
+		}
+	}
+	e.Download.SetCollision(collision)
+	e.SetDescriptionConfig(config.Descriptions, config.Providers)
+
+	// A reporter already sending to the same server keeps its count of reports
+	if !config.CrashReports.Enabled {
+		reporter = nil
+	} else if previous != config.CrashReports || reporter == nil {
+		var err error
+		if reporter, err = crash.NewReporter(config.CrashReports.DSN, e.Logger); err != nil {
+			e.Logger.Warn("Crash reporting disabled: %v", err)
+		} else if version != "" {
+			reporter.SetRelease(version)
+		}
+	}
+
+	e.settingsMutex.Lock()
+	defer e.settingsMutex.Unlock()
+	e.config = config
+	e.auditAll = config.AuditDownloads
+	e.failoverAll = config.FailoverDownloads
+	e.crash = reporter
+}
+
+// SetConfigOverrides sets a function adjusting every config applied from now on, such as
+// command-line flags taking precedence over the config file, and applies it to the
+// settings in effect
+func (e *Engine) SetConfigOverrides(overrides func(*Config)) {
+	e.settingsMutex.Lock()
+	e.overrides = overrides
+	config := e.config
+	e.settingsMutex.Unlock()
+
+	e.ApplyConfig(config)
+}
+
+// Config returns the settings in effect, overrides included
+func (e *Engine) Config() Config {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.config
+}
+
+// PreferredLanguages returns the order in which languages are chosen when a title or
+// description comes in several, English unless the config says otherwise
+func (e *Engine) PreferredLanguages() []string {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return core.LanguagePriority(e.config.PreferredLanguages)
+}
+
+// SetTimeouts applies time budgets to scraping and downloads
+func (e *Engine) SetTimeouts(timeouts Timeouts) {
+	e.settingsMutex.Lock()
+	e.timeouts = timeouts
+	e.config.Timeouts = timeouts
+	e.settingsMutex.Unlock()
+
+	e.Network.SetDefaultTimeout(time.Duration(timeouts.Request))
+	e.Download.SetPageTimeout(time.Duration(timeouts.Page))
+}
+
+// Timeouts returns the time budgets in effect
+func (e *Engine) Timeouts() Timeouts {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.timeouts
+}
+
+// WithSearchBudget returns a context limited to the search time budget of one provider
+// for the given number of pages
+func (e *Engine) WithSearchBudget(ctx context.Context, pages int) (context.Context, context.CancelFunc) {
+	return network.WithBudget(ctx, network.BudgetSearch, e.Timeouts().Search.Limit(pages))
+}
+
+// WithListBudget returns a context limited to the list time budget of one provider for
+// the given number of pages
+func (e *Engine) WithListBudget(ctx context.Context, pages int) (context.Context, context.CancelFunc) {
+	return network.WithBudget(ctx, network.BudgetList, e.Timeouts().List.Limit(pages))
+}
+
+// SetCacheConfig applies new budgets to the engine's cache, evicting entries beyond them
+func (e *Engine) SetCacheConfig(config CacheConfig) {
+	e.settingsMutex.Lock()
+	e.cacheConfig = config
+	e.config.Cache = config
+	e.settingsMutex.Unlock()
+
+	e.Cache.SetLimits(config.MaxEntries, config.MaxBytes)
+}
+
+// SetProviderSettings applies the settings of providers, keyed by provider ID
+func (e *Engine) SetProviderSettings(settings map[string]ProviderSettings) {
+	e.settingsMutex.Lock()
+	e.config.Providers = settings
+	e.settingsMutex.Unlock()
+
+	e.providerMutex.Lock()
+	e.providerSettings = settings
+	e.providerMutex.Unlock()
+
+	e.resizeSlots()
+}
+
+// ProviderSettings returns the settings of a provider; providers without any get the defaults
+func (e *Engine) ProviderSettings(id string) ProviderSettings {
+	e.providerMutex.RLock()
+	defer e.providerMutex.RUnlock()
+	return e.providerSettings[id]
+}
+
+// SnapshotAge returns how old warmed snapshots may be to answer interactive calls
+func (e *Engine) SnapshotAge() time.Duration {
+	return time.Duration(e.CacheConfig().SnapshotAge)
+}
+
+// CacheConfig returns the cache settings in effect
+func (e *Engine) CacheConfig() CacheConfig {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.cacheConfig
+}
+
+// CacheTTL returns how long provider lookups are reused from the cache
+func (e *Engine) CacheTTL() time.Duration {
+	return time.Duration(e.CacheConfig().TTL)
+}
@@ -0,0 +1,101 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/engine"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		text string
+		want engine.ByteSize
+	}{
+		{"750", 750},
+		{" 750 B ", 750},
+		{"500MB", 500e6},
+		{"500mb", 500e6},
+		{"1.5 GiB", 3 << 29},
+		{"2G", 2 << 30},
+		{"1TB", 1e12},
+		{"4KiB", 4096},
+	}
+	for _, tt := range tests {
+		got, err := engine.ParseByteSize(tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", tt.text, got, err, tt.want)
+		}
+	}
+
+	for _, text := range []string{"", "MB", "-1GB", "5 parsecs", "10XB"} {
+		if got, err := engine.ParseByteSize(text); err == nil {
+			t.Errorf("ParseByteSize(%q) = %d, want an error", text, got)
+		}
+	}
+}
+
+func TestByteSizeRoundTrips(t *testing.T) {
+	for _, size := range []engine.ByteSize{0, 999, 1000, 1024, 1536, 50e9, 2 << 30} {
+		parsed, err := engine.ParseByteSize(size.String())
+		if err != nil || parsed != size {
+			t.Errorf("%d written as %q reads back as %d, %v", int64(size), size.String(), parsed, err)
+		}
+
+		data, err := json.Marshal(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded engine.ByteSize
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != size {
+			t.Errorf("%d as JSON %s decodes to %d, %v", int64(size), data, decoded, err)
+		}
+	}
+
+	var bytes engine.ByteSize
+	if err := json.Unmarshal([]byte("1048576"), &bytes); err != nil || bytes != 1<<20 {
+		t.Errorf("plain number decodes to %d, %v", bytes, err)
+	}
+}
+
+func TestTimeoutPolicyLimit(t *testing.T) {
+	policy := engine.TimeoutPolicy{
+		Base:        engine.Duration(30 * time.Second),
+		PerPage:     engine.Duration(30 * time.Second),
+		PerProvider: engine.Duration(10 * time.Second),
+		Max:         engine.Duration(2 * time.Minute),
+	}
+	tests := []struct {
+		pages int
+		want  time.Duration
+	}{
+		{0, 70 * time.Second},
+		{1, 70 * time.Second},
+		{2, 100 * time.Second},
+		{10, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Limit(tt.pages); got != tt.want {
+			t.Errorf("Limit(%d) = %s, want %s", tt.pages, got, tt.want)
+		}
+	}
+	if got := (engine.TimeoutPolicy{PerPage: policy.PerPage}).Limit(3); got != 0 {
+		t.Errorf("Limit without a base = %s, want unlimited", got)
+	}
+}
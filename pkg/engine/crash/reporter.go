@@ -0,0 +1,231 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package crash
+
+import (
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxReports caps the crash reports one run sends, so a crash loop doesn't flood the server
+const maxReports = 10
+
+// sendTimeout bounds the upload of one report
+const sendTimeout = 10 * time.Second
+
+// Reporter uploads panics to a Sentry-compatible server. Only errors of the panic
+// category are sent, with secrets, query strings and home directories scrubbed from
+// their message and context. A nil Reporter reports nothing.
+type Reporter struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+	logger   logger.Logger
+
+	mu      sync.Mutex
+	release string
+	sent    map[string]bool // Fingerprints already reported this run
+	pending sync.WaitGroup
+}
+
+// NewReporter creates a reporter for a Sentry DSN such as
+// https://<public key>@o0.ingest.sentry.io/<project>
+func NewReporter(dsn string, log logger.Logger) (*Reporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, errors.Newf("invalid crash report DSN %q", dsn).
+			WithMessage("The crash report DSN must look like https://<key>@<host>/<project>").
+			Error()
+	}
+	prefix, project := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if project == "" {
+		return nil, errors.Newf("crash report DSN %q has no project", dsn).
+			WithMessage("The crash report DSN must end with the project ID, as in https://<key>@<host>/<project>").
+			Error()
+	}
+
+	endpoint := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path.Join(prefix, "api", project, "store") + "/"}
+	return &Reporter{
+		endpoint: endpoint.String(),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=luminary, sentry_key=%s", u.User.Username()),
+		client:   &http.Client{Timeout: sendTimeout},
+		logger:   log,
+		release:  "dev",
+		sent:     make(map[string]bool),
+	}, nil
+}
+
+// SetRelease sets the Luminary version the reports are filed under
+func (r *Reporter) SetRelease(version string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.release = version
+}
+
+// Report uploads err in the background if it is a panic; other errors are ignored, as
+// are panics already reported this run
+func (r *Reporter) Report(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	var tracked *errors.TrackedError
+	if !errors.As(err, &tracked) || tracked.Category != errors.CategoryPanic {
+		return
+	}
+
+	event := r.event(tracked)
+	if event == nil {
+		return
+	}
+	body, mErr := json.Marshal(event)
+	if mErr != nil {
+		r.logger.Debug("Crash report not sent: %v", mErr)
+		return
+	}
+
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		if err := r.send(body); err != nil {
+			r.logger.Debug("Crash report not sent: %v", err)
+			return
+		}
+		r.logger.Info("Crash report %s sent", event["event_id"])
+	}()
+}
+
+// Flush waits up to timeout for the reports still being uploaded
+func (r *Reporter) Flush(timeout time.Duration) {
+	if r == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// event builds the Sentry event of a panic; nil if it was reported already or the
+// run has used up its reports
+func (r *Reporter) event(tracked *errors.TrackedError) map[string]interface{} {
+	details := errors.ToJSON(tracked)
+
+	fingerprint := details.Code
+	if len(tracked.StackTrace) > 0 {
+		top := tracked.StackTrace[0]
+		fingerprint += fmt.Sprintf("@%s:%d", top.Function, top.Line)
+	}
+
+	r.mu.Lock()
+	if r.sent[fingerprint] || len(r.sent) >= maxReports {
+		r.mu.Unlock()
+		return nil
+	}
+	r.sent[fingerprint] = true
+	release := r.release
+	r.mu.Unlock()
+
+	// Sentry lists frames from the outermost call to the one that panicked
+	frames := make([]map[string]interface{}, 0, len(tracked.StackTrace))
+	for i := len(tracked.StackTrace) - 1; i >= 0; i-- {
+		frame := tracked.StackTrace[i]
+		frames = append(frames, map[string]interface{}{
+			"function": frame.Function,
+			"filename": frame.File,
+			"lineno":   frame.Line,
+			"in_app":   strings.HasPrefix(frame.Function, "Luminary/"),
+		})
+	}
+
+	tags := map[string]string{"code": details.Code}
+	if provider, ok := details.Context["provider_id"].(string); ok {
+		tags["provider"] = provider
+	}
+
+	return map[string]interface{}{
+		"event_id":  newEventID(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"platform":  "go",
+		"level":     "fatal",
+		"logger":    "luminary",
+		"release":   "luminary@" + release,
+		"tags":      tags,
+		"extra":     scrub(details.Context),
+		"contexts": map[string]interface{}{
+			"os":      map[string]string{"name": runtime.GOOS},
+			"runtime": map[string]string{"name": "go", "version": runtime.Version()},
+		},
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{{
+				"type":       string(tracked.Category),
+				"value":      scrubText(details.Message),
+				"stacktrace": map[string]interface{}{"frames": frames},
+			}},
+		},
+	}
+}
+
+// send posts an event to the store endpoint
+func (r *Reporter) send(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("crash report server answered %s", resp.Status)
+	}
+	return nil
+}
+
+// newEventID returns a random event ID, 32 hex digits as Sentry expects
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package crash
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// secretKeys are the parts of context keys whose values are never reported
+var secretKeys = []string{"pass", "secret", "token", "auth", "cookie", "key", "session", "credential"}
+
+// urlPattern finds URLs in free text
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// scrub returns a copy of the context of an error that is safe to report: secret
+// values are redacted and every string goes through scrubText
+func scrub(context map[string]interface{}) map[string]interface{} {
+	if len(context) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(context))
+	for key, value := range context {
+		if isSecret(key) {
+			out[key] = "[redacted]"
+			continue
+		}
+		out[key] = scrubValue(value)
+	}
+	return out
+}
+
+func scrubValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, int, int64, float64:
+		return v
+	case string:
+		return scrubText(v)
+	case map[string]interface{}:
+		return scrub(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = scrubValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = scrubText(item)
+		}
+		return out
+	default:
+		return scrubText(fmt.Sprint(v))
+	}
+}
+
+// scrubText strips credentials, query strings and fragments from the URLs in text and
+// replaces the home directory with ~
+func scrubText(text string) string {
+	text = urlPattern.ReplaceAllStringFunc(text, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "[url]"
+		}
+		u.User, u.RawQuery, u.Fragment = nil, "", ""
+		return u.String()
+	})
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		text = strings.ReplaceAll(text, home, "~")
+	}
+	return text
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeys {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/parser"
+	"regexp"
+	"slices"
+)
+
+// DescriptionBoilerplate is implemented by providers that know the boilerplate their site
+// adds to descriptions, such as "Read X online for free"; it is removed from them
+type DescriptionBoilerplate interface {
+	DescriptionBoilerplate() []*regexp.Regexp
+}
+
+// descriptionSettings are DescriptionConfig and the boilerplate of the provider settings,
+// compiled
+type descriptionSettings struct {
+	pipeline    parser.DescriptionPipeline
+	boilerplate map[string][]*regexp.Regexp // By provider ID
+}
+
+// SetDescriptionConfig sets how descriptions are cleaned up, with the boilerplate of the
+// providers' settings. Invalid settings are logged and left out.
+func (e *Engine) SetDescriptionConfig(config DescriptionConfig, providers map[string]ProviderSettings) {
+	settings := descriptionSettings{
+		pipeline:    parser.DescriptionPipeline{Format: parser.DescriptionText, MaxLength: max(config.MaxLength, 0)},
+		boilerplate: make(map[string][]*regexp.Regexp),
+	}
+	switch config.Format {
+	case "", parser.DescriptionText:
+	case parser.DescriptionMarkdown:
+		settings.pipeline.Format = parser.DescriptionMarkdown
+	default:
+		e.Logger.Warn("Ignoring descriptions.format %q, expected text or markdown", config.Format)
+	}
+
+	if patterns, err := parser.CompileBoilerplate(config.Boilerplate); err != nil {
+		e.Logger.Warn("Ignoring descriptions.boilerplate: %v", err)
+	} else {
+		settings.pipeline.Boilerplate = patterns
+	}
+	for id, provider := range providers {
+		patterns, err := parser.CompileBoilerplate(provider.Boilerplate)
+		if err != nil {
+			e.Logger.Warn("Ignoring the boilerplate of provider %s: %v", id, err)
+			continue
+		}
+		if len(patterns) > 0 {
+			settings.boilerplate[id] = patterns
+		}
+	}
+
+	e.settingsMutex.Lock()
+	e.config.Descriptions = config
+	e.descriptions = settings
+	e.settingsMutex.Unlock()
+}
+
+// CleanDescription runs a description of a provider through the description pipeline:
+// HTML is converted to text or markdown, the boilerplate of the config and the provider is
+// removed and the text is tidied up and shortened, as DescriptionConfig sets
+func (e *Engine) CleanDescription(providerID, description string) string {
+	if description == "" {
+		return ""
+	}
+
+	e.settingsMutex.RLock()
+	settings := e.descriptions
+	e.settingsMutex.RUnlock()
+
+	pipeline := settings.pipeline.WithBoilerplate(settings.boilerplate[providerID]...)
+	if provider, err := e.GetProvider(providerID); err == nil {
+		if known, ok := provider.(DescriptionBoilerplate); ok {
+			pipeline = pipeline.WithBoilerplate(known.DescriptionBoilerplate()...)
+		}
+	}
+	return pipeline.Clean(description)
+}
+
+// cleanMangaInfo returns manga details with a clean description. Details may be shared
+// through the cache, so they are copied rather than changed.
+func (e *Engine) cleanMangaInfo(providerID string, info *core.MangaInfo) *core.MangaInfo {
+	if info == nil || info.Description == "" {
+		return info
+	}
+	clean := *info
+	clean.Description = e.CleanDescription(providerID, info.Description)
+	return &clean
+}
+
+// cleanResults returns search results with clean descriptions, copied like cleanMangaInfo
+func (e *Engine) cleanResults(providerID string, results []core.Manga) []core.Manga {
+	if !slices.ContainsFunc(results, func(m core.Manga) bool { return m.Description != "" }) {
+		return results
+	}
+	results = slices.Clone(results)
+	for i := range results {
+		results[i].Description = e.CleanDescription(providerID, results[i].Description)
+	}
+	return results
+}
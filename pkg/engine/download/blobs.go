@@ -0,0 +1,199 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore keeps a single copy of identical pages, such as re-downloaded chapters, the
+// copies of a chapter released by several groups and credit pages shared by a series.
+// Pages stay ordinary files in their chapter directories, but are hard links to files in
+// the store named after their SHA-256, so the store has to be on the same file system
+// as the downloads.
+type BlobStore struct {
+	dir string
+}
+
+// NewBlobStore returns the store in dir, creating it if needed
+func NewBlobStore(dir string) (*BlobStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("directory", dir).AsFileSystem().Error()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Track(err).WithContext("directory", dir).AsFileSystem().Error()
+	}
+	return &BlobStore{dir: dir}, nil
+}
+
+// Dir returns the directory of the store
+func (b *BlobStore) Dir() string {
+	return b.dir
+}
+
+// Link replaces the file at path by a link to the stored copy of its content, whose hex
+// SHA-256 is sum, adding the file to the store if its content is new. It reports whether
+// the file was a duplicate and its space saved.
+func (b *BlobStore) Link(path, sum string) (bool, error) {
+	blob := b.blobPath(sum)
+
+	stored, err := os.Stat(blob)
+	if err == nil {
+		if info, err := os.Stat(path); err != nil || os.SameFile(info, stored) {
+			return false, err
+		}
+
+		// Swap the link in through a temporary name, so the page is never missing
+		temp := path + ".link"
+		if err := os.Link(blob, temp); err != nil {
+			return false, err
+		}
+		if err := os.Rename(temp, path); err != nil {
+			_ = os.Remove(temp)
+			return false, err
+		}
+		return true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		return false, err
+	}
+	if err := os.Link(path, blob); err != nil {
+		if os.IsExist(err) {
+			return b.Link(path, sum) // Stored by a concurrent download in the meantime
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// blobPath returns where content with the hex SHA-256 sum is stored
+func (b *BlobStore) blobPath(sum string) string {
+	return filepath.Join(b.dir, sum[:2], sum)
+}
+
+// Prune removes the stored copies no chapter below roots refers to in its manifest, and
+// returns how many it removed and the bytes freed. Copies that are still linked from
+// elsewhere, such as chapters below other directories, are kept where the file system
+// reports link counts.
+func (b *BlobStore) Prune(ctx context.Context, roots []string) (int, int64, error) {
+	used := make(map[string]bool)
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if abs, err := filepath.Abs(path); err == nil && abs == b.dir {
+					return filepath.SkipDir
+				}
+			}
+			if d.IsDir() || d.Name() != ManifestFile {
+				return nil
+			}
+
+			manifest, err := ReadManifest(filepath.Dir(path))
+			if err != nil {
+				return nil // Left to 'luminary verify'
+			}
+			for _, page := range manifest.Pages {
+				if page.SHA256 != "" {
+					used[page.SHA256] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, errors.Track(err).WithContext("directory", root).AsFileSystem().Error()
+		}
+	}
+
+	removed, freed := 0, int64(0)
+	err := filepath.WalkDir(b.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || used[d.Name()] {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(info); ok && links > 1 {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, errors.Track(err).WithContext("directory", b.dir).AsFileSystem().Error()
+	}
+	return removed, freed, nil
+}
+
+type blobStoreKey struct{}
+
+// WithBlobStore returns a context whose chapter downloads keep their pages in store
+func WithBlobStore(ctx context.Context, store *BlobStore) context.Context {
+	return context.WithValue(ctx, blobStoreKey{}, store)
+}
+
+// blobStoreFrom returns the blob store of ctx, or nil when pages aren't deduplicated
+func blobStoreFrom(ctx context.Context) *BlobStore {
+	store, _ := ctx.Value(blobStoreKey{}).(*BlobStore)
+	return store
+}
+
+// dedupe links the pages of a chapter directory to the blob store of ctx
+func (s *Service) dedupe(ctx context.Context, dir string, manifest *Manifest) {
+	store := blobStoreFrom(ctx)
+	if store == nil {
+		return
+	}
+
+	log := logger.FromContext(ctx, s.logger)
+	shared, saved := 0, int64(0)
+	for _, page := range manifest.Pages {
+		if page.Missing || page.SHA256 == "" {
+			continue
+		}
+		duplicate, err := store.Link(filepath.Join(dir, page.Filename), page.SHA256)
+		if err != nil {
+			// Usually a store on another file system; the pages are kept as they are
+			log.Warn("Failed to deduplicate the pages of %s: %v", dir, err)
+			return
+		}
+		if duplicate {
+			shared++
+			saved += page.Size
+		}
+	}
+	if shared > 0 {
+		log.Info("Shared %d pages of %s with earlier downloads, saving %d bytes", shared, dir, saved)
+	}
+}
@@ -0,0 +1,86 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeTestPage writes a page into dir and returns its SHA-256
+func writeTestPage(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPruneKeepsLinkedBlobs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("link counts are only checked on Unix")
+	}
+	base := t.TempDir()
+	store, err := NewBlobStore(filepath.Join(base, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A chapter below the pruned root with a manifest, one below another directory
+	// without, and a page whose chapter was deleted
+	root := filepath.Join(base, "library")
+	listed := filepath.Join(root, "Chapter_1")
+	listedSum := writeTestPage(t, listed, "001.png", "listed")
+	if err := WriteManifest(listed, &Manifest{Pages: []ManifestPage{{Filename: "001.png", SHA256: listedSum}}}); err != nil {
+		t.Fatal(err)
+	}
+	elsewhere := filepath.Join(base, "elsewhere", "Chapter_2")
+	elsewhereSum := writeTestPage(t, elsewhere, "001.png", "elsewhere")
+	deleted := filepath.Join(base, "deleted")
+	deletedSum := writeTestPage(t, deleted, "001.png", "deleted")
+
+	for dir, sum := range map[string]string{listed: listedSum, elsewhere: elsewhereSum, deleted: deletedSum} {
+		if _, err := store.Link(filepath.Join(dir, "001.png"), sum); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.RemoveAll(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, freed, err := store.Prune(context.Background(), []string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || freed != int64(len("deleted")) {
+		t.Errorf("removed %d blobs of %d bytes, want only the deleted page's", removed, freed)
+	}
+	for sum, want := range map[string]bool{listedSum: true, elsewhereSum: true, deletedSum: false} {
+		if _, err := os.Stat(store.blobPath(sum)); (err == nil) != want {
+			t.Errorf("blob %s kept = %t, want %t", sum[:8], err == nil, want)
+		}
+	}
+}
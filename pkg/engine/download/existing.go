@@ -0,0 +1,298 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ArchiveExtensions are the extensions of the chapter archives DownloadChapter recognizes
+// as an existing download of a chapter
+var ArchiveExtensions = []string{".cbz"}
+
+// existingArchive returns the archive that already holds a chapter in full, going by the
+// manifest packed into it: the one next to the chapter directory or, for archives renamed
+// since, one in the same directory whose file name has the chapter's number
+func existingArchive(chapterDir string, chapter *core.Chapter) (string, *Manifest, bool) {
+	for _, ext := range ArchiveExtensions {
+		path := chapterDir + ext
+		if manifest, ok := completeArchive(path, chapter); ok {
+			return path, manifest, true
+		}
+	}
+
+	if chapter.Info.Number <= 0 {
+		return "", nil, false
+	}
+	entries, err := os.ReadDir(filepath.Dir(chapterDir))
+	if err != nil {
+		return "", nil, false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !slices.ContainsFunc(ArchiveExtensions, func(ext string) bool {
+			return strings.EqualFold(filepath.Ext(name), ext)
+		}) {
+			continue
+		}
+		if _, number, ok := core.TitleFromFilename(name); !ok || number != chapter.Info.Number {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(chapterDir), name)
+		if manifest, ok := completeArchive(path, chapter); ok {
+			return path, manifest, true
+		}
+	}
+	return "", nil, false
+}
+
+// completeArchive returns the manifest of the archive at path if it holds the chapter
+// with none of its pages missing
+func completeArchive(path string, chapter *core.Chapter) (*Manifest, bool) {
+	if !fileExists(path) {
+		return nil, false
+	}
+	manifest, err := readArchiveManifest(path)
+	if err != nil || manifest == nil || manifest.ChapterID != chapter.Info.ID {
+		return nil, false
+	}
+	complete := len(manifest.Pages) > 0
+	for _, page := range manifest.Pages {
+		complete = complete && !page.Missing
+	}
+	return manifest, complete
+}
+
+// readArchiveManifest reads the manifest packed into a zip archive, nil if it has none
+func readArchiveManifest(path string) (*Manifest, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return readZipManifest(&archive.Reader)
+}
+
+// readZipManifest returns the manifest of an opened chapter archive, nil without one
+func readZipManifest(archive *zip.Reader) (*Manifest, error) {
+	for _, file := range archive.File {
+		if file.Name == ManifestFile {
+			manifest := &Manifest{}
+			if err := readZipJSON(file, manifest); err != nil {
+				return nil, err
+			}
+			return manifest, nil
+		}
+	}
+	return nil, nil
+}
+
+// readZipJSON decodes a JSON file of a zip archive into out
+func readZipJSON(file *zip.File, out any) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, 16<<20))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// archiveSize returns the size of a file, or 0 if it can't be read
+func archiveSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// CheckArchive checks the pages packed into a chapter archive against the manifest packed
+// with them, as CheckPage does for directories. Returns the manifest, nil for archives
+// without one, and the problem of every page that has one by its index.
+func CheckArchive(path string) (*Manifest, map[int]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("The archive can't be opened").
+			AsFileSystem().Error()
+	}
+	defer archive.Close()
+
+	var manifest *Manifest
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		entries[file.Name] = file
+		if file.Name == ManifestFile {
+			manifest = &Manifest{}
+			if err := readZipJSON(file, manifest); err != nil {
+				return nil, nil, errors.Track(err).
+					WithContext("path", path).
+					WithMessage("The chapter manifest is not valid JSON").
+					AsParser().Error()
+			}
+		}
+	}
+	if manifest == nil {
+		return nil, nil, nil
+	}
+
+	problems := make(map[int]string)
+	for _, page := range manifest.Pages {
+		file, ok := entries[page.Filename]
+		if !ok {
+			problems[page.Index] = PageMissing
+			continue
+		}
+		if problem := checkZipPage(file, page); problem != "" {
+			problems[page.Index] = problem
+		}
+	}
+	return manifest, problems, nil
+}
+
+// checkZipPage reports whether a page packed into an archive is corrupt. Reading an entry
+// to its end also verifies the archive's own checksum of it.
+func checkZipPage(file *zip.File, page ManifestPage) string {
+	size := int64(file.UncompressedSize64)
+	if size == 0 || (page.Size > 0 && size != page.Size) {
+		return PageCorrupt
+	}
+
+	r, err := file.Open()
+	if err != nil {
+		return PageCorrupt
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(r, head)
+	hash.Write(head[:n])
+	if _, err := io.Copy(hash, r); err != nil {
+		return PageCorrupt
+	}
+
+	if page.SHA256 != "" {
+		if hex.EncodeToString(hash.Sum(nil)) != page.SHA256 {
+			return PageCorrupt
+		}
+		return ""
+	}
+	if contentType := http.DetectContentType(head[:n]); !strings.HasPrefix(contentType, "image/") {
+		return PageCorrupt
+	}
+	return ""
+}
+
+// UnpackArchive extracts the files of a chapter archive into dir, so its pages can be
+// repaired like those of a chapter directory. Pages that can't be extracted, such as
+// damaged ones, are left out and returned by name, to be downloaded again.
+func UnpackArchive(path, dir string) (skipped []string, err error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", path).
+			WithMessage("The archive can't be opened").AsFileSystem().Error()
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		// Chapter archives keep their pages at the top; anything else isn't one of ours
+		if file.Name != filepath.Base(file.Name) || file.Name == ".." || strings.ContainsAny(file.Name, `/\`) {
+			return nil, errors.Newf("archive %s holds %s below its top level", path, file.Name).
+				WithMessage("Only archives Luminary packed can be repaired").AsFileSystem().Error()
+		}
+		if err := extractZipFile(file, filepath.Join(dir, file.Name)); err != nil {
+			// Without its manifest the chapter can't be repaired at all
+			if file.Name == ManifestFile {
+				return nil, errors.Track(err).WithContext("path", path).WithContext("file", file.Name).AsFileSystem().Error()
+			}
+			skipped = append(skipped, file.Name)
+		}
+	}
+	return skipped, nil
+}
+
+// extractZipFile writes an entry of a zip archive to dest. A damaged entry fails its
+// checksum once read to the end; nothing of it is kept then.
+func extractZipFile(file *zip.File, dest string) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+	}
+	return err
+}
+
+// RepackArchive replaces the archive at path with one holding the files of dir, the pages
+// in name order followed by the manifest
+func RepackArchive(dir, path string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Track(err).WithContext("path", dir).AsFileSystem().Error()
+	}
+
+	chapter := &ProcessedChapter{Dir: dir}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == ManifestFile || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		chapter.Pages = append(chapter.Pages, PageFile{Path: filepath.Join(dir, name)})
+	}
+
+	tempPath := path + ".tmp"
+	if err := writeZip(tempPath, chapter); err != nil {
+		_ = os.Remove(tempPath)
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestArchive packs files into a zip archive, with manifest as luminary.json unless nil
+func writeTestArchive(t *testing.T, manifest *Manifest, files map[string][]byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "chapter.cbz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if manifest != nil {
+		data, _ := json.Marshal(manifest)
+		files = maps.Clone(files)
+		files[ManifestFile] = data
+	}
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckArchive(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	sum := sha256.Sum256(png)
+	page := func(index int, name string) ManifestPage {
+		return ManifestPage{Index: index, Filename: name, Size: int64(len(png)), SHA256: hex.EncodeToString(sum[:])}
+	}
+	damaged := append([]byte{}, png...)
+	damaged[len(damaged)-1] = 'X'
+
+	tests := []struct {
+		name  string
+		pages []ManifestPage
+		files map[string][]byte
+		want  map[int]string
+	}{
+		{"intact", []ManifestPage{page(0, "001.png")}, map[string][]byte{"001.png": png}, map[int]string{}},
+		{"missing page", []ManifestPage{page(0, "001.png"), page(1, "002.png")}, map[string][]byte{"001.png": png}, map[int]string{1: PageMissing}},
+		{"checksum differs", []ManifestPage{page(0, "001.png")}, map[string][]byte{"001.png": damaged}, map[int]string{0: PageCorrupt}},
+		{"size differs", []ManifestPage{page(0, "001.png")}, map[string][]byte{"001.png": png[:8]}, map[int]string{0: PageCorrupt}},
+		{"no checksum, not an image", []ManifestPage{{Index: 0, Filename: "001.png"}}, map[string][]byte{"001.png": []byte("<html>")}, map[int]string{0: PageCorrupt}},
+		{"no checksum, image", []ManifestPage{{Index: 0, Filename: "001.png"}}, map[string][]byte{"001.png": png}, map[int]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestArchive(t, &Manifest{ChapterID: "c1", Pages: tt.pages}, tt.files)
+			manifest, problems, err := CheckArchive(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if manifest == nil || manifest.ChapterID != "c1" {
+				t.Fatalf("manifest = %+v", manifest)
+			}
+			if !maps.Equal(problems, tt.want) {
+				t.Errorf("problems = %v, want %v", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckArchiveWithoutManifest(t *testing.T) {
+	path := writeTestArchive(t, nil, map[string][]byte{"001.png": []byte("page")})
+	manifest, problems, err := CheckArchive(path)
+	if err != nil || manifest != nil || problems != nil {
+		t.Errorf("CheckArchive() = %v, %v, %v; want nothing for an archive without a manifest", manifest, problems, err)
+	}
+}
+
+func TestExistingArchiveRenamed(t *testing.T) {
+	manifest := &Manifest{ChapterID: "c12", Pages: []ManifestPage{{Index: 0, Filename: "001.png"}}}
+	packed := writeTestArchive(t, manifest, map[string][]byte{"001.png": []byte("page")})
+
+	outputDir := t.TempDir()
+	renamed := filepath.Join(outputDir, "Kaguya-sama - Love is War - Ch. 012.cbz")
+	if err := os.Rename(packed, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	chapter := &core.Chapter{Info: core.ChapterInfo{ID: "c12", Number: 12}}
+	path, _, ok := existingArchive(filepath.Join(outputDir, "Chapter_12"), chapter)
+	if !ok || path != renamed {
+		t.Errorf("existingArchive() = %q, %v; want %q", path, ok, renamed)
+	}
+
+	other := &core.Chapter{Info: core.ChapterInfo{ID: "c13", Number: 12}}
+	if path, _, ok := existingArchive(filepath.Join(outputDir, "Chapter_12"), other); ok {
+		t.Errorf("existingArchive() = %q for an archive of another chapter", path)
+	}
+}
+
+// writeDamagedArchive stores files uncompressed in an archive and then changes a byte of
+// the entry named damaged, which fails its checksum when read
+func writeDamagedArchive(t *testing.T, files map[string]string, damaged string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "chapter.cbz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte(files[damaged])
+	changed := append(bytes.Clone(content[:len(content)-1]), content[len(content)-1]^0xff)
+	if err := os.WriteFile(path, bytes.Replace(data, content, changed, 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnpackArchiveSkipsDamagedPages(t *testing.T) {
+	files := map[string]string{
+		"001.png":    "first page, damaged",
+		"002.png":    "second page, intact",
+		ManifestFile: `{"chapter_id": "c1"}`,
+	}
+	dir := t.TempDir()
+	skipped, err := UnpackArchive(writeDamagedArchive(t, files, "001.png"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0] != "001.png" {
+		t.Errorf("skipped %v, want [001.png]", skipped)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "001.png")); !os.IsNotExist(err) {
+		t.Errorf("the damaged page was kept: %v", err)
+	}
+	for _, name := range []string{"002.png", ManifestFile} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != files[name] {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, files[name])
+		}
+	}
+
+	if _, err := UnpackArchive(writeDamagedArchive(t, files, ManifestFile), t.TempDir()); err == nil {
+		t.Error("expected an error for a damaged manifest")
+	}
+}
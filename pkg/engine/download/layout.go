@@ -0,0 +1,293 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Layout decides where below the output directory a chapter is saved
+type Layout string
+
+// Download layouts
+const (
+	LayoutFlat     Layout = "flat"     // <output>/Chapter_10
+	LayoutLanguage Layout = "language" // <output>/<language>/Chapter_10, for chapters with a known language
+)
+
+// Layouts lists every layout
+var Layouts = []Layout{LayoutFlat, LayoutLanguage}
+
+type layoutKey struct{}
+
+// ParseLayout returns the layout with the given name
+func ParseLayout(name string) (Layout, error) {
+	for _, layout := range Layouts {
+		if strings.EqualFold(name, string(layout)) {
+			return layout, nil
+		}
+	}
+	return "", errors.Newf("unknown download layout %q", name).
+		WithMessagef("Unknown download layout %q, expected flat or language", name).
+		Error()
+}
+
+// WithLayout returns a context whose chapter downloads are saved according to layout
+func WithLayout(ctx context.Context, layout Layout) context.Context {
+	return context.WithValue(ctx, layoutKey{}, layout)
+}
+
+// outputDir returns the directory below destDir the chapter directory goes in
+func (s *Service) outputDir(ctx context.Context, destDir string, info core.ChapterInfo) string {
+	layout, _ := ctx.Value(layoutKey{}).(Layout)
+	if layout == LayoutLanguage && info.Language != "" {
+		return filepath.Join(destDir, s.sanitizeFilename(strings.ToLower(info.Language)))
+	}
+	return destDir
+}
+
+// Collision decides how a chapter is named when another chapter with the same number,
+// such as another group's release or translation, is already saved where it would go
+type Collision string
+
+// Naming strategies for chapters sharing a number
+const (
+	CollisionGroup    Collision = "group"    // Chapter_10 [GroupA], or by language without a group
+	CollisionLanguage Collision = "language" // Chapter_10 (es), or by group without a language
+	CollisionNone     Collision = "none"     // Chapter_10, overwriting the other chapter
+)
+
+// Collisions lists every naming strategy
+var Collisions = []Collision{CollisionGroup, CollisionLanguage, CollisionNone}
+
+type collisionKey struct{}
+
+// ParseCollision returns the naming strategy with the given name
+func ParseCollision(name string) (Collision, error) {
+	for _, collision := range Collisions {
+		if strings.EqualFold(name, string(collision)) {
+			return collision, nil
+		}
+	}
+	return "", errors.Newf("unknown collision strategy %q", name).
+		WithMessagef("Unknown collision strategy %q, expected group, language or none", name).
+		Error()
+}
+
+// WithCollision returns a context whose chapter downloads are named according to collision
+// instead of the service's strategy
+func WithCollision(ctx context.Context, collision Collision) context.Context {
+	return context.WithValue(ctx, collisionKey{}, collision)
+}
+
+// SetCollision sets how chapters sharing a number are named by default; empty means
+// CollisionGroup
+func (s *Service) SetCollision(collision Collision) {
+	s.namesMutex.Lock()
+	defer s.namesMutex.Unlock()
+	s.collision = collision
+}
+
+type renumberKey struct{}
+
+type volumeNamesKey struct{}
+
+// RenumberFunc returns the details a chapter is saved with, for numbering it other than
+// its site does
+type RenumberFunc func(context.Context, *core.Chapter) core.ChapterInfo
+
+// WithRenumber returns a context whose chapter downloads are named and described with the
+// details renumber returns
+func WithRenumber(ctx context.Context, renumber RenumberFunc) context.Context {
+	return context.WithValue(ctx, renumberKey{}, renumber)
+}
+
+// WithVolumeNames returns a context whose chapter downloads are named with their volume,
+// Vol_2_Chapter_1, for numbers starting over in every volume
+func WithVolumeNames(ctx context.Context) context.Context {
+	return context.WithValue(ctx, volumeNamesKey{}, true)
+}
+
+// renumbered returns the chapter with the details of the context's RenumberFunc, and its
+// details as the site gave them if they changed
+func renumbered(ctx context.Context, chapter *core.Chapter) (*core.Chapter, *core.ChapterInfo) {
+	renumber, _ := ctx.Value(renumberKey{}).(RenumberFunc)
+	if renumber == nil {
+		return chapter, nil
+	}
+	info := renumber(ctx, chapter)
+	if info == chapter.Info {
+		return chapter, nil
+	}
+	// Chapters may be shared through the cache, so they are copied rather than changed
+	source := chapter.Info
+	copied := *chapter
+	copied.Info = info
+	return &copied, &source
+}
+
+// baseName returns the name of the chapter's directory before collisions are resolved. It
+// is made from the number; the label the site shows is kept in the manifest instead.
+func baseName(ctx context.Context, info core.ChapterInfo) string {
+	name := fmt.Sprintf("Chapter_%g", info.Number)
+	if byVolume, _ := ctx.Value(volumeNamesKey{}).(bool); byVolume && info.Volume != "" {
+		name = "Vol_" + info.Volume + "_" + name
+	}
+	return name
+}
+
+// chapterName returns the name of the chapter's directory below outputDir. A chapter whose
+// number another chapter already took there gets a suffix naming its group or language,
+// and a counter if that isn't enough. Names are claimed until the download is done, so
+// chapters downloading at the same time don't share one either; call release then.
+func (s *Service) chapterName(ctx context.Context, outputDir string, info core.ChapterInfo) (name string, release func()) {
+	base := baseName(ctx, info)
+	name = s.sanitizeFilename(base)
+
+	s.namesMutex.Lock()
+	defer s.namesMutex.Unlock()
+
+	collision, ok := ctx.Value(collisionKey{}).(Collision)
+	if !ok {
+		collision = s.collision
+	}
+	if collision == CollisionNone || info.ID == "" {
+		return name, func() {}
+	}
+
+	var group, language string
+	if info.Group != "" {
+		group = " [" + info.Group + "]"
+	}
+	if info.Language != "" {
+		language = " (" + strings.ToLower(info.Language) + ")"
+	}
+	suffixes := []string{"", group, language, group + language}
+	if collision == CollisionLanguage {
+		suffixes = []string{"", language, group, language + group}
+	}
+
+	var candidates []string
+	for _, suffix := range suffixes {
+		candidate := name
+		if suffix != "" {
+			candidate = s.sanitizeFilename(base + suffix)
+		}
+		if !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	storage := s.storageFrom(ctx)
+	for i := 2; ; i++ {
+		for _, candidate := range candidates {
+			dir := filepath.Join(outputDir, candidate)
+			if s.claimName(ctx, storage, dir, info.ID) {
+				return candidate, s.releaser(storage, dir)
+			}
+		}
+		candidates = []string{fmt.Sprintf("%s (%d)", name, i)}
+	}
+}
+
+// claimName claims a chapter directory for a chapter, unless it belongs to
+// another one. Callers hold namesMutex.
+func (s *Service) claimName(ctx context.Context, storage Storage, dir, chapterID string) bool {
+	if owner, ok := s.names[dir]; ok {
+		return owner == chapterID
+	}
+	if owner := savedChapterID(ctx, storage, dir); owner != "" && owner != chapterID {
+		return false
+	}
+	if s.names == nil {
+		s.names = make(map[string]string)
+	}
+	s.names[dir] = chapterID
+	return true
+}
+
+// releaser returns the function giving up the claim of a chapter directory.
+// Claims on storage that can't be read back are kept, as the saved chapters can't be
+// told apart later.
+func (s *Service) releaser(storage Storage, dir string) func() {
+	if _, ok := storage.(StorageReader); storage != nil && !ok {
+		return func() {}
+	}
+	return func() {
+		s.namesMutex.Lock()
+		defer s.namesMutex.Unlock()
+		delete(s.names, dir)
+	}
+}
+
+// savedChapterID returns the ID of the chapter saved at a chapter directory, as a
+// directory or an archive, going by its manifest; with storage set, the directory is
+// looked up there. Empty if there is none or it doesn't say.
+func savedChapterID(ctx context.Context, storage Storage, dir string) string {
+	if storage != nil {
+		return storedChapterID(ctx, storage, storageName(dir))
+	}
+	if manifest, err := ReadManifest(dir); err == nil && manifest.ChapterID != "" {
+		return manifest.ChapterID
+	}
+	for _, ext := range ArchiveExtensions {
+		if !fileExists(dir + ext) {
+			continue
+		}
+		if manifest, err := readArchiveManifest(dir + ext); err == nil && manifest != nil {
+			return manifest.ChapterID
+		}
+	}
+	return ""
+}
+
+// storedChapterID is savedChapterID for a chapter saved as name on storage
+func storedChapterID(ctx context.Context, storage Storage, name string) string {
+	reader, ok := storage.(StorageReader)
+	if !ok {
+		return ""
+	}
+	if data, err := reader.Get(ctx, path.Join(name, ManifestFile)); err == nil {
+		var manifest Manifest
+		if json.Unmarshal(data, &manifest) == nil && manifest.ChapterID != "" {
+			return manifest.ChapterID
+		}
+	}
+	for _, ext := range ArchiveExtensions {
+		data, err := reader.Get(ctx, name+ext)
+		if err != nil {
+			continue
+		}
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			continue
+		}
+		if manifest, err := readZipManifest(archive); err == nil && manifest != nil {
+			return manifest.ChapterID
+		}
+	}
+	return ""
+}
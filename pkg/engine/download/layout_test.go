@@ -0,0 +1,77 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/engine/network"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChapterNameReleasesClaims(t *testing.T) {
+	s := NewService(network.NewClient(logger.NewService("")), logger.NewService(""))
+	ctx := context.Background()
+	outputDir := t.TempDir()
+	first := core.ChapterInfo{ID: "a", Number: 10, Language: "en"}
+	second := core.ChapterInfo{ID: "b", Number: 10, Language: "es"}
+
+	name, release := s.chapterName(ctx, outputDir, first)
+	if name != "Chapter_10" {
+		t.Fatalf("first chapter got %q", name)
+	}
+	other, releaseOther := s.chapterName(ctx, outputDir, second)
+	if other == name {
+		t.Fatal("a chapter downloading at the same time got the claimed name")
+	}
+	release()
+	releaseOther()
+	if len(s.names) != 0 {
+		t.Errorf("claims kept after release: %v", s.names)
+	}
+}
+
+func TestChapterNameLooksUpStorage(t *testing.T) {
+	s := NewService(network.NewClient(logger.NewService("")), logger.NewService(""))
+	storage := &LocalStorage{Root: t.TempDir()}
+	ctx := WithStorage(context.Background(), storage)
+
+	manifest, err := json.Marshal(Manifest{ChapterID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := storage.Location(filepath.Join("series", "Chapter_10", ManifestFile))
+	if err := os.MkdirAll(filepath.Dir(saved), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(saved, manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, release := s.chapterName(ctx, "series", core.ChapterInfo{ID: "b", Number: 10, Language: "es"})
+	defer release()
+	if name != "Chapter_10 (es)" {
+		t.Errorf("got %q, want the name next to the chapter saved on storage", name)
+	}
+	if name, _ := s.chapterName(ctx, "series", core.ChapterInfo{ID: "a", Number: 10}); name != "Chapter_10" {
+		t.Errorf("the saved chapter got %q, want its own name back", name)
+	}
+}
@@ -0,0 +1,200 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFile is the name of the manifest written into every chapter directory
+const ManifestFile = "luminary.json"
+
+// Manifest records where a chapter directory came from and what its pages should be,
+// so downloads can be verified and repaired later. Packed into archives with the pages,
+// it also keeps a library self-describing, recoverable from its files alone.
+type Manifest struct {
+	Provider  string  `json:"provider,omitempty"`
+	ChapterID string  `json:"chapter_id,omitempty"`
+	MangaID   string  `json:"manga_id,omitempty"`
+	Chapter   string  `json:"chapter"` // Display number or label
+	Number    float64 `json:"number,omitempty"`
+	Volume    string  `json:"volume,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Language  string  `json:"language,omitempty"`
+	Group     string  `json:"group,omitempty"`
+	SourceURL string  `json:"source_url,omitempty"` // The chapter's page on the site
+	// SourceLabel and SourceVolume are the chapter's number and volume on the site, when
+	// the chapter was renumbered
+	SourceLabel  string         `json:"source_label,omitempty"`
+	SourceVolume string         `json:"source_volume,omitempty"`
+	DownloadedAt time.Time      `json:"downloaded_at,omitzero"`
+	Updated      time.Time      `json:"updated"`
+	Pages        []ManifestPage `json:"pages"`
+	// Processed names the post-processors the pages went through, in order
+	Processed []string `json:"processed,omitempty"`
+}
+
+// ManifestPage is a page of a manifest; pages that failed to download are marked missing
+type ManifestPage struct {
+	Index    int    `json:"index"`
+	Filename string `json:"filename"`
+	URL      string `json:"url,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+// Problems a page can have on disk
+const (
+	PageMissing = "missing"
+	PageCorrupt = "corrupt"
+)
+
+// ReadManifest reads the manifest of a chapter directory
+func ReadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("The chapter manifest is not valid JSON").
+			AsParser().Error()
+	}
+	return &manifest, nil
+}
+
+// WriteManifest writes the manifest into a chapter directory, replacing any previous one
+func WriteManifest(dir string, manifest *Manifest) error {
+	manifest.Updated = time.Now()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Track(err).Error()
+	}
+
+	path := filepath.Join(dir, ManifestFile)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		_ = os.Remove(path + ".tmp")
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return nil
+}
+
+// CheckPage reports whether a manifest page is missing or corrupt on disk, or "" when it
+// is intact. Without a checksum, a page counts as corrupt if it isn't an image.
+func CheckPage(dir string, page ManifestPage) string {
+	path := filepath.Join(dir, page.Filename)
+	info, err := os.Stat(path)
+	if err != nil {
+		return PageMissing
+	}
+	if info.Size() == 0 || (page.Size > 0 && info.Size() != page.Size) {
+		return PageCorrupt
+	}
+
+	if page.SHA256 != "" {
+		_, sum, err := Digest(path)
+		if err != nil || sum != page.SHA256 {
+			return PageCorrupt
+		}
+		return ""
+	}
+
+	if !isImage(path) {
+		return PageCorrupt
+	}
+	return ""
+}
+
+// buildManifest describes a chapter whose pages were just written to dir
+func (s *Service) buildManifest(chapter *core.Chapter, dir string, failures []PageFailure) *Manifest {
+	failed := make(map[int]bool, len(failures))
+	for _, failure := range failures {
+		failed[failure.Index] = true
+	}
+
+	manifest := &Manifest{
+		ChapterID:    chapter.Info.ID,
+		MangaID:      chapter.MangaID,
+		Chapter:      chapter.Info.DisplayNumber(),
+		Number:       chapter.Info.Number,
+		Volume:       chapter.Info.Volume,
+		Title:        chapter.Info.Title,
+		Language:     chapter.Info.Language,
+		Group:        chapter.Info.Group,
+		DownloadedAt: time.Now().UTC(),
+		Pages:        make([]ManifestPage, len(chapter.Pages)),
+	}
+	for i, page := range chapter.Pages {
+		entry := ManifestPage{Index: i, Filename: s.pageFilename(page, i), URL: page.URL}
+		if failed[i] {
+			entry.Missing = true
+		} else if size, sum, err := Digest(filepath.Join(dir, entry.Filename)); err == nil {
+			entry.Size, entry.SHA256 = size, sum
+		} else {
+			entry.Missing = true
+		}
+		manifest.Pages[i] = entry
+	}
+	return manifest
+}
+
+// Digest returns the size and hex SHA-256 of a file
+func Digest(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// isImage sniffs the start of a file for an image format
+func isImage(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	contentType := http.DetectContentType(head[:n])
+	return len(contentType) > 6 && contentType[:6] == "image/"
+}
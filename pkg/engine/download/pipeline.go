@@ -0,0 +1,279 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Stage orders processors in a pipeline; processors run by stage, then in the order added
+type Stage int
+
+// Pipeline stages, in the order they run
+const (
+	StageConvert Stage = iota // Change the format of pages
+	StageFilter               // Drop pages
+	StageSplit                // Cut pages into several
+	StageArchive              // Pack the chapter
+)
+
+// PageFile is a page written to a chapter directory
+type PageFile struct {
+	Index int // Position of the page in the chapter, counting from 0; split pages share it
+	Path  string
+}
+
+// ProcessedChapter is a downloaded chapter going through a pipeline
+type ProcessedChapter struct {
+	Info    core.ChapterInfo
+	MangaID string
+	Dir     string     // Directory holding the pages
+	Path    string     // Where the chapter ends up; Dir unless it was packed into an archive
+	Pages   []PageFile // Pages in reading order
+}
+
+// Processor is a named step of a post-processing pipeline. It is either a
+// PageProcessor or a ChapterProcessor.
+type Processor interface {
+	Name() string
+	Stage() Stage
+}
+
+// PageProcessor transforms the pages of a chapter one at a time
+type PageProcessor interface {
+	Processor
+	// ProcessPage returns the files replacing the page: none drops it, several split it
+	ProcessPage(ctx context.Context, page PageFile) ([]PageFile, error)
+}
+
+// ChapterProcessor works on a whole chapter, e.g. to pack it into an archive
+type ChapterProcessor interface {
+	Processor
+	ProcessChapter(ctx context.Context, chapter *ProcessedChapter) error
+}
+
+// Pipeline runs processors over downloaded chapters
+type Pipeline struct {
+	processors []Processor
+}
+
+// NewPipeline returns a pipeline running the processors in stage order
+func NewPipeline(processors ...Processor) *Pipeline {
+	sorted := append([]Processor(nil), processors...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Stage() < sorted[j].Stage() })
+	return &Pipeline{processors: sorted}
+}
+
+// Names returns the names of the processors in the order they run
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.processors))
+	for i, processor := range p.processors {
+		names[i] = processor.Name()
+	}
+	return names
+}
+
+// PageNames returns the names of the processors that change pages, in order; chapter
+// processors such as cbz only pack them
+func (p *Pipeline) PageNames() []string {
+	var names []string
+	for _, processor := range p.processors {
+		if _, ok := processor.(PageProcessor); ok {
+			names = append(names, processor.Name())
+		}
+	}
+	return names
+}
+
+// Empty reports whether the pipeline has nothing to do
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.processors) == 0
+}
+
+// Run passes the chapter through every processor. beforeChapter, if set, is called once
+// the pages are final, before the first chapter processor.
+func (p *Pipeline) Run(ctx context.Context, chapter *ProcessedChapter, beforeChapter func(*ProcessedChapter) error) error {
+	pagesDone := false
+	finishPages := func() error {
+		if pagesDone || beforeChapter == nil {
+			pagesDone = true
+			return nil
+		}
+		pagesDone = true
+		return beforeChapter(chapter)
+	}
+
+	for _, processor := range p.processors {
+		if err := ctx.Err(); err != nil {
+			return errors.Track(err).WithMessage("Post-processing cancelled").Error()
+		}
+
+		switch processor := processor.(type) {
+		case PageProcessor:
+			var pages []PageFile
+			for _, page := range chapter.Pages {
+				out, err := processor.ProcessPage(ctx, page)
+				if err != nil {
+					return errors.Track(err).
+						WithContext("processor", processor.Name()).
+						WithContext("page", page.Index+1).
+						AsDownload().Error()
+				}
+				pages = append(pages, out...)
+			}
+			chapter.Pages = pages
+
+		case ChapterProcessor:
+			if err := finishPages(); err != nil {
+				return err
+			}
+			if err := processor.ProcessChapter(ctx, chapter); err != nil {
+				return errors.Track(err).
+					WithContext("processor", processor.Name()).
+					WithContext("directory", chapter.Dir).
+					AsDownload().Error()
+			}
+
+		default:
+			return errors.Newf("processor %s is neither a page nor a chapter processor", processor.Name()).Error()
+		}
+	}
+
+	return finishPages()
+}
+
+// ProcessorFactory creates a processor from the argument of its pipeline spec entry,
+// e.g. "jpeg" for "convert=jpeg"; the argument is empty when none is given
+type ProcessorFactory func(arg string) (Processor, error)
+
+var (
+	processorsMu sync.RWMutex
+	processors   = make(map[string]ProcessorFactory)
+)
+
+// RegisterProcessor makes a processor available to ParsePipeline under name
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	processors[name] = factory
+}
+
+// ProcessorNames returns the names of the registered processors, sorted
+func ProcessorNames() []string {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePipeline builds a pipeline from a comma-separated spec such as
+// "convert=jpeg,split=2000,cbz"
+func ParsePipeline(spec string) (*Pipeline, error) {
+	var steps []Processor
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(entry, "=")
+		processorsMu.RLock()
+		factory, ok := processors[name]
+		processorsMu.RUnlock()
+		if !ok {
+			return nil, errors.Newf("unknown processor %q", name).
+				WithMessagef("Available processors: %s", strings.Join(ProcessorNames(), ", ")).
+				Error()
+		}
+
+		processor, err := factory(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, errors.Track(err).
+				WithContext("processor", name).
+				WithMessagef("Invalid setting for processor %s: %v", name, err).
+				Error()
+		}
+		steps = append(steps, processor)
+	}
+	return NewPipeline(steps...), nil
+}
+
+type (
+	pipelineKey     struct{}
+	preprocessorKey struct{}
+)
+
+// WithPipeline returns a context whose chapter downloads are post-processed by pipeline
+// instead of the service's default one
+func WithPipeline(ctx context.Context, pipeline *Pipeline) context.Context {
+	return context.WithValue(ctx, pipelineKey{}, pipeline)
+}
+
+// WithPreprocessors returns a context whose chapter downloads run processors a provider
+// needs, such as one restoring pages the site scrambles, ahead of those of the pipeline
+// in the same stage
+func WithPreprocessors(ctx context.Context, processors ...Processor) context.Context {
+	return context.WithValue(ctx, preprocessorKey{}, processors)
+}
+
+// pipelineFrom returns the pipeline for a download: the context's, or the service
+// default, after the preprocessors of the context
+func (s *Service) pipelineFrom(ctx context.Context) *Pipeline {
+	pipeline, ok := ctx.Value(pipelineKey{}).(*Pipeline)
+	if !ok {
+		pipeline = s.pipeline
+	}
+
+	preprocessors, _ := ctx.Value(preprocessorKey{}).([]Processor)
+	if len(preprocessors) == 0 {
+		return pipeline
+	}
+	if pipeline != nil {
+		preprocessors = append(slices.Clip(preprocessors), pipeline.processors...)
+	}
+	return NewPipeline(preprocessors...)
+}
+
+// SetPipeline sets the pipeline downloads are post-processed with by default
+func (s *Service) SetPipeline(pipeline *Pipeline) {
+	s.pipeline = pipeline
+}
+
+// pageFiles lists the pages of a chapter that are on disk
+func pageFiles(dir string, manifest *Manifest) []PageFile {
+	var pages []PageFile
+	for _, page := range manifest.Pages {
+		path := filepath.Join(dir, page.Filename)
+		if _, err := os.Stat(path); err == nil && !page.Missing {
+			pages = append(pages, PageFile{Index: page.Index, Path: path})
+		}
+	}
+	return pages
+}
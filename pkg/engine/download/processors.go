@@ -0,0 +1,279 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // Register GIF decoding for convert and split
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Built-in processors
+func init() {
+	RegisterProcessor("convert", newConvertProcessor)
+	RegisterProcessor("filter", newFilterProcessor)
+	RegisterProcessor("split", newSplitProcessor)
+	RegisterProcessor("cbz", func(string) (Processor, error) { return cbzProcessor{}, nil })
+}
+
+// convertProcessor re-encodes pages as JPEG or PNG. Pages in formats the standard
+// library can't decode, such as WebP, are kept as they are.
+type convertProcessor struct {
+	format string
+}
+
+func newConvertProcessor(arg string) (Processor, error) {
+	switch strings.ToLower(arg) {
+	case "jpeg", "jpg":
+		return convertProcessor{format: "jpg"}, nil
+	case "png":
+		return convertProcessor{format: "png"}, nil
+	default:
+		return nil, fmt.Errorf("format must be jpeg or png, not %q", arg)
+	}
+}
+
+func (p convertProcessor) Name() string { return "convert" }
+func (p convertProcessor) Stage() Stage { return StageConvert }
+
+// ProcessPage implements PageProcessor
+func (p convertProcessor) ProcessPage(_ context.Context, page PageFile) ([]PageFile, error) {
+	if strings.EqualFold(strings.TrimPrefix(filepath.Ext(page.Path), "."), p.format) {
+		return []PageFile{page}, nil
+	}
+
+	img, _, err := decodeImage(page.Path)
+	if err != nil {
+		return []PageFile{page}, nil
+	}
+
+	target := strings.TrimSuffix(page.Path, filepath.Ext(page.Path)) + "." + p.format
+	if err := encodeImage(target, img, p.format); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(page.Path); err != nil {
+		return nil, err
+	}
+	return []PageFile{{Index: page.Index, Path: target}}, nil
+}
+
+// filterProcessor drops pages smaller than a minimum size, like credit banners and ads
+// inserted between pages. Its argument is WIDTHxHEIGHT, e.g. "400x300".
+type filterProcessor struct {
+	minWidth, minHeight int
+}
+
+func newFilterProcessor(arg string) (Processor, error) {
+	if arg == "" {
+		arg = "300x200"
+	}
+	w, h, ok := strings.Cut(strings.ToLower(arg), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width < 0 || height < 0 {
+		return nil, fmt.Errorf("minimum size must look like 400x300, not %q", arg)
+	}
+	return filterProcessor{minWidth: width, minHeight: height}, nil
+}
+
+func (p filterProcessor) Name() string { return "filter" }
+func (p filterProcessor) Stage() Stage { return StageFilter }
+
+// ProcessPage implements PageProcessor
+func (p filterProcessor) ProcessPage(_ context.Context, page PageFile) ([]PageFile, error) {
+	f, err := os.Open(page.Path)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(f)
+	_ = f.Close()
+
+	// Pages that can't be measured are kept
+	if err != nil || (config.Width >= p.minWidth && config.Height >= p.minHeight) {
+		return []PageFile{page}, nil
+	}
+	if err := os.Remove(page.Path); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// splitProcessor cuts pages taller than a maximum height, such as long webtoon strips,
+// into several. Its argument is the height in pixels.
+type splitProcessor struct {
+	maxHeight int
+}
+
+func newSplitProcessor(arg string) (Processor, error) {
+	if arg == "" {
+		arg = "2000"
+	}
+	height, err := strconv.Atoi(arg)
+	if err != nil || height < 100 {
+		return nil, fmt.Errorf("maximum height must be a number of pixels of at least 100, not %q", arg)
+	}
+	return splitProcessor{maxHeight: height}, nil
+}
+
+func (p splitProcessor) Name() string { return "split" }
+func (p splitProcessor) Stage() Stage { return StageSplit }
+
+// ProcessPage implements PageProcessor
+func (p splitProcessor) ProcessPage(_ context.Context, page PageFile) ([]PageFile, error) {
+	img, format, err := decodeImage(page.Path)
+	if err != nil || img.Bounds().Dy() <= p.maxHeight {
+		return []PageFile{page}, nil
+	}
+	if format != "png" {
+		format = "jpg"
+	}
+
+	bounds := img.Bounds()
+	base := strings.TrimSuffix(page.Path, filepath.Ext(page.Path))
+	var parts []PageFile
+	for top, n := bounds.Min.Y, 1; top < bounds.Max.Y; top, n = top+p.maxHeight, n+1 {
+		rect := image.Rect(bounds.Min.X, top, bounds.Max.X, min(top+p.maxHeight, bounds.Max.Y))
+		part := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+		draw.Draw(part, part.Bounds(), img, rect.Min, draw.Src)
+
+		path := fmt.Sprintf("%s_%02d.%s", base, n, format)
+		if err := encodeImage(path, part, format); err != nil {
+			return nil, err
+		}
+		parts = append(parts, PageFile{Index: page.Index, Path: path})
+	}
+
+	if err := os.Remove(page.Path); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// cbzProcessor packs the chapter directory into a CBZ archive next to it and removes the
+// directory. The manifest is packed along with the pages.
+type cbzProcessor struct{}
+
+func (cbzProcessor) Name() string { return "cbz" }
+func (cbzProcessor) Stage() Stage { return StageArchive }
+
+// ProcessChapter implements ChapterProcessor
+func (cbzProcessor) ProcessChapter(_ context.Context, chapter *ProcessedChapter) error {
+	archivePath := chapter.Dir + ".cbz"
+	tempPath := archivePath + ".tmp"
+
+	if err := writeZip(tempPath, chapter); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, archivePath); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if err := os.RemoveAll(chapter.Dir); err != nil {
+		return err
+	}
+
+	chapter.Path = archivePath
+	return nil
+}
+
+// writeZip writes the chapter's pages, then its manifest if there is one, into a zip file
+func writeZip(path string, chapter *ProcessedChapter) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	files := make([]string, 0, len(chapter.Pages)+2)
+	for _, page := range chapter.Pages {
+		files = append(files, page.Path)
+	}
+	if file := filepath.Join(chapter.Dir, ManifestFile); fileExists(file) {
+		files = append(files, file)
+	}
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		// Images are compressed already
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: filepath.Base(file), Method: zip.Store, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, src)
+		_ = src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// decodeImage decodes an image file, returning its format as named by the image package
+func decodeImage(path string) (image.Image, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	return image.Decode(f)
+}
+
+// encodeImage writes an image as "jpg" or "png"
+func encodeImage(path string, img image.Image, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if format == "png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 90})
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// fileExists reports whether path is an existing file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
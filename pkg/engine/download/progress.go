@@ -0,0 +1,132 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// speedWindow is how far back the transfer speed of a download is averaged
+const speedWindow = 10 * time.Second
+
+// Progress is how far a chapter download got, reported after every page
+type Progress struct {
+	Pages  int   // Pages of the chapter
+	Done   int   // Pages written
+	Failed int   // Pages that failed on every URL
+	Bytes  int64 // Bytes written
+	// RemainingBytes estimates the bytes of the pages still to download from the average
+	// page so far; 0 until a page is written
+	RemainingBytes int64
+	// Speed is the bytes written per second over the last speedWindow
+	Speed float64
+	// ETA estimates the time left from RemainingBytes and Speed; 0 when not known yet
+	ETA time.Duration
+	// Elapsed is the time since the pages started downloading
+	Elapsed time.Duration
+}
+
+// ProgressFunc receives the progress of a download. It is called from the page workers,
+// one call at a time, and must not block.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context that reports the progress of the chapters downloaded
+// with it to report, after the functions given to the contexts it derives from
+func WithProgress(ctx context.Context, report ProgressFunc) context.Context {
+	if outer, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		inner := report
+		report = func(p Progress) {
+			outer(p)
+			inner(p)
+		}
+	}
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// progressMeter turns the pages written into Progress
+type progressMeter struct {
+	report  ProgressFunc
+	started time.Time
+
+	mu       sync.Mutex
+	progress Progress
+	// samples holds the bytes written over time; the first one is the newest from before
+	// the window, so the speed covers all of it
+	samples []progressSample
+}
+
+type progressSample struct {
+	at    time.Time
+	bytes int64
+}
+
+// newProgressMeter returns a meter for a download of pages, or nil when ctx doesn't ask
+// for progress
+func newProgressMeter(ctx context.Context, pages int) *progressMeter {
+	report, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	if report == nil {
+		return nil
+	}
+	now := time.Now()
+	return &progressMeter{
+		report:   report,
+		started:  now,
+		progress: Progress{Pages: pages},
+		samples:  []progressSample{{at: now}},
+	}
+}
+
+// page records a page written with size bytes, or a failed one, and reports the progress
+func (m *progressMeter) page(size int64, failed bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	p := &m.progress
+	if failed {
+		p.Failed++
+	} else {
+		p.Done++
+		p.Bytes += size
+	}
+
+	m.samples = append(m.samples, progressSample{at: now, bytes: p.Bytes})
+	for len(m.samples) > 2 && now.Sub(m.samples[1].at) > speedWindow {
+		m.samples = m.samples[1:]
+	}
+	if oldest := m.samples[0]; now.After(oldest.at) {
+		p.Speed = float64(p.Bytes-oldest.bytes) / now.Sub(oldest.at).Seconds()
+	}
+
+	p.RemainingBytes, p.ETA = 0, 0
+	if p.Done > 0 {
+		p.RemainingBytes = p.Bytes / int64(p.Done) * int64(p.Pages-p.Done-p.Failed)
+	}
+	if p.Speed > 0 {
+		p.ETA = time.Duration(float64(p.RemainingBytes) / p.Speed * float64(time.Second))
+	}
+	p.Elapsed = now.Sub(m.started)
+
+	m.report(*p)
+}
@@ -0,0 +1,157 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrQuotaExceeded is returned for downloads into a library that reached the quota set
+// with SetQuota
+var ErrQuotaExceeded = stderrors.New("download quota exceeded")
+
+// quotaRescan is how long the measured size of a download directory is trusted; downloads
+// are added to it in between, files removed by other programs aren't
+const quotaRescan = 5 * time.Minute
+
+// quotaUsage is the size of a download directory
+type quotaUsage struct {
+	bytes    int64
+	measured time.Time
+}
+
+// SetQuota sets the largest size, in bytes, a library may grow to; downloads stop with
+// ErrQuotaExceeded once it is reached. Zero or less removes the quota.
+func (s *Service) SetQuota(bytes int64) {
+	s.quota.Store(max(bytes, 0))
+}
+
+// Quota returns the quota set with SetQuota, 0 without one
+func (s *Service) Quota() int64 {
+	return s.quota.Load()
+}
+
+// SetLibraryRoot sets the directory the library is downloaded into, so every download
+// below it counts towards one quota. Without one, or for downloads elsewhere, each
+// download directory has its own.
+func (s *Service) SetLibraryRoot(dir string) {
+	s.quotaMutex.Lock()
+	defer s.quotaMutex.Unlock()
+	s.libraryRoot = ""
+	if dir != "" {
+		s.libraryRoot = quotaKey(dir)
+	}
+}
+
+// QuotaUsage returns the size of the library a download directory is in, as the quota
+// counts it
+func (s *Service) QuotaUsage(dir string) int64 {
+	s.quotaMutex.Lock()
+	defer s.quotaMutex.Unlock()
+	root := s.quotaRoot(dir)
+
+	usage, ok := s.usage[root]
+	if !ok || time.Since(usage.measured) > quotaRescan {
+		usage = &quotaUsage{bytes: dirSize(root), measured: time.Now()}
+		if s.usage == nil {
+			s.usage = make(map[string]*quotaUsage)
+		}
+		s.usage[root] = usage
+	}
+	return usage.bytes
+}
+
+// CheckQuota returns an error when the library a download directory is in has reached
+// the quota
+func (s *Service) CheckQuota(dir string) error {
+	quota := s.quota.Load()
+	if quota <= 0 {
+		return nil
+	}
+	used := s.QuotaUsage(dir)
+	if used < quota {
+		return nil
+	}
+
+	s.quotaMutex.Lock()
+	root := s.quotaRoot(dir)
+	s.quotaMutex.Unlock()
+	return errors.Track(ErrQuotaExceeded).
+		WithContext("directory", root).
+		WithContext("used_bytes", used).
+		WithContext("quota_bytes", quota).
+		WithMessagef("%s uses %s of its %s quota; free up space or raise download_quota in the config",
+			root, formatSize(used), formatSize(quota)).
+		AsFileSystem().
+		Error()
+}
+
+// addUsage counts bytes written below a download directory, or removed with a negative
+// count, towards the quota of its library
+func (s *Service) addUsage(dir string, bytes int64) {
+	if s.quota.Load() <= 0 {
+		return
+	}
+
+	s.quotaMutex.Lock()
+	defer s.quotaMutex.Unlock()
+	if usage, ok := s.usage[s.quotaRoot(dir)]; ok {
+		usage.bytes = max(usage.bytes+bytes, 0)
+	}
+}
+
+// quotaRoot returns the directory whose size the quota of a download directory counts:
+// the library root when the directory is below it, else the directory itself. Callers
+// hold quotaMutex.
+func (s *Service) quotaRoot(dir string) string {
+	key := quotaKey(dir)
+	if s.libraryRoot == "" {
+		return key
+	}
+	if rel, err := filepath.Rel(s.libraryRoot, key); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return s.libraryRoot
+	}
+	return key
+}
+
+// quotaKey identifies a download directory however its path was written
+func quotaKey(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
+
+// formatSize formats a byte count in a human-readable form
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,101 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/engine/network"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuotaCoversLibraryRoot(t *testing.T) {
+	root := t.TempDir()
+	for name, size := range map[string]int{"a": 600, "b": 500} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name, "page.png"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewService(network.NewClient(logger.NewService("")), logger.NewService(""))
+	s.SetQuota(1000)
+	// Without a library root each series directory stays under its own quota
+	for _, dir := range []string{filepath.Join(root, "a"), filepath.Join(root, "b")} {
+		if err := s.CheckQuota(dir); err != nil {
+			t.Errorf("%s: %v", dir, err)
+		}
+	}
+
+	s.SetLibraryRoot(root)
+	if err := s.CheckQuota(filepath.Join(root, "b")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("series below the library root: %v, want ErrQuotaExceeded", err)
+	}
+	if used := s.QuotaUsage(filepath.Join(root, "a")); used != 1100 {
+		t.Errorf("usage = %d, want the whole library's 1100 bytes", used)
+	}
+	if err := s.CheckQuota(t.TempDir()); err != nil {
+		t.Errorf("directory outside the library: %v", err)
+	}
+}
+
+func TestQuotaRemovesChapterCutShort(t *testing.T) {
+	var page bytes.Buffer
+	if err := png.Encode(&page, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(page.Bytes())
+	}))
+	defer server.Close()
+
+	s := NewService(network.NewClient(logger.NewService("")), logger.NewService(""))
+	s.SetConcurrency(1)
+	s.SetThrottle(0)
+	s.SetQuota(1) // Reached by the first page
+	root := t.TempDir()
+	chapterDir := filepath.Join(root, "Chapter_1")
+	if err := os.MkdirAll(chapterDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := make([]core.Page, 3)
+	for i := range pages {
+		pages[i] = core.Page{Index: i, URL: server.URL + "/page.png"}
+	}
+	if _, err := s.downloadPages(context.Background(), pages, chapterDir, root); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("got %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := os.Stat(chapterDir); !os.IsNotExist(err) {
+		t.Errorf("chapter directory left behind: %v", err)
+	}
+	if used := s.QuotaUsage(root); used != 0 {
+		t.Errorf("usage = %d after removing the pages, want 0", used)
+	}
+}
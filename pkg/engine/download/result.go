@@ -0,0 +1,130 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/core"
+	"context"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ChapterResult describes a chapter handled by DownloadChapter
+type ChapterResult struct {
+	Provider string // Set by the caller, recorded in the chapter's manifest
+	Info     core.ChapterInfo
+	MangaID  string
+	Dir      string // Directory the pages were written to
+	Pages    int    // Pages written
+	Bytes    int64
+	// OutputDir is the directory Dir was created in: the destination directory, or one
+	// below it depending on the layout
+	OutputDir string
+	// Storage is the spec of the storage the chapter was written to, empty when it stayed on
+	// the local disk; Dir is its location there then
+	Storage string
+	// FailedPages lists the pages that couldn't be downloaded from any of their URLs when
+	// the rest of the chapter could
+	FailedPages []PageFailure
+	// SourceURL is the chapter's page on the provider's site, set by the caller and
+	// recorded in the chapter's manifest
+	SourceURL string
+	// Existing is set when the chapter was already on disk as an archive and nothing was
+	// downloaded; Dir is the archive then
+	Existing bool
+}
+
+// PageFailure is a page left out of a chapter
+type PageFailure struct {
+	Index int // Position in the chapter, counting from 0
+	URL   string
+	Err   error
+}
+
+type resultKey struct{}
+
+type refreshKey struct{}
+
+// RefreshFunc fetches a chapter again, for fresh page URLs when the known ones fail
+type RefreshFunc func(context.Context) (*core.Chapter, error)
+
+// WithRefresh returns a context that lets DownloadChapter re-scrape the chapter through
+// refresh once before giving up on a page
+func WithRefresh(ctx context.Context, refresh RefreshFunc) context.Context {
+	return context.WithValue(ctx, refreshKey{}, refresh)
+}
+
+// refreshFrom returns the refresh function of ctx, or nil
+func refreshFrom(ctx context.Context) RefreshFunc {
+	refresh, _ := ctx.Value(refreshKey{}).(RefreshFunc)
+	return refresh
+}
+
+type headersKey struct{}
+
+type hostHeaders struct {
+	host    string
+	headers map[string]string
+}
+
+// WithHeaders returns a context whose page requests to host send headers as well, such as
+// the credentials of the server the images are on. Pages on other hosts don't get them.
+func WithHeaders(ctx context.Context, host string, headers map[string]string) context.Context {
+	return context.WithValue(ctx, headersKey{}, hostHeaders{host: host, headers: headers})
+}
+
+// headersFrom returns the headers of ctx for a page request to rawURL, or nil
+func headersFrom(ctx context.Context, rawURL string) map[string]string {
+	scoped, ok := ctx.Value(headersKey{}).(hostHeaders)
+	if !ok {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Host, scoped.host) {
+		return nil
+	}
+	return scoped.headers
+}
+
+// WithResult returns a context that collects the result of a chapter downloaded with it
+func WithResult(ctx context.Context) (context.Context, *ChapterResult) {
+	result := &ChapterResult{}
+	return context.WithValue(ctx, resultKey{}, result), result
+}
+
+// resultFrom returns the result collector of ctx, or nil if the caller didn't ask for one
+func resultFrom(ctx context.Context) *ChapterResult {
+	result, _ := ctx.Value(resultKey{}).(*ChapterResult)
+	return result
+}
+
+// dirSize returns the total size of the regular files below dir
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
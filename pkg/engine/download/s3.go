@@ -0,0 +1,229 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage writes chapters to an S3 bucket, or a bucket of a compatible service such as
+// MinIO, Backblaze B2 or Wasabi
+type S3Storage struct {
+	bucket   string
+	prefix   string
+	region   string
+	endpoint *url.URL // Set for S3-compatible services, which are addressed path-style
+
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	client *http.Client
+}
+
+// NewS3Storage returns a storage writing below prefix in bucket. Credentials and the
+// region come from the usual AWS variables: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION; AWS_ENDPOINT_URL points to another service.
+func NewS3Storage(bucket, prefix string) (*S3Storage, error) {
+	if bucket == "" {
+		return nil, errors.New("S3 storage needs a bucket").
+			WithMessage("S3 storage needs a bucket, as in s3://bucket/prefix").Error()
+	}
+
+	s := &S3Storage{
+		bucket:       bucket,
+		prefix:       prefix,
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: storageTimeout},
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("S3 credentials are not set").
+			WithMessage("S3 storage needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY").
+			AsAuth().Error()
+	}
+
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, errors.Newf("invalid AWS_ENDPOINT_URL %q", endpoint).Error()
+		}
+		s.endpoint = u
+	}
+	return s, nil
+}
+
+// Put uploads r to the object name below the prefix
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), r)
+	if err != nil {
+		return errors.Track(err).WithContext("storage", s.String()).Error()
+	}
+	req.ContentLength = size
+	s.sign(req, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Track(err).WithContext("storage", s.String()).AsNetwork().Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return storageError(s, name, resp)
+	}
+	return nil
+}
+
+// Get downloads the object name below the prefix
+func (s *S3Storage) Get(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("storage", s.String()).Error()
+	}
+	s.sign(req, time.Now())
+	return readStorage(s, name, s.client, req)
+}
+
+// Location returns the s3:// URL of the object name
+func (s *S3Storage) Location(name string) string {
+	return "s3://" + s.bucket + "/" + s.key(name)
+}
+
+// String returns the storage as an s3:// spec
+func (s *S3Storage) String() string {
+	return strings.TrimSuffix("s3://"+s.bucket+"/"+s.prefix, "/")
+}
+
+// key returns the object key of name
+func (s *S3Storage) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, name), "/")
+}
+
+// objectURL returns the HTTPS URL of the object name: virtual-hosted on AWS, path-style on
+// other services
+func (s *S3Storage) objectURL(name string) string {
+	key := awsEscape(s.key(name))
+	if s.endpoint != nil {
+		return strings.TrimSuffix(s.endpoint.String(), "/") + "/" + awsEscape(s.bucket) + "/" + key
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+}
+
+// sign adds an AWS Signature Version 4 to req. The payload is left unsigned so uploads
+// don't have to be read twice; it is still protected by TLS.
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := date + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, date, s.region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the key signing the requests of a day to a service in a region
+func signingKey(secretKey, date, region, service string) []byte {
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return key
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape percent-encodes everything but slashes and unreserved characters, as
+// signatures expect
+func awsEscape(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
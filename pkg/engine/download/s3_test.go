@@ -0,0 +1,107 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigningKey(t *testing.T) {
+	// The example of the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	if got, want := hex.EncodeToString(key), "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"; got != want {
+		t.Errorf("signingKey() = %s, want %s", got, want)
+	}
+}
+
+func TestS3Sign(t *testing.T) {
+	now := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		storage   S3Storage
+		object    string
+		canonical string // Canonical request the signature must cover
+		signed    string
+	}{
+		{
+			name:    "aws",
+			storage: S3Storage{bucket: "manga", prefix: "library", region: "eu-central-1"},
+			object:  "One Piece/Chapter 1.cbz",
+			canonical: "PUT\n/library/One%20Piece/Chapter%201.cbz\n\n" +
+				"host:manga.s3.eu-central-1.amazonaws.com\n" +
+				"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+				"x-amz-date:20250314T092653Z\n" +
+				"\nhost;x-amz-content-sha256;x-amz-date\nUNSIGNED-PAYLOAD",
+			signed: "host;x-amz-content-sha256;x-amz-date",
+		},
+		{
+			name:    "session token",
+			storage: S3Storage{bucket: "manga", region: "us-east-1", sessionToken: "token"},
+			object:  "a+b.zip",
+			canonical: "PUT\n/a%2Bb.zip\n\n" +
+				"host:manga.s3.us-east-1.amazonaws.com\n" +
+				"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+				"x-amz-date:20250314T092653Z\n" +
+				"x-amz-security-token:token\n" +
+				"\nhost;x-amz-content-sha256;x-amz-date;x-amz-security-token\nUNSIGNED-PAYLOAD",
+			signed: "host;x-amz-content-sha256;x-amz-date;x-amz-security-token",
+		},
+		{
+			name: "compatible service",
+			storage: S3Storage{bucket: "manga", region: "us-east-1",
+				endpoint: &url.URL{Scheme: "http", Host: "localhost:9000"}},
+			object: "ch1/001.jpg",
+			canonical: "PUT\n/manga/ch1/001.jpg\n\n" +
+				"host:localhost:9000\n" +
+				"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+				"x-amz-date:20250314T092653Z\n" +
+				"\nhost;x-amz-content-sha256;x-amz-date\nUNSIGNED-PAYLOAD",
+			signed: "host;x-amz-content-sha256;x-amz-date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.storage
+			s.accessKey, s.secretKey = "AKIDEXAMPLE", "secret"
+			req, err := http.NewRequest(http.MethodPut, s.objectURL(tt.object), strings.NewReader("page"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.sign(req, now)
+
+			scope := "20250314/" + s.region + "/s3/aws4_request"
+			hash := sha256.Sum256([]byte(tt.canonical))
+			stringToSign := "AWS4-HMAC-SHA256\n20250314T092653Z\n" + scope + "\n" + hex.EncodeToString(hash[:])
+			signature := hex.EncodeToString(hmacSHA256(signingKey("secret", "20250314", s.region, "s3"), stringToSign))
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/" + scope + ", SignedHeaders=" + tt.signed + ", Signature=" + signature
+
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %s\nwant %s", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20250314T092653Z" {
+				t.Errorf("X-Amz-Date = %s", got)
+			}
+		})
+	}
+}
@@ -22,15 +22,23 @@ import (
 	"Luminary/pkg/engine/network"
 	"Luminary/pkg/errors"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrExternalChapter is returned when a chapter is only readable on an external (official) site
+var ErrExternalChapter = stderrors.New("chapter is hosted externally")
+
 // Service handles file downloads
 type Service struct {
 	client       *network.Client
@@ -38,27 +46,94 @@ type Service struct {
 	concurrency  int
 	outputFormat string
 	throttle     time.Duration
+	pageTimeout  atomic.Int64 // A time.Duration; reloading the config changes it while downloads run
+	pipeline     *Pipeline
+	storage      Storage
+
+	quota       atomic.Int64 // Bytes, see SetQuota
+	quotaMutex  sync.Mutex
+	libraryRoot string                 // See SetLibraryRoot
+	usage       map[string]*quotaUsage // Keyed by quotaRoot
+
+	namesMutex sync.Mutex
+	collision  Collision
+	names      map[string]string // Chapter ID by the chapter directory it claimed, while it downloads
 }
 
 // NewService creates a new download service
 func NewService(client *network.Client, logger logger.Logger) *Service {
-	return &Service{
+	s := &Service{
 		client:       client,
 		logger:       logger,
 		concurrency:  3,
 		outputFormat: "png",
 		throttle:     500 * time.Millisecond,
 	}
+	s.pageTimeout.Store(int64(2 * time.Minute))
+	return s
 }
 
 // DownloadChapter downloads all pages of a chapter
 func (s *Service) DownloadChapter(ctx context.Context, chapter *core.Chapter, destDir string) error {
+	chapter, source := renumbered(ctx, chapter)
+	result := resultFrom(ctx)
+	if result != nil {
+		result.Info = chapter.Info
+		result.MangaID = chapter.MangaID
+	}
+
+	if chapter.Info.ExternalURL != "" {
+		return errors.Track(fmt.Errorf("%w: %s", ErrExternalChapter, chapter.Info.ExternalURL)).
+			WithContext("external_url", chapter.Info.ExternalURL).
+			WithMessagef("Chapter is only available on the publisher's site: %s", chapter.Info.ExternalURL).
+			AsUnavailable(ReasonExternal).
+			Error()
+	}
+
 	if len(chapter.Pages) == 0 {
 		return errors.New("chapter has no pages").AsProvider("").Error()
 	}
 
-	// Create chapter directory
-	chapterDir := filepath.Join(destDir, s.sanitizeFilename(fmt.Sprintf("Chapter_%g", chapter.Info.Number)))
+	outputDir := s.outputDir(ctx, destDir, chapter.Info)
+	chapterName, release := s.chapterName(ctx, outputDir, chapter.Info)
+	defer release()
+	chapterDir := filepath.Join(outputDir, chapterName)
+
+	// Chapters going to other storage don't fill the disk the quota guards
+	storage := s.storageFrom(ctx)
+	quotaDir := destDir
+	if storage != nil {
+		quotaDir = ""
+	} else {
+		// A complete archive of the chapter from an earlier download is kept, whatever
+		// output format was asked for this time
+		if archive, manifest, ok := existingArchive(chapterDir, chapter); ok {
+			logger.FromContext(ctx, s.logger).Info("Chapter %s is already downloaded to %s",
+				chapter.Info.DisplayNumber(), archive)
+			if result != nil {
+				result.Dir = archive
+				result.OutputDir = outputDir
+				result.Pages = len(manifest.Pages)
+				result.Bytes = archiveSize(archive)
+				result.Existing = true
+			}
+			return nil
+		}
+		if err := s.CheckQuota(destDir); err != nil {
+			return err
+		}
+	}
+
+	// Create chapter directory; chapters going to other storage are put together in a
+	// temporary one first
+	if storage != nil {
+		staging, err := os.MkdirTemp("", "luminary-")
+		if err != nil {
+			return errors.Track(err).AsFileSystem().Error()
+		}
+		defer os.RemoveAll(staging)
+		chapterDir = filepath.Join(staging, chapterName)
+	}
 	if err := os.MkdirAll(chapterDir, 0755); err != nil {
 		return errors.Track(err).
 			WithContext("directory", chapterDir).
@@ -66,18 +141,142 @@ func (s *Service) DownloadChapter(ctx context.Context, chapter *core.Chapter, de
 			Error()
 	}
 
-	s.logger.Info("Downloading chapter %.1f to %s (%d pages)",
-		chapter.Info.Number, chapterDir, len(chapter.Pages))
+	location := chapterDir
+	if storage != nil {
+		location = storage.Location(path.Join(storageName(outputDir), chapterName))
+	}
+	logger.FromContext(ctx, s.logger).Info("Downloading chapter %.1f to %s (%d pages)",
+		chapter.Info.Number, location, len(chapter.Pages))
+
+	if result != nil {
+		result.Dir = chapterDir
+		result.OutputDir = outputDir
+	}
+
+	// Download pages concurrently; pages failing on every URL are left out unless all do
+	failures, err := s.downloadPages(ctx, chapter.Pages, chapterDir, quotaDir)
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		logger.FromContext(ctx, s.logger).Warn("Chapter %s is missing %d of %d pages",
+			chapter.Info.DisplayNumber(), len(failures), len(chapter.Pages))
+	}
+
+	// The manifest lets 'luminary verify' check and repair the chapter later
+	manifest := s.buildManifest(chapter, chapterDir, failures)
+	if source != nil {
+		manifest.SourceLabel, manifest.SourceVolume = source.DisplayNumber(), source.Volume
+	}
+	if result != nil {
+		manifest.Provider, manifest.SourceURL = result.Provider, result.SourceURL
+	}
+	if err := WriteManifest(chapterDir, manifest); err != nil {
+		logger.FromContext(ctx, s.logger).Warn("Failed to write manifest of %s: %v", chapterDir, err)
+	}
+
+	chapterPath := chapterDir
+	if pipeline := s.pipelineFrom(ctx); !pipeline.Empty() {
+		processed := &ProcessedChapter{
+			Info:    chapter.Info,
+			MangaID: chapter.MangaID,
+			Dir:     chapterDir,
+			Path:    chapterDir,
+			Pages:   pageFiles(chapterDir, manifest),
+		}
+		if err := pipeline.Run(ctx, processed, func(processed *ProcessedChapter) error {
+			return s.rewriteManifest(processed, manifest, pipeline.PageNames())
+		}); err != nil {
+			return err
+		}
+		chapterPath = processed.Path
+	}
+
+	// Pages packed into an archive or uploaded elsewhere can't be shared
+	if chapterPath == chapterDir && storage == nil {
+		s.dedupe(ctx, chapterDir, manifest)
+	}
+
+	if result != nil {
+		result.Dir = chapterPath
+		result.Pages = len(chapter.Pages) - len(failures)
+		result.FailedPages = failures
+		result.Bytes = dirSize(chapterPath)
+	}
+
+	if storage != nil {
+		name := path.Join(storageName(outputDir), filepath.Base(chapterPath))
+		if err := upload(ctx, storage, chapterPath, name); err != nil {
+			return err
+		}
+		if result != nil {
+			result.Dir = storage.Location(name)
+			result.Storage = storage.String()
+		}
+	}
+
+	return nil
+}
+
+// rewriteManifest updates a chapter's manifest to the pages left by its pipeline, so it
+// can still be verified. Only the processors that changed pages are recorded, since pages
+// they changed can no longer be repaired in place.
+func (s *Service) rewriteManifest(chapter *ProcessedChapter, manifest *Manifest, processors []string) error {
+	urls := make(map[int]string, len(manifest.Pages))
+	for _, page := range manifest.Pages {
+		urls[page.Index] = page.URL
+	}
+
+	pages := make([]ManifestPage, 0, len(chapter.Pages))
+	for _, page := range chapter.Pages {
+		size, sum, err := Digest(page.Path)
+		if err != nil {
+			return errors.Track(err).WithContext("path", page.Path).AsFileSystem().Error()
+		}
+		pages = append(pages, ManifestPage{
+			Index:    page.Index,
+			Filename: filepath.Base(page.Path),
+			URL:      urls[page.Index],
+			Size:     size,
+			SHA256:   sum,
+		})
+	}
+
+	// Pages that failed to download stay on record as missing
+	for _, page := range manifest.Pages {
+		if page.Missing {
+			pages = append(pages, page)
+		}
+	}
+
+	manifest.Pages = pages
+	manifest.Processed = processors
+	return WriteManifest(chapter.Dir, manifest)
+}
+
+// RepairPages downloads pages of a chapter again into its existing directory, replacing
+// whatever is there, and returns the pages that still failed
+func (s *Service) RepairPages(ctx context.Context, pages []core.Page, dir string) []PageFailure {
+	refresh := &chapterRefresh{fetch: refreshFrom(ctx)}
 
-	// Download pages concurrently
-	return s.downloadPages(ctx, chapter.Pages, chapterDir)
+	var failures []PageFailure
+	for _, page := range pages {
+		if page.Filename != "" {
+			_ = os.Remove(filepath.Join(dir, page.Filename))
+		}
+		if err := s.downloadPage(ctx, page, page.Index, dir, refresh); err != nil {
+			failures = append(failures, PageFailure{Index: page.Index, URL: page.URL, Err: err})
+		}
+	}
+	return failures
 }
 
 // DownloadFile downloads a single file
 func (s *Service) DownloadFile(ctx context.Context, url, destPath string) error {
 	// Check if file already exists
 	if _, err := os.Stat(destPath); err == nil {
-		s.logger.Debug("File already exists: %s", destPath)
+		logger.FromContext(ctx, s.logger).Debug("File already exists: %s", destPath)
 		return nil
 	}
 
@@ -86,9 +285,8 @@ func (s *Service) DownloadFile(ctx context.Context, url, destPath string) error
 
 	// Download to temporary file
 	if err := s.downloadToFile(ctx, url, tempPath); err != nil {
-		err := os.Remove(tempPath)
-		if err != nil {
-			return errors.Track(err).
+		if rmErr := os.Remove(tempPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			return errors.Track(rmErr).
 				WithContext("file", tempPath).
 				AsFileSystem().
 				Error()
@@ -115,8 +313,11 @@ func (s *Service) DownloadFile(ctx context.Context, url, destPath string) error
 	return nil
 }
 
-// downloadPages downloads multiple pages concurrently
-func (s *Service) downloadPages(ctx context.Context, pages []core.Page, destDir string) error {
+// downloadPages downloads multiple pages concurrently. Pages failing on every URL are
+// returned as failures; the error is only set when no page succeeded, ctx ended or the
+// quota of quotaDir was reached, which is checked before every page unless it is empty.
+// Reaching the quota removes the pages downloaded so far, and destDir if that empties it.
+func (s *Service) downloadPages(ctx context.Context, pages []core.Page, destDir, quotaDir string) ([]PageFailure, error) {
 	// Create work channel
 	type job struct {
 		page  core.Page
@@ -124,24 +325,55 @@ func (s *Service) downloadPages(ctx context.Context, pages []core.Page, destDir
 	}
 
 	jobs := make(chan job, len(pages))
-	errorChan := make(chan error, len(pages))
-
-	// Start workers
+	refresh := &chapterRefresh{fetch: refreshFrom(ctx)}
+	meter := newProgressMeter(ctx, len(pages))
+
+	var mu sync.Mutex
+	var failures []PageFailure
+	var quotaErr error
+	var written []string // Pages downloaded, removed again if the quota stops the chapter
+
+	// Start workers, as many as the context asks for
+	workers := s.concurrency
+	if n := core.PageConcurrency(ctx); n > 0 {
+		workers = n
+	}
 	var wg sync.WaitGroup
-	for i := 0; i < s.concurrency; i++ {
+	for i := 0; i < min(workers, len(pages)); i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				select {
-				case <-ctx.Done():
-					errorChan <- ctx.Err()
+				if ctx.Err() != nil {
 					return
-				default:
-					if err := s.downloadPage(ctx, j.page, j.index, destDir); err != nil {
-						errorChan <- err
+				}
+				if quotaDir != "" {
+					if err := s.CheckQuota(quotaDir); err != nil {
+						mu.Lock()
+						quotaErr = err
+						mu.Unlock()
+						return
 					}
 				}
+				if err := s.downloadPage(ctx, j.page, j.index, destDir, refresh); err != nil {
+					mu.Lock()
+					failures = append(failures, PageFailure{Index: j.index, URL: j.page.URL, Err: err})
+					mu.Unlock()
+					meter.page(0, true)
+					continue
+				}
+				path := filepath.Join(destDir, s.pageFilename(j.page, j.index))
+				var size int64
+				if info, err := os.Stat(path); err == nil {
+					size = info.Size()
+				}
+				if quotaDir != "" {
+					s.addUsage(quotaDir, size)
+					mu.Lock()
+					written = append(written, path)
+					mu.Unlock()
+				}
+				meter.page(size, false)
 			}
 		}()
 	}
@@ -154,54 +386,138 @@ func (s *Service) downloadPages(ctx context.Context, pages []core.Page, destDir
 
 	// Wait for completion
 	wg.Wait()
-	close(errorChan)
 
-	// Check for errors
-	var errs []error
-	for err := range errorChan {
-		errs = append(errs, err)
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Track(err).WithMessage("Download cancelled").Error()
+	}
+	if quotaErr != nil {
+		// A chapter stopped halfway would only take up the space the quota protects
+		for _, path := range written {
+			if info, err := os.Stat(path); err == nil && os.Remove(path) == nil {
+				s.addUsage(quotaDir, -info.Size())
+			}
+		}
+		_ = os.Remove(destDir)
+		return nil, quotaErr
 	}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Index < failures[j].Index })
+	if len(failures) == len(pages) {
+		errs := make([]error, len(failures))
+		for i, failure := range failures {
+			errs[i] = failure.Err
+		}
+		return nil, errors.Join(errs...)
 	}
 
-	return nil
+	return failures, nil
 }
 
-// downloadPage downloads a single page
-func (s *Service) downloadPage(ctx context.Context, page core.Page, index int, destDir string) error {
-	// Determine filename
-	filename := page.Filename
-	if filename == "" {
-		ext := s.extractExtension(page.URL)
-		if ext == "" {
-			ext = s.outputFormat
+// downloadPage downloads a single page, trying its fallback URLs and then the URLs of a
+// re-scraped chapter before giving up
+func (s *Service) downloadPage(parent context.Context, page core.Page, index int, destDir string, refresh *chapterRefresh) error {
+	destPath := filepath.Join(destDir, s.pageFilename(page, index))
+	log := logger.FromContext(parent, s.logger)
+
+	ctx, cancel := network.WithBudget(parent, network.BudgetPage, time.Duration(s.pageTimeout.Load()))
+	defer cancel()
+
+	tried := make(map[string]bool)
+	var lastErr error
+	try := func(candidates []string) bool {
+		for _, u := range candidates {
+			if u == "" || tried[u] || ctx.Err() != nil {
+				continue
+			}
+			tried[u] = true
+
+			// Apply throttling
+			if s.throttle > 0 {
+				time.Sleep(s.throttle)
+			}
+
+			log.Debug("Downloading page %d: %s", index+1, u)
+			if lastErr = s.DownloadFile(ctx, u, destPath); lastErr == nil {
+				return true
+			}
+			log.Debug("Page %d failed from %s: %v", index+1, u, lastErr)
+		}
+		return false
+	}
+
+	if try(append([]string{page.URL}, page.Fallbacks...)) {
+		return nil
+	}
+
+	// The page's URLs may have expired or been scraped from the wrong attribute
+	if fresh, ok := refresh.page(ctx, index); ok {
+		log.Debug("Retrying page %d with the URLs of the re-scraped chapter", index+1)
+		if try(append([]string{fresh.URL}, fresh.Fallbacks...)) {
+			return nil
 		}
-		filename = fmt.Sprintf("page_%03d.%s", index+1, ext)
 	}
 
-	destPath := filepath.Join(destDir, filename)
+	if budgetErr := network.BudgetError(ctx, parent); budgetErr != nil {
+		lastErr = budgetErr
+	} else if lastErr == nil {
+		lastErr = fmt.Errorf("page %d has no URL", index+1)
+	}
+	return errors.Track(lastErr).
+		WithContext("page", index+1).
+		WithContext("urls_tried", len(tried)).
+		AsDownload().
+		Error()
+}
+
+// pageFilename returns the name a page is saved under
+func (s *Service) pageFilename(page core.Page, index int) string {
+	if page.Filename != "" {
+		return page.Filename
+	}
+
+	ext := s.extractExtension(page.URL)
+	if ext == "" {
+		ext = s.outputFormat
+	}
+	return fmt.Sprintf("page_%03d.%s", index+1, ext)
+}
+
+// chapterRefresh re-scrapes a chapter at most once for all the pages of a download
+type chapterRefresh struct {
+	fetch RefreshFunc
+	once  sync.Once
+	pages []core.Page
+}
 
-	// Apply throttling
-	if s.throttle > 0 {
-		time.Sleep(s.throttle)
+// page returns the re-scraped page at index, if the chapter could be fetched again
+func (r *chapterRefresh) page(ctx context.Context, index int) (core.Page, bool) {
+	if r.fetch == nil {
+		return core.Page{}, false
 	}
 
-	s.logger.Debug("Downloading page %d: %s", index+1, page.URL)
+	r.once.Do(func() {
+		if chapter, err := r.fetch(ctx); err == nil {
+			r.pages = chapter.Pages
+		}
+	})
 
-	return s.DownloadFile(ctx, page.URL, destPath)
+	if index >= len(r.pages) {
+		return core.Page{}, false
+	}
+	return r.pages[index], true
 }
 
 // downloadToFile downloads content to a file
 func (s *Service) downloadToFile(ctx context.Context, url, destPath string) error {
 	// Create request
+	headers := map[string]string{
+		"Accept": "image/webp,image/apng,image/*,*/*;q=0.8",
+	}
+	maps.Copy(headers, headersFrom(ctx, url))
 	resp, err := s.client.Request(ctx, &network.Request{
-		URL:    url,
-		Method: "GET",
-		Headers: map[string]string{
-			"Accept": "image/webp,image/apng,image/*,*/*;q=0.8",
-		},
+		URL:     url,
+		Method:  "GET",
+		Headers: headers,
 	})
 	if err != nil {
 		return errors.Track(err).
@@ -221,9 +537,9 @@ func (s *Service) downloadToFile(ctx context.Context, url, destPath string) erro
 	defer func(file *os.File) {
 		err := file.Close()
 		if err != nil {
-			s.logger.Error("Failed to close file %s: %v", destPath, err)
+			logger.FromContext(ctx, s.logger).Error("Failed to close file %s: %v", destPath, err)
 		} else {
-			s.logger.Debug("File closed: %s", destPath)
+			logger.FromContext(ctx, s.logger).Debug("File closed: %s", destPath)
 		}
 	}(file)
 
@@ -280,7 +596,8 @@ func (s *Service) extractExtension(url string) string {
 	return ""
 }
 
-// SetConcurrency sets the number of concurrent downloads
+// SetConcurrency sets how many pages of a chapter are downloaded at once, unless the
+// context sets it with core.WithPageConcurrency
 func (s *Service) SetConcurrency(n int) {
 	if n > 0 {
 		s.concurrency = n
@@ -292,6 +609,12 @@ func (s *Service) SetThrottle(d time.Duration) {
 	s.throttle = d
 }
 
+// SetPageTimeout sets the time budget of a page, including retries and fallback URLs;
+// zero removes it
+func (s *Service) SetPageTimeout(timeout time.Duration) {
+	s.pageTimeout.Store(int64(timeout))
+}
+
 // SetOutputFormat sets the default output format
 func (s *Service) SetOutputFormat(format string) {
 	s.outputFormat = format
@@ -0,0 +1,267 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SFTPStorage writes chapters to a directory on an SSH server, such as a seedbox or an
+// e-reader running an SSH server. It drives the OpenSSH sftp client, so keys, agents,
+// known hosts and ~/.ssh/config work as they do for ssh; passwords can't be typed in.
+type SFTPStorage struct {
+	user string
+	host string
+	port string
+	root string // Relative paths are below the login directory
+	key  string // Private key file; the agent and ~/.ssh/config are used when empty
+
+	command string
+}
+
+// NewSFTPStorage returns a storage writing below the directory of u, an sftp:// URL such
+// as sftp://user@host:2222/srv/manga; sftp://host/~/manga is below the login directory.
+// The key file is given with ?key=/path/to/id_ed25519 or LUMINARY_SFTP_KEY.
+func NewSFTPStorage(u *url.URL) (*SFTPStorage, error) {
+	if u.Hostname() == "" {
+		return nil, errors.Newf("SFTP storage %q has no host", u.Redacted()).Error()
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, errors.New("SFTP storage doesn't take passwords").
+			WithMessage("SFTP storage logs in with keys, pass one with ?key= or LUMINARY_SFTP_KEY").
+			AsAuth().Error()
+	}
+
+	command, err := exec.LookPath("sftp")
+	if err != nil {
+		return nil, errors.Track(err).
+			WithMessage("SFTP storage needs the OpenSSH sftp client installed").
+			AsFileSystem().Error()
+	}
+
+	s := &SFTPStorage{
+		user:    u.User.Username(),
+		host:    u.Hostname(),
+		port:    u.Port(),
+		root:    strings.TrimSuffix(u.Path, "/"),
+		key:     u.Query().Get("key"),
+		command: command,
+	}
+	if s.root == "/~" || strings.HasPrefix(s.root, "/~/") {
+		s.root = strings.TrimPrefix(strings.TrimPrefix(s.root, "/~"), "/")
+	}
+	if s.key == "" {
+		s.key = os.Getenv("LUMINARY_SFTP_KEY")
+	}
+	if strings.HasPrefix(s.key, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			s.key = filepath.Join(home, s.key[2:])
+		}
+	}
+	return s, nil
+}
+
+// Put uploads r to name, through a temporary file unless it is one already
+func (s *SFTPStorage) Put(ctx context.Context, name string, r io.Reader, _ int64) error {
+	if f, ok := r.(*os.File); ok {
+		return s.putFiles(ctx, map[string]string{name: f.Name()})
+	}
+
+	f, err := os.CreateTemp("", "luminary-*")
+	if err != nil {
+		return errors.Track(err).AsFileSystem().Error()
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Track(err).WithContext("file", f.Name()).AsFileSystem().Error()
+	}
+	return s.putFiles(ctx, map[string]string{name: f.Name()})
+}
+
+// PutTree uploads the file or directory at local to name in a single session
+func (s *SFTPStorage) PutTree(ctx context.Context, local, name string) error {
+	files := make(map[string]string)
+	err := filepath.WalkDir(local, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(local, file)
+		if err != nil {
+			return err
+		}
+		files[path.Join(name, filepath.ToSlash(rel))] = file
+		return nil
+	})
+	if err != nil {
+		return errors.Track(err).WithContext("directory", local).AsFileSystem().Error()
+	}
+	return s.putFiles(ctx, files)
+}
+
+// putFiles uploads local files to the names they are keyed by. Each file is written under
+// a temporary name first, so readers never pick up half of one.
+func (s *SFTPStorage) putFiles(ctx context.Context, files map[string]string) error {
+	var batch strings.Builder
+	names := slices.Sorted(maps.Keys(files))
+
+	// A leading "-" lets the batch go on when the directory exists already
+	created := make(map[string]bool)
+	for _, name := range names {
+		var dirs []string
+		for dir := path.Dir(s.remotePath(name)); dir != "." && dir != "/" && !created[dir]; dir = path.Dir(dir) {
+			created[dir] = true
+			dirs = append(dirs, dir)
+		}
+		for i := len(dirs) - 1; i >= 0; i-- {
+			fmt.Fprintf(&batch, "-mkdir %s\n", sftpQuote(dirs[i]))
+		}
+	}
+
+	for _, name := range names {
+		remote := s.remotePath(name)
+		fmt.Fprintf(&batch, "put %s %s\n", sftpQuote(files[name]), sftpQuote(remote+".part"))
+		fmt.Fprintf(&batch, "-rm %s\n", sftpQuote(remote))
+		fmt.Fprintf(&batch, "rename %s %s\n", sftpQuote(remote+".part"), sftpQuote(remote))
+	}
+
+	if stderr, err := s.run(ctx, batch.String()); err != nil {
+		return errors.Track(err).
+			WithContext("storage", s.String()).
+			WithContext("stderr", stderr).
+			WithMessagef("Failed to upload to %s: %s", s.String(), lastLine(stderr)).
+			AsNetwork().Error()
+	}
+	return nil
+}
+
+// Get downloads name through a temporary file
+func (s *SFTPStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	f, err := os.CreateTemp("", "luminary-*")
+	if err != nil {
+		return nil, errors.Track(err).AsFileSystem().Error()
+	}
+	_ = f.Close()
+	defer os.Remove(f.Name())
+
+	remote := s.remotePath(name)
+	stderr, err := s.run(ctx, fmt.Sprintf("get %s %s\n", sftpQuote(remote), sftpQuote(f.Name())))
+	if err != nil {
+		// The client only says so in its messages
+		if lower := strings.ToLower(stderr); strings.Contains(lower, "not found") || strings.Contains(lower, "no such file") {
+			return nil, errors.Track(fmt.Errorf("%w: %s", fs.ErrNotExist, s.Location(name))).
+				WithContext("storage", s.String()).
+				AsNotFound().Error()
+		}
+		return nil, errors.Track(err).
+			WithContext("storage", s.String()).
+			WithContext("stderr", stderr).
+			WithMessagef("Failed to read %s: %s", s.Location(name), lastLine(stderr)).
+			AsNetwork().Error()
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, errors.Track(err).WithContext("file", f.Name()).AsFileSystem().Error()
+	}
+	return data, nil
+}
+
+// run runs an sftp batch and returns what the client wrote to standard error
+func (s *SFTPStorage) run(ctx context.Context, batch string) (string, error) {
+	args := []string{"-b", "-", "-o", "BatchMode=yes"}
+	if s.port != "" {
+		args = append(args, "-P", s.port)
+	}
+	if s.key != "" {
+		args = append(args, "-i", s.key)
+	}
+	destination := s.host
+	if s.user != "" {
+		destination = s.user + "@" + s.host
+	}
+	args = append(args, destination)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command, args...)
+	cmd.Stdin = strings.NewReader(batch)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return strings.TrimSpace(stderr.String()), err
+}
+
+// lastLine returns the last line of text, where the sftp client puts what went wrong
+func lastLine(text string) string {
+	lines := strings.Split(text, "\n")
+	return lines[len(lines)-1]
+}
+
+// remotePath returns the path of name on the server
+func (s *SFTPStorage) remotePath(name string) string {
+	if s.root == "" {
+		return path.Clean(name)
+	}
+	return path.Join(s.root, name)
+}
+
+// Location returns the sftp:// URL of name
+func (s *SFTPStorage) Location(name string) string {
+	return s.url(s.remotePath(name))
+}
+
+// String returns the storage as an sftp:// spec
+func (s *SFTPStorage) String() string {
+	u, _ := url.Parse(s.url(s.root))
+	if s.key != "" {
+		u.RawQuery = url.Values{"key": {s.key}}.Encode()
+	}
+	return u.String()
+}
+
+// url returns the sftp:// URL of a path on the server
+func (s *SFTPStorage) url(remote string) string {
+	u := url.URL{Scheme: "sftp", Host: s.host, Path: remote}
+	if s.port != "" {
+		u.Host += ":" + s.port
+	}
+	if s.user != "" {
+		u.User = url.User(s.user)
+	}
+	if !strings.HasPrefix(remote, "/") {
+		u.Path = "/~/" + remote
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// sftpQuote quotes a path for an sftp batch file
+func sftpQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
@@ -0,0 +1,274 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage is where finished chapters are written when they shouldn't stay on the disk
+// they were downloaded to. Chapters are downloaded and processed in a temporary
+// directory first and then copied to the storage file by file.
+type Storage interface {
+	// Put writes the size bytes of r to name, a slash-separated path below the root of the
+	// storage, replacing what is there
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	// Location returns where name ends up, for logs and the download history
+	Location(name string) string
+	// String returns the spec of the storage without credentials, as ParseStorage reads it
+	String() string
+}
+
+// StorageReader is implemented by storage chapters can be read back from, so a chapter
+// saved there keeps its name when another one with the same number comes along
+type StorageReader interface {
+	// Get reads name; a name that doesn't exist returns an error matching fs.ErrNotExist
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+// TreeUploader is implemented by storage that uploads a whole chapter faster at once than
+// file by file
+type TreeUploader interface {
+	// PutTree writes the file or directory at local to name
+	PutTree(ctx context.Context, local, name string) error
+}
+
+// storageTimeout bounds a single request to remote storage, the upload of a file included
+const storageTimeout = 10 * time.Minute
+
+// ParseStorage returns the storage named by spec:
+//
+//	file:///mnt/nas/manga        a directory on another disk or a mounted share
+//	s3://bucket/prefix           Amazon S3 or a compatible service
+//	webdav://host/path           WebDAV over HTTP, webdavs:// over HTTPS
+//	sftp://user@host/path        a directory on an SSH server
+//
+// An empty spec or "local" returns nil: chapters stay where they are downloaded.
+func ParseStorage(spec string) (Storage, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "local" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil || u.Scheme == "" {
+		return nil, errors.Newf("invalid storage %q", spec).
+			WithMessagef("Invalid storage %q, expected file://, s3://, webdav(s):// or sftp:// followed by a location", spec).
+			Error()
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, errors.Newf("storage %q has no directory", spec).Error()
+		}
+		return &LocalStorage{Root: filepath.FromSlash(u.Path)}, nil
+	case "s3":
+		return NewS3Storage(u.Host, strings.Trim(u.Path, "/"))
+	case "webdav", "webdavs":
+		return NewWebDAVStorage(u)
+	case "sftp":
+		return NewSFTPStorage(u)
+	case "stream":
+		return nil, errors.New("archive streams can't be written to again").
+			WithMessage("The chapter was streamed out as an archive; download it again with -o -").
+			Error()
+	default:
+		return nil, errors.Newf("unknown storage %q", u.Scheme).
+			WithMessagef("Unknown storage %q, expected file, s3, webdav, webdavs or sftp", u.Scheme).
+			Error()
+	}
+}
+
+type storageKey struct{}
+
+// WithStorage returns a context whose chapter downloads are written to storage instead of
+// the service's default one; a nil storage keeps them on the local disk
+func WithStorage(ctx context.Context, storage Storage) context.Context {
+	return context.WithValue(ctx, storageKey{}, &storage)
+}
+
+// storageFrom returns the storage for a download: the context's, or the service default
+func (s *Service) storageFrom(ctx context.Context) Storage {
+	if storage, ok := ctx.Value(storageKey{}).(*Storage); ok {
+		return *storage
+	}
+	return s.storage
+}
+
+// SetStorage sets the storage chapters are written to by default; nil keeps them on the
+// local disk
+func (s *Service) SetStorage(storage Storage) {
+	s.storage = storage
+}
+
+// storageName returns the slash-separated path below a storage root of a local directory
+// given as a destination; absolute paths and ".." are kept inside the root
+func storageName(dir string) string {
+	name := path.Clean("/" + filepath.ToSlash(dir))
+	return strings.TrimPrefix(name, "/")
+}
+
+// upload copies the file or directory at local to name on storage
+func upload(ctx context.Context, storage Storage, local, name string) error {
+	if tree, ok := storage.(TreeUploader); ok {
+		return tree.PutTree(ctx, local, name)
+	}
+
+	return filepath.WalkDir(local, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(local, file)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		if err := storage.Put(ctx, path.Join(name, filepath.ToSlash(rel)), f, info.Size()); err != nil {
+			return errors.Track(err).
+				WithContext("storage", storage.String()).
+				WithContext("file", rel).
+				Error()
+		}
+		return nil
+	})
+}
+
+// LocalStorage writes chapters below a directory, e.g. a mounted network share, so
+// downloads don't leave half-written chapters there
+type LocalStorage struct {
+	Root string
+}
+
+// Put writes r to name below the root through a temporary file
+func (l *LocalStorage) Put(_ context.Context, name string, r io.Reader, _ int64) error {
+	dest := l.Location(name)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Track(err).WithContext("directory", filepath.Dir(dest)).AsFileSystem().Error()
+	}
+
+	f, err := os.Create(dest + ".tmp")
+	if err != nil {
+		return errors.Track(err).WithContext("file", dest).AsFileSystem().Error()
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(dest + ".tmp")
+		return errors.Track(err).WithContext("file", dest).AsFileSystem().Error()
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(dest + ".tmp")
+		return errors.Track(err).WithContext("file", dest).AsFileSystem().Error()
+	}
+	if err := os.Rename(dest+".tmp", dest); err != nil {
+		_ = os.Remove(dest + ".tmp")
+		return errors.Track(err).WithContext("file", dest).AsFileSystem().Error()
+	}
+	return nil
+}
+
+// Get reads name below the root
+func (l *LocalStorage) Get(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(l.Location(name))
+	if err != nil {
+		return nil, errors.Track(err).WithContext("file", l.Location(name)).AsFileSystem().Error()
+	}
+	return data, nil
+}
+
+// Location returns the path of name on disk
+func (l *LocalStorage) Location(name string) string {
+	return filepath.Join(l.Root, filepath.FromSlash(name))
+}
+
+// String returns the storage as a file:// spec
+func (l *LocalStorage) String() string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(l.Root)}).String()
+}
+
+// readStorage sends a request reading name from remote storage and returns the body
+func readStorage(storage Storage, name string, client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("storage", storage.String()).AsNetwork().Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, storageError(storage, name, resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("storage", storage.String()).AsNetwork().Error()
+	}
+	return data, nil
+}
+
+// storageError describes a failed request to remote storage; a missing file matches
+// fs.ErrNotExist
+func storageError(storage Storage, name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	builder := errors.Newf("%s %s: %s", resp.Request.Method, storage.Location(name), resp.Status).
+		WithContext("storage", storage.String()).
+		WithContext("status_code", resp.StatusCode)
+	if text := strings.TrimSpace(string(body)); text != "" {
+		builder = builder.WithContext("response", text)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return builder.WithMessagef("Storage %s refused the credentials (%s)", storage.String(), resp.Status).
+			AsAuth().Error()
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.Track(fmt.Errorf("%w: %s", fs.ErrNotExist, storage.Location(name))).
+			WithContext("storage", storage.String()).
+			WithContext("status_code", resp.StatusCode).
+			AsNotFound().Error()
+	case resp.Request.Method == http.MethodGet:
+		return builder.WithMessagef("Failed to read %s (%s)", storage.Location(name), resp.Status).
+			AsNetwork().Error()
+	default:
+		return builder.WithMessagef("Failed to write %s (%s)", storage.Location(name), resp.Status).
+			AsNetwork().Error()
+	}
+}
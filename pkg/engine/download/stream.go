@@ -0,0 +1,108 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveStream is storage writing chapters as entries of a single tar or zip archive to
+// a stream, such as standard output. Chapters keep the paths they would have on disk.
+type ArchiveStream struct {
+	format string
+
+	mu  sync.Mutex
+	tar *tar.Writer
+	zip *zip.Writer
+}
+
+// ArchiveFormats lists the formats of an ArchiveStream
+var ArchiveFormats = []string{"tar", "zip"}
+
+// NewArchiveStream returns storage writing a tar or zip archive to w; Close finishes it
+func NewArchiveStream(w io.Writer, format string) (*ArchiveStream, error) {
+	a := &ArchiveStream{format: strings.ToLower(format)}
+	switch a.format {
+	case "tar":
+		a.tar = tar.NewWriter(w)
+	case "zip":
+		a.zip = zip.NewWriter(w)
+	default:
+		return nil, errors.Newf("unknown archive format %q", format).
+			WithMessagef("Unknown archive format %q, expected tar or zip", format).
+			Error()
+	}
+	return a, nil
+}
+
+// Put adds r to the archive as name
+func (a *ArchiveStream) Put(_ context.Context, name string, r io.Reader, size int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var w io.Writer
+	var err error
+	if a.tar != nil {
+		err = a.tar.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Size:     size,
+			Mode:     0644,
+			ModTime:  time.Now(),
+		})
+		w = a.tar
+	} else {
+		// Images are compressed already
+		w, err = a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	}
+	if err != nil {
+		return errors.Track(err).WithContext("file", name).Error()
+	}
+
+	if _, err := io.CopyN(w, r, size); err != nil {
+		return errors.Track(err).WithContext("file", name).Error()
+	}
+	return nil
+}
+
+// Location returns name, the path of the entry in the archive
+func (a *ArchiveStream) Location(name string) string {
+	return name
+}
+
+// String names the storage; a stream can't be written to again, so ParseStorage refuses it
+func (a *ArchiveStream) String() string {
+	return "stream:" + a.format
+}
+
+// Close writes the end of the archive
+func (a *ArchiveStream) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.tar != nil {
+		return a.tar.Close()
+	}
+	return a.zip.Close()
+}
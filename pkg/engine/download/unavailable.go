@@ -0,0 +1,62 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	stderrors "errors"
+	"fmt"
+)
+
+// ErrUnavailable is returned when a provider lists a chapter but won't serve its pages
+var ErrUnavailable = stderrors.New("chapter is unavailable")
+
+// Reasons a chapter is unavailable, the "reason" context of its error
+const (
+	ReasonExternal = "external" // Only readable on the publisher's site
+	ReasonLicensed = "licensed" // Removed from the provider, usually after licensing
+	ReasonRegion   = "region"   // Blocked in the user's region
+	ReasonLogin    = "login"    // Locked behind an account, coins or a premium membership
+)
+
+// unavailableMessages explain each reason to the user
+var unavailableMessages = map[string]string{
+	ReasonLicensed: "Chapter %s was removed from %s, most likely because it was licensed; try another provider",
+	ReasonRegion:   "Chapter %s is blocked in your region on %s; try another provider or a VPN",
+	ReasonLogin:    "Chapter %s on %s needs an account or a premium membership; try another provider",
+}
+
+// Unavailable returns the error of a chapter a provider won't serve for reason, one of
+// ReasonLicensed, ReasonRegion and ReasonLogin
+func Unavailable(providerID, chapterID, reason string) error {
+	message, ok := unavailableMessages[reason]
+	if !ok {
+		message = "Chapter %s is not available on %s; try another provider"
+	}
+	return errors.Track(fmt.Errorf("%w: %s", ErrUnavailable, reason)).
+		WithContext("provider_id", providerID).
+		WithContext("chapter_id", chapterID).
+		WithMessagef(message, chapterID, providerID).
+		AsUnavailable(reason).
+		Error()
+}
+
+// IsUnavailable reports whether err says a chapter can't be downloaded from its provider
+// at all, including chapters only readable on the publisher's site
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrExternalChapter)
+}
@@ -0,0 +1,181 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package download
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// WebDAVStorage writes chapters to a WebDAV share, as offered by most NAS systems and
+// by Nextcloud
+type WebDAVStorage struct {
+	base     *url.URL // http(s) URL of the root collection
+	user     string
+	password string
+	client   *http.Client
+
+	mu          sync.Mutex
+	collections map[string]bool // Collections known to exist
+}
+
+// NewWebDAVStorage returns a storage writing below the collection at u, a webdav:// or
+// webdavs:// URL. The user may be given in the URL; the password is read from
+// LUMINARY_WEBDAV_PASSWORD so it doesn't end up in the shell history or download records,
+// and LUMINARY_WEBDAV_USER is used when the URL has no user.
+func NewWebDAVStorage(u *url.URL) (*WebDAVStorage, error) {
+	if u.Host == "" {
+		return nil, errors.Newf("WebDAV storage %q has no host", u.Redacted()).Error()
+	}
+
+	base := *u
+	base.Scheme = "http"
+	if u.Scheme == "webdavs" {
+		base.Scheme = "https"
+	}
+	base.User = nil
+	base.Path = "/" + strings.Trim(u.Path, "/")
+	base.RawPath = ""
+
+	s := &WebDAVStorage{
+		base:        &base,
+		user:        os.Getenv("LUMINARY_WEBDAV_USER"),
+		password:    os.Getenv("LUMINARY_WEBDAV_PASSWORD"),
+		client:      &http.Client{Timeout: storageTimeout},
+		collections: map[string]bool{"": true},
+	}
+	if u.User != nil {
+		s.user = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			s.password = password
+		}
+	}
+	return s, nil
+}
+
+// Put creates the collections above name that are missing and uploads r to it
+func (s *WebDAVStorage) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	if err := s.ensureCollection(ctx, path.Dir(name)); err != nil {
+		return err
+	}
+
+	req, err := s.request(ctx, http.MethodPut, name, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Track(err).WithContext("storage", s.String()).AsNetwork().Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return storageError(s, name, resp)
+	}
+	return nil
+}
+
+// Get downloads name
+func (s *WebDAVStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return readStorage(s, name, s.client, req)
+}
+
+// ensureCollection creates the collection dir and its parents, which WebDAV servers
+// don't do on their own
+func (s *WebDAVStorage) ensureCollection(ctx context.Context, dir string) error {
+	dir = strings.Trim(path.Clean(dir), "/.")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.collections[dir] {
+		return nil
+	}
+
+	var current string
+	for _, part := range strings.Split(dir, "/") {
+		current = path.Join(current, part)
+		if s.collections[current] {
+			continue
+		}
+
+		req, err := s.request(ctx, "MKCOL", current+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return errors.Track(err).WithContext("storage", s.String()).AsNetwork().Error()
+		}
+		_ = resp.Body.Close()
+
+		// 405 Method Not Allowed is the answer for collections that exist already
+		if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusMethodNotAllowed {
+			return storageError(s, current, resp)
+		}
+		s.collections[current] = true
+	}
+	return nil
+}
+
+// request returns an authenticated request for name below the root collection
+func (s *WebDAVStorage) request(ctx context.Context, method, name string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.Location(name), body)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("storage", s.String()).Error()
+	}
+	if s.user != "" || s.password != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	return req, nil
+}
+
+// Location returns the http(s) URL of name
+func (s *WebDAVStorage) Location(name string) string {
+	u := *s.base
+	u.Path = path.Join(u.Path, name)
+	if strings.HasSuffix(name, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
+
+// String returns the storage as a webdav:// or webdavs:// spec, without the password
+func (s *WebDAVStorage) String() string {
+	u := *s.base
+	u.Scheme = "webdav"
+	if s.base.Scheme == "https" {
+		u.Scheme = "webdavs"
+	}
+	if s.user != "" {
+		u.User = url.User(s.user)
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
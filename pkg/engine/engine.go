@@ -18,18 +18,30 @@ package engine
 
 import (
 	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/crash"
 	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/engine/library"
 	"Luminary/pkg/engine/logger"
 	"Luminary/pkg/engine/network"
 	"Luminary/pkg/engine/parser"
 	"Luminary/pkg/errors"
+	"cmp"
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
+// crashFlushTimeout is how long shutting down waits for crash reports being uploaded
+const crashFlushTimeout = 3 * time.Second
+
 // Provider interface that providers must implement
 type Provider interface {
 	ID() string
@@ -46,6 +58,50 @@ type Provider interface {
 	DownloadChapter(context.Context, string, string) error
 }
 
+// PageCounter is an optional capability for providers that can report how many
+// pages a chapter has without resolving every page URL
+type PageCounter interface {
+	GetChapterPageCount(context.Context, string) (int, error)
+}
+
+// URLResolver is an optional capability for providers that can map a chapter URL
+// on their site to a chapter ID
+type URLResolver interface {
+	ResolveChapterURL(*url.URL) (string, error)
+}
+
+// MangaURLResolver is an optional capability for providers that can map a manga URL
+// on their site to a manga ID
+type MangaURLResolver interface {
+	ResolveMangaURL(*url.URL) (string, error)
+}
+
+// URLBuilder is an optional capability for providers that can build the URL of a manga
+// (URLKindManga) or chapter (URLKindChapter) on their site from its ID, the reverse of
+// the URL resolvers
+type URLBuilder interface {
+	URLFor(kind, id string) (string, error)
+}
+
+// TagBrowser is an optional capability for providers that can list the tags of their
+// catalogue and the manga carrying one of them
+type TagBrowser interface {
+	GetTags(context.Context) ([]core.Tag, error)
+	BrowseTag(ctx context.Context, tagID string, options core.SearchOptions) ([]core.Manga, error)
+}
+
+// AltTitleSearcher is an optional capability for providers whose site search may
+// already match alternative titles
+type AltTitleSearcher interface {
+	SearchesAltTitles() bool
+}
+
+// Requester is an optional capability for providers that can prepare a request
+// carrying their headers and rate limit, for fetching arbitrary pages of their site
+type Requester interface {
+	NewRequest(url string) *network.Request
+}
+
 // Engine is the central component providing services to providers
 type Engine struct {
 	// Core services (reduced from 11 to 4)
@@ -54,30 +110,101 @@ type Engine struct {
 	Download *download.Service
 	Logger   logger.Logger
 
+	// Library records downloads; nil when no home directory is available
+	Library *library.Library
+	// Links knows which series on different providers are the same, for failover; nil when
+	// no home directory is available
+	Links *library.Links
+	// Feed holds the chapters that appeared on followed series, see CheckFeed; nil when no
+	// home directory is available
+	Feed *library.Feed
+	// Queue holds chapters picked for a later download, see 'luminary queue'; nil when no
+	// home directory is available
+	Queue *library.Queue
+
+	// Events publishes download progress and provider health changes
+	Events *events.Bus
+
+	// Cache holds provider lookups in memory, within the budgets of SetCacheConfig
+	Cache *cache.Cache
+	// Snapshots keeps the manga details warmed by WarmCache; nil when neither a home directory
+	// nor WithCacheDir is available
+	Snapshots *cache.Snapshots
+
 	// Provider registry
 	providers     map[string]Provider
 	providerMutex sync.RWMutex
 
-	// Error formatting options
-	debugMode bool
+	// Error formatting options; the formatters have the suggestions of this engine's
+	// home directory
+	debugMode      bool
+	jsonErrors     bool
+	formatter      *errors.CLIFormatter
+	debugFormatter *errors.CLIFormatter
+
+	// Audit files of downloads go to auditDir
+	auditDir string
+
+	// Settings applied by ApplyConfig and the setters it calls, guarded by settingsMutex
+	// since ReloadConfig may change them while the engine is busy
+	settingsMutex    sync.RWMutex
+	config           Config
+	overrides        func(*Config)
+	version          string
+	timeouts         Timeouts
+	cacheConfig      CacheConfig
+	auditAll         bool                        // Audit every download
+	failoverAll      bool                        // Fail over every download
+	crash            *crash.Reporter             // Uploads panics when enabled in the config; nil otherwise
+	descriptions     descriptionSettings         // Compiled from config.Descriptions and the provider settings
+	providerSettings map[string]ProviderSettings // Guarded by providerMutex
+
+	// Semaphores limiting the calls running at once against each provider, by provider ID
+	slots      map[string]*semaphore
+	slotsMutex sync.Mutex
+
+	// Providers whose requests and selectors are traced, see SetTracing
+	tracing      map[string]bool
+	tracingMutex sync.RWMutex
+
+	// Initialization of the providers, see InitializeProviders
+	inits     map[string]*providerInit
+	initMutex sync.Mutex
+
+	// Shutdown runs once; later calls return its result
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
-// New creates a new Engine with default configuration
-func New() *Engine {
-	// Determine default log file
-	logFile := ""
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		logDir := filepath.Join(homeDir, ".luminary", "logs")
-		if err := os.MkdirAll(logDir, 0755); err == nil {
-			logFile = filepath.Join(logDir, "luminary.log")
-		}
+// New creates a new Engine with default configuration, customized by the given options
+func New(opts ...Option) *Engine {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	// Create logger first
-	log := logger.NewService(logFile)
+	// Create logger first, writing to the default log file unless one is given
+	homeDir, homeErr := os.UserHomeDir()
+	log := o.logger
+	if log == nil {
+		logFile := ""
+		if homeErr == nil {
+			logDir := filepath.Join(homeDir, ".luminary", "logs")
+			if err := os.MkdirAll(logDir, 0755); err == nil {
+				logFile = filepath.Join(logDir, "luminary.log")
+			}
+		}
+		log = logger.NewService(logFile)
+	}
 
 	// Create simplified services
 	networkClient := network.NewClient(log)
+	if o.httpClient != nil {
+		networkClient.SetHTTPClient(o.httpClient)
+	}
+	if o.rateLimits != nil {
+		networkClient.SetRateLimits(o.rateLimits)
+	}
 	parserService := parser.NewService(log)
 	downloadService := download.NewService(networkClient, log)
 
@@ -86,7 +213,65 @@ func New() *Engine {
 		Parser:    parserService,
 		Download:  downloadService,
 		Logger:    log,
+		Events:    events.NewBus(),
+		Cache:     cache.New(cache.DefaultMaxEntries, cache.DefaultMaxBytes),
 		providers: make(map[string]Provider),
+
+		formatter:      errors.NewCLIFormatter(),
+		debugFormatter: errors.NewDebugCLIFormatter(),
+	}
+
+	config := DefaultConfig()
+	if path, err := ConfigPath(); err == nil {
+		if config, err = LoadConfig(path); err != nil {
+			log.Warn("Using default settings: %v", err)
+		}
+	}
+	engine.ApplyConfig(config)
+
+	if homeErr == nil {
+		if extra, err := errors.ReadSuggestions(filepath.Join(homeDir, ".luminary", "suggestions.json")); err != nil {
+			log.Warn("Ignoring custom error suggestions: %v", err)
+		} else {
+			engine.formatter.MergeSuggestions(extra)
+			engine.debugFormatter.MergeSuggestions(extra)
+		}
+
+		engine.auditDir = filepath.Join(homeDir, ".luminary", "audit")
+
+		if err := networkClient.SetCooldownFile(filepath.Join(homeDir, ".luminary", "cooldowns.json")); err != nil {
+			log.Warn("Ignoring saved rate limit cooldowns: %v", err)
+		}
+
+		lib, err := library.Open(filepath.Join(homeDir, ".luminary", "library.jsonl"))
+		if err != nil {
+			log.Warn("Download history disabled: %v", err)
+		} else {
+			engine.Library = lib
+		}
+		if links, err := library.OpenLinks(filepath.Join(homeDir, ".luminary", "links.json")); err != nil {
+			log.Warn("Linked series disabled: %v", err)
+		} else {
+			engine.Links = links
+		}
+		if feed, err := library.OpenFeed(filepath.Join(homeDir, ".luminary", "feed.json")); err != nil {
+			log.Warn("Chapter feeds disabled: %v", err)
+		} else {
+			engine.Feed = feed
+		}
+		if queue, err := library.OpenQueue(filepath.Join(homeDir, ".luminary", "queue.json")); err != nil {
+			log.Warn("Download queue disabled: %v", err)
+		} else {
+			engine.Queue = queue
+		}
+	}
+
+	cacheDir := o.cacheDir
+	if cacheDir == "" && homeErr == nil {
+		cacheDir = filepath.Join(homeDir, ".luminary", "cache")
+	}
+	if cacheDir != "" {
+		engine.Snapshots = cache.NewSnapshots(cacheDir)
 	}
 
 	log.Info("Engine initialized successfully")
@@ -116,6 +301,43 @@ func (e *Engine) RegisterProvider(provider Provider) error {
 	return nil
 }
 
+// ReplaceProviders swaps all registered providers for the given ones at once, e.g. after
+// their definitions changed; calls already running finish with the providers they have.
+// It returns the IDs of the providers added and removed.
+func (e *Engine) ReplaceProviders(providers []Provider) (added, removed []string, err error) {
+	replacement := make(map[string]Provider, len(providers))
+	for _, provider := range providers {
+		id := provider.ID()
+		if id == "" {
+			return nil, nil, errors.Track(fmt.Errorf("provider has empty ID")).Error()
+		}
+		if _, exists := replacement[id]; exists {
+			return nil, nil, errors.Track(fmt.Errorf("provider with ID '%s' already registered", id)).Error()
+		}
+		replacement[id] = provider
+	}
+
+	e.providerMutex.Lock()
+	defer e.providerMutex.Unlock()
+
+	for id := range replacement {
+		if _, exists := e.providers[id]; !exists {
+			added = append(added, id)
+		}
+	}
+	for id := range e.providers {
+		if _, exists := replacement[id]; !exists {
+			removed = append(removed, id)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+
+	e.providers = replacement
+	e.Logger.Info("Replaced providers: %d registered, added %v, removed %v", len(replacement), added, removed)
+	return added, removed, nil
+}
+
 // GetProvider retrieves a registered provider by ID
 func (e *Engine) GetProvider(id string) (Provider, error) {
 	e.providerMutex.RLock()
@@ -137,7 +359,8 @@ func (e *Engine) GetProviderOrNil(id string) Provider {
 	return e.providers[id]
 }
 
-// AllProviders returns all registered providers
+// AllProviders returns all registered providers, highest priority first and by ID among
+// equal priorities
 func (e *Engine) AllProviders() []Provider {
 	e.providerMutex.RLock()
 	defer e.providerMutex.RUnlock()
@@ -146,9 +369,40 @@ func (e *Engine) AllProviders() []Provider {
 	for _, p := range e.providers {
 		providers = append(providers, p)
 	}
+	slices.SortFunc(providers, func(a, b Provider) int {
+		if pa, pb := e.providerSettings[a.ID()].Priority, e.providerSettings[b.ID()].Priority; pa != pb {
+			return cmp.Compare(pb, pa)
+		}
+		return strings.Compare(a.ID(), b.ID())
+	})
 	return providers
 }
 
+// SelectProviders returns the providers with the given IDs, in that order, or every
+// registered provider when no IDs are given
+func (e *Engine) SelectProviders(ids []string) ([]Provider, error) {
+	var providers []Provider
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		provider, err := e.GetProvider(id)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return e.AllProviders(), nil
+	}
+	return providers, nil
+}
+
 // ProviderExists checks if a provider exists
 func (e *Engine) ProviderExists(id string) bool {
 	e.providerMutex.RLock()
@@ -164,30 +418,189 @@ func (e *Engine) ProviderCount() int {
 	return len(e.providers)
 }
 
-// InitializeProviders initializes all registered providers
-func (e *Engine) InitializeProviders(ctx context.Context) error {
-	providers := e.AllProviders()
+// Log returns the engine logger scoped to the correlation ID carried by ctx
+func (e *Engine) Log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, e.Logger)
+}
 
-	for _, provider := range providers {
-		if err := provider.Initialize(ctx); err != nil {
-			e.Logger.Error("Failed to initialize provider %s: %v", provider.ID(), err)
-			// Continue with other providers
+// ChapterPageCount returns the number of pages of a chapter using the provider's
+// PageCounter capability, falling back to resolving the chapter's pages
+func (e *Engine) ChapterPageCount(ctx context.Context, provider Provider, chapterID string) (int, error) {
+	if counter, ok := provider.(PageCounter); ok {
+		return e.pageCountGuarded(ctx, provider, counter, chapterID)
+	}
+
+	chapter, err := e.GetChapter(ctx, provider, chapterID)
+	if err != nil {
+		return 0, err
+	}
+	return len(chapter.Pages), nil
+}
+
+// DownloadChapter downloads a chapter through its provider, records the outcome in the
+// library and publishes download events
+func (e *Engine) DownloadChapter(ctx context.Context, provider Provider, chapterID, destDir string) error {
+	_, err := e.DownloadChapterRecord(ctx, provider, chapterID, destDir)
+	return err
+}
+
+// DownloadChapterRecord is DownloadChapter, also returning the library record describing
+// the outcome, including pages missing from a partial download. With failover, a chapter
+// that can't be downloaded is downloaded from a linked series instead, see WithFailover.
+func (e *Engine) DownloadChapterRecord(ctx context.Context, provider Provider, chapterID, destDir string) (library.Record, error) {
+	record, err := e.downloadChapterRecord(ctx, provider, chapterID, destDir)
+	if err != nil && e.failingOver(ctx) {
+		return e.failover(ctx, record, err, destDir)
+	}
+	return record, err
+}
+
+// downloadChapterRecord downloads a chapter, records the outcome in the library and
+// publishes download events
+func (e *Engine) downloadChapterRecord(ctx context.Context, provider Provider, chapterID, destDir string) (library.Record, error) {
+	e.Events.Publish(events.DownloadStarted, map[string]interface{}{
+		"provider":   provider.ID(),
+		"chapter_id": chapterID,
+		"output_dir": destDir,
+	})
+
+	ctx, result := download.WithResult(ctx)
+	result.Provider = provider.ID()
+	if builder, ok := provider.(URLBuilder); ok {
+		result.SourceURL, _ = e.urlForGuarded(provider, builder, URLKindChapter, chapterID)
+	}
+
+	ctx = e.withRenumbering(ctx, provider)
+	ctx = download.WithProgress(ctx, func(p download.Progress) {
+		e.Events.Publish(events.DownloadProgress, map[string]interface{}{
+			"provider":        provider.ID(),
+			"chapter_id":      chapterID,
+			"pages":           p.Pages,
+			"pages_done":      p.Done,
+			"pages_failed":    p.Failed,
+			"bytes":           p.Bytes,
+			"remaining_bytes": p.RemainingBytes,
+			"bytes_per_sec":   int64(p.Speed),
+			"eta_seconds":     int64(p.ETA.Round(time.Second) / time.Second),
+		})
+	})
+
+	started := time.Now()
+	var audit *network.Audit
+	if e.auditing(ctx) {
+		ctx, audit = network.WithAudit(ctx)
+	}
+
+	chapterCtx, cancel := network.WithBudget(ctx, network.BudgetChapter, time.Duration(e.Timeouts().Chapter))
+	err := e.downloadGuarded(chapterCtx, provider, chapterID, destDir)
+	if budgetErr := network.BudgetError(chapterCtx, ctx); budgetErr != nil {
+		err = errors.Track(budgetErr).WithContext("chapter_id", chapterID).Error()
+	}
+	cancel()
+
+	record := library.Record{
+		Provider:  provider.ID(),
+		ChapterID: chapterID,
+		MangaID:   result.MangaID,
+		Title:     result.Info.Title,
+		Language:  result.Info.Language,
+		OutputDir: destDir,
+		Path:      result.Dir,
+		Storage:   result.Storage,
+		Pages:     result.Pages,
+		Bytes:     result.Bytes,
+		Status:    library.StatusCompleted,
+
+		FailoverFrom: failoverFrom(ctx),
+		Existing:     result.Existing,
+	}
+	// Providers with custom download logic may not report chapter details
+	if result.Info.ID != "" {
+		record.Chapter = result.Info.DisplayNumber()
+	}
+	// Retries go where the layout put the chapter
+	if result.OutputDir != "" {
+		record.OutputDir = result.OutputDir
+	}
+	// Sync, retries and verification may run from another directory, such as the daemon's
+	// or cron's, so local paths are kept absolute
+	if record.Storage == "" {
+		if abs, err := filepath.Abs(record.OutputDir); err == nil {
+			record.OutputDir = abs
+		}
+		if record.Path != "" {
+			if abs, err := filepath.Abs(record.Path); err == nil {
+				record.Path = abs
+			}
 		}
 	}
 
-	return nil
+	switch {
+	case download.IsUnavailable(err):
+		record.Status = library.StatusSkipped
+		record.Error = err.Error()
+	case err != nil:
+		record.Status = library.StatusFailed
+		record.Error = err.Error()
+	case len(result.FailedPages) > 0:
+		record.Status = library.StatusPartial
+		for _, failure := range result.FailedPages {
+			record.FailedPages = append(record.FailedPages, failure.Index+1)
+		}
+		record.Error = fmt.Sprintf("%d of %d pages failed, first: %v",
+			len(result.FailedPages), result.Pages+len(result.FailedPages), result.FailedPages[0].Err)
+	}
+
+	if audit != nil {
+		if path, auditErr := e.writeAudit(record, started, audit); auditErr != nil {
+			e.Log(ctx).Warn("Failed to write audit of %s:%s: %v", provider.ID(), chapterID, auditErr)
+		} else {
+			record.Audit = path
+		}
+	}
+
+	finished := map[string]interface{}{
+		"provider":   record.Provider,
+		"chapter_id": record.ChapterID,
+		"status":     string(record.Status),
+		"path":       record.Path,
+		"pages":      record.Pages,
+		"bytes":      record.Bytes,
+	}
+	if record.Error != "" {
+		finished["error"] = record.Error
+	}
+	if len(record.FailedPages) > 0 {
+		finished["failed_pages"] = record.FailedPages
+	}
+	if record.Audit != "" {
+		finished["audit"] = record.Audit
+	}
+	e.Events.Publish(events.DownloadFinished, finished)
+
+	if e.Library != nil {
+		if recErr := e.Library.Add(record); recErr != nil {
+			e.Log(ctx).Warn("Failed to record download of %s:%s: %v", provider.ID(), chapterID, recErr)
+		}
+	}
+
+	return record, err
 }
 
-// Shutdown gracefully shuts down the engine
+// Shutdown gracefully shuts down the engine. Calling it again does nothing.
 func (e *Engine) Shutdown() error {
-	e.Logger.Info("Shutting down engine...")
+	e.shutdownOnce.Do(func() {
+		e.Logger.Info("Shutting down engine...")
 
-	// Close logger
-	if closer, ok := e.Logger.(interface{ Close() error }); ok {
-		return closer.Close()
-	}
+		// Give crash reports a moment to reach the server
+		e.crashReporter().Flush(crashFlushTimeout)
 
-	return nil
+		// Close logger
+		if closer, ok := e.Logger.(interface{ Close() error }); ok {
+			e.shutdownErr = closer.Close()
+		}
+	})
+	return e.shutdownErr
 }
 
 // getProviderIDs returns a list of all provider IDs
@@ -199,6 +612,54 @@ func (e *Engine) getProviderIDs() []string {
 	return ids
 }
 
+// SetVersion records the Luminary version that crash reports are filed under
+func (e *Engine) SetVersion(version string) {
+	e.settingsMutex.Lock()
+	defer e.settingsMutex.Unlock()
+	e.version = version
+	e.crash.SetRelease(version)
+}
+
+// crashReporter returns the crash reporter in effect, nil when reporting is off
+func (e *Engine) crashReporter() *crash.Reporter {
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.crash
+}
+
+// ReportError uploads err as a crash report if it is a panic and crash reporting is
+// enabled; anything else is ignored, as is everything in offline mode
+func (e *Engine) ReportError(err error) {
+	if e.Offline() {
+		return
+	}
+	e.crashReporter().Report(err)
+}
+
+// RecoverPanic reports a panic of the calling goroutine and then lets it continue; use
+// it as "defer eng.RecoverPanic()"
+func (e *Engine) RecoverPanic() {
+	if r := recover(); r != nil {
+		e.ReportError(errors.Recovered(r))
+		e.crashReporter().Flush(crashFlushTimeout)
+		panic(r)
+	}
+}
+
+// SetOffline forbids network access, or allows it again. Offline, manga details come
+// from the snapshots saved by WarmCache and requests fail with network.ErrOffline.
+func (e *Engine) SetOffline(offline bool) {
+	e.Network.SetOffline(offline)
+	if offline {
+		e.Logger.Debug("Offline mode enabled")
+	}
+}
+
+// Offline reports whether network access is forbidden
+func (e *Engine) Offline() bool {
+	return e.Network.Offline()
+}
+
 // SetDebugMode enables or disables debug mode for error formatting
 func (e *Engine) SetDebugMode(enabled bool) {
 	e.debugMode = enabled
@@ -212,17 +673,31 @@ func (e *Engine) SetDebugMode(enabled bool) {
 	}
 }
 
+// SetJSONErrors makes FormatError write errors as a line of JSON, for wrappers that
+// parse failures
+func (e *Engine) SetJSONErrors(enabled bool) {
+	e.jsonErrors = enabled
+}
+
+// JSONErrors reports whether errors are formatted as JSON
+func (e *Engine) JSONErrors() bool {
+	return e.jsonErrors
+}
+
 // FormatError formats an error based on the current verbosity settings
 func (e *Engine) FormatError(err error) string {
 	if err == nil {
 		return ""
 	}
 
+	if e.jsonErrors {
+		return errors.FormatJSON(err)
+	}
 	if e.debugMode {
 		// When debug is enabled, show full tracked error with details
-		return errors.FormatCLIDebug(err)
+		return e.debugFormatter.Format(err)
 	} else {
 		// Default simple format
-		return errors.FormatCLISimple(err)
+		return e.formatter.FormatSimple(err)
 	}
 }
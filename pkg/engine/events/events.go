@@ -0,0 +1,118 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	DownloadStarted  = "download.started"
+	DownloadProgress = "download.progress"
+	DownloadFinished = "download.finished"
+	ProviderHealth   = "provider.health"
+	ChapterNew       = "chapter.new"
+)
+
+// Types lists every event type
+var Types = []string{DownloadStarted, DownloadProgress, DownloadFinished, ProviderHealth, ChapterNew}
+
+// subscriberBuffer is how many events a subscriber may lag behind before events are dropped
+const subscriberBuffer = 64
+
+// Event is something that happened in the engine
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Bus fans events out to subscribers
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]*subscriber
+	nextID int
+}
+
+type subscriber struct {
+	ch    chan Event
+	types map[string]bool // Empty for all types
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]*subscriber)}
+}
+
+// Publish sends an event to every subscriber interested in its type. It never blocks:
+// subscribers that fall too far behind miss events.
+func (b *Bus) Publish(eventType string, data map[string]interface{}) {
+	event := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if len(sub.types) > 0 && !sub.types[eventType] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events of the given types (all when none are
+// given) and a function ending the subscription, which closes the channel
+func (b *Bus) Subscribe(types ...string) (<-chan Event, func()) {
+	sub := &subscriber{
+		ch:    make(chan Event, subscriberBuffer),
+		types: make(map[string]bool),
+	}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// IsType reports whether t is a known event type
+func IsType(t string) bool {
+	for _, known := range Types {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
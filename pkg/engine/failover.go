@@ -0,0 +1,207 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"net"
+	"slices"
+	"strings"
+)
+
+type failoverKey struct{}
+
+type failoverFromKey struct{}
+
+type chapterHintKey struct{}
+
+// chapterHint is what a caller already knows of a chapter it downloads, so failover
+// finds it elsewhere even if the provider can't tell anymore
+type chapterHint struct {
+	mangaID string
+	chapter core.ChapterInfo
+}
+
+// withChapterHint returns a context telling failover the manga and details of the
+// chapter downloaded with it
+func withChapterHint(ctx context.Context, mangaID string, chapter core.ChapterInfo) context.Context {
+	return context.WithValue(ctx, chapterHintKey{}, chapterHint{mangaID: mangaID, chapter: chapter})
+}
+
+// WithFailover asks for chapters that can't be downloaded with ctx to be downloaded from a
+// series linked to theirs on another provider, see Links
+func WithFailover(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failoverKey{}, true)
+}
+
+// failingOver reports whether failed downloads made with ctx fail over, either asked for
+// by WithFailover or for all downloads by the config
+func (e *Engine) failingOver(ctx context.Context) bool {
+	if e.Links == nil {
+		return false
+	}
+	if requested, _ := ctx.Value(failoverKey{}).(bool); requested {
+		return true
+	}
+	e.settingsMutex.RLock()
+	defer e.settingsMutex.RUnlock()
+	return e.failoverAll
+}
+
+// failoverFrom returns the provider:chapter-id a download made with ctx stands in for, or ""
+func failoverFrom(ctx context.Context) string {
+	from, _ := ctx.Value(failoverFromKey{}).(string)
+	return from
+}
+
+// failover downloads the chapter of a failed record from the series linked to its manga,
+// best provider first, and returns the record of the first that succeeds. Without one,
+// the failed record and its error are returned.
+func (e *Engine) failover(ctx context.Context, failed library.Record, err error, destDir string) (library.Record, error) {
+	// Only a chapter its provider won't serve is fetched elsewhere; anything else may work
+	// on a retry, or would fail the same way anywhere else
+	if ctx.Err() != nil || !permanentFailure(err) {
+		e.Log(ctx).Debug("No failover for %s:%s: %v", failed.Provider, failed.ChapterID, err)
+		return failed, err
+	}
+	if failed.Status != library.StatusFailed && failed.Status != library.StatusSkipped {
+		return failed, err
+	}
+	// The chapter is found on the other providers by its number
+	e.completeFailedRecord(ctx, &failed)
+	if failed.MangaID == "" || failed.Chapter == "" {
+		e.Log(ctx).Debug("No failover for %s:%s: its manga or number is unknown", failed.Provider, failed.ChapterID)
+		return failed, err
+	}
+
+	linked, linkErr := e.Links.Equivalents(failed.Provider + ":" + failed.MangaID)
+	if linkErr != nil {
+		e.Log(ctx).Warn("No failover for %s:%s: %v", failed.Provider, failed.ChapterID, linkErr)
+		return failed, err
+	}
+	slices.SortStableFunc(linked, func(a, b string) int {
+		providerA, _, _ := strings.Cut(a, ":")
+		providerB, _, _ := strings.Cut(b, ":")
+		return e.ProviderSettings(providerB).Priority - e.ProviderSettings(providerA).Priority
+	})
+
+	failoverCtx := context.WithValue(ctx, failoverFromKey{}, failed.Provider+":"+failed.ChapterID)
+	for _, id := range linked {
+		providerID, mangaID, _ := strings.Cut(id, ":")
+		provider, providerErr := e.GetProvider(providerID)
+		if providerErr != nil {
+			continue
+		}
+		chapters, chaptersErr := e.Chapters(ctx, provider, mangaID, ChapterFilter{})
+		if chaptersErr != nil {
+			e.Log(ctx).Debug("Failover to %s skipped: %v", id, chaptersErr)
+			continue
+		}
+		chapter, ok := matchFailoverChapter(chapters, failed.Chapter, failed.Language)
+		if !ok {
+			continue
+		}
+
+		e.Log(ctx).Info("Chapter %s of %s:%s failed, downloading it from %s:%s instead",
+			failed.Chapter, failed.Provider, failed.MangaID, providerID, chapter.ID)
+		record, downloadErr := e.downloadChapterRecord(failoverCtx, provider, chapter.ID, destDir)
+		if downloadErr == nil {
+			return record, nil
+		}
+		if ctx.Err() != nil || errors.Is(downloadErr, download.ErrQuotaExceeded) {
+			return record, downloadErr
+		}
+	}
+	return failed, err
+}
+
+// permanentFailure reports whether err says a provider won't serve a chapter, rather than
+// failing to for now: the chapter is unavailable or missing, access to it is refused, or
+// the provider rejected it without a network failure or server error
+func permanentFailure(err error) bool {
+	if errors.Is(err, download.ErrQuotaExceeded) || errors.Is(err, network.ErrRequestBudget) ||
+		errors.Is(err, network.ErrCoolingDown) || errors.Is(err, network.ErrOffline) {
+		return false
+	}
+	if download.IsUnavailable(err) || errors.IsNotFound(err) || errors.IsForbidden(err) {
+		return true
+	}
+
+	var tracked *errors.TrackedError
+	if !errors.As(err, &tracked) || tracked.Category != errors.CategoryProvider {
+		return false
+	}
+	// Providers also wrap failed requests, which a retry may get through
+	var netErr net.Error
+	status, _ := tracked.Context["status_code"].(int)
+	return !errors.As(err, &netErr) && status < 500 && status != 408 && status != 429
+}
+
+// completeFailedRecord fills in the manga, number and language of a chapter that failed
+// before its provider described it, from the caller's hint or earlier downloads
+func (e *Engine) completeFailedRecord(ctx context.Context, failed *library.Record) {
+	if hint, ok := ctx.Value(chapterHintKey{}).(chapterHint); ok && hint.chapter.ID == failed.ChapterID {
+		if failed.MangaID == "" {
+			failed.MangaID = hint.mangaID
+		}
+		if failed.Chapter == "" {
+			failed.Chapter = hint.chapter.DisplayNumber()
+		}
+		if failed.Language == "" {
+			failed.Language = hint.chapter.Language
+		}
+	}
+	if (failed.MangaID != "" && failed.Chapter != "") || e.Library == nil {
+		return
+	}
+
+	records, err := e.Library.Records(library.Filter{Provider: failed.Provider})
+	if err != nil {
+		return
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.ChapterID != failed.ChapterID || r.MangaID == "" || r.Chapter == "" {
+			continue
+		}
+		failed.MangaID, failed.Chapter = r.MangaID, r.Chapter
+		if failed.Language == "" {
+			failed.Language = r.Language
+		}
+		return
+	}
+}
+
+// matchFailoverChapter finds the chapter with the given display number, in the language
+// of the failed one when it is known
+func matchFailoverChapter(chapters []core.ChapterInfo, number, language string) (core.ChapterInfo, bool) {
+	for _, chapter := range chapters {
+		if chapter.DisplayNumber() != number || chapter.ExternalURL != "" {
+			continue
+		}
+		if language != "" && chapter.Language != "" && !strings.EqualFold(chapter.Language, language) {
+			continue
+		}
+		return chapter, true
+	}
+	return core.ChapterInfo{}, false
+}
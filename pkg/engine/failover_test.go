@@ -0,0 +1,127 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newFailoverEngine returns an engine with failover on and two linked providers listing
+// chapter 1 of a series: "one", whose chapter fails with chapterErr, and "two"
+func newFailoverEngine(t *testing.T, chapterErr error) *engine.Engine {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	t.Cleanup(images.Close)
+
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	for _, id := range []string{"one", "two"} {
+		provider := base.New(e, base.Config{ID: id, Name: id, SiteURL: "https://example.com", Type: base.TypeWeb}).
+			WithGetManga(func(_ context.Context, mangaID string) (*core.MangaInfo, error) {
+				return &core.MangaInfo{
+					Manga:    core.Manga{ID: mangaID, Title: "Series"},
+					Chapters: []core.ChapterInfo{{ID: id + "-ch1", Number: 1, Language: "en"}},
+				}, nil
+			}).
+			WithGetChapter(func(_ context.Context, chapterID string) (*core.Chapter, error) {
+				if id == "one" {
+					return nil, chapterErr
+				}
+				return &core.Chapter{
+					Info:    core.ChapterInfo{ID: chapterID, Number: 1, Language: "en"},
+					MangaID: "series",
+					Pages:   []core.Page{{Index: 0, URL: images.URL + "/page.png"}},
+				}, nil
+			}).
+			Build()
+		if err := e.RegisterProvider(provider); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if e.Links == nil || e.Library == nil {
+		t.Fatal("no linked series or download history")
+	}
+	if err := e.Links.Link("one:series", "two:series"); err != nil {
+		t.Fatal(err)
+	}
+	err := e.Library.Add(library.Record{
+		Time:      time.Now(),
+		Provider:  "one",
+		MangaID:   "series",
+		ChapterID: "one-ch1",
+		Chapter:   "1",
+		Language:  "en",
+		Status:    library.StatusFailed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestFailoverOnlyOnPermanentFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		failover bool
+	}{
+		{"unavailable", download.Unavailable("one", "one-ch1", download.ReasonLicensed), true},
+		{"not found", errors.New("chapter not found").AsNotFound().Error(), true},
+		{"rejected", errors.New("chapter ID is malformed").AsProvider("one").Error(), true},
+		{"server error", errors.New("bad gateway").WithHTTPContext("GET", "https://example.com", 502).AsProvider("one").Error(), false},
+		{"rate limited", errors.New("too many requests").WithHTTPContext("GET", "https://example.com", 429).AsRateLimit().Error(), false},
+		{"network", errors.Track(fmt.Errorf("connection reset")).AsNetwork().Error(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newFailoverEngine(t, tt.err)
+			provider, err := e.GetProvider("one")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := engine.WithFailover(context.Background())
+			record, err := e.DownloadChapterRecord(ctx, provider, "one-ch1", t.TempDir())
+			if tt.failover {
+				if err != nil || record.Provider != "two" {
+					t.Errorf("got a %s record and %v, want the chapter from two", record.Provider, err)
+				}
+				return
+			}
+			if err == nil || record.Provider != "one" {
+				t.Errorf("got a %s record and %v, want the failure without failover", record.Provider, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+)
+
+// CheckFeed fetches the details of every series in the library again and adds the
+// chapters in the preferred languages that appeared since the last check to the feed,
+// announcing each as a chapter.new event. Returns the new chapters; a series that fails
+// doesn't stop the others.
+func (e *Engine) CheckFeed(ctx context.Context) ([]library.FeedItem, error) {
+	if e.Library == nil || e.Feed == nil {
+		return nil, errors.New("download history is not available").
+			WithMessage("Feeds need the download history in ~/.luminary to know which series you follow").
+			AsFileSystem().Error()
+	}
+
+	series, err := e.Library.Series()
+	if err != nil {
+		return nil, err
+	}
+
+	var added []library.FeedItem
+	followed := make([]string, 0, len(series))
+	for _, s := range series {
+		followed = append(followed, s.Provider+":"+s.MangaID)
+	}
+	for _, s := range series {
+		if err := ctx.Err(); err != nil {
+			return added, errors.Track(err).WithMessage("Feed check cancelled").Error()
+		}
+
+		provider, err := e.GetProvider(s.Provider)
+		if err != nil {
+			continue
+		}
+		info, err := e.GetManga(cache.Refresh(ctx), provider, s.MangaID)
+		if err != nil {
+			e.Log(ctx).Warn("Failed to check %s:%s for new chapters: %v", s.Provider, s.MangaID, err)
+			continue
+		}
+
+		// The chapters sync would download, so both report the same ones as new
+		chapters := syncChapters(info.Chapters, SyncOptions{}, e.PreferredLanguages())
+		items := make([]library.FeedItem, 0, len(chapters))
+		for _, chapter := range chapters {
+			items = append(items, e.feedItem(provider, info, chapter))
+		}
+
+		found, err := e.Feed.Update(s.Provider, s.MangaID, items)
+		if err != nil {
+			return added, err
+		}
+		if len(found) > 0 {
+			e.Log(ctx).Info("Found %d new chapters of %s:%s", len(found), s.Provider, s.MangaID)
+		}
+		for _, item := range found {
+			e.publishNewChapter(item, "feed")
+		}
+		added = append(added, found...)
+	}
+	// Series no longer followed don't need their chapters remembered
+	return added, e.Feed.Retain(followed)
+}
+
+// feedItem describes a chapter of a manga for the feed
+func (e *Engine) feedItem(provider Provider, info *core.MangaInfo, chapter core.ChapterInfo) library.FeedItem {
+	item := library.FeedItem{
+		Provider:   provider.ID(),
+		MangaID:    info.ID,
+		MangaTitle: info.Title,
+		ChapterID:  chapter.ID,
+		Chapter:    chapter.DisplayNumber(),
+		Title:      chapter.Title,
+		Language:   chapter.Language,
+		Group:      chapter.Group,
+		URL:        chapter.ExternalURL,
+	}
+	if chapter.Date != nil {
+		item.Published = *chapter.Date
+	}
+	if builder, ok := provider.(URLBuilder); ok {
+		item.MangaURL, _ = e.urlForGuarded(provider, builder, URLKindManga, info.ID)
+		if item.URL == "" {
+			item.URL, _ = e.urlForGuarded(provider, builder, URLKindChapter, chapter.ID)
+		}
+	}
+	return item
+}
@@ -0,0 +1,144 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"context"
+	"net/url"
+)
+
+// recoverProvider turns a panic in a provider method into a panic error tied to the
+// provider, so one broken provider can't take down a search across all of them or the
+// RPC daemon. It must be deferred directly: defer e.recoverProvider(id, "Search", &err)
+func (e *Engine) recoverProvider(providerID, method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	*err = errors.Track(errors.Recovered(r)).
+		WithContext("provider_id", providerID).
+		WithContext("method", method).
+		WithMessagef("Provider '%s' crashed in %s: %v", providerID, method, r).
+		AsPanic().Error()
+	e.Logger.Error("Provider %s panicked in %s: %v", providerID, method, r)
+	e.ReportError(*err)
+}
+
+// GetManga returns the details of a manga, isolating panics of the provider
+func (e *Engine) GetManga(ctx context.Context, provider Provider, mangaID string) (info *core.MangaInfo, err error) {
+	ctx, release, err := e.acquireProvider(ctx, provider.ID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer e.recoverProvider(provider.ID(), "GetManga", &err)
+	info, err = provider.GetManga(ctx, mangaID)
+	return e.cleanMangaInfo(provider.ID(), info), err
+}
+
+// GetChapter returns a chapter and its pages, isolating panics of the provider
+func (e *Engine) GetChapter(ctx context.Context, provider Provider, chapterID string) (chapter *core.Chapter, err error) {
+	ctx, release, err := e.acquireProvider(ctx, provider.ID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer e.recoverProvider(provider.ID(), "GetChapter", &err)
+	return provider.GetChapter(ctx, chapterID)
+}
+
+// GetTags returns the tags of a provider's catalogue, isolating panics of the provider
+func (e *Engine) GetTags(ctx context.Context, browser TagBrowser, providerID string) (tags []core.Tag, err error) {
+	ctx, release, err := e.acquireProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer e.recoverProvider(providerID, "GetTags", &err)
+	return browser.GetTags(ctx)
+}
+
+// searchGuarded runs a provider search, isolating its panics
+func (e *Engine) searchGuarded(ctx context.Context, provider Provider, query string, options core.SearchOptions) (results []core.Manga, err error) {
+	ctx, release, err := e.acquireProvider(ctx, provider.ID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer e.recoverProvider(provider.ID(), "Search", &err)
+	results, err = provider.Search(ctx, query, options)
+	return e.cleanResults(provider.ID(), results), err
+}
+
+// browseGuarded lists the manga of a tag, isolating panics of the provider
+func (e *Engine) browseGuarded(ctx context.Context, browser TagBrowser, providerID, tagID string, options core.SearchOptions) (results []core.Manga, err error) {
+	ctx, release, err := e.acquireProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer e.recoverProvider(providerID, "BrowseTag", &err)
+	results, err = browser.BrowseTag(ctx, tagID, options)
+	return e.cleanResults(providerID, results), err
+}
+
+// pageCountGuarded asks a PageCounter for the pages of a chapter, isolating its panics
+func (e *Engine) pageCountGuarded(ctx context.Context, provider Provider, counter PageCounter, chapterID string) (count int, err error) {
+	ctx, release, err := e.acquireProvider(ctx, provider.ID())
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	defer e.recoverProvider(provider.ID(), "GetChapterPageCount", &err)
+	return counter.GetChapterPageCount(ctx, chapterID)
+}
+
+// downloadGuarded downloads a chapter through its provider, isolating its panics
+func (e *Engine) downloadGuarded(ctx context.Context, provider Provider, chapterID, destDir string) (err error) {
+	defer e.recoverProvider(provider.ID(), "DownloadChapter", &err)
+	if err := e.ensureInitialized(ctx, provider); err != nil {
+		return err
+	}
+	return provider.DownloadChapter(e.providerContext(ctx, provider.ID()), chapterID, destDir)
+}
+
+// initializeGuarded initializes a provider, isolating its panics
+func (e *Engine) initializeGuarded(ctx context.Context, provider Provider) (err error) {
+	defer e.recoverProvider(provider.ID(), "Initialize", &err)
+	return provider.Initialize(ctx)
+}
+
+// resolveMangaGuarded maps a manga URL to its ID, isolating panics of the provider
+func (e *Engine) resolveMangaGuarded(provider Provider, resolver MangaURLResolver, u *url.URL) (id string, err error) {
+	defer e.recoverProvider(provider.ID(), "ResolveMangaURL", &err)
+	return resolver.ResolveMangaURL(u)
+}
+
+// urlForGuarded builds the site URL of a manga or chapter, isolating panics of the provider
+func (e *Engine) urlForGuarded(provider Provider, builder URLBuilder, kind, id string) (u string, err error) {
+	defer e.recoverProvider(provider.ID(), "URLFor", &err)
+	return builder.URLFor(kind, id)
+}
+
+// resolveChapterGuarded maps a chapter URL to its ID, isolating panics of the provider
+func (e *Engine) resolveChapterGuarded(provider Provider, resolver URLResolver, u *url.URL) (id string, err error) {
+	defer e.recoverProvider(provider.ID(), "ResolveChapterURL", &err)
+	return resolver.ResolveChapterURL(u)
+}
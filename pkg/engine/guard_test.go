@@ -0,0 +1,121 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"context"
+	"testing"
+)
+
+// newPanickingProvider returns a provider panicking in every method it implements
+func newPanickingProvider(e *engine.Engine, id string) engine.Provider {
+	return base.New(e, base.Config{ID: id, Name: id, SiteURL: "https://example.com", Type: base.TypeWeb}).
+		WithSearch(func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+			panic("search broke")
+		}).
+		WithGetManga(func(context.Context, string) (*core.MangaInfo, error) {
+			panic("manga broke")
+		}).
+		WithGetChapter(func(context.Context, string) (*core.Chapter, error) {
+			panic("chapter broke")
+		}).
+		WithGetTags(func(context.Context) ([]core.Tag, error) {
+			panic("tags broke")
+		}).
+		Build()
+}
+
+// altTitlesPanicking is a healthy provider whose SearchesAltTitles panics, which the
+// engine asks outside the provider's Search
+type altTitlesPanicking struct {
+	engine.Provider
+}
+
+func (altTitlesPanicking) SearchesAltTitles() bool { panic("alt titles broke") }
+
+// assertPanicError fails unless err is a panic error naming the provider
+func assertPanicError(t *testing.T, method string, err error) {
+	t.Helper()
+	var tracked *errors.TrackedError
+	if !errors.As(err, &tracked) || tracked.Category != errors.CategoryPanic {
+		t.Errorf("%s: got %v, want a panic error", method, err)
+		return
+	}
+	if tracked.Context["provider_id"] != "broken" {
+		t.Errorf("%s: context %v, want the provider", method, tracked.Context)
+	}
+}
+
+func TestGuardsIsolateProviderPanics(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	provider := newPanickingProvider(e, "broken")
+	ctx := context.Background()
+
+	_, err := e.Search(ctx, provider, "query", core.SearchOptions{})
+	assertPanicError(t, "Search", err)
+	_, err = e.GetManga(ctx, provider, "manga")
+	assertPanicError(t, "GetManga", err)
+	_, err = e.GetChapter(ctx, provider, "chapter")
+	assertPanicError(t, "GetChapter", err)
+
+	browser, ok := provider.(engine.TagBrowser)
+	if !ok {
+		t.Fatal("the provider doesn't browse tags")
+	}
+	_, err = e.GetTags(ctx, browser, provider.ID())
+	assertPanicError(t, "GetTags", err)
+}
+
+func TestSearchAllIsolatesPanics(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	searching := func(id string) engine.Provider {
+		return base.New(e, base.Config{ID: id, Name: id, SiteURL: "https://example.com", Type: base.TypeWeb}).
+			WithSearch(func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+				return []core.Manga{{ID: "a", Title: "Blue Lock"}}, nil
+			}).
+			Build()
+	}
+	healthy := searching("healthy")
+
+	tests := []struct {
+		name    string
+		broken  engine.Provider
+		options core.SearchOptions
+	}{
+		{"in the provider's search", newPanickingProvider(e, "broken"), core.SearchOptions{}},
+		{"around the provider's search", altTitlesPanicking{searching("broken")}, core.SearchOptions{IncludeAltTitles: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(map[string]engine.SearchResult)
+			for r := range e.SearchAll(context.Background(), []engine.Provider{tt.broken, healthy}, "blue lock", tt.options) {
+				results[r.Provider.ID()] = r
+			}
+			assertPanicError(t, "SearchAll", results["broken"].Err)
+			if r := results["healthy"]; r.Err != nil || len(r.Results) != 1 {
+				t.Errorf("healthy provider: %d results and %v", len(r.Results), r.Err)
+			}
+		})
+	}
+}
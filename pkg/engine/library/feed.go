@@ -0,0 +1,235 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"Luminary/pkg/errors"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxFeedItems is how many of the newest feed items are kept
+const MaxFeedItems = 500
+
+// feedMemory is how long the feed remembers chapters a series stopped listing and
+// chapters it announced
+const feedMemory = 90 * 24 * time.Hour
+
+// FeedItem is a chapter that appeared on a followed series
+type FeedItem struct {
+	Detected   time.Time `json:"detected"`
+	Provider   string    `json:"provider"`
+	MangaID    string    `json:"manga_id"`
+	MangaTitle string    `json:"manga_title,omitempty"`
+	MangaURL   string    `json:"manga_url,omitempty"`
+	ChapterID  string    `json:"chapter_id"`
+	Chapter    string    `json:"chapter,omitempty"` // Display number or label
+	Title      string    `json:"title,omitempty"`
+	Language   string    `json:"language,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	Published  time.Time `json:"published"` // Release date the provider gives, if any
+}
+
+// Feed remembers the chapters seen on followed series and keeps the ones that appeared
+// since, newest first, for feed readers
+type Feed struct {
+	path string
+	mu   sync.Mutex
+}
+
+// feedState is the content of the feed file
+type feedState struct {
+	Seen      map[string]map[string]time.Time `json:"seen"`      // When each chapter ID was last listed, by provider:manga-id
+	Announced map[string]time.Time            `json:"announced"` // When each provider:chapter-id was announced as new
+	Items     []FeedItem                      `json:"items"`
+}
+
+// OpenFeed returns the feed stored at path, creating its directory if needed
+func OpenFeed(path string) (*Feed, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			AsFileSystem().
+			Error()
+	}
+	return &Feed{path: path}, nil
+}
+
+// Path returns the location of the feed file
+func (f *Feed) Path() string {
+	return f.path
+}
+
+// Update records the chapters a series lists now and returns those it didn't list before,
+// which become feed items. The first update of a series only remembers its chapters, so
+// following a series doesn't flood the feed with its back catalogue. Chapters the series
+// stopped listing are forgotten after a while.
+func (f *Feed) Update(provider, mangaID string, chapters []FeedItem) ([]FeedItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	key := provider + ":" + mangaID
+	seen, known := state.Seen[key]
+	if seen == nil {
+		seen = make(map[string]time.Time, len(chapters))
+	}
+
+	var added []FeedItem
+	now := time.Now()
+	changed := !known
+	for _, item := range chapters {
+		last, ok := seen[item.ChapterID]
+		if !ok && known {
+			item.Detected = now
+			added = append(added, item)
+		}
+		// Listing times only need to be rough, so they don't rewrite the file every check
+		if !ok || now.Sub(last) > 24*time.Hour {
+			seen[item.ChapterID] = now
+			changed = true
+		}
+	}
+	for id, last := range seen {
+		if now.Sub(last) > feedMemory {
+			delete(seen, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	// Later chapters go first, like items detected later
+	state.Seen[key] = seen
+	newest := slices.Clone(added)
+	slices.Reverse(newest)
+	state.Items = append(newest, state.Items...)
+	if len(state.Items) > MaxFeedItems {
+		state.Items = state.Items[:MaxFeedItems]
+	}
+	return added, f.save(state)
+}
+
+// Announce reports whether a chapter is announced as new for the first time, and remembers
+// it was, so checks that find it separately announce it once
+func (f *Feed) Announce(provider, chapterID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, err := f.load()
+	if err != nil {
+		return false, err
+	}
+
+	key := provider + ":" + chapterID
+	if _, ok := state.Announced[key]; ok {
+		return false, nil
+	}
+	now := time.Now()
+	for id, announced := range state.Announced {
+		if now.Sub(announced) > feedMemory {
+			delete(state.Announced, id)
+		}
+	}
+	state.Announced[key] = now
+	return true, f.save(state)
+}
+
+// Retain forgets the chapters seen on every series but the given provider:manga-id ones,
+// the series still followed
+func (f *Feed) Retain(series []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for key := range state.Seen {
+		if !slices.Contains(series, key) {
+			delete(state.Seen, key)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return f.save(state)
+}
+
+// Items returns the feed items, newest first; with provider and mangaID set only those of
+// that series
+func (f *Feed) Items(provider, mangaID string) ([]FeedItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	items := state.Items[:0:0]
+	for _, item := range state.Items {
+		if provider != "" && (item.Provider != provider || item.MangaID != mangaID) {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// load reads the feed; a missing file is an empty one
+func (f *Feed) load() (*feedState, error) {
+	state := &feedState{Seen: make(map[string]map[string]time.Time), Announced: make(map[string]time.Time)}
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", f.path).AsFileSystem().Error()
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", f.path).
+			WithMessage("The feed file is not valid JSON").
+			AsParser().Error()
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]map[string]time.Time)
+	}
+	if state.Announced == nil {
+		state.Announced = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// save replaces the feed
+func (f *Feed) save(state *feedState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Track(err).Error()
+	}
+	return writeFile(f.path, data)
+}
@@ -0,0 +1,86 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFeedForgetsOldChapters(t *testing.T) {
+	feed, err := OpenFeed(filepath.Join(t.TempDir(), "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := func(ids ...string) []FeedItem {
+		var items []FeedItem
+		for _, id := range ids {
+			items = append(items, FeedItem{Provider: "tst", MangaID: "a", ChapterID: id})
+		}
+		return items
+	}
+
+	if added, err := feed.Update("tst", "a", items("c1", "c2")); err != nil || len(added) != 0 {
+		t.Fatalf("first update added %v, %v; want nothing", added, err)
+	}
+	if _, err := feed.Update("tst", "b", items("c9")); err != nil {
+		t.Fatal(err)
+	}
+
+	// c1 was last listed long ago, so listing it again makes it new
+	state, err := feed.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Seen["tst:a"]["c1"] = time.Now().Add(-feedMemory - time.Hour)
+	if err := feed.save(state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feed.Update("tst", "a", items("c2")); err != nil {
+		t.Fatal(err)
+	}
+	added, err := feed.Update("tst", "a", items("c1", "c2"))
+	if err != nil || len(added) != 1 || added[0].ChapterID != "c1" {
+		t.Errorf("added %v, %v; want c1", added, err)
+	}
+
+	if err := feed.Retain([]string{"tst:a"}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = feed.load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Seen["tst:b"]; ok || len(state.Seen["tst:a"]) != 2 {
+		t.Errorf("seen = %v, want only tst:a", state.Seen)
+	}
+}
+
+func TestFeedAnnouncesOnce(t *testing.T) {
+	feed, err := OpenFeed(filepath.Join(t.TempDir(), "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false} {
+		if first, err := feed.Announce("tst", "c1"); err != nil || first != want {
+			t.Errorf("announcement %d = %v, %v; want %v", i+1, first, err, want)
+		}
+	}
+	if first, err := feed.Announce("other", "c1"); err != nil || !first {
+		t.Errorf("another provider's chapter = %v, %v; want a first announcement", first, err)
+	}
+}
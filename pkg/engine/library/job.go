@@ -0,0 +1,170 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"Luminary/pkg/errors"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jobFormat names job files, so other JSON isn't mistaken for one
+const jobFormat = "luminary-job"
+
+// jobSignaturePrefix names the signing scheme in front of the signature
+const jobSignaturePrefix = "hmac-sha256:"
+
+// Job is a set of chapters to download, prepared on one machine and run on another
+type Job struct {
+	Created time.Time `json:"created"`
+	// Host is the machine the job was exported on
+	Host     string     `json:"host,omitempty"`
+	Chapters []string   `json:"chapters"`
+	Options  JobOptions `json:"options"`
+}
+
+// JobOptions are the download settings a job carries; the output directory is left to
+// the machine running it
+type JobOptions struct {
+	Format    string `json:"format,omitempty"`
+	Process   string `json:"process,omitempty"`
+	Layout    string `json:"layout,omitempty"`
+	Collision string `json:"collision,omitempty"`
+	Numbering string `json:"numbering,omitempty"`
+}
+
+// jobFile is the signed envelope written to disk. The signature covers the compact JSON
+// of the job, so the job is kept raw and compacted again before it is checked.
+type jobFile struct {
+	Format    string          `json:"format"`
+	Version   int             `json:"version"`
+	Job       json.RawMessage `json:"job"`
+	Signature string          `json:"signature"`
+}
+
+// EncodeJob signs a job with key and returns the job file
+func EncodeJob(job Job, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("no job key").AsAuth().Error()
+	}
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return nil, errors.Track(err).Error()
+	}
+	data, err := json.MarshalIndent(jobFile{
+		Format:    jobFormat,
+		Version:   1,
+		Job:       payload,
+		Signature: jobSignaturePrefix + hex.EncodeToString(signJob(payload, key)),
+	}, "", "  ")
+	if err != nil {
+		return nil, errors.Track(err).Error()
+	}
+	return append(data, '\n'), nil
+}
+
+// DecodeJob reads a job file, checking that it was signed with key
+func DecodeJob(data []byte, key []byte) (*Job, error) {
+	var file jobFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Format != jobFormat {
+		return nil, errors.New("not a job file").
+			WithMessage("The file is not a Luminary job file; create one with 'luminary queue export'").
+			AsParser().Error()
+	}
+	if file.Version != 1 {
+		return nil, errors.Newf("unsupported job file version %d", file.Version).
+			WithMessage("The job file was written by a newer Luminary; update it on this machine").
+			AsParser().Error()
+	}
+
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, file.Job); err != nil {
+		return nil, errors.Track(err).WithMessage("The job in the job file is not valid").AsParser().Error()
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(file.Signature, jobSignaturePrefix))
+	if err != nil || !strings.HasPrefix(file.Signature, jobSignaturePrefix) || !hmac.Equal(signature, signJob(payload.Bytes(), key)) {
+		return nil, errors.New("job file signature does not match").
+			WithMessage("The job file was changed or signed with another key; copy ~/.luminary/job.key from the machine that exported it, or set LUMINARY_JOB_KEY on both").
+			AsAuth().Error()
+	}
+
+	var job Job
+	if err := json.Unmarshal(file.Job, &job); err != nil {
+		return nil, errors.Track(err).WithMessage("The job in the job file is not valid").AsParser().Error()
+	}
+	return &job, nil
+}
+
+// signJob returns the HMAC-SHA256 of a job payload
+func signJob(payload, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// JobKeyPath returns the location of the key job files are signed with, ~/.luminary/job.key
+func JobKeyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Track(err).AsFileSystem().Error()
+	}
+	return filepath.Join(home, ".luminary", "job.key"), nil
+}
+
+// LoadJobKey reads the key at path. A missing key is generated when create is set, so
+// the first export works without setup; the file is then copied to the machines running
+// the jobs.
+func LoadJobKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key := bytes.TrimSpace(data)
+		if len(key) == 0 {
+			return nil, errors.New("job key is empty").WithContext("path", path).AsAuth().Error()
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	if !create {
+		return nil, errors.New("no job key").
+			WithContext("path", path).
+			WithMessagef("Job files are checked with the key in %s; copy it from the machine that exported the job, or set LUMINARY_JOB_KEY", path).
+			AsAuth().Error()
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Track(err).Error()
+	}
+	key := []byte(hex.EncodeToString(secret))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	if err := os.WriteFile(path, append(key, '\n'), 0600); err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return key, nil
+}
@@ -0,0 +1,78 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJobFiles(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	job := Job{
+		Created:  time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Host:     "desk",
+		Chapters: []string{"mgd:chapter-456", "https://example.com/chapter/457"},
+		Options:  JobOptions{Format: "png", Process: "cbz", Numbering: "absolute"},
+	}
+	data, err := EncodeJob(job, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		key     []byte
+		wantErr bool
+	}{
+		{"round trip", data, key, false},
+		{"reformatted", bytes.ReplaceAll(data, []byte("\n  "), []byte("\n\t")), key, false},
+		{"changed chapter", bytes.Replace(data, []byte("chapter-456"), []byte("chapter-999"), 1), key, true},
+		{"changed option", bytes.Replace(data, []byte(`"cbz"`), []byte(`"pdf"`), 1), key, true},
+		{"changed signature", bytes.Replace(data, []byte("hmac-sha256:"), []byte("hmac-sha256:00"), 1), key, true},
+		{"unsigned", bytes.Replace(data, []byte("hmac-sha256:"), []byte(""), 1), key, true},
+		{"wrong key", data, []byte("fedcba9876543210"), true},
+		{"no key", data, nil, true},
+		{"not a job file", []byte(`{"chapters": []}`), key, true},
+		{"not JSON", []byte("job"), key, true},
+		{"newer version", bytes.Replace(data, []byte(`"version": 1`), []byte(`"version": 2`), 1), key, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJob(tt.data, tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Created.Equal(job.Created) || got.Host != job.Host || !slices.Equal(got.Chapters, job.Chapters) || got.Options != job.Options {
+				t.Errorf("got %+v, want %+v", got, job)
+			}
+		})
+	}
+
+	if _, err := EncodeJob(job, nil); err == nil {
+		t.Error("expected an error encoding without a key")
+	}
+}
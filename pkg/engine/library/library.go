@@ -0,0 +1,288 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"Luminary/pkg/errors"
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a recorded download
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusPartial   Status = "partial" // Downloaded with some pages missing
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+// Record is a single download attempt
+type Record struct {
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider"`
+	ChapterID string    `json:"chapter_id"`
+	MangaID   string    `json:"manga_id,omitempty"`
+	Chapter   string    `json:"chapter,omitempty"` // Display number or label
+	Title     string    `json:"title,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	OutputDir string    `json:"output_dir"`
+	Path      string    `json:"path,omitempty"`
+	Pages     int       `json:"pages,omitempty"`
+	// FailedPages are the 1-based numbers of pages missing from a partial download
+	FailedPages []int  `json:"failed_pages,omitempty"`
+	Bytes       int64  `json:"bytes,omitempty"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	// Storage is the spec of the storage the chapter was written to; OutputDir is below its
+	// root then and Path is its location there
+	Storage string `json:"storage,omitempty"`
+	// Audit is the file listing the HTTP requests of the download, when it was audited
+	Audit string `json:"audit,omitempty"`
+	// FailoverFrom is the provider:chapter-id of the chapter whose download failed, when
+	// this one of a linked series was downloaded instead
+	FailoverFrom string `json:"failover_from,omitempty"`
+	// Existing is set when the chapter was already on disk as an archive, which Path names,
+	// so nothing was downloaded
+	Existing bool `json:"existing,omitempty"`
+}
+
+// Filter selects records
+type Filter struct {
+	Provider string
+	Since    time.Time
+	// Status keeps only records with this status. StatusFailed also keeps partial
+	// downloads, and drops failures of chapters downloaded completely afterwards.
+	Status Status
+}
+
+// Library stores download records as JSON lines in a single file
+type Library struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the library stored at path, creating its directory if needed
+func Open(path string) (*Library, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			AsFileSystem().
+			Error()
+	}
+	return &Library{path: path}, nil
+}
+
+// Path returns the location of the library file
+func (l *Library) Path() string {
+	return l.path
+}
+
+// Add appends a record to the library
+func (l *Library) Add(record Record) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.Track(err).AsFileSystem().Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Track(err).
+			WithContext("path", l.path).
+			AsFileSystem().
+			Error()
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return errors.Track(err).
+			WithContext("path", l.path).
+			AsFileSystem().
+			Error()
+	}
+	return nil
+}
+
+// Records returns the records matching the filter, oldest first
+func (l *Library) Records(filter Filter) ([]Record, error) {
+	all, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Last successful download per chapter, to hide failures that were fixed later, on
+	// the same provider or by failing over to another
+	var completed map[string]time.Time
+	if filter.Status == StatusFailed {
+		completed = make(map[string]time.Time)
+		for _, r := range all {
+			if r.Status == StatusCompleted {
+				completed[r.Provider+":"+r.ChapterID] = r.Time
+				if r.FailoverFrom != "" {
+					completed[r.FailoverFrom] = r.Time
+				}
+			}
+		}
+	}
+
+	var records []Record
+	for _, r := range all {
+		if filter.Provider != "" && r.Provider != filter.Provider {
+			continue
+		}
+		if !filter.Since.IsZero() && r.Time.Before(filter.Since) {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status &&
+			!(filter.Status == StatusFailed && r.Status == StatusPartial) {
+			continue
+		}
+		if t, ok := completed[r.Provider+":"+r.ChapterID]; ok && t.After(r.Time) {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// Series is a manga the library holds chapters of
+type Series struct {
+	Provider     string    `json:"provider"`
+	MangaID      string    `json:"manga_id"`
+	LastDownload time.Time `json:"last_download"`
+	// OutputDir and Storage are where the latest chapter was downloaded to
+	OutputDir string `json:"output_dir,omitempty"`
+	Storage   string `json:"storage,omitempty"`
+	// Latest is the highest chapter number downloaded, 0 if no chapter had a number
+	Latest float64 `json:"latest_chapter,omitempty"`
+	// Downloaded maps the IDs of the chapters downloaded to their display numbers
+	Downloaded map[string]string `json:"-"`
+}
+
+// Series lists the manga with completed or partial downloads, most recently downloaded
+// first. Records without a manga ID, from providers with custom download logic, are left out.
+func (l *Library) Series() ([]Series, error) {
+	all, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]int) // Series key to index in series
+	var series []Series
+	for _, r := range all {
+		if r.MangaID == "" || (r.Status != StatusCompleted && r.Status != StatusPartial) {
+			continue
+		}
+		key := r.Provider + ":" + r.MangaID
+		i, ok := latest[key]
+		if !ok {
+			i = len(series)
+			latest[key] = i
+			series = append(series, Series{Provider: r.Provider, MangaID: r.MangaID, Downloaded: make(map[string]string)})
+		}
+		s := &series[i]
+		if !r.Time.Before(s.LastDownload) {
+			s.LastDownload, s.OutputDir, s.Storage = r.Time, r.OutputDir, r.Storage
+		}
+		if number, err := strconv.ParseFloat(r.Chapter, 64); err == nil && number > s.Latest {
+			s.Latest = number
+		}
+		s.Downloaded[r.ChapterID] = r.Chapter
+	}
+
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].LastDownload.After(series[j].LastDownload)
+	})
+	return series, nil
+}
+
+// load reads every record; a missing file is an empty library
+func (l *Library) load() ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", l.path).
+			AsFileSystem().
+			Error()
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		// Skip lines that can't be parsed rather than losing the whole history
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", l.path).
+			AsFileSystem().
+			Error()
+	}
+	return records, nil
+}
+
+// writeFile replaces the file at path with data. The data is written to a temporary file
+// of its own and renamed into place whole, so concurrent saves never mix and a crash
+// never leaves half a file.
+func writeFile(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return nil
+}
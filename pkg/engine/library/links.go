@@ -0,0 +1,169 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"Luminary/pkg/errors"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Links records which series on different providers are the same, as groups of combined
+// provider:manga-id IDs, so a chapter failing on one can be downloaded from another
+type Links struct {
+	path string
+	mu   sync.Mutex
+}
+
+// OpenLinks returns the links stored at path, creating its directory if needed
+func OpenLinks(path string) (*Links, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			AsFileSystem().
+			Error()
+	}
+	return &Links{path: path}, nil
+}
+
+// Path returns the location of the links file
+func (l *Links) Path() string {
+	return l.path
+}
+
+// Groups returns every group of linked series
+func (l *Links) Groups() ([][]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.load()
+}
+
+// Link records the series as the same; groups already holding any of them are merged
+func (l *Links) Link(ids ...string) error {
+	for _, id := range ids {
+		if err := validateLinkID(id); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	groups, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	merged := slices.Clone(ids)
+	kept := groups[:0]
+	for _, group := range groups {
+		if slices.ContainsFunc(group, func(id string) bool { return slices.Contains(ids, id) }) {
+			merged = append(merged, group...)
+		} else {
+			kept = append(kept, group)
+		}
+	}
+	slices.Sort(merged)
+	merged = slices.Compact(merged)
+	if len(merged) > 1 {
+		kept = append(kept, merged)
+	}
+	return l.save(kept)
+}
+
+// Unlink removes a series from its group, reporting whether it was linked
+func (l *Links) Unlink(id string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	groups, err := l.load()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	kept := groups[:0]
+	for _, group := range groups {
+		if i := slices.Index(group, id); i >= 0 {
+			found = true
+			group = slices.Delete(group, i, i+1)
+		}
+		if len(group) > 1 {
+			kept = append(kept, group)
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	return true, l.save(kept)
+}
+
+// Equivalents returns the series linked to id, without id itself
+func (l *Links) Equivalents(id string) ([]string, error) {
+	groups, err := l.Groups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if slices.Contains(group, id) {
+			return slices.DeleteFunc(slices.Clone(group), func(other string) bool { return other == id }), nil
+		}
+	}
+	return nil, nil
+}
+
+// validateLinkID checks that id is a combined provider:manga-id ID
+func validateLinkID(id string) error {
+	provider, manga, ok := strings.Cut(id, ":")
+	if !ok || provider == "" || manga == "" {
+		return errors.Newf("invalid series ID %q", id).
+			WithMessagef("Linked series are given as provider:manga-id, got %q", id).
+			Error()
+	}
+	return nil
+}
+
+// load reads the groups; a missing file has none
+func (l *Links) load() ([][]string, error) {
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", l.path).AsFileSystem().Error()
+	}
+
+	var groups [][]string
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", l.path).
+			WithMessage("The linked series file is not valid JSON").
+			AsParser().Error()
+	}
+	return groups, nil
+}
+
+// save replaces the groups
+func (l *Links) save(groups [][]string) error {
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return errors.Track(err).Error()
+	}
+	return writeFile(l.path, append(data, '\n'))
+}
@@ -0,0 +1,141 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"Luminary/pkg/errors"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QueuedChapter is a chapter waiting to be downloaded
+type QueuedChapter struct {
+	// Ref is what download takes: a combined provider:chapter-id ID or a chapter URL
+	Ref   string    `json:"ref"`
+	Added time.Time `json:"added"`
+}
+
+// Queue holds the chapters picked for a later download, in the order they were added,
+// so they can be exported as a job file or downloaded in one go
+type Queue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// OpenQueue returns the queue stored at path, creating its directory if needed
+func OpenQueue(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			AsFileSystem().
+			Error()
+	}
+	return &Queue{path: path}, nil
+}
+
+// Path returns the location of the queue file
+func (q *Queue) Path() string {
+	return q.path
+}
+
+// Chapters returns the queued chapters
+func (q *Queue) Chapters() ([]QueuedChapter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load()
+}
+
+// Add queues chapters not queued yet, returning how many were added
+func (q *Queue) Add(refs ...string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	chapters, err := q.load()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	now := time.Now()
+	for _, ref := range refs {
+		if ref == "" || slices.ContainsFunc(chapters, func(c QueuedChapter) bool { return c.Ref == ref }) {
+			continue
+		}
+		chapters = append(chapters, QueuedChapter{Ref: ref, Added: now})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, q.save(chapters)
+}
+
+// Remove takes chapters off the queue, returning how many were queued
+func (q *Queue) Remove(refs ...string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	chapters, err := q.load()
+	if err != nil {
+		return 0, err
+	}
+
+	before := len(chapters)
+	chapters = slices.DeleteFunc(chapters, func(c QueuedChapter) bool { return slices.Contains(refs, c.Ref) })
+	if len(chapters) == before {
+		return 0, nil
+	}
+	return before - len(chapters), q.save(chapters)
+}
+
+// Clear empties the queue
+func (q *Queue) Clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.save([]QueuedChapter{})
+}
+
+// load reads the queued chapters; a missing file has none
+func (q *Queue) load() ([]QueuedChapter, error) {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", q.path).AsFileSystem().Error()
+	}
+
+	var chapters []QueuedChapter
+	if err := json.Unmarshal(data, &chapters); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", q.path).
+			WithMessage("The download queue file is not valid JSON").
+			AsParser().Error()
+	}
+	return chapters, nil
+}
+
+// save replaces the queued chapters
+func (q *Queue) save(chapters []QueuedChapter) error {
+	data, err := json.MarshalIndent(chapters, "", "  ")
+	if err != nil {
+		return errors.Track(err).Error()
+	}
+	return writeFile(q.path, append(data, '\n'))
+}
@@ -0,0 +1,124 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"os"
+	"sort"
+)
+
+// Stats summarizes the downloaded chapters still present on disk
+type Stats struct {
+	Series    int             `json:"series"`
+	Chapters  int             `json:"chapters"`
+	Bytes     int64           `json:"bytes"`
+	Providers []ProviderStats `json:"providers"`
+	TopSeries []SeriesStats   `json:"top_series"`
+}
+
+// ProviderStats is the per-provider part of Stats
+type ProviderStats struct {
+	Provider string `json:"provider"`
+	Series   int    `json:"series"`
+	Chapters int    `json:"chapters"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// SeriesStats is the per-series part of Stats
+type SeriesStats struct {
+	Provider string `json:"provider"`
+	MangaID  string `json:"manga_id"`
+	Chapters int    `json:"chapters"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// Stats computes library statistics, listing at most top series by chapter count.
+// Each chapter counts once, using its latest successful download, and only while
+// its directory still exists.
+func (l *Library) Stats(top int) (*Stats, error) {
+	records, err := l.Records(Filter{Status: StatusCompleted})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]Record)
+	for _, r := range records {
+		latest[r.Provider+":"+r.ChapterID] = r
+	}
+
+	providers := make(map[string]*ProviderStats)
+	series := make(map[string]*SeriesStats)
+	stats := &Stats{Providers: []ProviderStats{}, TopSeries: []SeriesStats{}}
+
+	for _, r := range latest {
+		if r.Path != "" {
+			if _, err := os.Stat(r.Path); err != nil {
+				continue
+			}
+		}
+
+		stats.Chapters++
+		stats.Bytes += r.Bytes
+
+		ps, ok := providers[r.Provider]
+		if !ok {
+			ps = &ProviderStats{Provider: r.Provider}
+			providers[r.Provider] = ps
+		}
+		ps.Chapters++
+		ps.Bytes += r.Bytes
+
+		// Providers with custom download logic may not report the manga
+		if r.MangaID == "" {
+			continue
+		}
+		key := r.Provider + ":" + r.MangaID
+		ss, ok := series[key]
+		if !ok {
+			ss = &SeriesStats{Provider: r.Provider, MangaID: r.MangaID}
+			series[key] = ss
+			ps.Series++
+		}
+		ss.Chapters++
+		ss.Bytes += r.Bytes
+	}
+
+	stats.Series = len(series)
+
+	for _, ps := range providers {
+		stats.Providers = append(stats.Providers, *ps)
+	}
+	sort.Slice(stats.Providers, func(i, j int) bool {
+		return stats.Providers[i].Provider < stats.Providers[j].Provider
+	})
+
+	for _, ss := range series {
+		stats.TopSeries = append(stats.TopSeries, *ss)
+	}
+	sort.Slice(stats.TopSeries, func(i, j int) bool {
+		a, b := stats.TopSeries[i], stats.TopSeries[j]
+		if a.Chapters != b.Chapters {
+			return a.Chapters > b.Chapters
+		}
+		return a.Provider+a.MangaID < b.Provider+b.MangaID
+	})
+	if top >= 0 && len(stats.TopSeries) > top {
+		stats.TopSeries = stats.TopSeries[:top]
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,90 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"Luminary/pkg/core"
+	"context"
+)
+
+type traceKey struct{}
+
+// WithTrace returns a context whose debug messages are logged whatever the log level,
+// tagged with name, to trace the work of one provider without raising the verbosity of
+// everything else
+func WithTrace(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, traceKey{}, name)
+}
+
+// Tracing reports whether ctx is traced, see WithTrace
+func Tracing(ctx context.Context) bool {
+	_, ok := ctx.Value(traceKey{}).(string)
+	return ok
+}
+
+// FromContext returns a logger that prefixes every message with the correlation ID
+// carried by ctx and logs debug messages of traced contexts, or l itself when there is
+// neither
+func FromContext(ctx context.Context, l Logger) Logger {
+	id := core.CorrelationID(ctx)
+	trace, traced := ctx.Value(traceKey{}).(string)
+	if id == "" && !traced {
+		return l
+	}
+
+	scoped := &scoped{base: l, trace: traced}
+	if id != "" {
+		scoped.prefix = "[" + id + "] "
+	}
+	if traced {
+		scoped.prefix += "[trace " + trace + "] "
+	}
+	return scoped
+}
+
+// scoped decorates a logger with a message prefix
+type scoped struct {
+	base   Logger
+	prefix string
+	trace  bool // Log debug messages whatever the level
+}
+
+func (l *scoped) Debug(format string, args ...interface{}) { l.log(LevelDebug, format, args...) }
+func (l *scoped) Info(format string, args ...interface{})  { l.log(LevelInfo, format, args...) }
+func (l *scoped) Warn(format string, args ...interface{})  { l.log(LevelWarn, format, args...) }
+func (l *scoped) Error(format string, args ...interface{}) { l.log(LevelError, format, args...) }
+func (l *scoped) SetLevel(level Level)                     { l.base.SetLevel(level) }
+
+func (l *scoped) log(level Level, format string, args ...interface{}) {
+	// Report the caller of the scoped logger, not this wrapper
+	if s, ok := l.base.(*Service); ok {
+		s.logDepth(level, 3, l.trace, l.prefix, format, args...)
+		return
+	}
+
+	format = l.prefix + format
+	switch level {
+	case LevelDebug:
+		l.base.Debug(format, args...)
+	case LevelInfo:
+		l.base.Info(format, args...)
+	case LevelWarn:
+		l.base.Warn(format, args...)
+	default:
+		l.base.Error(format, args...)
+	}
+}
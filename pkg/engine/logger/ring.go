@@ -0,0 +1,192 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RingSize is how many of the latest log records a Service keeps in memory
+const RingSize = 1000
+
+// timestampLayout is how log lines write their time, followed by ",<milliseconds>"
+const timestampLayout = "2006-01-02 15:04:05"
+
+// Record is one log entry, as kept in memory or read back from the log file
+type Record struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`  // DEBUG, INFO, WARN or ERROR
+	Module  string    `json:"module"` // Package directory of the code that logged, e.g. "network"
+	File    string    `json:"file"`   // Source file and line, e.g. "client.go:98"
+	Message string    `json:"message"`
+}
+
+// Matches reports whether the record is at least at level and comes from module; module
+// names a package directory such as "network" or a source file such as "mangadex", and
+// an empty module matches everything
+func (r Record) Matches(level Level, module string) bool {
+	if recordLevel, err := ParseLevel(r.Level); err == nil && recordLevel < level {
+		return false
+	}
+	if module == "" {
+		return true
+	}
+	file, _, _ := strings.Cut(r.File, ":")
+	return strings.EqualFold(r.Module, module) || strings.EqualFold(strings.TrimSuffix(file, ".go"), module)
+}
+
+// ParseLevel reads a level name such as "info" or "WARN"; an empty name is debug, the
+// lowest level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "", "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelDebug, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+}
+
+// ring keeps the latest records, overwriting the oldest once full
+type ring struct {
+	records []Record
+	next    int
+	full    bool
+}
+
+func (r *ring) add(record Record) {
+	if r.records == nil {
+		r.records = make([]Record, RingSize)
+	}
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// all returns the records oldest first
+func (r *ring) all() []Record {
+	if !r.full {
+		return r.records[:r.next]
+	}
+	return append(append([]Record(nil), r.records[r.next:]...), r.records[:r.next]...)
+}
+
+// Tail returns up to count of the latest records at least at level and from module,
+// oldest first; a count of zero or less returns all of them
+func (s *Service) Tail(count int, level Level, module string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return tail(s.ring.all(), count, level, module)
+}
+
+func tail(records []Record, count int, level Level, module string) []Record {
+	var matched []Record
+	for _, record := range records {
+		if record.Matches(level, module) {
+			matched = append(matched, record)
+		}
+	}
+	if count > 0 && len(matched) > count {
+		matched = matched[len(matched)-count:]
+	}
+	return matched
+}
+
+// linePattern matches the lines written by Service:
+// "2006-01-02 15:04:05,000 [pid] LEVEL - dir/file.go:line   - message"
+var linePattern = regexp.MustCompile(`^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d),(\d{3}) \[\d+\] (\w+)\s* - (\S+)\s* - (.*)$`)
+
+// ReadTail reads up to count of the latest records at least at level and from module
+// from a log file, oldest first. Lines that aren't records, such as stack traces, are
+// added to the message of the record before them.
+func ReadTail(path string, count int, level Level, module string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	keep := false // Whether the last record read matched
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		record, ok := parseLine(line)
+		if !ok {
+			if keep {
+				records[len(records)-1].Message += "\n" + line
+			}
+			continue
+		}
+
+		if keep = record.Matches(level, module); keep {
+			records = append(records, record)
+		}
+		// Keep memory bounded on large files
+		if count > 0 && len(records) >= 2*count {
+			records = append(records[:0], records[len(records)-count:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tail(records, count, LevelDebug, ""), nil
+}
+
+// parseLine reads a log line back into a record
+func parseLine(line string) (Record, bool) {
+	m := linePattern.FindStringSubmatch(line)
+	if m == nil {
+		return Record{}, false
+	}
+	t, err := time.ParseInLocation(timestampLayout, m[1], time.Local)
+	if err != nil {
+		return Record{}, false
+	}
+	var millis int
+	_, _ = fmt.Sscanf(m[2], "%d", &millis)
+
+	record := Record{
+		Time:    t.Add(time.Duration(millis) * time.Millisecond),
+		Level:   m[3],
+		File:    m[4],
+		Message: m[5],
+	}
+	if dir, file, ok := strings.Cut(m[4], "/"); ok {
+		record.Module, record.File = dir, file
+	}
+	return record, true
+}
+
+// callerInfo splits the source location of a caller into its package directory and
+// "file:line"
+func callerInfo(path string, line int) (module, fileInfo string) {
+	return filepath.Base(filepath.Dir(path)), fmt.Sprintf("%s:%d", filepath.Base(path), line)
+}
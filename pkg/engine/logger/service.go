@@ -56,6 +56,7 @@ type Service struct {
 	mu       sync.Mutex
 	colorize bool
 	pid      int
+	ring     ring // The latest records, for Tail
 }
 
 // NewService creates a new logger service
@@ -147,31 +148,39 @@ func (s *Service) Error(format string, args ...interface{}) {
 
 // log performs the actual logging
 func (s *Service) log(level Level, format string, args ...interface{}) {
+	s.logDepth(level, 3, false, "", format, args...)
+}
+
+// logDepth logs with the caller found depth frames up and an optional message prefix;
+// forced messages are logged whatever the level
+func (s *Service) logDepth(level Level, depth int, force bool, prefix, format string, args ...interface{}) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check level
-	if level < s.level {
+	if level < s.level && !force {
 		return
 	}
 
-	// Get caller information
-	_, file, line, ok := runtime.Caller(2)
-	fileInfo := "unknown:0"
+	// Get caller information: the package directory and file name, like network/client.go:98
+	_, file, line, ok := runtime.Caller(depth)
+	module, fileInfo := "", "unknown:0"
 	if ok {
-		// Extract just the filename from the full path
-		file = filepath.Base(file)
-		fileInfo = fmt.Sprintf("%s:%d", file, line)
+		module, fileInfo = callerInfo(file, line)
 	}
 
 	// Format timestamp with milliseconds and comma separator
 	now := time.Now()
 	timestamp := fmt.Sprintf("%s,%03d",
-		now.Format("2006-01-02 15:04:05"),
+		now.Format(timestampLayout),
 		now.Nanosecond()/1000000)
 
 	levelStr := s.levelString(level)
-	message := fmt.Sprintf(format, args...)
+	message := prefix + fmt.Sprintf(format, args...)
+	s.ring.add(Record{Time: now, Level: levelStr, Module: module, File: fileInfo, Message: message})
+	if module != "" {
+		fileInfo = module + "/" + fileInfo
+	}
 
 	// Pad file info to consistent width (23 characters based on log pattern)
 	paddedFileInfo := fileInfo
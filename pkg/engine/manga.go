@@ -0,0 +1,134 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"sync"
+)
+
+// MangaDownloadOptions configures DownloadManga
+type MangaDownloadOptions struct {
+	Filter    ChapterFilter
+	OutputDir string
+	// AllLanguages downloads chapters in every language when the filter names none;
+	// otherwise the preferred languages (see Engine.PreferredLanguages) are downloaded
+	AllLanguages bool
+	// StopOnError ends the download at the first failed chapter instead of continuing
+	StopOnError bool
+	// OnChapter, if set, is called with the outcome of every chapter as soon as it is known
+	OnChapter func(library.Record)
+}
+
+// DownloadManga downloads the chapters of a manga matching the filter in reading order and
+// returns the outcome of each. Failed chapters don't fail the call; the error is only set
+// when the chapter list can't be fetched, the download is cancelled or StopOnError applies.
+// Up to core.ChapterConcurrency chapters are downloaded at once; their records keep the
+// reading order. Reaching the download quota always stops the download.
+func (e *Engine) DownloadManga(ctx context.Context, provider Provider, mangaID string, opts MangaDownloadOptions) ([]library.Record, error) {
+	if len(opts.Filter.Languages) == 0 && !opts.AllLanguages {
+		opts.Filter.Languages = e.PreferredLanguages()
+	}
+
+	chapters, err := e.Chapters(ctx, provider, mangaID, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Log(ctx).Info("Downloading %d chapters of %s:%s to %s", len(chapters), provider.ID(), mangaID, opts.OutputDir)
+
+	// Chapters are started in order; once one fails with StopOnError no more are started
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		stopErr error
+	)
+	handled := make([]*library.Record, len(chapters))
+	sem := make(chan struct{}, core.ChapterConcurrency(ctx))
+
+	for i, chapter := range chapters {
+		sem <- struct{}{}
+		mu.Lock()
+		stopped := stopErr != nil
+		mu.Unlock()
+		if stopped || ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			record, err := e.DownloadChapterRecord(withChapterHint(ctx, mangaID, chapter), provider, chapter.ID, opts.OutputDir)
+			// Custom download implementations may not report the chapter they handled
+			if record.Chapter == "" {
+				record.Chapter = chapter.DisplayNumber()
+			}
+			if record.Title == "" {
+				record.Title = chapter.Title
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			handled[i] = &record
+			if opts.OnChapter != nil {
+				opts.OnChapter(record)
+			}
+			// A full download directory stops the download whether or not errors should
+			if errors.Is(err, download.ErrQuotaExceeded) && stopErr == nil {
+				stopErr = err
+			}
+			// So does running out of the request budget of the run
+			if budgetErr := network.RequestBudgetError(ctx); budgetErr != nil && stopErr == nil {
+				stopErr = budgetErr
+			}
+			if err != nil && record.Status == library.StatusFailed && opts.StopOnError && stopErr == nil {
+				stopErr = errors.Track(err).
+					WithContext("manga_id", mangaID).
+					WithContext("chapter_id", chapter.ID).
+					WithMessagef("Stopped after chapter %s failed", record.Chapter).
+					Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	records := make([]library.Record, 0, len(chapters))
+	for _, record := range handled {
+		if record != nil {
+			records = append(records, *record)
+		}
+	}
+
+	if stopErr != nil {
+		return records, stopErr
+	}
+	if err := ctx.Err(); err != nil && len(records) < len(chapters) {
+		return records, errors.Track(err).
+			WithContext("manga_id", mangaID).
+			WithMessagef("Download cancelled after %d of %d chapters", len(records), len(chapters)).
+			Error()
+	}
+	return records, nil
+}
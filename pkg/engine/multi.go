@@ -0,0 +1,54 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"context"
+	"time"
+)
+
+// Result returns what the provider found as the result of a search across providers
+func (r SearchResult) Result() core.ProviderResult[[]core.Manga] {
+	return core.ProviderResult[[]core.Manga]{Provider: r.Provider.ID(), Value: r.Results, Err: r.Err, Elapsed: r.Elapsed}
+}
+
+// SearchAcrossProviders searches the providers concurrently like SearchAll and returns
+// once every provider answered, with the results and errors of each. onResult, if set, is
+// called with each provider's results as soon as they arrive.
+func (e *Engine) SearchAcrossProviders(ctx context.Context, providers []Provider, query string, options core.SearchOptions, onResult func(SearchResult)) core.MultiResult[[]core.Manga] {
+	start := time.Now()
+	found := make(map[string]core.ProviderResult[[]core.Manga], len(providers))
+	for r := range e.SearchAll(ctx, providers, query, options) {
+		if onResult != nil {
+			onResult(r)
+		}
+		found[r.Provider.ID()] = r.Result()
+	}
+	return inProviderOrder(providers, found, time.Since(start))
+}
+
+// inProviderOrder puts the results of the providers in the order the providers were given
+func inProviderOrder[T any](providers []Provider, found map[string]core.ProviderResult[T], elapsed time.Duration) core.MultiResult[T] {
+	result := core.MultiResult[T]{Results: make([]core.ProviderResult[T], 0, len(found)), Elapsed: elapsed}
+	for _, provider := range providers {
+		if r, ok := found[provider.ID()]; ok {
+			result.Results = append(result.Results, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AuditEntry describes one attempt of an HTTP request
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Status     int       `json:"status,omitempty"` // Absent when no response arrived
+	Bytes      int64     `json:"bytes"`            // Size of the response body
+	DurationMS int64     `json:"duration_ms"`
+	Attempt    int       `json:"attempt"` // 1 for the first try, higher for retries
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditSummary totals the requests of an audit
+type AuditSummary struct {
+	Requests   int   `json:"requests"` // Attempts, retries included
+	Retries    int   `json:"retries"`
+	Failed     int   `json:"failed"` // Attempts ending in an error or an error status
+	Bytes      int64 `json:"bytes"`
+	DurationMS int64 `json:"duration_ms"` // Summed over attempts, which may overlap
+}
+
+// Audit collects every request attempt made with a context from WithAudit
+type Audit struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+type auditKey struct{}
+
+// WithAudit returns a context whose requests are recorded in the returned audit
+func WithAudit(ctx context.Context) (context.Context, *Audit) {
+	audit := &Audit{}
+	return context.WithValue(ctx, auditKey{}, audit), audit
+}
+
+// auditFrom returns the audit of ctx, or nil
+func auditFrom(ctx context.Context) *Audit {
+	audit, _ := ctx.Value(auditKey{}).(*Audit)
+	return audit
+}
+
+// Entries returns the recorded attempts in the order they finished
+func (a *Audit) Entries() []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]AuditEntry(nil), a.entries...)
+}
+
+// Summary totals the recorded attempts
+func (a *Audit) Summary() AuditSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var summary AuditSummary
+	for _, entry := range a.entries {
+		summary.Requests++
+		if entry.Attempt > 1 {
+			summary.Retries++
+		}
+		if entry.Error != "" || entry.Status >= 400 {
+			summary.Failed++
+		}
+		summary.Bytes += entry.Bytes
+		summary.DurationMS += entry.DurationMS
+	}
+	return summary
+}
+
+// recordAttempt adds the outcome of one attempt to the audit of ctx, if any
+func recordAttempt(ctx context.Context, req *Request, attempt int, start time.Time, resp *Response, err error) {
+	audit := auditFrom(ctx)
+	if audit == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:       start,
+		Method:     req.Method,
+		URL:        req.URL,
+		DurationMS: time.Since(start).Milliseconds(),
+		Attempt:    attempt,
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+		entry.Bytes = int64(len(resp.Body))
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	audit.entries = append(audit.entries, entry)
+}
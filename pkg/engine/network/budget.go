@@ -0,0 +1,95 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Budget names a time limit on network work
+type Budget string
+
+// Time budgets, from the narrowest to the widest
+const (
+	BudgetRequest Budget = "request" // One HTTP request attempt
+	BudgetPage    Budget = "page"    // One page, including retries and fallback URLs
+	BudgetChapter Budget = "chapter" // One chapter, from resolving its pages to writing the last one
+
+	BudgetSearch Budget = "search" // A search across one or more providers
+	BudgetList   Budget = "list"   // Browsing manga page by page
+
+	// BudgetOperation is a limit given for a whole command or RPC call, replacing the others
+	BudgetOperation Budget = "operation"
+)
+
+// BudgetExceeded is the cause of a context whose time budget ran out
+type BudgetExceeded struct {
+	Budget Budget
+	Limit  time.Duration
+}
+
+func (e *BudgetExceeded) Error() string {
+	return fmt.Sprintf("%s time budget of %s exceeded", e.Budget, e.Limit)
+}
+
+// WithBudget returns a context ending after limit, with a BudgetExceeded as its cause.
+// A limit of zero or less sets no deadline, as does any limit within WithOverride.
+func WithBudget(ctx context.Context, budget Budget, limit time.Duration) (context.Context, context.CancelFunc) {
+	if limit <= 0 || overridden(ctx) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, limit, &BudgetExceeded{Budget: budget, Limit: limit})
+}
+
+type overrideKey struct{}
+
+// WithOverride returns a context ending after limit that replaces every budget of the work
+// done with it: WithBudget sets no deadlines within it, so the computed budgets can't cut
+// off work over slow connections before the given limit. A limit of zero or less leaves
+// the work unlimited.
+func WithOverride(ctx context.Context, limit time.Duration) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, overrideKey{}, true)
+	if limit <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, limit, &BudgetExceeded{Budget: BudgetOperation, Limit: limit})
+}
+
+// overridden reports whether ctx comes from WithOverride
+func overridden(ctx context.Context) bool {
+	is, _ := ctx.Value(overrideKey{}).(bool)
+	return is
+}
+
+// BudgetError returns a timeout error naming the budget if ctx ended because its own
+// budget ran out, or nil. Budgets of enclosing contexts are reported by their owners.
+func BudgetError(ctx, parent context.Context) error {
+	var exceeded *BudgetExceeded
+	if ctx.Err() == nil || parent.Err() != nil || !errors.As(context.Cause(ctx), &exceeded) {
+		return nil
+	}
+
+	return errors.Track(exceeded).
+		WithContext("budget", string(exceeded.Budget)).
+		WithContext("limit", exceeded.Limit.String()).
+		WithMessagef("Gave up after the %s time budget of %s ran out", exceeded.Budget, exceeded.Limit).
+		AsTimeout().
+		Error()
+}
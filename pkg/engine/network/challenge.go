@@ -0,0 +1,90 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"Luminary/pkg/errors"
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrChallenge is returned when a site answers with a bot check instead of the page
+var ErrChallenge = stderrors.New("blocked by a bot check")
+
+// challengeMarkers identify challenge pages by their body, per kind of challenge
+var challengeMarkers = []struct {
+	kind    string
+	markers []string
+}{
+	{"Cloudflare", []string{
+		"<title>Just a moment...</title>",
+		"cf-browser-verification",
+		"cf_chl_opt",
+		"/cdn-cgi/challenge-platform/",
+		"Attention Required! | Cloudflare",
+	}},
+	{"DDoS-Guard", []string{"ddos-guard.net", "DDoS-Guard"}},
+	{"CAPTCHA", []string{"g-recaptcha", "www.google.com/recaptcha/", "h-captcha", "hcaptcha.com/1/api.js"}},
+}
+
+// detectChallenge returns the kind of bot check a response is, if it is one. Challenge
+// pages are served with 403, 429 or 503, except Cloudflare's interstitial, which is
+// recognized by its title whatever the status.
+func detectChallenge(resp *Response) (string, bool) {
+	if resp.Headers.Get("Cf-Mitigated") == "challenge" {
+		return "Cloudflare", true
+	}
+
+	blocked := resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable
+	if !blocked {
+		if bytes.Contains(resp.Body, []byte("<title>Just a moment...</title>")) {
+			return "Cloudflare", true
+		}
+		return "", false
+	}
+
+	for _, challenge := range challengeMarkers {
+		for _, marker := range challenge.markers {
+			if bytes.Contains(resp.Body, []byte(marker)) {
+				return challenge.kind, true
+			}
+		}
+	}
+	return "", false
+}
+
+// challengeError describes a challenge response
+func challengeError(req *Request, resp *Response, kind string) error {
+	domain := req.URL
+	if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+		domain = u.Host
+	}
+
+	return errors.Track(fmt.Errorf("%w: %s challenge from %s", ErrChallenge, kind, domain)).
+		WithContext("url", req.URL).
+		WithContext("domain", domain).
+		WithContext("challenge", kind).
+		WithContext("status_code", resp.StatusCode).
+		WithMessagef("%s is protected by a %s check that can't be passed automatically", domain, kind).
+		AsChallenge().
+		Error()
+}
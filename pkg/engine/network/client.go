@@ -17,33 +17,45 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"Luminary/pkg/engine/logger"
 	"Luminary/pkg/errors"
 )
 
+// ErrOffline is returned for every request while the client is offline
+var ErrOffline = stderrors.New("network access is disabled in offline mode")
+
 // Client provides unified HTTP operations with rate limiting and retries
 type Client struct {
 	http    *http.Client
 	limiter *RateLimiter
 	logger  logger.Logger
 
+	// Delays per host overriding those of requests, see SetRateLimits
+	rateLimits map[string]time.Duration
+	offline    atomic.Bool
+
 	// Default settings
 	defaultRetries int
-	defaultTimeout time.Duration
+	defaultTimeout atomic.Int64 // A time.Duration; reloading the config changes it while requests run
 	defaultHeaders map[string]string
 }
 
 // NewClient creates a new network client
 func NewClient(logger logger.Logger) *Client {
-	return &Client{
+	c := &Client{
+		// Requests carry their own time budget, see Request.Timeout
 		http: &http.Client{
-			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 20,
@@ -54,24 +66,46 @@ func NewClient(logger logger.Logger) *Client {
 		limiter:        NewRateLimiter(),
 		logger:         logger,
 		defaultRetries: 3,
-		defaultTimeout: 30 * time.Second,
 		defaultHeaders: map[string]string{
 			"User-Agent": "Luminary/1.0",
 			"Accept":     "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
 		},
 	}
+	c.defaultTimeout.Store(int64(30 * time.Second))
+	return c
 }
 
 // Do execute an HTTP request with rate limiting and retries
 func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
-	// Apply rate limiting
-	if req.RateLimit > 0 {
-		if err := c.limiter.Wait(ctx, req.URL, req.RateLimit); err != nil {
-			return nil, errors.Track(err).
-				WithContext("url", req.URL).
-				AsNetwork().
-				Error()
+	if c.offline.Load() {
+		return nil, errors.Track(ErrOffline).
+			WithContext("url", req.URL).
+			WithMessage("Luminary is offline; only cached manga details and the download history are available").
+			AsNetwork().
+			Error()
+	}
+
+	// A spent request budget doesn't wait for the rate limit first
+	if err := RequestBudgetError(ctx); err != nil {
+		return nil, err
+	}
+
+	// Apply rate limiting, waiting out the cooldown of a site that rate limited us
+	delay := req.RateLimit
+	if limit, ok := c.rateLimits[ExtractDomain(req.URL)]; ok {
+		delay = limit
+	}
+	waitStart := time.Now()
+	err := c.limiter.Wait(ctx, req.URL, delay)
+	recordWait(ctx, req, time.Since(waitStart))
+	if err != nil {
+		if errors.Is(err, ErrCoolingDown) {
+			return nil, err
 		}
+		return nil, errors.Track(err).
+			WithContext("url", req.URL).
+			AsNetwork().
+			Error()
 	}
 
 	// Set defaults
@@ -79,7 +113,7 @@ func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
 		req.Method = "GET"
 	}
 	if req.Timeout == 0 {
-		req.Timeout = c.defaultTimeout
+		req.Timeout = time.Duration(c.defaultTimeout.Load())
 	}
 	if req.MaxRetries == 0 {
 		req.MaxRetries = c.defaultRetries
@@ -116,18 +150,27 @@ func (c *Client) executeWithRetry(ctx context.Context, req *Request) (*Response,
 	var allErrors []error // Collect all errors during retries
 
 	for attempt := 0; attempt <= req.MaxRetries; attempt++ {
+		// Retries count towards the request budget like first tries
+		if err := reserveRequest(ctx, req); err != nil {
+			allErrors = append(allErrors, err)
+			return nil, errors.Join(allErrors...)
+		}
+
+		start := time.Now()
 		resp, err := c.executeRequest(ctx, req)
+		recordAttempt(ctx, req, attempt+1, start, resp, err)
+		recordResponse(ctx, req, resp)
 
 		// Log response details
 		if err != nil {
-			c.logger.Debug("[HTTP] Request failed (attempt %d/%d): %v", attempt+1, req.MaxRetries+1, err)
+			logger.FromContext(ctx, c.logger).Debug("[HTTP] Request failed (attempt %d/%d): %v", attempt+1, req.MaxRetries+1, err)
 			// Add attempt context to the error
 			attemptErr := errors.Track(err).
 				WithMessage(fmt.Sprintf("attempt %d/%d failed", attempt+1, req.MaxRetries+1)).
 				AsNetwork().Error()
 			allErrors = append(allErrors, attemptErr)
 		} else if resp != nil {
-			c.logger.Debug("[HTTP] Response received (attempt %d/%d): status %d", attempt+1, req.MaxRetries+1, resp.StatusCode)
+			logger.FromContext(ctx, c.logger).Debug("[HTTP] Response received (attempt %d/%d): status %d", attempt+1, req.MaxRetries+1, resp.StatusCode)
 		}
 
 		// Network or connection error - retry
@@ -139,7 +182,7 @@ func (c *Client) executeWithRetry(ctx context.Context, req *Request) (*Response,
 					backoff = 30 * time.Second
 				}
 
-				c.logger.Debug("[HTTP] Retrying in %v...", backoff)
+				logger.FromContext(ctx, c.logger).Debug("[HTTP] Retrying in %v...", backoff)
 
 				select {
 				case <-ctx.Done():
@@ -157,12 +200,30 @@ func (c *Client) executeWithRetry(ctx context.Context, req *Request) (*Response,
 
 		// At this point, we know resp is not nil
 
+		// Sites that rate limit us get a pause, saved for the next run as well
+		throttled := resp.StatusCode == http.StatusTooManyRequests ||
+			(resp.StatusCode == http.StatusServiceUnavailable && resp.Headers.Get("Retry-After") != "")
+		recordFootprint(ctx, req, resp, throttled)
+		var until time.Time
+		if throttled {
+			until = c.limiter.Throttled(ExtractDomain(req.URL), ParseRetryAfter(resp.Headers.Get("Retry-After")))
+			logger.FromContext(ctx, c.logger).Debug("[HTTP] Rate limited by %s, cooling down until %s", ExtractDomain(req.URL), until.Format(time.TimeOnly))
+		} else if resp.StatusCode < 400 {
+			c.limiter.Recovered(ExtractDomain(req.URL))
+		}
+
+		// Bot checks don't go away by retrying
+		if kind, ok := detectChallenge(resp); ok {
+			logger.FromContext(ctx, c.logger).Debug("[HTTP] %s challenge at %s (status %d)", kind, req.URL, resp.StatusCode)
+			return nil, challengeError(req, resp, kind)
+		}
+
 		// Check status code - retry only for 5xx server errors
 		if resp.StatusCode >= 500 {
 			// Create a server error and add it to the list
 			serverErr := errors.Track(
 				fmt.Errorf("server returned %d status code", resp.StatusCode),
-			).WithMessage(
+			).WithContext("status_code", resp.StatusCode).WithMessage(
 				fmt.Sprintf("attempt %d/%d: server error %d", attempt+1, req.MaxRetries+1, resp.StatusCode),
 			).AsNetwork().Error()
 			allErrors = append(allErrors, serverErr)
@@ -174,7 +235,16 @@ func (c *Client) executeWithRetry(ctx context.Context, req *Request) (*Response,
 					backoff = 30 * time.Second
 				}
 
-				c.logger.Debug("[HTTP] Server error %d, retrying in %v...", resp.StatusCode, backoff)
+				// A site that asked for a pause isn't asked again before it is over, and not
+				// at all by this request if that takes longer than requests wait for one
+				if throttled {
+					backoff = max(backoff, time.Until(until))
+					if backoff > MaxCooldownWait {
+						return nil, coolingDownError(ExtractDomain(req.URL), until)
+					}
+				}
+
+				logger.FromContext(ctx, c.logger).Debug("[HTTP] Server error %d, retrying in %v...", resp.StatusCode, backoff)
 
 				select {
 				case <-ctx.Done():
@@ -242,8 +312,25 @@ func (c *Client) executeWithRetry(ctx context.Context, req *Request) (*Response,
 
 // executeRequest performs a single HTTP request
 func (c *Client) executeRequest(ctx context.Context, req *Request) (*Response, error) {
+	// Form data is encoded per attempt, so retried requests resend it
+	body := req.Body
+	if req.FormData != nil {
+		body = strings.NewReader(req.FormData.Encode())
+	}
+	// JSON data likewise
+	if req.JSONData != nil {
+		data, err := json.Marshal(req.JSONData)
+		if err != nil {
+			return nil, errors.Track(err).
+				WithContext("url", req.URL).
+				WithContext("method", req.Method).
+				Error()
+		}
+		body = bytes.NewReader(data)
+	}
+
 	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, req.Body)
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, body)
 	if err != nil {
 		return nil, errors.Track(err).
 			WithContext("url", req.URL).
@@ -255,21 +342,37 @@ func (c *Client) executeRequest(ctx context.Context, req *Request) (*Response, e
 	for k, v := range c.defaultHeaders {
 		httpReq.Header.Set(k, v)
 	}
+	if req.FormData != nil {
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if req.JSONData != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
 	for k, v := range req.Headers {
 		httpReq.Header.Set(k, v)
 	}
 
-	// Set timeout
-	if req.Timeout > 0 {
-		ctx, cancel := context.WithTimeout(ctx, req.Timeout)
-		defer cancel()
-		httpReq = httpReq.WithContext(ctx)
-	}
+	// Each attempt gets the request budget, which covers reading the body
+	reqCtx, cancel := WithBudget(ctx, BudgetRequest, req.Timeout)
+	defer cancel()
+	httpReq = httpReq.WithContext(reqCtx)
 
 	// Execute request
-	c.logger.Debug("[HTTP] %s request to %s", req.Method, req.URL)
+	log := logger.FromContext(ctx, c.logger)
+	log.Debug("[HTTP] %s request to %s", req.Method, req.URL)
+	traced := logger.Tracing(ctx)
+	if traced {
+		log.Debug("[HTTP] Request headers: %v", redactHeaders(httpReq.Header))
+	}
+	started := time.Now()
 	httpResp, err := c.http.Do(httpReq)
 	if err != nil {
+		if budgetErr := BudgetError(reqCtx, ctx); budgetErr != nil {
+			return nil, errors.Track(budgetErr).
+				WithContext("url", req.URL).
+				WithContext("method", req.Method).
+				Error()
+		}
 		return nil, errors.Track(err).
 			WithContext("url", req.URL).
 			WithContext("method", req.Method).
@@ -287,13 +390,33 @@ func (c *Client) executeRequest(ctx context.Context, req *Request) (*Response, e
 	// Create response using the newResponse helper from types.go
 	resp, err := newResponse(httpResp)
 	if err != nil {
+		if budgetErr := BudgetError(reqCtx, ctx); budgetErr != nil {
+			return nil, errors.Track(budgetErr).WithContext("url", req.URL).Error()
+		}
 		return nil, err
 	}
+	resp.Endpoint = req.Endpoint
 
-	c.logger.Debug("[HTTP] %s %s - Status: %d", req.Method, req.URL, resp.StatusCode)
+	log.Debug("[HTTP] %s %s - Status: %d", req.Method, req.URL, resp.StatusCode)
+	if traced {
+		log.Debug("[HTTP] Response of %s: %d bytes of %s in %v", req.URL, len(resp.Body),
+			resp.Headers.Get("Content-Type"), time.Since(started).Round(time.Millisecond))
+		resp.trace = log
+	}
 	return resp, nil
 }
 
+// redactHeaders returns request headers for the log, without their credentials
+func redactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, name := range []string{"Authorization", "Proxy-Authorization", "Cookie"} {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "[redacted]")
+		}
+	}
+	return redacted
+}
+
 // SetDefaultHeader sets a default header for all requests
 func (c *Client) SetDefaultHeader(key, value string) {
 	c.defaultHeaders[key] = value
@@ -301,7 +424,38 @@ func (c *Client) SetDefaultHeader(key, value string) {
 
 // SetDefaultTimeout sets the default timeout for requests
 func (c *Client) SetDefaultTimeout(timeout time.Duration) {
-	c.defaultTimeout = timeout
+	c.defaultTimeout.Store(int64(timeout))
+}
+
+// SetHTTPClient replaces the HTTP client requests are sent with. It is meant to be called
+// before the first request
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.http = client
+}
+
+// SetRateLimits sets the minimum delay between requests per host, taking precedence over
+// the rate limit of each request. It is meant to be called before the first request
+func (c *Client) SetRateLimits(limits map[string]time.Duration) {
+	c.rateLimits = make(map[string]time.Duration, len(limits))
+	for host, delay := range limits {
+		c.rateLimits[host] = delay
+	}
+}
+
+// SetOffline makes every request fail with ErrOffline, or sends them again
+func (c *Client) SetOffline(offline bool) {
+	c.offline.Store(offline)
+}
+
+// Offline reports whether requests are refused
+func (c *Client) Offline() bool {
+	return c.offline.Load()
+}
+
+// SetCooldownFile saves the cooldowns of rate limited sites to path, and loads those of
+// earlier runs from it
+func (c *Client) SetCooldownFile(path string) error {
+	return c.limiter.SetCooldownFile(path)
 }
 
 // SetDefaultRetries sets the default number of retries
@@ -0,0 +1,289 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Luminary/pkg/errors"
+)
+
+// ErrCoolingDown is returned for requests to a site that asked for a pause longer than
+// MaxCooldownWait
+var ErrCoolingDown = stderrors.New("site is cooling down after rate limiting")
+
+const (
+	// MaxCooldownWait is the longest requests wait for a cooldown; they fail with
+	// ErrCoolingDown when more of it is left
+	MaxCooldownWait = time.Minute
+
+	// Cooldowns chosen after rate limiting without a Retry-After start at minBackoff and
+	// double with every further rate limit up to maxBackoff
+	minBackoff = 5 * time.Second
+	maxBackoff = 10 * time.Minute
+
+	// cooldownMemory is how long a passed cooldown is remembered, so being rate limited
+	// again soon after backs off further
+	cooldownMemory = time.Hour
+)
+
+// cooldownState is the saved cooldown of a domain. Updated is when it last changed, so
+// runs saving the file at the same time keep the newest of each other's cooldowns; a
+// forgotten cooldown is kept without Until for a while, so older ones don't return.
+type cooldownState struct {
+	Until     time.Time `json:"until"`
+	BackoffMS int64     `json:"backoff_ms,omitempty"`
+	Updated   time.Time `json:"updated,omitempty"`
+}
+
+// stale reports whether the state is no longer worth remembering
+func (s cooldownState) stale() bool {
+	return time.Since(s.Until) > cooldownMemory && time.Since(s.Updated) > cooldownMemory
+}
+
+// SetCooldownFile makes the limiter save the cooldowns of rate limited domains to path and
+// loads those saved by earlier runs, so a command run right after another doesn't trip the
+// site's rate limiting again
+func (r *RateLimiter) SetCooldownFile(path string) error {
+	r.cooldownMutex.Lock()
+	r.cooldownFile = path
+	r.cooldownMutex.Unlock()
+
+	states, err := readCooldowns(path)
+	if err != nil {
+		return err
+	}
+	for domain, state := range states {
+		limiter := r.getLimiter(domain)
+		limiter.mu.Lock()
+		limiter.cooldownUntil = state.Until
+		limiter.backoff = time.Duration(state.BackoffMS) * time.Millisecond
+		limiter.cooldownUpdated = state.Updated
+		limiter.mu.Unlock()
+	}
+	return nil
+}
+
+// readCooldowns reads the cooldowns saved at path that are still worth remembering; a
+// missing file has none
+func readCooldowns(path string) (map[string]cooldownState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]cooldownState{}, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	var states map[string]cooldownState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("The saved rate limit cooldowns are not valid JSON").
+			AsParser().Error()
+	}
+	for domain, state := range states {
+		if state.stale() {
+			delete(states, domain)
+		}
+	}
+	if states == nil {
+		states = map[string]cooldownState{}
+	}
+	return states, nil
+}
+
+// Throttled starts a cooldown of a domain that rate limited a request. The cooldown lasts
+// retryAfter as the site asked, or backs off further than the last one without it.
+func (r *RateLimiter) Throttled(domain string, retryAfter time.Duration) time.Time {
+	limiter := r.getLimiter(domain)
+	limiter.mu.Lock()
+	if retryAfter <= 0 {
+		limiter.backoff = min(max(limiter.backoff*2, minBackoff), maxBackoff)
+		retryAfter = limiter.backoff
+	}
+	if until := time.Now().Add(retryAfter); until.After(limiter.cooldownUntil) {
+		limiter.cooldownUntil = until
+	}
+	limiter.cooldownUpdated = time.Now()
+	until := limiter.cooldownUntil
+	limiter.mu.Unlock()
+
+	r.saveCooldowns()
+	return until
+}
+
+// Recovered forgets the cooldown of a domain that answered a request normally
+func (r *RateLimiter) Recovered(domain string) {
+	r.mu.RLock()
+	limiter, exists := r.domains[domain]
+	r.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	limiter.mu.Lock()
+	changed := limiter.backoff != 0 || !limiter.cooldownUntil.IsZero()
+	limiter.backoff = 0
+	limiter.cooldownUntil = time.Time{}
+	if changed {
+		limiter.cooldownUpdated = time.Now()
+	}
+	limiter.mu.Unlock()
+
+	if changed {
+		r.saveCooldowns()
+	}
+}
+
+// CooldownUntil returns when the cooldown of a domain ends, or the zero time without one
+func (r *RateLimiter) CooldownUntil(domain string) time.Time {
+	limiter := r.getLimiter(domain)
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if time.Now().After(limiter.cooldownUntil) {
+		return time.Time{}
+	}
+	return limiter.cooldownUntil
+}
+
+// saveCooldowns merges the cooldowns this run changed into the cooldown file, if set.
+// Other runs may save theirs at the same time, so the file is read again and the newer
+// state of each domain kept, and the merged file is renamed into place whole. Failing to
+// save only loses the cooldowns for the next run, so errors are ignored.
+func (r *RateLimiter) saveCooldowns() {
+	r.cooldownMutex.Lock()
+	defer r.cooldownMutex.Unlock()
+	if r.cooldownFile == "" {
+		return
+	}
+
+	states, err := readCooldowns(r.cooldownFile)
+	if err != nil {
+		states = map[string]cooldownState{}
+	}
+	// ResetAll forgets the cooldowns saved before it, including those of other runs
+	for domain, state := range states {
+		if !r.resetAt.IsZero() && !state.Updated.After(r.resetAt) {
+			states[domain] = cooldownState{Updated: r.resetAt}
+		}
+	}
+	r.mu.RLock()
+	for domain, limiter := range r.domains {
+		limiter.mu.Lock()
+		state := cooldownState{Until: limiter.cooldownUntil, BackoffMS: limiter.backoff.Milliseconds(), Updated: limiter.cooldownUpdated}
+		limiter.mu.Unlock()
+
+		// Domains this run never rate limited or recovered leave the saved state alone
+		if state.Updated.IsZero() || states[domain].Updated.After(state.Updated) {
+			continue
+		}
+		states[domain] = state
+	}
+	r.mu.RUnlock()
+	for domain, state := range states {
+		if state.stale() {
+			delete(states, domain)
+		}
+	}
+
+	if len(states) == 0 {
+		_ = os.Remove(r.cooldownFile)
+		return
+	}
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(r.cooldownFile), 0755)
+	// Every run writes its own temporary file, so concurrent saves don't mix
+	temp, err := os.CreateTemp(filepath.Dir(r.cooldownFile), filepath.Base(r.cooldownFile)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), r.cooldownFile)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+}
+
+// cooldown waits for the cooldown of the domain to pass, failing with ErrCoolingDown when
+// it lasts longer than MaxCooldownWait
+func (l *domainLimiter) cooldown(ctx context.Context, domain string) error {
+	l.mu.Lock()
+	until := l.cooldownUntil
+	l.mu.Unlock()
+
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		return nil
+	}
+	if remaining > MaxCooldownWait {
+		return coolingDownError(domain, until)
+	}
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Track(ctx.Err()).
+			WithContext("wait_time", remaining).
+			AsNetwork().
+			Error()
+	}
+}
+
+// coolingDownError is the error of requests to a domain cooling down until until
+func coolingDownError(domain string, until time.Time) error {
+	return errors.Track(ErrCoolingDown).
+		WithContext("domain", domain).
+		WithContext("until", until.Format(time.RFC3339)).
+		WithMessagef("%s rate limited Luminary; try again after %s", domain, until.Format(time.TimeOnly)).
+		AsRateLimit().Error()
+}
+
+// ParseRetryAfter returns the pause a Retry-After header asks for, given in seconds or as
+// an HTTP date, or 0 without a valid one
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
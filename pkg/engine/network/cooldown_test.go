@@ -0,0 +1,183 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Luminary/pkg/engine/logger"
+)
+
+func TestCooldownsMergeAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldowns.json")
+	first, second := NewRateLimiter(), NewRateLimiter()
+	for _, r := range []*RateLimiter{first, second} {
+		if err := r.SetCooldownFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.Throttled("a.example", time.Minute)
+	second.Throttled("b.example", time.Minute)
+	saved, err := readCooldowns(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cooling(saved, "a.example") || !cooling(saved, "b.example") {
+		t.Fatalf("saved %v, want the cooldowns of both runs", saved)
+	}
+
+	// The second run recovering from a.example is newer than the first run's cooldown
+	second.Recovered("b.example")
+	second.Throttled("a.example", 2*time.Minute)
+	second.Recovered("a.example")
+	first.Throttled("c.example", time.Minute)
+	saved, err = readCooldowns(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cooling(saved, "a.example") {
+		t.Error("an older cooldown overwrote the newer recovery")
+	}
+	if cooling(saved, "b.example") {
+		t.Error("the recovered cooldown was kept")
+	}
+	if !cooling(saved, "c.example") {
+		t.Error("the new cooldown was not saved")
+	}
+
+	third := NewRateLimiter()
+	if err := third.SetCooldownFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if third.CooldownUntil("c.example").IsZero() {
+		t.Error("a later run did not load the saved cooldown")
+	}
+}
+
+func TestResetRemovesSavedCooldowns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldowns.json")
+	r, other := NewRateLimiter(), NewRateLimiter()
+	for _, limiter := range []*RateLimiter{r, other} {
+		if err := limiter.SetCooldownFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.Throttled("a.example", time.Minute)
+	r.Throttled("b.example", time.Minute)
+	other.Throttled("c.example", time.Minute)
+
+	r.Reset("a.example")
+	saved, err := readCooldowns(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cooling(saved, "a.example") || !cooling(saved, "b.example") || !cooling(saved, "c.example") {
+		t.Errorf("after Reset the file has %v", saved)
+	}
+	if !r.CooldownUntil("a.example").IsZero() {
+		t.Error("Reset kept the cooldown in memory")
+	}
+
+	r.ResetAll()
+	saved, err = readCooldowns(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for domain := range saved {
+		if cooling(saved, domain) {
+			t.Errorf("after ResetAll the file has %v", saved)
+		}
+	}
+
+	// The other run's older cooldown doesn't come back when it saves again
+	other.Throttled("d.example", time.Minute)
+	if saved, err = readCooldowns(path); err != nil {
+		t.Fatal(err)
+	}
+	if cooling(saved, "c.example") || !cooling(saved, "d.example") {
+		t.Errorf("after another save the file has %v", saved)
+	}
+}
+
+// cooling reports whether the saved cooldowns have a domain cooling down
+func cooling(saved map[string]cooldownState, domain string) bool {
+	return !saved[domain].Until.IsZero()
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"padded", " 5 ", 5 * time.Second},
+		{"negative", "-3", 0},
+		{"invalid", "soon", 0},
+		{"past date", "Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(date); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("ParseRetryAfter(%q) = %v, want about an hour", date, got)
+	}
+}
+
+func TestServiceUnavailableWaitsForRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c := NewClient(logger.NewService(""))
+	start := time.Now()
+	resp, err := c.Do(context.Background(), &Request{URL: server.URL, MaxRetries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Fatalf("status %d after %d requests", resp.StatusCode, requests.Load())
+	}
+	// The retry backs off 1s on its own; the site asked for 2s
+	if elapsed := time.Since(start); elapsed < 2*time.Second-100*time.Millisecond {
+		t.Errorf("retried after %v, before the Retry-After passed", elapsed)
+	}
+	if !c.limiter.CooldownUntil(ExtractDomain(server.URL)).IsZero() {
+		t.Error("the cooldown was not forgotten after the site answered")
+	}
+}
@@ -0,0 +1,212 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"Luminary/pkg/errors"
+	"cmp"
+	"context"
+	stderrors "errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrRequestBudget is returned for requests made after the request budget of a run ran out
+var ErrRequestBudget = stderrors.New("request budget exhausted")
+
+// minCountedWait is the shortest rate limit wait counted in a footprint; shorter ones are
+// scheduling noise
+const minCountedWait = 10 * time.Millisecond
+
+// SourceFootprint counts what a run asked of one provider, or of one site for requests
+// not made for a provider
+type SourceFootprint struct {
+	Source    string `json:"source"`
+	Requests  int    `json:"requests"` // Attempts, retries included
+	Bytes     int64  `json:"bytes"`    // Size of the response bodies
+	CacheHits int    `json:"cache_hits"`
+	// Waits counts the requests held back by the rate limit or a cooldown, for Waited in total
+	Waits     int           `json:"rate_limit_waits"`
+	Waited    time.Duration `json:"waited_ns"`
+	Throttled int           `json:"throttled"` // Responses asking us to slow down
+}
+
+// Footprint tallies the requests of a run by provider and may cap how many are sent
+type Footprint struct {
+	mu       sync.Mutex
+	limit    int
+	requests int
+	refused  error // The first request refused for the budget
+	sources  map[string]*SourceFootprint
+}
+
+type footprintKey struct{}
+
+type sourceKey struct{}
+
+// WithFootprint returns a context whose requests are counted in the returned footprint.
+// With a limit above zero, requests beyond it fail with ErrRequestBudget.
+func WithFootprint(ctx context.Context, limit int) (context.Context, *Footprint) {
+	footprint := &Footprint{limit: max(limit, 0), sources: make(map[string]*SourceFootprint)}
+	return context.WithValue(ctx, footprintKey{}, footprint), footprint
+}
+
+// footprintFrom returns the footprint of ctx, or nil
+func footprintFrom(ctx context.Context) *Footprint {
+	footprint, _ := ctx.Value(footprintKey{}).(*Footprint)
+	return footprint
+}
+
+// WithSource returns a context whose requests count towards the provider in footprints;
+// other requests count towards their site
+func WithSource(ctx context.Context, providerID string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, providerID)
+}
+
+// sourceOf names what a request counts towards
+func sourceOf(ctx context.Context, rawURL string) string {
+	if source, _ := ctx.Value(sourceKey{}).(string); source != "" {
+		return source
+	}
+	return ExtractDomain(rawURL)
+}
+
+// RecordCacheHit counts a lookup of the provider answered without a request
+func RecordCacheHit(ctx context.Context, providerID string) {
+	if f := footprintFrom(ctx); f != nil {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.source(providerID).CacheHits++
+	}
+}
+
+// RequestBudgetError returns the error of the first request refused because the request
+// budget of ctx ran out, or nil while it lasts. Bulk work stops on it instead of failing
+// every item left.
+func RequestBudgetError(ctx context.Context) error {
+	f := footprintFrom(ctx)
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.refused
+}
+
+// Sources returns the tallies of every provider and site, most requested first
+func (f *Footprint) Sources() []SourceFootprint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sources := make([]SourceFootprint, 0, len(f.sources))
+	for _, source := range f.sources {
+		sources = append(sources, *source)
+	}
+	slices.SortFunc(sources, func(a, b SourceFootprint) int {
+		if c := cmp.Compare(b.Requests, a.Requests); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Source, b.Source)
+	})
+	return sources
+}
+
+// Total sums the tallies of every provider and site
+func (f *Footprint) Total() SourceFootprint {
+	total := SourceFootprint{Source: "total"}
+	for _, source := range f.Sources() {
+		total.Requests += source.Requests
+		total.Bytes += source.Bytes
+		total.CacheHits += source.CacheHits
+		total.Waits += source.Waits
+		total.Waited += source.Waited
+		total.Throttled += source.Throttled
+	}
+	return total
+}
+
+// Limit returns the request budget, 0 when there is none
+func (f *Footprint) Limit() int {
+	return f.limit
+}
+
+// source returns the tally of a provider or site; f.mu must be held
+func (f *Footprint) source(name string) *SourceFootprint {
+	source, ok := f.sources[name]
+	if !ok {
+		source = &SourceFootprint{Source: name}
+		f.sources[name] = source
+	}
+	return source
+}
+
+// reserveRequest counts a request attempt against the budget of ctx, failing once it is spent
+func reserveRequest(ctx context.Context, req *Request) error {
+	f := footprintFrom(ctx)
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.limit > 0 && f.requests >= f.limit {
+		err := errors.Track(ErrRequestBudget).
+			WithContext("url", req.URL).
+			WithContext("limit", f.limit).
+			WithMessagef("Stopped after the request budget of %d requests ran out", f.limit).
+			AsNetwork().
+			Error()
+		if f.refused == nil {
+			f.refused = err
+		}
+		return err
+	}
+	f.requests++
+	f.source(sourceOf(ctx, req.URL)).Requests++
+	return nil
+}
+
+// recordFootprint adds a response to the footprint of ctx, if any
+func recordFootprint(ctx context.Context, req *Request, resp *Response, throttled bool) {
+	f := footprintFrom(ctx)
+	if f == nil || resp == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source := f.source(sourceOf(ctx, req.URL))
+	source.Bytes += int64(len(resp.Body))
+	if throttled {
+		source.Throttled++
+	}
+}
+
+// recordWait adds time a request was held back by the rate limit to the footprint of ctx
+func recordWait(ctx context.Context, req *Request, waited time.Duration) {
+	f := footprintFrom(ctx)
+	if f == nil || waited < minCountedWait {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source := f.source(sourceOf(ctx, req.URL))
+	source.Waits++
+	source.Waited += waited
+}
@@ -29,13 +29,25 @@ import (
 type RateLimiter struct {
 	domains map[string]*domainLimiter
 	mu      sync.RWMutex
+
+	// Cooldowns are saved here, see SetCooldownFile; cooldowns saved before resetAt were
+	// cleared by ResetAll
+	cooldownFile  string
+	resetAt       time.Time
+	cooldownMutex sync.Mutex
 }
 
 // domainLimiter tracks rate limiting for a specific domain
 type domainLimiter struct {
 	lastRequest time.Time
 	delay       time.Duration
-	mu          sync.Mutex
+	// No requests are sent before cooldownUntil, which the site asked for or the limiter
+	// chose after being rate limited; backoff is the last cooldown it chose
+	cooldownUntil time.Time
+	backoff       time.Duration
+	// cooldownUpdated is when the cooldown was last started or forgotten, zero if never
+	cooldownUpdated time.Time
+	mu              sync.Mutex
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -53,12 +65,18 @@ func (r *RateLimiter) Wait(ctx context.Context, rawURL string, delay time.Durati
 	}
 
 	limiter := r.getLimiter(domain)
+	if err := limiter.cooldown(ctx, domain); err != nil {
+		return err
+	}
 	return limiter.wait(ctx, delay)
 }
 
 // WaitForDomain enforces rate limiting for a specific domain
 func (r *RateLimiter) WaitForDomain(ctx context.Context, domain string, delay time.Duration) error {
 	limiter := r.getLimiter(domain)
+	if err := limiter.cooldown(ctx, domain); err != nil {
+		return err
+	}
 	return limiter.wait(ctx, delay)
 }
 
@@ -70,18 +88,25 @@ func (r *RateLimiter) SetDefaultDelay(domain string, delay time.Duration) {
 	limiter.mu.Unlock()
 }
 
-// Reset clears rate limiting for a domain
+// Reset clears rate limiting for a domain, removing its saved cooldown as well
 func (r *RateLimiter) Reset(domain string) {
 	r.mu.Lock()
-	delete(r.domains, domain)
+	r.domains[domain] = &domainLimiter{cooldownUpdated: time.Now()}
 	r.mu.Unlock()
+	r.saveCooldowns()
 }
 
-// ResetAll clears all rate limiting
+// ResetAll clears all rate limiting, removing the saved cooldowns as well
 func (r *RateLimiter) ResetAll() {
+	now := time.Now()
+	r.cooldownMutex.Lock()
+	r.resetAt = now
+	r.cooldownMutex.Unlock()
+
 	r.mu.Lock()
 	r.domains = make(map[string]*domainLimiter)
 	r.mu.Unlock()
+	r.saveCooldowns()
 }
 
 // getLimiter returns or creates a limiter for a domain
@@ -0,0 +1,252 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordIndexFile is the file of a recording directory listing the recorded responses
+const RecordIndexFile = "index.jsonl"
+
+// RecordEntry describes a recorded response in the index of its recording
+type RecordEntry struct {
+	Time        time.Time `json:"time"`
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	Endpoint    string    `json:"endpoint,omitempty"`
+	Request     any       `json:"request,omitempty"` // Form or JSON data of POST requests, without credentials
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	File        string    `json:"file"` // Body, relative to the recording directory
+}
+
+// Recorder saves the raw bodies of text responses (HTML, JSON, XML and the like) received
+// with a context from WithRecorder, so broken selectors can be reproduced from them
+type Recorder struct {
+	dir string
+
+	mu     sync.Mutex
+	count  int
+	failed int   // Responses that couldn't be saved
+	err    error // First error saving a response
+}
+
+type recorderKey struct{}
+
+// NewRecorder returns a recorder writing to dir, creating it if needed
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Track(err).WithContext("directory", dir).AsFileSystem().Error()
+	}
+	return &Recorder{dir: dir}, nil
+}
+
+// WithRecorder returns a context whose responses are saved by recorder
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// recorderFrom returns the recorder of ctx, or nil
+func recorderFrom(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+// Dir returns the directory the recorder writes to
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// Count returns how many responses were recorded
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Err returns the first error saving a response and how many couldn't be saved
+func (r *Recorder) Err() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed, r.err
+}
+
+// fail remembers a response that couldn't be saved; the caller holds mu
+func (r *Recorder) fail(err error) {
+	r.failed++
+	if r.err == nil {
+		r.err = errors.Track(err).WithContext("directory", r.dir).AsFileSystem().Error()
+	}
+}
+
+// recordSlug keeps file names of recorded bodies to safe characters
+var recordSlug = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// recordResponse saves the body of resp to the recorder of ctx, if any. Images and other
+// binary bodies are left out. Failures are kept for Recorder.Err rather than returned, so
+// recording never breaks a request.
+func recordResponse(ctx context.Context, req *Request, resp *Response) {
+	recorder := recorderFrom(ctx)
+	if recorder == nil || resp == nil {
+		return
+	}
+	contentType := resp.Headers.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(resp.Body)
+	}
+	ext, ok := recordExtension(contentType)
+	if !ok {
+		return
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	name := ExtractDomain(req.URL)
+	if req.Endpoint != "" {
+		name += "-" + req.Endpoint
+	} else if u, err := url.Parse(req.URL); err == nil {
+		name += "-" + strings.Trim(u.Path, "/")
+	}
+	name = strings.Trim(recordSlug.ReplaceAllString(name, "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	file := fmt.Sprintf("%04d-%s%s", recorder.count+1, name, ext)
+	if err := os.WriteFile(filepath.Join(recorder.dir, file), resp.Body, 0644); err != nil {
+		recorder.fail(err)
+		return
+	}
+	recorder.count++
+
+	entry := RecordEntry{
+		Time:        time.Now(),
+		Method:      req.Method,
+		URL:         req.URL,
+		Endpoint:    req.Endpoint,
+		Status:      resp.StatusCode,
+		ContentType: contentType,
+		File:        file,
+	}
+	switch {
+	case req.JSONData != nil:
+		entry.Request = redactJSON(req.JSONData)
+	case len(req.FormData) > 0:
+		entry.Request = redactForm(req.FormData)
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		recorder.fail(err)
+		return
+	}
+	index, err := os.OpenFile(filepath.Join(recorder.dir, RecordIndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		recorder.fail(err)
+		return
+	}
+	_, err = index.Write(append(line, '\n'))
+	if closeErr := index.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		recorder.fail(err)
+	}
+}
+
+// secretField matches the names of request fields holding credentials
+var secretField = regexp.MustCompile(`(?i)passw|^pass$|secret|token|session|cookie|csrf|credential|authorization|^auth$|api_?key|^key$|^otp$`)
+
+// redactForm returns form data for a recording, without its credentials
+func redactForm(form url.Values) url.Values {
+	redacted := make(url.Values, len(form))
+	for name, values := range form {
+		if secretField.MatchString(name) {
+			values = []string{"[redacted]"}
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// redactJSON returns JSON request data for a recording, without the credentials in its
+// objects at any depth
+func redactJSON(data any) any {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	return redactValue(value)
+}
+
+// redactValue redacts the credentials of a decoded JSON value
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if secretField.MatchString(name) {
+				v[name] = "[redacted]"
+			} else {
+				v[name] = redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// recordExtension returns the file extension for bodies of a content type, and false for
+// binary ones that aren't recorded
+func recordExtension(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	switch {
+	case strings.Contains(mediaType, "json"):
+		return ".json", true
+	case strings.Contains(mediaType, "html"):
+		return ".html", true
+	case strings.Contains(mediaType, "xml"):
+		return ".xml", true
+	case strings.Contains(mediaType, "javascript"):
+		return ".js", true
+	case strings.HasPrefix(mediaType, "text/"):
+		return ".txt", true
+	default:
+		return "", false
+	}
+}
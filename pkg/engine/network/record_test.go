@@ -0,0 +1,99 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordRedactsCredentials(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithRecorder(context.Background(), recorder)
+	resp := &Response{StatusCode: 200, Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{}`)}
+
+	recordResponse(ctx, &Request{Method: "POST", URL: "https://example.com/login", FormData: url.Values{
+		"username": {"me"}, "password": {"hunter2"}, "keyword": {"blue"},
+	}}, resp)
+	recordResponse(ctx, &Request{Method: "POST", URL: "https://example.com/api", JSONData: map[string]any{
+		"query":   "blue",
+		"session": map[string]string{"token": "abc"},
+		"auth":    []string{"x"},
+		"filters": []map[string]string{{"api_key": "k", "author": "Oda"}},
+	}}, resp)
+	if recorder.Count() != 2 {
+		t.Fatalf("recorded %d responses, want 2", recorder.Count())
+	}
+
+	index, err := os.ReadFile(filepath.Join(recorder.Dir(), RecordIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "abc", `"x"`, `"k"`} {
+		if strings.Contains(string(index), secret) {
+			t.Errorf("index holds %s:\n%s", secret, index)
+		}
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(index)))
+	var entries []RecordEntry
+	for scanner.Scan() {
+		var entry RecordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	form, _ := entries[0].Request.(map[string]any)
+	if len(entries) != 2 || form["username"] == nil || form["keyword"] == nil {
+		t.Errorf("entries = %+v, want the other fields kept", entries)
+	}
+	if !strings.Contains(string(index), "Oda") {
+		t.Errorf("index lost a field that isn't a credential:\n%s", index)
+	}
+}
+
+func TestRecordReportsWriteErrors(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "responses")
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRecorder(context.Background(), recorder)
+	resp := &Response{StatusCode: 200, Headers: http.Header{"Content-Type": {"text/html"}}, Body: []byte("<p>")}
+	recordResponse(ctx, &Request{Method: "GET", URL: "https://example.com/"}, resp)
+
+	if recorder.Count() != 0 {
+		t.Errorf("counted %d responses that weren't saved", recorder.Count())
+	}
+	if failed, err := recorder.Err(); failed != 1 || err == nil {
+		t.Errorf("Err() = %d, %v; want the failed write", failed, err)
+	}
+}
@@ -17,6 +17,7 @@
 package network
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,8 +26,11 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"Luminary/pkg/engine/logger"
 	"Luminary/pkg/engine/parser/html"
+	"Luminary/pkg/engine/parser/protowire"
 	"Luminary/pkg/errors"
 )
 
@@ -44,6 +48,12 @@ type Request struct {
 
 	// Form data (for POST requests)
 	FormData url.Values
+	// JSONData is marshalled to JSON and sent as the body instead of Body, with a JSON
+	// content type, for API POST requests. Any value encoding/json accepts will do.
+	JSONData any
+
+	// Endpoint names the API endpoint for error reporting (e.g. "search")
+	Endpoint string
 }
 
 // Response represents an HTTP response with parsed content
@@ -54,12 +64,16 @@ type Response struct {
 	Body       []byte
 
 	// Request info
-	URL    string
-	Method string
+	URL      string
+	Method   string
+	Endpoint string
 
 	// Lazy-parsed content
 	json json.RawMessage
 	html *html.Parser
+
+	// trace logs the selectors queried on the HTML of traced requests
+	trace logger.Logger
 }
 
 // newResponse creates a Response from an http.Response
@@ -117,7 +131,15 @@ func newResponse(httpResp *http.Response) (*Response, error) {
 	return resp, nil
 }
 
-// JSON unmarshal the response body as JSON
+// Validator is implemented by response types that can check the decoded data for
+// fields the API must return
+type Validator interface {
+	Validate() error
+}
+
+// JSON unmarshal the response body as JSON. HTML pages, malformed JSON, values of
+// the wrong type and, for types implementing Validator, missing data are reported as
+// parser errors naming the endpoint and including an excerpt of the body.
 func (r *Response) JSON(v interface{}) error {
 	// Defensive check for nil receiver
 	if r == nil {
@@ -131,29 +153,136 @@ func (r *Response) JSON(v interface{}) error {
 
 	// Handle empty body
 	if len(r.json) == 0 {
-		return errors.Track(fmt.Errorf("cannot parse JSON from empty response body")).
-			WithContext("url", r.URL).
-			AsParser().Error()
+		return r.decodeError(fmt.Errorf("cannot parse JSON from empty response body")).
+			WithMessagef("%s returned an empty response (HTTP %d)", r.endpointName(), r.StatusCode).
+			Error()
+	}
+
+	// Error pages, challenges and maintenance notices are usually HTML
+	if r.IsHTML() || bytes.HasPrefix(bytes.TrimSpace(r.json), []byte("<")) {
+		message := fmt.Sprintf("%s returned an HTML page where JSON was expected (HTTP %d)", r.endpointName(), r.StatusCode)
+		if doc, err := r.HTML(); err == nil {
+			if title := strings.TrimSpace(doc.Title()); title != "" {
+				message += ": " + title
+			}
+		}
+		return r.decodeError(fmt.Errorf("expected JSON, received HTML")).WithMessage(message).Error()
 	}
 
 	if err := json.Unmarshal(r.json, v); err != nil {
-		// Create preview safely, handling nil Body
-		preview := "empty response"
-		if r.Body != nil && len(r.Body) > 0 {
-			previewLen := min(len(r.Body), 200)
-			preview = string(r.Body[:previewLen])
+		var (
+			syntaxErr *json.SyntaxError
+			typeErr   *json.UnmarshalTypeError
+			message   string
+		)
+		switch {
+		case errors.As(err, &syntaxErr):
+			message = fmt.Sprintf("%s returned malformed JSON (at byte %d)", r.endpointName(), syntaxErr.Offset)
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			message = fmt.Sprintf("%s returned %s for %q where %s was expected",
+				r.endpointName(), typeErr.Value, typeErr.Field, typeErr.Type)
+		case errors.As(err, &typeErr):
+			message = fmt.Sprintf("%s returned %s where %s was expected", r.endpointName(), typeErr.Value, typeErr.Type)
+		default:
+			message = fmt.Sprintf("%s returned a response that could not be decoded", r.endpointName())
 		}
+		return r.decodeError(err).WithMessage(message).Error()
+	}
 
-		return errors.Track(err).
-			WithContext("url", r.URL).
-			WithContext("response_preview", preview).
-			WithContext("body_length", len(r.Body)).
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return r.decodeError(err).
+				WithMessagef("%s returned an unexpected response: %v", r.endpointName(), err).
+				Error()
+		}
+	}
+
+	return nil
+}
+
+// Proto decodes a protocol buffers response body into m, reporting undecodable bodies
+// like JSON does
+func (r *Response) Proto(m protowire.Message) error {
+	if r == nil {
+		return errors.Track(fmt.Errorf("cannot parse protobuf: response is nil")).
 			AsParser().Error()
 	}
 
+	if r.IsHTML() {
+		message := fmt.Sprintf("%s returned an HTML page where protobuf was expected (HTTP %d)", r.endpointName(), r.StatusCode)
+		if doc, err := r.HTML(); err == nil {
+			if title := strings.TrimSpace(doc.Title()); title != "" {
+				message += ": " + title
+			}
+		}
+		return r.decodeError(fmt.Errorf("expected protobuf, received HTML")).WithMessage(message).Error()
+	}
+
+	if err := m.UnmarshalProto(r.Body); err != nil {
+		return r.decodeError(err).
+			WithMessagef("%s returned a protobuf message that could not be decoded: %v", r.endpointName(), err).
+			Error()
+	}
+
+	if validator, ok := m.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return r.decodeError(err).
+				WithMessagef("%s returned an unexpected response: %v", r.endpointName(), err).
+				Error()
+		}
+	}
+
 	return nil
 }
 
+// decodeError builds a parser error carrying the endpoint, status and a body excerpt
+func (r *Response) decodeError(err error) *errors.ErrorBuilder {
+	b := errors.Track(err).
+		WithContext("url", r.URL).
+		WithContext("status", r.StatusCode).
+		WithContext("body_length", len(r.Body)).
+		WithContext("body_excerpt", bodyExcerpt(r.Body, 200)).
+		AsParser()
+	if r.Endpoint != "" {
+		b = b.WithContext("endpoint", r.Endpoint)
+	}
+	return b
+}
+
+// endpointName describes the endpoint for messages
+func (r *Response) endpointName() string {
+	if r.Endpoint != "" {
+		return fmt.Sprintf("The %s endpoint", r.Endpoint)
+	}
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "The server"
+}
+
+// bodyExcerpt returns up to n bytes of a body with whitespace collapsed
+func bodyExcerpt(body []byte, n int) string {
+	if len(body) == 0 {
+		return "empty response"
+	}
+	excerpt := strings.Join(strings.Fields(cutRunes(string(body), n*2)), " ")
+	if len(excerpt) > n {
+		excerpt = cutRunes(excerpt, n) + "..."
+	}
+	return excerpt
+}
+
+// cutRunes returns at most the first n bytes of s without splitting a UTF-8 sequence
+func cutRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 // HTML returns the parsed HTML document
 func (r *Response) HTML() (*html.Parser, error) {
 	if r.html == nil {
@@ -165,10 +294,34 @@ func (r *Response) HTML() (*html.Parser, error) {
 				AsParser().
 				Error()
 		}
+		r.traceHTML(r.html)
 	}
 	return r.html, nil
 }
 
+// HTMLFragment parses HTML embedded in the response, such as a fragment in a field of an
+// AJAX response; its selectors are traced like those of HTML
+func (r *Response) HTMLFragment(fragment string) (*html.Parser, error) {
+	doc, err := html.ParseString(fragment)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("url", r.URL).
+			AsParser().
+			Error()
+	}
+	r.traceHTML(doc)
+	return doc, nil
+}
+
+// traceHTML logs the selectors queried on HTML of the response when it is traced
+func (r *Response) traceHTML(doc *html.Parser) {
+	if log, url := r.trace, r.URL; log != nil {
+		doc.Trace(func(selector string, matches int) {
+			log.Debug("[HTML] %q matched %d element(s) on %s", selector, matches, url)
+		})
+	}
+}
+
 // Text returns the response body as a string
 func (r *Response) Text() string {
 	return string(r.Body)
@@ -0,0 +1,38 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package network
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBodyExcerptKeepsRunesWhole(t *testing.T) {
+	body := []byte(strings.Repeat("é", 150))
+	excerpt := bodyExcerpt(body, 99)
+	if !utf8.ValidString(excerpt) {
+		t.Fatalf("excerpt %q is not valid UTF-8", excerpt)
+	}
+	if want := strings.Repeat("é", 49) + "..."; excerpt != want {
+		t.Errorf("excerpt = %q, want %q", excerpt, want)
+	}
+
+	if got := bodyExcerpt([]byte("  short \n body "), 200); got != "short body" {
+		t.Errorf("excerpt = %q, want whitespace collapsed", got)
+	}
+}
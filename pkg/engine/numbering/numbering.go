@@ -0,0 +1,283 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package numbering
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/errors"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scheme decides how chapters are numbered
+type Scheme string
+
+// Numbering schemes
+const (
+	SchemeSource   Scheme = "source"   // As the site numbers them
+	SchemeAbsolute Scheme = "absolute" // Counting on across volumes: Vol.2 Ch.1 becomes Ch.9 after an 8 chapter volume
+	SchemeVolume   Scheme = "volume"   // Starting at 1 in every volume: Ch.9 becomes Vol.2 Ch.1
+)
+
+// Schemes lists every numbering scheme
+var Schemes = []Scheme{SchemeSource, SchemeAbsolute, SchemeVolume}
+
+// ParseScheme returns the numbering scheme with the given name
+func ParseScheme(name string) (Scheme, error) {
+	for _, scheme := range Schemes {
+		if strings.EqualFold(name, string(scheme)) {
+			return scheme, nil
+		}
+	}
+	return "", errors.Newf("unknown numbering scheme %q", name).
+		WithMessagef("Unknown numbering scheme %q, expected source, absolute or volume", name).
+		Error()
+}
+
+// Mapping corrects the numbers and volumes a site gives the chapters of a manga, read from
+// a mapping file like
+//
+//	{
+//	  "volumes": [{"volume": "1", "from": 1, "to": 8}, {"volume": "2", "from": 9}],
+//	  "chapters": {"10.5": {"number": 11, "title": "Omake"}, "a1b2c3": {"volume": "3"}}
+//	}
+type Mapping struct {
+	// Volumes puts the chapters whose numbers are in a range into a volume
+	Volumes []VolumeRange `json:"volumes,omitempty"`
+	// Chapters overrides single chapters, by chapter ID or by the number the site shows
+	Chapters map[string]ChapterOverride `json:"chapters,omitempty"`
+}
+
+// VolumeRange is a volume and the numbers of its chapters
+type VolumeRange struct {
+	Volume string  `json:"volume"`
+	From   float64 `json:"from"`
+	To     float64 `json:"to,omitempty"` // 0 leaves the range open
+}
+
+// ChapterOverride replaces the details of a chapter that are set
+type ChapterOverride struct {
+	Number *float64 `json:"number,omitempty"`
+	Label  string   `json:"label,omitempty"`
+	Volume string   `json:"volume,omitempty"`
+	Title  string   `json:"title,omitempty"`
+}
+
+// LoadMapping reads a mapping file
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	var mapping Mapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("The numbering mapping file is not valid JSON").
+			AsParser().Error()
+	}
+	for _, r := range mapping.Volumes {
+		if r.Volume == "" || (r.To > 0 && r.To < r.From) {
+			return nil, errors.Newf("invalid volume range %q: %g-%g", r.Volume, r.From, r.To).
+				WithContext("path", path).
+				WithMessage("Volume ranges of numbering mapping files need a volume and a range from low to high").
+				AsParser().Error()
+		}
+	}
+	return &mapping, nil
+}
+
+// Normalizer renumbers the chapters of a manga: the mapping corrects what the site got
+// wrong first, then the scheme renumbers the result
+type Normalizer struct {
+	Scheme  Scheme
+	Mapping *Mapping
+}
+
+// Active reports whether the normalizer changes anything
+func (n Normalizer) Active() bool {
+	return (n.Scheme != "" && n.Scheme != SchemeSource) || n.Mapping != nil
+}
+
+// Normalize returns the chapters of a manga renumbered; the chapters themselves are left
+// as they are. Numbering depends on the other chapters of the manga, so all of them should
+// be given, not a filtered list. Renumbered chapters lose labels such as "10.5 Omake"
+// that carried their old number; chapters without a number are kept as they are.
+func (n Normalizer) Normalize(chapters []core.ChapterInfo) []core.ChapterInfo {
+	normalized := slices.Clone(chapters)
+	if n.Mapping != nil {
+		for i := range normalized {
+			n.Mapping.apply(&normalized[i])
+		}
+	}
+
+	switch n.Scheme {
+	case SchemeAbsolute:
+		offsets := absoluteOffsets(normalized)
+		for i := range normalized {
+			renumber(&normalized[i], offsets[normalized[i].Volume])
+		}
+	case SchemeVolume:
+		bounds := volumeBounds(normalized)
+		for i, ch := range normalized {
+			// Chapters outside volumes keep their numbers
+			if b, ok := bounds[ch.Volume]; ok && ch.Volume != "" && b.low >= 1 {
+				renumber(&normalized[i], 1-math.Floor(b.low))
+			}
+		}
+	}
+	return normalized
+}
+
+// apply corrects a chapter as the mapping says
+func (m *Mapping) apply(ch *core.ChapterInfo) {
+	if ch.Number > 0 {
+		for _, r := range m.Volumes {
+			if ch.Number >= r.From && (r.To <= 0 || ch.Number <= r.To) {
+				ch.Volume = r.Volume
+				break
+			}
+		}
+	}
+
+	override, ok := m.Chapters[ch.ID]
+	if !ok {
+		override, ok = m.Chapters[ch.DisplayNumber()]
+	}
+	if !ok {
+		return
+	}
+	if override.Number != nil {
+		ch.Number = *override.Number
+		ch.Label = formatNumber(ch.Number)
+	}
+	if override.Label != "" {
+		ch.Label = override.Label
+	}
+	if override.Volume != "" {
+		ch.Volume = override.Volume
+	}
+	if override.Title != "" {
+		ch.Title = override.Title
+	}
+}
+
+// bounds are the lowest and highest chapter number of a volume
+type bounds struct {
+	low, high float64
+	first     int // Position of the volume's first chapter
+}
+
+// volumeBounds returns the bounds of every volume, chapters without a volume counting as
+// the volume ""
+func volumeBounds(chapters []core.ChapterInfo) map[string]bounds {
+	volumes := make(map[string]bounds)
+	for i, ch := range chapters {
+		if ch.Number <= 0 {
+			continue
+		}
+		b, ok := volumes[ch.Volume]
+		if !ok {
+			volumes[ch.Volume] = bounds{low: ch.Number, high: ch.Number, first: i}
+			continue
+		}
+		b.low = min(b.low, ch.Number)
+		b.high = max(b.high, ch.Number)
+		volumes[ch.Volume] = b
+	}
+	return volumes
+}
+
+// absoluteOffsets returns what to add to the numbers of each volume to count on across
+// volumes. Volumes go in the order of their numbers, or in the order they are listed in
+// if not all are numbers. Chapters without a volume are usually the newest, not collected
+// yet, so they come last. A volume whose numbers don't start over is already numbered
+// absolutely and keeps them.
+func absoluteOffsets(chapters []core.ChapterInfo) map[string]float64 {
+	volumes := volumeBounds(chapters)
+	names := make([]string, 0, len(volumes))
+	numeric := true
+	for name := range volumes {
+		if name == "" {
+			continue
+		}
+		names = append(names, name)
+		if _, err := strconv.ParseFloat(name, 64); err != nil {
+			numeric = false
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if numeric {
+			x, _ := strconv.ParseFloat(a, 64)
+			y, _ := strconv.ParseFloat(b, 64)
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		}
+		return volumes[a].first - volumes[b].first
+	})
+	if _, ok := volumes[""]; ok {
+		names = append(names, "")
+	}
+
+	offsets := make(map[string]float64, len(names))
+	last := 0.0 // Highest absolute number so far
+	for _, name := range names {
+		b := volumes[name]
+		if b.low <= last {
+			offsets[name] = math.Floor(last)
+		}
+		last = max(last, b.high+offsets[name])
+	}
+	return offsets
+}
+
+// renumber adds offset to the number of a chapter
+func renumber(ch *core.ChapterInfo, offset float64) {
+	if ch.Number <= 0 || offset == 0 {
+		return
+	}
+	ch.Number += offset
+	ch.Label = formatNumber(ch.Number)
+}
+
+// formatNumber formats a chapter number as core.ChapterInfo.DisplayNumber does
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'g', -1, 64)
+}
+
+type normalizerKey struct{}
+
+// WithNormalizer returns a context whose chapter lists and downloads are renumbered by n
+func WithNormalizer(ctx context.Context, n Normalizer) context.Context {
+	return context.WithValue(ctx, normalizerKey{}, n)
+}
+
+// FromContext returns the normalizer of ctx, if it has one that changes anything
+func FromContext(ctx context.Context) (Normalizer, bool) {
+	n, ok := ctx.Value(normalizerKey{}).(Normalizer)
+	return n, ok && n.Active()
+}
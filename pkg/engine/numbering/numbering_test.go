@@ -0,0 +1,165 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package numbering
+
+import (
+	"Luminary/pkg/core"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chapter returns a chapter with an ID, number and volume
+func chapter(id string, number float64, volume string) core.ChapterInfo {
+	return core.ChapterInfo{ID: id, Number: number, Volume: volume}
+}
+
+// numbers returns the display numbers of chapters, by ID
+func numbers(chapters []core.ChapterInfo) map[string]string {
+	got := make(map[string]string, len(chapters))
+	for _, ch := range chapters {
+		got[ch.ID] = ch.Volume + "/" + ch.DisplayNumber()
+	}
+	return got
+}
+
+func TestNormalize(t *testing.T) {
+	eleven := 11.0
+
+	tests := []struct {
+		name       string
+		normalizer Normalizer
+		chapters   []core.ChapterInfo
+		want       map[string]string // Volume/number by chapter ID
+	}{
+		{
+			name:       "absolute restarts per volume",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 2, "1"), chapter("c", 1, "2"), chapter("d", 2.5, "2")},
+			want:       map[string]string{"a": "1/1", "b": "1/2", "c": "2/3", "d": "2/4.5"},
+		},
+		{
+			name:       "absolute orders volumes by number",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("c", 1, "10"), chapter("a", 1, "2"), chapter("b", 2, "2")},
+			want:       map[string]string{"a": "2/1", "b": "2/2", "c": "10/3"},
+		},
+		{
+			name:       "absolute keeps numbering that counts on",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 2, "1"), chapter("c", 3, "2")},
+			want:       map[string]string{"a": "1/1", "b": "1/2", "c": "2/3"},
+		},
+		{
+			name:       "absolute without volumes",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, ""), chapter("b", 2, ""), chapter("c", 0, "")},
+			want:       map[string]string{"a": "/1", "b": "/2", "c": "/0"},
+		},
+		{
+			name:       "absolute counts uncollected chapters on",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 2, "1"), chapter("c", 1, ""), chapter("d", 2, "")},
+			want:       map[string]string{"a": "1/1", "b": "1/2", "c": "/3", "d": "/4"},
+		},
+		{
+			name:       "absolute keeps uncollected chapters that count on",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 2, "1"), chapter("c", 3, "")},
+			want:       map[string]string{"a": "1/1", "b": "1/2", "c": "/3"},
+		},
+		{
+			name:       "absolute orders named volumes as listed",
+			normalizer: Normalizer{Scheme: SchemeAbsolute},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "Arc Two"), chapter("b", 1, "Arc One"), chapter("c", 2, "Arc One")},
+			want:       map[string]string{"a": "Arc Two/1", "b": "Arc One/2", "c": "Arc One/3"},
+		},
+		{
+			name:       "volume starts every volume at 1",
+			normalizer: Normalizer{Scheme: SchemeVolume},
+			chapters:   []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 8, "1"), chapter("c", 9, "2"), chapter("d", 10.5, "2"), chapter("e", 11, "")},
+			want:       map[string]string{"a": "1/1", "b": "1/8", "c": "2/1", "d": "2/2.5", "e": "/11"},
+		},
+		{
+			name:       "volume with named volumes",
+			normalizer: Normalizer{Scheme: SchemeVolume},
+			chapters:   []core.ChapterInfo{chapter("a", 5, "Arc One"), chapter("b", 6, "Arc One"), chapter("c", 7, "Arc Two")},
+			want:       map[string]string{"a": "Arc One/1", "b": "Arc One/2", "c": "Arc Two/1"},
+		},
+		{
+			name: "mapping volumes and overrides",
+			normalizer: Normalizer{Mapping: &Mapping{
+				Volumes:  []VolumeRange{{Volume: "1", From: 1, To: 2}, {Volume: "2", From: 3}},
+				Chapters: map[string]ChapterOverride{"10.5": {Number: &eleven, Title: "Omake"}, "x": {Volume: "3"}},
+			}},
+			chapters: []core.ChapterInfo{chapter("a", 1, ""), chapter("b", 3, ""), chapter("c", 10.5, ""), chapter("x", 4, "")},
+			want:     map[string]string{"a": "1/1", "b": "2/3", "c": "2/11", "x": "3/4"},
+		},
+		{
+			name: "mapping before the scheme",
+			normalizer: Normalizer{Scheme: SchemeVolume, Mapping: &Mapping{
+				Volumes: []VolumeRange{{Volume: "1", From: 1, To: 2}, {Volume: "2", From: 3}},
+			}},
+			chapters: []core.ChapterInfo{chapter("a", 1, ""), chapter("b", 2, ""), chapter("c", 3, ""), chapter("d", 4, "")},
+			want:     map[string]string{"a": "1/1", "b": "1/2", "c": "2/1", "d": "2/2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := numbers(tt.normalizer.Normalize(tt.chapters))
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Errorf("chapter %s = %s, want %s", id, got[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeLeavesChaptersAlone(t *testing.T) {
+	chapters := []core.ChapterInfo{chapter("a", 1, "1"), chapter("b", 1, "2")}
+	Normalizer{Scheme: SchemeAbsolute}.Normalize(chapters)
+	if chapters[1].Number != 1 {
+		t.Errorf("the given chapters were renumbered: %+v", chapters)
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"volumes": [{"volume": "1", "from": 1, "to": 8}], "chapters": {"10.5": {"number": 11}}}`, false},
+		{"open range", `{"volumes": [{"volume": "2", "from": 9}]}`, false},
+		{"no volume", `{"volumes": [{"from": 1, "to": 8}]}`, true},
+		{"range backwards", `{"volumes": [{"volume": "1", "from": 8, "to": 1}]}`, true},
+		{"not JSON", `volumes`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadMapping(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadMapping() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
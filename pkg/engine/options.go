@@ -0,0 +1,65 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/engine/logger"
+	"net/http"
+	"time"
+)
+
+// Option customizes an Engine created by New
+type Option func(*options)
+
+// options collects the settings given to New
+type options struct {
+	logger     logger.Logger
+	httpClient *http.Client
+	cacheDir   string
+	rateLimits map[string]time.Duration
+}
+
+// WithLogger sets the logger of all services instead of the default one writing to
+// ~/.luminary/logs/luminary.log
+func WithLogger(log logger.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with, e.g. one with a proxy or a
+// test transport. Rate limiting, retries and timeouts still apply on top of it
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithCacheDir sets the directory of the snapshot cache instead of ~/.luminary/cache
+func WithCacheDir(dir string) Option {
+	return func(o *options) {
+		o.cacheDir = dir
+	}
+}
+
+// WithRateLimits sets the minimum delay between requests per host, taking precedence
+// over the delays of the providers. A zero delay turns rate limiting off for the host
+func WithRateLimits(limits map[string]time.Duration) Option {
+	return func(o *options) {
+		o.rateLimits = limits
+	}
+}
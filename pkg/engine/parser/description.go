@@ -0,0 +1,149 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"Luminary/pkg/engine/parser/html"
+	"Luminary/pkg/errors"
+	stdhtml "html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Description formats
+const (
+	DescriptionText     = "text"
+	DescriptionMarkdown = "markdown"
+)
+
+// htmlTag matches the tags descriptions scraped as HTML are made of
+var htmlTag = regexp.MustCompile(`(?i)<(?:p|br|div|span|a|b|i|em|strong|ul|ol|li|h[1-6]|hr|blockquote)\b[^>]*>`)
+
+// DescriptionPipeline cleans up manga descriptions the same way for every provider: HTML
+// is converted to text or markdown, boilerplate is removed, whitespace is tidied up and
+// long descriptions are shortened
+type DescriptionPipeline struct {
+	// Format is DescriptionText (the default) or DescriptionMarkdown, which keeps the
+	// emphasis, links and lists of HTML descriptions
+	Format string
+	// Boilerplate matches text to remove, such as "Read X online for free at Y"
+	Boilerplate []*regexp.Regexp
+	// MaxLength shortens descriptions to this many characters at a word boundary; zero
+	// leaves them whole
+	MaxLength int
+}
+
+// CompileBoilerplate compiles boilerplate patterns, case-insensitively
+func CompileBoilerplate(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, errors.Track(err).
+				WithContext("pattern", pattern).
+				WithMessagef("Invalid boilerplate pattern %q", pattern).
+				Error()
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// WithBoilerplate returns the pipeline also removing the given patterns
+func (p DescriptionPipeline) WithBoilerplate(patterns ...*regexp.Regexp) DescriptionPipeline {
+	if len(patterns) > 0 {
+		p.Boilerplate = append(append([]*regexp.Regexp(nil), p.Boilerplate...), patterns...)
+	}
+	return p
+}
+
+// Clean runs a description through the pipeline
+func (p DescriptionPipeline) Clean(description string) string {
+	if description == "" {
+		return ""
+	}
+
+	text := description
+	if htmlTag.MatchString(text) {
+		if doc, err := html.ParseString(text); err == nil {
+			if p.Format == DescriptionMarkdown {
+				text = doc.Markdown()
+			} else {
+				text = doc.PlainText()
+			}
+		}
+	} else {
+		text = stdhtml.UnescapeString(text)
+	}
+
+	for _, re := range p.Boilerplate {
+		text = re.ReplaceAllString(text, "")
+	}
+	text = tidyDescription(text)
+
+	if p.MaxLength > 0 {
+		text = shorten(text, p.MaxLength)
+	}
+	return text
+}
+
+// tidyDescription collapses runs of spaces, trims lines and leaves at most one blank line
+// between paragraphs
+func tidyDescription(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '\t' || r == '\u00a0' }), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shorten cuts text to at most limit characters, ending it at a word boundary with an
+// ellipsis
+func shorten(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := runes[:max(limit-1, 0)]
+	// A cut right before a space already ends at a word boundary
+	if i := lastSpace(cut); i > len(cut)/2 && !unicode.IsSpace(runes[len(cut)]) {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(string(cut), " \n\t,;:-") + "…"
+}
+
+// lastSpace returns the index of the last whitespace in runes, or -1
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
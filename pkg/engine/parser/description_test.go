@@ -0,0 +1,75 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDescriptionPipelineClean(t *testing.T) {
+	html := `<p>A <b>bold</b> start&amp;more.</p><p>Read it at <a href="https://example.com">Example</a></p><ul><li>One</li><li>Two</li></ul>`
+	tests := []struct {
+		name     string
+		pipeline DescriptionPipeline
+		in       string
+		want     string
+	}{
+		{"empty", DescriptionPipeline{}, "", ""},
+		{"plain text", DescriptionPipeline{}, "  Tom &amp; Jerry\r\n\r\n\r\n  chase   each other ", "Tom & Jerry\n\nchase each other"},
+		{"html as text", DescriptionPipeline{}, html, "A bold start&more.\n\nRead it at Example\n\n- One\n- Two"},
+		{"html as markdown", DescriptionPipeline{Format: DescriptionMarkdown}, html,
+			"A **bold** start&more.\n\nRead it at [Example](https://example.com)\n\n- One\n- Two"},
+		{"boilerplate", DescriptionPipeline{Boilerplate: []*regexp.Regexp{regexp.MustCompile(`(?i)read .* online at \S+`)}},
+			"A story.\nRead Series online at example.com", "A story."},
+		{"shortened", DescriptionPipeline{MaxLength: 20}, "The quick brown fox jumps over the lazy dog", "The quick brown fox…"},
+		{"shortened mid-word", DescriptionPipeline{MaxLength: 22}, "The quick brown fox jumps over the lazy dog", "The quick brown fox…"},
+		{"shortened runes", DescriptionPipeline{MaxLength: 6}, "日本語の説明文です", "日本語の説…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pipeline.Clean(tt.in); got != tt.want {
+				t.Errorf("Clean(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileBoilerplate(t *testing.T) {
+	patterns, err := CompileBoilerplate([]string{`free at \S+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := DescriptionPipeline{}.WithBoilerplate(patterns...)
+	if got := pipeline.Clean("A story. Read FREE AT site.com"); got != "A story. Read" {
+		t.Errorf("got %q, want the pattern removed case-insensitively", got)
+	}
+
+	if _, err := CompileBoilerplate([]string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestWithBoilerplateKeepsOriginal(t *testing.T) {
+	base := DescriptionPipeline{Boilerplate: make([]*regexp.Regexp, 1, 4)}
+	base.Boilerplate[0] = regexp.MustCompile("a")
+	first := base.WithBoilerplate(regexp.MustCompile("b"))
+	second := base.WithBoilerplate(regexp.MustCompile("c"))
+	if len(base.Boilerplate) != 1 || first.Boilerplate[1].String() != "b" || second.Boilerplate[1].String() != "c" {
+		t.Errorf("pipelines share patterns: %v, %v, %v", base.Boilerplate, first.Boilerplate, second.Boilerplate)
+	}
+}
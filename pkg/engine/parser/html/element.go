@@ -55,6 +55,41 @@ func (e *Element) AttrOr(name string, defaultValue string) string {
 	return defaultValue
 }
 
+// Attribute is a single attribute of an element
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// Attrs returns all attributes of the element in document order
+func (e *Element) Attrs() []Attribute {
+	if len(e.selection.Nodes) == 0 {
+		return nil
+	}
+
+	node := e.selection.Nodes[0]
+	attrs := make([]Attribute, 0, len(node.Attr))
+	for _, a := range node.Attr {
+		name := a.Key
+		if a.Namespace != "" {
+			name = a.Namespace + ":" + a.Key
+		}
+		attrs = append(attrs, Attribute{Name: name, Value: a.Val})
+	}
+	return attrs
+}
+
+// Tag returns the element's tag name
+func (e *Element) Tag() string {
+	return goquery.NodeName(e.selection)
+}
+
+// OuterHTML returns the HTML of the element including its own tag
+func (e *Element) OuterHTML() string {
+	html, _ := goquery.OuterHtml(e.selection)
+	return html
+}
+
 // HasAttr checks if an attribute exists
 func (e *Element) HasAttr(name string) bool {
 	_, exists := e.Attr(name)
@@ -82,6 +82,71 @@ func (e *Extractor) AbsSrc(baseURL string) string {
 	return base.ResolveReference(u).String()
 }
 
+// backgroundURLPattern finds the url(...) of a background image in a style attribute
+var backgroundURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// ImageURL returns the first image URL found in the given attributes, tried in order.
+// Srcset attributes yield their largest candidate and "style" its background image;
+// inline data: placeholders of lazy loaders are skipped.
+func (e *Extractor) ImageURL(attrs []string) string {
+	if urls := e.ImageURLs(attrs); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// ImageURLs returns every distinct image URL found in the given attributes, in the
+// order of ImageURL's preference
+func (e *Extractor) ImageURLs(attrs []string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, attr := range attrs {
+		value := strings.TrimSpace(e.element.AttrOr(attr, ""))
+		switch {
+		case value == "":
+			continue
+		case attr == "style":
+			match := backgroundURLPattern.FindStringSubmatch(value)
+			if match == nil {
+				continue
+			}
+			value = strings.TrimSpace(match[1])
+		case strings.HasSuffix(attr, "srcset"):
+			value = LargestSrcsetCandidate(value)
+		}
+
+		if value != "" && !strings.HasPrefix(value, "data:") && !seen[value] {
+			seen[value] = true
+			urls = append(urls, value)
+		}
+	}
+	return urls
+}
+
+// LargestSrcsetCandidate returns the URL of the widest (or densest) candidate in a
+// srcset value such as "a.jpg 480w, b.jpg 1080w"; candidates without a descriptor count as 1x
+func LargestSrcsetCandidate(srcset string) string {
+	best, bestSize := "", -1.0
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+
+		size := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[len(fields)-1]
+			if n, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil {
+				size = n
+			}
+		}
+		if size > bestSize {
+			best, bestSize = fields[0], size
+		}
+	}
+	return best
+}
+
 // Alt returns the alt attribute
 func (e *Extractor) Alt() string {
 	return e.element.AttrOr("alt", "")
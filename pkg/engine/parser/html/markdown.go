@@ -0,0 +1,136 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package html
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"regexp"
+	"strings"
+)
+
+// spaceRun matches the whitespace HTML collapses into one space
+var spaceRun = regexp.MustCompile(`\s+`)
+
+// Markdown converts the document to markdown: paragraphs, line breaks, headings, lists,
+// emphasis and links are kept, everything else becomes plain text
+func (p *Parser) Markdown() string {
+	var b strings.Builder
+	renderText(&b, p.doc.Selection, true)
+	return tidyBlocks(b.String())
+}
+
+// PlainText converts the document to text, keeping its paragraphs, line breaks and list
+// items apart unlike Text
+func (p *Parser) PlainText() string {
+	var b strings.Builder
+	renderText(&b, p.doc.Selection, false)
+	return tidyBlocks(b.String())
+}
+
+// renderText writes the contents of s to b, as markdown when markdown is set
+func renderText(b *strings.Builder, s *goquery.Selection, markdown bool) {
+	s.Contents().Each(func(_ int, node *goquery.Selection) {
+		name := goquery.NodeName(node)
+		switch name {
+		case "#text":
+			b.WriteString(spaceRun.ReplaceAllString(node.Text(), " "))
+		case "#comment", "script", "style", "head", "title":
+		case "br":
+			b.WriteString("\n")
+		case "hr":
+			if markdown {
+				b.WriteString("\n\n---\n\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			b.WriteString("\n\n")
+			if markdown {
+				b.WriteString(strings.Repeat("#", int(name[1]-'0')) + " ")
+			}
+			b.WriteString(inlineText(node, markdown))
+			b.WriteString("\n\n")
+		case "p", "div", "section", "article", "blockquote", "ul", "ol", "table", "tr":
+			b.WriteString("\n\n")
+			renderText(b, node, markdown)
+			b.WriteString("\n\n")
+		case "li":
+			b.WriteString("\n- ")
+			b.WriteString(inlineText(node, markdown))
+		case "strong", "b":
+			wrapInline(b, node, markdown, "**")
+		case "em", "i":
+			wrapInline(b, node, markdown, "*")
+		case "a":
+			href, _ := node.Attr("href")
+			text := inlineText(node, markdown)
+			if markdown && text != "" && (strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://")) {
+				b.WriteString("[" + text + "](" + href + ")")
+			} else {
+				b.WriteString(text)
+			}
+		default:
+			renderText(b, node, markdown)
+		}
+	})
+}
+
+// inlineText renders the contents of s on one line
+func inlineText(s *goquery.Selection, markdown bool) string {
+	var b strings.Builder
+	renderText(&b, s, markdown)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// wrapInline writes the contents of s between markdown markers, keeping the spaces around
+// them outside so the markers stay valid
+func wrapInline(b *strings.Builder, s *goquery.Selection, markdown bool, marker string) {
+	var inner strings.Builder
+	renderText(&inner, s, markdown)
+	text := inner.String()
+	trimmed := strings.TrimSpace(text)
+	if !markdown || trimmed == "" {
+		b.WriteString(text)
+		return
+	}
+	if strings.HasPrefix(text, " ") {
+		b.WriteString(" ")
+	}
+	b.WriteString(marker + trimmed + marker)
+	if strings.HasSuffix(text, " ") {
+		b.WriteString(" ")
+	}
+}
+
+// tidyBlocks trims every line and leaves at most one blank line between blocks
+func tidyBlocks(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
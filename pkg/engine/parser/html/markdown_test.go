@@ -0,0 +1,85 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package html
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		markdown  string
+		plainText string
+	}{
+		{
+			name:      "paragraphs and breaks",
+			html:      "<p>First   line<br>second line</p><p>Next</p>",
+			markdown:  "First line\nsecond line\n\nNext",
+			plainText: "First line\nsecond line\n\nNext",
+		},
+		{
+			name:      "emphasis keeps spaces outside",
+			html:      "<p>a<strong> bold </strong>and <em>italic</em> word</p>",
+			markdown:  "a **bold** and *italic* word",
+			plainText: "a bold and italic word",
+		},
+		{
+			name:      "headings and rules",
+			html:      "<h2>Synopsis</h2><p>Text</p><hr><p>More</p>",
+			markdown:  "## Synopsis\n\nText\n\n---\n\nMore",
+			plainText: "Synopsis\n\nText\n\nMore",
+		},
+		{
+			name:      "lists",
+			html:      "<ul><li>One</li><li><b>Two</b></li></ul>",
+			markdown:  "- One\n- **Two**",
+			plainText: "- One\n- Two",
+		},
+		{
+			name:      "links",
+			html:      `<p><a href="https://example.com/a">Site</a> and <a href="/relative">here</a></p>`,
+			markdown:  "[Site](https://example.com/a) and here",
+			plainText: "Site and here",
+		},
+		{
+			name:      "scripts and comments dropped",
+			html:      "<div>Shown<script>hidden()</script><!-- note --></div><style>p{}</style>",
+			markdown:  "Shown",
+			plainText: "Shown",
+		},
+		{
+			name:      "empty emphasis",
+			html:      "<p>x<b> </b>y</p>",
+			markdown:  "x y",
+			plainText: "x y",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseString(tt.html)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := doc.Markdown(); got != tt.markdown {
+				t.Errorf("Markdown() = %q, want %q", got, tt.markdown)
+			}
+			if got := doc.PlainText(); got != tt.plainText {
+				t.Errorf("PlainText() = %q, want %q", got, tt.plainText)
+			}
+		})
+	}
+}
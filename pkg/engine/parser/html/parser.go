@@ -28,6 +28,8 @@ import (
 // Parser wraps goquery document for HTML parsing
 type Parser struct {
 	doc *goquery.Document
+	// trace, if set, is told every selector queried and how many elements it matched
+	trace func(selector string, matches int)
 }
 
 // Parse creates a new parser from HTML content
@@ -66,6 +68,12 @@ func (p *Parser) Select(selector string) *Selector {
 	}
 }
 
+// Trace reports every selector queried from now on to fn, with the number of elements
+// it matched; nil stops it
+func (p *Parser) Trace(fn func(selector string, matches int)) {
+	p.trace = fn
+}
+
 // Find is an alias for Select for familiarity
 func (p *Parser) Find(selector string) *Selector {
 	return p.Select(selector)
@@ -28,9 +28,18 @@ type Selector struct {
 	selector string
 }
 
+// find queries the selector, reporting it to the parser's trace
+func (s *Selector) find() *goquery.Selection {
+	selection := s.parser.doc.Find(s.selector)
+	if s.parser.trace != nil {
+		s.parser.trace(s.selector, selection.Length())
+	}
+	return selection
+}
+
 // First returns the first element matching the selector
 func (s *Selector) First() (*Element, error) {
-	selection := s.parser.doc.Find(s.selector).First()
+	selection := s.find().First()
 	if selection.Length() == 0 {
 		return nil, errors.Track(fmt.Errorf("no elements found")).
 			WithContext("selector", s.selector).
@@ -41,7 +50,7 @@ func (s *Selector) First() (*Element, error) {
 
 // FirstOrNil returns the first element or nil if not found
 func (s *Selector) FirstOrNil() *Element {
-	selection := s.parser.doc.Find(s.selector).First()
+	selection := s.find().First()
 	if selection.Length() == 0 {
 		return nil
 	}
@@ -52,7 +61,7 @@ func (s *Selector) FirstOrNil() *Element {
 func (s *Selector) All() ([]*Element, error) {
 	var elements []*Element
 
-	s.parser.doc.Find(s.selector).Each(func(i int, sel *goquery.Selection) {
+	s.find().Each(func(i int, sel *goquery.Selection) {
 		elements = append(elements, &Element{selection: sel})
 	})
 
@@ -69,7 +78,7 @@ func (s *Selector) All() ([]*Element, error) {
 func (s *Selector) AllOrEmpty() []*Element {
 	var elements []*Element
 
-	s.parser.doc.Find(s.selector).Each(func(i int, sel *goquery.Selection) {
+	s.find().Each(func(i int, sel *goquery.Selection) {
 		elements = append(elements, &Element{selection: sel})
 	})
 
@@ -78,7 +87,7 @@ func (s *Selector) AllOrEmpty() []*Element {
 
 // Count returns the number of elements matching the selector
 func (s *Selector) Count() int {
-	return s.parser.doc.Find(s.selector).Length()
+	return s.find().Length()
 }
 
 // Exists checks if any elements match the selector
@@ -88,7 +97,7 @@ func (s *Selector) Exists() bool {
 
 // Each iterates over all matching elements
 func (s *Selector) Each(fn func(int, *Element)) {
-	s.parser.doc.Find(s.selector).Each(func(i int, sel *goquery.Selection) {
+	s.find().Each(func(i int, sel *goquery.Selection) {
 		fn(i, &Element{selection: sel})
 	})
 }
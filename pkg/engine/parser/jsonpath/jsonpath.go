@@ -0,0 +1,458 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package jsonpath evaluates JSONPath-like expressions on decoded JSON
+// (map[string]interface{}, []interface{} and scalars).
+//
+// Supported syntax:
+//
+//	$.data.items          fields (the leading "$." is optional)
+//	items[0], items[-1]   array indices, negative from the end
+//	items[*], data.*      every element or value
+//	['odd.key']           quoted field names
+//	[?(@.type=='author')] filters comparing a relative path with a string, number,
+//	                      boolean or null using ==, !=, <, <=, > or >=
+//	[?(@.name)]           filters keeping elements where the path exists
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled expression
+type Path struct {
+	expr  string
+	steps []step
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+	stepFilter
+)
+
+type step struct {
+	kind   stepKind
+	field  string
+	index  int
+	filter *filter
+}
+
+// filter is a [?(...)] condition
+type filter struct {
+	path  *Path
+	op    string // Empty for an existence check
+	value interface{}
+}
+
+// Compile parses an expression
+func Compile(expr string) (*Path, error) {
+	p := &parser{input: strings.TrimSpace(expr)}
+	if strings.HasPrefix(p.input, "$") {
+		p.pos = 1
+	}
+
+	steps, err := p.parseSteps(false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+	}
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("invalid path %q: unexpected %q at %d", expr, p.input[p.pos], p.pos)
+	}
+	return &Path{expr: expr, steps: steps}, nil
+}
+
+// String returns the expression the path was compiled from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get returns every value the path matches, in document order
+func (p *Path) Get(data interface{}) []interface{} {
+	nodes := []interface{}{data}
+	for _, s := range p.steps {
+		var next []interface{}
+		for _, node := range nodes {
+			next = s.apply(node, next)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// First returns the first value the path matches, or nil
+func (p *Path) First(data interface{}) interface{} {
+	if values := p.Get(data); len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
+
+// Strings returns the matched values that are strings or numbers, as strings
+func (p *Path) Strings(data interface{}) []string {
+	var result []string
+	for _, value := range p.Get(data) {
+		if s, ok := ToString(value); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// ToString converts a decoded JSON scalar to a string
+func ToString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// apply appends the values step s selects from node to out
+func (s step) apply(node interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case stepField:
+		if m, ok := node.(map[string]interface{}); ok {
+			if v, exists := m[s.field]; exists {
+				out = append(out, v)
+			}
+		}
+	case stepIndex:
+		if a, ok := node.([]interface{}); ok {
+			i := s.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				out = append(out, a[i])
+			}
+		}
+	case stepWildcard:
+		out = append(out, children(node)...)
+	case stepFilter:
+		for _, child := range children(node) {
+			if s.filter.matches(child) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// children returns the elements of an array or the values of an object (sorted by key)
+func children(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		values := make([]interface{}, len(keys))
+		for i, k := range keys {
+			values[i] = v[k]
+		}
+		return values
+	}
+	return nil
+}
+
+func (f *filter) matches(node interface{}) bool {
+	values := f.path.Get(node)
+	if f.op == "" {
+		return len(values) > 0
+	}
+
+	for _, v := range values {
+		if compare(v, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies op to two decoded JSON values; values of different types are never equal
+func compare(a interface{}, op string, b interface{}) bool {
+	var c int
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return op == "!="
+		}
+		c = strings.Compare(av, bv)
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return op == "!="
+		}
+		switch {
+		case av < bv:
+			c = -1
+		case av > bv:
+			c = 1
+		}
+	default:
+		equal := a == b
+		switch op {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		}
+		return false
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// parser turns an expression into steps
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseSteps parses steps until the input ends or, inside a filter, until an operator or ')'
+func (p *parser) parseSteps(inFilter bool) ([]step, error) {
+	var steps []step
+	first := true
+
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '.':
+			p.pos++
+			if p.peek() == '*' {
+				p.pos++
+				steps = append(steps, step{kind: stepWildcard})
+				break
+			}
+			name := p.readName()
+			if name == "" {
+				return nil, fmt.Errorf("missing field name at %d", p.pos)
+			}
+			steps = append(steps, step{kind: stepField, field: name})
+		case c == '[':
+			s, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case inFilter && (c == ' ' || c == ')' || strings.ContainsRune("=!<>", rune(c))):
+			return steps, nil
+		case first && !inFilter:
+			// The leading field needs no dot: "data.items"
+			name := p.readName()
+			if name == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+			}
+			steps = append(steps, step{kind: stepField, field: name})
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+		}
+		first = false
+	}
+
+	return steps, nil
+}
+
+// readName reads an unquoted field name
+func (p *parser) readName() string {
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(".[]()=!<> ", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// parseBracket parses [n], [*], ['name'] or [?(...)]
+func (p *parser) parseBracket() (step, error) {
+	p.pos++ // [
+	p.skipSpaces()
+
+	var s step
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		s = step{kind: stepWildcard}
+	case c == '\'' || c == '"':
+		name, err := p.readQuoted()
+		if err != nil {
+			return step{}, err
+		}
+		s = step{kind: stepField, field: name}
+	case c == '?':
+		f, err := p.parseFilter()
+		if err != nil {
+			return step{}, err
+		}
+		s = step{kind: stepFilter, filter: f}
+	default:
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '-' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil {
+			return step{}, fmt.Errorf("invalid index at %d", start)
+		}
+		s = step{kind: stepIndex, index: index}
+	}
+
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return step{}, fmt.Errorf("missing ] at %d", p.pos)
+	}
+	p.pos++
+	return s, nil
+}
+
+// parseFilter parses ?(@.path op literal) or ?(@.path)
+func (p *parser) parseFilter() (*filter, error) {
+	p.pos++ // ?
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("missing ( after ? at %d", p.pos)
+	}
+	p.pos++
+	p.skipSpaces()
+
+	if p.peek() != '@' {
+		return nil, fmt.Errorf("filter must start with @ at %d", p.pos)
+	}
+	start := p.pos
+	p.pos++
+	steps, err := p.parseSteps(true)
+	if err != nil {
+		return nil, err
+	}
+	f := &filter{path: &Path{expr: p.input[start:p.pos], steps: steps}}
+
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			f.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+
+	if f.op != "" {
+		p.skipSpaces()
+		if f.value, err = p.readLiteral(); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+	}
+
+	if p.peek() != ')' {
+		return nil, fmt.Errorf("missing ) at %d", p.pos)
+	}
+	p.pos++
+	return f, nil
+}
+
+// readLiteral reads a quoted string, number, true, false or null
+func (p *parser) readLiteral() (interface{}, error) {
+	if c := p.peek(); c == '\'' || c == '"' {
+		return p.readQuoted()
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(" )", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	word := p.input[start:p.pos]
+
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid literal %q at %d", word, start)
+	}
+	return n, nil
+}
+
+// readQuoted reads a string in single or double quotes; a backslash escapes the next character
+func (p *parser) readQuoted() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.input):
+			b.WriteByte(p.input[p.pos])
+			p.pos++
+		case c == quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
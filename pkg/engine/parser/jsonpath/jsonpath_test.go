@@ -0,0 +1,151 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const document = `{
+	"data": {
+		"title": "Blue Lock",
+		"rating": 8.5,
+		"ongoing": true,
+		"cover": null,
+		"odd.key": "dotted",
+		"relationships": [
+			{"type": "author", "name": "Muneyuki Kaneshiro", "rank": 1},
+			{"type": "artist", "name": "Yusuke Nomura", "rank": 2},
+			{"type": "cover_art", "file": "cover.jpg"}
+		],
+		"tags": {"b": "Sports", "a": "Drama"}
+	}
+}`
+
+func decode(t *testing.T) interface{} {
+	t.Helper()
+	var data interface{}
+	if err := json.Unmarshal([]byte(document), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPathGet(t *testing.T) {
+	data := decode(t)
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"$.data.title", []string{"Blue Lock"}},
+		{"data.title", []string{"Blue Lock"}},
+		{"$.data.rating", []string{"8.5"}},
+		{"$.data.ongoing", []string{"true"}},
+		{"$.data['odd.key']", []string{"dotted"}},
+		{`$.data["odd.key"]`, []string{"dotted"}},
+		{"$.data.relationships[0].name", []string{"Muneyuki Kaneshiro"}},
+		{"$.data.relationships[-1].file", []string{"cover.jpg"}},
+		{"$.data.relationships[ 1 ].name", []string{"Yusuke Nomura"}},
+		{"$.data.relationships[*].name", []string{"Muneyuki Kaneshiro", "Yusuke Nomura"}},
+		{"$.data.tags.*", []string{"Drama", "Sports"}},
+		{"$.data.relationships[?(@.type=='author')].name", []string{"Muneyuki Kaneshiro"}},
+		{`$.data.relationships[?(@.type != "author")].type`, []string{"artist", "cover_art"}},
+		{"$.data.relationships[?(@.rank >= 2)].name", []string{"Yusuke Nomura"}},
+		{"$.data.relationships[?(@.rank < 2)].name", []string{"Muneyuki Kaneshiro"}},
+		{"$.data.relationships[?(@.file)].file", []string{"cover.jpg"}},
+		{"$.data.relationships[?(@.rank == '1')].name", nil},
+		{"$.data.missing", nil},
+		{"$.data.relationships[5]", nil},
+		{"$.data.title[0]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := path.Strings(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathFirst(t *testing.T) {
+	data := decode(t)
+	path, err := Compile("$.data.relationships[*].type")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := path.First(data); got != "author" {
+		t.Errorf("First = %v, want author", got)
+	}
+
+	path, err = Compile("$.data.cover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := path.Get(data); len(values) != 1 || values[0] != nil {
+		t.Errorf("Get = %v, want the null value", values)
+	}
+	if path, err = Compile("$"); err != nil {
+		t.Fatal(err)
+	}
+	if got := path.Get(data); len(got) != 1 || !reflect.DeepEqual(got[0], data) {
+		t.Errorf("$ matched %v, want the whole document", got)
+	}
+}
+
+func TestCompileRejectsInvalidPaths(t *testing.T) {
+	for _, expr := range []string{
+		"$.",
+		"$.data[",
+		"$.data[abc]",
+		"$.data['open",
+		"$.data[?@.type]",
+		"$.data[?(.type)]",
+		"$.data[?(@.type == 'a']",
+		"$.data[?(@.type == author)]",
+		"$.data]",
+	} {
+		if path, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) = %v, want an error", expr, path)
+		}
+	}
+}
+
+func TestToString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+		ok    bool
+	}{
+		{"text", "text", true},
+		{float64(42), "42", true},
+		{0.25, "0.25", true},
+		{false, "false", true},
+		{nil, "", false},
+		{map[string]interface{}{}, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := ToString(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("ToString(%v) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package protowire reads the protocol buffers wire format, for decoding responses of
+// APIs that answer with application/x-protobuf. Messages decode themselves by walking
+// their fields with a Reader:
+//
+//	func (m *Title) UnmarshalProto(b []byte) error {
+//		r := protowire.NewReader(b)
+//		for r.More() {
+//			field, wt, err := r.Next()
+//			if err != nil {
+//				return err
+//			}
+//			switch field {
+//			case 1:
+//				m.ID, err = r.Int32()
+//			case 2:
+//				m.Name, err = r.String()
+//			default:
+//				err = r.Skip(wt)
+//			}
+//			if err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	}
+package protowire
+
+import (
+	"fmt"
+	"math"
+)
+
+// WireType is the encoding of a field value
+type WireType int
+
+const (
+	Varint     WireType = 0
+	Fixed64    WireType = 1
+	Bytes      WireType = 2
+	StartGroup WireType = 3
+	EndGroup   WireType = 4
+	Fixed32    WireType = 5
+)
+
+// maxGroupDepth bounds how deeply Skip follows nested groups, so a hostile message can't
+// exhaust the stack; protobuf implementations use the same limit for nesting
+const maxGroupDepth = 100
+
+// Message is implemented by types that decode themselves from the wire format
+type Message interface {
+	UnmarshalProto([]byte) error
+}
+
+// Reader reads the fields of an encoded message in order
+type Reader struct {
+	buf []byte
+	pos int
+}
+
+// NewReader creates a reader over an encoded message
+func NewReader(b []byte) *Reader {
+	return &Reader{buf: b}
+}
+
+// More reports whether fields remain
+func (r *Reader) More() bool {
+	return r.pos < len(r.buf)
+}
+
+// Next reads the tag of the next field
+func (r *Reader) Next() (int, WireType, error) {
+	tag, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	field, wt := int(tag>>3), WireType(tag&7)
+	if field <= 0 || wt > Fixed32 {
+		return 0, 0, fmt.Errorf("invalid field tag %d at byte %d", tag, r.pos)
+	}
+	return field, wt, nil
+}
+
+// Uint64 reads a varint field
+func (r *Reader) Uint64() (uint64, error) {
+	return r.varint()
+}
+
+// Int64 reads an int64 field
+func (r *Reader) Int64() (int64, error) {
+	v, err := r.varint()
+	return int64(v), err
+}
+
+// Int32 reads an int32 or enum field
+func (r *Reader) Int32() (int32, error) {
+	v, err := r.varint()
+	return int32(v), err
+}
+
+// Sint64 reads a zigzag-encoded sint64 field
+func (r *Reader) Sint64() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// Bool reads a bool field
+func (r *Reader) Bool() (bool, error) {
+	v, err := r.varint()
+	return v != 0, err
+}
+
+// Fixed32 reads a fixed32 field
+func (r *Reader) Fixed32() (uint32, error) {
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
+}
+
+// Fixed64 reads a fixed64 field
+func (r *Reader) Fixed64() (uint64, error) {
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for i := 7; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, nil
+}
+
+// Float reads a float field
+func (r *Reader) Float() (float32, error) {
+	v, err := r.Fixed32()
+	return math.Float32frombits(v), err
+}
+
+// Double reads a double field
+func (r *Reader) Double() (float64, error) {
+	v, err := r.Fixed64()
+	return math.Float64frombits(v), err
+}
+
+// Bytes reads a length-delimited field; the result aliases the message buffer
+func (r *Reader) Bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("length %d at byte %d exceeds the message", n, r.pos)
+	}
+	return r.take(int(n))
+}
+
+// String reads a string field
+func (r *Reader) String() (string, error) {
+	b, err := r.Bytes()
+	return string(b), err
+}
+
+// Message reads an embedded message field into m
+func (r *Reader) Message(m Message) error {
+	b, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	return m.UnmarshalProto(b)
+}
+
+// Skip skips the value of a field the message doesn't know
+func (r *Reader) Skip(wt WireType) error {
+	return r.skip(wt, 0)
+}
+
+// skip skips a value inside depth groups
+func (r *Reader) skip(wt WireType, depth int) error {
+	var err error
+	switch wt {
+	case Varint:
+		_, err = r.varint()
+	case Fixed64:
+		_, err = r.take(8)
+	case Bytes:
+		_, err = r.Bytes()
+	case Fixed32:
+		_, err = r.take(4)
+	case StartGroup:
+		if depth >= maxGroupDepth {
+			return fmt.Errorf("groups nested more than %d deep at byte %d", maxGroupDepth, r.pos)
+		}
+		for {
+			field, nested, err := r.Next()
+			if err != nil {
+				return err
+			}
+			if nested == EndGroup {
+				return nil
+			}
+			if err := r.skip(nested, depth+1); err != nil {
+				return fmt.Errorf("field %d: %w", field, err)
+			}
+		}
+	default:
+		err = fmt.Errorf("unexpected wire type %d at byte %d", wt, r.pos)
+	}
+	return err
+}
+
+// varint reads a base 128 varint
+func (r *Reader) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if r.pos >= len(r.buf) {
+			return 0, fmt.Errorf("truncated varint at byte %d", r.pos)
+		}
+		b := r.buf[r.pos]
+		r.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("varint overflow at byte %d", r.pos)
+}
+
+// take returns the next n bytes
+func (r *Reader) take(n int) ([]byte, error) {
+	if len(r.buf)-r.pos < n {
+		return nil, fmt.Errorf("truncated message: need %d bytes at byte %d", n, r.pos)
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
@@ -0,0 +1,207 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package protowire
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// groups returns depth nested groups of field 1, each closed again
+func groups(depth int) []byte {
+	return append(bytes.Repeat([]byte{0x0b}, depth), bytes.Repeat([]byte{0x0c}, depth)...)
+}
+
+func TestSkip(t *testing.T) {
+	tests := []struct {
+		name    string
+		wt      WireType
+		data    []byte
+		read    int // Bytes the value takes
+		wantErr string
+	}{
+		{"varint", Varint, []byte{0x96, 0x01, 0xff}, 2, ""},
+		{"fixed64", Fixed64, make([]byte, 9), 8, ""},
+		{"fixed32", Fixed32, make([]byte, 5), 4, ""},
+		{"bytes", Bytes, []byte{0x02, 'h', 'i', 0xff}, 3, ""},
+		{"group", StartGroup, []byte{0x08, 0x01, 0x12, 0x01, 'x', 0x0c}, 6, ""},
+		{"nested groups", StartGroup, groups(maxGroupDepth)[1:], 2*maxGroupDepth - 1, ""},
+		{"groups too deep", StartGroup, groups(maxGroupDepth + 1)[1:], 0, "nested more than 100 deep"},
+		{"unterminated group", StartGroup, []byte{0x08, 0x01}, 0, "truncated varint"},
+		{"truncated fixed64", Fixed64, make([]byte, 7), 0, "truncated message"},
+		{"bytes past the end", Bytes, []byte{0x05, 'h'}, 0, "exceeds the message"},
+		{"end group", EndGroup, nil, 0, "unexpected wire type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(tt.data)
+			err := r.Skip(tt.wt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Skip() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Skip() error = %v", err)
+			}
+			if r.pos != tt.read {
+				t.Errorf("Skip() read %d bytes, want %d", r.pos, tt.read)
+			}
+		})
+	}
+}
+
+func TestSkipDeepGroupsInMessage(t *testing.T) {
+	r := NewReader(groups(100000))
+	field, wt, err := r.Next()
+	if err != nil || field != 1 || wt != StartGroup {
+		t.Fatalf("Next() = %d, %d, %v", field, wt, err)
+	}
+	if err := r.Skip(wt); err == nil {
+		t.Fatal("Skip() of 100000 nested groups succeeded")
+	}
+}
+
+// title is a message of the kind the package doc shows
+type title struct {
+	ID     int32
+	Name   string
+	Score  float32
+	Rating float64
+	Tags   []string
+	Author *author
+}
+
+type author struct {
+	Name string
+}
+
+func (m *author) UnmarshalProto(b []byte) error {
+	r := NewReader(b)
+	for r.More() {
+		field, wt, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if field == 1 {
+			m.Name, err = r.String()
+		} else {
+			err = r.Skip(wt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *title) UnmarshalProto(b []byte) error {
+	r := NewReader(b)
+	for r.More() {
+		field, wt, err := r.Next()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			m.ID, err = r.Int32()
+		case 2:
+			m.Name, err = r.String()
+		case 3:
+			m.Score, err = r.Float()
+		case 4:
+			m.Rating, err = r.Double()
+		case 5:
+			var tag string
+			tag, err = r.String()
+			m.Tags = append(m.Tags, tag)
+		case 6:
+			m.Author = &author{}
+			err = r.Message(m.Author)
+		default:
+			err = r.Skip(wt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    title
+		wantErr string
+	}{
+		{"empty", nil, title{}, ""},
+		{"varint and string", []byte{0x08, 0x96, 0x01, 0x12, 0x03, 'O', 'n', 'e'}, title{ID: 150, Name: "One"}, ""},
+		{"fixed", []byte{0x1d, 0x00, 0x00, 0xc0, 0x3f, 0x21, 0, 0, 0, 0, 0, 0, 0x04, 0x40}, title{Score: 1.5, Rating: 2.5}, ""},
+		{"repeated", []byte{0x2a, 0x01, 'a', 0x2a, 0x01, 'b'}, title{Tags: []string{"a", "b"}}, ""},
+		{"embedded message", []byte{0x32, 0x04, 0x0a, 0x02, 'O', 'd'}, title{Author: &author{Name: "Od"}}, ""},
+		{"negative int32", []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, title{ID: -1}, ""},
+		{"unknown fields", []byte{0x50, 0x01, 0x5a, 0x01, 'x', 0x63, 0x08, 0x01, 0x64, 0x08, 0x07}, title{ID: 7}, ""},
+		{"field zero", []byte{0x00, 0x01}, title{}, "invalid field tag"},
+		{"invalid wire type", []byte{0x0e}, title{}, "invalid field tag"},
+		{"truncated varint", []byte{0x08, 0x96}, title{}, "truncated varint"},
+		{"varint overflow", append([]byte{0x08}, bytes.Repeat([]byte{0xff}, 10)...), title{}, "varint overflow"},
+		{"truncated string", []byte{0x12, 0x05, 'O'}, title{}, "exceeds the message"},
+		{"truncated float", []byte{0x1d, 0x00, 0x00}, title{}, "truncated message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got title
+			err := got.UnmarshalProto(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UnmarshalProto() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalProto() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalProto() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSint64(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want int64
+	}{
+		{[]byte{0x00}, 0},
+		{[]byte{0x01}, -1},
+		{[]byte{0x02}, 1},
+		{[]byte{0x03}, -2},
+		{[]byte{0xfe, 0xff, 0xff, 0xff, 0x0f}, 2147483647},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, -2147483648},
+	}
+	for _, tt := range tests {
+		got, err := NewReader(tt.data).Sint64()
+		if err != nil || got != tt.want {
+			t.Errorf("Sint64(% x) = %d, %v, want %d", tt.data, got, err, tt.want)
+		}
+	}
+}
@@ -41,8 +41,10 @@ func NewService(logger logger.Logger) *Service {
 		logger: logger,
 		patterns: map[string]*regexp.Regexp{
 			"chapter_number": regexp.MustCompile(`(?i)(?:chapter|ch\.?|episode|ep\.?)[\s:]*(\d+(?:\.\d+)?)`),
+			"chapter_prefix": regexp.MustCompile(`(?i)^\s*(?:chapter|ch\.?|episode|ep\.?)[\s:.-]*`),
 			"volume_number":  regexp.MustCompile(`(?i)(?:volume|vol\.?)[\s:]*(\d+)`),
 			"date":           regexp.MustCompile(`(\d{4}[-/]\d{2}[-/]\d{2})`),
+			"relative_date":  regexp.MustCompile(`(?i)\b(\d+|an?|one)\s+(sec(?:ond)?|min(?:ute)?|h(?:ou)?r|day|week|month|year)s?\s+ago\b`),
 			"year":           regexp.MustCompile(`\b(19|20)\d{2}\b`),
 			"number":         regexp.MustCompile(`\d+(?:\.\d+)?`),
 			"url":            regexp.MustCompile(`https?://[^\s<>"{}|\\^` + "`" + `\[\]]+`),
@@ -107,6 +109,13 @@ func (s *Service) ExtractChapterNumber(text string) (float64, error) {
 		Error()
 }
 
+// ExtractChapterLabel returns the raw chapter label with any "Chapter"/"Ch." prefix removed,
+// e.g. "Chapter 10.5 Omake" becomes "10.5 Omake" and "Extra" stays "Extra"
+func (s *Service) ExtractChapterLabel(text string) string {
+	label := s.patterns["chapter_prefix"].ReplaceAllString(strings.TrimSpace(text), "")
+	return strings.Join(strings.Fields(label), " ")
+}
+
 // ExtractVolumeNumber extracts volume number from text
 func (s *Service) ExtractVolumeNumber(text string) (int, error) {
 	pattern := s.patterns["volume_number"]
@@ -129,16 +138,25 @@ func (s *Service) ExtractVolumeNumber(text string) (int, error) {
 		Error()
 }
 
-// ExtractDate attempts to extract a date from text
+// ExtractDate attempts to extract a date from text, including relative dates like
+// "3 days ago" or "yesterday"
 func (s *Service) ExtractDate(text string) (*time.Time, error) {
+	text = strings.TrimSpace(text)
+	if t := s.relativeDate(text, time.Now().Round(0)); t != nil {
+		return t, nil
+	}
+
 	// Common date formats to try
 	formats := []string{
 		"2006-01-02",
 		"2006/01/02",
+		"2006.01.02",
 		"01/02/2006",
 		"02/01/2006",
 		"Jan 2, 2006",
 		"January 2, 2006",
+		"Jan 2 2006",
+		"January 2 2006",
 		"2 Jan 2006",
 		"2 January 2006",
 	}
@@ -166,6 +184,47 @@ func (s *Service) ExtractDate(text string) (*time.Time, error) {
 		Error()
 }
 
+// relativeDate resolves dates relative to now, or returns nil
+func (s *Service) relativeDate(text string, now time.Time) *time.Time {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "just now"), lower == "now", lower == "today":
+		return &now
+	case lower == "yesterday":
+		t := now.AddDate(0, 0, -1)
+		return &t
+	}
+
+	match := s.patterns["relative_date"].FindStringSubmatch(lower)
+	if match == nil {
+		return nil
+	}
+
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		n = 1 // "a", "an" or "one"
+	}
+
+	var t time.Time
+	switch unit := match[2]; {
+	case strings.HasPrefix(unit, "sec"):
+		t = now.Add(-time.Duration(n) * time.Second)
+	case strings.HasPrefix(unit, "min"):
+		t = now.Add(-time.Duration(n) * time.Minute)
+	case strings.HasPrefix(unit, "h"):
+		t = now.Add(-time.Duration(n) * time.Hour)
+	case unit == "day":
+		t = now.AddDate(0, 0, -n)
+	case unit == "week":
+		t = now.AddDate(0, 0, -7*n)
+	case unit == "month":
+		t = now.AddDate(0, -n, 0)
+	default:
+		t = now.AddDate(-n, 0, 0)
+	}
+	return &t
+}
+
 // ExtractURLs extracts all URLs from text
 func (s *Service) ExtractURLs(text string) []string {
 	pattern := s.patterns["url"]
@@ -0,0 +1,186 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/errors"
+	"context"
+	"sync"
+	"time"
+)
+
+// Readiness is how far a provider got initializing
+type Readiness string
+
+// Readiness states
+const (
+	ReadinessPending      Readiness = "pending"      // Not initialized yet, waiting for its first use with lazy_init
+	ReadinessInitializing Readiness = "initializing" // Initializing right now
+	ReadinessReady        Readiness = "ready"
+	ReadinessFailed       Readiness = "failed" // Tried again on its next use after initRetryDelay
+)
+
+// initRetryDelay is how long a provider that failed to initialize isn't tried again
+const initRetryDelay = time.Minute
+
+// ProviderReadiness describes the initialization of a provider
+type ProviderReadiness struct {
+	State    Readiness `json:"state"`
+	Error    string    `json:"error,omitempty"`    // Why it failed
+	Duration Duration  `json:"duration,omitempty"` // How long the last attempt took
+	At       time.Time `json:"at,omitzero"`        // When the last attempt ended
+}
+
+type initializingKey struct{}
+
+// providerInit is the initialization of a provider; done is closed once an attempt ends
+type providerInit struct {
+	provider Provider
+	done     chan struct{}
+	state    ProviderReadiness
+	err      error
+}
+
+// InitializeProviders initializes all registered providers at once, each within the
+// initialize timeout. Failures are logged and reported by ProviderReadiness rather than
+// returned; the provider is tried again on its next use. With lazy_init set providers are
+// only initialized on their first use.
+func (e *Engine) InitializeProviders(ctx context.Context) error {
+	providers := e.AllProviders()
+	lazy := e.Config().LazyInit
+
+	// Providers replaced by a reload are initialized anew
+	e.initMutex.Lock()
+	e.inits = make(map[string]*providerInit, len(providers))
+	for _, provider := range providers {
+		e.inits[provider.ID()] = &providerInit{provider: provider, state: ProviderReadiness{State: ReadinessPending}}
+	}
+	e.initMutex.Unlock()
+	if lazy {
+		e.Logger.Info("Initializing %d providers on their first use", len(providers))
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = e.ensureInitialized(ctx, provider)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// ensureInitialized initializes a provider unless it is ready, waiting for an attempt
+// already running. It fails while the provider's last attempt failed within
+// initRetryDelay.
+func (e *Engine) ensureInitialized(ctx context.Context, provider Provider) error {
+	if id, _ := ctx.Value(initializingKey{}).(string); id == provider.ID() {
+		return nil
+	}
+
+	e.initMutex.Lock()
+	init, ok := e.inits[provider.ID()]
+	if !ok || init.provider != provider {
+		// Providers registered after InitializeProviders are left to their caller
+		e.initMutex.Unlock()
+		return nil
+	}
+	switch init.state.State {
+	case ReadinessReady:
+		e.initMutex.Unlock()
+		return nil
+	case ReadinessInitializing:
+		done := init.done
+		e.initMutex.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return errors.Track(ctx.Err()).WithContext("provider_id", provider.ID()).Error()
+		}
+		e.initMutex.Lock()
+		defer e.initMutex.Unlock()
+		return init.err
+	case ReadinessFailed:
+		if time.Since(init.state.At) < initRetryDelay {
+			e.initMutex.Unlock()
+			return init.err
+		}
+	}
+	init.state.State = ReadinessInitializing
+	init.done = make(chan struct{})
+	e.initMutex.Unlock()
+
+	// The attempt outlives callers that give up waiting, so others can still use it
+	initCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+	if timeout := time.Duration(e.Timeouts().Initialize); timeout > 0 {
+		initCtx, cancel = context.WithTimeout(initCtx, timeout)
+	}
+	// Calls the provider makes while initializing don't wait for it
+	initCtx = context.WithValue(initCtx, initializingKey{}, provider.ID())
+	started := time.Now()
+	err := e.initializeGuarded(initCtx, provider)
+	if err == nil && initCtx.Err() != nil {
+		err = initCtx.Err()
+	}
+	cancel()
+	if err != nil {
+		err = errors.Track(err).
+			WithContext("provider_id", provider.ID()).
+			WithMessagef("Provider %s failed to initialize: %v", provider.ID(), err).
+			AsProvider(provider.ID()).Error()
+	}
+
+	e.initMutex.Lock()
+	init.err = err
+	init.state = ProviderReadiness{State: ReadinessReady, Duration: Duration(time.Since(started)), At: time.Now()}
+	if err != nil {
+		init.state.State = ReadinessFailed
+		init.state.Error = err.Error()
+	}
+	close(init.done)
+	e.initMutex.Unlock()
+
+	if err != nil {
+		e.Logger.Error("Failed to initialize provider %s: %v", provider.ID(), err)
+		e.Events.Publish(events.ProviderHealth, map[string]interface{}{
+			"provider": provider.ID(),
+			"status":   "unavailable",
+			"error":    err.Error(),
+		})
+		return err
+	}
+	e.Events.Publish(events.ProviderHealth, map[string]interface{}{
+		"provider": provider.ID(),
+		"status":   "ready",
+	})
+	return nil
+}
+
+// ProviderReadiness returns how far a provider got initializing; providers registered
+// after InitializeProviders count as ready
+func (e *Engine) ProviderReadiness(id string) ProviderReadiness {
+	e.initMutex.Lock()
+	defer e.initMutex.Unlock()
+	if init, ok := e.inits[id]; ok {
+		return init.state
+	}
+	return ProviderReadiness{State: ReadinessReady}
+}
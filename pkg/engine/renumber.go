@@ -0,0 +1,73 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/numbering"
+	"context"
+	"slices"
+)
+
+// withRenumbering returns a context whose chapter downloads from provider are numbered by
+// the normalizer of ctx, if it has one
+func (e *Engine) withRenumbering(ctx context.Context, provider Provider) context.Context {
+	n, ok := numbering.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ctx = download.WithRenumber(ctx, func(ctx context.Context, chapter *core.Chapter) core.ChapterInfo {
+		return e.renumberChapter(ctx, provider, n, chapter)
+	})
+	// Numbers starting over in every volume would collide without it
+	if n.Scheme == numbering.SchemeVolume {
+		ctx = download.WithVolumeNames(ctx)
+	}
+	return ctx
+}
+
+// renumberChapter returns the details of a chapter as n numbers them among the other
+// chapters of its manga. Without its manga only the mapping of n applies.
+func (e *Engine) renumberChapter(ctx context.Context, provider Provider, n numbering.Normalizer, chapter *core.Chapter) core.ChapterInfo {
+	chapters := []core.ChapterInfo{chapter.Info}
+	if chapter.MangaID == "" {
+		n.Scheme = numbering.SchemeSource
+	} else if info, err := e.GetManga(ctx, provider, chapter.MangaID); err != nil {
+		e.Log(ctx).Warn("Numbering chapter %s without the other chapters of %s: %v", chapter.Info.ID, chapter.MangaID, err)
+		n.Scheme = numbering.SchemeSource
+	} else {
+		chapters = info.Chapters
+		if !slices.ContainsFunc(chapters, func(ch core.ChapterInfo) bool { return ch.ID == chapter.Info.ID }) {
+			chapters = append(slices.Clone(chapters), chapter.Info)
+		}
+	}
+
+	for _, ch := range n.Normalize(chapters) {
+		if ch.ID == chapter.Info.ID {
+			// The chapter list may lack details the chapter itself has
+			renumbered := chapter.Info
+			renumbered.Number, renumbered.Label = ch.Number, ch.Label
+			renumbered.Volume = ch.Volume
+			if ch.Title != "" {
+				renumbered.Title = ch.Title
+			}
+			return renumbered
+		}
+	}
+	return chapter.Info
+}
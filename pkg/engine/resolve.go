@@ -0,0 +1,161 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/errors"
+	"net/url"
+	"strings"
+)
+
+// ResolveChapter turns a chapter reference into a provider and chapter ID. The reference
+// is either a combined "provider:chapter-id" or a chapter URL on a provider's site.
+func (e *Engine) ResolveChapter(ref string) (Provider, string, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return e.resolveChapterURL(ref)
+	}
+
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, "", errors.Newf("invalid chapter ID format: %s", ref).
+			WithMessage("Chapter IDs must look like provider:chapter-id or be a chapter URL").
+			Error()
+	}
+
+	provider, err := e.GetProvider(parts[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return provider, parts[1], nil
+}
+
+// Kinds of entities a URL can point to
+const (
+	URLKindManga   = "manga"
+	URLKindChapter = "chapter"
+)
+
+// ResolvedURL is a provider URL mapped to the entity it points to
+type ResolvedURL struct {
+	Provider Provider
+	Kind     string
+	ID       string
+}
+
+// ResolveURL maps a manga or chapter URL on a provider's site to its provider and ID.
+// Manga URLs are tried first since chapter URLs usually extend them.
+func (e *Engine) ResolveURL(rawURL string) (*ResolvedURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, errors.Newf("invalid URL: %s", rawURL).
+			WithContext("url", rawURL).
+			WithMessage("Expected an absolute URL like https://example.com/manga/title").
+			Error()
+	}
+
+	provider, err := e.ProviderForURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolver, ok := provider.(MangaURLResolver); ok {
+		if id, err := e.resolveMangaGuarded(provider, resolver, u); err == nil {
+			return &ResolvedURL{Provider: provider, Kind: URLKindManga, ID: id}, nil
+		}
+	}
+
+	if resolver, ok := provider.(URLResolver); ok {
+		if id, err := e.resolveChapterGuarded(provider, resolver, u); err == nil {
+			return &ResolvedURL{Provider: provider, Kind: URLKindChapter, ID: id}, nil
+		}
+	}
+
+	return nil, errors.Newf("cannot resolve %s", rawURL).
+		WithContext("url", rawURL).
+		WithMessage("The URL does not point to a manga or chapter on " + provider.Name()).
+		AsNotFound().Error()
+}
+
+// URLFor returns the URL of a manga or chapter (URLKindManga or URLKindChapter) on its
+// provider's site
+func (e *Engine) URLFor(provider Provider, kind, id string) (string, error) {
+	builder, ok := provider.(URLBuilder)
+	if !ok {
+		return "", errors.Newf("provider %s cannot build site URLs", provider.ID()).
+			WithContext("id", id).
+			AsProvider(provider.ID()).Error()
+	}
+	return e.urlForGuarded(provider, builder, kind, id)
+}
+
+// resolveChapterURL asks the provider whose site hosts the URL for the chapter ID
+func (e *Engine) resolveChapterURL(rawURL string) (Provider, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", errors.Track(err).
+			WithContext("url", rawURL).
+			WithMessage("Invalid chapter URL").
+			Error()
+	}
+
+	provider, err := e.ProviderForURL(u)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resolver, ok := provider.(URLResolver)
+	if !ok {
+		return nil, "", errors.Newf("provider %s cannot resolve chapter URLs", provider.ID()).
+			WithContext("url", rawURL).
+			AsProvider(provider.ID()).Error()
+	}
+
+	id, err := e.resolveChapterGuarded(provider, resolver, u)
+	if err != nil {
+		// A manga URL is the most likely mistake; point to its chapters instead
+		if mangaResolver, ok := provider.(MangaURLResolver); ok {
+			if mangaID, mangaErr := e.resolveMangaGuarded(provider, mangaResolver, u); mangaErr == nil {
+				return nil, "", errors.Newf("%s is a manga URL, not a chapter URL", rawURL).
+					WithContext("url", rawURL).
+					WithMessagef("This is the page of a manga; list its chapters with 'luminary chapters %s:%s'", provider.ID(), mangaID).
+					Error()
+			}
+		}
+		return nil, "", err
+	}
+	return provider, id, nil
+}
+
+// ProviderForURL returns the provider whose site hosts the URL
+func (e *Engine) ProviderForURL(u *url.URL) (Provider, error) {
+	for _, provider := range e.AllProviders() {
+		site, err := url.Parse(provider.SiteURL())
+		if err == nil && normalizeHost(site.Hostname()) == normalizeHost(u.Hostname()) {
+			return provider, nil
+		}
+	}
+
+	return nil, errors.Newf("no provider for %s", u.Hostname()).
+		WithContext("url", u.String()).
+		WithMessage("No registered provider serves this site. Use 'luminary providers' to list supported sites.").
+		AsNotFound().Error()
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
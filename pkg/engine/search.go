@@ -0,0 +1,221 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SearchResult is what one provider found for a search
+type SearchResult struct {
+	Provider Provider
+	Results  []core.Manga
+	Err      error
+	Elapsed  time.Duration
+}
+
+// Search searches one provider within the search time budget
+func (e *Engine) Search(ctx context.Context, provider Provider, query string, options core.SearchOptions) ([]core.Manga, error) {
+	return e.searchWithin(ctx, provider, query, options)
+}
+
+// SearchAll searches the providers concurrently and sends each provider's results as soon
+// as it answers, so callers can show them without waiting for the slowest site. The
+// channel is closed once every provider is done; a failing provider doesn't stop the
+// others. Every provider gets the search time budget from when it has a free slot, so one
+// busy with other calls doesn't use up the time of the others, nor they its.
+func (e *Engine) SearchAll(ctx context.Context, providers []Provider, query string, options core.SearchOptions) <-chan SearchResult {
+	results := make(chan SearchResult, len(providers))
+	if len(providers) == 0 {
+		close(results)
+		return results
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(providers))
+	for _, provider := range providers {
+		go func(provider Provider) {
+			defer wg.Done()
+
+			start := time.Now()
+			mangas, err := e.searchWithin(ctx, provider, query, options)
+			if err != nil {
+				e.Log(ctx).Debug("Search failed for %s: %v", provider.ID(), err)
+			}
+			results <- SearchResult{Provider: provider, Results: mangas, Err: err, Elapsed: time.Since(start)}
+		}(provider)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// searchWithin searches a provider holding one of its slots; the search time budget starts
+// once it has one. It runs on its own goroutine for SearchAll, so panics anywhere in the
+// search are isolated, not only those of the provider's Search.
+func (e *Engine) searchWithin(ctx context.Context, provider Provider, query string, options core.SearchOptions) (mangas []core.Manga, err error) {
+	defer e.recoverProvider(provider.ID(), "Search", &err)
+	heldCtx, release, err := e.acquireProvider(ctx, provider.ID())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	searchCtx, cancel := e.WithSearchBudget(heldCtx, options.Pages)
+	defer cancel()
+	mangas, err = e.searchProvider(searchCtx, provider, query, options)
+	if budgetErr := network.BudgetError(searchCtx, heldCtx); budgetErr != nil {
+		err = errors.Track(budgetErr).WithContext("provider", provider.ID()).Error()
+	}
+	return mangas, err
+}
+
+// altTitleCandidates is how many more results than asked for are fetched from providers
+// whose search ignores alternative titles, to make up for those filtered out
+const altTitleCandidates = 2
+
+// searchProvider searches a provider, matching alternative titles when asked to
+func (e *Engine) searchProvider(ctx context.Context, provider Provider, query string, options core.SearchOptions) ([]core.Manga, error) {
+	if !options.IncludeAltTitles {
+		results, err := e.searchGuarded(ctx, provider, query, options)
+		return dedupeResults(results), err
+	}
+
+	native := false
+	if searcher, ok := provider.(AltTitleSearcher); ok {
+		native = searcher.SearchesAltTitles()
+	}
+
+	fetch := options
+	if !native && options.Limit > 0 {
+		fetch.Limit = options.Limit * altTitleCandidates
+	}
+	results, err := e.searchGuarded(ctx, provider, query, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	results = e.matchAltTitles(ctx, provider, query, dedupeResults(results), !native, options.Concurrency)
+	if options.Limit > 0 && len(results) > options.Limit {
+		results = results[:options.Limit]
+	}
+	return results, nil
+}
+
+// dedupeResults drops the results a site listed more than once, which paged searches do
+// when the listing shifts between pages: those with the ID of an earlier result, and
+// those with its title and cover, listed under another URL
+func dedupeResults(results []core.Manga) []core.Manga {
+	if len(results) < 2 {
+		return results
+	}
+
+	kept := make([]core.Manga, 0, len(results))
+	for _, m := range results {
+		duplicate := slices.ContainsFunc(kept, func(k core.Manga) bool {
+			return k.ID == m.ID || (m.CoverURL != "" && k.CoverURL == m.CoverURL && core.SameTitle(k.Title, m.Title))
+		})
+		if !duplicate {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// matchAltTitles records which title of every result matched the query. With filter set,
+// results matching none of their titles are looked up for the alternative titles search
+// results often leave out, and dropped if still none matches, even if that leaves none.
+func (e *Engine) matchAltTitles(ctx context.Context, provider Provider, query string, results []core.Manga, filter bool, concurrency int) []core.Manga {
+	var unmatched []int
+	for i := range results {
+		if title, ok := results[i].MatchTitle(query); ok {
+			results[i].MatchedTitle = title
+		} else {
+			unmatched = append(unmatched, i)
+		}
+	}
+	if !filter || len(unmatched) == 0 {
+		return results
+	}
+
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, i := range unmatched {
+		wg.Add(1)
+		go func(m *core.Manga) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := e.matchLookedUpTitles(ctx, provider, query, m); err != nil {
+				e.Log(ctx).Debug("Failed to look up alternative titles of %s:%s: %v", provider.ID(), m.ID, err)
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	matched := make([]core.Manga, 0, len(results))
+	for _, m := range results {
+		if m.MatchedTitle != "" {
+			matched = append(matched, m)
+		}
+	}
+	if dropped := len(results) - len(matched); dropped > 0 {
+		e.Log(ctx).Debug("Dropped %d results of %s matching none of their titles", dropped, provider.ID())
+	}
+	return matched
+}
+
+// matchLookedUpTitles looks up the alternative titles of a search result and records the
+// one matching the query, if any. It runs on its own goroutine, so its panics are isolated.
+func (e *Engine) matchLookedUpTitles(ctx context.Context, provider Provider, query string, m *core.Manga) (err error) {
+	defer e.recoverProvider(provider.ID(), "GetManga", &err)
+	info, err := e.GetManga(ctx, provider, m.ID)
+	if err != nil {
+		return err
+	}
+	m.AlternativeTitles = info.AlternativeTitles
+	if title, ok := m.MatchTitle(query); ok {
+		m.MatchedTitle = title
+	}
+	return nil
+}
+
+// BrowseTag lists the manga of a tag within the list time budget
+func (e *Engine) BrowseTag(ctx context.Context, browser TagBrowser, providerID, tagID string, options core.SearchOptions) ([]core.Manga, error) {
+	listCtx, cancel := e.WithListBudget(ctx, options.Pages)
+	defer cancel()
+
+	results, err := e.browseGuarded(listCtx, browser, providerID, tagID, options)
+	if budgetErr := network.BudgetError(listCtx, ctx); budgetErr != nil {
+		err = errors.Track(budgetErr).WithContext("provider", providerID).WithContext("tag", tagID).Error()
+	}
+	return results, err
+}
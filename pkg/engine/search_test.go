@@ -0,0 +1,60 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/provider/base"
+	"context"
+	"testing"
+)
+
+func TestSearchAltTitlesDropsUnmatchedResults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	provider := base.New(e, base.Config{ID: "test", Name: "Test", SiteURL: "https://example.com", Type: base.TypeWeb}).
+		WithSearch(func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+			return []core.Manga{{ID: "a", Title: "Something Else"}, {ID: "b", Title: "Another One"}}, nil
+		}).
+		WithGetManga(func(_ context.Context, id string) (*core.MangaInfo, error) {
+			info := &core.MangaInfo{Manga: core.Manga{ID: id}}
+			if id == "b" {
+				info.AlternativeTitles = []string{"Kaguya-sama wa Kokurasetai"}
+			}
+			return info, nil
+		}).
+		Build()
+
+	options := core.SearchOptions{IncludeAltTitles: true}
+	results, err := e.Search(context.Background(), provider, "kaguya-sama wa kokurasetai", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "b" || results[0].MatchedTitle != "Kaguya-sama wa Kokurasetai" {
+		t.Errorf("got %+v, want b matched by its alternative title", results)
+	}
+
+	results, err = e.Search(context.Background(), provider, "blue lock", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %+v, want no results when none matches", results)
+	}
+}
@@ -0,0 +1,289 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SelfTestFixture is a search, manga and chapter known to work on a provider's site.
+// Without a manga ID the first search result is tested, without a chapter ID the first
+// chapter that can be downloaded.
+type SelfTestFixture struct {
+	Query     string `json:"query"`
+	MangaID   string `json:"manga_id,omitempty"`
+	ChapterID string `json:"chapter_id,omitempty"`
+}
+
+// SelfTestable is an optional capability for providers that know a fixture to test their
+// selectors and endpoints against the live site with
+type SelfTestable interface {
+	SelfTestFixture() (SelfTestFixture, bool)
+}
+
+// Outcomes of self-test checks and providers
+const (
+	SelfTestPassed      = "passed"
+	SelfTestBroken      = "broken"      // The site answered, but not with what the provider expects
+	SelfTestUnreachable = "unreachable" // The site couldn't be reached, blocked us or timed out
+	SelfTestSkipped     = "skipped"
+)
+
+// SelfTestReport is the outcome of testing providers against their live sites
+type SelfTestReport struct {
+	Started     time.Time          `json:"started"`
+	Finished    time.Time          `json:"finished"`
+	Passed      int                `json:"passed"`
+	Broken      int                `json:"broken"`
+	Unreachable int                `json:"unreachable"`
+	Skipped     int                `json:"skipped"`
+	Providers   []ProviderSelfTest `json:"providers"`
+}
+
+// ProviderSelfTest is the outcome of testing one provider; its status is that of its
+// worst check
+type ProviderSelfTest struct {
+	Provider string          `json:"provider"`
+	Name     string          `json:"name"`
+	Status   string          `json:"status"`
+	Fixture  SelfTestFixture `json:"fixture"`
+	Checks   []SelfTestCheck `json:"checks"`
+}
+
+// SelfTestCheck is one step of a provider self-test: search, manga or chapter
+type SelfTestCheck struct {
+	Check      string `json:"check"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"` // Error code, see errors.Code
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// SelfTest runs the fixture of every provider against its live site, the providers side
+// by side, and reports which look broken. Providers without a fixture are skipped.
+func (e *Engine) SelfTest(ctx context.Context, providers []Provider) *SelfTestReport {
+	report := &SelfTestReport{Started: time.Now(), Providers: make([]ProviderSelfTest, len(providers))}
+
+	// Fresh answers only; the cache would hide a broken site
+	ctx = cache.Refresh(ctx)
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Providers[i] = e.selfTestProvider(ctx, provider)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Providers {
+		switch result.Status {
+		case SelfTestPassed:
+			report.Passed++
+		case SelfTestBroken:
+			report.Broken++
+		case SelfTestUnreachable:
+			report.Unreachable++
+		default:
+			report.Skipped++
+		}
+	}
+	report.Finished = time.Now()
+	return report
+}
+
+// selfTestProvider searches for the fixture, reads its manga and resolves the pages of
+// one chapter, stopping at the first check that doesn't pass
+func (e *Engine) selfTestProvider(ctx context.Context, provider Provider) ProviderSelfTest {
+	result := ProviderSelfTest{Provider: provider.ID(), Name: provider.Name(), Status: SelfTestSkipped}
+
+	testable, ok := provider.(SelfTestable)
+	if ok {
+		result.Fixture, ok = testable.SelfTestFixture()
+	}
+	if !ok || result.Fixture.Query == "" {
+		result.Checks = append(result.Checks, SelfTestCheck{Check: "fixture", Status: SelfTestSkipped, Detail: "no fixture to test with"})
+		return result
+	}
+	e.Log(ctx).Info("Self-testing %s with %q", provider.ID(), result.Fixture.Query)
+
+	mangaID := result.Fixture.MangaID
+	check := e.selfTestCheck(ctx, "search", func(ctx context.Context) (string, error) {
+		results, err := e.Search(ctx, provider, result.Fixture.Query, core.SearchOptions{Limit: 20, Pages: 1})
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 {
+			return "", brokenf("search for %q found nothing", result.Fixture.Query)
+		}
+		if results[0].ID == "" || results[0].Title == "" {
+			return "", brokenf("search results have no ID or title")
+		}
+
+		detail := fmt.Sprintf("%d results", len(results))
+		if mangaID == "" {
+			mangaID = results[0].ID
+		} else if !slices.ContainsFunc(results, func(m core.Manga) bool { return m.ID == mangaID }) {
+			detail += ", without the fixture manga"
+		}
+		return detail, nil
+	})
+	if result.add(check) {
+		return result
+	}
+
+	chapterID := result.Fixture.ChapterID
+	check = e.selfTestCheck(ctx, "manga", func(ctx context.Context) (string, error) {
+		info, err := e.GetManga(ctx, provider, mangaID)
+		if err != nil {
+			return "", err
+		}
+		if info.Title == "" {
+			return "", brokenf("manga %s has no title", mangaID)
+		}
+		if len(info.Chapters) == 0 {
+			return "", brokenf("manga %s has no chapters", mangaID)
+		}
+
+		if chapterID == "" {
+			for _, chapter := range info.Chapters {
+				if chapter.ExternalURL == "" && chapter.ID != "" {
+					chapterID = chapter.ID
+					break
+				}
+			}
+			if chapterID == "" {
+				return "", brokenf("manga %s has no chapter that can be downloaded", mangaID)
+			}
+		}
+		return fmt.Sprintf("%s, %d chapters", info.Title, len(info.Chapters)), nil
+	})
+	if result.add(check) {
+		return result
+	}
+
+	check = e.selfTestCheck(ctx, "chapter", func(ctx context.Context) (string, error) {
+		chapter, err := e.GetChapter(ctx, provider, chapterID)
+		if err != nil {
+			return "", err
+		}
+		if len(chapter.Pages) == 0 {
+			return "", brokenf("chapter %s has no pages", chapterID)
+		}
+		for _, page := range chapter.Pages {
+			if strings.TrimSpace(page.URL) == "" {
+				return "", brokenf("page %d of chapter %s has no URL", page.Index+1, chapterID)
+			}
+		}
+		return fmt.Sprintf("chapter %s, %d pages", chapterID, len(chapter.Pages)), nil
+	})
+	result.add(check)
+	return result
+}
+
+// add records a check and takes on its status if it is worse; it reports whether the
+// check failed, so the checks depending on it can't run
+func (r *ProviderSelfTest) add(check SelfTestCheck) bool {
+	r.Checks = append(r.Checks, check)
+	switch {
+	case check.Status == SelfTestBroken:
+		r.Status = SelfTestBroken
+	case check.Status == SelfTestUnreachable && r.Status != SelfTestBroken:
+		r.Status = SelfTestUnreachable
+	case check.Status == SelfTestPassed && r.Status == SelfTestSkipped:
+		r.Status = SelfTestPassed
+	}
+	return check.Status != SelfTestPassed
+}
+
+// selfTestCheck runs one check and classifies how it failed
+func (e *Engine) selfTestCheck(ctx context.Context, name string, run func(context.Context) (string, error)) SelfTestCheck {
+	check := SelfTestCheck{Check: name, Status: SelfTestPassed}
+	start := time.Now()
+	detail, err := run(ctx)
+	check.DurationMS = time.Since(start).Milliseconds()
+	if err == nil {
+		check.Detail = detail
+		return check
+	}
+
+	check.Error, check.Code = err.Error(), errors.Code(err)
+	// Failed retries only say how many there were; the cause of the last tells what went wrong
+	var tracked *errors.TrackedError
+	if errors.As(err, &tracked) {
+		if joined, ok := tracked.Context["errors"].([]error); ok && len(joined) > 0 {
+			last := joined[len(joined)-1]
+			if errors.As(last, &tracked) && tracked.Original != nil {
+				last = tracked.Original
+			}
+			check.Error += ", the last: " + last.Error()
+		}
+	}
+	check.Status = SelfTestBroken
+	if unreachable(err) {
+		check.Status = SelfTestUnreachable
+	}
+	e.Log(ctx).Warn("Self-test %s failed: %v", name, err)
+	return check
+}
+
+// brokenf describes answers of a site that don't hold what the provider expects
+func brokenf(format string, args ...interface{}) error {
+	return errors.Newf(format, args...).AsParser().Error()
+}
+
+// unreachable reports whether err comes from not getting a usable answer at all: a
+// connection failure, a timeout, a server error, rate limiting or a bot check, rather
+// than from the provider misreading the site. Providers wrap errors of the network in
+// their own category, so the causes are looked for below it.
+func unreachable(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, network.ErrOffline) ||
+		errors.Is(err, network.ErrRequestBudget) || errors.As(err, &netErr) {
+		return true
+	}
+
+	var tracked *errors.TrackedError
+	if !errors.As(err, &tracked) {
+		return false
+	}
+	switch tracked.Category {
+	case errors.CategoryTimeout, errors.CategoryRateLimit, errors.CategoryChallenge:
+		return true
+	}
+	if status, _ := tracked.Context["status_code"].(int); status == http.StatusTooManyRequests || status >= 500 {
+		return true
+	}
+	// Retries joined: unreachable if every attempt was
+	if joined, ok := tracked.Context["errors"].([]error); ok && len(joined) > 0 {
+		return !slices.ContainsFunc(joined, func(err error) bool { return !unreachable(err) })
+	}
+	return false
+}
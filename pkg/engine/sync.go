@@ -0,0 +1,325 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SyncOptions configures Sync
+type SyncOptions struct {
+	// FillGaps also downloads missing chapters below the highest one downloaded; by default
+	// only newer chapters are
+	FillGaps bool
+	// Providers, if set, only syncs the series of these providers
+	Providers []string
+	// Latest, if set, only looks for missing chapters among the newest chapters, this many,
+	// so a series far behind catches up on its latest chapters only
+	Latest int
+	// Prefer picks the copy of chapters released more than once; by default the preferred
+	// languages decide (see Engine.PreferredLanguages)
+	Prefer *ChapterPreference
+	// AllLanguages syncs chapters in every language, one copy of each per language, instead
+	// of the preferred languages. A chapter then only counts as downloaded by its ID, as
+	// copies in other languages share its number.
+	AllLanguages bool
+	// DryRun only lists the missing chapters
+	DryRun bool
+	// OnSeries, if set, is called with the outcome of every series once it is synced
+	OnSeries func(SyncResult)
+	// OnChapter, if set, is called with the outcome of every chapter as soon as it is known
+	OnChapter func(library.Record)
+}
+
+// SyncResult is the outcome of syncing one series
+type SyncResult struct {
+	Provider  string `json:"provider"`
+	MangaID   string `json:"manga_id"`
+	Title     string `json:"title,omitempty"`
+	OutputDir string `json:"output_dir,omitempty"`
+	Chapters  int    `json:"chapters"` // Chapters the provider lists in the synced languages
+	// Missing holds the display numbers of the chapters found missing, in reading order
+	Missing []string         `json:"missing,omitempty"`
+	Records []library.Record `json:"records,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// SyncSummary is the outcome of Sync
+type SyncSummary struct {
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished"`
+	DryRun   bool         `json:"dry_run,omitempty"`
+	Series   []SyncResult `json:"series"`
+
+	Checked    int `json:"checked"`       // Series whose details were fetched
+	Failed     int `json:"failed_series"` // Series that couldn't be fetched or downloaded to
+	Missing    int `json:"missing"`       // Chapters found missing
+	Downloaded int `json:"downloaded"`
+	Partial    int `json:"partial"`
+	Errors     int `json:"failed_chapters"`
+	Skipped    int `json:"skipped"`
+}
+
+// Failures counts the series and chapters that failed
+func (s *SyncSummary) Failures() int {
+	return s.Failed + s.Errors
+}
+
+// Sync brings the library up to date: it fetches the details of every series in it again,
+// keeping them in the cache, and downloads the chapters missing from it to where the
+// series' latest chapter went. Failed series and chapters don't stop the others; the
+// error is only set when the library can't be read, the sync is cancelled or the
+// download quota is reached.
+func (e *Engine) Sync(ctx context.Context, opts SyncOptions) (*SyncSummary, error) {
+	if e.Library == nil {
+		return nil, errors.New("download history is not available").
+			WithMessage("Syncing needs the download history in ~/.luminary to know which series you follow").
+			AsFileSystem().Error()
+	}
+
+	series, err := e.Library.Series()
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Providers) > 0 {
+		providers, err := e.SelectProviders(opts.Providers)
+		if err != nil {
+			return nil, err
+		}
+		series = slices.DeleteFunc(series, func(s library.Series) bool {
+			return !slices.ContainsFunc(providers, func(p Provider) bool { return p.ID() == s.Provider })
+		})
+	}
+
+	summary := &SyncSummary{Started: time.Now(), DryRun: opts.DryRun, Series: []SyncResult{}}
+	e.Log(ctx).Info("Syncing %d series", len(series))
+
+	for _, s := range series {
+		if err := ctx.Err(); err != nil {
+			summary.Finished = time.Now()
+			return summary, errors.Track(err).
+				WithMessagef("Sync cancelled after %d of %d series", len(summary.Series), len(series)).
+				Error()
+		}
+
+		result, err := e.syncSeries(ctx, s, opts)
+		if err == nil {
+			err = network.RequestBudgetError(ctx)
+		}
+		summary.add(result)
+		if opts.OnSeries != nil {
+			opts.OnSeries(result)
+		}
+		if err != nil {
+			summary.Finished = time.Now()
+			return summary, err
+		}
+	}
+
+	summary.Finished = time.Now()
+	return summary, nil
+}
+
+// syncSeries refreshes a series and downloads its missing chapters. The error is only set
+// when the whole sync has to stop.
+func (e *Engine) syncSeries(ctx context.Context, s library.Series, opts SyncOptions) (SyncResult, error) {
+	result := SyncResult{Provider: s.Provider, MangaID: s.MangaID, OutputDir: s.OutputDir}
+
+	provider, err := e.GetProvider(s.Provider)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	info, err := e.GetManga(cache.Refresh(ctx), provider, s.MangaID)
+	if err != nil {
+		result.Error = err.Error()
+		e.Log(ctx).Warn("Failed to sync %s:%s: %v", s.Provider, s.MangaID, err)
+		return result, nil
+	}
+	result.Title = info.Title
+
+	chapters := syncChapters(info.Chapters, opts, e.PreferredLanguages())
+	result.Chapters = len(chapters)
+	if opts.Latest > 0 {
+		chapters = LatestChapters(chapters, opts.Latest)
+	}
+	missing := missingChapters(chapters, s, opts)
+	for _, chapter := range missing {
+		result.Missing = append(result.Missing, chapter.DisplayNumber())
+	}
+	if opts.DryRun || len(missing) == 0 {
+		return result, nil
+	}
+
+	if s.Storage != "" {
+		storage, err := download.ParseStorage(s.Storage)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		ctx = download.WithStorage(ctx, storage)
+	}
+
+	e.Log(ctx).Info("Downloading %d new chapters of %s:%s to %s", len(missing), s.Provider, s.MangaID, s.OutputDir)
+	for _, chapter := range missing {
+		if err := ctx.Err(); err != nil {
+			return result, errors.Track(err).
+				WithContext("manga_id", s.MangaID).
+				WithMessage("Sync cancelled").
+				Error()
+		}
+
+		record, err := e.DownloadChapterRecord(withChapterHint(ctx, s.MangaID, chapter), provider, chapter.ID, s.OutputDir)
+		if record.Chapter == "" {
+			record.Chapter = chapter.DisplayNumber()
+		}
+		result.Records = append(result.Records, record)
+		if opts.OnChapter != nil {
+			opts.OnChapter(record)
+		}
+		// Chapters past the newest one downloaded are news once they are in the library, so
+		// each is announced once; filled gaps are not news
+		downloaded := record.Status == library.StatusCompleted || record.Status == library.StatusPartial
+		if downloaded && chapter.Number > s.Latest {
+			e.publishNewChapter(e.feedItem(provider, info, chapter), "sync")
+		}
+		if errors.Is(err, download.ErrQuotaExceeded) {
+			return result, err
+		}
+		if budgetErr := network.RequestBudgetError(ctx); budgetErr != nil {
+			return result, budgetErr
+		}
+	}
+	return result, nil
+}
+
+// syncChapters returns the chapters of a series in the preferred languages, one copy of
+// each, in reading order. With AllLanguages it returns one copy of each in every language.
+func syncChapters(chapters []core.ChapterInfo, opts SyncOptions, languages []string) []core.ChapterInfo {
+	prefer := ChapterPreference{Languages: languages}
+	if opts.Prefer != nil {
+		prefer = *opts.Prefer
+		if len(prefer.Languages) == 0 {
+			prefer.Languages = languages
+		}
+	}
+
+	if !opts.AllLanguages {
+		chapters = ResolveDuplicates(FilterChapters(chapters, ChapterFilter{Languages: languages}), prefer)
+	} else {
+		byLanguage := make(map[string][]core.ChapterInfo)
+		var order []string
+		for _, chapter := range chapters {
+			language := strings.ToLower(chapter.Language)
+			if _, ok := byLanguage[language]; !ok {
+				order = append(order, language)
+			}
+			byLanguage[language] = append(byLanguage[language], chapter)
+		}
+		chapters = nil
+		for _, language := range order {
+			chapters = append(chapters, ResolveDuplicates(byLanguage[language], prefer)...)
+		}
+	}
+	SortChapters(chapters)
+	return chapters
+}
+
+// missingChapters returns the chapters that aren't in the library: those newer than the
+// highest one downloaded, or every one with FillGaps. A chapter counts as downloaded when
+// any copy of its number is, unless AllLanguages is set.
+func missingChapters(chapters []core.ChapterInfo, s library.Series, opts SyncOptions) []core.ChapterInfo {
+	numbers := make(map[string]bool, len(s.Downloaded))
+	for _, number := range s.Downloaded {
+		if number != "" {
+			numbers[number] = true
+		}
+	}
+
+	var missing []core.ChapterInfo
+	for _, chapter := range chapters {
+		_, downloaded := s.Downloaded[chapter.ID]
+		switch {
+		case downloaded || (numbers[chapter.DisplayNumber()] && !opts.AllLanguages):
+		case chapter.ExternalURL != "": // Can't be downloaded anyway
+		case !opts.FillGaps && chapter.Number <= s.Latest:
+		default:
+			missing = append(missing, chapter)
+		}
+	}
+	return missing
+}
+
+// publishNewChapter announces a chapter found on a followed series; source names the
+// check that found it. A chapter the feed check and sync both find is announced once.
+func (e *Engine) publishNewChapter(item library.FeedItem, source string) {
+	if e.Feed != nil {
+		first, err := e.Feed.Announce(item.Provider, item.ChapterID)
+		if err != nil {
+			e.Logger.Warn("Failed to remember announcing %s:%s: %v", item.Provider, item.ChapterID, err)
+		} else if !first {
+			return
+		}
+	}
+	e.Events.Publish(events.ChapterNew, map[string]interface{}{
+		"provider":    item.Provider,
+		"manga_id":    item.MangaID,
+		"manga_title": item.MangaTitle,
+		"chapter_id":  item.ChapterID,
+		"chapter":     item.Chapter,
+		"title":       item.Title,
+		"language":    item.Language,
+		"group":       item.Group,
+		"url":         item.URL,
+		"source":      source,
+	})
+}
+
+// add counts a series' outcome
+func (s *SyncSummary) add(result SyncResult) {
+	s.Series = append(s.Series, result)
+	if result.Title != "" {
+		s.Checked++
+	}
+	if result.Error != "" {
+		s.Failed++
+	}
+	s.Missing += len(result.Missing)
+	for _, record := range result.Records {
+		switch record.Status {
+		case library.StatusCompleted:
+			s.Downloaded++
+		case library.StatusPartial:
+			s.Partial++
+		case library.StatusFailed:
+			s.Errors++
+		case library.StatusSkipped:
+			s.Skipped++
+		}
+	}
+}
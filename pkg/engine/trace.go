@@ -0,0 +1,77 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/engine/network"
+	"context"
+	"slices"
+)
+
+// SetTracing turns tracing of a provider on or off. Traced providers log every request
+// with its headers and response, and every selector queried on their pages, at debug
+// level whatever the log level, so one provider can be looked into without the noise
+// of the others. Tracing applies to calls started afterwards.
+func (e *Engine) SetTracing(providerID string, on bool) error {
+	if _, err := e.GetProvider(providerID); err != nil {
+		return err
+	}
+
+	e.tracingMutex.Lock()
+	defer e.tracingMutex.Unlock()
+	if !on {
+		delete(e.tracing, providerID)
+		e.Logger.Info("Stopped tracing provider %s", providerID)
+		return nil
+	}
+	if e.tracing == nil {
+		e.tracing = make(map[string]bool)
+	}
+	e.tracing[providerID] = true
+	e.Logger.Info("Tracing provider %s", providerID)
+	return nil
+}
+
+// Tracing reports whether a provider is traced, see SetTracing
+func (e *Engine) Tracing(providerID string) bool {
+	e.tracingMutex.RLock()
+	defer e.tracingMutex.RUnlock()
+	return e.tracing[providerID]
+}
+
+// TracedProviders returns the IDs of the traced providers, sorted
+func (e *Engine) TracedProviders() []string {
+	e.tracingMutex.RLock()
+	defer e.tracingMutex.RUnlock()
+	ids := make([]string, 0, len(e.tracing))
+	for id := range e.tracing {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// providerContext returns the context of a call to a provider: its requests count towards
+// the provider's footprint and are traced when the provider is
+func (e *Engine) providerContext(ctx context.Context, providerID string) context.Context {
+	ctx = network.WithSource(ctx, providerID)
+	if e.Tracing(providerID) {
+		ctx = logger.WithTrace(ctx, providerID)
+	}
+	return ctx
+}
@@ -0,0 +1,305 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/events"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/provider/base"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newUpdatesEngine returns an engine with a provider listing chapters 1 to *latest of one
+// series, of which chapter 1 is in the download history. Downloading chapter 3 fails.
+func newUpdatesEngine(t *testing.T, latest *int) *engine.Engine {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	t.Cleanup(images.Close)
+
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	provider := base.New(e, base.Config{ID: "tst", Name: "Test", SiteURL: "https://example.com", Type: base.TypeWeb}).
+		WithGetManga(func(_ context.Context, id string) (*core.MangaInfo, error) {
+			info := &core.MangaInfo{Manga: core.Manga{ID: id, Title: "Series"}}
+			for n := 1; n <= *latest; n++ {
+				info.Chapters = append(info.Chapters, core.ChapterInfo{
+					ID:       "ch" + strconv.Itoa(n),
+					Number:   float64(n),
+					Language: "en",
+				})
+			}
+			return info, nil
+		}).
+		WithGetChapter(func(_ context.Context, id string) (*core.Chapter, error) {
+			if id == "ch3" {
+				return nil, errors.New("site unavailable")
+			}
+			number, _ := strconv.Atoi(strings.TrimPrefix(id, "ch"))
+			return &core.Chapter{
+				Info:    core.ChapterInfo{ID: id, Number: float64(number), Language: "en"},
+				MangaID: "series",
+				Pages:   []core.Page{{Index: 0, URL: images.URL + "/page.png"}},
+			}, nil
+		}).
+		Build()
+	if err := e.RegisterProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Library == nil {
+		t.Fatal("no download history")
+	}
+	err := e.Library.Add(library.Record{
+		Time:      time.Now(),
+		Provider:  "tst",
+		MangaID:   "series",
+		ChapterID: "ch1",
+		Chapter:   "1",
+		OutputDir: t.TempDir(),
+		Status:    library.StatusCompleted,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// newChapters collects the chapter.new events published while run runs
+func newChapters(t *testing.T, e *engine.Engine, run func()) []events.Event {
+	t.Helper()
+
+	ch, stop := e.Events.Subscribe(events.ChapterNew)
+	run()
+	stop()
+
+	var received []events.Event
+	for event := range ch {
+		received = append(received, event)
+	}
+	return received
+}
+
+func TestSyncDryRunListsMissingChapters(t *testing.T) {
+	latest := 3
+	e := newUpdatesEngine(t, &latest)
+
+	summary, err := e.Sync(context.Background(), engine.SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Series) != 1 {
+		t.Fatalf("synced %d series, want 1", len(summary.Series))
+	}
+	result := summary.Series[0]
+	if result.Title != "Series" || result.Chapters != 3 || len(result.Records) != 0 {
+		t.Errorf("result = %+v, want 3 chapters of Series and no downloads", result)
+	}
+	if got := strings.Join(result.Missing, ","); got != "2,3" {
+		t.Errorf("missing = %s, want 2,3", got)
+	}
+	if summary.Missing != 2 || summary.Downloaded != 0 {
+		t.Errorf("summary counts %d missing and %d downloaded, want 2 and 0", summary.Missing, summary.Downloaded)
+	}
+}
+
+func TestSyncLatestOnlyLooksAtNewestChapters(t *testing.T) {
+	latest := 5
+	e := newUpdatesEngine(t, &latest)
+
+	tests := []struct {
+		opts engine.SyncOptions
+		want string
+	}{
+		{engine.SyncOptions{DryRun: true}, "2,3,4,5"},
+		{engine.SyncOptions{DryRun: true, Latest: 2}, "4,5"},
+		{engine.SyncOptions{DryRun: true, Latest: 10}, "2,3,4,5"},
+	}
+	for _, tt := range tests {
+		summary, err := e.Sync(context.Background(), tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := summary.Series[0]
+		if got := strings.Join(result.Missing, ","); got != tt.want || result.Chapters != 5 {
+			t.Errorf("latest %d: missing %s of %d chapters, want %s of 5", tt.opts.Latest, got, result.Chapters, tt.want)
+		}
+	}
+}
+
+func TestSyncSelectsProviders(t *testing.T) {
+	latest := 3
+	e := newUpdatesEngine(t, &latest)
+	other := base.New(e, base.Config{ID: "oth", Name: "Other", SiteURL: "https://other.example.com", Type: base.TypeWeb}).Build()
+	if err := e.RegisterProvider(other); err != nil {
+		t.Fatal(err)
+	}
+
+	for providers, want := range map[string]int{"": 1, "tst": 1, "oth": 0, "oth,tst": 1} {
+		summary, err := e.Sync(context.Background(), engine.SyncOptions{DryRun: true, Providers: strings.Split(providers, ",")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(summary.Series) != want {
+			t.Errorf("providers %q synced %d series, want %d", providers, len(summary.Series), want)
+		}
+	}
+	if _, err := e.Sync(context.Background(), engine.SyncOptions{Providers: []string{"nope"}}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestSyncWritesManifests(t *testing.T) {
+	latest := 2
+	e := newUpdatesEngine(t, &latest)
+
+	summary, err := e.Sync(context.Background(), engine.SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Series) != 1 || len(summary.Series[0].Records) != 1 {
+		t.Fatalf("summary = %+v, want chapter 2 downloaded", summary)
+	}
+	manifest, err := download.ReadManifest(summary.Series[0].Records[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Provider != "tst" || manifest.ChapterID != "ch2" || manifest.Number != 2 || manifest.Language != "en" || manifest.DownloadedAt.IsZero() {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestSyncPublishesNewChapters(t *testing.T) {
+	latest := 3
+	e := newUpdatesEngine(t, &latest)
+	sync := func(opts engine.SyncOptions) []string {
+		t.Helper()
+		var ids []string
+		for _, event := range newChapters(t, e, func() {
+			if _, err := e.Sync(context.Background(), opts); err != nil {
+				t.Fatal(err)
+			}
+		}) {
+			ids = append(ids, event.Data["chapter_id"].(string))
+			if event.Data["source"] != "sync" || event.Data["manga_title"] != "Series" {
+				t.Errorf("event data = %v", event.Data)
+			}
+		}
+		return ids
+	}
+
+	// A dry run downloads nothing, so it announces nothing
+	if ids := sync(engine.SyncOptions{DryRun: true}); len(ids) != 0 {
+		t.Errorf("dry run announced %v, want nothing", ids)
+	}
+
+	// Only the chapters that were downloaded are announced, each once
+	if ids := sync(engine.SyncOptions{}); !slices.Equal(ids, []string{"ch2"}) {
+		t.Errorf("new chapters = %v, want [ch2]", ids)
+	}
+	if ids := sync(engine.SyncOptions{}); len(ids) != 0 {
+		t.Errorf("second sync announced %v, want nothing", ids)
+	}
+}
+
+func TestCheckFeedPublishesNewChapters(t *testing.T) {
+	latest := 3
+	e := newUpdatesEngine(t, &latest)
+
+	// The first check only remembers the chapters the series has
+	if received := newChapters(t, e, func() {
+		if _, err := e.CheckFeed(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}); len(received) != 0 {
+		t.Errorf("first check published %d events, want none", len(received))
+	}
+
+	latest = 4
+	received := newChapters(t, e, func() {
+		if _, err := e.CheckFeed(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if len(received) != 1 || received[0].Data["chapter_id"] != "ch4" || received[0].Data["source"] != "feed" {
+		t.Errorf("events = %v, want chapter.new of ch4 from the feed", received)
+	}
+}
+
+func TestSyncAndFeedAnnounceChaptersOnce(t *testing.T) {
+	latest := 3
+	e := newUpdatesEngine(t, &latest)
+	ctx := context.Background()
+	announced := func(run func() error) []string {
+		t.Helper()
+		var ids []string
+		for _, event := range newChapters(t, e, func() {
+			if err := run(); err != nil {
+				t.Fatal(err)
+			}
+		}) {
+			ids = append(ids, event.Data["chapter_id"].(string))
+		}
+		return ids
+	}
+	checkFeed := func() error { _, err := e.CheckFeed(ctx); return err }
+	sync := func() error { _, err := e.Sync(ctx, engine.SyncOptions{}); return err }
+
+	if ids := announced(checkFeed); len(ids) != 0 {
+		t.Fatalf("first check announced %v, want nothing", ids)
+	}
+
+	// The feed finds chapter 4 first, so sync only announces the chapter it missed
+	latest = 4
+	if ids := announced(checkFeed); !slices.Equal(ids, []string{"ch4"}) {
+		t.Errorf("feed announced %v, want [ch4]", ids)
+	}
+	if ids := announced(sync); !slices.Equal(ids, []string{"ch2"}) {
+		t.Errorf("sync announced %v, want [ch2]", ids)
+	}
+
+	// Sync finds chapter 5 first, so the feed doesn't announce it again
+	latest = 5
+	if ids := announced(sync); !slices.Equal(ids, []string{"ch5"}) {
+		t.Errorf("sync announced %v, want [ch5]", ids)
+	}
+	if ids := announced(checkFeed); len(ids) != 0 {
+		t.Errorf("feed announced %v again", ids)
+	}
+	items, err := e.Feed.Items("tst", "series")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ChapterID != "ch5" {
+		t.Errorf("feed items = %+v, want ch5 and ch4", items)
+	}
+}
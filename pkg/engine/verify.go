@@ -0,0 +1,463 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/library"
+	"Luminary/pkg/errors"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ChapterCheck is the outcome of verifying one chapter directory or archive
+type ChapterCheck struct {
+	Dir       string `json:"dir"`
+	Provider  string `json:"provider,omitempty"`
+	ChapterID string `json:"chapter_id,omitempty"`
+	Chapter   string `json:"chapter,omitempty"`
+	Pages     int    `json:"pages"`
+	// 1-based numbers of the pages found missing or corrupt
+	Missing []int `json:"missing,omitempty"`
+	Corrupt []int `json:"corrupt,omitempty"`
+	// Repaired lists the pages downloaded again; pages still broken stay in Missing
+	Repaired []int `json:"repaired,omitempty"`
+	// NoManifest is set for chapters downloaded before manifests were written; only their
+	// page count can be checked
+	NoManifest bool `json:"no_manifest,omitempty"`
+	// Archive is set for chapters packed into an archive; Dir is the archive then
+	Archive bool `json:"archive,omitempty"`
+	// Unverified is set for archives without a manifest, whose pages can't be checked
+	Unverified bool   `json:"unverified,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OK reports whether every page of the chapter is intact
+func (c *ChapterCheck) OK() bool {
+	return c.Error == "" && len(c.Missing) == 0 && len(c.Corrupt) == 0
+}
+
+// VerifyChapter checks the pages of a chapter directory against its manifest and, with
+// repair, downloads missing or corrupt pages again through the chapter's provider
+func (e *Engine) VerifyChapter(ctx context.Context, dir string, repair bool) (*ChapterCheck, error) {
+	manifest, err := download.ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &ChapterCheck{
+		Dir:       dir,
+		Provider:  manifest.Provider,
+		ChapterID: manifest.ChapterID,
+		Chapter:   manifest.Chapter,
+		Pages:     len(manifest.Pages),
+	}
+
+	var broken []download.ManifestPage
+	for _, page := range manifest.Pages {
+		switch download.CheckPage(dir, page) {
+		case download.PageMissing:
+			check.Missing = append(check.Missing, page.Index+1)
+			broken = append(broken, page)
+		case download.PageCorrupt:
+			check.Corrupt = append(check.Corrupt, page.Index+1)
+			broken = append(broken, page)
+		}
+	}
+
+	if !repair || len(broken) == 0 {
+		return check, nil
+	}
+
+	if err := e.repairChapter(ctx, check, manifest, broken, check.Dir); err != nil {
+		check.Error = err.Error()
+	}
+	return check, nil
+}
+
+// VerifyArchive checks the pages packed into a chapter archive against the manifest packed
+// with them. With repair, the archive is unpacked, its broken pages are downloaded again
+// like those of a chapter directory and it is packed anew.
+func (e *Engine) VerifyArchive(ctx context.Context, path string, repair bool) (*ChapterCheck, error) {
+	manifest, problems, err := download.CheckArchive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &ChapterCheck{Dir: path, Archive: true}
+	if manifest == nil {
+		check.Unverified = true
+		return check, nil
+	}
+
+	check.Provider, check.ChapterID, check.Chapter = manifest.Provider, manifest.ChapterID, manifest.Chapter
+	check.Pages = len(manifest.Pages)
+	var broken []download.ManifestPage
+	for _, page := range manifest.Pages {
+		switch problems[page.Index] {
+		case download.PageMissing:
+			check.Missing = append(check.Missing, page.Index+1)
+			broken = append(broken, page)
+		case download.PageCorrupt:
+			check.Corrupt = append(check.Corrupt, page.Index+1)
+			broken = append(broken, page)
+		}
+	}
+
+	if !repair || len(broken) == 0 {
+		return check, nil
+	}
+	if err := e.repairArchive(ctx, check, manifest, broken); err != nil {
+		check.Error = err.Error()
+	}
+	return check, nil
+}
+
+// repairArchive repairs the broken pages of a chapter archive in a directory next to it,
+// then replaces the archive with one holding the repaired pages
+func (e *Engine) repairArchive(ctx context.Context, check *ChapterCheck, manifest *download.Manifest, broken []download.ManifestPage) error {
+	dir, err := os.MkdirTemp(filepath.Dir(check.Dir), ".repair-*")
+	if err != nil {
+		return errors.Track(err).WithContext("path", check.Dir).AsFileSystem().Error()
+	}
+	defer os.RemoveAll(dir)
+
+	skipped, err := download.UnpackArchive(check.Dir, dir)
+	if err != nil {
+		return err
+	}
+	// Pages that couldn't be unpacked are downloaded again along with the broken ones
+	for _, name := range skipped {
+		i := slices.IndexFunc(manifest.Pages, func(page download.ManifestPage) bool { return page.Filename == name })
+		if i < 0 {
+			e.Log(ctx).Warn("Leaving %s out of %s, it can't be unpacked", name, check.Dir)
+			continue
+		}
+		if !isBroken(broken, manifest.Pages[i].Index) {
+			broken = append(broken, manifest.Pages[i])
+		}
+	}
+	if err := e.repairChapter(ctx, check, manifest, broken, dir); err != nil {
+		return err
+	}
+	if len(check.Repaired) == 0 {
+		return nil
+	}
+	return download.RepackArchive(dir, check.Dir)
+}
+
+// isArchive reports whether path names a chapter archive
+func isArchive(path string) bool {
+	for _, ext := range download.ArchiveExtensions {
+		if strings.EqualFold(filepath.Ext(path), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// repairChapter downloads the broken pages of a chapter again into dir, which holds its
+// pages, and updates its manifest there
+func (e *Engine) repairChapter(ctx context.Context, check *ChapterCheck, manifest *download.Manifest, broken []download.ManifestPage, dir string) error {
+	if manifest.Provider == "" || manifest.ChapterID == "" {
+		return errors.Newf("manifest of %s doesn't name its provider and chapter", check.Dir).
+			WithMessage("The chapter can't be repaired; download it again instead").Error()
+	}
+	if processed := pageProcessors(manifest.Processed); len(processed) > 0 {
+		return errors.Newf("pages of %s were post-processed (%s)", check.Dir, strings.Join(processed, ", ")).
+			WithMessage("Post-processed chapters can't be repaired; download them again instead").Error()
+	}
+
+	provider, err := e.GetProvider(manifest.Provider)
+	if err != nil {
+		return err
+	}
+
+	// Page URLs in the manifest may have expired, so the chapter is resolved again
+	chapter, err := e.GetChapter(ctx, provider, manifest.ChapterID)
+	if err != nil {
+		return err
+	}
+
+	pages := make([]core.Page, 0, len(broken))
+	for _, entry := range broken {
+		page := core.Page{Index: entry.Index, URL: entry.URL, Filename: entry.Filename}
+		if entry.Index < len(chapter.Pages) {
+			fresh := chapter.Pages[entry.Index]
+			page.URL = fresh.URL
+			page.Fallbacks = append(slices.Clone(fresh.Fallbacks), entry.URL)
+		}
+		pages = append(pages, page)
+	}
+
+	e.Log(ctx).Info("Repairing %d pages of %s", len(pages), check.Dir)
+	ctx = download.WithRefresh(ctx, func(ctx context.Context) (*core.Chapter, error) {
+		return e.GetChapter(ctx, provider, manifest.ChapterID)
+	})
+	failures := e.Download.RepairPages(ctx, pages, dir)
+
+	failed := make(map[int]bool, len(failures))
+	for _, failure := range failures {
+		failed[failure.Index] = true
+	}
+
+	check.Missing, check.Corrupt = nil, nil
+	for i, entry := range manifest.Pages {
+		if !isBroken(broken, entry.Index) {
+			continue
+		}
+		if failed[entry.Index] {
+			manifest.Pages[i].Missing = true
+			check.Missing = append(check.Missing, entry.Index+1)
+			continue
+		}
+		size, sum, _ := download.Digest(filepath.Join(dir, entry.Filename))
+		manifest.Pages[i].Size, manifest.Pages[i].SHA256, manifest.Pages[i].Missing = size, sum, false
+		check.Repaired = append(check.Repaired, entry.Index+1)
+	}
+
+	if err := download.WriteManifest(dir, manifest); err != nil {
+		return err
+	}
+
+	// A chapter made whole again no longer shows up among failed downloads
+	if len(check.Missing) == 0 && e.Library != nil {
+		record := library.Record{
+			Time:      time.Now(),
+			Provider:  manifest.Provider,
+			ChapterID: manifest.ChapterID,
+			MangaID:   manifest.MangaID,
+			Chapter:   manifest.Chapter,
+			Title:     manifest.Title,
+			Language:  manifest.Language,
+			OutputDir: filepath.Dir(check.Dir),
+			Path:      check.Dir,
+			Pages:     len(manifest.Pages),
+			Status:    library.StatusCompleted,
+		}
+		if err := e.Library.Add(record); err != nil {
+			e.Log(ctx).Warn("Failed to record repair of %s: %v", check.Dir, err)
+		}
+	}
+	return nil
+}
+
+// pageProcessors leaves out the processors that only packed the pages into an archive,
+// which manifests of older downloads list along with those that changed pages
+func pageProcessors(processed []string) []string {
+	return slices.DeleteFunc(slices.Clone(processed), func(name string) bool {
+		return slices.Contains(download.ArchiveExtensions, "."+name)
+	})
+}
+
+// isBroken reports whether the page at index is among the broken ones
+func isBroken(broken []download.ManifestPage, index int) bool {
+	for _, page := range broken {
+		if page.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyPath verifies every chapter directory with a manifest and every chapter archive at
+// or below root
+func (e *Engine) VerifyPath(ctx context.Context, root string, repair bool, each func(*ChapterCheck)) error {
+	found := false
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.IsDir() && isArchive(path) {
+			found = true
+			check, err := e.VerifyArchive(ctx, path, repair)
+			if err != nil {
+				check = &ChapterCheck{Dir: path, Archive: true, Error: err.Error()}
+			}
+			each(check)
+			return nil
+		}
+		if d.IsDir() || d.Name() != download.ManifestFile {
+			return nil
+		}
+
+		found = true
+		check, err := e.VerifyChapter(ctx, filepath.Dir(path), repair)
+		if err != nil {
+			check = &ChapterCheck{Dir: filepath.Dir(path), Error: err.Error()}
+		}
+		each(check)
+		return nil
+	})
+	if err != nil {
+		return errors.Track(err).WithContext("path", root).AsFileSystem().Error()
+	}
+
+	if !found {
+		return errors.Newf("no downloaded chapters found in %s", root).
+			WithMessagef("No %s manifests or chapter archives below %s; only chapters downloaded by Luminary can be verified", download.ManifestFile, root).
+			AsNotFound().Error()
+	}
+	return nil
+}
+
+// VerifyLibrary verifies the latest download of every chapter in the library that still
+// exists on disk. Chapters without a manifest are checked by page count and, with repair,
+// downloaded again into their directory, which keeps the pages already there.
+func (e *Engine) VerifyLibrary(ctx context.Context, repair bool, each func(*ChapterCheck)) error {
+	if e.Library == nil {
+		return errors.New("library is not available").
+			WithMessage("Verifying the library requires a home directory with download records").Error()
+	}
+
+	records, err := e.Library.Records(library.Filter{})
+	if err != nil {
+		return err
+	}
+
+	latest := make(map[string]library.Record)
+	var order []string
+	for _, r := range records {
+		if r.Path == "" || (r.Status != library.StatusCompleted && r.Status != library.StatusPartial) {
+			continue
+		}
+		key := r.Provider + ":" + r.ChapterID
+		if _, ok := latest[key]; !ok {
+			order = append(order, key)
+		}
+		latest[key] = r
+	}
+
+	for _, key := range order {
+		if err := ctx.Err(); err != nil {
+			return errors.Track(err).WithMessage("Verification cancelled").Error()
+		}
+
+		r := latest[key]
+		if r.Storage != "" {
+			continue // Written to other storage, and only kept there
+		}
+		info, err := os.Stat(r.Path)
+		if err != nil {
+			continue
+		}
+		// Chapters packed into archives by a pipeline are checked against the manifest
+		// packed with them
+		if !info.IsDir() {
+			if !isArchive(r.Path) {
+				continue
+			}
+			check, err := e.VerifyArchive(ctx, r.Path, repair)
+			if err != nil {
+				check = &ChapterCheck{Dir: r.Path, Archive: true, Error: err.Error()}
+			}
+			if check.Provider == "" {
+				check.Provider, check.ChapterID = r.Provider, r.ChapterID
+			}
+			each(check)
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(r.Path, download.ManifestFile)); err == nil {
+			check, err := e.VerifyChapter(ctx, r.Path, repair)
+			if err != nil {
+				check = &ChapterCheck{Dir: r.Path, Provider: r.Provider, ChapterID: r.ChapterID, Error: err.Error()}
+			}
+			each(check)
+			continue
+		}
+
+		each(e.verifyRecord(ctx, r, repair))
+	}
+	return nil
+}
+
+// verifyRecord checks a chapter without a manifest against the page count of its record
+func (e *Engine) verifyRecord(ctx context.Context, r library.Record, repair bool) *ChapterCheck {
+	check := &ChapterCheck{
+		Dir:        r.Path,
+		Provider:   r.Provider,
+		ChapterID:  r.ChapterID,
+		Chapter:    r.Chapter,
+		Pages:      r.Pages + len(r.FailedPages),
+		Missing:    r.FailedPages,
+		NoManifest: true,
+	}
+
+	if found := countPageFiles(r.Path); found < r.Pages {
+		check.Missing = nil
+		for n := found + 1; n <= check.Pages; n++ {
+			check.Missing = append(check.Missing, n)
+		}
+	}
+	if !repair || check.OK() {
+		return check
+	}
+
+	provider, err := e.GetProvider(r.Provider)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	// Existing pages are kept, so only the missing ones are fetched; the new download
+	// also writes a manifest for next time
+	record, err := e.DownloadChapterRecord(ctx, provider, r.ChapterID, r.OutputDir)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	stillMissing := make(map[int]bool, len(record.FailedPages))
+	for _, n := range record.FailedPages {
+		stillMissing[n] = true
+	}
+	for _, n := range check.Missing {
+		if !stillMissing[n] {
+			check.Repaired = append(check.Repaired, n)
+		}
+	}
+	check.Missing = record.FailedPages
+	check.NoManifest = false
+	return check
+}
+
+// countPageFiles counts the files in a chapter directory, leaving out the manifest and
+// unfinished downloads
+func countPageFiles(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == download.ManifestFile || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		count++
+	}
+	return count
+}
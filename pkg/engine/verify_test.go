@@ -0,0 +1,132 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/engine/download"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestVerifyArchiveRepairs(t *testing.T) {
+	latest := 2
+	e := newUpdatesEngine(t, &latest)
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	sum := sha256.Sum256(png)
+	manifest := download.Manifest{
+		Provider:  "tst",
+		ChapterID: "ch2",
+		Chapter:   "2",
+		Pages:     []download.ManifestPage{{Index: 0, Filename: "001.png", Size: int64(len(png)), SHA256: hex.EncodeToString(sum[:])}},
+	}
+
+	path := filepath.Join(t.TempDir(), "Chapter_2.cbz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range map[string][]byte{"001.png": []byte("<html>"), download.ManifestFile: mustJSON(t, manifest)} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	check, err := e.VerifyArchive(context.Background(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Error != "" || !slices.Equal(check.Repaired, []int{1}) {
+		t.Fatalf("check = %+v, want page 1 repaired", check)
+	}
+
+	_, problems, err := download.CheckArchive(path)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("CheckArchive() after repair = %v, %v; want no problems", problems, err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".repair-*")); len(leftovers) > 0 {
+		t.Errorf("repair left %v behind", leftovers)
+	}
+}
+
+func TestVerifyArchiveRepairsDamagedEntries(t *testing.T) {
+	latest := 2
+	e := newUpdatesEngine(t, &latest)
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	sum := sha256.Sum256(png)
+	manifest := download.Manifest{
+		Provider:  "tst",
+		ChapterID: "ch2",
+		Chapter:   "2",
+		Pages:     []download.ManifestPage{{Index: 0, Filename: "001.png", Size: int64(len(png)), SHA256: hex.EncodeToString(sum[:])}},
+	}
+
+	// The page is stored as is, then damaged, so it fails the archive's checksum
+	path := filepath.Join(t.TempDir(), "Chapter_2.cbz")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range map[string][]byte{"001.png": png, download.ManifestFile: mustJSON(t, manifest)} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Replace(buf.Bytes(), png, []byte("\x89PNG\r\n\x1a\x00"), 1)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := e.VerifyArchive(context.Background(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Error != "" || !slices.Equal(check.Repaired, []int{1}) {
+		t.Fatalf("check = %+v, want page 1 repaired", check)
+	}
+	if _, problems, err := download.CheckArchive(path); err != nil || len(problems) != 0 {
+		t.Errorf("CheckArchive() after repair = %v, %v; want no problems", problems, err)
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,113 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"time"
+)
+
+// WarmResult is the outcome of warming the cache for one series
+type WarmResult struct {
+	Provider string        `json:"provider"`
+	MangaID  string        `json:"manga_id"`
+	Title    string        `json:"title,omitempty"`
+	Chapters int           `json:"chapters"`
+	Elapsed  time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// WarmCache fetches the details of every series in the library again and keeps them in
+// the cache and as snapshots, so interactive calls about them are answered right away.
+// each, if set, is called with the outcome of every series; a failed series doesn't stop
+// the others, running out of the request budget does. It fails if caching manga details is
+// disabled, as there would be nothing to keep.
+func (e *Engine) WarmCache(ctx context.Context, each func(WarmResult)) (int, error) {
+	if e.Library == nil {
+		return 0, errors.New("download history is not available").
+			WithMessage("Cache warming needs the download history in ~/.luminary to know which series you follow").
+			AsFileSystem().Error()
+	}
+
+	// Manga details are only cached and saved as snapshots for a TTL above zero
+	if e.CacheTTL() <= 0 {
+		return 0, errors.New("caching manga details is disabled").
+			WithMessage("Cache warming saves nothing while the cache TTL is 0; set cache.ttl in ~/.luminary/config.json").
+			Error()
+	}
+
+	series, err := e.Library.Series()
+	if err != nil {
+		return 0, err
+	}
+
+	e.Log(ctx).Info("Warming the cache for %d series", len(series))
+	ctx = cache.Refresh(ctx)
+
+	warmed := 0
+	for _, s := range series {
+		if err := ctx.Err(); err != nil {
+			return warmed, errors.Track(err).
+				WithMessagef("Cache warming cancelled after %d of %d series", warmed, len(series)).
+				Error()
+		}
+
+		result := WarmResult{Provider: s.Provider, MangaID: s.MangaID}
+		start := time.Now()
+
+		provider, err := e.GetProvider(s.Provider)
+		if err == nil {
+			var info *core.MangaInfo
+			if info, err = e.GetManga(ctx, provider, s.MangaID); err == nil {
+				result.Title = info.Title
+				result.Chapters = len(info.Chapters)
+				warmed++
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+			e.Log(ctx).Warn("Failed to warm %s:%s: %v", s.Provider, s.MangaID, err)
+		}
+		result.Elapsed = time.Since(start)
+
+		if each != nil {
+			each(result)
+		}
+		if budgetErr := network.RequestBudgetError(ctx); budgetErr != nil {
+			return warmed, budgetErr
+		}
+	}
+
+	return warmed, nil
+}
+
+// SavedManga returns the details of a manga saved by warming the cache, however old, without
+// going to the site; nil if there are none. Providers key their snapshots this way.
+func (e *Engine) SavedManga(providerID, mangaID string) *core.MangaInfo {
+	if e.Snapshots == nil {
+		return nil
+	}
+	var info *core.MangaInfo
+	if _, ok := e.Snapshots.Load(providerID+":manga:"+mangaID, &info, 0); !ok {
+		return nil
+	}
+	return info
+}
@@ -0,0 +1,38 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package engine_test
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"context"
+	"testing"
+)
+
+func TestWarmCacheNeedsCacheTTL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	config := e.CacheConfig()
+	config.TTL = 0
+	e.SetCacheConfig(config)
+
+	called := false
+	warmed, err := e.WarmCache(context.Background(), func(engine.WarmResult) { called = true })
+	if err == nil || warmed != 0 || called {
+		t.Errorf("got %d series warmed and %v, want an error and none warmed", warmed, err)
+	}
+}
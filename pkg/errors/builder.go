@@ -33,10 +33,10 @@ func Track(err error) *ErrorBuilder {
 		return nil
 	}
 
-	// If it's already a TrackedError, wrap it in a builder
+	// If it's already a TrackedError, wrap a copy in a builder since the error may be shared
 	var tracked *TrackedError
 	if As(err, &tracked) {
-		return &ErrorBuilder{err: tracked}
+		return &ErrorBuilder{err: tracked.clone()}
 	}
 
 	// Create new tracked error
@@ -46,7 +46,7 @@ func Track(err error) *ErrorBuilder {
 
 // New creates a new error with tracking
 func New(message string) *ErrorBuilder {
-	return Track(fmt.Errorf(message))
+	return Track(errors.New(message))
 }
 
 // Newf creates a new formatted error with tracking
@@ -82,6 +82,12 @@ func (b *ErrorBuilder) WithMessage(message string) *ErrorBuilder {
 		return b
 	}
 
+	// Challenge, unavailability and timeout messages explain the failure, keep them over
+	// generic wrappers
+	if b.err.sticky() && b.err.UserMessage != "" {
+		return b
+	}
+
 	b.err.UserMessage = message
 	return b
 }
@@ -110,6 +116,12 @@ func (b *ErrorBuilder) AsCategory(category ErrorCategory) *ErrorBuilder {
 		return b
 	}
 
+	// A detected challenge, unavailable chapter or timeout is more specific than any
+	// category added while wrapping
+	if b.err.sticky() {
+		return b
+	}
+
 	b.err.Category = category
 	return b
 }
@@ -163,6 +175,17 @@ func (b *ErrorBuilder) AsDownload() *ErrorBuilder {
 	return b.AsCategory(CategoryDownload)
 }
 
+// AsChallenge marks the error as a bot check (Cloudflare, CAPTCHA) blocking the request
+func (b *ErrorBuilder) AsChallenge() *ErrorBuilder {
+	return b.AsCategory(CategoryChallenge)
+}
+
+// AsUnavailable marks the error as a chapter the provider won't serve, for the reason
+// given: licensed, region-blocked, behind a login and so on
+func (b *ErrorBuilder) AsUnavailable(reason string) *ErrorBuilder {
+	return b.WithContext("reason", reason).AsCategory(CategoryUnavailable)
+}
+
 // AsPanic marks the error as panic-related
 func (b *ErrorBuilder) AsPanic() *ErrorBuilder {
 	return b.AsCategory(CategoryPanic)
@@ -308,6 +331,23 @@ func Chain(errs ...error) error {
 	}
 }
 
+// AddContext attaches a context entry to err, tracking it first if it isn't tracked yet
+func AddContext(err error, key string, value interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	// The error may be shared, so the entry goes on a copy
+	var tracked *TrackedError
+	if errors.As(err, &tracked) {
+		tracked = tracked.clone()
+		tracked.Context[key] = value
+		return tracked
+	}
+
+	return Track(err).WithContext(key, value).Error()
+}
+
 // FromContext creates an error from a context
 func FromContext(ctx context.Context) *ErrorBuilder {
 	if err := ctx.Err(); err != nil {
@@ -0,0 +1,74 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStickyCategoriesSurviveWrapping(t *testing.T) {
+	challenge := New("challenge from example.org").
+		WithMessage("example.org is protected by a Cloudflare check").
+		AsChallenge().
+		Error()
+	unavailable := New("licensed").WithMessage("Chapter 1 was removed").AsUnavailable("licensed").Error()
+	budget := New("budget").WithContext("budget", "request").WithMessage("Gave up").AsTimeout().Error()
+	timeout := New("deadline exceeded").WithMessage("The site didn't answer in time").AsTimeout().Error()
+
+	tests := []struct {
+		name     string
+		err      error
+		category ErrorCategory
+		message  string
+	}{
+		{"challenge", challenge, CategoryChallenge, "example.org is protected by a Cloudflare check"},
+		{"wrapped challenge", fmt.Errorf("search: %w", challenge), CategoryChallenge, "example.org is protected by a Cloudflare check"},
+		{"unavailable", unavailable, CategoryUnavailable, "Chapter 1 was removed"},
+		{"budget", budget, CategoryTimeout, "Gave up"},
+		{"timeout", timeout, CategoryTimeout, "The site didn't answer in time"},
+		{"network", New("connection refused").AsNetwork().Error(), CategoryProvider, "Search failed"},
+		{"plain", fmt.Errorf("boom"), CategoryProvider, "Search failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Track(tt.err).WithMessage("Search failed").AsProvider("tst").Error()
+			var tracked *TrackedError
+			if !As(err, &tracked) {
+				t.Fatalf("Track() = %v, not tracked", err)
+			}
+			if tracked.Category != tt.category {
+				t.Errorf("category = %s, want %s", tracked.Category, tt.category)
+			}
+			if tracked.UserMessage != tt.message {
+				t.Errorf("message = %q, want %q", tracked.UserMessage, tt.message)
+			}
+			if tracked.Context["provider_id"] != "tst" {
+				t.Errorf("provider_id = %v, want tst", tracked.Context["provider_id"])
+			}
+		})
+	}
+}
+
+func TestTPKeepsChallenges(t *testing.T) {
+	challenge := New("challenge").WithMessage("blocked").AsChallenge().Error()
+
+	var tracked *TrackedError
+	if !As(TP(challenge, "tst"), &tracked) || tracked.Category != CategoryChallenge {
+		t.Errorf("TP() recategorised the challenge: %+v", tracked)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"os"
 	"strings"
 
 	"github.com/fatih/color"
@@ -63,6 +64,8 @@ type CLIFormatter struct {
 	DownloadStyle   *color.Color
 	TimeoutStyle    *color.Color
 	PanicStyle      *color.Color
+	ChallengeStyle  *color.Color
+	UnavailStyle    *color.Color
 
 	// Text styles (matching CLI formatter)
 	HeaderStyle      *color.Color
@@ -116,6 +119,8 @@ func (f *CLIFormatter) initStyles() {
 	f.DownloadStyle = color.New(color.FgCyan)
 	f.TimeoutStyle = color.New(color.FgYellow)
 	f.PanicStyle = color.New(color.FgHiRed)
+	f.ChallengeStyle = color.New(color.FgHiYellow)
+	f.UnavailStyle = color.New(color.FgMagenta)
 
 	// Configure text styles to match CLI formatter
 	f.HeaderStyle = color.New(color.Bold, color.FgCyan)
@@ -148,6 +153,41 @@ func (f *CLIFormatter) loadSuggestions() {
 	}
 }
 
+// MergeSuggestions adds suggestion lists over the loaded ones; a list replaces the one
+// under the same key, and an empty list removes it
+func (f *CLIFormatter) MergeSuggestions(extra SuggestionsMap) {
+	for key, suggestions := range extra {
+		if len(suggestions) == 0 {
+			delete(f.Suggestions, key)
+			continue
+		}
+		f.Suggestions[key] = suggestions
+	}
+}
+
+// ReadSuggestions reads a JSON file of suggestions shaped like the embedded
+// suggestions.json, so operators can add their own troubleshooting steps with
+// MergeSuggestions. Keys "provider:<id>" and "provider:<id>:<category>" hold steps for
+// errors of one provider, which come before the general ones. A missing file gives none.
+func ReadSuggestions(path string) (SuggestionsMap, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	var extra SuggestionsMap
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, Track(err).
+			WithContext("path", path).
+			WithMessagef("%s is not valid JSON: %v", path, err).
+			AsParser().Error()
+	}
+	return extra, nil
+}
+
 // NewDebugCLIFormatter creates a CLI formatter with debug information enabled
 func NewDebugCLIFormatter() *CLIFormatter {
 	f := &CLIFormatter{
@@ -266,35 +306,8 @@ func (f *CLIFormatter) getCategoryGuidance(trackedErr *TrackedError) string {
 
 	// Get suggestions based on specific error patterns first
 	var suggestions []string
-
-	if trackedErr.Original != nil {
-		errStr := strings.ToLower(trackedErr.Original.Error())
-
-		// Check for specific network error patterns
-		if category == "network" {
-			switch {
-			case strings.Contains(errStr, "no such host"):
-				suggestions = f.getSuggestionsForKey("network_no_such_host")
-			case strings.Contains(errStr, "connection refused"):
-				suggestions = f.getSuggestionsForKey("network_connection_refused")
-			case strings.Contains(errStr, "timeout"):
-				suggestions = f.getSuggestionsForKey("network_timeout")
-			case strings.Contains(errStr, "tls") || strings.Contains(errStr, "certificate"):
-				suggestions = f.getSuggestionsForKey("network_tls")
-			}
-		}
-
-		// Check for specific filesystem error patterns
-		if category == "filesystem" {
-			switch {
-			case strings.Contains(errStr, "permission"):
-				suggestions = f.getSuggestionsForKey("file_system_permission")
-			case strings.Contains(errStr, "no space"):
-				suggestions = f.getSuggestionsForKey("file_system_no_space")
-			case strings.Contains(errStr, "no such file"):
-				suggestions = f.getSuggestionsForKey("file_system_no_such_file")
-			}
-		}
+	if code := Code(trackedErr); code != category {
+		suggestions = f.getSuggestionsForKey(code)
 	}
 
 	// If no specific suggestions were found, use the general category suggestions
@@ -302,6 +315,16 @@ func (f *CLIFormatter) getCategoryGuidance(trackedErr *TrackedError) string {
 		suggestions = f.getSuggestionsForKey(category)
 	}
 
+	// Steps for the site at hand go first
+	if providerID := f.extractProviderID(trackedErr); providerID != "" {
+		var specific []string
+		specific = append(specific, f.getSuggestionsForKey("provider:"+providerID+":"+category)...)
+		specific = append(specific, f.getSuggestionsForKey("provider:"+providerID)...)
+		if len(specific) > 0 {
+			suggestions = append(specific, suggestions...)
+		}
+	}
+
 	// If still no suggestions, return empty string
 	if len(suggestions) == 0 {
 		return ""
@@ -321,6 +344,14 @@ func (f *CLIFormatter) getCategoryGuidance(trackedErr *TrackedError) string {
 		}
 	}
 
+	// Name the blocked site for challenges
+	if category == "challenge" {
+		if domain, ok := GetContext(trackedErr)["domain"].(string); ok && domain != "" {
+			formattedSuggestions = append(formattedSuggestions, "")
+			formattedSuggestions = append(formattedSuggestions, fmt.Sprintf("Blocked domain: %s", f.DetailValueStyle.Sprint(domain)))
+		}
+	}
+
 	// Add provider ID for provider errors if available
 	if category == "provider" {
 		if providerID := f.extractProviderID(trackedErr); providerID != "" {
@@ -677,6 +708,10 @@ func (f *CLIFormatter) getCategoryPrefix(category ErrorCategory) string {
 		return "[TIMEOUT]"
 	case CategoryPanic:
 		return "[PANIC]"
+	case CategoryChallenge:
+		return "[CHALLENGE]"
+	case CategoryUnavailable:
+		return "[UNAVAILABLE]"
 	default:
 		return "[ERROR]"
 	}
@@ -705,6 +740,10 @@ func (f *CLIFormatter) getCategoryStyle(category ErrorCategory) *color.Color {
 		return f.TimeoutStyle
 	case CategoryPanic:
 		return f.PanicStyle
+	case CategoryChallenge:
+		return f.ChallengeStyle
+	case CategoryUnavailable:
+		return f.UnavailStyle
 	default:
 		return f.ErrorStyle
 	}
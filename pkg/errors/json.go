@@ -0,0 +1,164 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorJSON is the machine-readable form of an error, for wrappers that parse failures
+type ErrorJSON struct {
+	Category string `json:"category"`
+	// Code refines the category where the cause is known, e.g. "network_timeout" or
+	// "timeout_budget"; it is the category otherwise
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Error     string                 `json:"error,omitempty"` // The underlying error, when the message is a friendlier one
+	Context   map[string]interface{} `json:"context,omitempty"`
+	CallChain []CallJSON             `json:"call_chain,omitempty"`
+	Time      *time.Time             `json:"time,omitempty"`
+}
+
+// CallJSON is a function of the call chain of an ErrorJSON
+type CallJSON struct {
+	Function  string                 `json:"function"`
+	File      string                 `json:"file"`
+	Line      int                    `json:"line"`
+	Operation string                 `json:"operation,omitempty"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+}
+
+// ToJSON describes err for serialization; errors that aren't tracked get the unknown
+// category
+func ToJSON(err error) ErrorJSON {
+	var tracked *TrackedError
+	if !errors.As(err, &tracked) {
+		return ErrorJSON{Category: string(CategoryUnknown), Code: string(CategoryUnknown), Message: err.Error()}
+	}
+
+	out := ErrorJSON{
+		Category: string(tracked.Category),
+		Code:     Code(tracked),
+		Message:  tracked.Error(),
+		Context:  jsonContext(tracked.Context),
+	}
+	if tracked.UserMessage != "" && tracked.Original != nil && tracked.Original.Error() != tracked.UserMessage {
+		out.Error = tracked.Original.Error()
+	}
+	if !tracked.Timestamp.IsZero() {
+		out.Time = &tracked.Timestamp
+	}
+	for _, call := range tracked.CallChain {
+		out.CallChain = append(out.CallChain, CallJSON{
+			Function:  call.Function,
+			File:      call.File,
+			Line:      call.Line,
+			Operation: call.Operation,
+			Context:   jsonContext(call.Context),
+		})
+	}
+	return out
+}
+
+// FormatJSON writes err as a single line of JSON
+func FormatJSON(err error) string {
+	if err == nil {
+		return ""
+	}
+	data, marshalErr := json.Marshal(ToJSON(err))
+	if marshalErr != nil {
+		return fmt.Sprintf(`{"category":"unknown","code":"unknown","message":%q}`, err.Error())
+	}
+	return string(data)
+}
+
+// Code returns the most specific name for the cause of err: a known cause within its
+// category, such as "network_no_such_host", or else the category
+func Code(err error) string {
+	var tracked *TrackedError
+	if !errors.As(err, &tracked) {
+		return string(CategoryUnknown)
+	}
+
+	category := strings.ToLower(string(tracked.Category))
+	if tracked.Original == nil {
+		return category
+	}
+	errStr := strings.ToLower(tracked.Original.Error())
+
+	switch category {
+	case "network":
+		switch {
+		case strings.Contains(errStr, "no such host"):
+			return "network_no_such_host"
+		case strings.Contains(errStr, "connection refused"):
+			return "network_connection_refused"
+		case strings.Contains(errStr, "timeout"):
+			return "network_timeout"
+		case strings.Contains(errStr, "tls") || strings.Contains(errStr, "certificate"):
+			return "network_tls"
+		}
+	case "unavailable":
+		// The reason decides what can be done about it
+		if reason, ok := tracked.Context["reason"].(string); ok && reason != "" {
+			return "unavailable_" + reason
+		}
+	case "timeout":
+		// Budgets are user settings, so they get their own code
+		if tracked.Context["budget"] != nil {
+			return "timeout_budget"
+		}
+	case "filesystem":
+		switch {
+		case strings.Contains(errStr, "permission"):
+			return "file_system_permission"
+		case strings.Contains(errStr, "no space"):
+			return "file_system_no_space"
+		case strings.Contains(errStr, "no such file"):
+			return "file_system_no_such_file"
+		}
+	}
+	return category
+}
+
+// jsonContext returns context values that can be serialized, writing others as text
+func jsonContext(context map[string]interface{}) map[string]interface{} {
+	if len(context) == 0 {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(context))
+	for key, value := range context {
+		switch v := value.(type) {
+		case error:
+			out[key] = v.Error()
+		case fmt.Stringer:
+			out[key] = v.String()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				out[key] = fmt.Sprint(v)
+			} else {
+				out[key] = v
+			}
+		}
+	}
+	return out
+}
@@ -19,7 +19,9 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 )
@@ -39,6 +41,10 @@ const (
 	CategoryFileSystem ErrorCategory = "filesystem"
 	CategoryDownload   ErrorCategory = "download"
 	CategoryPanic      ErrorCategory = "panic"
+	CategoryChallenge  ErrorCategory = "challenge"
+	// CategoryUnavailable is a chapter the provider won't serve: licensed, region-blocked
+	// or behind a login; the "reason" context says which
+	CategoryUnavailable ErrorCategory = "unavailable"
 )
 
 // TrackedError wraps an error with additional context
@@ -51,6 +57,9 @@ type TrackedError struct {
 	Context     map[string]interface{}
 	StackTrace  []StackFrame
 	Timestamp   time.Time
+
+	// source is the error this one was copied from
+	source *TrackedError
 }
 
 // FunctionCall represents a function in the call chain
@@ -90,6 +99,9 @@ func (e *TrackedError) Unwrap() error {
 
 // Is implements errors.Is support
 func (e *TrackedError) Is(target error) bool {
+	if e.source != nil && (target == error(e.source) || e.source.Is(target)) {
+		return true
+	}
 	return errors.Is(e.Original, target) || errors.Is(e.RootCause, target)
 }
 
@@ -99,7 +111,21 @@ func (e *TrackedError) As(target interface{}) bool {
 		*t = e
 		return true
 	}
-	return errors.As(e.Original, &target)
+	// errors.As continues with the wrapped error through Unwrap
+	return false
+}
+
+// clone returns a copy of the error whose context and call chain can be changed without
+// affecting the error, which may be shared. The copy still matches it with errors.Is.
+func (e *TrackedError) clone() *TrackedError {
+	c := *e
+	c.Context = maps.Clone(e.Context)
+	if c.Context == nil {
+		c.Context = make(map[string]interface{})
+	}
+	c.CallChain = slices.Clone(e.CallChain)
+	c.source = e
+	return &c
 }
 
 // GetContext returns the error context
@@ -107,6 +133,13 @@ func (e *TrackedError) GetContext() map[string]interface{} {
 	return e.Context
 }
 
+// sticky reports whether the error's category and message explain the failure better than
+// anything added while wrapping it: a bot challenge, an unavailable chapter, or a timeout
+// such as a time budget running out
+func (e *TrackedError) sticky() bool {
+	return e.Category == CategoryChallenge || e.Category == CategoryUnavailable || e.Category == CategoryTimeout
+}
+
 // GetCategory returns the error category
 func (e *TrackedError) GetCategory() string {
 	return string(e.Category)
@@ -147,9 +180,11 @@ func trackError(err error, context ...map[string]interface{}) *TrackedError {
 		return nil
 	}
 
-	// If already tracked, return as is
+	// If already tracked, return a copy since the error may be shared
 	var tracked *TrackedError
 	if As(err, &tracked) {
+		tracked = tracked.clone()
+
 		// Add new function to call chain
 		pc, file, line, _ := runtime.Caller(2)
 		fn := runtime.FuncForPC(pc)
@@ -247,6 +282,33 @@ func extractPackageName(fullName string) string {
 	return strings.Join(packageParts, ".")
 }
 
+// moduleRoot is the directory Luminary was built in, found from the path of this file
+var moduleRoot = func() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok || !strings.HasSuffix(file, "pkg/errors/tracker.go") {
+		return ""
+	}
+	return strings.TrimSuffix(file, "pkg/errors/tracker.go")
+}()
+
+// sourcePath returns the path of a source file relative to its module, or to the source
+// directory of the standard library, so stack traces don't reveal where Luminary was built
+func sourcePath(file string) string {
+	if moduleRoot != "" && strings.HasPrefix(file, moduleRoot) {
+		return strings.TrimPrefix(file, moduleRoot)
+	}
+	if i := strings.LastIndex(file, "/pkg/mod/"); i >= 0 {
+		return file[i+len("/pkg/mod/"):]
+	}
+	if i := strings.LastIndex(file, "/src/"); i >= 0 {
+		return file[i+len("/src/"):]
+	}
+	if strings.HasPrefix(file, "/") || strings.Contains(file, ":") {
+		return extractFileName(file)
+	}
+	return file
+}
+
 func extractFileName(path string) string {
 	idx := strings.LastIndex(path, "/")
 	if idx == -1 {
@@ -359,6 +421,31 @@ func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
 
+// IsNotFound reports whether err says a resource doesn't exist: a not-found error, or
+// an HTTP 404 from any layer below
+func IsNotFound(err error) bool {
+	var tracked *TrackedError
+	if !As(err, &tracked) || tracked == nil {
+		return false
+	}
+	if tracked.Category == CategoryNotFound {
+		return true
+	}
+	status, _ := tracked.Context["status_code"].(int)
+	return status == 404
+}
+
+// IsForbidden reports whether err is an HTTP 401 or 403 from any layer below, a site
+// refusing to serve something rather than failing to
+func IsForbidden(err error) bool {
+	var tracked *TrackedError
+	if !As(err, &tracked) || tracked == nil {
+		return false
+	}
+	status, _ := tracked.Context["status_code"].(int)
+	return status == 401 || status == 403
+}
+
 // As finds the first error in err's chain that matches target
 func As(err error, target interface{}) bool {
 	if err == nil {
@@ -441,5 +528,61 @@ func Join(errs ...error) error {
 
 	te.Category = maxCategory
 
+	// A challenge, unavailable chapter or exceeded budget behind any of the errors explains
+	// them all
+	for _, err := range nonNil {
+		var tracked *TrackedError
+		if As(err, &tracked) && tracked != nil && tracked.sticky() {
+			te.Category = tracked.Category
+			te.UserMessage = tracked.UserMessage
+			if budget, ok := tracked.Context["budget"]; ok {
+				te.Context["budget"] = budget
+			}
+			if reason, ok := tracked.Context["reason"]; ok {
+				te.Context["reason"] = reason
+			}
+			break
+		}
+	}
+
+	return te
+}
+
+// Recovered turns a value returned by recover() into a panic error whose stack trace
+// starts where the panic was raised; call it from the deferred function that recovered
+func Recovered(value interface{}) error {
+	err, ok := value.(error)
+	if !ok {
+		err = fmt.Errorf("%v", value)
+	}
+
+	te := trackError(fmt.Errorf("panic: %w", err))
+	te.Category = CategoryPanic
+	te.StackTrace = panicStackTrace()
 	return te
 }
+
+// panicStackTrace captures the stack of a panicking goroutine, leaving out the runtime
+// frames that raised the panic. Files are named relative to their module.
+func panicStackTrace() []StackFrame {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	var stack []StackFrame
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" || frame.Function == "panic" {
+			stack = stack[:0] // The frames before the panic belong to the recovering code
+		} else {
+			stack = append(stack, StackFrame{
+				Function: frame.Function,
+				File:     sourcePath(frame.File),
+				Line:     frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
@@ -0,0 +1,70 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSourcePath(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"module", moduleRoot + "pkg/engine/engine.go", "pkg/engine/engine.go"},
+		{"main package", moduleRoot + "cmd/luminary/main.go", "cmd/luminary/main.go"},
+		{"dependency", "/home/user/go/pkg/mod/github.com/urfave/cli/v3@v3.3.8/command.go", "github.com/urfave/cli/v3@v3.3.8/command.go"},
+		{"standard library", "/usr/local/go/src/runtime/panic.go", "runtime/panic.go"},
+		{"windows", "C:/Program Files/Go/src/net/http/server.go", "net/http/server.go"},
+		{"unknown directory", "/opt/build/generated.go", "generated.go"},
+		{"trimmed", "Luminary/pkg/engine/engine.go", "Luminary/pkg/engine/engine.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourcePath(tt.file); got != tt.want {
+				t.Errorf("sourcePath(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoveredStackIsModuleRelative(t *testing.T) {
+	if moduleRoot == "" {
+		t.Skip("module root unknown")
+	}
+
+	var err error
+	func() {
+		defer func() { err = Recovered(recover()) }()
+		panic("boom")
+	}()
+
+	var tracked *TrackedError
+	if !As(err, &tracked) || len(tracked.StackTrace) == 0 {
+		t.Fatalf("Recovered() = %v without a stack trace", err)
+	}
+	if top := tracked.StackTrace[0]; top.File != "pkg/errors/tracker_test.go" {
+		t.Errorf("top frame in %q, want pkg/errors/tracker_test.go", top.File)
+	}
+	for _, frame := range tracked.StackTrace {
+		if strings.HasPrefix(frame.File, "/") {
+			t.Errorf("frame %s has the absolute path %s", frame.Function, frame.File)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package luminary embeds Luminary in other Go programs: searching every provider,
+// looking up manga and downloading chapters without going through the CLI. Its types are
+// meant to stay stable; the engine packages below it may change between releases.
+//
+//	client, err := luminary.New(ctx, luminary.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	results, err := client.Search(ctx, "one piece", luminary.SearchOptions{Limit: 5})
+package luminary
+
+import (
+	_ "Luminary/internal/providers" // Import for side effects (auto-registration)
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/logger"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/registry"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options configures a Client
+type Options struct {
+	// Version is reported in crash reports, when the user enabled them in the config
+	Version string
+	// HTTPClient sends the requests instead of Luminary's own client
+	HTTPClient *http.Client
+	// CacheDir holds the manga details kept between runs instead of ~/.luminary/cache
+	CacheDir string
+	// RateLimits is the minimum delay between requests per host, taking precedence over
+	// the delays of the providers
+	RateLimits map[string]time.Duration
+	// Logger receives the log messages instead of ~/.luminary/logs/luminary.log; its
+	// handler decides which levels are kept. slog.New(slog.DiscardHandler) turns logging off.
+	Logger *slog.Logger
+}
+
+// Client searches and downloads manga through every provider Luminary supports. It is
+// safe for concurrent use.
+type Client struct {
+	engine *engine.Engine
+}
+
+// New creates a client with every provider loaded and initialized. Settings come from
+// ~/.luminary/config.json like for the CLI and stay with the client, so clients created
+// at different times don't share them; providers failing to initialize are logged and
+// stay usable for what doesn't need the initialization.
+func New(ctx context.Context, options Options) (*Client, error) {
+	var opts []engine.Option
+	if options.HTTPClient != nil {
+		opts = append(opts, engine.WithHTTPClient(options.HTTPClient))
+	}
+	if options.CacheDir != "" {
+		opts = append(opts, engine.WithCacheDir(options.CacheDir))
+	}
+	if options.RateLimits != nil {
+		opts = append(opts, engine.WithRateLimits(options.RateLimits))
+	}
+	if options.Logger != nil {
+		opts = append(opts, engine.WithLogger(slogLogger{options.Logger}))
+	}
+
+	eng := engine.New(opts...)
+	eng.SetVersion(options.Version)
+	if err := registry.LoadAll(eng); err != nil {
+		_ = eng.Shutdown()
+		return nil, err
+	}
+	if err := eng.InitializeProviders(ctx); err != nil {
+		eng.Logger.Warn("Some providers failed to initialize: %v", err)
+	}
+
+	return &Client{engine: eng}, nil
+}
+
+// slogLogger passes the messages of the engine on to a slog.Logger
+type slogLogger struct {
+	log *slog.Logger
+}
+
+func (l slogLogger) Debug(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Info(format string, args ...interface{}) {
+	l.log.Info(fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Warn(format string, args ...interface{}) {
+	l.log.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l slogLogger) Error(format string, args ...interface{}) {
+	l.log.Error(fmt.Sprintf(format, args...))
+}
+
+// SetLevel does nothing; the handler of the slog.Logger filters by level
+func (slogLogger) SetLevel(logger.Level) {}
+
+// Close releases the resources of the client; it must not be used afterwards
+func (c *Client) Close() error {
+	return c.engine.Shutdown()
+}
+
+// Providers returns the providers available, highest priority first
+func (c *Client) Providers() []Provider {
+	all := c.engine.AllProviders()
+	providers := make([]Provider, 0, len(all))
+	for _, p := range all {
+		providers = append(providers, Provider{
+			ID:          p.ID(),
+			Name:        p.Name(),
+			Description: p.Description(),
+			SiteURL:     p.SiteURL(),
+		})
+	}
+	return providers
+}
+
+// Search searches the providers concurrently within the search time budget of the config
+func (c *Client) Search(ctx context.Context, query string, options SearchOptions) (*SearchResults, error) {
+	providers, err := c.engine.SelectProviders(options.Providers)
+	if err != nil {
+		return nil, err
+	}
+
+	searchOptions := core.SearchOptions{
+		Query:            query,
+		Limit:            options.Limit,
+		Pages:            options.Pages,
+		Sort:             options.Sort,
+		IncludeAltTitles: options.AltTitles,
+		Filters:          options.Filters,
+	}
+
+	multi := c.engine.SearchAcrossProviders(ctx, providers, query, searchOptions, nil)
+	if multi.AllFailed() {
+		return nil, errors.Track(multi.FirstError()).
+			WithContext("query", query).
+			WithMessagef("Search failed on all %d providers", len(providers)).
+			Error()
+	}
+
+	// Results are kept in provider order
+	results := &SearchResults{Manga: []Manga{}, Errors: multi.Errors()}
+	for _, success := range multi.Successes() {
+		for _, manga := range success.Value {
+			results.Manga = append(results.Manga, newManga(success.Provider, manga))
+		}
+	}
+	return results, nil
+}
+
+// GetManga returns the details and chapters of a manga, given as a combined ID like
+// "mgd:a1c7c817-..." or as the URL of the manga on its provider's site
+func (c *Client) GetManga(ctx context.Context, ref string) (*MangaDetails, error) {
+	provider, id, err := c.resolveManga(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.engine.GetManga(ctx, provider, id)
+	if err != nil {
+		return nil, err
+	}
+	return newMangaDetails(provider.ID(), info), nil
+}
+
+// Download downloads a chapter, given as a combined ID like "mgd:7b1b5c3d-..." or as the
+// URL of the chapter on its provider's site, and records it in the download history.
+// The result is returned also when the download fails; a chapter the provider won't serve
+// (external, licensed, region-blocked or locked) is skipped with StatusSkipped and an error.
+func (c *Client) Download(ctx context.Context, ref string, options DownloadOptions) (*DownloadResult, error) {
+	provider, id, err := c.engine.ResolveChapter(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Layout != "" {
+		layout, err := download.ParseLayout(options.Layout)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithLayout(ctx, layout)
+	}
+	if options.Storage != "" {
+		storage, err := download.ParseStorage(options.Storage)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithStorage(ctx, storage)
+	}
+	if options.Process != "" {
+		pipeline, err := download.ParsePipeline(options.Process)
+		if err != nil {
+			return nil, err
+		}
+		ctx = download.WithPipeline(ctx, pipeline)
+	}
+	if options.Audit {
+		ctx = engine.WithAudit(ctx)
+	}
+
+	outputDir := options.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+
+	record, err := c.engine.DownloadChapterRecord(ctx, provider, id, outputDir)
+	return newDownloadResult(record), err
+}
+
+// resolveManga turns a manga reference into a provider and manga ID
+func (c *Client) resolveManga(ref string) (engine.Provider, string, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		resolved, err := c.engine.ResolveURL(ref)
+		if err != nil {
+			return nil, "", err
+		}
+		if resolved.Kind != engine.URLKindManga {
+			return nil, "", errors.Newf("%s is not a manga URL", ref).
+				WithMessage("The URL points to a chapter; pass the URL of the manga instead").
+				Error()
+		}
+		return resolved.Provider, resolved.ID, nil
+	}
+
+	providerID, id, ok := strings.Cut(ref, ":")
+	if !ok || id == "" {
+		return nil, "", errors.Newf("invalid manga ID format: %s", ref).
+			WithMessage("Manga IDs must look like provider:manga-id or be a manga URL").
+			Error()
+	}
+
+	provider, err := c.engine.GetProvider(providerID)
+	if err != nil {
+		return nil, "", err
+	}
+	return provider, id, nil
+}
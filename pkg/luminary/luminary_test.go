@@ -0,0 +1,138 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package luminary
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/provider/base"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestClient returns a client with its own home directory holding config, and a "tst"
+// provider serving one manga with one chapter of one page
+func newTestClient(t *testing.T, config string) *Client {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".luminary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".luminary", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	t.Cleanup(images.Close)
+
+	client, err := New(context.Background(), Options{Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	chapter := core.ChapterInfo{ID: "ch1", Number: 1, Language: "en"}
+	provider := base.New(client.engine, base.Config{ID: "tst", Name: "Test", SiteURL: "https://example.com", Type: base.TypeWeb}).
+		WithSearch(func(context.Context, string, core.SearchOptions) ([]core.Manga, error) {
+			return []core.Manga{{ID: "blue-lock", Title: "Blue Lock"}}, nil
+		}).
+		WithGetManga(func(_ context.Context, id string) (*core.MangaInfo, error) {
+			return &core.MangaInfo{Manga: core.Manga{ID: id, Title: "Blue Lock"}, Chapters: []core.ChapterInfo{chapter}}, nil
+		}).
+		WithGetChapter(func(_ context.Context, id string) (*core.Chapter, error) {
+			return &core.Chapter{Info: chapter, MangaID: "blue-lock", Pages: []core.Page{{Index: 0, URL: images.URL + "/1.png"}}}, nil
+		}).
+		Build()
+	if err := client.engine.RegisterProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestClientSearchGetMangaAndDownload(t *testing.T) {
+	client := newTestClient(t, `{"lazy_init": true}`)
+	ctx := context.Background()
+
+	if !slices.ContainsFunc(client.Providers(), func(p Provider) bool { return p.ID == "tst" }) {
+		t.Fatal("the test provider is not listed")
+	}
+
+	results, err := client.Search(ctx, "blue lock", SearchOptions{Providers: []string{"tst"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Manga) != 1 || results.Manga[0].ID != "tst:blue-lock" || results.Manga[0].Provider != "tst" {
+		t.Fatalf("got %+v, want tst:blue-lock", results.Manga)
+	}
+
+	details, err := client.GetManga(ctx, results.Manga[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.Chapters) != 1 || details.Chapters[0].ID != "tst:ch1" || details.Chapters[0].Label != "1" {
+		t.Fatalf("got chapters %+v, want tst:ch1", details.Chapters)
+	}
+
+	result, err := client.Download(ctx, details.Chapters[0].ID, DownloadOptions{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != StatusCompleted || result.Pages != 1 || result.ChapterID != "tst:ch1" || result.MangaID != "tst:blue-lock" {
+		t.Errorf("got %+v, want a completed download of tst:ch1", result)
+	}
+	if _, err := os.Stat(result.Path); err != nil {
+		t.Errorf("downloaded chapter: %v", err)
+	}
+}
+
+func TestClientRejectsInvalidReferences(t *testing.T) {
+	client := newTestClient(t, `{"lazy_init": true}`)
+	ctx := context.Background()
+
+	for _, ref := range []string{"blue-lock", "tst:", "missing:blue-lock"} {
+		if _, err := client.GetManga(ctx, ref); err == nil {
+			t.Errorf("GetManga(%q) succeeded, want an error", ref)
+		}
+	}
+	if _, err := client.Search(ctx, "blue lock", SearchOptions{Providers: []string{"missing"}}); err == nil {
+		t.Error("searching an unknown provider succeeded, want an error")
+	}
+	if _, err := client.Download(ctx, "tst:ch1", DownloadOptions{Layout: "sideways"}); err == nil {
+		t.Error("downloading with an unknown layout succeeded, want an error")
+	}
+}
+
+func TestClientsKeepTheirOwnSettings(t *testing.T) {
+	japanese := newTestClient(t, `{"lazy_init": true, "preferred_languages": ["ja"]}`)
+	english := newTestClient(t, `{"lazy_init": true, "preferred_languages": ["en"]}`)
+
+	if got := japanese.engine.PreferredLanguages(); !slices.Equal(got, []string{"ja"}) {
+		t.Errorf("first client prefers %v after the second was created, want [ja]", got)
+	}
+	if got := english.engine.PreferredLanguages(); !slices.Equal(got, []string{"en"}) {
+		t.Errorf("second client prefers %v, want [en]", got)
+	}
+}
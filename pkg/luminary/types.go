@@ -0,0 +1,203 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package luminary
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/library"
+	"time"
+)
+
+// Provider is a manga source
+type Provider struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SiteURL     string `json:"site_url"`
+}
+
+// Manga is a search result or the summary of a manga. ID combines the provider and the
+// manga ID ("mgd:a1c7c817-..."), as the CLI shows it and GetManga accepts it.
+type Manga struct {
+	ID                string   `json:"id"`
+	Provider          string   `json:"provider"`
+	Title             string   `json:"title"`
+	AlternativeTitles []string `json:"alt_titles,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	Authors           []string `json:"authors,omitempty"`
+	Artists           []string `json:"artists,omitempty"`
+	Status            string   `json:"status,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	CoverURL          string   `json:"cover_url,omitempty"`
+	Demographic       string   `json:"demographic,omitempty"`
+	ContentRating     string   `json:"content_rating,omitempty"`
+}
+
+// MangaDetails is a manga with its chapters
+type MangaDetails struct {
+	Manga
+	Chapters           []Chapter  `json:"chapters"`
+	LastUpdated        *time.Time `json:"last_updated,omitempty"`
+	AvailableLanguages []string   `json:"available_languages,omitempty"`
+	// ExternalIDs maps tracker sites ("anilist", "mal", "mangaupdates") to the manga's ID there
+	ExternalIDs map[string]string `json:"external_ids,omitempty"`
+}
+
+// Chapter is a chapter of a manga. ID combines the provider and the chapter ID, as
+// Download accepts it.
+type Chapter struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Number   float64    `json:"number"`
+	Label    string     `json:"label"` // The number as the site shows it ("10.5", "Extra")
+	Volume   string     `json:"volume,omitempty"`
+	Language string     `json:"language,omitempty"`
+	Group    string     `json:"group,omitempty"`
+	Date     *time.Time `json:"date,omitempty"`
+	Pages    int        `json:"pages,omitempty"`
+	// ExternalURL is set when the chapter can only be read on an external (official) site,
+	// Download skips it then
+	ExternalURL string `json:"external_url,omitempty"`
+}
+
+// SearchOptions configures Search
+type SearchOptions struct {
+	// Providers limits the search to these provider IDs; all providers when empty
+	Providers []string
+	// Limit is the maximum number of results per provider; the provider default when 0
+	Limit int
+	// Pages is the number of result pages fetched per provider; the provider default when 0
+	Pages int
+	// Sort orders the results, e.g. "relevance", "popularity" or "latest"
+	Sort string
+	// AltTitles also matches alternative titles, e.g. the romaji or original title
+	AltTitles bool
+	// Filters narrows results by field, e.g. {"demographic": "seinen"}
+	Filters map[string]string
+}
+
+// SearchResults is what a search found. A search only fails when no provider answered;
+// the others' results are kept when some fail.
+type SearchResults struct {
+	Manga []Manga `json:"manga"`
+	// Errors holds the error of each provider that failed, by provider ID
+	Errors map[string]error `json:"-"`
+}
+
+// DownloadOptions configures Download
+type DownloadOptions struct {
+	// OutputDir is the directory chapters are saved in; the working directory when empty
+	OutputDir string
+	// Layout is "flat" (the default) or "language" to keep languages in subdirectories
+	Layout string
+	// Storage writes the chapters elsewhere than the local disk, e.g. "s3://bucket/prefix"
+	Storage string
+	// Process is a pipeline of page processing steps, as the CLI's --process takes it
+	Process string
+	// Audit records the HTTP requests of the download in a file, see DownloadResult.AuditFile
+	Audit bool
+}
+
+// Outcomes of a download
+const (
+	StatusCompleted = string(library.StatusCompleted)
+	StatusPartial   = string(library.StatusPartial) // Downloaded with some pages missing
+	StatusFailed    = string(library.StatusFailed)
+	StatusSkipped   = string(library.StatusSkipped) // External, licensed, region-blocked or locked
+)
+
+// DownloadResult describes the download of a chapter
+type DownloadResult struct {
+	ChapterID string `json:"chapter_id"`
+	MangaID   string `json:"manga_id,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Chapter   string `json:"chapter,omitempty"` // The number or label of the chapter
+	Language  string `json:"language,omitempty"`
+	Status    string `json:"status"`
+	Path      string `json:"path,omitempty"`
+	Pages     int    `json:"pages"`
+	Bytes     int64  `json:"bytes"`
+	// FailedPages are the 1-based numbers of pages missing from a partial download
+	FailedPages []int  `json:"failed_pages,omitempty"`
+	Error       string `json:"error,omitempty"`
+	AuditFile   string `json:"audit_file,omitempty"`
+}
+
+// newManga converts a provider's manga to the public type
+func newManga(providerID string, m core.Manga) Manga {
+	return Manga{
+		ID:                providerID + ":" + m.ID,
+		Provider:          providerID,
+		Title:             m.Title,
+		AlternativeTitles: m.AlternativeTitles,
+		Description:       m.Description,
+		Authors:           m.Authors,
+		Artists:           m.Artists,
+		Status:            m.Status,
+		Tags:              m.Tags,
+		CoverURL:          m.CoverURL,
+		Demographic:       m.Demographic,
+		ContentRating:     m.ContentRating,
+	}
+}
+
+// newMangaDetails converts a provider's manga details to the public type
+func newMangaDetails(providerID string, info *core.MangaInfo) *MangaDetails {
+	details := &MangaDetails{
+		Manga:              newManga(providerID, info.Manga),
+		Chapters:           make([]Chapter, 0, len(info.Chapters)),
+		LastUpdated:        info.LastUpdated,
+		AvailableLanguages: info.AvailableLanguages,
+		ExternalIDs:        info.ExternalIDs,
+	}
+	for _, ch := range info.Chapters {
+		details.Chapters = append(details.Chapters, Chapter{
+			ID:          providerID + ":" + ch.ID,
+			Title:       ch.Title,
+			Number:      ch.Number,
+			Label:       ch.DisplayNumber(),
+			Volume:      ch.Volume,
+			Language:    ch.Language,
+			Group:       ch.Group,
+			Date:        ch.Date,
+			Pages:       ch.PageCount,
+			ExternalURL: ch.ExternalURL,
+		})
+	}
+	return details
+}
+
+// newDownloadResult converts a download record to the public type
+func newDownloadResult(record library.Record) *DownloadResult {
+	result := &DownloadResult{
+		ChapterID:   record.Provider + ":" + record.ChapterID,
+		Title:       record.Title,
+		Chapter:     record.Chapter,
+		Language:    record.Language,
+		Status:      string(record.Status),
+		Path:        record.Path,
+		Pages:       record.Pages,
+		Bytes:       record.Bytes,
+		FailedPages: record.FailedPages,
+		Error:       record.Error,
+		AuditFile:   record.Audit,
+	}
+	if record.MangaID != "" {
+		result.MangaID = record.Provider + ":" + record.MangaID
+	}
+	return result
+}
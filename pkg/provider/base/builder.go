@@ -19,7 +19,9 @@ package base
 import (
 	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/parser/protowire"
 	"context"
+	"net/url"
 )
 
 // Builder provides fluent configuration for providers
@@ -80,6 +82,42 @@ func (b *Builder) WithGetChapterPages(fn func(context.Context, string) ([]string
 	return b
 }
 
+// WithGetChapterPageCount sets a custom page count function
+func (b *Builder) WithGetChapterPageCount(fn func(context.Context, string) (int, error)) *Builder {
+	b.provider.ops.GetChapterPageCount = fn
+	return b
+}
+
+// WithResolveChapterURL sets a custom chapter URL resolver
+func (b *Builder) WithResolveChapterURL(fn func(*url.URL) (string, error)) *Builder {
+	b.provider.ops.ResolveChapterURL = fn
+	return b
+}
+
+// WithResolveMangaURL sets a custom manga URL resolver
+func (b *Builder) WithResolveMangaURL(fn func(*url.URL) (string, error)) *Builder {
+	b.provider.ops.ResolveMangaURL = fn
+	return b
+}
+
+// WithURLFor sets a custom builder of manga and chapter URLs
+func (b *Builder) WithURLFor(fn func(kind, id string) (string, error)) *Builder {
+	b.provider.ops.URLFor = fn
+	return b
+}
+
+// WithGetTags sets a custom tag listing function
+func (b *Builder) WithGetTags(fn func(context.Context) ([]core.Tag, error)) *Builder {
+	b.provider.ops.GetTags = fn
+	return b
+}
+
+// WithBrowseTag sets a custom function listing the manga of a tag
+func (b *Builder) WithBrowseTag(fn func(context.Context, string, core.SearchOptions) ([]core.Manga, error)) *Builder {
+	b.provider.ops.BrowseTag = fn
+	return b
+}
+
 // WithDownloadChapter sets a custom download function
 func (b *Builder) WithDownloadChapter(fn func(context.Context, string, string) error) *Builder {
 	b.provider.ops.DownloadChapter = fn
@@ -103,6 +141,8 @@ func NewAPIConfig(baseURL string) *APIBuilder {
 			BaseURL:         baseURL,
 			Endpoints:       make(map[string]string),
 			ResponseMapping: make(map[string]ResponseMap),
+			Pagination:      make(map[string]PaginationConfig),
+			ProtoMessages:   make(map[string]func() protowire.Message),
 		},
 	}
 }
@@ -119,6 +159,18 @@ func (b *APIBuilder) WithResponseMapping(endpoint string, mapping ResponseMap) *
 	return b
 }
 
+// WithPagination configures how an endpoint is paged
+func (b *APIBuilder) WithPagination(endpoint string, pagination PaginationConfig) *APIBuilder {
+	b.config.Pagination[endpoint] = pagination
+	return b
+}
+
+// WithProtoMessage registers the message type of an endpoint answering with protocol buffers
+func (b *APIBuilder) WithProtoMessage(endpoint string, message func() protowire.Message) *APIBuilder {
+	b.config.ProtoMessages[endpoint] = message
+	return b
+}
+
 // Build returns the API configuration
 func (b *APIBuilder) Build() *APIConfig {
 	return &b.config
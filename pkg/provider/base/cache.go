@@ -0,0 +1,116 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/engine/cache"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/errors"
+	"context"
+	"time"
+)
+
+// cachedLookup runs a lookup through the engine's cache. Results are kept for ttl, or
+// not at all if it is zero; resources the site reports missing are kept for the
+// provider's NotFoundTTL and fail right away until then. Contexts marked by the cache
+// package may also read results from, or write them to, the warmed snapshots. Offline,
+// only the cache and snapshots of any age answer.
+func cachedLookup[T any](ctx context.Context, p *Provider, kind, id string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	var zero T
+	c := p.Engine.Cache
+	if c == nil {
+		return fetch()
+	}
+
+	key := p.ID() + ":" + kind + ":" + id
+	snapshots := p.Engine.Snapshots
+	refresh := cache.Refreshing(ctx)
+
+	if p.Engine.Offline() {
+		return offlineLookup[T](p, kind, id, key, ttl)
+	}
+
+	if !refresh {
+		if value, ok := c.Get(key); ok {
+			switch value := value.(type) {
+			case miss:
+				p.Engine.Logger.Debug("Skipping %s %s of %s, recently not found", kind, id, p.ID())
+				network.RecordCacheHit(ctx, p.ID())
+				return zero, errors.New(value.message).
+					WithContext("provider", p.ID()).
+					WithContext(kind+"_id", id).
+					WithContext("remembered", true).
+					AsNotFound().
+					Error()
+			case T:
+				network.RecordCacheHit(ctx, p.ID())
+				return value, nil
+			}
+		}
+
+		// Snapshots aren't put in memory, where callers wanting fresh results would get them
+		if ttl > 0 && snapshots != nil && cache.StaleAllowed(ctx) {
+			var snapshot T
+			if taken, ok := snapshots.Load(key, &snapshot, p.Engine.SnapshotAge()); ok {
+				p.Engine.Logger.Debug("Using snapshot of %s %s of %s from %s", kind, id, p.ID(), taken.Format(time.RFC3339))
+				network.RecordCacheHit(ctx, p.ID())
+				return snapshot, nil
+			}
+		}
+	}
+
+	result, err := fetch()
+	switch {
+	case err != nil && errors.IsNotFound(err):
+		if missTTL := p.notFoundTTL(); missTTL > 0 {
+			c.Set(key, miss{message: err.Error()}, int64(len(key)+len(err.Error())), missTTL)
+		}
+	case err == nil && ttl > 0:
+		c.Set(key, result, int64(len(key))+cache.SizeOf(result), ttl)
+		if refresh && snapshots != nil {
+			if saveErr := snapshots.Save(key, result); saveErr != nil {
+				p.Engine.Logger.Warn("Failed to save snapshot of %s %s of %s: %v", kind, id, p.ID(), saveErr)
+			}
+		}
+	}
+	return result, err
+}
+
+// offlineLookup answers a lookup from the memory cache or a snapshot of any age, failing
+// with a hint how to get the resource cached when neither has it
+func offlineLookup[T any](p *Provider, kind, id, key string, ttl time.Duration) (T, error) {
+	if value, ok := p.Engine.Cache.Get(key); ok {
+		if value, ok := value.(T); ok {
+			return value, nil
+		}
+	}
+
+	var snapshot T
+	if ttl > 0 && p.Engine.Snapshots != nil {
+		if taken, ok := p.Engine.Snapshots.Load(key, &snapshot, 0); ok {
+			p.Engine.Logger.Debug("Offline, using snapshot of %s %s of %s from %s", kind, id, p.ID(), taken.Format(time.RFC3339))
+			return snapshot, nil
+		}
+	}
+
+	return snapshot, errors.Newf("%s %s of %s is not cached", kind, id, p.ID()).
+		WithContext("provider", p.ID()).
+		WithContext(kind+"_id", id).
+		WithMessagef("The %s isn't cached, so it isn't available offline. Save manga details for offline use with 'luminary cache warm'", kind).
+		AsNotFound().
+		Error()
+}
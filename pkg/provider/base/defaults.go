@@ -18,14 +18,17 @@ package base
 
 import (
 	"Luminary/pkg/core"
+	"Luminary/pkg/engine/download"
 	"Luminary/pkg/engine/network"
 	"Luminary/pkg/engine/parser/html"
 	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/common"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // defaultAPISearch implements default search for API providers
@@ -39,10 +42,34 @@ func (p *Provider) defaultAPISearch(ctx context.Context, query string, options c
 		return nil, errors.Track(fmt.Errorf("search endpoint not configured")).AsProvider(p.ID()).Error()
 	}
 
+	// Walk the pages of paginated endpoints until the limit is reached
+	if pagination, ok := p.Config.API.Pagination["search"]; ok {
+		if options.Limit > 0 && (pagination.PageSize == 0 || pagination.PageSize > options.Limit) {
+			pagination.PageSize = options.Limit
+		}
+
+		var results []core.Manga
+		for resp, err := range p.Paginate(ctx, pagination, "search", url.Values{"q": {query}}) {
+			if err != nil {
+				return nil, err
+			}
+
+			page, err := p.parseAPISearchResults(resp)
+			if err != nil {
+				return nil, errors.Track(err).WithContext("search_url", resp.URL).Error()
+			}
+			results = append(results, page...)
+			if options.Limit > 0 && len(results) >= options.Limit {
+				return results[:options.Limit], nil
+			}
+		}
+		return results, nil
+	}
+
 	// Build URL with query parameters
 	u, err := url.Parse(p.Config.API.BaseURL + endpoint)
 	if err != nil {
-		return nil, errors.Track(err).AsProvider(p.ID()).Error()
+		return nil, errors.TP(err, p.ID())
 	}
 
 	q := u.Query()
@@ -59,6 +86,7 @@ func (p *Provider) defaultAPISearch(ctx context.Context, query string, options c
 		Method:    "GET",
 		Headers:   p.Config.Headers,
 		RateLimit: p.Config.RateLimit,
+		Endpoint:  "search",
 	})
 	if err != nil {
 		return nil, errors.Track(err).
@@ -74,57 +102,46 @@ func (p *Provider) defaultAPISearch(ctx context.Context, query string, options c
 			AsNetwork().Error()
 	}
 
-	// Parse response based on mapping
+	results, err := p.parseAPISearchResults(resp)
+	if err != nil {
+		return nil, errors.Track(err).WithContext("search_url", u.String()).Error()
+	}
+	return results, nil
+}
+
+// parseAPISearchResults parses a search response using the search mapping, or generically without one
+func (p *Provider) parseAPISearchResults(resp *network.Response) ([]core.Manga, error) {
 	var results []core.Manga
 	mapping, hasMapping := p.Config.API.ResponseMapping["search"]
 
 	if hasMapping {
 		// Use custom mapping
-		var data map[string]interface{}
-		if err := json.Unmarshal(resp.Body, &data); err != nil {
-			return nil, errors.Track(err).AsProvider(p.ID()).Error()
+		var data interface{}
+		if err := p.decodeAPIResponse("search", resp, &data); err != nil {
+			return nil, err
 		}
+		results, err := mapping.mapManga(data, p.Engine.PreferredLanguages())
+		if err != nil {
+			return nil, errors.TP(err, p.ID())
+		}
+		return results, nil
+	}
 
-		// Extract results array
-		resultsPath := strings.Split(mapping.Fields["results"], ".")
-		resultsData := extractFromPath(data, resultsPath)
-
-		if items, ok := resultsData.([]interface{}); ok {
-			for _, item := range items {
-				if m, ok := item.(map[string]interface{}); ok {
-					manga := core.Manga{
-						ID:    extractString(m, mapping.IDField),
-						Title: extractString(m, mapping.TitleField),
-					}
-
-					// Extract additional fields
-					if descField, ok := mapping.Fields["description"]; ok {
-						manga.Description = extractString(m, descField)
-					}
-
-					results = append(results, manga)
-				}
-			}
+	// Try generic parsing
+	if err := p.decodeAPIResponse("search", resp, &results); err != nil {
+		// Try wrapped response
+		var wrapped struct {
+			Data    []core.Manga `json:"data"`
+			Results []core.Manga `json:"results"`
+		}
+		if err := p.decodeAPIResponse("search", resp, &wrapped); err != nil {
+			return nil, err
 		}
-	} else {
-		// Try generic parsing
-		if err := json.Unmarshal(resp.Body, &results); err != nil {
-			// Try wrapped response
-			var wrapped struct {
-				Data    []core.Manga `json:"data"`
-				Results []core.Manga `json:"results"`
-			}
-			if err := json.Unmarshal(resp.Body, &wrapped); err != nil {
-				return nil, errors.Track(err).
-					WithContext("response", string(resp.Body)).
-					AsProvider(p.ID()).Error()
-			}
 
-			if len(wrapped.Data) > 0 {
-				results = wrapped.Data
-			} else {
-				results = wrapped.Results
-			}
+		if len(wrapped.Data) > 0 {
+			results = wrapped.Data
+		} else {
+			results = wrapped.Results
 		}
 	}
 
@@ -137,22 +154,26 @@ func (p *Provider) defaultWebSearch(ctx context.Context, query string, options c
 
 	if p.Config.Web != nil && p.Config.Web.SearchPath != "" {
 		searchURL = p.Config.SiteURL + strings.ReplaceAll(p.Config.Web.SearchPath, "{query}", url.QueryEscape(query))
-	} else if p.Config.Type == TypeMadara {
-		// Madara default search
-		searchURL = p.Config.SiteURL + "/?s=" + url.QueryEscape(query) + "&post_type=wp-manga"
 	} else {
 		searchURL = p.Config.SiteURL + "/search?q=" + url.QueryEscape(query)
 	}
 
+	var selector string
+	if p.Config.Web != nil {
+		selector = p.Config.Web.Selectors["search_results"]
+	}
+
+	return p.fetchSearchResults(ctx, p.NewRequest(searchURL), selector)
+}
+
+// fetchSearchResults requests a page of search results and extracts the manga linked by
+// selector, falling back to common patterns when it is empty
+func (p *Provider) fetchSearchResults(ctx context.Context, req *network.Request, selector string) ([]core.Manga, error) {
 	// Make request
-	resp, err := p.Engine.Network.Request(ctx, &network.Request{
-		URL:       searchURL,
-		Headers:   p.Config.Headers,
-		RateLimit: p.Config.RateLimit,
-	})
+	resp, err := p.Engine.Network.Request(ctx, req)
 	if err != nil {
 		return nil, errors.Track(err).
-			WithContext("search_url", searchURL).
+			WithContext("search_url", req.URL).
 			WithMessage("Failed to connect to website. Please check your internet connection.").
 			AsNetwork().Error()
 	}
@@ -163,31 +184,28 @@ func (p *Provider) defaultWebSearch(ctx context.Context, query string, options c
 			AsNetwork().Error()
 	}
 
-	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	return p.parseSearchResults(resp, selector)
+}
+
+// parseSearchResults extracts the manga linked by selector from a page of search results
+func (p *Provider) parseSearchResults(resp *network.Response, selector string) ([]core.Manga, error) {
+	doc, err := resp.HTML()
 	if err != nil {
 		return nil, errors.Track(err).
-			WithContext("search_url", searchURL).
+			WithContext("search_url", resp.URL).
 			AsParser().Error()
 	}
 
-	// Extract results using selectors
-	var selector string
-	if p.Config.Type == TypeMadara && p.Config.Madara != nil {
-		selector = p.Config.Madara.Selectors["search"]
-	} else if p.Config.Web != nil {
-		selector = p.Config.Web.Selectors["search_results"]
-	}
-
 	if selector == "" {
 		selector = "a.manga-title, .manga-item a, .post-title a" // Common patterns
 	}
 
 	elements, err := doc.Select(selector).All()
 	if err != nil {
-		return nil, errors.Track(err).AsProvider(p.ID()).Error()
+		return nil, errors.TP(err, p.ID())
 	}
 
+	coverSelector := p.getSelector("search_cover", ".tab-thumb img, .item-thumb img, img")
 	var results []core.Manga
 	for _, elem := range elements {
 		href := elem.Extract().Href()
@@ -199,14 +217,36 @@ func (p *Provider) defaultWebSearch(ctx context.Context, query string, options c
 		id := extractIDFromURL(href, p.Config.SiteURL)
 
 		results = append(results, core.Manga{
-			ID:    id,
-			Title: elem.Extract().Text(),
+			ID:       id,
+			Title:    elem.Extract().Text(),
+			CoverURL: p.listingCover(elem, selector, coverSelector, resp.URL),
 		})
 	}
 
 	return results, nil
 }
 
+// maxCoverDepth bounds how far above a result link its thumbnail is looked for
+const maxCoverDepth = 5
+
+// listingCover finds the thumbnail next to a result link: the first cover image in the
+// closest ancestor that doesn't also hold other results
+func (p *Provider) listingCover(link *html.Element, linkSelector, coverSelector, pageURL string) string {
+	entry := link
+	for depth := 0; depth < maxCoverDepth; depth++ {
+		if entry = entry.Parent(); entry == nil || entry.Find(linkSelector).Count() > 1 {
+			return ""
+		}
+
+		img := entry.Find(coverSelector).FirstOrNil()
+		if img == nil {
+			continue
+		}
+		return resolveURL(pageURL, img.Extract().ImageURL(p.imageAttributes()))
+	}
+	return ""
+}
+
 // defaultAPIGetManga implements default manga retrieval for API providers
 func (p *Provider) defaultAPIGetManga(ctx context.Context, id string) (*core.MangaInfo, error) {
 	if p.Config.API == nil {
@@ -227,6 +267,7 @@ func (p *Provider) defaultAPIGetManga(ctx context.Context, id string) (*core.Man
 		Method:    "GET",
 		Headers:   p.Config.Headers,
 		RateLimit: p.Config.RateLimit,
+		Endpoint:  "manga",
 	})
 	if err != nil {
 		return nil, errors.Track(err).
@@ -244,10 +285,8 @@ func (p *Provider) defaultAPIGetManga(ctx context.Context, id string) (*core.Man
 
 	// Parse response
 	var info core.MangaInfo
-	if err := json.Unmarshal(resp.Body, &info); err != nil {
-		return nil, errors.Track(err).
-			WithContext("response", string(resp.Body)).
-			AsProvider(p.ID()).Error()
+	if err := p.decodeAPIResponse("manga", resp, &info); err != nil {
+		return nil, err
 	}
 
 	// Fetch chapters if endpoint is configured
@@ -265,13 +304,7 @@ func (p *Provider) defaultAPIGetManga(ctx context.Context, id string) (*core.Man
 
 // defaultWebGetManga implements default manga retrieval for web scraping providers
 func (p *Provider) defaultWebGetManga(ctx context.Context, id string) (*core.MangaInfo, error) {
-	var mangaURL string
-
-	if p.Config.Web != nil && p.Config.Web.MangaPath != "" {
-		mangaURL = p.Config.SiteURL + strings.ReplaceAll(p.Config.Web.MangaPath, "{id}", id)
-	} else {
-		mangaURL = p.Config.SiteURL + "/manga/" + id
-	}
+	mangaURL := p.mangaURL(id)
 
 	// Make request
 	resp, err := p.Engine.Network.Request(ctx, &network.Request{
@@ -294,7 +327,7 @@ func (p *Provider) defaultWebGetManga(ctx context.Context, id string) (*core.Man
 	}
 
 	// Parse HTML
-	doc, err := html.Parse(resp.Body)
+	doc, err := resp.HTML()
 	if err != nil {
 		return nil, errors.Track(err).
 			WithContext("manga_url", mangaURL).
@@ -313,29 +346,192 @@ func (p *Provider) defaultWebGetManga(ctx context.Context, id string) (*core.Man
 		info.Title = elem.Extract().Text()
 	}
 
-	// Extract description
+	// Extract description, as HTML so the engine keeps its paragraphs
 	descSelector := p.getSelector("description", ".description, .summary, .manga-description")
 	if elem, err := doc.Select(descSelector).First(); err == nil {
-		info.Description = elem.Extract().Text()
+		info.Description = strings.TrimSpace(elem.InnerHTML())
 	}
 
+	// Extract authors and artists
+	authorSelector := p.getSelector("author", ".author-content a, .manga-authors a")
+	info.Authors = doc.Select(authorSelector).MapString(func(elem *html.Element) string {
+		return elem.Extract().CleanText()
+	})
+
+	artistSelector := p.getSelector("artist", ".artist-content a")
+	info.Artists = doc.Select(artistSelector).MapString(func(elem *html.Element) string {
+		return elem.Extract().CleanText()
+	})
+
+	// Extract genres; Madara sites list demographic and maturity among them
+	genreSelector := p.getSelector("genres", ".genres-content a, .manga-genres a")
+	info.Tags = doc.Select(genreSelector).MapString(func(elem *html.Element) string {
+		return elem.Extract().CleanText()
+	})
+	info.Demographic = common.InferDemographic(info.Tags)
+	info.ContentRating = common.InferContentRating(info.Tags)
+
+	// Extract related manga (Madara "related manga" widget and similar blocks)
+	relatedSelector := p.getSelector("related", ".related-reading-content .widget-title a, .related-manga a")
+	doc.Select(relatedSelector).Each(func(_ int, elem *html.Element) {
+		href := elem.Extract().Href()
+		if href == "" {
+			return
+		}
+		info.Related = append(info.Related, core.RelatedManga{
+			ID:       extractIDFromURL(href, p.Config.SiteURL),
+			Title:    elem.Extract().CleanText(),
+			Relation: "related",
+		})
+	})
+
 	// Extract chapters
 	chapterSelector := p.getSelector("chapters", "li.chapter a, .chapter-list a")
+	pageCountSelector := p.getSelector("page_count", "")
+	dateSelector := p.getSelector("chapter_date", ".chapter-release-date")
 	if chapters, err := doc.Select(chapterSelector).All(); err == nil {
 		for i, ch := range chapters {
-			info.Chapters = append(info.Chapters, core.ChapterInfo{
-				ID:     extractIDFromURL(ch.Extract().Href(), p.Config.SiteURL),
-				Title:  ch.Extract().Text(),
-				Number: float64(len(chapters) - i), // Reverse order
-			})
+			title := ch.Extract().Text()
+			chapter := core.ChapterInfo{
+				ID:       extractIDFromURL(ch.Extract().Href(), p.Config.SiteURL),
+				Title:    title,
+				Label:    p.Engine.Parser.ExtractChapterLabel(title),
+				Sequence: len(chapters) - i, // Listed newest first
+			}
+
+			// Labels like "Extra" carry no number; Sequence keeps them in order
+			if number, err := p.Engine.Parser.ExtractChapterNumber(title); err == nil {
+				chapter.Number = number
+			}
+
+			// Some themes render the page count in a span next to the chapter link
+			if pageCountSelector != "" {
+				if parent := ch.Parent(); parent != nil {
+					if span := parent.Find(pageCountSelector).FirstOrNil(); span != nil {
+						chapter.PageCount = span.Extract().IntOr(0)
+					}
+				}
+			}
+
+			// Dates unknown to the page stay unset rather than guessed
+			if parent := ch.Parent(); parent != nil {
+				if elem := parent.Find(dateSelector).FirstOrNil(); elem != nil {
+					chapter.Date = p.chapterDate(elem)
+				}
+			}
+
+			info.Chapters = append(info.Chapters, chapter)
 		}
 	}
 
 	return info, nil
 }
 
+// mangaURL returns the page of a manga on the site
+func (p *Provider) mangaURL(id string) string {
+	if p.Config.Web != nil && p.Config.Web.MangaPath != "" {
+		return p.Config.SiteURL + strings.ReplaceAll(p.Config.Web.MangaPath, "{id}", id)
+	}
+	return p.Config.SiteURL + "/manga/" + id
+}
+
+// chapterURL returns the reader page of a chapter on the site; chapter IDs extend the
+// manga ID
+func (p *Provider) chapterURL(chapterID string) string {
+	return strings.TrimSuffix(p.Config.SiteURL, "/") + "/manga/" + chapterID + "/"
+}
+
+// defaultImageAttributes are the attributes lazy loaders keep page URLs in, before the
+// src that often only holds a placeholder until the page is scrolled to
+var defaultImageAttributes = []string{"data-src", "data-lazy-src", "data-url", "data-srcset", "srcset", "src"}
+
+// defaultWebChapterPages reads the page images of a chapter's reader page. URLs in the
+// other image attributes become the pages' fallbacks.
+func (p *Provider) defaultWebChapterPages(ctx context.Context, chapterID string) ([]core.Page, error) {
+	chapterURL := p.chapterURL(chapterID)
+
+	resp, err := p.Engine.Network.Request(ctx, p.NewRequest(chapterURL))
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("chapter_url", chapterURL).
+			WithMessage("Failed to fetch chapter pages").
+			AsNetwork().Error()
+	}
+
+	doc, err := resp.HTML()
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("chapter_url", chapterURL).
+			AsParser().Error()
+	}
+
+	attrs := p.imageAttributes()
+	var pages []core.Page
+	doc.Select(p.getSelector("pages", "div.page-break img, .reading-content img")).Each(func(_ int, elem *html.Element) {
+		urls := elem.Extract().ImageURLs(attrs)
+		if len(urls) == 0 {
+			return
+		}
+		for i := range urls {
+			urls[i] = resolveURL(resp.URL, urls[i])
+		}
+		pages = append(pages, core.Page{Index: len(pages), URL: urls[0], Fallbacks: urls[1:]})
+	})
+
+	if len(pages) == 0 {
+		// Licensed, region-blocked and locked chapters show a notice instead
+		if reason, ok := p.unavailableReason(doc); ok {
+			return nil, download.Unavailable(p.ID(), chapterID, reason)
+		}
+		return nil, errors.New("no page images found on the chapter page").
+			WithContext("chapter_url", chapterURL).
+			WithContext("attributes", strings.Join(attrs, ",")).
+			AsParser().Error()
+	}
+	return pages, nil
+}
+
 // Helper functions
 
+// imageAttributes returns the attributes image URLs are read from, in order
+func (p *Provider) imageAttributes() []string {
+	if p.Config.Madara != nil && len(p.Config.Madara.ImageAttributes) > 0 {
+		return p.Config.Madara.ImageAttributes
+	}
+	return defaultImageAttributes
+}
+
+// resolveURL resolves a possibly relative URL against the page it was found on
+func resolveURL(pageURL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// chapterDate parses a chapter's release date element. Madara replaces the date of recent
+// chapters with a "new" badge linking to the relative date in its title attribute.
+func (p *Provider) chapterDate(elem *html.Element) *time.Time {
+	text := elem.Extract().CleanText()
+	if badge := elem.Find("a[title]").FirstOrNil(); badge != nil {
+		text = badge.AttrOr("title", text)
+	}
+
+	date, err := p.Engine.Parser.ExtractDate(text)
+	if err != nil {
+		return nil
+	}
+	return date
+}
+
 func (p *Provider) getSelector(name string, defaultSelector string) string {
 	// Check Madara config first
 	if p.Config.Type == TypeMadara && p.Config.Madara != nil {
@@ -354,14 +550,34 @@ func (p *Provider) getSelector(name string, defaultSelector string) string {
 	return defaultSelector
 }
 
+// fetchAPIChapters fetches a manga's chapters, walking every page of paginated endpoints
 func (p *Provider) fetchAPIChapters(ctx context.Context, mangaID string, endpoint string) ([]core.ChapterInfo, error) {
-	buildUrl := p.Config.API.BaseURL + strings.ReplaceAll(endpoint, "{id}", mangaID)
+	path := strings.ReplaceAll(endpoint, "{id}", mangaID)
+
+	if pagination, ok := p.Config.API.Pagination["chapters"]; ok {
+		var chapters []core.ChapterInfo
+		for resp, err := range p.Paginate(ctx, pagination, path, nil) {
+			if err != nil {
+				return nil, err
+			}
+
+			page, err := p.parseAPIChapters(resp)
+			if err != nil {
+				return nil, errors.Track(err).WithContext("chapters_url", resp.URL).AsParser().Error()
+			}
+			chapters = append(chapters, page...)
+		}
+		return chapters, nil
+	}
+
+	buildUrl := p.Config.API.BaseURL + path
 
 	resp, err := p.Engine.Network.Request(ctx, &network.Request{
 		URL:       buildUrl,
 		Method:    "GET",
 		Headers:   p.Config.Headers,
 		RateLimit: p.Config.RateLimit,
+		Endpoint:  "chapters",
 	})
 	if err != nil {
 		return nil, errors.Track(err).
@@ -376,14 +592,51 @@ func (p *Provider) fetchAPIChapters(ctx context.Context, mangaID string, endpoin
 			AsNetwork().Error()
 	}
 
+	return p.parseAPIChapters(resp)
+}
+
+// decodeAPIResponse decodes the response of an endpoint into v like encoding/json. Endpoints
+// with a registered protobuf message are decoded into the message first, which is then
+// converted through its json tags.
+func (p *Provider) decodeAPIResponse(endpoint string, resp *network.Response, v interface{}) error {
+	newMessage, ok := p.Config.API.ProtoMessages[endpoint]
+	if !ok {
+		if err := resp.JSON(v); err != nil {
+			return errors.Track(err).WithContext("provider_id", p.ID()).Error()
+		}
+		return nil
+	}
+
+	message := newMessage()
+	if err := resp.Proto(message); err != nil {
+		return errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+
+	encoded, err := json.Marshal(message)
+	if err == nil {
+		err = json.Unmarshal(encoded, v)
+	}
+	if err != nil {
+		return errors.Track(err).
+			WithContext("endpoint", endpoint).
+			WithContext("provider_id", p.ID()).
+			WithMessagef("The %s endpoint's protobuf message does not convert to the expected data", endpoint).
+			AsParser().
+			Error()
+	}
+	return nil
+}
+
+// parseAPIChapters parses a chapter list, either bare or wrapped in "data" or "chapters"
+func (p *Provider) parseAPIChapters(resp *network.Response) ([]core.ChapterInfo, error) {
 	var chapters []core.ChapterInfo
-	if err := json.Unmarshal(resp.Body, &chapters); err != nil {
+	if err := p.decodeAPIResponse("chapters", resp, &chapters); err != nil {
 		// Try wrapped response
 		var wrapped struct {
 			Data     []core.ChapterInfo `json:"data"`
 			Chapters []core.ChapterInfo `json:"chapters"`
 		}
-		if err := json.Unmarshal(resp.Body, &wrapped); err != nil {
+		if err := p.decodeAPIResponse("chapters", resp, &wrapped); err != nil {
 			return nil, err
 		}
 
@@ -397,29 +650,6 @@ func (p *Provider) fetchAPIChapters(ctx context.Context, mangaID string, endpoin
 	return chapters, nil
 }
 
-func extractFromPath(data map[string]interface{}, path []string) interface{} {
-	current := interface{}(data)
-
-	for _, key := range path {
-		if m, ok := current.(map[string]interface{}); ok {
-			current = m[key]
-		} else {
-			return nil
-		}
-	}
-
-	return current
-}
-
-func extractString(data map[string]interface{}, field string) string {
-	if val, ok := data[field]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
 func extractIDFromURL(href, siteURL string) string {
 	// Remove site URL
 	id := strings.TrimPrefix(href, siteURL)
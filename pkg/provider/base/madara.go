@@ -0,0 +1,439 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/parser/html"
+	"Luminary/pkg/errors"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Names of the Madara search strategies
+const (
+	MadaraAjaxArchive   = "ajax_archive"   // The theme's "load more" AJAX action, paged
+	MadaraDirectArchive = "direct_archive" // The search archive at /page/N/?s=..., paged
+	MadaraSearchPage    = "search_page"    // The first page of the search archive only
+	MadaraAutocomplete  = "autocomplete"   // The search box's title suggestions, for queries only
+)
+
+// maxMadaraSearchPages bounds how many pages a paged strategy requests for one search
+const maxMadaraSearchPages = 5
+
+// MadaraSearchStrategy fetches pages of search results from a Madara site
+type MadaraSearchStrategy interface {
+	// Page returns the results on a page, counting from 0; an empty page ends the search
+	Page(ctx context.Context, p *Provider, query string, page int) ([]core.Manga, error)
+	// Paged reports whether pages after the first can be requested
+	Paged() bool
+}
+
+// madaraSearchStrategies maps strategy names to implementations
+var madaraSearchStrategies = map[string]MadaraSearchStrategy{
+	MadaraAjaxArchive:   AjaxArchive{},
+	MadaraDirectArchive: DirectArchive{},
+	MadaraSearchPage:    SearchPage{},
+	MadaraAutocomplete:  Autocomplete{},
+}
+
+// AjaxArchive searches through the admin-ajax.php "load more" action the theme's own
+// infinite scrolling uses
+type AjaxArchive struct{}
+
+// Page implements MadaraSearchStrategy
+func (AjaxArchive) Page(ctx context.Context, p *Provider, query string, page int) ([]core.Manga, error) {
+	config := p.madaraConfig()
+	action := "madara_load_more"
+	if config.CustomLoadAction != "" {
+		action = config.CustomLoadAction
+	}
+
+	req := p.NewRequest(strings.TrimSuffix(p.Config.SiteURL, "/") + "/wp-admin/admin-ajax.php")
+	req.Method = "POST"
+	req.FormData = url.Values{
+		"action":            {action},
+		"page":              {strconv.Itoa(page)},
+		"template":          {"madara-core/content/content-search"},
+		"vars[s]":           {query},
+		"vars[paged]":       {"1"},
+		"vars[post_type]":   {"wp-manga"},
+		"vars[post_status]": {"publish"},
+	}
+	if config.ItemsPerPage > 0 {
+		req.FormData.Set("vars[posts_per_page]", strconv.Itoa(config.ItemsPerPage))
+	}
+	if config.OrderBy != "" {
+		req.FormData.Set("vars[orderby]", config.OrderBy)
+	}
+	for name, value := range config.LoadVars {
+		req.FormData.Set("vars["+name+"]", value)
+	}
+
+	// The action answers "0" when the site doesn't register it
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err == nil && strings.TrimSpace(resp.Text()) == "0" {
+		err = fmt.Errorf("AJAX action %s is not available", action)
+	}
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("search_url", req.URL).
+			WithContext("strategy", MadaraAjaxArchive).
+			AsProvider(p.ID()).
+			Error()
+	}
+
+	return p.parseSearchResults(resp, p.madaraSearchSelector())
+}
+
+// Paged implements MadaraSearchStrategy
+func (AjaxArchive) Paged() bool { return true }
+
+// DirectArchive walks the pages of the WordPress search archive, or of the manga
+// archive by latest update when there is no query
+type DirectArchive struct{}
+
+// Page implements MadaraSearchStrategy
+func (DirectArchive) Page(ctx context.Context, p *Provider, query string, page int) ([]core.Manga, error) {
+	var elems []string
+	values := url.Values{"s": {query}, "post_type": {"wp-manga"}}
+	orderBy := p.madaraConfig().OrderBy
+	if strings.TrimSpace(query) == "" {
+		elems = append(elems, "manga")
+		values = url.Values{}
+		if orderBy == "" {
+			orderBy = "latest"
+		}
+	}
+	if orderBy != "" {
+		values.Set("m_orderby", orderBy)
+	}
+	if page > 0 {
+		elems = append(elems, "page", strconv.Itoa(page+1))
+	}
+
+	archiveURL, err := url.JoinPath(p.Config.SiteURL, append(elems, "/")...)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("site_url", p.Config.SiteURL).
+			WithContext("strategy", MadaraDirectArchive).
+			AsProvider(p.ID()).
+			Error()
+	}
+	return p.fetchSearchResults(ctx, p.NewRequest(archiveURL+"?"+values.Encode()), p.madaraSearchSelector())
+}
+
+// Paged implements MadaraSearchStrategy
+func (DirectArchive) Paged() bool { return true }
+
+// SearchPage reads the first page of the search archive, at the site's configured
+// search path when it has one
+type SearchPage struct{}
+
+// Page implements MadaraSearchStrategy
+func (SearchPage) Page(ctx context.Context, p *Provider, query string, _ int) ([]core.Manga, error) {
+	searchURL, err := p.madaraSearchPageURL(query)
+	if err != nil {
+		return nil, err
+	}
+	return p.fetchSearchResults(ctx, p.NewRequest(searchURL), p.madaraSearchSelector())
+}
+
+// madaraSearchPageURL returns the URL of the first page of the search archive, adding
+// the configured order unless the search path sets one
+func (p *Provider) madaraSearchPageURL(query string) (string, error) {
+	searchURL := strings.TrimSuffix(p.Config.SiteURL, "/") + "/?" +
+		url.Values{"s": {query}, "post_type": {"wp-manga"}}.Encode()
+	if p.Config.Web != nil && p.Config.Web.SearchPath != "" {
+		searchURL = p.Config.SiteURL + strings.ReplaceAll(p.Config.Web.SearchPath, "{query}", url.QueryEscape(query))
+	}
+
+	orderBy := p.madaraConfig().OrderBy
+	if orderBy == "" {
+		return searchURL, nil
+	}
+	u, err := url.Parse(searchURL)
+	if err != nil {
+		return "", errors.Track(err).
+			WithContext("search_url", searchURL).
+			AsProvider(p.ID()).
+			Error()
+	}
+	values := u.Query()
+	if !values.Has("m_orderby") {
+		values.Set("m_orderby", orderBy)
+		u.RawQuery = values.Encode()
+	}
+	return u.String(), nil
+}
+
+// Paged implements MadaraSearchStrategy
+func (SearchPage) Paged() bool { return false }
+
+// Autocomplete asks the theme's live search action for titles matching the query. It
+// matches titles only, but answers with little more than a JSON list.
+type Autocomplete struct{}
+
+// Page implements MadaraSearchStrategy
+func (Autocomplete) Page(ctx context.Context, p *Provider, query string, _ int) ([]core.Manga, error) {
+	req := p.NewRequest(strings.TrimSuffix(p.Config.SiteURL, "/") + "/wp-admin/admin-ajax.php")
+	req.Method = "POST"
+	req.Endpoint = MadaraAutocomplete
+	req.FormData = url.Values{
+		"action": {"wp-manga-search-manga"},
+		"title":  {query},
+	}
+
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("search_url", req.URL).
+			WithContext("strategy", MadaraAutocomplete).
+			AsProvider(p.ID()).
+			Error()
+	}
+
+	// No matches are reported as {"success": false, "data": [{"error": ...}]}
+	var suggestions struct {
+		Success bool `json:"success"`
+		Data    []struct {
+			Title string `json:"title"`
+			URL   string `json:"url"`
+		} `json:"data"`
+	}
+	if err := resp.JSON(&suggestions); err != nil {
+		return nil, errors.Track(err).WithContext("provider_id", p.ID()).Error()
+	}
+	if !suggestions.Success {
+		return nil, nil
+	}
+
+	var results []core.Manga
+	for _, suggestion := range suggestions.Data {
+		if suggestion.URL == "" {
+			continue
+		}
+		results = append(results, core.Manga{
+			ID:    extractIDFromURL(suggestion.URL, p.Config.SiteURL),
+			Title: strings.TrimSpace(suggestion.Title),
+		})
+	}
+	return results, nil
+}
+
+// Paged implements MadaraSearchStrategy
+func (Autocomplete) Paged() bool { return false }
+
+// defaultMadaraSearch tries the site's search strategies in order until one succeeds
+func (p *Provider) defaultMadaraSearch(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+	var lastErr error
+	for _, name := range p.madaraSearchOrder(query) {
+		strategy, ok := madaraSearchStrategies[name]
+		if !ok {
+			return nil, errors.Newf("unknown Madara search strategy %q", name).
+				WithContext("strategy", name).
+				AsProvider(p.ID()).
+				Error()
+		}
+
+		results, err := p.runMadaraSearch(ctx, strategy, query, options.Limit)
+		if err == nil {
+			return results, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		p.Engine.Log(ctx).Debug("[%s] Search strategy %s failed, trying the next: %v", p.ID(), name, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// runMadaraSearch collects pages from a strategy until the limit, if any, is reached or
+// the results run out. Failures after the first page end the search with the results so far.
+func (p *Provider) runMadaraSearch(ctx context.Context, strategy MadaraSearchStrategy, query string, limit int) ([]core.Manga, error) {
+	var results []core.Manga
+	seen := make(map[string]bool)
+	perPage := p.madaraConfig().ItemsPerPage
+
+	for page := 0; page < maxMadaraSearchPages; page++ {
+		items, err := strategy.Page(ctx, p, query, page)
+		if err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			break
+		}
+
+		added := 0
+		for _, manga := range items {
+			if !seen[manga.ID] {
+				seen[manga.ID] = true
+				results = append(results, manga)
+				added++
+			}
+		}
+
+		// Stop on the last page, or when a site ignores the page number
+		if added == 0 || !strategy.Paged() || (limit > 0 && len(results) >= limit) {
+			break
+		}
+		if perPage > 0 && len(items) < perPage {
+			break
+		}
+	}
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// madaraSearchOrder returns the names of the strategies to try; autocomplete only runs for queries
+func (p *Provider) madaraSearchOrder(query string) []string {
+	order := []string{MadaraDirectArchive, MadaraAutocomplete}
+	if p.Config.Madara != nil && len(p.Config.Madara.SearchStrategies) > 0 {
+		order = p.Config.Madara.SearchStrategies
+	} else if p.Config.Madara != nil && p.Config.Madara.AjaxSearch {
+		order = []string{MadaraAjaxArchive, MadaraDirectArchive, MadaraAutocomplete}
+	}
+
+	if strings.TrimSpace(query) != "" {
+		return order
+	}
+	browse := make([]string, 0, len(order))
+	for _, name := range order {
+		if name != MadaraAutocomplete {
+			browse = append(browse, name)
+		}
+	}
+	return browse
+}
+
+// genreArchive walks the pages of a genre's archive at /manga-genre/<slug>/
+type genreArchive struct {
+	slug string
+}
+
+// Page implements MadaraSearchStrategy
+func (g genreArchive) Page(ctx context.Context, p *Provider, _ string, page int) ([]core.Manga, error) {
+	archiveURL := strings.TrimSuffix(p.Config.SiteURL, "/") + "/manga-genre/" + url.PathEscape(g.slug) + "/"
+	if page > 0 {
+		archiveURL += fmt.Sprintf("page/%d/", page+1)
+	}
+	if orderBy := p.madaraConfig().OrderBy; orderBy != "" {
+		archiveURL += "?m_orderby=" + url.QueryEscape(orderBy)
+	}
+	return p.fetchSearchResults(ctx, p.NewRequest(archiveURL), p.madaraSearchSelector())
+}
+
+// Paged implements MadaraSearchStrategy
+func (genreArchive) Paged() bool { return true }
+
+// genreCountPattern matches the "(123)" post count the genres widget appends to names
+var genreCountPattern = regexp.MustCompile(`\s*\(([\d.,]+)\)\s*$`)
+
+// defaultMadaraTags reads the genres widget of the site's front page
+func (p *Provider) defaultMadaraTags(ctx context.Context) ([]core.Tag, error) {
+	req := p.NewRequest(strings.TrimSuffix(p.Config.SiteURL, "/") + "/")
+	resp, err := p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("url", req.URL).
+			WithMessage("Failed to fetch the genre list").
+			AsNetwork().Error()
+	}
+
+	doc, err := resp.HTML()
+	if err != nil {
+		return nil, errors.Track(err).WithContext("url", req.URL).AsParser().Error()
+	}
+
+	var tags []core.Tag
+	seen := make(map[string]bool)
+	doc.Select(p.getSelector("genre_list", `.genres_wrap a[href*="manga-genre/"], a[href*="/manga-genre/"]`)).Each(func(_ int, elem *html.Element) {
+		slug := genreSlug(elem.Extract().Href())
+		if slug == "" || seen[slug] {
+			return
+		}
+		seen[slug] = true
+
+		tag := core.Tag{ID: slug, Name: elem.Extract().CleanText()}
+		if match := genreCountPattern.FindStringSubmatch(tag.Name); match != nil {
+			tag.Name = strings.TrimSpace(tag.Name[:len(tag.Name)-len(match[0])])
+			tag.Count, _ = strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(match[1]))
+		}
+		tags = append(tags, tag)
+	})
+
+	return tags, nil
+}
+
+// defaultMadaraBrowseTag lists the manga of a genre, paging its archive up to the limit
+func (p *Provider) defaultMadaraBrowseTag(ctx context.Context, tagID string, options core.SearchOptions) ([]core.Manga, error) {
+	slug := strings.Trim(tagID, "/")
+	if slug == "" {
+		return nil, errors.New("tag ID is required").AsProvider(p.ID()).Error()
+	}
+	return p.runMadaraSearch(ctx, genreArchive{slug: slug}, "", options.Limit)
+}
+
+// genreSlug extracts the slug of a /manga-genre/<slug>/ link
+func genreSlug(href string) string {
+	_, rest, found := strings.Cut(href, "manga-genre/")
+	if !found {
+		return ""
+	}
+	if idx := strings.IndexAny(rest, "/?#"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	return rest
+}
+
+// madaraChapterID trims a reader path to its "<manga>/<chapter>" ID. The paged reader adds
+// the page as /p/<n>/ and the reading style as ?style=, neither of which names the chapter.
+func madaraChapterID(path string) string {
+	segments := strings.Split(path, "/")
+	if len(segments) == 4 && segments[2] == "p" {
+		segments = segments[:2]
+	}
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return ""
+	}
+	return strings.Join(segments, "/")
+}
+
+// madaraConfig returns the Madara settings, empty ones for sites without any
+func (p *Provider) madaraConfig() MadaraConfig {
+	if p.Config.Madara == nil {
+		return MadaraConfig{}
+	}
+	return *p.Config.Madara
+}
+
+// madaraSearchSelector returns the selector of search result links
+func (p *Provider) madaraSearchSelector() string {
+	if p.Config.Madara != nil {
+		return p.Config.Madara.Selectors["search"]
+	}
+	return ""
+}
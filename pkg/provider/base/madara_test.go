@@ -0,0 +1,99 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/core"
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestMadaraSearchPageURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		searchPath string
+		orderBy    string
+		want       string
+	}{
+		{"default", "", "", "https://example.org/?post_type=wp-manga&s=one+piece"},
+		{"default ordered", "", "views", "https://example.org/?m_orderby=views&post_type=wp-manga&s=one+piece"},
+		{"path without query", "/search/{query}/", "views", "https://example.org/search/one+piece/?m_orderby=views"},
+		{"path with query", "/?s={query}", "views", "https://example.org/?m_orderby=views&s=one+piece"},
+		{"path setting the order", "/?s={query}&m_orderby=latest", "views", "https://example.org/?s=one+piece&m_orderby=latest"},
+		{"path unordered", "/search/{query}/", "", "https://example.org/search/one+piece/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{Config: Config{
+				SiteURL: "https://example.org",
+				Madara:  &MadaraConfig{OrderBy: tt.orderBy},
+			}}
+			if tt.searchPath != "" {
+				p.Config.Web = &WebConfig{SearchPath: tt.searchPath}
+			}
+
+			got, err := p.madaraSearchPageURL("one piece")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("madaraSearchPageURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// pagedStrategy serves two results on each of its first pages
+type pagedStrategy struct {
+	pages int
+}
+
+func (s pagedStrategy) Page(_ context.Context, _ *Provider, _ string, page int) ([]core.Manga, error) {
+	if page >= s.pages {
+		return nil, nil
+	}
+	id := strconv.Itoa(page * 2)
+	return []core.Manga{{ID: id + "a"}, {ID: id + "b"}}, nil
+}
+
+func (pagedStrategy) Paged() bool { return true }
+
+func TestRunMadaraSearchLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages int
+		limit int
+		want  int
+	}{
+		{"no limit", 3, 0, 6},
+		{"no limit bounded", 10, 0, 2 * maxMadaraSearchPages},
+		{"limit", 3, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{}
+			results, err := p.runMadaraSearch(context.Background(), pagedStrategy{tt.pages}, "query", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != tt.want {
+				t.Errorf("got %d results, want %d", len(results), tt.want)
+			}
+		})
+	}
+}
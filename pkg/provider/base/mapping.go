@@ -0,0 +1,306 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine/parser/jsonpath"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/common"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// mapManga maps a decoded API response to manga using the ResponseMap. The "results"
+// field selects the result items (the whole response when unset); every other path
+// is relative to an item. Localized values are chosen by the language priority given.
+func (m ResponseMap) mapManga(data interface{}, languages []string) ([]core.Manga, error) {
+	paths, err := m.compile(languages)
+	if err != nil {
+		return nil, err
+	}
+
+	items := resultItems(paths["results"].values(data))
+	results := make([]core.Manga, 0, len(items))
+	for _, item := range items {
+		if manga, ok := mapItem(paths, item); ok {
+			results = append(results, manga)
+		}
+	}
+
+	return results, nil
+}
+
+// MappingReport describes how a ResponseMap applies to a response
+type MappingReport struct {
+	Items  int // Result items selected by the results path
+	Fields []FieldReport
+	Manga  []core.Manga // What the mapping produces
+}
+
+// FieldReport describes how one path of a ResponseMap matched
+type FieldReport struct {
+	Field     string
+	Path      string
+	Transform string
+	Required  bool
+	Matched   int           // Items the path matched in (the response for "results")
+	Missing   int           // Items the path matched nothing in
+	Samples   []interface{} // Raw values of the first matches
+	Values    []interface{} // The first values after transforms
+}
+
+// MissingRequired reports whether a required field matched nothing at all
+func (r FieldReport) MissingRequired() bool {
+	return r.Required && r.Matched == 0
+}
+
+// requiredFields must be mapped for a result to be usable
+var requiredFields = map[string]bool{"results": true, "id": true, "title": true}
+
+// maxSamples bounds the raw values kept per field in a report
+const maxSamples = 3
+
+// Validate runs the mapping against a raw response and reports which paths matched,
+// which required fields are missing and the raw values seen, choosing localized values by
+// the language priority given. It is meant for developing mappings; the mapping is not
+// changed.
+func (m ResponseMap) Validate(body []byte, languages []string) (*MappingReport, error) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, errors.Track(err).
+			WithMessage("The response is not valid JSON").
+			AsParser().Error()
+	}
+
+	paths, err := m.compile(languages)
+	if err != nil {
+		return nil, err
+	}
+
+	items := resultItems(paths["results"].values(data))
+	report := &MappingReport{Items: len(items)}
+
+	fields := make([]string, 0, len(paths))
+	for field := range paths {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		// Required fields first, then alphabetically
+		if requiredFields[fields[i]] != requiredFields[fields[j]] {
+			return requiredFields[fields[i]]
+		}
+		return fields[i] < fields[j]
+	})
+
+	for _, field := range fields {
+		fp := paths[field]
+		fr := FieldReport{
+			Field:     field,
+			Path:      fp.path.String(),
+			Transform: strings.Join(fp.transforms, "|"),
+			Required:  requiredFields[field],
+		}
+
+		scopes := items
+		if field == "results" {
+			scopes = []interface{}{data}
+		}
+		for _, scope := range scopes {
+			raw := fp.path.Get(scope)
+			if len(raw) == 0 {
+				fr.Missing++
+				continue
+			}
+			fr.Matched++
+			for _, v := range raw {
+				if len(fr.Samples) < maxSamples {
+					fr.Samples = append(fr.Samples, v)
+				}
+			}
+			for _, v := range fp.apply(raw) {
+				if len(fr.Values) < maxSamples {
+					fr.Values = append(fr.Values, v)
+				}
+			}
+		}
+		report.Fields = append(report.Fields, fr)
+	}
+
+	for _, item := range items {
+		if manga, ok := mapItem(paths, item); ok {
+			report.Manga = append(report.Manga, manga)
+		}
+	}
+
+	return report, nil
+}
+
+// resultItems returns the result items among the values of the results path; a path to
+// an array selects its elements
+func resultItems(values []interface{}) []interface{} {
+	var items []interface{}
+	for _, match := range values {
+		if array, ok := match.([]interface{}); ok {
+			items = append(items, array...)
+		} else {
+			items = append(items, match)
+		}
+	}
+	return items
+}
+
+// mapItem maps a single result item; items without an ID are skipped
+func mapItem(paths map[string]*fieldPath, item interface{}) (core.Manga, bool) {
+	manga := core.Manga{
+		ID:    firstString(paths["id"].values(item)),
+		Title: firstString(paths["title"].values(item)),
+	}
+	if manga.ID == "" {
+		return manga, false
+	}
+
+	manga.Description = firstString(paths["description"].values(item))
+	manga.Status = firstString(paths["status"].values(item))
+	manga.CoverURL = firstString(paths["cover"].values(item))
+	manga.AlternativeTitles = allStrings(paths["alt_titles"].values(item))
+	manga.Authors = allStrings(paths["authors"].values(item))
+	manga.Artists = allStrings(paths["artists"].values(item))
+	manga.Tags = allStrings(paths["tags"].values(item))
+
+	return manga, true
+}
+
+// fieldPath is a compiled path of a mapping with the transforms applied to its values
+type fieldPath struct {
+	path       *jsonpath.Path
+	transforms []string
+	fns        []common.Transform
+	languages  []string // Language priority the transforms choose localized values by
+}
+
+// values returns the transformed values matched in scope; nil paths match nothing
+func (fp *fieldPath) values(scope interface{}) []interface{} {
+	if fp == nil {
+		return nil
+	}
+	return fp.apply(fp.path.Get(scope))
+}
+
+// apply runs the transforms in order
+func (fp *fieldPath) apply(values []interface{}) []interface{} {
+	for _, fn := range fp.fns {
+		if len(values) == 0 {
+			break
+		}
+		values = fn(values, fp.languages)
+	}
+	return values
+}
+
+// compile compiles every path of the mapping and resolves its transforms, keyed by field name,
+// for the language priority given. The id and title paths are required.
+func (m ResponseMap) compile(languages []string) (map[string]*fieldPath, error) {
+	for field, expr := range map[string]string{"id": m.IDField, "title": m.TitleField} {
+		if strings.TrimSpace(expr) == "" {
+			return nil, errors.Newf("response mapping has no %s path", field).
+				WithContext("field", field).
+				WithMessagef("The response mapping needs a path for %s", field).
+				AsParser().
+				Error()
+		}
+	}
+
+	exprs := map[string]string{
+		"results": "$",
+		"id":      m.IDField,
+		"title":   m.TitleField,
+	}
+	for field, expr := range m.Fields {
+		exprs[field] = expr
+	}
+
+	paths := make(map[string]*fieldPath, len(exprs))
+	for field, expr := range exprs {
+		if expr == "" {
+			continue
+		}
+		path, err := jsonpath.Compile(expr)
+		if err != nil {
+			return nil, errors.Track(err).
+				WithContext("field", field).
+				WithMessagef("Invalid response mapping for %s: %v", field, err).
+				AsParser().
+				Error()
+		}
+		paths[field] = &fieldPath{path: path, languages: languages}
+	}
+
+	for field, chain := range m.Transforms {
+		fp, ok := paths[field]
+		if !ok {
+			return nil, errors.Newf("transform for unmapped field %s", field).
+				WithContext("field", field).
+				AsParser().
+				Error()
+		}
+
+		for _, name := range strings.Split(chain, "|") {
+			name = strings.TrimSpace(name)
+			fn, ok := common.LookupTransform(name)
+			if !ok {
+				return nil, errors.Newf("unknown transform %q for %s", name, field).
+					WithContext("field", field).
+					WithMessagef("Unknown transform %q; available: %s", name, strings.Join(common.TransformNames(), ", ")).
+					AsParser().
+					Error()
+			}
+			fp.transforms = append(fp.transforms, name)
+			fp.fns = append(fp.fns, fn)
+		}
+	}
+
+	return paths, nil
+}
+
+// firstString returns the first string value, or ""
+func firstString(values []interface{}) string {
+	for _, v := range values {
+		if s, ok := jsonpath.ToString(v); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// allStrings returns every string value, flattening arrays
+func allStrings(values []interface{}) []string {
+	var result []string
+	for _, match := range values {
+		if array, ok := match.([]interface{}); ok {
+			for _, v := range array {
+				if s, ok := jsonpath.ToString(v); ok {
+					result = append(result, s)
+				}
+			}
+		} else if s, ok := jsonpath.ToString(match); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import "testing"
+
+func TestValidateRequiresIDAndTitlePaths(t *testing.T) {
+	body := []byte(`[{"id": "a", "name": "Alpha"}]`)
+	tests := []struct {
+		name    string
+		mapping ResponseMap
+		wantErr bool
+	}{
+		{"complete", ResponseMap{IDField: "$.id", TitleField: "$.name"}, false},
+		{"no id", ResponseMap{TitleField: "$.name"}, true},
+		{"no title", ResponseMap{IDField: "$.id"}, true},
+		{"blank title", ResponseMap{IDField: "$.id", TitleField: " "}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := tt.mapping.Validate(body, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(report.Manga) != 1 {
+				t.Errorf("got %d manga, want 1", len(report.Manga))
+			}
+		})
+	}
+}
+
+func TestMappingChoosesLocalizedValuesByLanguagePriority(t *testing.T) {
+	body := []byte(`[{"id": "a", "title": {"en": "Blue Lock", "ja-ro": "Buruu Rokku"}}]`)
+	mapping := ResponseMap{
+		IDField:    "$.id",
+		TitleField: "$.title",
+		Transforms: map[string]string{"title": "bestLocalizedString"},
+	}
+	for _, tt := range []struct {
+		languages []string
+		want      string
+	}{
+		{[]string{"en"}, "Blue Lock"},
+		{[]string{"ja-ro", "en"}, "Buruu Rokku"},
+	} {
+		report, err := mapping.Validate(body, tt.languages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Manga) != 1 || report.Manga[0].Title != tt.want {
+			t.Errorf("languages %v: got %+v, want title %q", tt.languages, report.Manga, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import "time"
+
+// defaultNotFoundTTL is how long a lookup answered with "not found" is remembered
+const defaultNotFoundTTL = 5 * time.Minute
+
+// miss is cached for lookups that found nothing, so batches referring to removed manga
+// or chapters don't request them again and again and trip the site's rate limit
+type miss struct {
+	message string
+}
+
+// notFoundTTL returns how long the provider remembers missing resources; zero when it doesn't
+func (p *Provider) notFoundTTL() time.Duration {
+	switch {
+	case p.Config.NotFoundTTL < 0:
+		return 0
+	case p.Config.NotFoundTTL == 0:
+		return defaultNotFoundTTL
+	default:
+		return p.Config.NotFoundTTL
+	}
+}
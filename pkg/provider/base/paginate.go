@@ -0,0 +1,249 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/engine/parser/jsonpath"
+	"Luminary/pkg/errors"
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PaginationStyle selects how an API endpoint is paged
+type PaginationStyle string
+
+const (
+	PaginateOffset PaginationStyle = "offset" // ?limit=N&offset=M
+	PaginatePage   PaginationStyle = "page"   // ?limit=N&page=M
+	PaginateCursor PaginationStyle = "cursor" // ?limit=N&cursor=<from the previous response>
+)
+
+// PaginationConfig describes how an API endpoint pages its results. Zero values
+// select the defaults noted on each field.
+type PaginationConfig struct {
+	Style    PaginationStyle // Default PaginateOffset
+	PageSize int             // Items to request per page; 0 leaves the size to the API
+	MaxPages int             // Stop after this many pages; 0 for no limit
+
+	LimitParam     string // Default "limit"
+	OffsetParam    string // Default "offset"
+	PageParam      string // Default "page"
+	CursorParam    string // Default "cursor"
+	ZeroBasedPages bool   // Whether page numbers start at 0 instead of 1
+
+	// JSONPath expressions evaluated on each response
+	ItemsPath  string // The items of a page, used to detect the last page (default "$")
+	TotalPath  string // The total number of items, if the API reports it
+	CursorPath string // The cursor of the next page; required for PaginateCursor
+}
+
+// withDefaults fills in the defaults of unset fields
+func (c PaginationConfig) withDefaults() PaginationConfig {
+	if c.Style == "" {
+		c.Style = PaginateOffset
+	}
+	if c.LimitParam == "" {
+		c.LimitParam = "limit"
+	}
+	if c.OffsetParam == "" {
+		c.OffsetParam = "offset"
+	}
+	if c.PageParam == "" {
+		c.PageParam = "page"
+	}
+	if c.CursorParam == "" {
+		c.CursorParam = "cursor"
+	}
+	if c.ItemsPath == "" {
+		c.ItemsPath = "$"
+	}
+	return c
+}
+
+// Paginate walks the pages of an API endpoint, yielding each response until the
+// last page is reached, an error occurs or the caller stops ranging. endpoint is
+// the name of a configured endpoint or a path relative to the API base URL; params
+// are sent with every request alongside the pagination parameters.
+func (p *Provider) Paginate(ctx context.Context, config PaginationConfig, endpoint string, params url.Values) iter.Seq2[*network.Response, error] {
+	return func(yield func(*network.Response, error) bool) {
+		pageURL, err := p.endpointURL(endpoint)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		name := p.endpointName(endpoint)
+
+		config = config.withDefaults()
+		itemsPath, totalPath, cursorPath, err := config.compile()
+		if err != nil {
+			yield(nil, errors.TP(err, p.ID()))
+			return
+		}
+
+		seen, page, cursor := 0, 1, ""
+		if config.ZeroBasedPages {
+			page = 0
+		}
+
+		for n := 0; config.MaxPages == 0 || n < config.MaxPages; n++ {
+			q := url.Values{}
+			for key, values := range params {
+				q[key] = append([]string(nil), values...)
+			}
+			if config.PageSize > 0 {
+				q.Set(config.LimitParam, strconv.Itoa(config.PageSize))
+			}
+			switch config.Style {
+			case PaginateOffset:
+				q.Set(config.OffsetParam, strconv.Itoa(seen))
+			case PaginatePage:
+				q.Set(config.PageParam, strconv.Itoa(page))
+			case PaginateCursor:
+				if cursor != "" {
+					q.Set(config.CursorParam, cursor)
+				}
+			}
+
+			reqURL := pageURL
+			if encoded := q.Encode(); encoded != "" {
+				if strings.Contains(reqURL, "?") {
+					reqURL += "&" + encoded
+				} else {
+					reqURL += "?" + encoded
+				}
+			}
+
+			req := p.NewRequest(reqURL)
+			req.Endpoint = name
+			resp, err := p.Engine.Network.Request(ctx, req)
+			if err != nil {
+				yield(nil, errors.Track(err).
+					WithContext("page_url", reqURL).
+					AsProvider(p.ID()).
+					Error())
+				return
+			}
+
+			var data interface{}
+			if err := p.decodeAPIResponse(name, resp, &data); err != nil {
+				yield(nil, err)
+				return
+			}
+			count := len(resultItems(itemsPath.Get(data)))
+
+			if !yield(resp, nil) {
+				return
+			}
+
+			// An empty or short page is the last one
+			seen += count
+			if count == 0 || (config.PageSize > 0 && count < config.PageSize) {
+				return
+			}
+			if totalPath != nil {
+				if total, ok := firstInt(totalPath.Get(data)); ok && seen >= total {
+					return
+				}
+			}
+
+			switch config.Style {
+			case PaginatePage:
+				page++
+			case PaginateCursor:
+				next := firstString(cursorPath.Get(data))
+				if next == "" || next == cursor {
+					return
+				}
+				cursor = next
+			}
+		}
+	}
+}
+
+// compile compiles the JSONPath expressions of the configuration
+func (c PaginationConfig) compile() (items, total, cursor *jsonpath.Path, err error) {
+	if items, err = jsonpath.Compile(c.ItemsPath); err != nil {
+		return nil, nil, nil, errors.Track(err).WithContext("items_path", c.ItemsPath).AsParser().Error()
+	}
+	if c.TotalPath != "" {
+		if total, err = jsonpath.Compile(c.TotalPath); err != nil {
+			return nil, nil, nil, errors.Track(err).WithContext("total_path", c.TotalPath).AsParser().Error()
+		}
+	}
+	if c.Style == PaginateCursor {
+		if c.CursorPath == "" {
+			return nil, nil, nil, errors.New("cursor pagination requires a cursor path").AsParser().Error()
+		}
+		if cursor, err = jsonpath.Compile(c.CursorPath); err != nil {
+			return nil, nil, nil, errors.Track(err).WithContext("cursor_path", c.CursorPath).AsParser().Error()
+		}
+	}
+	return items, total, cursor, nil
+}
+
+// endpointURL returns the URL of a configured endpoint, or of a path relative to the API base URL
+func (p *Provider) endpointURL(endpoint string) (string, error) {
+	if p.Config.API == nil {
+		return "", errors.New("API configuration not set").AsProvider(p.ID()).Error()
+	}
+	if path, ok := p.Config.API.Endpoints[endpoint]; ok {
+		endpoint = path
+	}
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return endpoint, nil
+	}
+	return p.Config.API.BaseURL + endpoint, nil
+}
+
+// endpointName returns the name of the configured endpoint a path was built from, with
+// "{id}" matching a single path segment, or the path itself
+func (p *Provider) endpointName(endpoint string) string {
+	if _, ok := p.Config.API.Endpoints[endpoint]; ok {
+		return endpoint
+	}
+	for name, template := range p.Config.API.Endpoints {
+		prefix, suffix, found := strings.Cut(template, "{id}")
+		if !found {
+			continue
+		}
+		if strings.HasPrefix(endpoint, prefix) && strings.HasSuffix(endpoint, suffix) && len(endpoint) > len(prefix)+len(suffix) {
+			if id := endpoint[len(prefix) : len(endpoint)-len(suffix)]; !strings.Contains(id, "/") {
+				return name
+			}
+		}
+	}
+	return endpoint
+}
+
+// firstInt returns the first numeric value, accepting numbers encoded as strings
+func firstInt(values []interface{}) (int, bool) {
+	for _, v := range values {
+		switch n := v.(type) {
+		case float64:
+			return int(n), true
+		case string:
+			if i, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -19,9 +19,16 @@ package base
 import (
 	"Luminary/pkg/core"
 	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/network"
+	"Luminary/pkg/engine/parser/protowire"
 	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/common"
 	"context"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -66,6 +73,17 @@ type Config struct {
 	Headers   map[string]string
 	RateLimit time.Duration
 	Timeout   time.Duration
+	// NotFoundTTL is how long manga and chapters the site reports missing are remembered
+	// as such instead of being requested again; 5 minutes if unset, negative to disable
+	NotFoundTTL time.Duration
+	// SearchesAltTitles tells that the site's search matches alternative titles too; otherwise
+	// searches asking for them are matched against alternative titles by the engine
+	SearchesAltTitles bool
+	// SelfTest is a search, manga and chapter known to work, for 'luminary providers selftest'
+	SelfTest *engine.SelfTestFixture
+	// Boilerplate matches what the site adds to descriptions, such as "Read X online for
+	// free"; the engine removes it from them
+	Boilerplate []*regexp.Regexp
 }
 
 // APIConfig for API-based providers
@@ -74,6 +92,11 @@ type APIConfig struct {
 	Endpoints map[string]string
 	// Response mapping configuration
 	ResponseMapping map[string]ResponseMap
+	// Pagination of endpoints that return their results in pages, by endpoint name
+	Pagination map[string]PaginationConfig
+	// Message types of endpoints answering with protocol buffers, by endpoint name. The
+	// decoded message is mapped like JSON through its json tags.
+	ProtoMessages map[string]func() protowire.Message
 }
 
 // WebConfig for web scraping providers
@@ -95,15 +118,38 @@ type MadaraConfig struct {
 	AjaxSearch bool
 	// Custom AJAX action if different from default
 	CustomLoadAction string
+	// Search strategies in the order they are tried (MadaraAjaxArchive, MadaraDirectArchive,
+	// MadaraSearchPage, MadaraAutocomplete); by default AJAX search when enabled, then the
+	// direct archive, then autocomplete
+	SearchStrategies []string
+	// Results per page of the site's archives; a shorter page is taken as the last one.
+	// Also sent as the AJAX search's posts_per_page when set.
+	ItemsPerPage int
+	// Archive order (m_orderby), e.g. "latest", "alphabet", "rating", "trending" or
+	// "views"; searches keep the site's relevance order and browsing uses "latest" if unset
+	OrderBy string
+	// Extra vars[...] form values of the AJAX load action, e.g. {"meta_key": "_latest_update"}
+	LoadVars map[string]string
+	// Attributes holding page image URLs, tried in order; "srcset"-style attributes yield
+	// their largest candidate and "style" its background image. Defaults to the common
+	// lazy-load attributes before src.
+	ImageAttributes []string
 }
 
-// ResponseMap defines how to map API responses to core types
+// ResponseMap defines how to map API responses to core types. Fields and paths are
+// JSONPath-like expressions (see package jsonpath), e.g.
+// "relationships[?(@.type=='author')].attributes.name".
 type ResponseMap struct {
 	IDField      string
 	TitleField   string
 	ChaptersPath string
-	// Additional field mappings
+	// Additional field mappings: "results" selects the items of the response, and
+	// "description", "status", "cover", "alt_titles", "authors", "artists" and "tags"
+	// are read from each item
 	Fields map[string]string
+	// Transforms maps a field ("id", "title" or a key of Fields) to named transforms
+	// applied to its values, chained with "|" (see common.RegisterTransform)
+	Transforms map[string]string
 }
 
 // Operations that can be overridden
@@ -114,10 +160,27 @@ type Operations struct {
 	GetChapter      func(ctx context.Context, chapterID string) (*core.Chapter, error)
 	GetChapterPages func(ctx context.Context, chapterID string) ([]string, error)
 	DownloadChapter func(ctx context.Context, chapterID, destDir string) error
+
+	GetChapterPageCount func(ctx context.Context, chapterID string) (int, error)
+	ResolveChapterURL   func(u *url.URL) (string, error)
+	ResolveMangaURL     func(u *url.URL) (string, error)
+	URLFor              func(kind, id string) (string, error)
+
+	GetTags   func(ctx context.Context) ([]core.Tag, error)
+	BrowseTag func(ctx context.Context, tagID string, options core.SearchOptions) ([]core.Manga, error)
 }
 
 // Interface compliance check
-var _ engine.Provider = (*Provider)(nil)
+var (
+	_ engine.Provider         = (*Provider)(nil)
+	_ engine.PageCounter      = (*Provider)(nil)
+	_ engine.URLResolver      = (*Provider)(nil)
+	_ engine.MangaURLResolver = (*Provider)(nil)
+	_ engine.URLBuilder       = (*Provider)(nil)
+	_ engine.Requester        = (*Provider)(nil)
+	_ engine.TagBrowser       = (*Provider)(nil)
+	_ engine.AltTitleSearcher = (*Provider)(nil)
+)
 
 // Identity methods
 
@@ -126,6 +189,31 @@ func (p *Provider) Name() string        { return p.Config.Name }
 func (p *Provider) Description() string { return p.Config.Description }
 func (p *Provider) SiteURL() string     { return p.Config.SiteURL }
 
+// SearchesAltTitles reports whether the site's search matches alternative titles
+func (p *Provider) SearchesAltTitles() bool { return p.Config.SearchesAltTitles }
+
+// SelfTestFixture returns what the self-test checks the site with, if the provider has it
+func (p *Provider) SelfTestFixture() (engine.SelfTestFixture, bool) {
+	if p.Config.SelfTest == nil {
+		return engine.SelfTestFixture{}, false
+	}
+	return *p.Config.SelfTest, true
+}
+
+// DescriptionBoilerplate returns the patterns of the boilerplate the site adds to descriptions
+func (p *Provider) DescriptionBoilerplate() []*regexp.Regexp { return p.Config.Boilerplate }
+
+// NewRequest prepares a GET request with the provider's headers, rate limit and timeout
+func (p *Provider) NewRequest(url string) *network.Request {
+	return &network.Request{
+		URL:       url,
+		Method:    "GET",
+		Headers:   p.Config.Headers,
+		RateLimit: p.Config.RateLimit,
+		Timeout:   p.Config.Timeout,
+	}
+}
+
 // Initialize initializes the provider
 func (p *Provider) Initialize(ctx context.Context) error {
 	p.Engine.Logger.Info("Initializing provider: %s (%s)", p.Name(), p.ID())
@@ -145,6 +233,16 @@ func (p *Provider) Initialize(ctx context.Context) error {
 
 // Search performs a manga search
 func (p *Provider) Search(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+	results, err := p.search(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filters are applied here so every provider honours them, even if the source can't filter
+	return common.FilterManga(results, options.Filters), nil
+}
+
+func (p *Provider) search(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
 	if p.ops.Search != nil {
 		return p.ops.Search(ctx, query, options)
 	}
@@ -153,8 +251,10 @@ func (p *Provider) Search(ctx context.Context, query string, options core.Search
 	switch p.Config.Type {
 	case TypeAPI:
 		return p.defaultAPISearch(ctx, query, options)
-	case TypeWeb, TypeMadara:
+	case TypeWeb:
 		return p.defaultWebSearch(ctx, query, options)
+	case TypeMadara:
+		return p.defaultMadaraSearch(ctx, query, options)
 	default:
 		return nil, errors.Track(fmt.Errorf("search not implemented for provider type: %s", p.Config.Type)).
 			AsProvider(p.ID()).
@@ -164,6 +264,17 @@ func (p *Provider) Search(ctx context.Context, query string, options core.Search
 
 // GetManga retrieves detailed manga information
 func (p *Provider) GetManga(ctx context.Context, id string) (*core.MangaInfo, error) {
+	info, err := cachedLookup(ctx, p, "manga", id, p.Engine.CacheTTL(), func() (*core.MangaInfo, error) {
+		return p.getManga(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Callers may change what they get; the cached copy stays as the site sent it
+	return info.Clone(), nil
+}
+
+func (p *Provider) getManga(ctx context.Context, id string) (*core.MangaInfo, error) {
 	if p.ops.GetManga != nil {
 		return p.ops.GetManga(ctx, id)
 	}
@@ -182,31 +293,146 @@ func (p *Provider) GetManga(ctx context.Context, id string) (*core.MangaInfo, er
 
 // GetChapter retrieves chapter information with pages
 func (p *Provider) GetChapter(ctx context.Context, chapterID string) (*core.Chapter, error) {
+	// Page URLs may be signed or expire, so only missing chapters are cached
+	return cachedLookup(ctx, p, "chapter", chapterID, 0, func() (*core.Chapter, error) {
+		return p.getChapter(ctx, chapterID)
+	})
+}
+
+func (p *Provider) getChapter(ctx context.Context, chapterID string) (*core.Chapter, error) {
 	if p.ops.GetChapter != nil {
 		return p.ops.GetChapter(ctx, chapterID)
 	}
 
 	// Default implementation
-	pages, err := p.getChapterPages(ctx, chapterID)
+	pages, err := p.chapterPages(ctx, chapterID)
 	if err != nil {
 		return nil, err
 	}
 
-	chapter := &core.Chapter{
+	return &core.Chapter{
 		Info: core.ChapterInfo{
 			ID: chapterID,
 		},
-		Pages: make([]core.Page, len(pages)),
+		Pages: pages,
+	}, nil
+}
+
+// GetTags lists the tags the provider's catalogue can be browsed by
+func (p *Provider) GetTags(ctx context.Context) ([]core.Tag, error) {
+	if p.ops.GetTags != nil {
+		return p.ops.GetTags(ctx)
 	}
 
-	for i, url := range pages {
-		chapter.Pages[i] = core.Page{
-			Index: i,
-			URL:   url,
-		}
+	switch p.Config.Type {
+	case TypeMadara:
+		return p.defaultMadaraTags(ctx)
+	default:
+		return nil, errors.Track(fmt.Errorf("tag browsing not implemented for provider type: %s", p.Config.Type)).
+			AsProvider(p.ID()).
+			Error()
+	}
+}
+
+// BrowseTag lists manga carrying a tag, as returned by GetTags
+func (p *Provider) BrowseTag(ctx context.Context, tagID string, options core.SearchOptions) ([]core.Manga, error) {
+	var results []core.Manga
+	var err error
+	switch {
+	case p.ops.BrowseTag != nil:
+		results, err = p.ops.BrowseTag(ctx, tagID, options)
+	case p.Config.Type == TypeMadara:
+		results, err = p.defaultMadaraBrowseTag(ctx, tagID, options)
+	default:
+		err = errors.Track(fmt.Errorf("tag browsing not implemented for provider type: %s", p.Config.Type)).
+			AsProvider(p.ID()).
+			Error()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return common.FilterManga(results, options.Filters), nil
+}
+
+// GetChapterPageCount returns the number of pages in a chapter
+func (p *Provider) GetChapterPageCount(ctx context.Context, chapterID string) (int, error) {
+	if p.ops.GetChapterPageCount != nil {
+		return p.ops.GetChapterPageCount(ctx, chapterID)
 	}
 
-	return chapter, nil
+	// Default implementation resolves the chapter and counts its pages
+	chapter, err := p.GetChapter(ctx, chapterID)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(chapter.Pages), nil
+}
+
+// ResolveChapterURL maps a chapter URL on the provider's site to a chapter ID
+func (p *Provider) ResolveChapterURL(u *url.URL) (string, error) {
+	if p.ops.ResolveChapterURL != nil {
+		return p.ops.ResolveChapterURL(u)
+	}
+
+	// Default implementation mirrors how chapter IDs are extracted from links, which keep
+	// their escaping
+	id := extractIDFromURL(u.EscapedPath(), "")
+	if p.Config.Type == TypeMadara {
+		id = madaraChapterID(id)
+	}
+	if id == "" {
+		return "", errors.Track(fmt.Errorf("no chapter ID in URL")).
+			WithContext("url", u.String()).
+			AsProvider(p.ID()).
+			Error()
+	}
+
+	return id, nil
+}
+
+// ResolveMangaURL maps a manga URL on the provider's site to a manga ID
+func (p *Provider) ResolveMangaURL(u *url.URL) (string, error) {
+	if p.ops.ResolveMangaURL != nil {
+		return p.ops.ResolveMangaURL(u)
+	}
+
+	// Default implementation: manga IDs are a single path segment, chapter IDs extend them
+	id := extractIDFromURL(u.EscapedPath(), "")
+	if id == "" || strings.Contains(id, "/") {
+		return "", errors.Track(fmt.Errorf("no manga ID in URL")).
+			WithContext("url", u.String()).
+			AsProvider(p.ID()).
+			Error()
+	}
+
+	return id, nil
+}
+
+// URLFor returns the URL of a manga or chapter (engine.URLKindManga or
+// engine.URLKindChapter) on the provider's site
+func (p *Provider) URLFor(kind, id string) (string, error) {
+	if p.ops.URLFor != nil {
+		return p.ops.URLFor(kind, id)
+	}
+
+	// Default implementation builds the pages web scraping reads; APIs have no such pages
+	if p.Config.Type == TypeAPI {
+		return "", errors.Newf("provider %s cannot build site URLs", p.ID()).
+			WithContext("id", id).
+			AsProvider(p.ID()).
+			Error()
+	}
+
+	switch kind {
+	case engine.URLKindManga:
+		return p.mangaURL(id), nil
+	case engine.URLKindChapter:
+		return p.chapterURL(id), nil
+	default:
+		return "", errors.Newf("unknown URL kind %q", kind).AsProvider(p.ID()).Error()
+	}
 }
 
 // TryGetMangaForChapter attempts to retrieve manga info for a chapter
@@ -230,13 +456,31 @@ func (p *Provider) DownloadChapter(ctx context.Context, chapterID, destDir strin
 		return err
 	}
 
+	// Pages failing on every URL get another chance with freshly scraped ones
+	ctx = download.WithRefresh(ctx, func(ctx context.Context) (*core.Chapter, error) {
+		return p.GetChapter(ctx, chapterID)
+	})
 	return p.Engine.Download.DownloadChapter(ctx, chapter, destDir)
 }
 
 // Helper to get chapter pages
-func (p *Provider) getChapterPages(ctx context.Context, chapterID string) ([]string, error) {
+func (p *Provider) chapterPages(ctx context.Context, chapterID string) ([]core.Page, error) {
 	if p.ops.GetChapterPages != nil {
-		return p.ops.GetChapterPages(ctx, chapterID)
+		urls, err := p.ops.GetChapterPages(ctx, chapterID)
+		if err != nil {
+			return nil, err
+		}
+
+		pages := make([]core.Page, len(urls))
+		for i, url := range urls {
+			pages[i] = core.Page{Index: i, URL: url}
+		}
+		return pages, nil
+	}
+
+	switch p.Config.Type {
+	case TypeWeb, TypeMadara:
+		return p.defaultWebChapterPages(ctx, chapterID)
 	}
 
 	return nil, errors.Track(fmt.Errorf("get chapter pages not implemented")).
@@ -0,0 +1,64 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package base
+
+import (
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/engine/parser/html"
+	"strings"
+)
+
+// lockedSelectors match the notices Madara and similar themes show instead of the pages
+// of chapters behind a login, coins or a premium membership
+const lockedSelectors = ".premium-block, .c-premium-content, .wp-manga-chapter-locked, .chapter-locked, .login-required"
+
+// unavailableNotices are phrases of notices shown instead of a chapter's pages, by the
+// reason they give
+var unavailableNotices = []struct {
+	reason  string
+	phrases []string
+}{
+	{download.ReasonRegion, []string{
+		"not available in your country", "not available in your region", "blocked in your country",
+		"blocked in your region",
+	}},
+	{download.ReasonLicensed, []string{
+		"has been licensed", "removed due to licensing", "removed due to dmca", "removed due to a dmca",
+		"licensed and removed",
+	}},
+	{download.ReasonLogin, []string{
+		"this chapter is locked", "unlock this chapter", "buy this chapter", "purchase this chapter",
+		"login to read", "log in to read", "sign in to read", "premium chapter",
+	}},
+}
+
+// unavailableReason tells why a reader page without page images shows none, going by the
+// site's locked-chapter markup ("locked" selector) and the notices it shows instead
+func (p *Provider) unavailableReason(doc *html.Parser) (string, bool) {
+	text := strings.ToLower(strings.Join(strings.Fields(doc.Text()), " "))
+	for _, notice := range unavailableNotices {
+		for _, phrase := range notice.phrases {
+			if strings.Contains(text, phrase) {
+				return notice.reason, true
+			}
+		}
+	}
+	if doc.Select(p.getSelector("locked", lockedSelectors)).Exists() {
+		return download.ReasonLogin, true
+	}
+	return "", false
+}
@@ -0,0 +1,125 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"Luminary/pkg/core"
+	"strings"
+)
+
+// Known demographics, matching MangaDex's publicationDemographic values
+var demographics = []string{"shounen", "shoujo", "seinen", "josei"}
+
+// Genre tags that imply a content rating, checked from most to least restrictive
+var ratingTags = []struct {
+	rating string
+	tags   []string
+}{
+	{"pornographic", []string{"hentai", "pornographic"}},
+	{"erotica", []string{"smut", "erotica", "adult"}},
+	{"suggestive", []string{"ecchi", "mature", "suggestive"}},
+}
+
+// InferDemographic guesses the demographic from genre tags, as Madara sites list it among genres.
+// Returns an empty string when no demographic tag is present.
+func InferDemographic(tags []string) string {
+	for _, tag := range tags {
+		t := strings.ToLower(strings.TrimSpace(tag))
+		// Accept the common alternative spellings (shonen, shojo)
+		t = strings.NewReplacer("shonen", "shounen", "shojo", "shoujo").Replace(t)
+		for _, d := range demographics {
+			if t == d {
+				return d
+			}
+		}
+	}
+	return ""
+}
+
+// InferContentRating guesses the content rating from genre tags.
+// Returns "safe" when tags are present but none is restrictive, and an empty string without tags.
+func InferContentRating(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	lowered := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		lowered[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	for _, rt := range ratingTags {
+		for _, tag := range rt.tags {
+			if lowered[tag] {
+				return rt.rating
+			}
+		}
+	}
+	return "safe"
+}
+
+// FilterManga keeps the results matching every filter. Supported keys are
+// "demographic", "content_rating" (alias "rating") and "status"; values may be
+// comma-separated alternatives. A field left empty is unknown and matches no filter on
+// it, so filtering on fields a source doesn't list leaves none of its results.
+func FilterManga(results []core.Manga, filters map[string]string) []core.Manga {
+	if len(filters) == 0 {
+		return results
+	}
+
+	filtered := make([]core.Manga, 0, len(results))
+	for _, manga := range results {
+		if matchesFilters(manga, filters) {
+			filtered = append(filtered, manga)
+		}
+	}
+	return filtered
+}
+
+func matchesFilters(manga core.Manga, filters map[string]string) bool {
+	for key, value := range filters {
+		var field string
+		switch strings.ToLower(key) {
+		case "demographic":
+			field = manga.Demographic
+		case "content_rating", "rating":
+			field = manga.ContentRating
+		case "status":
+			field = manga.Status
+		default:
+			// Unknown filters are ignored rather than dropping every result
+			continue
+		}
+
+		if !matchesAny(field, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(field, values string) bool {
+	if field == "" {
+		return false
+	}
+	for _, v := range strings.Split(values, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), field) {
+			return true
+		}
+	}
+	return false
+}
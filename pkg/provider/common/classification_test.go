@@ -0,0 +1,92 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"Luminary/pkg/core"
+	"slices"
+	"testing"
+)
+
+func TestFilterManga(t *testing.T) {
+	results := []core.Manga{
+		{ID: "berserk", Demographic: "seinen", ContentRating: "suggestive", Status: "ongoing"},
+		{ID: "nana", Demographic: "josei", ContentRating: "safe", Status: "hiatus"},
+		{ID: "yotsuba", Demographic: "seinen", ContentRating: "safe", Status: "completed"},
+		// Madara and web results often carry none of the fields
+		{ID: "unknown"},
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]string
+		want    []string
+	}{
+		{"no filters", nil, []string{"berserk", "nana", "yotsuba", "unknown"}},
+		{"demographic", map[string]string{"demographic": "seinen"}, []string{"berserk", "yotsuba"}},
+		{"alternatives", map[string]string{"status": "hiatus, completed"}, []string{"nana", "yotsuba"}},
+		{"case insensitive", map[string]string{"Status": "ONGOING"}, []string{"berserk"}},
+		{"rating alias", map[string]string{"rating": "safe"}, []string{"nana", "yotsuba"}},
+		{"every filter", map[string]string{"demographic": "seinen", "content_rating": "safe"}, []string{"yotsuba"}},
+		{"unknown key ignored", map[string]string{"genre": "horror"}, []string{"berserk", "nana", "yotsuba", "unknown"}},
+		{"no match", map[string]string{"demographic": "shoujo"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, manga := range FilterManga(results, tt.filters) {
+				got = append(got, manga.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterManga(%v) = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMangaDropsUnknownFields(t *testing.T) {
+	// MangaDex leaves the demographic of some manga null; they can't be known to match
+	results := []core.Manga{
+		{ID: "a", ContentRating: "safe", Status: "ongoing"},
+		{ID: "b", ContentRating: "safe", Status: "ongoing", Demographic: "seinen"},
+	}
+
+	got := FilterManga(results, map[string]string{"status": "ongoing", "demographic": "seinen"})
+	if len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("FilterManga() = %v, want only b", got)
+	}
+}
+
+func TestInferContentRating(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"Action", "Comedy"}, "safe"},
+		{[]string{"Action", "Ecchi"}, "suggestive"},
+		{[]string{"Ecchi", "Smut"}, "erotica"},
+		{[]string{" Hentai "}, "pornographic"},
+	}
+
+	for _, tt := range tests {
+		if got := InferContentRating(tt.tags); got != tt.want {
+			t.Errorf("InferContentRating(%v) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
@@ -17,23 +17,18 @@
 package common
 
 import (
-	"strings"
+	"Luminary/pkg/core"
 	"time"
 )
 
 // ExtractBestTitle selects the most appropriate title from a map of localized strings.
-// It prioritizes English ("en"), then the first available non-empty title as a fallback.
+// It follows the language priority given (see Engine.PreferredLanguages), then falls back
+// to any non-empty title.
 // This is useful for APIs that return multilingual data.
-func ExtractBestTitle(titleMap map[string]string) string {
-	if title, ok := titleMap["en"]; ok && strings.TrimSpace(title) != "" {
+func ExtractBestTitle(titleMap map[string]string, preferred []string) string {
+	if title := core.BestLocalized(titleMap, preferred); title != "" {
 		return title
 	}
-	// Fallback to the first non-empty title available
-	for _, title := range titleMap {
-		if strings.TrimSpace(title) != "" {
-			return title
-		}
-	}
 	return "Untitled" // Default if no titles are found
 }
 
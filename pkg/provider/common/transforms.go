@@ -0,0 +1,144 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transform converts the raw JSON values matched by a response mapping path. Values
+// are decoded JSON: strings, float64, bool, nil, []interface{} and map[string]interface{}.
+// languages is the language priority of the engine mapping the response.
+type Transform func(values []interface{}, languages []string) []interface{}
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]Transform{
+		"bestLocalizedString": bestLocalizedString,
+		"parseFloat":          parseFloat,
+		"rfc3339Date":         rfc3339Date,
+		"joinNames":           joinNames,
+	}
+)
+
+// RegisterTransform makes a transform available by name to response mappings,
+// replacing any transform registered under the same name
+func RegisterTransform(name string, fn Transform) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = fn
+}
+
+// LookupTransform returns the transform registered under name
+func LookupTransform(name string) (Transform, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	fn, ok := transforms[name]
+	return fn, ok
+}
+
+// TransformNames returns the names of all registered transforms, sorted
+func TransformNames() []string {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bestLocalizedString reduces maps of language to text (e.g. {"en": "...", "ja": "..."})
+// to a single string, see ExtractBestTitle
+func bestLocalizedString(values []interface{}, languages []string) []interface{} {
+	var out []interface{}
+	for _, v := range values {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			out = append(out, v)
+			continue
+		}
+
+		localized := make(map[string]string, len(m))
+		for lang, text := range m {
+			if s, ok := text.(string); ok && strings.TrimSpace(s) != "" {
+				localized[lang] = s
+			}
+		}
+		if len(localized) > 0 {
+			out = append(out, ExtractBestTitle(localized, languages))
+		}
+	}
+	return out
+}
+
+// parseFloat turns numeric strings into numbers, dropping values that aren't numbers
+func parseFloat(values []interface{}, _ []string) []interface{} {
+	var out []interface{}
+	for _, v := range values {
+		switch n := v.(type) {
+		case float64:
+			out = append(out, n)
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+// rfc3339Date normalizes dates in any format ParseDate understands to RFC 3339,
+// dropping values that aren't dates
+func rfc3339Date(values []interface{}, _ []string) []interface{} {
+	var out []interface{}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			if t := ParseDate(strings.TrimSpace(s)); t != nil {
+				out = append(out, t.Format(time.RFC3339))
+			}
+		}
+	}
+	return out
+}
+
+// joinNames joins all string values, including those inside arrays, into a single
+// comma-separated string
+func joinNames(values []interface{}, _ []string) []interface{} {
+	var names []string
+	for _, v := range values {
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				names = append(names, strings.TrimSpace(s))
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return []interface{}{strings.Join(names, ", ")}
+}
@@ -0,0 +1,470 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package opds reads OPDS catalogs, such as those of Komga and Kavita, as providers. Books
+// become chapters when the catalog streams their pages (OPDS-PSE).
+package opds
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/download"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Link relations and types of the OPDS and OPDS-PSE specifications
+const (
+	relSearch      = "search"
+	relNext        = "next"
+	relSubsection  = "subsection"
+	relImage       = "http://opds-spec.org/image"
+	relStream      = "http://vaemendis.net/opds-pse/stream"
+	typeNavigable  = "profile=opds-catalog"
+	typeOpenSearch = "application/opensearchdescription+xml"
+)
+
+// Config describes a remote catalog
+type Config struct {
+	ID          string
+	Name        string
+	Description string
+	URL         string // Address of the catalog's root feed
+	Username    string // Optional HTTP basic auth credentials
+	Password    string
+}
+
+type feed struct {
+	Title   string  `xml:"title"`
+	Links   []link  `xml:"link"`
+	Entries []entry `xml:"entry"`
+}
+
+type entry struct {
+	ID       string `xml:"id"`
+	Title    string `xml:"title"`
+	Updated  string `xml:"updated"`
+	Summary  string `xml:"summary"`
+	Content  string `xml:"content"`
+	Language string `xml:"http://purl.org/dc/terms/ language"`
+	Authors  []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+	Links []link `xml:"link"`
+}
+
+type link struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Count int    `xml:"http://vaemendis.net/opds-pse/ns count,attr"`
+}
+
+type openSearchDescription struct {
+	URLs []struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	} `xml:"Url"`
+}
+
+// find returns the first link with the relation, or nil
+func find(links []link, rel string) *link {
+	for i := range links {
+		if links[i].Rel == rel {
+			return &links[i]
+		}
+	}
+	return nil
+}
+
+// New creates the provider of a catalog. Manga IDs are the paths of series feeds on the
+// catalog's server, chapter IDs "<manga-id>#<entry-id>".
+func New(e *engine.Engine, config Config) (engine.Provider, error) {
+	root, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Track(err).
+			WithContext("url", config.URL).
+			WithMessagef("Invalid catalog address %q", config.URL).
+			AsProvider(config.ID).Error()
+	}
+
+	c := &catalog{root: root, series: make(map[string]seriesFeed)}
+	if config.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Username + ":" + config.Password))
+		c.auth = "Basic " + credentials
+	}
+
+	description := config.Description
+	if description == "" {
+		description = "OPDS catalog at " + config.URL
+	}
+
+	// The credentials are added per request, so they only go to the catalog's server
+	b := base.New(e, base.Config{
+		ID:          config.ID,
+		Name:        config.Name,
+		Description: description,
+		SiteURL:     root.Scheme + "://" + root.Host,
+		Type:        base.TypeAPI,
+
+		API: &base.APIConfig{
+			BaseURL: config.URL,
+		},
+
+		Headers: map[string]string{
+			"User-Agent": "Luminary/1.0 (https://github.com/lumisxh/luminary)",
+			"Accept":     "application/atom+xml,application/xml;q=0.9,*/*;q=0.8",
+		},
+		RateLimit: 200 * time.Millisecond,
+	})
+
+	c.p = b.Build().(*base.Provider)
+	return b.WithSearch(c.search).
+		WithGetManga(c.getManga).
+		WithGetChapter(c.getChapter).
+		WithDownloadChapter(c.downloadChapter).
+		Build(), nil
+}
+
+// seriesTTL is how long a series feed read for a chapter is reused for the next ones
+const seriesTTL = 5 * time.Minute
+
+// seriesFeed is a series feed read before
+type seriesFeed struct {
+	title   string
+	entries []entry
+	read    time.Time
+}
+
+// catalog implements the operations of a catalog's provider
+type catalog struct {
+	p    *base.Provider
+	root *url.URL
+	auth string // Authorization header for the catalog's server, if any
+
+	mu     sync.Mutex
+	series map[string]seriesFeed // By manga ID
+}
+
+// fetch reads the feed at ref, resolved against the root feed
+func (c *catalog) fetch(ctx context.Context, ref, endpoint string, v any) error {
+	target, err := c.root.Parse(ref)
+	if err != nil {
+		return errors.Track(err).WithContext("ref", ref).AsProvider(c.p.ID()).Error()
+	}
+	req := c.p.NewRequest(target.String())
+	req.Endpoint = endpoint
+	if c.auth != "" && strings.EqualFold(target.Host, c.root.Host) {
+		req.Headers = maps.Clone(req.Headers)
+		req.Headers["Authorization"] = c.auth
+	}
+	resp, err := c.p.Engine.Network.Request(ctx, req)
+	if err != nil {
+		return errors.TP(err, c.p.ID())
+	}
+	if err := xml.Unmarshal(resp.Body, v); err != nil {
+		return errors.Track(err).
+			WithContext("provider_id", c.p.ID()).
+			WithContext("url", target.String()).
+			WithMessagef("%s is not an OPDS feed", target.String()).
+			AsParser().Error()
+	}
+	return nil
+}
+
+// id turns a link into a manga ID, its path and query on the catalog's server
+func (c *catalog) id(href string) string {
+	target, err := c.root.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(target.RequestURI(), "/")
+}
+
+// searchTemplate finds the catalog's search URL, with {searchTerms} in it
+func (c *catalog) searchTemplate(ctx context.Context) (string, error) {
+	var root feed
+	if err := c.fetch(ctx, c.root.String(), "catalog", &root); err != nil {
+		return "", err
+	}
+	search := find(root.Links, relSearch)
+	if search == nil {
+		return "", errors.Newf("catalog %s cannot be searched", c.p.ID()).
+			WithMessage("The catalog's root feed has no search link").
+			AsProvider(c.p.ID()).Error()
+	}
+	if !strings.Contains(search.Type, typeOpenSearch) {
+		return search.Href, nil
+	}
+
+	// The link points to an OpenSearch description holding the template
+	var description openSearchDescription
+	if err := c.fetch(ctx, search.Href, "opensearch", &description); err != nil {
+		return "", err
+	}
+	for _, u := range description.URLs {
+		if strings.Contains(u.Type, "atom") {
+			return u.Template, nil
+		}
+	}
+	return "", errors.Newf("catalog %s has no feed search", c.p.ID()).
+		WithMessage("The catalog's OpenSearch description has no Atom template").
+		AsProvider(c.p.ID()).Error()
+}
+
+// search lists the series whose feeds match the query
+func (c *catalog) search(ctx context.Context, query string, options core.SearchOptions) ([]core.Manga, error) {
+	template, err := c.searchTemplate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	next := strings.ReplaceAll(template, "{searchTerms}", url.QueryEscape(query))
+
+	var results []core.Manga
+	for n := 1; next != "" && n <= max(options.Pages, 1); n++ {
+		var page feed
+		if err := c.fetch(ctx, next, "search", &page); err != nil {
+			return nil, err
+		}
+
+		for _, e := range page.Entries {
+			nav := navigation(e)
+			if nav == nil {
+				continue
+			}
+			manga := core.Manga{ID: c.id(nav.Href), Title: e.Title}
+			if image := find(e.Links, relImage); image != nil {
+				manga.CoverURL = c.absolute(image.Href)
+			}
+			results = append(results, manga)
+			if options.Limit > 0 && len(results) >= options.Limit {
+				return results, nil
+			}
+		}
+
+		next = ""
+		if l := find(page.Links, relNext); l != nil {
+			next = l.Href
+		}
+	}
+	return results, nil
+}
+
+// navigation returns the link of an entry leading to another feed, or nil for books
+func navigation(e entry) *link {
+	if l := find(e.Links, relSubsection); l != nil {
+		return l
+	}
+	for i := range e.Links {
+		if e.Links[i].Rel == "" && strings.Contains(e.Links[i].Type, typeNavigable) {
+			return &e.Links[i]
+		}
+	}
+	return nil
+}
+
+// absolute resolves a link against the root feed
+func (c *catalog) absolute(href string) string {
+	target, err := c.root.Parse(href)
+	if err != nil {
+		return href
+	}
+	return target.String()
+}
+
+// entries reads every page of a series feed. A feed read less than seriesTTL ago is
+// reused unless fresh is set, so downloading many chapters reads it once.
+func (c *catalog) entries(ctx context.Context, mangaID string, fresh bool) (string, []entry, error) {
+	c.mu.Lock()
+	cached, ok := c.series[mangaID]
+	c.mu.Unlock()
+	if ok && !fresh && time.Since(cached.read) < seriesTTL {
+		return cached.title, cached.entries, nil
+	}
+
+	var title string
+	var entries []entry
+	for next := "/" + mangaID; next != ""; {
+		var page feed
+		if err := c.fetch(ctx, next, "series", &page); err != nil {
+			return "", nil, errors.Track(err).WithContext("manga_id", mangaID).Error()
+		}
+		if title == "" {
+			title = page.Title
+		}
+		entries = append(entries, page.Entries...)
+
+		next = ""
+		if l := find(page.Links, relNext); l != nil {
+			next = l.Href
+		}
+	}
+
+	c.mu.Lock()
+	c.series[mangaID] = seriesFeed{title: title, entries: entries, read: time.Now()}
+	c.mu.Unlock()
+	return title, entries, nil
+}
+
+// getManga reads a series feed; its books with streamable pages are the chapters
+func (c *catalog) getManga(ctx context.Context, id string) (*core.MangaInfo, error) {
+	title, entries, err := c.entries(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &core.MangaInfo{Manga: core.Manga{ID: id, Title: title}}
+	for _, e := range entries {
+		stream := find(e.Links, relStream)
+		if stream == nil {
+			continue
+		}
+		// The series is described by its books
+		if len(info.Chapters) == 0 {
+			for _, author := range e.Authors {
+				info.Authors = append(info.Authors, author.Name)
+			}
+			for _, category := range e.Categories {
+				info.Tags = append(info.Tags, category.Term)
+			}
+			if image := find(e.Links, relImage); image != nil {
+				info.CoverURL = c.absolute(image.Href)
+			}
+		}
+
+		chapter := c.chapter(id, e, stream)
+		chapter.Sequence = len(info.Chapters) + 1
+		info.Chapters = append(info.Chapters, chapter)
+		if chapter.Language != "" && !slices.Contains(info.AvailableLanguages, chapter.Language) {
+			info.AvailableLanguages = append(info.AvailableLanguages, chapter.Language)
+		}
+	}
+	return info, nil
+}
+
+// chapter maps a book with streamable pages
+func (c *catalog) chapter(mangaID string, e entry, stream *link) core.ChapterInfo {
+	parser := c.p.Engine.Parser
+	chapter := core.ChapterInfo{
+		ID:        mangaID + "#" + e.ID,
+		Title:     e.Title,
+		Label:     parser.ExtractChapterLabel(e.Title),
+		Language:  e.Language,
+		PageCount: stream.Count,
+	}
+	chapter.Number, _ = parser.ExtractChapterNumber(e.Title)
+	if updated, err := time.Parse(time.RFC3339, e.Updated); err == nil {
+		chapter.Date = &updated
+	}
+	return chapter
+}
+
+// getChapter finds a book in its series feed and lists the URLs of its streamed pages
+func (c *catalog) getChapter(ctx context.Context, chapterID string) (*core.Chapter, error) {
+	return c.readChapter(ctx, chapterID, false)
+}
+
+// readChapter is getChapter, reading the series feed again when fresh is set
+func (c *catalog) readChapter(ctx context.Context, chapterID string, fresh bool) (*core.Chapter, error) {
+	i := strings.LastIndex(chapterID, "#")
+	if i <= 0 || i == len(chapterID)-1 {
+		return nil, errors.Newf("invalid OPDS chapter ID: %s", chapterID).
+			WithMessage("OPDS chapter IDs look like <manga-id>#<entry-id>").
+			AsProvider(c.p.ID()).Error()
+	}
+	mangaID, entryID := chapterID[:i], chapterID[i+1:]
+
+	_, entries, err := c.entries(ctx, mangaID, fresh)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		stream := find(e.Links, relStream)
+		if e.ID != entryID || stream == nil {
+			continue
+		}
+
+		chapter := &core.Chapter{MangaID: mangaID, Info: c.chapter(mangaID, e, stream)}
+		for page := 0; page < stream.Count; page++ {
+			chapter.Pages = append(chapter.Pages, core.Page{
+				Index: page,
+				URL:   c.absolute(pageURL(stream.Href, page)),
+			})
+		}
+		if len(chapter.Pages) == 0 {
+			return nil, errors.Newf("no pages found for chapter %s", chapterID).
+				WithContext("chapter_id", chapterID).
+				AsProvider(c.p.ID()).Error()
+		}
+		return chapter, nil
+	}
+
+	return nil, errors.Newf("chapter %s not found", chapterID).
+		WithContext("chapter_id", chapterID).
+		WithMessage("The book is gone from the catalog, or it doesn't stream its pages").
+		AsNotFound().Error()
+}
+
+// pageURL fills in the page stream template; pages count from 0. Templates may ask for
+// a width to scale to, which is left out to get the originals.
+func pageURL(template string, page int) string {
+	u := strings.ReplaceAll(template, "{pageNumber}", strconv.Itoa(page))
+	if !strings.Contains(u, "{maxWidth}") {
+		return u
+	}
+	if parsed, err := url.Parse(strings.ReplaceAll(u, "{maxWidth}", "")); err == nil {
+		query := parsed.Query()
+		for key, values := range query {
+			if len(values) == 1 && values[0] == "" {
+				query.Del(key)
+			}
+		}
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+	return u
+}
+
+// downloadChapter downloads a chapter, sending the catalog's credentials with the pages
+// on its server
+func (c *catalog) downloadChapter(ctx context.Context, chapterID, destDir string) error {
+	chapter, err := c.getChapter(ctx, chapterID)
+	if err != nil {
+		return err
+	}
+
+	if c.auth != "" {
+		ctx = download.WithHeaders(ctx, c.root.Host, map[string]string{"Authorization": c.auth})
+	}
+	ctx = download.WithRefresh(ctx, func(ctx context.Context) (*core.Chapter, error) {
+		return c.readChapter(ctx, chapterID, true)
+	})
+	return c.p.Engine.Download.DownloadChapter(ctx, chapter, destDir)
+}
@@ -0,0 +1,274 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package opds
+
+import (
+	"Luminary/pkg/core"
+	"Luminary/pkg/engine"
+	"Luminary/pkg/engine/logger"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const rootFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Catalog</title>
+  <link rel="search" type="application/opensearchdescription+xml" href="/opds/search.xml"/>
+</feed>`
+
+const searchDescription = `<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <Url type="application/atom+xml;profile=opds-catalog" template="/opds/search?q={searchTerms}"/>
+</OpenSearchDescription>`
+
+const searchFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>s1</id>
+    <title>Blue Lock</title>
+    <link rel="subsection" type="application/atom+xml;profile=opds-catalog" href="/opds/series/1"/>
+    <link rel="http://opds-spec.org/image" href="/covers/1.jpg"/>
+  </entry>
+  <entry>
+    <id>b9</id>
+    <title>A loose book</title>
+    <link rel="http://opds-spec.org/acquisition" href="/books/9/file"/>
+  </entry>
+</feed>`
+
+// seriesFeedPage has one book streaming its pages from the catalog, one from another host
+// (%s), and one without streamed pages
+const seriesFeedPage = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:pse="http://vaemendis.net/opds-pse/ns">
+  <title>Blue Lock</title>
+  <entry>
+    <id>b1</id>
+    <title>Chapter 1</title>
+    <updated>2025-03-01T10:00:00Z</updated>
+    <dcterms:language>en</dcterms:language>
+    <author><name>Muneyuki Kaneshiro</name></author>
+    <category term="Sports" label="Sports"/>
+    <link rel="http://vaemendis.net/opds-pse/stream" type="image/jpeg" pse:count="2" href="/opds/books/1/page/{pageNumber}?width={maxWidth}"/>
+  </entry>
+  <entry>
+    <id>b2</id>
+    <title>Chapter 2.5</title>
+    <link rel="http://vaemendis.net/opds-pse/stream" type="image/jpeg" pse:count="1" href="%s/books/2/page/{pageNumber}"/>
+  </entry>
+  <entry>
+    <id>b3</id>
+    <title>Extras</title>
+    <link rel="http://opds-spec.org/acquisition" href="/books/3/file"/>
+  </entry>
+</feed>`
+
+// testCatalog serves a catalog behind basic auth and an image host, recording the
+// requests each one gets
+type testCatalog struct {
+	catalog *httptest.Server
+	images  *httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int    // Catalog paths
+	auth     map[string]string // Authorization headers, by path on either server
+}
+
+func newTestCatalog(t *testing.T) *testCatalog {
+	t.Helper()
+	tc := &testCatalog{requests: make(map[string]int), auth: make(map[string]string)}
+
+	tc.images = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc.record(r, false)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	t.Cleanup(tc.images.Close)
+
+	tc.catalog = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc.record(r, true)
+		if user, password, ok := r.BasicAuth(); !ok || user != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/opds":
+			_, _ = w.Write([]byte(rootFeed))
+		case r.URL.Path == "/opds/search.xml":
+			_, _ = w.Write([]byte(searchDescription))
+		case r.URL.Path == "/opds/search":
+			_, _ = w.Write([]byte(searchFeed))
+		case r.URL.Path == "/opds/series/1":
+			_, _ = fmt.Fprintf(w, seriesFeedPage, tc.images.URL)
+		case strings.HasPrefix(r.URL.Path, "/opds/books/"):
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(tc.catalog.Close)
+	return tc
+}
+
+func (tc *testCatalog) record(r *http.Request, catalog bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if catalog {
+		tc.requests[r.URL.Path]++
+	}
+	tc.auth[r.URL.Path] = r.Header.Get("Authorization")
+}
+
+func (tc *testCatalog) provider(t *testing.T) engine.Provider {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	p, err := New(e, Config{ID: "kmg", Name: "Komga", URL: tc.catalog.URL + "/opds", Username: "me", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNewRejectsInvalidURL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := engine.New(engine.WithLogger(logger.NewService("")))
+	if _, err := New(e, Config{ID: "kmg", Name: "Komga", URL: "http://[::1"}); err == nil {
+		t.Fatal("expected an error for an invalid URL")
+	}
+}
+
+func TestSearchListsSeriesFeeds(t *testing.T) {
+	tc := newTestCatalog(t)
+	p := tc.provider(t)
+
+	results, err := p.Search(context.Background(), "blue", core.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want the series only: %+v", len(results), results)
+	}
+	if results[0].ID != "opds/series/1" || results[0].Title != "Blue Lock" {
+		t.Errorf("got %+v", results[0])
+	}
+	if want := tc.catalog.URL + "/covers/1.jpg"; results[0].CoverURL != want {
+		t.Errorf("cover = %q, want %q", results[0].CoverURL, want)
+	}
+}
+
+func TestGetMangaMapsStreamedBooks(t *testing.T) {
+	tc := newTestCatalog(t)
+	p := tc.provider(t)
+
+	info, err := p.GetManga(context.Background(), "opds/series/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Blue Lock" {
+		t.Errorf("title = %q", info.Title)
+	}
+	if len(info.Chapters) != 2 {
+		t.Fatalf("got %d chapters, want the 2 streamed books", len(info.Chapters))
+	}
+	first, second := info.Chapters[0], info.Chapters[1]
+	if first.ID != "opds/series/1#b1" || first.Number != 1 || first.PageCount != 2 || first.Language != "en" || first.Date == nil {
+		t.Errorf("first chapter = %+v", first)
+	}
+	if second.Number != 2.5 || second.Sequence != 2 {
+		t.Errorf("second chapter = %+v", second)
+	}
+	if len(info.Authors) != 1 || info.Authors[0] != "Muneyuki Kaneshiro" || len(info.Tags) != 1 || info.Tags[0] != "Sports" {
+		t.Errorf("authors %v, tags %v", info.Authors, info.Tags)
+	}
+}
+
+func TestGetChapterReusesSeriesFeed(t *testing.T) {
+	tc := newTestCatalog(t)
+	p := tc.provider(t)
+	ctx := context.Background()
+
+	chapter, err := p.GetChapter(ctx, "opds/series/1#b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		tc.catalog.URL + "/opds/books/1/page/0",
+		tc.catalog.URL + "/opds/books/1/page/1",
+	}
+	if len(chapter.Pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(chapter.Pages), len(want))
+	}
+	for i, page := range chapter.Pages {
+		if page.URL != want[i] {
+			t.Errorf("page %d = %q, want %q", i, page.URL, want[i])
+		}
+	}
+
+	if _, err := p.GetChapter(ctx, "opds/series/1#b2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetChapter(ctx, "opds/series/1#b3"); err == nil {
+		t.Error("expected an error for a book without streamed pages")
+	}
+	if n := tc.requests["/opds/series/1"]; n != 1 {
+		t.Errorf("series feed read %d times, want once", n)
+	}
+}
+
+func TestCredentialsStayOnCatalogHost(t *testing.T) {
+	tc := newTestCatalog(t)
+	p := tc.provider(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"opds/series/1#b1", "opds/series/1#b2"} {
+		if err := p.DownloadChapter(ctx, id, t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.auth["/opds/books/1/page/0"] == "" {
+		t.Error("catalog pages were requested without credentials")
+	}
+	if auth := tc.auth["/books/2/page/0"]; auth != "" {
+		t.Errorf("image host got the credentials: %q", auth)
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	tests := []struct {
+		template string
+		page     int
+		want     string
+	}{
+		{"/books/1/page/{pageNumber}", 3, "/books/1/page/3"},
+		{"/books/1/page/{pageNumber}?width={maxWidth}", 0, "/books/1/page/0"},
+		{"/books/1/page/{pageNumber}?width={maxWidth}&zero=1", 2, "/books/1/page/2?zero=1"},
+	}
+	for _, tt := range tests {
+		if got := pageURL(tt.template, tt.page); got != tt.want {
+			t.Errorf("pageURL(%q, %d) = %q, want %q", tt.template, tt.page, got, tt.want)
+		}
+	}
+}
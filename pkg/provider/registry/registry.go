@@ -18,6 +18,7 @@ package registry
 
 import (
 	"Luminary/pkg/engine"
+	"context"
 	"sync"
 )
 
@@ -45,27 +46,92 @@ func Register(constructor ProviderConstructor) {
 
 // LoadAll creates and registers all providers with the engine
 func LoadAll(e *engine.Engine) error {
-	global.mu.RLock()
-	constructors := make([]ProviderConstructor, len(global.constructors))
-	copy(constructors, global.constructors)
-	global.mu.RUnlock()
-
-	for _, constructor := range constructors {
-		provider := constructor(e)
-		if provider == nil {
-			continue
-		}
-
+	for _, provider := range builtIn(e) {
 		if err := e.RegisterProvider(provider); err != nil {
 			e.Logger.Error("Failed to register provider: %v", err)
 			// Continue with other providers
 		}
 	}
 
+	// Sites added with 'luminary provider add-madara' follow the built-in providers
+	loadUserSites(e)
+
 	e.Logger.Info("Loaded %d providers", e.ProviderCount())
 	return nil
 }
 
+// ReloadResult describes what Reload changed
+type ReloadResult struct {
+	Providers []string `json:"providers"` // IDs of the providers registered now
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// Reload reads the config file and the sites file again, then replaces every provider
+// with a fresh instance and initializes it, without restarting. Nothing changes when
+// either file is invalid.
+func Reload(ctx context.Context, e *engine.Engine) (*ReloadResult, error) {
+	path, err := engine.ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	config, err := engine.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := userSites(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// As when loading, sites can't take the ID of a provider before them
+	var providers []engine.Provider
+	seen := make(map[string]bool)
+	for _, provider := range append(builtIn(e), sites...) {
+		if seen[provider.ID()] {
+			e.Logger.Error("Failed to register provider: provider with ID '%s' already registered", provider.ID())
+			continue
+		}
+		seen[provider.ID()] = true
+		providers = append(providers, provider)
+	}
+
+	e.ApplyConfig(config)
+	added, removed, err := e.ReplaceProviders(providers)
+	if err != nil {
+		return nil, err
+	}
+	// Lookups cached by the old providers may not match what the new ones find
+	e.Cache.Clear()
+	if err := e.InitializeProviders(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{Added: append([]string{}, added...), Removed: append([]string{}, removed...)}
+	for _, provider := range providers {
+		result.Providers = append(result.Providers, provider.ID())
+	}
+	e.Logger.Info("Reloaded %d providers and the settings in %s", len(providers), path)
+	return result, nil
+}
+
+// builtIn creates the providers registered in code
+func builtIn(e *engine.Engine) []engine.Provider {
+	global.mu.RLock()
+	constructors := make([]ProviderConstructor, len(global.constructors))
+	copy(constructors, global.constructors)
+	global.mu.RUnlock()
+
+	var providers []engine.Provider
+	for _, constructor := range constructors {
+		if provider := constructor(e); provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
 // Clear removes all registered constructors (useful for testing)
 func Clear() {
 	global.mu.Lock()
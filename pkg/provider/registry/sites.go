@@ -0,0 +1,279 @@
+// Luminary: A streamlined CLI tool for searching and downloading manga.
+// Copyright (C) 2025 Luca M. Schmidt (LuMiSxh)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"Luminary/pkg/engine"
+	"Luminary/pkg/errors"
+	"Luminary/pkg/provider/base"
+	"Luminary/pkg/provider/opds"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kinds of sites in the sites file
+const (
+	SiteMadara = "madara"
+	SiteOPDS   = "opds"
+)
+
+// Site declares a site added without recompiling, as stored in the sites file: a Madara
+// site, or an OPDS catalog
+type Site struct {
+	Type        string `json:"type,omitempty"` // SiteMadara when empty
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"` // Site address; for catalogs, the root feed
+
+	// User name of OPDS catalogs behind HTTP basic auth; the password is read from the
+	// environment, see PasswordVar
+	Username string `json:"username,omitempty"`
+
+	// Optional tuning, see base.MadaraConfig
+	Selectors        map[string]string `json:"selectors,omitempty"`
+	AjaxSearch       bool              `json:"ajax_search,omitempty"`
+	CustomLoadAction string            `json:"load_action,omitempty"`
+	SearchStrategies []string          `json:"search_strategies,omitempty"`
+	ItemsPerPage     int               `json:"items_per_page,omitempty"`
+	OrderBy          string            `json:"order_by,omitempty"`
+	ImageAttributes  []string          `json:"image_attributes,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+
+	// SelfTest is what 'luminary providers selftest' checks the site with
+	SelfTest *engine.SelfTestFixture `json:"selftest,omitempty"`
+}
+
+// siteIDPattern restricts IDs to what fits in "provider:manga-id" references
+var siteIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SitesPath returns the default location of the sites file, ~/.luminary/sites.json
+func SitesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Track(err).AsFileSystem().Error()
+	}
+	return filepath.Join(home, ".luminary", "sites.json"), nil
+}
+
+// Validate checks that the site can be turned into a provider
+func (s Site) Validate() error {
+	if s.Type != "" && s.Type != SiteMadara && s.Type != SiteOPDS {
+		return errors.Newf("site %s has unknown type %q", s.ID, s.Type).
+			WithMessage("Sites are of type \"madara\" or \"opds\"").
+			Error()
+	}
+	if !siteIDPattern.MatchString(s.ID) {
+		return errors.Newf("invalid site ID %q", s.ID).
+			WithMessage("Site IDs use lowercase letters, digits, '-' and '_', like \"kmg\"").
+			Error()
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.Newf("site %s has no name", s.ID).Error()
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Newf("invalid site URL %q", s.URL).
+			WithContext("site", s.ID).
+			WithMessage("Expected the site's address, like https://example.com").
+			Error()
+	}
+	return nil
+}
+
+// PasswordVar returns the environment variable holding the password of an OPDS catalog,
+// LUMINARY_OPDS_PASSWORD_<ID> with the ID in upper case and '-' as '_'. Passwords are kept
+// out of the sites file and the shell history that way.
+func (s Site) PasswordVar() string {
+	return "LUMINARY_OPDS_PASSWORD_" + strings.ToUpper(strings.ReplaceAll(s.ID, "-", "_"))
+}
+
+// Provider builds the provider the site declares
+func (s Site) Provider(e *engine.Engine) (engine.Provider, error) {
+	if s.Type == SiteOPDS {
+		return opds.New(e, opds.Config{
+			ID:          s.ID,
+			Name:        s.Name,
+			Description: s.Description,
+			URL:         s.URL,
+			Username:    s.Username,
+			Password:    os.Getenv(s.PasswordVar()),
+		})
+	}
+
+	siteURL := strings.TrimSuffix(s.URL, "/")
+	headers := map[string]string{
+		"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Language": "en-US,en;q=0.9",
+		"Referer":         siteURL + "/",
+	}
+	for name, value := range s.Headers {
+		headers[name] = value
+	}
+
+	description := s.Description
+	if description == "" {
+		description = "Madara site added from " + siteURL
+	}
+
+	return base.New(e, base.Config{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: description,
+		SiteURL:     siteURL,
+		Type:        base.TypeMadara,
+
+		Madara: &base.MadaraConfig{
+			Selectors:        s.Selectors,
+			AjaxSearch:       s.AjaxSearch,
+			CustomLoadAction: s.CustomLoadAction,
+			SearchStrategies: s.SearchStrategies,
+			ItemsPerPage:     s.ItemsPerPage,
+			OrderBy:          s.OrderBy,
+			ImageAttributes:  s.ImageAttributes,
+		},
+
+		Headers:   headers,
+		RateLimit: 2 * time.Second,
+		SelfTest:  s.SelfTest,
+	}).Build(), nil
+}
+
+// LoadSites reads the sites file at path; a missing file holds no sites
+func LoadSites(path string) ([]Site, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+
+	var sites []Site
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return nil, errors.Track(err).
+			WithContext("path", path).
+			WithMessage("The sites file is not valid JSON").
+			AsParser().Error()
+	}
+	return sites, nil
+}
+
+// AddSite appends a site to the sites file at path and registers its provider with the
+// engine right away
+func AddSite(e *engine.Engine, path string, site Site) error {
+	if err := site.Validate(); err != nil {
+		return err
+	}
+	if e.GetProviderOrNil(site.ID) != nil {
+		return errors.Newf("provider %s already exists", site.ID).
+			WithMessage("Choose another ID; run 'luminary providers' to see the ones in use").
+			Error()
+	}
+
+	sites, err := LoadSites(path)
+	if err != nil {
+		return err
+	}
+	for _, existing := range sites {
+		if existing.ID == site.ID {
+			return errors.Newf("site %s is already defined in %s", site.ID, path).Error()
+		}
+	}
+
+	provider, err := site.Provider(e)
+	if err != nil {
+		return err
+	}
+	if err := saveSites(path, append(sites, site)); err != nil {
+		return err
+	}
+	return e.RegisterProvider(provider)
+}
+
+// saveSites replaces the sites file, writing a temporary file first so a failed write
+// can't lose the existing definitions. Only the user can read it, as it may hold
+// credentials.
+func saveSites(path string, sites []Site) error {
+	data, err := json.MarshalIndent(sites, "", "  ")
+	if err != nil {
+		return errors.Track(err).Error()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, append(data, '\n'), 0600); err != nil {
+		return errors.Track(err).WithContext("path", tempPath).AsFileSystem().Error()
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return errors.Track(err).WithContext("path", path).AsFileSystem().Error()
+	}
+	return nil
+}
+
+// loadUserSites registers the providers of the user's sites file, skipping invalid entries
+func loadUserSites(e *engine.Engine) {
+	providers, err := userSites(e)
+	if err != nil {
+		e.Logger.Error("Failed to load sites: %v", err)
+		return
+	}
+
+	for _, provider := range providers {
+		if err := e.RegisterProvider(provider); err != nil {
+			e.Logger.Error("Failed to register site %s: %v", provider.ID(), err)
+		}
+	}
+}
+
+// userSites creates the providers of the user's sites file, skipping invalid entries;
+// there are none without a home directory
+func userSites(e *engine.Engine) ([]engine.Provider, error) {
+	path, err := SitesPath()
+	if err != nil {
+		return nil, nil
+	}
+
+	sites, err := LoadSites(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []engine.Provider
+	for _, site := range sites {
+		if err := site.Validate(); err != nil {
+			e.Logger.Error("Skipping site from %s: %v", path, err)
+			continue
+		}
+		provider, err := site.Provider(e)
+		if err != nil {
+			e.Logger.Error("Skipping site from %s: %v", path, err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}